@@ -10,6 +10,7 @@ import (
 	"github.com/helixml/helix/api/pkg/cli/app"
 	"github.com/helixml/helix/api/pkg/cli/fs"
 	"github.com/helixml/helix/api/pkg/cli/knowledge"
+	"github.com/helixml/helix/api/pkg/cli/org"
 	"github.com/helixml/helix/api/pkg/cli/secret"
 )
 
@@ -33,6 +34,7 @@ func NewRootCmd() *cobra.Command {
 	RootCmd.AddCommand(fs.New())
 	RootCmd.AddCommand(fs.NewUploadCmd()) // Shortcut for upload
 	RootCmd.AddCommand(secret.New())
+	RootCmd.AddCommand(org.New())
 
 	// Commands available on all platforms
 	RootCmd.AddCommand(newServeCmd())
@@ -224,19 +224,13 @@ func serve(cmd *cobra.Command, cfg *config.ServerConfig) error {
 		gse = gptscript.NewExecutor(cfg, ps)
 	}
 
-	var extractor extract.Extractor
-
-	switch cfg.TextExtractor.Provider {
-	case types.ExtractorTika:
-		extractor = extract.NewTikaExtractor(cfg.TextExtractor.Tika.URL)
-	case types.ExtractorUnstructured:
-		extractor = extract.NewDefaultExtractor(cfg.TextExtractor.Unstructured.URL)
-	default:
-		return fmt.Errorf("unknown extractor: %s", cfg.TextExtractor.Provider)
+	extractor, err := extract.NewExtractor(&cfg.TextExtractor)
+	if err != nil {
+		return err
 	}
 
 	// Must use the same allocator for both new LLM requests and old sessions
-	scheduler := scheduler.NewScheduler(ctx, cfg, func(work *scheduler.Workload, err error) {
+	scheduler := scheduler.NewScheduler(ctx, cfg, store, func(work *scheduler.Workload, err error) {
 		// This function describes what happens when errors occur in jobs.
 		// Each request type (session vs. LLM requests) has a differeht code path handling results,
 		// hence for now we need to separate cases to handle errors.
@@ -291,7 +285,10 @@ func serve(cmd *cobra.Command, cfg *config.ServerConfig) error {
 		// TODO: bigquery
 	}
 
-	providerManager := manager.NewProviderManager(cfg, helixInference, logStores...)
+	providerManager, err := manager.NewProviderManager(cfg, store, helixInference, logStores...)
+	if err != nil {
+		return fmt.Errorf("failed to create provider manager: %w", err)
+	}
 
 	// controllerOpenAIClient = logger.Wrap(cfg, controllerOpenAIClient, logStores...)
 
@@ -320,6 +317,12 @@ func serve(cmd *cobra.Command, cfg *config.ServerConfig) error {
 			DeleteURL: cfg.RAG.Llamaindex.RAGDeleteURL,
 		})
 		log.Info().Msgf("Using Llamaindex for RAG")
+	case "pgvector":
+		ragClient, err = rag.NewPGVector(cfg, providerManager)
+		if err != nil {
+			return fmt.Errorf("failed to create pgvector RAG client: %v", err)
+		}
+		log.Info().Msgf("Using pgvector for RAG")
 	default:
 		return fmt.Errorf("unknown RAG provider: %s", cfg.RAG.DefaultRagProvider)
 	}
@@ -359,7 +362,7 @@ func serve(cmd *cobra.Command, cfg *config.ServerConfig) error {
 		return fmt.Errorf("failed to create browser pool: %w", err)
 	}
 
-	knowledgeReconciler, err := knowledge.New(cfg, store, fs, extractor, ragClient, browserPool)
+	knowledgeReconciler, err := knowledge.New(cfg, store, fs, extractor, ragClient, providerManager, browserPool)
 	if err != nil {
 		return err
 	}
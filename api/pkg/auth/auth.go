@@ -2,12 +2,16 @@ package auth
 
 import (
 	"context"
+	"errors"
 
 	jwt "github.com/golang-jwt/jwt/v5"
 
 	"github.com/helixml/helix/api/pkg/types"
 )
 
+// ErrUserNotFound is returned by GetUserByID for unknown users
+var ErrUserNotFound = errors.New("user not found")
+
 type Authenticator interface {
 	GetUserByID(ctx context.Context, userID string) (*types.User, error)
 	ValidateUserToken(ctx context.Context, token string) (*jwt.Token, error)
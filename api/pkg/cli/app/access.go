@@ -0,0 +1,135 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func init() {
+	rootCmd.AddCommand(accessCmd)
+
+	accessCmd.AddCommand(accessListCmd)
+	accessCmd.AddCommand(accessGrantCmd)
+	accessCmd.AddCommand(accessRevokeCmd)
+
+	accessGrantCmd.Flags().String("role", string(types.AccessRoleViewer), "Role to grant: viewer, editor or admin")
+}
+
+var accessCmd = &cobra.Command{
+	Use:   "access",
+	Short: "Manage who can access an app",
+}
+
+var accessListCmd = &cobra.Command{
+	Use:     "list <app>",
+	Aliases: []string{"ls"},
+	Short:   "List the users that have been granted access to an app",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		app, err := lookupApp(apiClient, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to lookup app: %w", err)
+		}
+
+		grants, err := apiClient.ListAppAccessGrants(app.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list access grants: %w", err)
+		}
+
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+
+		table.SetHeader([]string{"User ID", "Role", "Granted By", "Created"})
+
+		table.SetAutoWrapText(false)
+		table.SetAutoFormatHeaders(true)
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetCenterSeparator("")
+		table.SetColumnSeparator("")
+		table.SetRowSeparator("")
+		table.SetHeaderLine(false)
+		table.SetBorder(false)
+		table.SetTablePadding(" ")
+		table.SetNoWhiteSpace(false)
+
+		for _, grant := range grants {
+			table.Append([]string{
+				grant.UserID,
+				string(grant.Role),
+				grant.GrantedBy,
+				grant.Created.Format(time.DateTime),
+			})
+		}
+
+		table.Render()
+
+		return nil
+	},
+}
+
+var accessGrantCmd = &cobra.Command{
+	Use:   "grant <app> <user-id>",
+	Short: "Give a user a role on an app",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		role, _ := cmd.Flags().GetString("role")
+
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		app, err := lookupApp(apiClient, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to lookup app: %w", err)
+		}
+
+		grant, err := apiClient.GrantAppAccess(app.ID, &types.CreateAccessGrantRequest{
+			UserID: args[1],
+			Role:   types.AccessRole(role),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to grant access: %w", err)
+		}
+
+		fmt.Printf("Granted %s the %s role on app %s\n", grant.UserID, grant.Role, app.ID)
+
+		return nil
+	},
+}
+
+var accessRevokeCmd = &cobra.Command{
+	Use:   "revoke <app> <user-id>",
+	Short: "Remove the role granted to a user on an app",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		app, err := lookupApp(apiClient, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to lookup app: %w", err)
+		}
+
+		if err := apiClient.RevokeAppAccess(app.ID, args[1]); err != nil {
+			return fmt.Errorf("failed to revoke access: %w", err)
+		}
+
+		fmt.Printf("Revoked access of %s to app %s\n", args[1], app.ID)
+
+		return nil
+	},
+}
@@ -0,0 +1,64 @@
+package knowledge
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	exportCmd.Flags().StringP("output", "o", "", "Output file (defaults to <knowledge ID>.tar.gz)")
+	exportCmd.Flags().Bool("include-embeddings", false, "Export the chunk embeddings, kept on import if the target uses the same embeddings model")
+
+	rootCmd.AddCommand(exportCmd)
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [knowledge name or ID]",
+	Short: "Export knowledge to import into another Helix instance",
+	Long:  `Exports the knowledge metadata, RAG settings and extracted text as a gzipped tarball.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		includeEmbeddings, err := cmd.Flags().GetBool("include-embeddings")
+		if err != nil {
+			return err
+		}
+
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		knowledge, err := lookupKnowledge(apiClient, args[0])
+		if err != nil {
+			return err
+		}
+
+		if output == "" {
+			output = knowledge.ID + ".tar.gz"
+		}
+
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer f.Close()
+
+		err = apiClient.ExportKnowledge(cmd.Context(), knowledge.ID, includeEmbeddings, f)
+		if err != nil {
+			_ = os.Remove(output)
+			return fmt.Errorf("failed to export knowledge: %w", err)
+		}
+
+		fmt.Printf("Knowledge %s exported to %s\n", knowledge.ID, output)
+
+		return nil
+	},
+}
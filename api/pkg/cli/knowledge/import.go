@@ -0,0 +1,57 @@
+package knowledge
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	importCmd.Flags().String("app-id", "", "App of the imported knowledge (defaults to the exported app)")
+	importCmd.Flags().String("name", "", "Name of the imported knowledge (defaults to the exported name)")
+
+	rootCmd.AddCommand(importCmd)
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import knowledge exported from another Helix instance",
+	Long:  `Recreates the knowledge from an export and indexes it without fetching its sources again.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID, err := cmd.Flags().GetString("app-id")
+		if err != nil {
+			return err
+		}
+
+		name, err := cmd.Flags().GetString("name")
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", args[0], err)
+		}
+		defer f.Close()
+
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		knowledge, err := apiClient.ImportKnowledge(cmd.Context(), f, &client.KnowledgeImportOptions{
+			AppID: appID,
+			Name:  name,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import knowledge: %w", err)
+		}
+
+		fmt.Printf("Knowledge %s imported\n", knowledge.ID)
+
+		return nil
+	},
+}
@@ -0,0 +1,60 @@
+package org
+
+import (
+	"fmt"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+var rootCmd = &cobra.Command{
+	Use:     "org",
+	Short:   "Helix organization management",
+	Aliases: []string{"organization"},
+	Long:    `Manage organizations and their members. Apps, knowledge and secrets can be owned by an organization and are shared with all of its members.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Do Stuff Here
+	},
+}
+
+func New() *cobra.Command {
+	return rootCmd
+}
+
+func lookupOrganization(apiClient *client.HelixClient, ref string) (*types.Organization, error) {
+	orgs, err := apiClient.ListOrganizations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	for _, org := range orgs {
+		if org.Name == ref || org.ID == ref {
+			return org, nil
+		}
+	}
+
+	return nil, fmt.Errorf("organization not found: %s", ref)
+}
+
+func newTable(cmd *cobra.Command, header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(cmd.OutOrStdout())
+
+	table.SetHeader(header)
+
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding(" ")
+	table.SetNoWhiteSpace(false)
+
+	return table
+}
@@ -0,0 +1,41 @@
+package org
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func init() {
+	rootCmd.AddCommand(createCmd)
+	createCmd.Flags().String("display-name", "", "Display name of the organization")
+}
+
+var createCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a new organization",
+	Long:  `Create a new organization, you become its owner.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		displayName, _ := cmd.Flags().GetString("display-name")
+
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		org, err := apiClient.CreateOrganization(&types.Organization{
+			Name:        args[0],
+			DisplayName: displayName,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create organization: %w", err)
+		}
+
+		fmt.Printf("Organization %s created (%s)\n", org.Name, org.ID)
+		return nil
+	},
+}
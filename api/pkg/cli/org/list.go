@@ -0,0 +1,46 @@
+package org
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helixml/helix/api/pkg/client"
+)
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the organizations you are a member of",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		orgs, err := apiClient.ListOrganizations()
+		if err != nil {
+			return fmt.Errorf("failed to list organizations: %w", err)
+		}
+
+		table := newTable(cmd, []string{"ID", "Name", "Display Name", "Created"})
+
+		for _, org := range orgs {
+			table.Append([]string{
+				org.ID,
+				org.Name,
+				org.DisplayName,
+				org.Created.Format(time.RFC3339),
+			})
+		}
+
+		table.Render()
+
+		return nil
+	},
+}
@@ -0,0 +1,146 @@
+package org
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func init() {
+	rootCmd.AddCommand(membersCmd)
+
+	membersCmd.AddCommand(membersListCmd)
+	membersCmd.AddCommand(membersAddCmd)
+	membersCmd.AddCommand(membersSetRoleCmd)
+	membersCmd.AddCommand(membersRemoveCmd)
+
+	membersAddCmd.Flags().String("role", string(types.OrganizationRoleMember), "Role of the member: owner, admin or member")
+}
+
+var membersCmd = &cobra.Command{
+	Use:     "members",
+	Aliases: []string{"member"},
+	Short:   "Manage organization members",
+}
+
+var membersListCmd = &cobra.Command{
+	Use:     "list <org>",
+	Aliases: []string{"ls"},
+	Short:   "List the members of an organization",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		org, err := lookupOrganization(apiClient, args[0])
+		if err != nil {
+			return err
+		}
+
+		members, err := apiClient.ListOrganizationMembers(org.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list members: %w", err)
+		}
+
+		table := newTable(cmd, []string{"User ID", "Role", "Created"})
+
+		for _, m := range members {
+			table.Append([]string{
+				m.UserID,
+				string(m.Role),
+				m.Created.Format(time.RFC3339),
+			})
+		}
+
+		table.Render()
+
+		return nil
+	},
+}
+
+var membersAddCmd = &cobra.Command{
+	Use:   "add <org> <user-id>",
+	Short: "Add a user to an organization",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		role, _ := cmd.Flags().GetString("role")
+
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		org, err := lookupOrganization(apiClient, args[0])
+		if err != nil {
+			return err
+		}
+
+		_, err = apiClient.AddOrganizationMember(org.ID, &types.AddOrganizationMemberRequest{
+			UserID: args[1],
+			Role:   types.OrganizationRole(role),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add member: %w", err)
+		}
+
+		fmt.Printf("Added %s to %s as %s\n", args[1], org.Name, role)
+		return nil
+	},
+}
+
+var membersSetRoleCmd = &cobra.Command{
+	Use:   "set-role <org> <user-id> <role>",
+	Short: "Change the role of an organization member",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		org, err := lookupOrganization(apiClient, args[0])
+		if err != nil {
+			return err
+		}
+
+		_, err = apiClient.UpdateOrganizationMember(org.ID, args[1], types.OrganizationRole(args[2]))
+		if err != nil {
+			return fmt.Errorf("failed to update member: %w", err)
+		}
+
+		fmt.Printf("%s is now %s of %s\n", args[1], args[2], org.Name)
+		return nil
+	},
+}
+
+var membersRemoveCmd = &cobra.Command{
+	Use:     "rm <org> <user-id>",
+	Aliases: []string{"remove"},
+	Short:   "Remove a user from an organization",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		org, err := lookupOrganization(apiClient, args[0])
+		if err != nil {
+			return err
+		}
+
+		err = apiClient.RemoveOrganizationMember(org.ID, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to remove member: %w", err)
+		}
+
+		fmt.Printf("Removed %s from %s\n", args[1], org.Name)
+		return nil
+	},
+}
@@ -0,0 +1,40 @@
+package org
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helixml/helix/api/pkg/client"
+)
+
+func init() {
+	rootCmd.AddCommand(removeCmd)
+}
+
+var removeCmd = &cobra.Command{
+	Use:     "rm <org>",
+	Aliases: []string{"delete"},
+	Short:   "Delete an organization",
+	Long:    `Delete an organization by name or ID. Apps, knowledge and secrets owned by the organization have to be deleted first.`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		org, err := lookupOrganization(apiClient, args[0])
+		if err != nil {
+			return err
+		}
+
+		err = apiClient.DeleteOrganization(org.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete organization: %w", err)
+		}
+
+		fmt.Printf("Organization %s deleted\n", org.Name)
+		return nil
+	},
+}
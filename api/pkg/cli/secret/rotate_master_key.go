@@ -0,0 +1,44 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/store"
+)
+
+func init() {
+	rootCmd.AddCommand(rotateMasterKeyCmd)
+}
+
+var rotateMasterKeyCmd = &cobra.Command{
+	Use:   "rotate-master-key",
+	Short: "Re-encrypt all secrets with the current master key",
+	Long: `Re-encrypt all secrets with the current master key. This command connects to the
+database directly and reads the same configuration as the server.
+
+To rotate the master key, set SECRETS_MASTER_KEY to the new key and
+SECRETS_PREVIOUS_MASTER_KEYS to the old key, run this command, then remove the
+old key. Secrets stored before encryption was enabled are encrypted as well.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfg, err := config.LoadServerConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load server config: %w", err)
+		}
+
+		db, err := store.NewPostgresStore(cfg.Store)
+		if err != nil {
+			return fmt.Errorf("failed to create store: %w", err)
+		}
+
+		updated, err := db.RotateSecretsMasterKey(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to rotate master key: %w", err)
+		}
+
+		fmt.Printf("Re-encrypted %d secrets\n", updated)
+		return nil
+	},
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// ListAppAccessGrants retrieves the users that have been granted a role on the app
+func (c *HelixClient) ListAppAccessGrants(appID string) ([]*types.AccessGrant, error) {
+	var grants []*types.AccessGrant
+	err := c.makeRequest(http.MethodGet, fmt.Sprintf("/apps/%s/access", appID), nil, &grants)
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// GrantAppAccess gives a user a role on the app, replacing any role granted before
+func (c *HelixClient) GrantAppAccess(appID string, req *types.CreateAccessGrantRequest) (*types.AccessGrant, error) {
+	bts, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal access grant: %w", err)
+	}
+
+	var grant types.AccessGrant
+	err = c.makeRequest(http.MethodPost, fmt.Sprintf("/apps/%s/access", appID), bytes.NewBuffer(bts), &grant)
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// RevokeAppAccess removes the role granted to a user on the app
+func (c *HelixClient) RevokeAppAccess(appID, userID string) error {
+	return c.makeRequest(http.MethodDelete, fmt.Sprintf("/apps/%s/access/%s", appID, userID), nil, nil)
+}
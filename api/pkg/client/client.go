@@ -22,17 +22,32 @@ type Client interface {
 	UpdateApp(app *types.App) (*types.App, error)
 	DeleteApp(appID string, deleteKnowledge bool) error
 	ListApps(f *AppFilter) ([]*types.App, error)
+	ListAppAccessGrants(appID string) ([]*types.AccessGrant, error)
+	GrantAppAccess(appID string, req *types.CreateAccessGrantRequest) (*types.AccessGrant, error)
+	RevokeAppAccess(appID, userID string) error
 
 	ListKnowledge(f *KnowledgeFilter) ([]*types.Knowledge, error)
 	GetKnowledge(id string) (*types.Knowledge, error)
 	DeleteKnowledge(id string) error
 	RefreshKnowledge(id string) error
+	ExportKnowledge(ctx context.Context, id string, includeEmbeddings bool, w io.Writer) error
+	ImportKnowledge(ctx context.Context, archive io.Reader, opts *KnowledgeImportOptions) (*types.Knowledge, error)
 
 	ListSecrets() ([]*types.Secret, error)
 	CreateSecret(secret *types.CreateSecretRequest) (*types.Secret, error)
 	UpdateSecret(id string, secret *types.Secret) (*types.Secret, error)
 	DeleteSecret(id string) error
 
+	ListOrganizations() ([]*types.Organization, error)
+	GetOrganization(id string) (*types.Organization, error)
+	CreateOrganization(org *types.Organization) (*types.Organization, error)
+	UpdateOrganization(org *types.Organization) (*types.Organization, error)
+	DeleteOrganization(id string) error
+	ListOrganizationMembers(orgID string) ([]*types.OrganizationMembership, error)
+	AddOrganizationMember(orgID string, req *types.AddOrganizationMemberRequest) (*types.OrganizationMembership, error)
+	UpdateOrganizationMember(orgID, userID string, role types.OrganizationRole) (*types.OrganizationMembership, error)
+	RemoveOrganizationMember(orgID, userID string) error
+
 	ListKnowledgeVersions(f *KnowledgeVersionsFilter) ([]*types.KnowledgeVersion, error)
 
 	FilestoreList(ctx context.Context, path string) ([]filestore.FileStoreItem, error)
@@ -1,8 +1,13 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/helixml/helix/api/pkg/types"
 )
@@ -69,3 +74,82 @@ func (c *HelixClient) ListKnowledgeVersions(f *KnowledgeVersionsFilter) ([]*type
 
 	return knowledge, nil
 }
+
+// ExportKnowledge writes the knowledge export tarball to w
+func (c *HelixClient) ExportKnowledge(ctx context.Context, id string, includeEmbeddings bool, w io.Writer) error {
+	query := url.Values{}
+	if includeEmbeddings {
+		query.Set("include_embeddings", "true")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/knowledge/"+id+"/export?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to export knowledge: %s, %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download knowledge export: %w", err)
+	}
+
+	return nil
+}
+
+type KnowledgeImportOptions struct {
+	AppID string // Defaults to the exported app
+	Name  string // Defaults to the exported name
+}
+
+// ImportKnowledge uploads a knowledge export tarball, the knowledge is
+// recreated and indexed without fetching its sources
+func (c *HelixClient) ImportKnowledge(ctx context.Context, archive io.Reader, opts *KnowledgeImportOptions) (*types.Knowledge, error) {
+	query := url.Values{}
+	if opts.AppID != "" {
+		query.Set("app_id", opts.AppID)
+	}
+	if opts.Name != "" {
+		query.Set("name", opts.Name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/knowledge/import?"+query.Encode(), archive)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to import knowledge: %s, %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var knowledge *types.Knowledge
+	err = json.Unmarshal(body, &knowledge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode imported knowledge: %w", err)
+	}
+
+	return knowledge, nil
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// ListOrganizations retrieves the organizations the user is a member of
+func (c *HelixClient) ListOrganizations() ([]*types.Organization, error) {
+	var orgs []*types.Organization
+	err := c.makeRequest(http.MethodGet, "/orgs", nil, &orgs)
+	if err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// GetOrganization retrieves an organization by ID
+func (c *HelixClient) GetOrganization(id string) (*types.Organization, error) {
+	var org types.Organization
+	err := c.makeRequest(http.MethodGet, fmt.Sprintf("/orgs/%s", id), nil, &org)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// CreateOrganization creates a new organization owned by the user
+func (c *HelixClient) CreateOrganization(org *types.Organization) (*types.Organization, error) {
+	bts, err := json.Marshal(org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal organization: %w", err)
+	}
+
+	var created types.Organization
+	err = c.makeRequest(http.MethodPost, "/orgs", bytes.NewBuffer(bts), &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateOrganization updates the name of an organization
+func (c *HelixClient) UpdateOrganization(org *types.Organization) (*types.Organization, error) {
+	bts, err := json.Marshal(org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal organization: %w", err)
+	}
+
+	var updated types.Organization
+	err = c.makeRequest(http.MethodPut, fmt.Sprintf("/orgs/%s", org.ID), bytes.NewBuffer(bts), &updated)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteOrganization deletes an organization by ID
+func (c *HelixClient) DeleteOrganization(id string) error {
+	return c.makeRequest(http.MethodDelete, fmt.Sprintf("/orgs/%s", id), nil, nil)
+}
+
+// ListOrganizationMembers retrieves the members of an organization
+func (c *HelixClient) ListOrganizationMembers(orgID string) ([]*types.OrganizationMembership, error) {
+	var members []*types.OrganizationMembership
+	err := c.makeRequest(http.MethodGet, fmt.Sprintf("/orgs/%s/members", orgID), nil, &members)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddOrganizationMember adds a user to an organization
+func (c *HelixClient) AddOrganizationMember(orgID string, req *types.AddOrganizationMemberRequest) (*types.OrganizationMembership, error) {
+	bts, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal member: %w", err)
+	}
+
+	var member types.OrganizationMembership
+	err = c.makeRequest(http.MethodPost, fmt.Sprintf("/orgs/%s/members", orgID), bytes.NewBuffer(bts), &member)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// UpdateOrganizationMember changes the role of an organization member
+func (c *HelixClient) UpdateOrganizationMember(orgID, userID string, role types.OrganizationRole) (*types.OrganizationMembership, error) {
+	bts, err := json.Marshal(&types.UpdateOrganizationMemberRequest{Role: role})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal member: %w", err)
+	}
+
+	var member types.OrganizationMembership
+	err = c.makeRequest(http.MethodPut, fmt.Sprintf("/orgs/%s/members/%s", orgID, userID), bytes.NewBuffer(bts), &member)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveOrganizationMember removes a user from an organization
+func (c *HelixClient) RemoveOrganizationMember(orgID, userID string) error {
+	return c.makeRequest(http.MethodDelete, fmt.Sprintf("/orgs/%s/members/%s", orgID, userID), nil, nil)
+}
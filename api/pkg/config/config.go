@@ -24,6 +24,8 @@ type ServerConfig struct {
 	PubSub             PubSub
 	WebServer          WebServer
 	SubscriptionQuotas SubscriptionQuotas
+	UsageQuotas        UsageQuotas
+	ResponseCache      ResponseCache
 	GitHub             GitHub
 	FineTuning         FineTuning
 	Apps               Apps
@@ -49,6 +51,19 @@ type Providers struct {
 	OpenAI     OpenAI
 	TogetherAI TogetherAI
 	Helix      Helix
+
+	// EndpointsFile is a path to a YAML file declaring additional OpenAI compatible
+	// provider endpoints (vLLM, LiteLLM, Azure, etc.). Environment variables in the
+	// file are expanded so API keys can be passed in as ${MY_API_KEY}.
+	EndpointsFile string `envconfig:"PROVIDER_ENDPOINTS_FILE" description:"Path to a YAML file with additional OpenAI compatible provider endpoints."`
+
+	// RoutersFile is a path to a YAML file declaring routing providers that
+	// load balance and fail over between other providers for the same model.
+	RoutersFile string `envconfig:"PROVIDER_ROUTERS_FILE" description:"Path to a YAML file with routing providers (failover and load balancing)."`
+
+	// PricesFile is a path to a YAML file with the price per million tokens
+	// of each provider and model, used to estimate the cost in usage reports.
+	PricesFile string `envconfig:"PROVIDER_PRICES_FILE" description:"Path to a YAML file with token prices per provider and model."`
 }
 
 type OpenAI struct {
@@ -69,6 +84,14 @@ type Helix struct {
 	RunnerTTL          time.Duration `envconfig:"HELIX_RUNNER_TTL" default:"30s"`                         // How long before runners are considered dead
 	SchedulingStrategy string        `envconfig:"HELIX_SCHEDULING_STRATEGY" default:"max_spread" description:"The strategy to use for scheduling workloads."`
 	QueueSize          int           `envconfig:"HELIX_QUEUE_SIZE" default:"100" description:"The size of the queue when buffering workloads."`
+	SchedulerInstance  string        `envconfig:"HELIX_SCHEDULER_INSTANCE" description:"Name of the API instance, it reschedules its own queued work after a restart. The queued work of instances that stop heartbeating is taken over by the others. Defaults to the hostname."`
+
+	// Fair-share scheduling, the tenant of a workload is its app or else its owner
+	PriorityClassWeights          map[types.SchedulerPriorityClass]float64 `envconfig:"HELIX_SCHEDULER_PRIORITY_CLASS_WEIGHTS" default:"interactive:8,batch:2,finetune:1" description:"Share of the runners of the interactive, batch and finetune priority classes."`
+	TenantWeights                 map[string]float64                       `envconfig:"HELIX_SCHEDULER_TENANT_WEIGHTS" description:"Share of the runners by app or owner ID, e.g. app_123:4, the other tenants have a weight of 1."`
+	TenantMaxConcurrency          int                                      `envconfig:"HELIX_SCHEDULER_TENANT_MAX_CONCURRENCY" default:"0" description:"Maximum scheduled and running workloads of a tenant, 0 is unlimited."`
+	TenantMaxConcurrencyOverrides map[string]int                           `envconfig:"HELIX_SCHEDULER_TENANT_MAX_CONCURRENCY_OVERRIDES" description:"Maximum scheduled and running workloads by app or owner ID, e.g. app_123:10."`
+	TenantQueueSize               int                                      `envconfig:"HELIX_SCHEDULER_TENANT_QUEUE_SIZE" default:"0" description:"Maximum queued workloads of a tenant, 0 is only limited by the queue size."`
 }
 
 type Tools struct {
@@ -152,7 +175,11 @@ type DataPrepText struct {
 }
 
 type TextExtractor struct {
-	Provider types.Extractor `envconfig:"TEXT_EXTRACTION_PROVIDER" default:"tika"`
+	Provider types.Extractor `envconfig:"TEXT_EXTRACTION_PROVIDER" default:"tika" description:"The text extractor, one of tika, unstructured, builtin or auto."`
+	// Used by the auto provider, configured routes are tried first, then the
+	// builtin extractor if it supports the type, then the fallback
+	Routes   map[string]types.Extractor `envconfig:"TEXT_EXTRACTION_ROUTES" description:"Extractors by MIME type for the auto provider, e.g. application/pdf:tika,text/html:builtin."`
+	Fallback types.Extractor            `envconfig:"TEXT_EXTRACTION_FALLBACK_PROVIDER" default:"tika" description:"The extractor for the types the builtin extractor fails on or doesn't support with the auto provider, empty to disable."`
 	// the URL we post documents to so we can get the text back from them
 	Unstructured struct {
 		URL string `envconfig:"TEXT_EXTRACTION_URL" default:"http://llamaindex:5000/api/v1/extract" description:"The URL to extract text from a document."`
@@ -167,7 +194,7 @@ type RAG struct {
 	IndexingConcurrency int `envconfig:"RAG_INDEXING_CONCURRENCY" default:"1" description:"The number of concurrent indexing tasks."`
 
 	// DefaultRagProvider is the default RAG provider to use if not specified
-	DefaultRagProvider string `envconfig:"RAG_DEFAULT_PROVIDER" default:"typesense" description:"The default RAG provider to use if not specified."`
+	DefaultRagProvider string `envconfig:"RAG_DEFAULT_PROVIDER" default:"typesense" description:"The default RAG provider to use if not specified, one of typesense, llamaindex or pgvector."`
 
 	MaxVersions int `envconfig:"RAG_MAX_VERSIONS" default:"3" description:"The maximum number of versions to keep for a knowledge."`
 
@@ -177,6 +204,13 @@ type RAG struct {
 		APIKey string `envconfig:"RAG_TYPESENSE_API_KEY" default:"typesense" description:"The API key to the Typesense server."`
 	}
 
+	// PGVector stores RAG records in the store's Postgres database (POSTGRES_*), which needs the pgvector extension
+	PGVector struct {
+		Provider        types.Provider `envconfig:"RAG_PGVECTOR_PROVIDER" default:"openai" description:"The provider used to generate the embeddings."`
+		EmbeddingsModel string         `envconfig:"RAG_PGVECTOR_EMBEDDINGS_MODEL" default:"text-embedding-3-small" description:"The model used to generate the embeddings, changing it requires re-indexing the knowledge."`
+		Dimensions      int            `envconfig:"RAG_PGVECTOR_DIMENSIONS" default:"1536" description:"The number of dimensions of the embeddings model."`
+	}
+
 	Llamaindex struct {
 		// the URL we can post a chunk of text to for RAG indexing
 		RAGIndexingURL string `envconfig:"RAG_INDEX_URL" default:"http://llamaindex:5000/api/v1/rag/chunk" description:"The URL to index text with RAG."`
@@ -187,6 +221,12 @@ type RAG struct {
 		RAGDeleteURL string `envconfig:"RAG_DELETE_URL" default:"http://llamaindex:5000/api/v1/rag" description:"The URL to delete RAG records."`
 	}
 
+	// Local directory knowledge sources, disabled unless roots are allowed
+	Local struct {
+		AllowedRoots  []string      `envconfig:"RAG_LOCAL_ALLOWED_ROOTS" description:"Comma separated server directories that local knowledge sources can index."`
+		WatchDebounce time.Duration `envconfig:"RAG_LOCAL_WATCH_DEBOUNCE" default:"30s" description:"How long to wait after the last file change before re-indexing a watched local knowledge source."`
+	}
+
 	Crawler struct {
 		ChromeURL       string `envconfig:"RAG_CRAWLER_CHROME_URL" default:"http://chrome:9222" description:"The URL to the Chrome instance."`
 		LauncherEnabled bool   `envconfig:"RAG_CRAWLER_LAUNCHER_ENABLED" default:"true" description:"Whether to use the Launcher to start the browser."`
@@ -239,6 +279,16 @@ type Store struct {
 	IdleConns       int           `envconfig:"DATABASE_IDLE_CONNS" default:"25"`
 	MaxConnLifetime time.Duration `envconfig:"DATABASE_MAX_CONN_LIFETIME" default:"1h"`
 	MaxConnIdleTime time.Duration `envconfig:"DATABASE_MAX_CONN_IDLE_TIME" default:"1m"`
+
+	SecretsEncryption SecretsEncryption
+}
+
+// SecretsEncryption configures the master key used to encrypt user secrets at rest.
+// Secrets are stored in plain text if no master key is set.
+type SecretsEncryption struct {
+	MasterKey          string   `envconfig:"SECRETS_MASTER_KEY" description:"Base64 encoded 32 byte master key used to encrypt secrets."`
+	MasterKeyFile      string   `envconfig:"SECRETS_MASTER_KEY_FILE" description:"Path to a file containing the base64 encoded master key."`
+	PreviousMasterKeys []string `envconfig:"SECRETS_PREVIOUS_MASTER_KEYS" description:"Base64 encoded master keys that are still accepted for decryption, used while rotating the master key."`
 }
 
 type WebServer struct {
@@ -280,6 +330,38 @@ type SubscriptionQuotas struct {
 	}
 }
 
+// UsageQuotas limit the token consumption and request rate on the OpenAI
+// compatible API. A value of 0 means unlimited. App owners can override
+// the app limits in the app config.
+type UsageQuotas struct {
+	Enabled bool `envconfig:"USAGE_QUOTAS_ENABLED" default:"false"`
+	User    struct {
+		DailyTokens       int64 `envconfig:"USAGE_QUOTAS_USER_DAILY_TOKENS" default:"0"`
+		MonthlyTokens     int64 `envconfig:"USAGE_QUOTAS_USER_MONTHLY_TOKENS" default:"0"`
+		RequestsPerMinute int64 `envconfig:"USAGE_QUOTAS_USER_REQUESTS_PER_MINUTE" default:"0"`
+	}
+	APIKey struct {
+		DailyTokens       int64 `envconfig:"USAGE_QUOTAS_API_KEY_DAILY_TOKENS" default:"0"`
+		MonthlyTokens     int64 `envconfig:"USAGE_QUOTAS_API_KEY_MONTHLY_TOKENS" default:"0"`
+		RequestsPerMinute int64 `envconfig:"USAGE_QUOTAS_API_KEY_REQUESTS_PER_MINUTE" default:"0"`
+	}
+	App struct {
+		DailyTokens       int64 `envconfig:"USAGE_QUOTAS_APP_DAILY_TOKENS" default:"0"`
+		MonthlyTokens     int64 `envconfig:"USAGE_QUOTAS_APP_MONTHLY_TOKENS" default:"0"`
+		RequestsPerMinute int64 `envconfig:"USAGE_QUOTAS_APP_REQUESTS_PER_MINUTE" default:"0"`
+	}
+}
+
+// ResponseCache configures the chat completion response cache, caching is
+// enabled per assistant in the app config
+type ResponseCache struct {
+	Enabled    bool `envconfig:"RESPONSE_CACHE_ENABLED" default:"true"`
+	MaxEntries int  `envconfig:"RESPONSE_CACHE_MAX_ENTRIES" default:"10000"`
+	// Embeddings are used for similarity caching, the provider must be openai or togetherai
+	EmbeddingsProvider types.Provider `envconfig:"RESPONSE_CACHE_EMBEDDINGS_PROVIDER" description:"Provider used to embed questions for similarity caching (openai or togetherai)."`
+	EmbeddingsModel    string         `envconfig:"RESPONSE_CACHE_EMBEDDINGS_MODEL" default:"text-embedding-3-small"`
+}
+
 type GitHub struct {
 	Enabled      bool   `envconfig:"GITHUB_INTEGRATION_ENABLED" default:"false" description:"Enable github integration."`
 	ClientID     string `envconfig:"GITHUB_INTEGRATION_CLIENT_ID" description:"The github app client id."`
@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v2"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// AccessDeniedError is returned when the user is missing a permission on a
+// resource. The message names the permission so that denials can be audited.
+type AccessDeniedError struct {
+	UserID       string
+	ResourceType types.AccessResourceType
+	ResourceID   string
+	Permission   types.Permission
+}
+
+func (e *AccessDeniedError) Error() string {
+	return fmt.Sprintf("permission denied: user %s is missing the %s permission on %s %s", e.UserID, e.Permission, e.ResourceType, e.ResourceID)
+}
+
+// AuthorizeApp returns an AccessDeniedError unless the user has the permission on the app
+func (c *Controller) AuthorizeApp(ctx context.Context, user *types.User, app *types.App, permission types.Permission) error {
+	role, err := c.appRole(ctx, user, app, permission, true)
+	if err != nil {
+		return err
+	}
+	return authorize(user, role, types.AccessResourceTypeApp, app.ID, permission)
+}
+
+// AuthorizeKnowledge returns an AccessDeniedError unless the user has the
+// permission on the knowledge, either directly or through its app
+func (c *Controller) AuthorizeKnowledge(ctx context.Context, user *types.User, knowledge *types.Knowledge, permission types.Permission) error {
+	role, err := c.knowledgeRole(ctx, user, knowledge, permission)
+	if err != nil {
+		return err
+	}
+	return authorize(user, role, types.AccessResourceTypeKnowledge, knowledge.ID, permission)
+}
+
+// AppRole returns the role the user has on the app, empty if the user can't access it
+func (c *Controller) AppRole(ctx context.Context, user *types.User, app *types.App) (types.AccessRole, error) {
+	return c.appRole(ctx, user, app, "", true)
+}
+
+// KnowledgeRole returns the role the user has on the knowledge, empty if the user can't access it
+func (c *Controller) KnowledgeRole(ctx context.Context, user *types.User, knowledge *types.Knowledge) (types.AccessRole, error) {
+	return c.knowledgeRole(ctx, user, knowledge, "")
+}
+
+// ListAppAccess lists everyone that can access the app and where the access comes from.
+// Server admins can access every app and are not listed.
+func (c *Controller) ListAppAccess(ctx context.Context, app *types.App) ([]*types.ResourceAccess, error) {
+	var access []*types.ResourceAccess
+
+	add := func(userID string, role types.AccessRole, source types.AccessSource) {
+		access = append(access, &types.ResourceAccess{
+			UserID:      userID,
+			Role:        role,
+			Source:      source,
+			Permissions: role.Permissions(),
+		})
+	}
+
+	if app.OwnerType == types.OwnerTypeOrg {
+		memberships, err := c.Options.Store.ListOrganizationMemberships(ctx, &store.ListOrganizationMembershipsQuery{
+			OrganizationID: app.Owner,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organization members: %w", err)
+		}
+		for _, m := range memberships {
+			add(m.UserID, organizationAccessRole(m.Role), types.AccessSourceOrganization)
+		}
+	} else {
+		add(app.Owner, types.AccessRoleAdmin, types.AccessSourceOwner)
+	}
+
+	grants, err := c.Options.Store.ListAccessGrants(ctx, &store.ListAccessGrantsQuery{
+		ResourceType: types.AccessResourceTypeApp,
+		ResourceID:   app.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access grants: %w", err)
+	}
+	for _, grant := range grants {
+		add(grant.UserID, grant.Role, types.AccessSourceGrant)
+	}
+
+	if app.Global || app.Shared {
+		add("*", types.AccessRoleViewer, types.AccessSourcePublic)
+	}
+
+	return access, nil
+}
+
+// appRole resolves the role of the user on the app. The lookup stops as soon as
+// the role includes the wanted permission. Global and shared apps make every
+// user a viewer when public is set.
+func (c *Controller) appRole(ctx context.Context, user *types.User, app *types.App, want types.Permission, public bool) (types.AccessRole, error) {
+	if user.Admin {
+		return types.AccessRoleAdmin, nil
+	}
+
+	role, err := c.ownerRole(ctx, user.ID, app.Owner, app.OwnerType)
+	if err != nil {
+		return "", err
+	}
+
+	if public && (app.Global || app.Shared) {
+		role = role.Max(types.AccessRoleViewer)
+	}
+
+	if want != "" && role.Has(want) {
+		return role, nil
+	}
+
+	granted, err := c.grantedRole(ctx, user.ID, types.AccessResourceTypeApp, app.ID)
+	if err != nil {
+		return "", err
+	}
+
+	return role.Max(granted), nil
+}
+
+func (c *Controller) knowledgeRole(ctx context.Context, user *types.User, knowledge *types.Knowledge, want types.Permission) (types.AccessRole, error) {
+	if user.Admin {
+		return types.AccessRoleAdmin, nil
+	}
+
+	role, err := c.ownerRole(ctx, user.ID, knowledge.Owner, knowledge.OwnerType)
+	if err != nil {
+		return "", err
+	}
+
+	if want != "" && role.Has(want) {
+		return role, nil
+	}
+
+	granted, err := c.grantedRole(ctx, user.ID, types.AccessResourceTypeKnowledge, knowledge.ID)
+	if err != nil {
+		return "", err
+	}
+	role = role.Max(granted)
+
+	if knowledge.AppID == "" || (want != "" && role.Has(want)) {
+		return role, nil
+	}
+
+	// Access to an app includes its knowledge. Everyone can chat with global
+	// and shared apps, but that doesn't give access to the knowledge sources.
+	app, err := c.Options.Store.GetApp(ctx, knowledge.AppID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return role, nil
+		}
+		return "", fmt.Errorf("failed to get app: %w", err)
+	}
+
+	appRole, err := c.appRole(ctx, user, app, want, false)
+	if err != nil {
+		return "", err
+	}
+
+	return role.Max(appRole), nil
+}
+
+// ownerRole returns admin for the owner of a resource and for the owners and
+// admins of the organization that owns it, organization members are viewers
+func (c *Controller) ownerRole(ctx context.Context, userID, owner string, ownerType types.OwnerType) (types.AccessRole, error) {
+	if userID == "" {
+		return "", nil
+	}
+
+	if ownerType != types.OwnerTypeOrg {
+		if owner == userID {
+			return types.AccessRoleAdmin, nil
+		}
+		return "", nil
+	}
+
+	membership, err := c.Options.Store.GetOrganizationMembership(ctx, owner, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get organization membership: %w", err)
+	}
+
+	return organizationAccessRole(membership.Role), nil
+}
+
+func (c *Controller) grantedRole(ctx context.Context, userID string, resourceType types.AccessResourceType, resourceID string) (types.AccessRole, error) {
+	if userID == "" {
+		return "", nil
+	}
+
+	grants, err := c.Options.Store.ListAccessGrants(ctx, &store.ListAccessGrantsQuery{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		UserID:       userID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list access grants: %w", err)
+	}
+
+	var role types.AccessRole
+	for _, grant := range grants {
+		role = role.Max(grant.Role)
+	}
+	return role, nil
+}
+
+func organizationAccessRole(role types.OrganizationRole) types.AccessRole {
+	if role.Includes(types.OrganizationRoleAdmin) {
+		return types.AccessRoleAdmin
+	}
+	if role.Includes(types.OrganizationRoleMember) {
+		return types.AccessRoleViewer
+	}
+	return ""
+}
+
+func authorize(user *types.User, role types.AccessRole, resourceType types.AccessResourceType, resourceID string, permission types.Permission) error {
+	if role.Has(permission) {
+		return nil
+	}
+
+	log.Info().
+		Str("user_id", user.ID).
+		Str("resource_type", string(resourceType)).
+		Str("resource_id", resourceID).
+		Str("permission", string(permission)).
+		Str("role", string(role)).
+		Msg("access denied")
+
+	return &AccessDeniedError{
+		UserID:       user.ID,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Permission:   permission,
+	}
+}
+
+// AuthorizeAppUpdate checks the permissions needed to change the app into the
+// updated app on top of the edit permission. Secrets are substituted in the
+// whole app config, so referring to a new secret needs manage_secrets like
+// changing the app secrets does. Sharing the app changes who can access it.
+func (c *Controller) AuthorizeAppUpdate(ctx context.Context, user *types.User, existing, updated *types.App) error {
+	if updated.Global != existing.Global && !user.Admin {
+		return authorize(user, "", types.AccessResourceTypeApp, existing.ID, types.PermissionManageAccess)
+	}
+
+	if updated.Shared != existing.Shared {
+		if err := c.AuthorizeApp(ctx, user, existing, types.PermissionManageAccess); err != nil {
+			return err
+		}
+	}
+
+	secretsChanged := !maps.Equal(existing.Config.Secrets, updated.Config.Secrets)
+	if !secretsChanged {
+		added, err := addedSecretReferences(existing, updated)
+		if err != nil {
+			return err
+		}
+		secretsChanged = added
+	}
+	if secretsChanged {
+		return c.AuthorizeApp(ctx, user, existing, types.PermissionManageSecrets)
+	}
+
+	return nil
+}
+
+func addedSecretReferences(existing, updated *types.App) (bool, error) {
+	before, err := appSecretReferences(existing)
+	if err != nil {
+		return false, err
+	}
+	after, err := appSecretReferences(updated)
+	if err != nil {
+		return false, err
+	}
+	for name := range after {
+		if !before[name] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func appSecretReferences(app *types.App) (map[string]bool, error) {
+	config, err := yaml.Marshal(app.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal app config: %w", err)
+	}
+	return References(string(config))
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+	"go.uber.org/mock/gomock"
+)
+
+func (suite *ControllerSuite) Test_AuthorizeApp_Owner() {
+	app := &types.App{ID: "app_id", Owner: suite.user.ID, OwnerType: types.OwnerTypeUser}
+
+	for _, permission := range types.AccessRoleAdmin.Permissions() {
+		suite.NoError(suite.controller.AuthorizeApp(suite.ctx, suite.user, app, permission))
+	}
+}
+
+func (suite *ControllerSuite) Test_AuthorizeApp_Grant() {
+	app := &types.App{ID: "app_id", Owner: "someone_else", OwnerType: types.OwnerTypeUser}
+
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), &store.ListAccessGrantsQuery{
+		ResourceType: types.AccessResourceTypeApp,
+		ResourceID:   "app_id",
+		UserID:       suite.user.ID,
+	}).Return([]*types.AccessGrant{
+		{ResourceType: types.AccessResourceTypeApp, ResourceID: "app_id", UserID: suite.user.ID, Role: types.AccessRoleEditor},
+	}, nil).Times(2)
+
+	suite.NoError(suite.controller.AuthorizeApp(suite.ctx, suite.user, app, types.PermissionEdit))
+
+	err := suite.controller.AuthorizeApp(suite.ctx, suite.user, app, types.PermissionManageSecrets)
+	suite.Error(err)
+
+	var denied *AccessDeniedError
+	suite.True(errors.As(err, &denied))
+	suite.Equal(types.PermissionManageSecrets, denied.Permission)
+	suite.Equal("permission denied: user user_id is missing the manage_secrets permission on app app_id", err.Error())
+}
+
+func (suite *ControllerSuite) Test_AuthorizeApp_Shared() {
+	app := &types.App{ID: "app_id", Owner: "someone_else", OwnerType: types.OwnerTypeUser, Shared: true}
+
+	// Chatting with a shared app doesn't need a grant
+	suite.NoError(suite.controller.AuthorizeApp(suite.ctx, suite.user, app, types.PermissionChat))
+
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	suite.Error(suite.controller.AuthorizeApp(suite.ctx, suite.user, app, types.PermissionEdit))
+}
+
+func (suite *ControllerSuite) Test_AuthorizeApp_OrganizationMember() {
+	app := &types.App{ID: "app_id", Owner: "org_id", OwnerType: types.OwnerTypeOrg}
+
+	suite.store.EXPECT().GetOrganizationMembership(gomock.Any(), "org_id", suite.user.ID).Return(&types.OrganizationMembership{
+		OrganizationID: "org_id",
+		UserID:         suite.user.ID,
+		Role:           types.OrganizationRoleMember,
+	}, nil).Times(2)
+
+	suite.NoError(suite.controller.AuthorizeApp(suite.ctx, suite.user, app, types.PermissionChat))
+
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	suite.Error(suite.controller.AuthorizeApp(suite.ctx, suite.user, app, types.PermissionDelete))
+}
+
+func (suite *ControllerSuite) Test_AuthorizeKnowledge_ThroughApp() {
+	knowledge := &types.Knowledge{ID: "knowledge_id", Owner: "someone_else", OwnerType: types.OwnerTypeUser, AppID: "app_id"}
+
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), &store.ListAccessGrantsQuery{
+		ResourceType: types.AccessResourceTypeKnowledge,
+		ResourceID:   "knowledge_id",
+		UserID:       suite.user.ID,
+	}).Return(nil, nil)
+
+	suite.store.EXPECT().GetApp(gomock.Any(), "app_id").Return(&types.App{
+		ID:        "app_id",
+		Owner:     "someone_else",
+		OwnerType: types.OwnerTypeUser,
+	}, nil)
+
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), &store.ListAccessGrantsQuery{
+		ResourceType: types.AccessResourceTypeApp,
+		ResourceID:   "app_id",
+		UserID:       suite.user.ID,
+	}).Return([]*types.AccessGrant{
+		{ResourceType: types.AccessResourceTypeApp, ResourceID: "app_id", UserID: suite.user.ID, Role: types.AccessRoleViewer},
+	}, nil)
+
+	suite.NoError(suite.controller.AuthorizeKnowledge(suite.ctx, suite.user, knowledge, types.PermissionView))
+}
+
+func (suite *ControllerSuite) Test_AuthorizeKnowledge_SharedApp() {
+	knowledge := &types.Knowledge{ID: "knowledge_id", Owner: "someone_else", OwnerType: types.OwnerTypeUser, AppID: "app_id"}
+
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+
+	suite.store.EXPECT().GetApp(gomock.Any(), "app_id").Return(&types.App{
+		ID:        "app_id",
+		Owner:     "someone_else",
+		OwnerType: types.OwnerTypeUser,
+		Shared:    true,
+	}, nil)
+
+	// Everyone can chat with a shared app but not read its knowledge
+	suite.Error(suite.controller.AuthorizeKnowledge(suite.ctx, suite.user, knowledge, types.PermissionView))
+}
+
+func (suite *ControllerSuite) Test_ListAppAccess() {
+	app := &types.App{ID: "app_id", Owner: "org_id", OwnerType: types.OwnerTypeOrg, Shared: true}
+
+	suite.store.EXPECT().ListOrganizationMemberships(gomock.Any(), &store.ListOrganizationMembershipsQuery{
+		OrganizationID: "org_id",
+	}).Return([]*types.OrganizationMembership{
+		{OrganizationID: "org_id", UserID: "owner_id", Role: types.OrganizationRoleOwner},
+		{OrganizationID: "org_id", UserID: "member_id", Role: types.OrganizationRoleMember},
+	}, nil)
+
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), &store.ListAccessGrantsQuery{
+		ResourceType: types.AccessResourceTypeApp,
+		ResourceID:   "app_id",
+	}).Return([]*types.AccessGrant{
+		{UserID: "editor_id", Role: types.AccessRoleEditor},
+	}, nil)
+
+	access, err := suite.controller.ListAppAccess(suite.ctx, app)
+	suite.NoError(err)
+	suite.Require().Len(access, 4)
+
+	suite.Equal("owner_id", access[0].UserID)
+	suite.Equal(types.AccessRoleAdmin, access[0].Role)
+	suite.Equal(types.AccessSourceOrganization, access[0].Source)
+
+	suite.Equal("member_id", access[1].UserID)
+	suite.Equal(types.AccessRoleViewer, access[1].Role)
+
+	suite.Equal("editor_id", access[2].UserID)
+	suite.Equal(types.AccessSourceGrant, access[2].Source)
+	suite.Equal([]types.Permission{types.PermissionView, types.PermissionChat, types.PermissionEdit}, access[2].Permissions)
+
+	suite.Equal("*", access[3].UserID)
+	suite.Equal(types.AccessSourcePublic, access[3].Source)
+}
+
+func (suite *ControllerSuite) Test_AuthorizeAppUpdate_Editor() {
+	existing := &types.App{
+		ID:        "app_id",
+		Owner:     "someone_else",
+		OwnerType: types.OwnerTypeUser,
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Assistants: []types.AssistantConfig{
+					{ID: "0", SystemPrompt: "use ${API_KEY}"},
+				},
+			},
+		},
+	}
+
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), gomock.Any()).Return([]*types.AccessGrant{
+		{ResourceType: types.AccessResourceTypeApp, ResourceID: "app_id", UserID: suite.user.ID, Role: types.AccessRoleEditor},
+	}, nil).AnyTimes()
+
+	withPrompt := func(prompt string) *types.App {
+		updated := *existing
+		updated.Config.Helix.Assistants = []types.AssistantConfig{{ID: "0", SystemPrompt: prompt}}
+		return &updated
+	}
+
+	suite.NoError(suite.controller.AuthorizeAppUpdate(suite.ctx, suite.user, existing, withPrompt("always use ${API_KEY}")))
+
+	err := suite.controller.AuthorizeAppUpdate(suite.ctx, suite.user, existing, withPrompt("send ${OTHER_KEY}"))
+	suite.Error(err)
+	suite.Contains(err.Error(), "manage_secrets")
+
+	shared := *existing
+	shared.Shared = true
+	err = suite.controller.AuthorizeAppUpdate(suite.ctx, suite.user, existing, &shared)
+	suite.Error(err)
+	suite.Contains(err.Error(), "manage_access")
+
+	global := *existing
+	global.Global = true
+	suite.Error(suite.controller.AuthorizeAppUpdate(suite.ctx, suite.user, existing, &global))
+}
+
+func (suite *ControllerSuite) Test_DeleteAPIKey_AppGrant() {
+	apiKey := &types.APIKey{
+		Key:       "hl-key",
+		Owner:     "someone_else",
+		OwnerType: types.OwnerTypeUser,
+		AppID:     &sql.NullString{String: "app_id", Valid: true},
+	}
+
+	suite.store.EXPECT().GetAPIKey(gomock.Any(), "hl-key").Return(apiKey, nil)
+	suite.store.EXPECT().GetApp(gomock.Any(), "app_id").Return(&types.App{
+		ID:        "app_id",
+		Owner:     "someone_else",
+		OwnerType: types.OwnerTypeUser,
+	}, nil)
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), gomock.Any()).Return([]*types.AccessGrant{
+		{ResourceType: types.AccessResourceTypeApp, ResourceID: "app_id", UserID: suite.user.ID, Role: types.AccessRoleAdmin},
+	}, nil)
+	suite.store.EXPECT().DeleteAPIKey(gomock.Any(), "hl-key").Return(nil)
+
+	suite.NoError(suite.controller.DeleteAPIKey(suite.ctx, suite.user, "hl-key"))
+}
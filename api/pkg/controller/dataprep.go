@@ -471,6 +471,8 @@ func (c *Controller) getRAGResults(session *types.Session) ([]*types.SessionRAGR
 		DistanceThreshold: session.Metadata.RagSettings.Threshold,
 		DistanceFunction:  session.Metadata.RagSettings.DistanceFunction,
 		MaxResults:        session.Metadata.RagSettings.ResultsCount,
+		RetrievalMode:     session.Metadata.RagSettings.RetrievalMode,
+		HybridAlpha:       session.Metadata.RagSettings.HybridAlpha,
 	})
 }
 
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/model"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// CreateEmbeddings creates the embeddings with the provider, the inference
+// provider is used if it's not set. Helix runners default to the Nomic model.
+func (c *Controller) CreateEmbeddings(ctx context.Context, provider types.Provider, req openai.EmbeddingRequest) (*openai.EmbeddingResponse, error) {
+	if provider == "" {
+		provider = c.Options.Config.Inference.Provider
+	}
+
+	if req.Model == "" && provider == types.ProviderHelix {
+		req.Model = openai.EmbeddingModel(model.Model_Ollama_NomicEmbedText)
+	}
+
+	client, err := c.getClient(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.CreateEmbeddings(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+
+	return &resp, nil
+}
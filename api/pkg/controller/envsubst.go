@@ -31,3 +31,16 @@ func Eval(spec string, envs map[string]string) (string, error) {
 
 	return config, nil
 }
+
+// References returns the names of the variables the spec refers to
+func References(spec string) (map[string]bool, error) {
+	refs := make(map[string]bool)
+	_, err := envsubst.Eval(spec, func(k string) string {
+		refs[k] = true
+		return ""
+	})
+	if err != nil {
+		return nil, fmt.Errorf("spec env substitution failed: %w", err)
+	}
+	return refs, nil
+}
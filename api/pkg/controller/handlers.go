@@ -61,15 +61,28 @@ func (c *Controller) GetAPIKeys(ctx context.Context, user *types.User) ([]*types
 		}
 		return c.GetAPIKeys(ctx, user)
 	}
-	// return all api key types
+	// return all api key types, including the keys of the user's organizations
 	apiKeys, err = c.Options.Store.ListAPIKeys(ctx, &store.ListApiKeysQuery{
 		Owner:     user.ID,
 		OwnerType: user.Type,
+		Member:    user.ID,
 	})
 	if err != nil {
 		return nil, err
 	}
-	return apiKeys, nil
+
+	// Keys are credentials, organization keys are only listed to the admins
+	var visible []*types.APIKey
+	for _, apiKey := range apiKeys {
+		ok, err := c.HasOwnerAccess(ctx, user.ID, apiKey.Owner, apiKey.OwnerType, types.OrganizationRoleAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			visible = append(visible, apiKey)
+		}
+	}
+	return visible, nil
 }
 
 func (c *Controller) DeleteAPIKey(ctx context.Context, user *types.User, apiKey string) error {
@@ -80,8 +93,28 @@ func (c *Controller) DeleteAPIKey(ctx context.Context, user *types.User, apiKey
 	if fetchedApiKey == nil {
 		return errors.New("no such key")
 	}
-	// only the owner of an api key can delete it
-	if fetchedApiKey.Owner != user.ID || fetchedApiKey.OwnerType != user.Type {
+	// only the owner of an api key, or an admin of the organization that owns it, can delete it
+	ok, err := c.HasOwnerAccess(ctx, user.ID, fetchedApiKey.Owner, fetchedApiKey.OwnerType, types.OrganizationRoleAdmin)
+	if err != nil {
+		return err
+	}
+	if ok && fetchedApiKey.OwnerType != types.OwnerTypeOrg && fetchedApiKey.OwnerType != user.Type {
+		ok = false
+	}
+	// app keys can also be deleted by the users that manage the app keys
+	if !ok && fetchedApiKey.AppID != nil && fetchedApiKey.AppID.Valid {
+		app, err := c.Options.Store.GetApp(ctx, fetchedApiKey.AppID.String)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		if app != nil {
+			if err := c.AuthorizeApp(ctx, user, app, types.PermissionManageKeys); err != nil {
+				return err
+			}
+			ok = true
+		}
+	}
+	if !ok {
 		return errors.New("unauthorized")
 	}
 	err = c.Options.Store.DeleteAPIKey(ctx, fetchedApiKey.Key)
@@ -136,6 +169,7 @@ func (c *Controller) GetDashboardData(ctx context.Context) (*types.DashboardData
 	return &types.DashboardData{
 		DesiredSlots:              c.scheduler.DashboardSlotsData(),
 		SessionQueue:              summaryData,
+		TenantQueues:              c.scheduler.DashboardTenantsData(),
 		Runners:                   runners,
 		GlobalSchedulingDecisions: c.schedulingDecisions,
 	}, nil
@@ -3,12 +3,17 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/helixml/helix/api/pkg/data"
 	"github.com/helixml/helix/api/pkg/model"
 	oai "github.com/helixml/helix/api/pkg/openai"
 	"github.com/helixml/helix/api/pkg/openai/manager"
+	"github.com/helixml/helix/api/pkg/openai/transport"
 	"github.com/helixml/helix/api/pkg/prompts"
 	"github.com/helixml/helix/api/pkg/pubsub"
 	"github.com/helixml/helix/api/pkg/rag"
@@ -28,6 +33,9 @@ type ChatCompletionOptions struct {
 	Provider    types.Provider
 
 	QueryParams map[string]string
+
+	// Citations is set by the controller to the knowledge added to the prompt
+	Citations []*types.Citation
 }
 
 // ChatCompletion is used by the OpenAI compatible API. Doesn't handle any historical sessions, etc.
@@ -40,7 +48,14 @@ func (c *Controller) ChatCompletion(ctx context.Context, user *types.User, req o
 		return nil, nil, err
 	}
 
-	if len(assistant.Tools) > 0 {
+	nativeTools := useNativeTools(assistant)
+
+	// Keep the request as sent for the classifier fallback, the messages
+	// are modified in place below
+	originalReq := req
+	originalReq.Messages = slices.Clone(req.Messages)
+
+	if len(assistant.Tools) > 0 && !nativeTools {
 		// Check whether the app is configured for the call,
 		// if yes, execute the tools and return the response
 		toolResp, ok, err := c.evaluateToolUsage(ctx, user, req, opts)
@@ -79,11 +94,41 @@ func (c *Controller) ChatCompletion(ctx context.Context, user *types.User, req o
 		return nil, nil, fmt.Errorf("failed to enrich prompt with knowledge: %w", err)
 	}
 
+	if assistant.Cache != nil {
+		ctx = oai.SetContextCache(ctx, assistant.Cache)
+	}
+
 	client, err := c.getClient(ctx, opts.Provider)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get client: %v", err)
 	}
 
+	if nativeTools {
+		resp, err := c.chatCompletionWithTools(ctx, client, &req, assistant, opts)
+		if err == nil {
+			return resp, &req, nil
+		}
+
+		if !errors.Is(err, errToolCallingUnsupported) {
+			log.Err(err).Msg("error creating chat completion with tools")
+			return nil, nil, err
+		}
+
+		log.Warn().Err(err).Str("model", req.Model).Msg("model rejected tools, falling back to the classifier")
+
+		toolResp, ok, err := c.evaluateToolUsage(ctx, user, originalReq, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tool execution failed: %w", err)
+		}
+
+		if ok {
+			return toolResp, &originalReq, nil
+		}
+
+		req.Tools = originalReq.Tools
+		req.ToolChoice = originalReq.ToolChoice
+	}
+
 	resp, err := client.CreateChatCompletion(ctx, req)
 	if err != nil {
 		log.Err(err).Msg("error creating chat completion")
@@ -104,7 +149,14 @@ func (c *Controller) ChatCompletionStream(ctx context.Context, user *types.User,
 		return nil, nil, err
 	}
 
-	if len(assistant.Tools) > 0 {
+	nativeTools := useNativeTools(assistant)
+
+	// Keep the request as sent for the classifier fallback, the messages
+	// are modified in place below
+	originalReq := req
+	originalReq.Messages = slices.Clone(req.Messages)
+
+	if len(assistant.Tools) > 0 && !nativeTools {
 		// Check whether the app is configured for the call,
 		// if yes, execute the tools and return the response
 		toolRespStream, ok, err := c.evaluateToolUsageStream(ctx, user, req, opts)
@@ -144,11 +196,41 @@ func (c *Controller) ChatCompletionStream(ctx context.Context, user *types.User,
 		return nil, nil, fmt.Errorf("failed to enrich prompt with knowledge: %w", err)
 	}
 
+	if assistant.Cache != nil {
+		ctx = oai.SetContextCache(ctx, assistant.Cache)
+	}
+
 	client, err := c.getClient(ctx, opts.Provider)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get client: %v", err)
 	}
 
+	if nativeTools {
+		stream, err := c.chatCompletionStreamWithTools(ctx, client, &req, assistant, opts)
+		if err == nil {
+			return stream, &req, nil
+		}
+
+		if !errors.Is(err, errToolCallingUnsupported) {
+			log.Err(err).Msg("error creating chat completion stream with tools")
+			return nil, nil, err
+		}
+
+		log.Warn().Err(err).Str("model", req.Model).Msg("model rejected tools, falling back to the classifier")
+
+		toolRespStream, ok, err := c.evaluateToolUsageStream(ctx, user, originalReq, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load tools: %w", err)
+		}
+
+		if ok {
+			return toolRespStream, &originalReq, nil
+		}
+
+		req.Tools = originalReq.Tools
+		req.ToolChoice = originalReq.ToolChoice
+	}
+
 	stream, err := client.CreateChatCompletionStream(ctx, req)
 	if err != nil {
 		log.Err(err).Msg("error creating chat completion stream")
@@ -175,112 +257,167 @@ func (c *Controller) getClient(ctx context.Context, provider types.Provider) (oa
 }
 
 func (c *Controller) evaluateToolUsage(ctx context.Context, user *types.User, req openai.ChatCompletionRequest, opts *ChatCompletionOptions) (*openai.ChatCompletionResponse, bool, error) {
-	vals, ok := oai.GetContextValues(ctx)
-	if !ok {
-		vals = &oai.ContextValues{}
-	}
-
-	selectedTool, isActionable, ok, err := c.selectAndConfigureTool(ctx, user, req, opts)
+	result, err := c.runClassifierLoop(ctx, user, req, opts, false)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to select and configure tool: %w", err)
+		return nil, false, err
 	}
 
-	if !ok {
+	if result == nil {
 		return nil, false, nil
 	}
 
-	history := types.HistoryFromChatCompletionRequest(req)
-
-	c.emitStepInfo(ctx, &types.StepInfo{
-		Name:    selectedTool.Name,
-		Type:    types.StepInfoTypeToolUse,
-		Message: "Running action",
-	})
+	return &openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{
+			{
+				Message: openai.ChatCompletionMessage{
+					Content: result.message,
+				},
+			},
+		},
+	}, true, nil
+}
 
-	resp, err := c.ToolsPlanner.RunAction(ctx, vals.SessionID, vals.InteractionID, selectedTool, history, isActionable.Api)
+func (c *Controller) evaluateToolUsageStream(ctx context.Context, user *types.User, req openai.ChatCompletionRequest, opts *ChatCompletionOptions) (*openai.ChatCompletionStream, bool, error) {
+	result, err := c.runClassifierLoop(ctx, user, req, opts, true)
 	if err != nil {
-		c.emitStepInfo(ctx, &types.StepInfo{
-			Name:    selectedTool.Name,
-			Type:    types.StepInfoTypeToolUse,
-			Message: fmt.Sprintf("Action failed: %s", err),
-		})
+		return nil, false, err
+	}
 
-		return nil, false, fmt.Errorf("failed to perform action: %w", err)
+	if result == nil {
+		return nil, false, nil
 	}
 
-	c.emitStepInfo(ctx, &types.StepInfo{
-		Name:    selectedTool.Name,
-		Type:    types.StepInfoTypeToolUse,
-		Message: "Action completed",
-	})
+	if result.stream != nil {
+		return result.stream, true, nil
+	}
 
-	return &openai.ChatCompletionResponse{
+	stream, err := transport.NewOpenAIStreamFromResponse(req, &openai.ChatCompletionResponse{
+		Model: req.Model,
 		Choices: []openai.ChatCompletionChoice{
 			{
 				Message: openai.ChatCompletionMessage{
-					Content: resp.Message,
+					Role:    openai.ChatMessageRoleAssistant,
+					Content: result.message,
 				},
+				FinishReason: openai.FinishReasonStop,
 			},
 		},
-	}, true, nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to stream the action response: %w", err)
+	}
+
+	return stream, true, nil
 }
 
-func (c *Controller) evaluateToolUsageStream(ctx context.Context, user *types.User, req openai.ChatCompletionRequest, opts *ChatCompletionOptions) (*openai.ChatCompletionStream, bool, error) {
-	vals, ok := oai.GetContextValues(ctx)
-	if !ok {
-		vals = &oai.ContextValues{}
-	}
+// classifierResult is the answer of the last action run by the classifier,
+// streamed if the action was run in streaming mode
+type classifierResult struct {
+	message string
+	stream  *openai.ChatCompletionStream
+}
 
-	selectedTool, isActionable, ok, err := c.selectAndConfigureTool(ctx, user, req, opts)
+// runClassifierLoop asks the classifier which action to run and runs it until
+// the classifier finds nothing more to do or the iterations run out. Each
+// classification sees the answers of the previous actions. The classifier only
+// looks at the last user input so the loop stops when it picks an action that
+// already ran. Returns nil if no action ran. When streaming, the action of the
+// last iteration is streamed, the answers of the earlier ones are complete.
+func (c *Controller) runClassifierLoop(ctx context.Context, user *types.User, req openai.ChatCompletionRequest, opts *ChatCompletionOptions, stream bool) (*classifierResult, error) {
+	assistant, err := c.loadAssistant(ctx, user, opts)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to select and configure tool: %w", err)
+		log.Info().Msg("no assistant found")
+		return nil, err
 	}
 
+	vals, ok := oai.GetContextValues(ctx)
 	if !ok {
-		return nil, false, nil
+		vals = &oai.ContextValues{}
 	}
 
 	history := types.HistoryFromChatCompletionRequest(req)
+	limit := maxIterations(assistant)
+	ranActions := make(map[string]bool)
 
-	c.emitStepInfo(ctx, &types.StepInfo{
-		Name:    selectedTool.Name,
-		Type:    types.StepInfoTypeToolUse,
-		Message: "Running action",
-	})
+	var result *classifierResult
 
-	stream, err := c.ToolsPlanner.RunActionStream(ctx, vals.SessionID, vals.InteractionID, selectedTool, history, isActionable.Api)
-	if err != nil {
-		log.Warn().
-			Err(err).
-			Str("tool", selectedTool.Name).
-			Str("action", isActionable.Api).
-			Msg("failed to perform action")
+	for iteration := 1; iteration <= limit; iteration++ {
+		selectedTool, isActionable, ok, err := c.selectAndConfigureTool(ctx, assistant, history, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select and configure tool: %w", err)
+		}
+
+		if !ok || ranActions[isActionable.Api] {
+			return result, nil
+		}
+
+		ranActions[isActionable.Api] = true
 
 		c.emitStepInfo(ctx, &types.StepInfo{
-			Name:    selectedTool.Name,
-			Type:    types.StepInfoTypeToolUse,
-			Message: fmt.Sprintf("Action failed: %s", err),
+			Name:      selectedTool.Name,
+			Type:      types.StepInfoTypeToolUse,
+			Message:   "Running action",
+			Iteration: iteration,
 		})
 
-		return nil, false, fmt.Errorf("failed to perform action: %w", err)
+		if stream && iteration == limit {
+			actionStream, err := c.ToolsPlanner.RunActionStream(ctx, vals.SessionID, vals.InteractionID, selectedTool, history, isActionable.Api)
+			if err != nil {
+				return nil, c.actionFailed(ctx, iteration, selectedTool, isActionable.Api, err)
+			}
+
+			c.emitStepInfo(ctx, &types.StepInfo{
+				Name:      selectedTool.Name,
+				Type:      types.StepInfoTypeToolUse,
+				Message:   "Action completed",
+				Iteration: iteration,
+			})
+
+			return &classifierResult{stream: actionStream}, nil
+		}
+
+		resp, err := c.ToolsPlanner.RunAction(ctx, vals.SessionID, vals.InteractionID, selectedTool, history, isActionable.Api)
+		if err != nil {
+			return nil, c.actionFailed(ctx, iteration, selectedTool, isActionable.Api, err)
+		}
+
+		c.emitStepInfo(ctx, &types.StepInfo{
+			Name:      selectedTool.Name,
+			Type:      types.StepInfoTypeToolUse,
+			Message:   "Action completed",
+			Iteration: iteration,
+		})
+
+		result = &classifierResult{message: resp.Message}
+
+		history = append(history, &types.ToolHistoryMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: resp.Message,
+		})
 	}
 
+	return result, nil
+}
+
+func (c *Controller) actionFailed(ctx context.Context, iteration int, tool *types.Tool, action string, err error) error {
+	log.Warn().
+		Err(err).
+		Str("tool", tool.Name).
+		Str("action", action).
+		Int("iteration", iteration).
+		Msg("failed to perform action")
+
 	c.emitStepInfo(ctx, &types.StepInfo{
-		Name:    selectedTool.Name,
-		Type:    types.StepInfoTypeToolUse,
-		Message: "Action completed",
+		Name:      tool.Name,
+		Type:      types.StepInfoTypeToolUse,
+		Message:   fmt.Sprintf("Action failed: %s", err),
+		Iteration: iteration,
 	})
 
-	return stream, true, nil
+	return fmt.Errorf("failed to perform action: %w", err)
 }
 
-func (c *Controller) selectAndConfigureTool(ctx context.Context, user *types.User, req openai.ChatCompletionRequest, opts *ChatCompletionOptions) (*types.Tool, *tools.IsActionableResponse, bool, error) {
-	assistant, err := c.loadAssistant(ctx, user, opts)
-	if err != nil {
-		log.Info().Msg("no assistant found")
-		return nil, nil, false, err
-	}
-
+func (c *Controller) selectAndConfigureTool(ctx context.Context, assistant *types.AssistantConfig, history []*types.ToolHistoryMessage, opts *ChatCompletionOptions) (*types.Tool, *tools.IsActionableResponse, bool, error) {
 	if len(assistant.Tools) == 0 {
 		log.Info().
 			Str("assistant_id", assistant.ID).
@@ -301,8 +438,6 @@ func (c *Controller) selectAndConfigureTool(ctx context.Context, user *types.Use
 		options = append(options, tools.WithModel(assistant.Model))
 	}
 
-	history := types.HistoryFromChatCompletionRequest(req)
-
 	vals, ok := oai.GetContextValues(ctx)
 	if !ok {
 		vals = &oai.ContextValues{}
@@ -335,29 +470,36 @@ func (c *Controller) selectAndConfigureTool(ctx context.Context, user *types.Use
 		return nil, nil, false, fmt.Errorf("tool not found for action: %s", isActionable.Api)
 	}
 
+	// Configured for this request only, the assistant can be shared by
+	// concurrent requests
+	selectedTool = copyTool(selectedTool)
+	configureTool(selectedTool, assistant, opts)
+
+	return selectedTool, isActionable, true, nil
+}
+
+func configureTool(tool *types.Tool, assistant *types.AssistantConfig, opts *ChatCompletionOptions) {
 	// If assistant has configured a model, give the hint to the tool that it should use that model too
 	if assistant != nil && assistant.Model != "" {
-		if selectedTool.Config.API != nil && selectedTool.Config.API.Model == "" {
+		if tool.Config.API != nil && tool.Config.API.Model == "" {
 			log.Info().
 				Str("assistant_id", assistant.ID).
 				Str("assistant_name", assistant.Name).
 				Str("assistant_model", assistant.Model).
-				Str("tool_name", selectedTool.Name).
+				Str("tool_name", tool.Name).
 				Msg("assistant has configured a model, and tool has no model specified, using assistant model for tool")
 
-			selectedTool.Config.API.Model = assistant.Model
+			tool.Config.API.Model = assistant.Model
 		}
 	}
 
-	if len(opts.QueryParams) > 0 && selectedTool.Config.API != nil {
-		selectedTool.Config.API.Query = make(map[string]string)
+	if len(opts.QueryParams) > 0 && tool.Config.API != nil {
+		tool.Config.API.Query = make(map[string]string)
 
 		for k, v := range opts.QueryParams {
-			selectedTool.Config.API.Query[k] = v
+			tool.Config.API.Query[k] = v
 		}
 	}
-
-	return selectedTool, isActionable, true, nil
 }
 
 func (c *Controller) loadAssistant(ctx context.Context, user *types.User, opts *ChatCompletionOptions) (*types.AssistantConfig, error) {
@@ -374,8 +516,8 @@ func (c *Controller) loadAssistant(ctx context.Context, user *types.User, opts *
 		return nil, fmt.Errorf("error getting app: %w", err)
 	}
 
-	if (!app.Global && !app.Shared) && app.Owner != user.ID {
-		return nil, fmt.Errorf("you do not have access to the app with the id: %s", app.ID)
+	if err := c.AuthorizeApp(ctx, user, app, types.PermissionChat); err != nil {
+		return nil, err
 	}
 
 	// Load secrets into the app
@@ -394,12 +536,34 @@ func (c *Controller) loadAssistant(ctx context.Context, user *types.User, opts *
 }
 
 func (c *Controller) evaluateSecrets(ctx context.Context, user *types.User, app *types.App) (*types.App, error) {
-	secrets, err := c.Options.Store.ListSecrets(ctx, &store.ListSecretsQuery{
+	var secrets []*types.Secret
+
+	// Apps owned by an organization get the organization secrets scoped to
+	// the app. Any member can create an org app, so the secrets of the whole
+	// organization are not available to it. The user's own secrets are added
+	// last so they take precedence.
+	if app.OwnerType == types.OwnerTypeOrg {
+		orgSecrets, err := c.Options.Store.ListSecrets(ctx, &store.ListSecretsQuery{
+			Owner:     app.Owner,
+			OwnerType: types.OwnerTypeOrg,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organization secrets: %w", err)
+		}
+		for _, secret := range orgSecrets {
+			if secret.AppID == app.ID {
+				secrets = append(secrets, secret)
+			}
+		}
+	}
+
+	userSecrets, err := c.Options.Store.ListSecrets(ctx, &store.ListSecretsQuery{
 		Owner: user.ID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list secrets: %w", err)
 	}
+	secrets = append(secrets, userSecrets...)
 
 	var filteredSecrets []*types.Secret
 
@@ -446,6 +610,8 @@ func enrichAppWithSecrets(app *types.App, secrets []*types.Secret) (*types.App,
 }
 
 func (c *Controller) enrichPromptWithKnowledge(ctx context.Context, user *types.User, req *openai.ChatCompletionRequest, assistant *types.AssistantConfig, opts *ChatCompletionOptions) error {
+	opts.Citations = nil
+
 	// Check for an extra RAG context
 	ragResults, err := c.evaluateRAG(ctx, user, *req, opts)
 	if err != nil {
@@ -488,6 +654,8 @@ func (c *Controller) evaluateRAG(ctx context.Context, user *types.User, req open
 		DistanceThreshold: entity.Config.RAGSettings.Threshold,
 		DistanceFunction:  entity.Config.RAGSettings.DistanceFunction,
 		MaxResults:        entity.Config.RAGSettings.ResultsCount,
+		RetrievalMode:     entity.Config.RAGSettings.RetrievalMode,
+		HybridAlpha:       entity.Config.RAGSettings.HybridAlpha,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error querying RAG: %w", err)
@@ -499,6 +667,7 @@ func (c *Controller) evaluateRAG(ctx context.Context, user *types.User, req open
 			DocumentID: result.DocumentID,
 			Content:    result.Content,
 		})
+		opts.Citations = append(opts.Citations, newCitation("", result))
 	}
 
 	return ragContent, nil
@@ -548,6 +717,8 @@ func (c *Controller) evaluateKnowledge(ctx context.Context, user *types.User, re
 				DistanceThreshold: knowledge.RAGSettings.Threshold,
 				DistanceFunction:  knowledge.RAGSettings.DistanceFunction,
 				MaxResults:        knowledge.RAGSettings.ResultsCount,
+				RetrievalMode:     knowledge.RAGSettings.RetrievalMode,
+				HybridAlpha:       knowledge.RAGSettings.HybridAlpha,
 			})
 			if err != nil {
 				return nil, nil, fmt.Errorf("error querying RAG: %w", err)
@@ -566,6 +737,7 @@ func (c *Controller) evaluateKnowledge(ctx context.Context, user *types.User, re
 					Source:      result.Source,
 					Content:     result.Content,
 				})
+				opts.Citations = append(opts.Citations, newCitation(knowledge.ID, result))
 			}
 
 			if len(ragResults) > 0 {
@@ -577,6 +749,47 @@ func (c *Controller) evaluateKnowledge(ctx context.Context, user *types.User, re
 	return backgroundKnowledge, usedKnowledge, nil
 }
 
+// maxCitationSnippetLength is the maximum length of the chunk content
+// returned with the citations
+const maxCitationSnippetLength = 300
+
+func newCitation(knowledgeID string, result *types.SessionRAGResult) *types.Citation {
+	citation := &types.Citation{
+		DocumentID:  result.DocumentID,
+		KnowledgeID: knowledgeID,
+		Source:      result.Source,
+		Snippet:     getCitationSnippet(result.Content),
+		Metadata:    result.Metadata,
+	}
+
+	// Link to the section of the web page
+	if u, err := url.Parse(result.Source); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		if anchor := result.Metadata[types.RAGMetadataAnchor]; anchor != "" && u.Fragment == "" {
+			u.Fragment = anchor
+		}
+		citation.URL = u.String()
+	}
+
+	return citation
+}
+
+// getCitationSnippet shortens the content at a word boundary
+func getCitationSnippet(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+
+	runes := []rune(content)
+	if len(runes) <= maxCitationSnippetLength {
+		return content
+	}
+
+	snippet := string(runes[:maxCitationSnippetLength])
+	if idx := strings.LastIndex(snippet, " "); idx > 0 {
+		snippet = snippet[:idx]
+	}
+
+	return snippet + "..."
+}
+
 func (c *Controller) emitStepInfo(ctx context.Context, stepInfo *types.StepInfo) error {
 	vals, ok := oai.GetContextValues(ctx)
 	if !ok {
@@ -665,9 +878,73 @@ func setSystemPrompt(req *openai.ChatCompletionRequest, systemPrompt string) ope
 }
 
 func (c *Controller) GetRagClient(ctx context.Context, knowledge *types.Knowledge) (rag.RAG, error) {
+	ragClient := c.Options.RAG
+
 	if knowledge.RAGSettings.IndexURL != "" && knowledge.RAGSettings.QueryURL != "" {
-		return rag.NewLlamaindex(&knowledge.RAGSettings), nil
+		ragClient = rag.NewLlamaindex(&knowledge.RAGSettings)
+	}
+
+	settings := &knowledge.RAGSettings.Reranking
+	if !settings.Enabled {
+		return ragClient, nil
+	}
+
+	var reranker rag.Reranker
+
+	switch settings.Type {
+	case types.RAGRerankerCrossEncoder:
+		if settings.URL == "" {
+			return nil, fmt.Errorf("cross-encoder reranker URL is not set")
+		}
+
+		apiKey, err := c.getKnowledgeSecret(ctx, knowledge, settings.APIKeySecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reranker API key: %w", err)
+		}
+
+		reranker = rag.NewCrossEncoderReranker(settings, apiKey)
+	case types.RAGRerankerLLM:
+		client, err := c.getClient(ctx, settings.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reranker client: %w", err)
+		}
+
+		model := settings.Model
+		if model == "" {
+			model = c.Options.Config.Tools.Model
+		}
+
+		reranker = rag.NewLLMReranker(client, model)
+	default:
+		return nil, fmt.Errorf("unknown reranker type: %s", settings.Type)
+	}
+
+	return rag.NewReranking(ragClient, reranker, settings.Candidates), nil
+}
+
+// getKnowledgeSecret returns the value of a secret of the knowledge owner that
+// is available to the knowledge app, empty if no secret is named
+func (c *Controller) getKnowledgeSecret(ctx context.Context, knowledge *types.Knowledge, name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+
+	secrets, err := c.Options.Store.ListSecrets(ctx, &store.ListSecretsQuery{
+		Owner: knowledge.Owner,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	for _, secret := range secrets {
+		if secret.Name != name {
+			continue
+		}
+		if secret.AppID != "" && secret.AppID != knowledge.AppID {
+			continue
+		}
+		return string(secret.Value), nil
 	}
 
-	return c.Options.RAG, nil
+	return "", fmt.Errorf("secret '%s' not found", name)
 }
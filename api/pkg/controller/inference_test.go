@@ -2,7 +2,10 @@ package controller
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/helixml/helix/api/pkg/config"
@@ -19,6 +22,7 @@ import (
 	"go.uber.org/mock/gomock"
 
 	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -70,7 +74,7 @@ func (suite *ControllerSuite) SetupTest() {
 	cfg.Tools.Enabled = false
 	cfg.Inference.Provider = types.ProviderTogetherAI
 
-	scheduler := scheduler.NewScheduler(suite.ctx, cfg, nil)
+	scheduler := scheduler.NewScheduler(suite.ctx, cfg, nil, nil)
 
 	c, err := NewController(context.Background(), ControllerOptions{
 		Config:          cfg,
@@ -197,6 +201,114 @@ func (suite *ControllerSuite) Test_BasicInferenceWithKnowledge() {
 	}, resp)
 }
 
+func (suite *ControllerSuite) Test_InferenceWithKnowledge_Citations() {
+	req := openai.ChatCompletionRequest{
+		Model: openai.GPT4TurboPreview,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: "How do I install it?",
+			},
+		},
+	}
+
+	app := &types.App{
+		ID:     "app_id",
+		Global: true,
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Assistants: []types.AssistantConfig{
+					{
+						ID: "0",
+						Knowledge: []*types.AssistantKnowledge{
+							{
+								Name: "knowledge_name",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	suite.store.EXPECT().GetAppWithTools(suite.ctx, "app_id").Return(app, nil)
+	suite.store.EXPECT().ListSecrets(gomock.Any(), gomock.Any()).Return([]*types.Secret{}, nil)
+
+	suite.store.EXPECT().LookupKnowledge(suite.ctx, gomock.Any()).Return(&types.Knowledge{
+		ID:      "knowledge_id",
+		AppID:   "app_id",
+		Version: "v1",
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{URLs: []string{"https://docs.helix.ml"}},
+		},
+	}, nil)
+
+	suite.rag.EXPECT().Query(suite.ctx, gomock.Any()).Return([]*types.SessionRAGResult{
+		{
+			DocumentID: "doc_1",
+			Source:     "https://docs.helix.ml/install",
+			Content:    "Run the\n  install script",
+			Metadata: map[string]string{
+				types.RAGMetadataHeadings: "Install > Linux",
+				types.RAGMetadataAnchor:   "linux",
+			},
+		},
+		{
+			DocumentID: "doc_2",
+			Source:     "guide.pdf",
+			Content:    "See page two",
+			Metadata:   map[string]string{types.RAGMetadataPage: "2"},
+		},
+	}, nil)
+
+	suite.openAiClient.EXPECT().CreateChatCompletion(suite.ctx, gomock.Any()).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{
+			{
+				Message: openai.ChatCompletionMessage{
+					Content: "Run the install script [DOC_ID:doc_1]",
+				},
+			},
+		},
+	}, nil)
+
+	opts := &ChatCompletionOptions{
+		AppID:       "app_id",
+		AssistantID: "0",
+	}
+
+	_, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, req, opts)
+	suite.Require().NoError(err)
+
+	suite.Equal([]*types.Citation{
+		{
+			DocumentID:  "doc_1",
+			KnowledgeID: "knowledge_id",
+			Source:      "https://docs.helix.ml/install",
+			URL:         "https://docs.helix.ml/install#linux",
+			Snippet:     "Run the install script",
+			Metadata: map[string]string{
+				types.RAGMetadataHeadings: "Install > Linux",
+				types.RAGMetadataAnchor:   "linux",
+			},
+		},
+		{
+			DocumentID:  "doc_2",
+			KnowledgeID: "knowledge_id",
+			Source:      "guide.pdf",
+			Snippet:     "See page two",
+			Metadata:    map[string]string{types.RAGMetadataPage: "2"},
+		},
+	}, opts.Citations)
+}
+
+func Test_getCitationSnippet(t *testing.T) {
+	assert.Equal(t, "short", getCitationSnippet(" short "))
+
+	snippet := getCitationSnippet(strings.Repeat("word ", 100))
+	assert.True(t, strings.HasSuffix(snippet, "word..."), snippet)
+	assert.LessOrEqual(t, len(snippet), maxCitationSnippetLength+3)
+}
+
 func (suite *ControllerSuite) Test_EvaluateSecrets() {
 	app := &types.App{
 		ID:     "app_id",
@@ -240,6 +352,69 @@ func (suite *ControllerSuite) Test_EvaluateSecrets() {
 	suite.Equal(app.Config.Helix.Assistants[0].Tools[0].Config.API.Headers["X-Secret-Key"], "secret_value")
 }
 
+func (suite *ControllerSuite) Test_GetRagClient_RerankerSecret() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("Bearer rerank-key", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"results": [{"index": 1, "relevance_score": 0.9}, {"index": 0, "relevance_score": 0.1}]}`))
+	}))
+	defer srv.Close()
+
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: suite.user.ID,
+		AppID: "app_id",
+		RAGSettings: types.RAGSettings{
+			Reranking: types.RAGRerankSettings{
+				Enabled:      true,
+				Type:         types.RAGRerankerCrossEncoder,
+				URL:          srv.URL,
+				APIKeySecret: "RERANK_KEY",
+			},
+		},
+	}
+
+	suite.store.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{
+		Owner: suite.user.ID,
+	}).Return([]*types.Secret{
+		// Secrets of other apps aren't available to the knowledge
+		{Name: "RERANK_KEY", AppID: "other_app_id", Value: []byte("other-key")},
+		{Name: "RERANK_KEY", Value: []byte("rerank-key")},
+	}, nil)
+
+	suite.rag.EXPECT().Query(gomock.Any(), gomock.Any()).Return([]*types.SessionRAGResult{
+		{Content: "kubernetes"},
+		{Content: "helix"},
+	}, nil)
+
+	client, err := suite.controller.GetRagClient(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+
+	results, err := client.Query(suite.ctx, &types.SessionRAGQuery{Prompt: "what is helix?"})
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Equal("helix", results[0].Content)
+}
+
+func (suite *ControllerSuite) Test_GetRagClient_RerankerSecretNotFound() {
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: suite.user.ID,
+		RAGSettings: types.RAGSettings{
+			Reranking: types.RAGRerankSettings{
+				Enabled:      true,
+				Type:         types.RAGRerankerCrossEncoder,
+				URL:          "http://reranker",
+				APIKeySecret: "RERANK_KEY",
+			},
+		},
+	}
+
+	suite.store.EXPECT().ListSecrets(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	_, err := suite.controller.GetRagClient(suite.ctx, knowledge)
+	suite.ErrorContains(err, "secret 'RERANK_KEY' not found")
+}
+
 func Test_setSystemPrompt(t *testing.T) {
 	type args struct {
 		req          *openai.ChatCompletionRequest
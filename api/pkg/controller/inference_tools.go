@@ -0,0 +1,344 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/transport"
+	"github.com/helixml/helix/api/pkg/tools"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// errToolCallingUnsupported is returned when the provider rejects a request
+// with tools, in which case we fall back to the classifier
+var errToolCallingUnsupported = errors.New("model does not support tool calling")
+
+// unsupportedToolsMessages are the lowercase error messages of the providers
+// rejecting requests with tools
+var unsupportedToolsMessages = []string{
+	"does not support tools",                         // Ollama
+	"'tools' is not supported",                       // OpenAI
+	"auto\" tool choice requires --enable-auto-tool", // vLLM
+	"does not support function calling",
+	"tools are not supported",
+}
+
+// useNativeTools returns true when the assistant's tools are passed to the
+// model as function definitions instead of running the classifier. Both run
+// up to MaxIterations rounds of tools before answering.
+func useNativeTools(assistant *types.AssistantConfig) bool {
+	return len(assistant.Tools) > 0 && assistant.ToolCalling == types.ToolCallingModeNative
+}
+
+// defaultMaxIterations is used when the assistant doesn't set MaxIterations,
+// for both the agent loop and the classifier loop
+const defaultMaxIterations = 5
+
+// chatCompletionWithTools adds the assistant's tools to the request next to the
+// tools sent by the caller and runs the agent loop. The tool calls are returned
+// to the caller when they are executed on the client or when the model calls
+// the caller's tools.
+func (c *Controller) chatCompletionWithTools(ctx context.Context, client oai.Client, req *openai.ChatCompletionRequest, assistant *types.AssistantConfig, opts *ChatCompletionOptions) (*openai.ChatCompletionResponse, error) {
+	functions, err := c.addToolFunctions(req, assistant, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.runAgentLoop(ctx, client, req, assistant, functions)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil {
+		return resp, nil
+	}
+
+	final, err := client.CreateChatCompletion(agentIterationContext(ctx, maxIterations(assistant)+1), *req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response for the tool results: %w", err)
+	}
+
+	return &final, nil
+}
+
+// chatCompletionStreamWithTools is the streaming version of chatCompletionWithTools.
+// The agent loop calls the model without streaming to find out whether it wants
+// to call any tools, the final response is then streamed to the caller as it is.
+func (c *Controller) chatCompletionStreamWithTools(ctx context.Context, client oai.Client, req *openai.ChatCompletionRequest, assistant *types.AssistantConfig, opts *ChatCompletionOptions) (*openai.ChatCompletionStream, error) {
+	functions, err := c.addToolFunctions(req, assistant, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.runAgentLoop(ctx, client, req, assistant, functions)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil {
+		return transport.NewOpenAIStreamFromResponse(*req, resp)
+	}
+
+	stream, err := client.CreateChatCompletionStream(agentIterationContext(ctx, maxIterations(assistant)+1), *req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response for the tool results: %w", err)
+	}
+
+	return stream, nil
+}
+
+// runAgentLoop calls the model and runs the assistant's tools it asks for until
+// it answers or the iterations run out. Each iteration sees the results of all
+// the previous tool calls. Returns the model's last response, or nil if the
+// iterations ran out and the answer still has to be generated, in which case
+// the request is updated to disable further tool calls.
+func (c *Controller) runAgentLoop(ctx context.Context, client oai.Client, req *openai.ChatCompletionRequest, assistant *types.AssistantConfig, functions []*tools.ToolFunction) (*openai.ChatCompletionResponse, error) {
+	limit := maxIterations(assistant)
+
+	for iteration := 1; iteration <= limit; iteration++ {
+		iterationReq := *req
+		iterationReq.Stream = false
+		iterationReq.StreamOptions = nil
+
+		resp, err := client.CreateChatCompletion(agentIterationContext(ctx, iteration), iterationReq)
+		if err != nil {
+			if iteration == 1 {
+				return nil, toolCallingError(err)
+			}
+			return nil, fmt.Errorf("failed to run agent iteration %d: %w", iteration, err)
+		}
+
+		if !shouldRunToolCalls(assistant, functions, &resp) {
+			return &resp, nil
+		}
+
+		c.runToolCalls(ctx, iteration, req, functions, &resp)
+	}
+
+	c.emitStepInfo(ctx, &types.StepInfo{
+		Name:      "agent",
+		Type:      types.StepInfoTypeToolUse,
+		Message:   fmt.Sprintf("Reached the maximum of %d iterations", limit),
+		Iteration: limit,
+	})
+
+	// The model has to answer with the results it has
+	req.ToolChoice = "none"
+
+	return nil, nil
+}
+
+func maxIterations(assistant *types.AssistantConfig) int {
+	if assistant.MaxIterations > 0 {
+		return assistant.MaxIterations
+	}
+	return defaultMaxIterations
+}
+
+// agentIterationContext records the model calls of the agent loop as
+// agent_iteration LLM call steps
+func agentIterationContext(ctx context.Context, iteration int) context.Context {
+	return oai.SetStep(ctx, &oai.Step{
+		Step:      types.LLMCallStepAgentIteration,
+		Iteration: iteration,
+	})
+}
+
+// addToolFunctions puts the assistant's tool functions in front of the tools
+// sent by the caller. The tools are configured for this request only, the
+// assistant can be shared by concurrent requests.
+func (c *Controller) addToolFunctions(req *openai.ChatCompletionRequest, assistant *types.AssistantConfig, opts *ChatCompletionOptions) ([]*tools.ToolFunction, error) {
+	assistantTools := make([]*types.Tool, 0, len(assistant.Tools))
+	for _, tool := range assistant.Tools {
+		copied := copyTool(tool)
+		configureTool(copied, assistant, opts)
+		assistantTools = append(assistantTools, copied)
+	}
+
+	functions, err := tools.GetToolFunctions(assistantTools)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tool functions: %w", err)
+	}
+
+	var requestTools []openai.Tool
+	for _, fn := range functions {
+		requestTools = append(requestTools, fn.Definition)
+	}
+
+	req.Tools = append(requestTools, req.Tools...)
+
+	return functions, nil
+}
+
+// copyTool copies the parts of the tool changed by configureTool
+func copyTool(tool *types.Tool) *types.Tool {
+	copied := *tool
+	if copied.Config.API != nil {
+		api := *copied.Config.API
+		copied.Config.API = &api
+	}
+	return &copied
+}
+
+// shouldRunToolCalls returns true if the server is configured to run the tool
+// calls and all of them are for the assistant's tools. Calls of the caller's
+// own tools are always returned to the caller.
+func shouldRunToolCalls(assistant *types.AssistantConfig, functions []*tools.ToolFunction, resp *openai.ChatCompletionResponse) bool {
+	if assistant.ToolExecution == types.ToolExecutionClient {
+		return false
+	}
+
+	if len(resp.Choices) == 0 || len(resp.Choices[0].Message.ToolCalls) == 0 {
+		return false
+	}
+
+	for _, toolCall := range resp.Choices[0].Message.ToolCalls {
+		if getToolFunction(functions, toolCall.Function.Name) == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// runToolCalls runs the tool calls from the response in parallel and appends
+// them together with their results to the request. Failed calls are reported
+// to the model so it can try something else or tell the user.
+func (c *Controller) runToolCalls(ctx context.Context, iteration int, req *openai.ChatCompletionRequest, functions []*tools.ToolFunction, resp *openai.ChatCompletionResponse) {
+	message := resp.Choices[0].Message
+
+	results := make([]string, len(message.ToolCalls))
+
+	var wg sync.WaitGroup
+
+	for i, toolCall := range message.ToolCalls {
+		wg.Add(1)
+
+		go func(i int, toolCall openai.ToolCall) {
+			defer wg.Done()
+
+			results[i] = c.runToolCall(ctx, iteration, getToolFunction(functions, toolCall.Function.Name), toolCall)
+		}(i, toolCall)
+	}
+
+	wg.Wait()
+
+	req.Messages = append(req.Messages, message)
+
+	for i, toolCall := range message.ToolCalls {
+		req.Messages = append(req.Messages, openai.ChatCompletionMessage{
+			Role:       openai.ChatMessageRoleTool,
+			Content:    results[i],
+			Name:       toolCall.Function.Name,
+			ToolCallID: toolCall.ID,
+		})
+	}
+}
+
+// runToolCall runs a single tool call and returns the result for the model
+func (c *Controller) runToolCall(ctx context.Context, iteration int, fn *tools.ToolFunction, toolCall openai.ToolCall) string {
+	vals, ok := oai.GetContextValues(ctx)
+	if !ok {
+		vals = &oai.ContextValues{}
+	}
+
+	c.emitStepInfo(ctx, &types.StepInfo{
+		Name:      fn.Tool.Name,
+		Type:      types.StepInfoTypeToolUse,
+		Message:   fmt.Sprintf("Running action %s", fn.Action),
+		Iteration: iteration,
+	})
+
+	result, err := c.ToolsPlanner.RunToolCall(ctx, vals.SessionID, vals.InteractionID, fn.Tool, fn.Action, toolCall.Function.Arguments)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("tool", fn.Tool.Name).
+			Str("action", fn.Action).
+			Int("iteration", iteration).
+			Msg("failed to run tool call")
+
+		c.emitStepInfo(ctx, &types.StepInfo{
+			Name:      fn.Tool.Name,
+			Type:      types.StepInfoTypeToolUse,
+			Message:   fmt.Sprintf("Action failed: %s", err),
+			Iteration: iteration,
+		})
+
+		return fmt.Sprintf("Error: %s", err)
+	}
+
+	if result.Error != "" {
+		c.emitStepInfo(ctx, &types.StepInfo{
+			Name:      fn.Tool.Name,
+			Type:      types.StepInfoTypeToolUse,
+			Message:   fmt.Sprintf("Action failed: %s", result.Error),
+			Iteration: iteration,
+		})
+
+		return fmt.Sprintf("Error: %s\n%s", result.Error, result.Message)
+	}
+
+	c.emitStepInfo(ctx, &types.StepInfo{
+		Name:      fn.Tool.Name,
+		Type:      types.StepInfoTypeToolUse,
+		Message:   "Action completed",
+		Iteration: iteration,
+	})
+
+	return result.Message
+}
+
+func getToolFunction(functions []*tools.ToolFunction, name string) *tools.ToolFunction {
+	for _, fn := range functions {
+		if fn.Name() == name {
+			return fn
+		}
+	}
+	return nil
+}
+
+// toolCallingError marks errors of providers rejecting the request because of
+// the tools so the caller can fall back to the classifier
+func toolCallingError(err error) error {
+	var (
+		apiErr  *openai.APIError
+		reqErr  *openai.RequestError
+		status  int
+		message string
+	)
+
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+		message = apiErr.Message
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+		if reqErr.Err != nil {
+			message = reqErr.Err.Error()
+		}
+	}
+
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusNotImplemented:
+	default:
+		return err
+	}
+
+	message = strings.ToLower(message)
+	for _, unsupported := range unsupportedToolsMessages {
+		if strings.Contains(message, unsupported) {
+			return fmt.Errorf("%w: %w", errToolCallingUnsupported, err)
+		}
+	}
+
+	return err
+}
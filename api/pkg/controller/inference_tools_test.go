@@ -0,0 +1,541 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const weatherAPISpec = `openapi: "3.0.0"
+info:
+  version: 1.0.0
+  title: Weather
+paths:
+  /weather:
+    get:
+      summary: Get the current weather for a city
+      operationId: getWeather
+      parameters:
+        - name: city
+          in: query
+          required: true
+          schema:
+            type: string
+      responses:
+        '200':
+          description: The weather
+`
+
+func (suite *ControllerSuite) setupNativeToolsApp(url string, execution types.ToolExecution, maxIterations int) {
+	app := &types.App{
+		ID:     "app_id",
+		Global: true,
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Assistants: []types.AssistantConfig{
+					{
+						ID:            "0",
+						ToolCalling:   types.ToolCallingModeNative,
+						ToolExecution: execution,
+						MaxIterations: maxIterations,
+						Tools: []*types.Tool{
+							{
+								ID:       "tool_id",
+								Name:     "weather",
+								ToolType: types.ToolTypeAPI,
+								Config: types.ToolConfig{
+									API: &types.ToolApiConfig{
+										URL:    url,
+										Schema: weatherAPISpec,
+										Actions: []*types.ToolApiAction{
+											{
+												Name:        "getWeather",
+												Description: "Get the current weather for a city",
+												Method:      "GET",
+												Path:        "/weather",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	suite.store.EXPECT().GetAppWithTools(gomock.Any(), "app_id").Return(app, nil).AnyTimes()
+	suite.store.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{
+		Owner: suite.user.ID,
+	}).Return([]*types.Secret{}, nil).AnyTimes()
+}
+
+func newToolCallResponse(arguments string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{
+			{
+				Message: openai.ChatCompletionMessage{
+					Role: openai.ChatMessageRoleAssistant,
+					ToolCalls: []openai.ToolCall{
+						{
+							ID:   "call_1",
+							Type: openai.ToolTypeFunction,
+							Function: openai.FunctionCall{
+								Name:      "getWeather",
+								Arguments: arguments,
+							},
+						},
+					},
+				},
+				FinishReason: openai.FinishReasonToolCalls,
+			},
+		},
+	}
+}
+
+func newWeatherRequest() openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model: openai.GPT4o,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: "What's the weather in London?",
+			},
+		},
+	}
+}
+
+func (suite *ControllerSuite) Test_NativeTools_ServerExecution() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("/weather", r.URL.Path)
+		suite.Equal("London", r.URL.Query().Get("city"))
+
+		fmt.Fprint(w, `{"temperature": 12}`)
+	}))
+	defer ts.Close()
+
+	suite.setupNativeToolsApp(ts.URL, "", 0)
+
+	suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+			suite.Require().Len(req.Tools, 1)
+			suite.Equal("getWeather", req.Tools[0].Function.Name)
+
+			return newToolCallResponse(`{"city": "London"}`), nil
+		})
+
+	suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+			suite.Require().Len(req.Messages, 3)
+			suite.Equal(openai.ChatMessageRoleTool, req.Messages[2].Role)
+			suite.Equal("call_1", req.Messages[2].ToolCallID)
+			suite.Equal(`{"temperature": 12}`, req.Messages[2].Content)
+			suite.Nil(req.ToolChoice)
+
+			return openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{
+					{Message: openai.ChatCompletionMessage{Content: "It's 12 degrees in London"}},
+				},
+			}, nil
+		})
+
+	resp, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, newWeatherRequest(), &ChatCompletionOptions{
+		AppID:       "app_id",
+		AssistantID: "0",
+	})
+	suite.Require().NoError(err)
+	suite.Equal("It's 12 degrees in London", resp.Choices[0].Message.Content)
+}
+
+func (suite *ControllerSuite) Test_NativeTools_ClientExecution() {
+	suite.setupNativeToolsApp("http://localhost:1", types.ToolExecutionClient, 0)
+
+	suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newToolCallResponse(`{"city": "London"}`), nil)
+
+	resp, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, newWeatherRequest(), &ChatCompletionOptions{
+		AppID:       "app_id",
+		AssistantID: "0",
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(resp.Choices[0].Message.ToolCalls, 1)
+	suite.Equal("getWeather", resp.Choices[0].Message.ToolCalls[0].Function.Name)
+}
+
+func (suite *ControllerSuite) Test_NativeTools_CallerTools() {
+	suite.setupNativeToolsApp("http://localhost:1", "", 0)
+
+	req := newWeatherRequest()
+	req.Tools = []openai.Tool{
+		{
+			Type:     openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{Name: "lookupCalendar"},
+		},
+	}
+
+	toolCallResp := newToolCallResponse(`{}`)
+	toolCallResp.Choices[0].Message.ToolCalls[0].Function.Name = "lookupCalendar"
+
+	// Calls of the caller's own tools are returned to the caller
+	suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+			suite.Require().Len(req.Tools, 2)
+			suite.Equal("getWeather", req.Tools[0].Function.Name)
+			suite.Equal("lookupCalendar", req.Tools[1].Function.Name)
+
+			return toolCallResp, nil
+		})
+
+	resp, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, req, &ChatCompletionOptions{
+		AppID:       "app_id",
+		AssistantID: "0",
+	})
+	suite.Require().NoError(err)
+	suite.Equal("lookupCalendar", resp.Choices[0].Message.ToolCalls[0].Function.Name)
+}
+
+func (suite *ControllerSuite) Test_NativeTools_FallbackToClassifier() {
+	suite.setupNativeToolsApp("http://localhost:1", "", 0)
+
+	gomock.InOrder(
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{}, &openai.APIError{
+			HTTPStatusCode: http.StatusBadRequest,
+			Message:        "tools are not supported",
+		}),
+		// Classifier
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{
+				{Message: openai.ChatCompletionMessage{Content: `{"needs_tool": "no", "justification": "just chatting"}`}},
+			},
+		}, nil),
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+				suite.Empty(req.Tools)
+
+				return openai.ChatCompletionResponse{
+					Choices: []openai.ChatCompletionChoice{
+						{Message: openai.ChatCompletionMessage{Content: "I don't know"}},
+					},
+				}, nil
+			}),
+	)
+
+	resp, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, newWeatherRequest(), &ChatCompletionOptions{
+		AppID:       "app_id",
+		AssistantID: "0",
+	})
+	suite.Require().NoError(err)
+	suite.Equal("I don't know", resp.Choices[0].Message.Content)
+}
+
+func (suite *ControllerSuite) Test_NativeTools_Stream() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"temperature": 12}`)
+	}))
+	defer ts.Close()
+
+	suite.setupNativeToolsApp(ts.URL, "", 0)
+
+	suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+			suite.False(req.Stream)
+
+			return openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{
+					{Message: openai.ChatCompletionMessage{Content: "Hello there"}},
+				},
+			}, nil
+		})
+
+	stream, _, err := suite.controller.ChatCompletionStream(suite.ctx, suite.user, newWeatherRequest(), &ChatCompletionOptions{
+		AppID:       "app_id",
+		AssistantID: "0",
+	})
+	suite.Require().NoError(err)
+
+	var content string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		suite.Require().NoError(err)
+
+		if len(chunk.Choices) > 0 {
+			content += chunk.Choices[0].Delta.Content
+		}
+	}
+
+	suite.Equal("Hello there", content)
+}
+
+func (suite *ControllerSuite) Test_NativeTools_MultipleIterations() {
+	var (
+		mu     sync.Mutex
+		cities []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		cities = append(cities, r.URL.Query().Get("city"))
+		mu.Unlock()
+
+		fmt.Fprintf(w, `{"city": %q, "temperature": 12}`, r.URL.Query().Get("city"))
+	}))
+	defer ts.Close()
+
+	suite.setupNativeToolsApp(ts.URL, "", 2)
+
+	// Two parallel calls in the first iteration
+	parallel := newToolCallResponse(`{"city": "London"}`)
+	parallel.Choices[0].Message.ToolCalls = append(parallel.Choices[0].Message.ToolCalls, openai.ToolCall{
+		ID:   "call_2",
+		Type: openai.ToolTypeFunction,
+		Function: openai.FunctionCall{
+			Name:      "getWeather",
+			Arguments: `{"city": "Paris"}`,
+		},
+	})
+
+	gomock.InOrder(
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _ openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+				step, ok := oai.GetStep(ctx)
+				suite.Require().True(ok)
+				suite.Equal(types.LLMCallStepAgentIteration, step.Step)
+				suite.Equal(1, step.Iteration)
+
+				return parallel, nil
+			}),
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+				// user, assistant, two tool results
+				suite.Require().Len(req.Messages, 4)
+				suite.Equal("call_1", req.Messages[2].ToolCallID)
+				suite.Contains(req.Messages[2].Content, "London")
+				suite.Equal("call_2", req.Messages[3].ToolCallID)
+				suite.Contains(req.Messages[3].Content, "Paris")
+
+				return newToolCallResponse(`{"city": "Berlin"}`), nil
+			}),
+		// Out of iterations, the model has to answer
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+				suite.Require().Len(req.Messages, 6)
+				suite.Equal("none", req.ToolChoice)
+
+				step, ok := oai.GetStep(ctx)
+				suite.Require().True(ok)
+				suite.Equal(3, step.Iteration)
+
+				return openai.ChatCompletionResponse{
+					Choices: []openai.ChatCompletionChoice{
+						{Message: openai.ChatCompletionMessage{Content: "It's 12 degrees everywhere"}},
+					},
+				}, nil
+			}),
+	)
+
+	resp, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, newWeatherRequest(), &ChatCompletionOptions{
+		AppID:       "app_id",
+		AssistantID: "0",
+	})
+	suite.Require().NoError(err)
+	suite.Equal("It's 12 degrees everywhere", resp.Choices[0].Message.Content)
+	suite.ElementsMatch([]string{"London", "Paris", "Berlin"}, cities)
+}
+
+func Test_toolCallingError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		unsupported bool
+	}{
+		{
+			name:        "ollama",
+			err:         &openai.APIError{HTTPStatusCode: http.StatusBadRequest, Message: "registry.ollama.ai/library/llama2:latest does not support tools"},
+			unsupported: true,
+		},
+		{
+			name:        "openai",
+			err:         &openai.APIError{HTTPStatusCode: http.StatusBadRequest, Message: "Unsupported parameter: 'tools' is not supported with this model."},
+			unsupported: true,
+		},
+		{
+			name:        "vllm",
+			err:         &openai.RequestError{HTTPStatusCode: http.StatusBadRequest, Err: errors.New(`"auto" tool choice requires --enable-auto-tool-choice and --tool-call-parser to be set`)},
+			unsupported: true,
+		},
+		{
+			name: "context length",
+			err:  &openai.APIError{HTTPStatusCode: http.StatusBadRequest, Message: "This model's maximum context length is 8192 tokens"},
+		},
+		{
+			name: "invalid tool schema",
+			err:  &openai.APIError{HTTPStatusCode: http.StatusBadRequest, Message: "Invalid schema for function 'getWeather'"},
+		},
+		{
+			name: "server error",
+			err:  &openai.APIError{HTTPStatusCode: http.StatusInternalServerError, Message: "tools are not supported"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := toolCallingError(tt.err)
+			assert.Equal(t, tt.unsupported, errors.Is(err, errToolCallingUnsupported))
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func (suite *ControllerSuite) Test_NativeTools_AssistantNotModified() {
+	assistant := &types.AssistantConfig{
+		Model: "assistant-model",
+		Tools: []*types.Tool{
+			{
+				Name:     "weather",
+				ToolType: types.ToolTypeAPI,
+				Config: types.ToolConfig{
+					API: &types.ToolApiConfig{
+						URL:    "http://localhost:1",
+						Schema: weatherAPISpec,
+					},
+				},
+			},
+		},
+	}
+
+	req := newWeatherRequest()
+
+	_, err := suite.controller.addToolFunctions(&req, assistant, &ChatCompletionOptions{
+		QueryParams: map[string]string{"city": "London"},
+	})
+	suite.Require().NoError(err)
+
+	suite.Empty(assistant.Tools[0].Config.API.Model)
+	suite.Nil(assistant.Tools[0].Config.API.Query)
+}
+
+func (suite *ControllerSuite) setupClassifierToolsApp(url string) {
+	forecastSpec := strings.NewReplacer(
+		"/weather", "/forecast",
+		"getWeather", "getForecast",
+		"current weather", "weather forecast",
+	).Replace(weatherAPISpec)
+
+	newTool := func(name, action, path, schema string) *types.Tool {
+		return &types.Tool{
+			ID:       name,
+			Name:     name,
+			ToolType: types.ToolTypeAPI,
+			Config: types.ToolConfig{
+				API: &types.ToolApiConfig{
+					URL:    url,
+					Schema: schema,
+					Actions: []*types.ToolApiAction{
+						{Name: action, Method: "GET", Path: path},
+					},
+				},
+			},
+		}
+	}
+
+	app := &types.App{
+		ID:     "app_id",
+		Global: true,
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Assistants: []types.AssistantConfig{
+					{
+						ID: "0",
+						Tools: []*types.Tool{
+							newTool("weather", "getWeather", "/weather", weatherAPISpec),
+							newTool("forecast", "getForecast", "/forecast", forecastSpec),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	suite.store.EXPECT().GetAppWithTools(gomock.Any(), "app_id").Return(app, nil).AnyTimes()
+	suite.store.EXPECT().ListSecrets(gomock.Any(), gomock.Any()).Return([]*types.Secret{}, nil).AnyTimes()
+}
+
+func newChatResponse(content string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{
+			{Message: openai.ChatCompletionMessage{Content: content}},
+		},
+	}
+}
+
+func (suite *ControllerSuite) Test_ClassifierTools_MultipleIterations() {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		fmt.Fprint(w, `{"temperature": 12}`)
+	}))
+	defer ts.Close()
+
+	suite.setupClassifierToolsApp(ts.URL)
+
+	lastMessage := func(req openai.ChatCompletionRequest) string {
+		// The classifier and the parameters prompts end with their own instruction
+		return req.Messages[len(req.Messages)-2].Content
+	}
+
+	gomock.InOrder(
+		// Classifier, parameters and interpretation of the first action
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(
+			newChatResponse(`{"needs_tool": "yes", "api": "getWeather", "justification": "weather"}`), nil),
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(
+			newChatResponse(`{"city": "London"}`), nil),
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(
+			newChatResponse("It's 12 degrees in London"), nil),
+		// The classifier sees the answer of the first action
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+				suite.Equal("It's 12 degrees in London", lastMessage(req))
+
+				return newChatResponse(`{"needs_tool": "yes", "api": "getForecast", "justification": "forecast"}`), nil
+			}),
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(
+			newChatResponse(`{"city": "London"}`), nil),
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(
+			newChatResponse("It's 12 degrees in London today and tomorrow"), nil),
+		// Picking an action that already ran ends the loop
+		suite.openAiClient.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(
+			newChatResponse(`{"needs_tool": "yes", "api": "getWeather", "justification": "weather"}`), nil),
+	)
+
+	resp, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, newWeatherRequest(), &ChatCompletionOptions{
+		AppID:       "app_id",
+		AssistantID: "0",
+	})
+	suite.Require().NoError(err)
+	suite.Equal("It's 12 degrees in London today and tomorrow", resp.Choices[0].Message.Content)
+	suite.Equal([]string{"/weather", "/forecast"}, paths)
+}
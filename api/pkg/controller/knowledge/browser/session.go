@@ -0,0 +1,191 @@
+package browser
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Session is an incognito context of a pooled browser. Its pages share
+// cookies with each other but not with pooled pages or other sessions, so
+// authenticated crawls don't leak their credentials.
+type Session struct {
+	browser *rod.Browser
+	headers map[string]string // Extra headers sent to the hosts
+	hosts   []string
+
+	routersMu sync.Mutex
+	routers   map[*rod.Page]*rod.HijackRouter
+}
+
+// NewSession creates an isolated session in the browser. Headers are sent
+// with the requests of the session pages to the hosts only, not with
+// subresources or redirects to other hosts.
+func (b *Browser) NewSession(browser *rod.Browser, headers map[string]string, hosts []string) (*Session, error) {
+	incognito, err := browser.Incognito()
+	if err != nil {
+		return nil, fmt.Errorf("error creating browser session: %w", err)
+	}
+
+	return &Session{
+		browser: incognito,
+		headers: headers,
+		hosts:   hosts,
+		routers: make(map[*rod.Page]*rod.HijackRouter),
+	}, nil
+}
+
+// GetPage opens a new page in the session, pages are not pooled and have
+// to be closed with PutPage
+func (s *Session) GetPage(opts proto.TargetCreateTarget) (*rod.Page, error) {
+	page, err := s.browser.Page(proto.TargetCreateTarget{
+		URL: "about:blank",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating page: %w", err)
+	}
+
+	if len(s.headers) > 0 && len(s.hosts) > 0 {
+		router := page.HijackRequests()
+
+		err = router.Add("*", "", s.setHeaders)
+		if err != nil {
+			_ = router.Stop()
+			_ = page.Close()
+			return nil, fmt.Errorf("error setting page headers: %w", err)
+		}
+
+		go router.Run()
+
+		s.routersMu.Lock()
+		s.routers[page] = router
+		s.routersMu.Unlock()
+	}
+
+	err = page.Navigate(opts.URL)
+	if err != nil {
+		_ = page.Close()
+		return nil, fmt.Errorf("error navigating to %s: %w", opts.URL, err)
+	}
+
+	return page, nil
+}
+
+func (s *Session) PutPage(page *rod.Page) {
+	if page == nil {
+		return
+	}
+
+	s.routersMu.Lock()
+	router, ok := s.routers[page]
+	delete(s.routers, page)
+	s.routersMu.Unlock()
+
+	if ok {
+		_ = router.Stop()
+	}
+
+	_ = page.Close()
+}
+
+// setHeaders continues the request with the session headers if it goes to
+// one of the session hosts
+func (s *Session) setHeaders(h *rod.Hijack) {
+	if !s.allowed(h.Request.URL().Hostname()) {
+		h.ContinueRequest(&proto.FetchContinueRequest{})
+		return
+	}
+
+	var headers []*proto.FetchHeaderEntry
+
+	for name, value := range h.Request.Headers() {
+		if !s.overrides(name) {
+			headers = append(headers, &proto.FetchHeaderEntry{Name: name, Value: value.Str()})
+		}
+	}
+
+	for name, value := range s.headers {
+		headers = append(headers, &proto.FetchHeaderEntry{Name: name, Value: value})
+	}
+
+	h.ContinueRequest(&proto.FetchContinueRequest{Headers: headers})
+}
+
+func (s *Session) allowed(host string) bool {
+	for _, h := range s.hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Session) overrides(header string) bool {
+	for name := range s.headers {
+		if strings.EqualFold(name, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCookies adds the cookies to the session, cookies without a domain
+// are set for the URL
+func (s *Session) SetCookies(u string, cookies []*http.Cookie) error {
+	var params []*proto.NetworkCookieParam
+
+	for _, c := range cookies {
+		param := &proto.NetworkCookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+		}
+
+		if param.Domain == "" {
+			param.URL = u
+		}
+
+		params = append(params, param)
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+
+	return s.browser.SetCookies(params)
+}
+
+// Cookies returns all cookies of the session, e.g. set by a login page
+func (s *Session) Cookies() ([]*http.Cookie, error) {
+	cookies, err := s.browser.GetCookies()
+	if err != nil {
+		return nil, fmt.Errorf("error getting session cookies: %w", err)
+	}
+
+	result := make([]*http.Cookie, 0, len(cookies))
+
+	for _, c := range cookies {
+		result = append(result, &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HTTPOnly,
+		})
+	}
+
+	return result, nil
+}
+
+// Close disposes the session context and its cookies
+func (s *Session) Close() error {
+	return s.browser.Close()
+}
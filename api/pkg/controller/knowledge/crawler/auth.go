@@ -0,0 +1,301 @@
+package crawler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/controller/knowledge/browser"
+)
+
+const loginTimeout = 30 * time.Second
+
+// Auth are the credentials of a web source, with the secrets resolved
+type Auth struct {
+	Username    string
+	Password    string
+	BearerToken string
+	Headers     map[string]string
+	Cookies     []*http.Cookie
+	FormLogin   *FormLogin
+	Hosts       []string // Hosts the credentials are sent to, from the source URLs
+}
+
+// FormLogin fills and submits a login form in the browser, selectors are
+// CSS selectors
+type FormLogin struct {
+	URL              string
+	Username         string
+	Password         string
+	UsernameSelector string
+	PasswordSelector string
+	SubmitSelector   string
+	WaitSelector     string
+}
+
+// GetHeaders returns the headers sent with every request, including the
+// Authorization header
+func (a *Auth) GetHeaders() map[string]string {
+	if a == nil {
+		return nil
+	}
+
+	headers := make(map[string]string, len(a.Headers)+1)
+
+	switch {
+	case a.BearerToken != "":
+		headers["Authorization"] = "Bearer " + a.BearerToken
+	case a.Username != "" || a.Password != "":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password))
+	}
+
+	// Custom headers take precedence
+	for k, v := range a.Headers {
+		headers[k] = v
+	}
+
+	return headers
+}
+
+// Apply sets the headers and the cookies matching the request host, requests
+// to other hosts than the source hosts are left as they are
+func (a *Auth) Apply(req *http.Request) {
+	if a == nil || !a.allowed(req.URL.Hostname()) {
+		return
+	}
+
+	for k, v := range a.GetHeaders() {
+		req.Header.Set(k, v)
+	}
+
+	for _, c := range a.Cookies {
+		if a.cookieMatches(c, req.URL.Hostname()) {
+			req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+	}
+}
+
+// Do sends the request with the credentials, they are removed when the
+// request is redirected to another host
+func (a *Auth) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if a == nil {
+		return client.Do(req)
+	}
+
+	a.Apply(req)
+
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !a.allowed(req.URL.Hostname()) {
+			for k := range a.GetHeaders() {
+				req.Header.Del(k)
+			}
+			req.Header.Del("Cookie")
+		}
+
+		if client.CheckRedirect != nil {
+			return client.CheckRedirect(req, via)
+		}
+
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+
+		return nil
+	}
+
+	return c.Do(req)
+}
+
+// allowed returns true if the credentials can be sent to the host
+func (a *Auth) allowed(host string) bool {
+	for _, h := range a.Hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// useSession returns true if the browser needs an isolated session
+func (a *Auth) useSession() bool {
+	return a != nil && (a.FormLogin != nil || len(a.Cookies) > 0 || len(a.GetHeaders()) > 0)
+}
+
+// cookieMatches returns true if the cookie is sent to the host, cookies
+// without a domain are sent to the source hosts only
+func (a *Auth) cookieMatches(c *http.Cookie, host string) bool {
+	if !a.allowed(host) {
+		return false
+	}
+
+	domain := strings.TrimPrefix(c.Domain, ".")
+	if domain == "" {
+		return true
+	}
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// LoginCookies logs in with the auth form login in an isolated browser
+// session and returns its cookies, or the auth cookies without form login
+func LoginCookies(ctx context.Context, b *browser.Browser, auth *Auth) ([]*http.Cookie, error) {
+	if auth == nil || auth.FormLogin == nil {
+		return auth.getCookies(), nil
+	}
+
+	rb, err := b.GetBrowser()
+	if err != nil {
+		return nil, fmt.Errorf("error getting browser: %w", err)
+	}
+
+	session, err := startSession(ctx, b, rb, auth, []string{auth.FormLogin.URL})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = session.Close()
+	}()
+
+	return session.Cookies()
+}
+
+func (a *Auth) getCookies() []*http.Cookie {
+	if a == nil {
+		return nil
+	}
+	return a.Cookies
+}
+
+// withCookies returns a copy of the auth using the cookies
+func (a *Auth) withCookies(cookies []*http.Cookie) *Auth {
+	var auth Auth
+	if a != nil {
+		auth = *a
+	}
+	auth.Cookies = cookies
+	return &auth
+}
+
+// startSession creates an isolated browser session with the auth headers and
+// cookies, logging in if the form login is set
+func startSession(ctx context.Context, b *browser.Browser, rb *rod.Browser, auth *Auth, urls []string) (*browser.Session, error) {
+	session, err := b.NewSession(rb, auth.GetHeaders(), auth.Hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setSessionCookies(session, auth, urls)
+	if err == nil && auth.FormLogin != nil {
+		err = login(ctx, session, auth.FormLogin)
+	}
+	if err != nil {
+		_ = session.Close()
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// login fills and submits the login form, the session keeps the cookies
+// set by the site
+func login(ctx context.Context, session *browser.Session, form *FormLogin) error {
+	log.Info().Str("url", form.URL).Msg("logging in before crawling")
+
+	page, err := session.GetPage(proto.TargetCreateTarget{URL: form.URL})
+	if err != nil {
+		return err
+	}
+	defer session.PutPage(page)
+
+	page = page.Context(ctx).Timeout(loginTimeout)
+
+	err = page.WaitLoad()
+	if err != nil {
+		return fmt.Errorf("error waiting for login page to load: %w", err)
+	}
+
+	usernameInput, err := page.Element(form.UsernameSelector)
+	if err != nil {
+		return fmt.Errorf("error finding username field '%s': %w", form.UsernameSelector, err)
+	}
+
+	err = usernameInput.Input(form.Username)
+	if err != nil {
+		return fmt.Errorf("error filling username: %w", err)
+	}
+
+	passwordInput, err := page.Element(form.PasswordSelector)
+	if err != nil {
+		return fmt.Errorf("error finding password field '%s': %w", form.PasswordSelector, err)
+	}
+
+	err = passwordInput.Input(form.Password)
+	if err != nil {
+		return fmt.Errorf("error filling password: %w", err)
+	}
+
+	waitNavigation := page.WaitNavigation(proto.PageLifecycleEventNameLoad)
+
+	if form.SubmitSelector != "" {
+		submit, err := page.Element(form.SubmitSelector)
+		if err != nil {
+			return fmt.Errorf("error finding submit button '%s': %w", form.SubmitSelector, err)
+		}
+
+		err = submit.Click(proto.InputMouseButtonLeft, 1)
+		if err != nil {
+			return fmt.Errorf("error submitting login form: %w", err)
+		}
+	} else {
+		err = passwordInput.Type(input.Enter)
+		if err != nil {
+			return fmt.Errorf("error submitting login form: %w", err)
+		}
+	}
+
+	if form.WaitSelector != "" {
+		_, err = page.Element(form.WaitSelector)
+		if err != nil {
+			return fmt.Errorf("login failed, '%s' not found: %w", form.WaitSelector, err)
+		}
+		return nil
+	}
+
+	waitNavigation()
+
+	return nil
+}
+
+// setSessionCookies adds the auth cookies to the session for every URL
+func setSessionCookies(session *browser.Session, auth *Auth, urls []string) error {
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return err
+		}
+
+		var cookies []*http.Cookie
+		for _, c := range auth.Cookies {
+			if auth.cookieMatches(c, parsed.Hostname()) {
+				cookies = append(cookies, c)
+			}
+		}
+
+		err = session.SetCookies(u, cookies)
+		if err != nil {
+			return fmt.Errorf("error setting session cookies: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_GetHeaders(t *testing.T) {
+	auth := &Auth{
+		Username: "helix",
+		Password: "secret",
+		Headers:  map[string]string{"X-Tenant": "docs"},
+	}
+
+	assert.Equal(t, map[string]string{
+		"Authorization": "Basic aGVsaXg6c2VjcmV0",
+		"X-Tenant":      "docs",
+	}, auth.GetHeaders())
+
+	// Bearer token instead of basic auth, custom headers take precedence
+	auth.BearerToken = "token"
+	assert.Equal(t, "Bearer token", auth.GetHeaders()["Authorization"])
+
+	auth.Headers["Authorization"] = "Custom"
+	assert.Equal(t, "Custom", auth.GetHeaders()["Authorization"])
+
+	var noAuth *Auth
+	assert.Empty(t, noAuth.GetHeaders())
+	assert.False(t, noAuth.useSession())
+}
+
+func TestAuth_Apply(t *testing.T) {
+	auth := &Auth{
+		BearerToken: "token",
+		Cookies: []*http.Cookie{
+			{Name: "session", Value: "1"},
+			{Name: "wiki", Value: "2", Domain: ".example.com"},
+			{Name: "other", Value: "3", Domain: "other.com"},
+		},
+		Hosts: []string{"wiki.example.com", "other.com"},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "https://wiki.example.com/page", nil)
+	auth.Apply(req)
+
+	assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	assert.Equal(t, "session=1; wiki=2", req.Header.Get("Cookie"))
+
+	// Not a source host, even if the cookie domain matches
+	req = httptest.NewRequest(http.MethodGet, "https://cdn.example.com/page", nil)
+	auth.Apply(req)
+
+	assert.Empty(t, req.Header.Get("Authorization"))
+	assert.Empty(t, req.Header.Get("Cookie"))
+}
+
+func TestAuth_Do_Redirect(t *testing.T) {
+	var headers []http.Header
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header)
+	}))
+	defer other.Close()
+
+	// 127.0.0.1 and localhost are different hosts for the credentials
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header)
+		http.Redirect(w, r, otherURL, http.StatusFound)
+	}))
+	defer server.Close()
+
+	auth := &Auth{
+		Headers: map[string]string{"X-Api-Key": "key"},
+		Cookies: []*http.Cookie{{Name: "session", Value: "1"}},
+		Hosts:   []string{"127.0.0.1"},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, server.URL, nil)
+	req.RequestURI = ""
+
+	resp, err := auth.Do(http.DefaultClient, req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Len(t, headers, 2)
+	assert.Equal(t, "key", headers[0].Get("X-Api-Key"))
+	assert.Equal(t, "session=1", headers[0].Get("Cookie"))
+	assert.Empty(t, headers[1].Get("X-Api-Key"))
+	assert.Empty(t, headers[1].Get("Cookie"))
+}
+
+func Test_setCollectorAuth(t *testing.T) {
+	var (
+		authorization string
+		cookies       []*http.Cookie
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		cookies = r.Cookies()
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	auth := &Auth{
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		Cookies: []*http.Cookie{
+			{Name: "session", Value: "1", Path: "/"},
+			{Name: "other", Value: "2", Domain: "other.com"},
+		},
+		Hosts: []string{"127.0.0.1"},
+	}
+
+	collector := colly.NewCollector(colly.IgnoreRobotsTxt())
+	require.NoError(t, setCollectorAuth(collector, auth, []string{server.URL}))
+	require.NoError(t, collector.Visit(server.URL+"/docs"))
+
+	assert.Equal(t, "Bearer token", authorization)
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "session", cookies[0].Name)
+	assert.Equal(t, "1", cookies[0].Value)
+}
+
+func TestFirecrawl_getHeaders(t *testing.T) {
+	f := &Firecrawl{
+		auth: &Auth{
+			Username: "helix",
+			Password: "secret",
+			Cookies: []*http.Cookie{
+				{Name: "a", Value: "1"},
+				{Name: "b", Value: "2"},
+			},
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"Authorization": "Basic aGVsaXg6c2VjcmV0",
+		"Cookie":        "a=1; b=2",
+	}, f.getHeaders())
+
+	assert.Empty(t, (&Firecrawl{}).getHeaders())
+}
@@ -14,19 +14,19 @@ type Crawler interface {
 	Crawl(ctx context.Context) ([]*types.CrawledDocument, error)
 }
 
-func NewCrawler(browserPool *browser.Browser, k *types.Knowledge) (Crawler, error) {
+func NewCrawler(browserPool *browser.Browser, k *types.Knowledge, auth *Auth) (Crawler, error) {
 	switch {
 	case k.Source.Web.Crawler.Firecrawl != nil:
 		log.Info().
 			Str("knowledge_id", k.ID).
 			Str("knowledge_name", k.Name).
 			Msgf("Using firecrawl crawler")
-		return NewFirecrawl(k)
+		return NewFirecrawl(k, auth)
 	default:
 		log.Info().
 			Str("knowledge_id", k.ID).
 			Str("knowledge_name", k.Name).
 			Msgf("Using default Helix crawler")
-		return NewDefault(browserPool, k)
+		return NewDefault(browserPool, k, auth)
 	}
 }
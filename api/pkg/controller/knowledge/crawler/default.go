@@ -2,9 +2,11 @@ package crawler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/cookiejar"
 	"net/url"
-	"regexp"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -24,6 +26,7 @@ const (
 	defaultMaxDepth    = 10  // How deep to crawl the website
 	defaultMaxPages    = 500 // How many pages to crawl before stopping
 	defaultParallelism = 5   // How many pages to crawl in parallel
+	fetchesPerPage     = 10  // How many pages can be fetched per crawled page, including the pages only fetched for links
 	defaultUserAgent   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
 )
 
@@ -35,15 +38,21 @@ type Default struct {
 	converter *md.Converter
 	parser    readability.Parser
 
-	browser *browser.Browser
+	browser    *browser.Browser
+	httpClient *http.Client // Sitemaps and robots.txt client
+
+	auth    *Auth
+	session *browser.Session // Isolated browser session of authenticated crawls
 }
 
-func NewDefault(browser *browser.Browser, k *types.Knowledge) (*Default, error) {
+func NewDefault(browser *browser.Browser, k *types.Knowledge, auth *Auth) (*Default, error) {
 	crawler := &Default{
-		knowledge: k,
-		converter: md.NewConverter("", true, nil),
-		parser:    readability.NewParser(),
-		browser:   browser,
+		knowledge:  k,
+		converter:  md.NewConverter("", true, nil),
+		parser:     readability.NewParser(),
+		browser:    browser,
+		httpClient: http.DefaultClient,
+		auth:       auth,
 	}
 
 	return crawler, nil
@@ -56,14 +65,19 @@ func (d *Default) Crawl(ctx context.Context) ([]*types.CrawledDocument, error) {
 		if err != nil {
 			return nil, err
 		}
-		domains = append(domains, parsedURL.Host)
+		// Matched against the host name without the port
+		domains = append(domains, parsedURL.Hostname())
+	}
+
+	filter, err := newURLFilter(d.knowledge.Source.Web)
+	if err != nil {
+		return nil, err
 	}
 
 	var (
-		maxPages    int32
-		maxDepth    int
-		userAgent   string
-		pageCounter atomic.Int32
+		maxPages  int32
+		maxDepth  int
+		userAgent string
 	)
 
 	if d.knowledge.Source.Web.Crawler.MaxDepth == 0 {
@@ -92,19 +106,20 @@ func (d *Default) Crawl(ctx context.Context) ([]*types.CrawledDocument, error) {
 		}
 	}
 
-	pageCounter.Store(0)
+	budget := newCrawlBudget(maxPages)
 
 	collyOptions := []colly.CollectorOption{
 		colly.AllowedDomains(domains...),
 		colly.UserAgent(userAgent),
 		colly.MaxDepth(maxDepth), // Limit crawl depth to avoid infinite crawling
-		colly.IgnoreRobotsTxt(),
 	}
 
-	if len(d.knowledge.Source.Web.Excludes) > 0 {
-		// Create the regex for the excludes
-		excludesRegex := regexp.MustCompile(strings.Join(d.knowledge.Source.Web.Excludes, "|"))
-		collyOptions = append(collyOptions, colly.DisallowedURLFilters(excludesRegex))
+	if !d.knowledge.Source.Web.Crawler.RespectRobotsTxt {
+		collyOptions = append(collyOptions, colly.IgnoreRobotsTxt())
+	}
+
+	if len(filter.excludes) > 0 {
+		collyOptions = append(collyOptions, colly.DisallowedURLFilters(filter.excludes...))
 	}
 
 	collector := colly.NewCollector(collyOptions...)
@@ -114,11 +129,52 @@ func (d *Default) Crawl(ctx context.Context) ([]*types.CrawledDocument, error) {
 		return nil, fmt.Errorf("error getting browser: %w", err)
 	}
 
-	for _, domain := range domains {
-		collector.Limit(&colly.LimitRule{
-			DomainGlob:  fmt.Sprintf("*%s*", domain),
+	// Requests made outside of the browser use the cookies of the browser
+	// session, e.g. set by the login form
+	auth := d.auth
+
+	if d.auth.useSession() {
+		d.session, err = startSession(ctx, d.browser, b, d.auth, d.knowledge.Source.Web.URLs)
+		if err != nil {
+			return nil, fmt.Errorf("error starting browser session: %w", err)
+		}
+		defer func() {
+			_ = d.session.Close()
+			d.session = nil
+		}()
+
+		cookies, err := d.session.Cookies()
+		if err != nil {
+			return nil, err
+		}
+		auth = d.auth.withCookies(cookies)
+	}
+
+	err = setCollectorAuth(collector, auth, d.knowledge.Source.Web.URLs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range d.knowledge.Source.Web.URLs {
+		parsedURL, _ := url.Parse(u)
+
+		rule := &colly.LimitRule{
+			DomainGlob:  fmt.Sprintf("*%s*", parsedURL.Host),
 			Parallelism: defaultParallelism,
-		})
+		}
+
+		if d.knowledge.Source.Web.Crawler.RespectRobotsTxt {
+			delay := getCrawlDelay(ctx, d.httpClient, d.knowledge, auth, parsedURL.Scheme+"://"+parsedURL.Host, userAgent)
+			if delay > 0 {
+				rule.Delay = delay
+				rule.Parallelism = 1
+			}
+		}
+
+		err = collector.Limit(rule)
+		if err != nil {
+			return nil, fmt.Errorf("error setting crawl limits: %w", err)
+		}
 	}
 
 	var crawledDocs []*types.CrawledDocument
@@ -130,7 +186,7 @@ func (d *Default) Crawl(ctx context.Context) ([]*types.CrawledDocument, error) {
 			return
 		}
 
-		visited := pageCounter.Load()
+		visited := budget.pages.Load()
 
 		log.Info().
 			Str("knowledge_id", d.knowledge.ID).
@@ -138,6 +194,12 @@ func (d *Default) Crawl(ctx context.Context) ([]*types.CrawledDocument, error) {
 			Str("url", e.Request.URL.String()).Msg("visiting link")
 
 		visitedURLs[e.Request.URL.String()] = true
+		budget.fetches.Add(1)
+
+		// Only crawled for links
+		if !filter.included(e.Request.URL.String()) {
+			return
+		}
 
 		doc, err := d.crawlWithBrowser(ctx, b, e.Request.URL.String())
 		if err != nil {
@@ -155,20 +217,28 @@ func (d *Default) Crawl(ctx context.Context) ([]*types.CrawledDocument, error) {
 
 		crawledDocs = append(crawledDocs, doc)
 
-		pageCounter.Add(1)
+		budget.pages.Add(1)
 	})
 
-	// Add this new OnHTML callback to find and visit links
+	// Links are queued and followed once the source and sitemap pages are
+	// visited, so the max pages don't run out on the first links found
+	var links []string
+
+	queuedLinks := make(map[string]bool)
+
 	collector.OnHTML("a[href]", func(e *colly.HTMLElement) {
-		if pageCounter.Load() >= maxPages {
-			log.Warn().
-				Str("knowledge_id", d.knowledge.ID).
-				Msg("Max pages reached")
+		// No more pages will be fetched, stop growing the queue
+		if budget.exhausted() {
+			return
+		}
+
+		link := e.Request.AbsoluteURL(e.Attr("href"))
+		if link == "" || queuedLinks[link] {
 			return
 		}
 
-		link := e.Attr("href")
-		collector.Visit(e.Request.AbsoluteURL(link))
+		queuedLinks[link] = true
+		links = append(links, link)
 	})
 
 	collector.OnRequest(func(r *colly.Request) {
@@ -191,26 +261,164 @@ func (d *Default) Crawl(ctx context.Context) ([]*types.CrawledDocument, error) {
 		}
 	}
 
+	if d.knowledge.Source.Web.Crawler.Enabled && d.knowledge.Source.Web.Crawler.Sitemap {
+		d.visitSitemap(ctx, collector, auth, budget)
+	}
+
+	// Follow the links breadth first
+	for len(links) > 0 {
+		if budget.exhausted() {
+			log.Warn().
+				Str("knowledge_id", d.knowledge.ID).
+				Int32("pages", budget.pages.Load()).
+				Int32("fetched_pages", budget.fetches.Load()).
+				Msg("Max pages reached")
+			break
+		}
+
+		link := links[0]
+		links = links[1:]
+
+		err := collector.Visit(link)
+		if err != nil && !errors.Is(err, colly.ErrAlreadyVisited) {
+			log.Debug().Err(err).Str("url", link).Msg("Error visiting URL")
+		}
+	}
+
 	log.Info().
 		Str("knowledge_id", d.knowledge.ID).
 		Str("knowledge_name", d.knowledge.Name).
 		Str("url", d.knowledge.Source.Web.URLs[0]).
 		Str("domains", strings.Join(domains, ",")).
-		Int32("pages_crawled", pageCounter.Load()).
+		Int32("pages_crawled", budget.pages.Load()).
+		Int32("pages_fetched", budget.fetches.Load()).
 		Msg("finished crawling the website")
 
 	return crawledDocs, nil
 }
 
+// visitSitemap visits the sitemap pages before the links are followed, the
+// pages already visited are skipped
+func (d *Default) visitSitemap(ctx context.Context, collector *colly.Collector, auth *Auth, budget *crawlBudget) {
+	entries, err := GetSitemapEntries(ctx, d.httpClient, d.knowledge, auth)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("knowledge_id", d.knowledge.ID).
+			Msg("failed to get sitemap, crawling links only")
+		return
+	}
+
+	log.Info().
+		Str("knowledge_id", d.knowledge.ID).
+		Int("sitemap_pages", len(entries)).
+		Msg("visiting sitemap pages")
+
+	for _, entry := range entries {
+		if budget.exhausted() {
+			log.Warn().
+				Str("knowledge_id", d.knowledge.ID).
+				Msg("Max pages reached")
+			return
+		}
+
+		err := collector.Visit(entry.URL)
+		if err != nil && !errors.Is(err, colly.ErrAlreadyVisited) {
+			log.Debug().Err(err).Str("url", entry.URL).Msg("Error visiting sitemap URL")
+		}
+	}
+}
+
+// crawlBudget limits the crawled pages and the fetched pages. Pages that
+// aren't included are fetched for their links without being crawled, they
+// count towards the fetched pages only.
+type crawlBudget struct {
+	maxPages   int32
+	maxFetches int32
+	pages      atomic.Int32
+	fetches    atomic.Int32
+}
+
+func newCrawlBudget(maxPages int32) *crawlBudget {
+	return &crawlBudget{
+		maxPages:   maxPages,
+		maxFetches: maxPages * fetchesPerPage,
+	}
+}
+
+// exhausted returns true once enough pages were crawled or fetched
+func (b *crawlBudget) exhausted() bool {
+	return b.pages.Load() >= b.maxPages || b.fetches.Load() >= b.maxFetches
+}
+
+// setCollectorAuth sends the auth headers and cookies with the collector
+// requests to the source hosts
+func setCollectorAuth(collector *colly.Collector, auth *Auth, urls []string) error {
+	headers := auth.GetHeaders()
+	if len(headers) > 0 {
+		collector.OnRequest(func(r *colly.Request) {
+			if !auth.allowed(r.URL.Hostname()) {
+				return
+			}
+			for k, v := range headers {
+				r.Headers.Set(k, v)
+			}
+		})
+	}
+
+	cookies := auth.getCookies()
+	if len(cookies) == 0 {
+		return nil
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return fmt.Errorf("error creating cookie jar: %w", err)
+	}
+
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return err
+		}
+
+		var matching []*http.Cookie
+		for _, c := range cookies {
+			if auth.cookieMatches(c, parsed.Hostname()) {
+				matching = append(matching, c)
+			}
+		}
+
+		jar.SetCookies(parsed, matching)
+	}
+
+	collector.SetCookieJar(jar)
+
+	return nil
+}
+
 func (d *Default) crawlWithBrowser(ctx context.Context, b *rod.Browser, url string) (*types.CrawledDocument, error) {
 
 	log.Info().Str("url", url).Msg("crawling with browser")
 
-	page, err := d.browser.GetPage(b, proto.TargetCreateTarget{URL: url})
-	if err != nil {
-		return nil, fmt.Errorf("error getting page for %s: %w", url, err)
+	var (
+		page *rod.Page
+		err  error
+	)
+
+	if d.session != nil {
+		page, err = d.session.GetPage(proto.TargetCreateTarget{URL: url})
+		if err != nil {
+			return nil, fmt.Errorf("error getting page for %s: %w", url, err)
+		}
+		defer d.session.PutPage(page)
+	} else {
+		page, err = d.browser.GetPage(b, proto.TargetCreateTarget{URL: url})
+		if err != nil {
+			return nil, fmt.Errorf("error getting page for %s: %w", url, err)
+		}
+		defer d.browser.PutPage(page)
 	}
-	defer d.browser.PutPage(page)
 
 	if d.knowledge.Source.Web.Crawler.UserAgent != "" {
 		page.SetUserAgent(&proto.NetworkSetUserAgentOverride{
@@ -2,8 +2,13 @@ package crawler
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/helixml/helix/api/pkg/config"
@@ -33,7 +38,7 @@ func TestDefault_Crawl(t *testing.T) {
 	browserManager, err := browser.New(&cfg)
 	require.NoError(t, err)
 
-	d, err := NewDefault(browserManager, k)
+	d, err := NewDefault(browserManager, k, nil)
 	require.NoError(t, err)
 
 	docs, err := d.Crawl(context.Background())
@@ -89,7 +94,7 @@ func TestDefault_CrawlSingle(t *testing.T) {
 	browserManager, err := browser.New(&cfg)
 	require.NoError(t, err)
 
-	d, err := NewDefault(browserManager, k)
+	d, err := NewDefault(browserManager, k, nil)
 	require.NoError(t, err)
 
 	docs, err := d.Crawl(context.Background())
@@ -115,7 +120,7 @@ func TestDefault_ParseWithCodeBlock_WithReadability(t *testing.T) {
 	browserManager, err := browser.New(&cfg)
 	require.NoError(t, err)
 
-	d, err := NewDefault(browserManager, k)
+	d, err := NewDefault(browserManager, k, nil)
 	require.NoError(t, err)
 
 	content, err := os.ReadFile("../readability/testdata/example_code_block.html")
@@ -149,7 +154,7 @@ func TestDefault_ConvertHTMLToMarkdown(t *testing.T) {
 	browserManager, err := browser.New(&cfg)
 	require.NoError(t, err)
 
-	d, err := NewDefault(browserManager, k)
+	d, err := NewDefault(browserManager, k, nil)
 	require.NoError(t, err)
 
 	ctx := context.Background()
@@ -162,3 +167,60 @@ func TestDefault_ConvertHTMLToMarkdown(t *testing.T) {
 
 	assert.True(t, strings.Contains(doc.Content, "Target Austin UT Campus") || strings.Contains(doc.Content, "This site uses cookies"))
 }
+
+func TestDefault_Crawl_IncludesMatchNothing(t *testing.T) {
+	var fetched atomic.Int32
+
+	// Every page links to two new pages, the crawl only ends on its budget
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched.Add(1)
+
+		id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/page/"))
+		fmt.Fprintf(w, `<html><body><p>Page %d</p><a href="/page/%d">next</a><a href="/page/%d">other</a></body></html>`, id, 2*id+1, 2*id+2)
+	}))
+	defer ts.Close()
+
+	k := &types.Knowledge{
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs: []string{ts.URL + "/page/0"},
+				Crawler: &types.WebsiteCrawler{
+					Enabled:  true,
+					MaxPages: 5,
+				},
+				// Only the seed page is included
+				Includes: []string{"/page/0$"},
+			},
+		},
+	}
+
+	cfg, err := config.LoadServerConfig()
+	require.NoError(t, err)
+
+	browserManager, err := browser.New(&cfg)
+	require.NoError(t, err)
+
+	d, err := NewDefault(browserManager, k, nil)
+	require.NoError(t, err)
+
+	_, err = d.Crawl(context.Background())
+	require.NoError(t, err)
+
+	// The browser may fetch the seed page once more
+	assert.LessOrEqual(t, fetched.Load(), int32(5*fetchesPerPage+1))
+}
+
+func Test_crawlBudget(t *testing.T) {
+	budget := newCrawlBudget(2)
+	assert.False(t, budget.exhausted())
+
+	// Pages only fetched for their links
+	budget.fetches.Add(2*fetchesPerPage - 1)
+	assert.False(t, budget.exhausted())
+	budget.fetches.Add(1)
+	assert.True(t, budget.exhausted())
+
+	budget = newCrawlBudget(2)
+	budget.pages.Add(2)
+	assert.True(t, budget.exhausted())
+}
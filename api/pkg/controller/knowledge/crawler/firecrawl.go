@@ -3,6 +3,7 @@ package crawler
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/mendableai/firecrawl-go"
 	"github.com/rs/zerolog/log"
@@ -11,11 +12,15 @@ import (
 	"github.com/helixml/helix/api/pkg/types"
 )
 
-func NewFirecrawl(k *types.Knowledge) (*Firecrawl, error) {
+func NewFirecrawl(k *types.Knowledge, auth *Auth) (*Firecrawl, error) {
 	if k.Source.Web == nil || k.Source.Web.Crawler == nil || k.Source.Web.Crawler.Firecrawl == nil {
 		return nil, fmt.Errorf("firecrawl is not configured for this knowledge")
 	}
 
+	if auth != nil && auth.FormLogin != nil {
+		return nil, fmt.Errorf("form login is not supported with firecrawl")
+	}
+
 	var (
 		apiKey = k.Source.Web.Crawler.Firecrawl.APIKey
 		apiUrl = k.Source.Web.Crawler.Firecrawl.APIURL
@@ -29,12 +34,14 @@ func NewFirecrawl(k *types.Knowledge) (*Firecrawl, error) {
 	return &Firecrawl{
 		app:       app,
 		knowledge: k,
+		auth:      auth,
 	}, nil
 }
 
 type Firecrawl struct {
 	app       *firecrawl.FirecrawlApp
 	knowledge *types.Knowledge
+	auth      *Auth
 }
 
 func (f *Firecrawl) Crawl(ctx context.Context) ([]*types.CrawledDocument, error) {
@@ -44,6 +51,12 @@ func (f *Firecrawl) Crawl(ctx context.Context) ([]*types.CrawledDocument, error)
 		},
 	}
 
+	if headers := f.getHeaders(); len(headers) > 0 {
+		crawlParams["pageOptions"] = map[string]any{
+			"headers": headers,
+		}
+	}
+
 	idempotencyKey := system.GenerateUUID()
 
 	log.Info().
@@ -82,3 +95,23 @@ func (f *Firecrawl) Crawl(ctx context.Context) ([]*types.CrawledDocument, error)
 
 	return crawledDocs, nil
 }
+
+// getHeaders returns the auth headers, firecrawl fetches the pages so
+// cookies are sent in the Cookie header
+func (f *Firecrawl) getHeaders() map[string]string {
+	headers := f.auth.GetHeaders()
+
+	var cookies []string
+	for _, c := range f.auth.getCookies() {
+		cookies = append(cookies, c.Name+"="+c.Value)
+	}
+
+	if len(cookies) > 0 {
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers["Cookie"] = strings.Join(cookies, "; ")
+	}
+
+	return headers
+}
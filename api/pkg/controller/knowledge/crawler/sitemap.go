@@ -0,0 +1,353 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/temoto/robotstxt"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const (
+	maxSitemapDepth = 3                // How many levels of sitemap indexes to follow
+	maxSitemapSize  = 50 * 1024 * 1024 // Sitemaps are limited to 50MB uncompressed
+)
+
+// SitemapEntry is a page listed in a sitemap
+type SitemapEntry struct {
+	URL          string
+	LastModified time.Time // Zero if the sitemap doesn't have lastmod
+}
+
+type sitemapURLSet struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+}
+
+type sitemapIndex struct {
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// GetSitemapEntries lists the pages of the knowledge web source sitemaps
+// that are allowed by the domain, include and exclude filters
+func GetSitemapEntries(ctx context.Context, client *http.Client, k *types.Knowledge, auth *Auth) ([]*SitemapEntry, error) {
+	filter, err := newURLFilter(k.Source.Web)
+	if err != nil {
+		return nil, err
+	}
+
+	sitemapURLs := k.Source.Web.Crawler.SitemapURLs
+	if len(sitemapURLs) == 0 {
+		sitemapURLs = discoverSitemaps(ctx, client, k, auth)
+	}
+
+	s := &sitemapReader{
+		client:    client,
+		knowledge: k,
+		auth:      auth,
+		visited:   make(map[string]bool),
+		seen:      make(map[string]bool),
+	}
+
+	for _, u := range sitemapURLs {
+		err := s.read(ctx, u, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []*SitemapEntry
+
+	for _, entry := range s.entries {
+		if filter.allowed(entry.URL) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+type sitemapReader struct {
+	client    *http.Client
+	knowledge *types.Knowledge
+	auth      *Auth
+	visited   map[string]bool // Sitemap URLs
+	seen      map[string]bool // Page URLs
+	entries   []*SitemapEntry
+}
+
+func (s *sitemapReader) read(ctx context.Context, sitemapURL string, depth int) error {
+	if depth > maxSitemapDepth || s.visited[sitemapURL] {
+		return nil
+	}
+	s.visited[sitemapURL] = true
+
+	bts, err := s.get(ctx, sitemapURL)
+	if err != nil {
+		return fmt.Errorf("failed to get sitemap %s: %w", sitemapURL, err)
+	}
+
+	root, err := getXMLRootElement(bts)
+	if err != nil {
+		return fmt.Errorf("failed to parse sitemap %s: %w", sitemapURL, err)
+	}
+
+	switch root {
+	case "sitemapindex":
+		var index sitemapIndex
+		if err := xml.Unmarshal(bts, &index); err != nil {
+			return fmt.Errorf("failed to parse sitemap index %s: %w", sitemapURL, err)
+		}
+
+		for _, sitemap := range index.Sitemaps {
+			err := s.read(ctx, strings.TrimSpace(sitemap.Loc), depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	case "urlset":
+		var set sitemapURLSet
+		if err := xml.Unmarshal(bts, &set); err != nil {
+			return fmt.Errorf("failed to parse sitemap %s: %w", sitemapURL, err)
+		}
+
+		for _, u := range set.URLs {
+			loc := strings.TrimSpace(u.Loc)
+			if loc == "" || s.seen[loc] {
+				continue
+			}
+			s.seen[loc] = true
+
+			s.entries = append(s.entries, &SitemapEntry{
+				URL:          loc,
+				LastModified: parseLastMod(u.LastMod),
+			})
+		}
+	default:
+		return fmt.Errorf("unexpected sitemap %s root element '%s'", sitemapURL, root)
+	}
+
+	return nil
+}
+
+func (s *sitemapReader) get(ctx context.Context, u string) ([]byte, error) {
+	resp, err := getURL(ctx, s.client, s.knowledge, s.auth, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	bts, err := io.ReadAll(io.LimitReader(resp.Body, maxSitemapSize))
+	if err != nil {
+		return nil, err
+	}
+
+	// sitemap.xml.gz, the transport only decompresses Content-Encoding gzip
+	if len(bts) > 2 && bts[0] == 0x1f && bts[1] == 0x8b {
+		gz, err := gzip.NewReader(bytes.NewReader(bts))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		return io.ReadAll(io.LimitReader(gz, maxSitemapSize))
+	}
+
+	return bts, nil
+}
+
+func getXMLRootElement(bts []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(bts))
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func parseLastMod(lastMod string) time.Time {
+	lastMod = strings.TrimSpace(lastMod)
+
+	// W3C datetime, from the full timestamp down to the date
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		t, err := time.Parse(layout, lastMod)
+		if err == nil {
+			return t.UTC()
+		}
+	}
+
+	return time.Time{}
+}
+
+// discoverSitemaps returns the sitemaps listed in the robots.txt of the
+// source URLs hosts, or their /sitemap.xml
+func discoverSitemaps(ctx context.Context, client *http.Client, k *types.Knowledge, auth *Auth) []string {
+	var (
+		result []string
+		hosts  = make(map[string]bool)
+	)
+
+	for _, u := range k.Source.Web.URLs {
+		parsed, err := url.Parse(u)
+		if err != nil || hosts[parsed.Host] {
+			continue
+		}
+		hosts[parsed.Host] = true
+
+		base := parsed.Scheme + "://" + parsed.Host
+
+		robots, err := getRobots(ctx, client, k, auth, base)
+		if err != nil {
+			log.Debug().Err(err).Str("url", base).Msg("failed to get robots.txt")
+		}
+
+		if robots != nil && len(robots.Sitemaps) > 0 {
+			result = append(result, robots.Sitemaps...)
+			continue
+		}
+
+		result = append(result, base+"/sitemap.xml")
+	}
+
+	return result
+}
+
+// getRobots fetches the robots.txt of the site, missing robots.txt
+// allows everything
+func getRobots(ctx context.Context, client *http.Client, k *types.Knowledge, auth *Auth, base string) (*robotstxt.RobotsData, error) {
+	resp, err := getURL(ctx, client, k, auth, base+"/robots.txt")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return robotstxt.FromResponse(resp)
+}
+
+// getCrawlDelay returns the robots.txt crawl delay of the site for the
+// user agent
+func getCrawlDelay(ctx context.Context, client *http.Client, k *types.Knowledge, auth *Auth, base, userAgent string) time.Duration {
+	robots, err := getRobots(ctx, client, k, auth, base)
+	if err != nil {
+		log.Debug().Err(err).Str("url", base).Msg("failed to get robots.txt")
+		return 0
+	}
+
+	return robots.FindGroup(userAgent).CrawlDelay
+}
+
+func getURL(ctx context.Context, client *http.Client, k *types.Knowledge, auth *Auth, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", getUserAgent(k))
+
+	// Sitemaps can list other hosts, they don't get the credentials
+	return auth.Do(client, req)
+}
+
+func getUserAgent(k *types.Knowledge) string {
+	if k.Source.Web.Crawler != nil && k.Source.Web.Crawler.UserAgent != "" {
+		return k.Source.Web.Crawler.UserAgent
+	}
+	return defaultUserAgent
+}
+
+// urlFilter matches page URLs against the source domains and the include
+// and exclude regexes
+type urlFilter struct {
+	domains  map[string]bool
+	includes []*regexp.Regexp
+	excludes []*regexp.Regexp
+}
+
+func newURLFilter(source *types.KnowledgeSourceWeb) (*urlFilter, error) {
+	f := &urlFilter{
+		domains: make(map[string]bool),
+	}
+
+	for _, u := range source.URLs {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, err
+		}
+		f.domains[parsed.Host] = true
+	}
+
+	for _, pattern := range source.Includes {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include regex '%s': %w", pattern, err)
+		}
+		f.includes = append(f.includes, re)
+	}
+
+	for _, pattern := range source.Excludes {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude regex '%s': %w", pattern, err)
+		}
+		f.excludes = append(f.excludes, re)
+	}
+
+	return f, nil
+}
+
+// allowed returns true if the page should be crawled and indexed
+func (f *urlFilter) allowed(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || !f.domains[parsed.Host] {
+		return false
+	}
+
+	for _, re := range f.excludes {
+		if re.MatchString(u) {
+			return false
+		}
+	}
+
+	return f.included(u)
+}
+
+// included returns true if the page matches the includes, pages that
+// don't are still crawled for links
+func (f *urlFilter) included(u string) bool {
+	if len(f.includes) == 0 {
+		return true
+	}
+
+	for _, re := range f.includes {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+
+	return false
+}
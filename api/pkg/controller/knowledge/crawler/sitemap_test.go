@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func newSitemapServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+
+	gzipped := func(s string) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte(s))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		return buf.Bytes()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\nCrawl-delay: 2\n\nSitemap: " + server.URL + "/sitemap_index.xml\n"))
+	})
+	mux.HandleFunc("/sitemap_index.xml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>` + server.URL + `/sitemap_docs.xml</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/sitemap_blog.xml.gz</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/sitemap_index.xml</loc></sitemap>
+</sitemapindex>`))
+	})
+	mux.HandleFunc("/sitemap_docs.xml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>` + server.URL + `/docs/install</loc><lastmod>2024-05-01</lastmod></url>
+  <url><loc>` + server.URL + `/docs/usage</loc><lastmod>2024-05-02T10:30:00+02:00</lastmod></url>
+  <url><loc>` + server.URL + `/docs/draft</loc></url>
+  <url><loc>https://other.example.com/docs/install</loc></url>
+</urlset>`))
+	})
+	mux.HandleFunc("/sitemap_blog.xml.gz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(gzipped(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>` + server.URL + `/blog/release</loc><lastmod>2024-06-01T00:00:00Z</lastmod></url>
+  <url><loc>` + server.URL + `/docs/install</loc></url>
+</urlset>`))
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestGetSitemapEntries(t *testing.T) {
+	server := newSitemapServer(t)
+
+	k := &types.Knowledge{
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs:     []string{server.URL + "/docs"},
+				Excludes: []string{"/draft$"},
+				Crawler: &types.WebsiteCrawler{
+					Enabled: true,
+					Sitemap: true,
+				},
+			},
+		},
+	}
+
+	// Discovered from robots.txt
+	entries, err := GetSitemapEntries(context.Background(), http.DefaultClient, k, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*SitemapEntry{
+		{URL: server.URL + "/docs/install", LastModified: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{URL: server.URL + "/docs/usage", LastModified: time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)},
+		{URL: server.URL + "/blog/release", LastModified: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}, entries)
+
+	// Explicit sitemap, filtered by includes
+	k.Source.Web.Includes = []string{"/docs/"}
+	k.Source.Web.Crawler.SitemapURLs = []string{server.URL + "/sitemap_docs.xml"}
+
+	entries, err = GetSitemapEntries(context.Background(), http.DefaultClient, k, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, server.URL+"/docs/install", entries[0].URL)
+	assert.Equal(t, server.URL+"/docs/usage", entries[1].URL)
+}
+
+func TestGetSitemapEntries_DefaultSitemap(t *testing.T) {
+	server := newSitemapServer(t)
+
+	k := &types.Knowledge{
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs:    []string{server.URL},
+				Crawler: &types.WebsiteCrawler{Enabled: true, Sitemap: true},
+			},
+		},
+	}
+
+	// No robots.txt sitemaps, /sitemap.xml doesn't exist
+	_, err := GetSitemapEntries(context.Background(), &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/robots.txt" {
+				return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Request: req}, nil
+			}
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	}, k, nil)
+	assert.ErrorContains(t, err, "/sitemap.xml")
+}
+
+func TestGetCrawlDelay(t *testing.T) {
+	server := newSitemapServer(t)
+
+	k := &types.Knowledge{
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{URLs: []string{server.URL}},
+		},
+	}
+
+	assert.Equal(t, 2*time.Second, getCrawlDelay(context.Background(), http.DefaultClient, k, nil, server.URL, defaultUserAgent))
+}
+
+func Test_parseLastMod(t *testing.T) {
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), parseLastMod(" 2024-05-01 "))
+	assert.Equal(t, time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC), parseLastMod("2024-05-01T10:00+01:00"))
+	assert.True(t, parseLastMod("yesterday").IsZero())
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
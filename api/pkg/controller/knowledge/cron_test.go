@@ -53,14 +53,14 @@ func (suite *CronSuite) SetupTest() {
 
 	b := &browser.Browser{}
 
-	suite.reconciler, err = New(suite.cfg, suite.store, suite.filestore, suite.extractor, suite.rag, b)
+	suite.reconciler, err = New(suite.cfg, suite.store, suite.filestore, suite.extractor, suite.rag, nil, b)
 	suite.Require().NoError(err)
 
 	suite.reconciler.newRagClient = func(settings *types.RAGSettings) rag.RAG {
 		return suite.rag
 	}
 
-	suite.reconciler.newCrawler = func(k *types.Knowledge) (crawler.Crawler, error) {
+	suite.reconciler.newCrawler = func(k *types.Knowledge, _ *crawler.Auth) (crawler.Crawler, error) {
 		return suite.crawler, nil
 	}
 }
@@ -3,6 +3,7 @@ package knowledge
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/helixml/helix/api/pkg/controller/knowledge/crawler"
 	"github.com/helixml/helix/api/pkg/extract"
 	"github.com/helixml/helix/api/pkg/filestore"
+	"github.com/helixml/helix/api/pkg/openai/manager"
 	"github.com/helixml/helix/api/pkg/rag"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/types"
@@ -22,6 +24,8 @@ import (
 
 type KnowledgeManager interface {
 	NextRun(ctx context.Context, knowledgeID string) (time.Time, error)
+	Export(ctx context.Context, k *types.Knowledge, w io.Writer, opts *ExportOptions) error
+	Import(ctx context.Context, archive io.Reader, opts *ImportOptions) (*types.Knowledge, error)
 }
 
 type Reconciler struct {
@@ -32,12 +36,20 @@ type Reconciler struct {
 	httpClient   *http.Client
 	ragClient    rag.RAG                                   // Default server RAG client
 	newRagClient func(settings *types.RAGSettings) rag.RAG // Custom RAG server client constructor
-	newCrawler   func(k *types.Knowledge) (crawler.Crawler, error)
-	cron         gocron.Scheduler
-	wg           sync.WaitGroup
+	// Embeddings of the semantic splitter
+	providerManager manager.ProviderManager
+	newCrawler      func(k *types.Knowledge, auth *crawler.Auth) (crawler.Crawler, error)
+	browser         *browser.Browser // Form login of direct downloads
+	// S3 or GCS client constructor, secrets are the app secrets by name
+	newBucketClient func(ctx context.Context, k *types.Knowledge, secrets map[string]string) (bucketClient, error)
+	githubURL       string // GitHub base URL repositories are cloned from
+	cron            gocron.Scheduler
+	watchers        map[string]*localWatcher // Local source watchers by knowledge ID
+	watchersMu      sync.Mutex
+	wg              sync.WaitGroup
 }
 
-func New(config *config.ServerConfig, store store.Store, filestore filestore.FileStore, extractor extract.Extractor, ragClient rag.RAG, b *browser.Browser) (*Reconciler, error) {
+func New(config *config.ServerConfig, store store.Store, filestore filestore.FileStore, extractor extract.Extractor, ragClient rag.RAG, providerManager manager.ProviderManager, b *browser.Browser) (*Reconciler, error) {
 	s, err := gocron.NewScheduler()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create scheduler: %w", err)
@@ -54,9 +66,14 @@ func New(config *config.ServerConfig, store store.Store, filestore filestore.Fil
 		newRagClient: func(settings *types.RAGSettings) rag.RAG {
 			return rag.NewLlamaindex(settings)
 		},
-		newCrawler: func(k *types.Knowledge) (crawler.Crawler, error) {
-			return crawler.NewCrawler(b, k)
+		newCrawler: func(k *types.Knowledge, auth *crawler.Auth) (crawler.Crawler, error) {
+			return crawler.NewCrawler(b, k, auth)
 		},
+		providerManager: providerManager,
+		browser:         b,
+		newBucketClient: newBucketClient,
+		githubURL:       githubURL,
+		watchers:        make(map[string]*localWatcher),
 	}, nil
 }
 
@@ -85,6 +102,11 @@ func (r *Reconciler) Start(ctx context.Context) error {
 		r.runCronManager(ctx)
 	}()
 
+	wg.Add(1)
+	go func() {
+		r.runLocalWatchers(ctx)
+	}()
+
 	wg.Wait()
 
 	return nil
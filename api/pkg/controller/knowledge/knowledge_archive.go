@@ -0,0 +1,563 @@
+package knowledge
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/rag"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// archiveFormatVersion is increased on incompatible changes of the archive
+const archiveFormatVersion = 1
+
+// Files of the archive, in the order they are written
+const (
+	archiveManifestFile  = "manifest.json"
+	archiveKnowledgeFile = "knowledge.json"
+	archiveDocumentsFile = "documents.json"
+	archiveChunksFile    = "chunks.jsonl"
+)
+
+// importBatchSize is the number of imported chunks indexed per request
+const importBatchSize = 100
+
+// maxArchiveLineSize is the maximum size of a chunk in the archive
+const maxArchiveLineSize = 64 * 1024 * 1024
+
+// ErrExportNotSupported is returned for knowledge indexed in a RAG backend
+// that can't list its chunks
+var ErrExportNotSupported = errors.New("the RAG backend of the knowledge doesn't support exports")
+
+// ExportOptions configure the knowledge export
+type ExportOptions struct {
+	// IncludeEmbeddings exports the chunk embeddings, the import keeps
+	// them if the target uses the same embeddings model
+	IncludeEmbeddings bool
+}
+
+// ImportOptions configure the knowledge import
+type ImportOptions struct {
+	Owner     string
+	OwnerType types.OwnerType
+	AppID     string // App of the imported knowledge, the exported app belongs to another instance
+	Name      string // Overrides the name of the exported knowledge
+}
+
+type archiveManifest struct {
+	FormatVersion    int       `json:"format_version"`
+	Exported         time.Time `json:"exported"`
+	KnowledgeID      string    `json:"knowledge_id"`
+	KnowledgeVersion string    `json:"knowledge_version"`
+	EmbeddingsModel  string    `json:"embeddings_model,omitempty"` // Set if the chunks have embeddings
+	Chunks           int       `json:"chunks"`
+}
+
+// archiveChunk is an indexed chunk of the extracted text, the data entity
+// and document group IDs belong to the source instance and aren't kept
+type archiveChunk struct {
+	Source        string            `json:"source"`
+	Filename      string            `json:"filename"`
+	DocumentID    string            `json:"document_id"`
+	ContentOffset int               `json:"content_offset"`
+	Content       string            `json:"content"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Embedding     []float32         `json:"embedding,omitempty"`
+}
+
+// Export writes the knowledge, its documents and the chunks of the current
+// version as a gzipped tarball
+func (r *Reconciler) Export(ctx context.Context, k *types.Knowledge, w io.Writer, opts *ExportOptions) error {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+
+	if k.State != types.KnowledgeStateReady {
+		return fmt.Errorf("knowledge is %s, only ready knowledge can be exported", k.State)
+	}
+
+	manifest := &archiveManifest{
+		FormatVersion:    archiveFormatVersion,
+		Exported:         time.Now().UTC(),
+		KnowledgeID:      k.ID,
+		KnowledgeVersion: k.Version,
+	}
+
+	var chunks []*types.SessionRAGIndexChunk
+
+	// Plain text knowledge isn't indexed
+	if k.Source.Content == nil {
+		exporter, ok := r.getRagClient(k).(rag.Exporter)
+		if !ok {
+			return ErrExportNotSupported
+		}
+
+		var err error
+
+		chunks, err = exporter.Export(ctx, &types.ExportIndexRequest{
+			DataEntityID:      k.GetDataEntityID(),
+			IncludeEmbeddings: opts.IncludeEmbeddings,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to export chunks: %w", err)
+		}
+
+		if opts.IncludeEmbeddings {
+			manifest.EmbeddingsModel = exporter.EmbeddingsModel()
+		}
+	}
+
+	manifest.Chunks = len(chunks)
+
+	documents, err := r.store.ListKnowledgeDocuments(ctx, k.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list knowledge documents: %w", err)
+	}
+
+	// The size of chunks.jsonl has to be known before it is written to the
+	// tarball, it is written to a temporary file instead of memory
+	chunksFile, err := writeArchiveChunks(chunks)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		chunksFile.Close()
+		os.Remove(chunksFile.Name())
+	}()
+
+	// Nothing is written to w until all the data is ready so the caller can
+	// still report the failures above
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, file := range []struct {
+		name string
+		v    interface{}
+	}{
+		{archiveManifestFile, manifest},
+		{archiveKnowledgeFile, exportedKnowledge(k)},
+		{archiveDocumentsFile, documents},
+	} {
+		bts, err := json.MarshalIndent(file.v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+
+		err = writeArchiveFile(tw, file.name, bytes.NewReader(bts), int64(len(bts)))
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := chunksFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read chunks: %w", err)
+	}
+
+	err = writeArchiveFile(tw, archiveChunksFile, chunksFile, info.Size())
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return gw.Close()
+}
+
+// writeArchiveChunks encodes the chunks as JSON lines to a temporary file,
+// returned rewound
+func writeArchiveChunks(chunks []*types.SessionRAGIndexChunk) (*os.File, error) {
+	f, err := os.CreateTemp("", "knowledge-export-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chunks file: %w", err)
+	}
+
+	bw := bufio.NewWriter(f)
+	encoder := json.NewEncoder(bw)
+
+	for _, chunk := range chunks {
+		err = encoder.Encode(&archiveChunk{
+			Source:        chunk.Source,
+			Filename:      chunk.Filename,
+			DocumentID:    chunk.DocumentID,
+			ContentOffset: chunk.ContentOffset,
+			Content:       chunk.Content,
+			Metadata:      chunk.Metadata,
+			Embedding:     chunk.Embedding,
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to write chunks: %w", err)
+	}
+
+	return f, nil
+}
+
+// exportedKnowledge returns a copy of the knowledge without the source
+// credentials, the importing instance has to set up its own
+func exportedKnowledge(k *types.Knowledge) *types.Knowledge {
+	exported := *k
+	exported.RedactCredentials()
+
+	// Firecrawl can't be used without its API key, crawl with the default crawler
+	if web := exported.Source.Web; web != nil && web.Crawler != nil && web.Crawler.Firecrawl != nil {
+		crawler := *web.Crawler
+		crawler.Firecrawl = nil
+		web.Crawler = &crawler
+	}
+
+	return &exported
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, content io.Reader, size int64) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	_, err = io.Copy(tw, content)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// knowledgeImport is the state of an import while the archive is read
+type knowledgeImport struct {
+	manifest  *archiveManifest
+	knowledge *types.Knowledge
+	documents []*types.KnowledgeDocument
+	version   string
+	created   bool
+}
+
+// Import creates the knowledge of an export and indexes its chunks into the
+// RAG backend of the knowledge, the sources aren't fetched
+func (r *Reconciler) Import(ctx context.Context, archive io.Reader, opts *ImportOptions) (*types.Knowledge, error) {
+	gr, err := gzip.NewReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gr.Close()
+
+	imp := &knowledgeImport{
+		version: system.GenerateVersion(),
+	}
+
+	err = r.readArchive(ctx, tar.NewReader(gr), imp, opts)
+	if err == nil && !imp.created {
+		err = r.createImportedKnowledge(ctx, imp, opts)
+	}
+	if err == nil {
+		err = r.completeImport(ctx, imp)
+	}
+	if err != nil {
+		if imp.created {
+			r.deleteImportedKnowledge(imp.knowledge, imp.version)
+		}
+		return nil, err
+	}
+
+	log.Info().
+		Str("knowledge_id", imp.knowledge.ID).
+		Str("exported_knowledge_id", imp.manifest.KnowledgeID).
+		Str("version", imp.version).
+		Int("chunks", imp.manifest.Chunks).
+		Msg("knowledge imported")
+
+	return imp.knowledge, nil
+}
+
+func (r *Reconciler) readArchive(ctx context.Context, tr *tar.Reader, imp *knowledgeImport, opts *ImportOptions) error {
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		switch header.Name {
+		case archiveManifestFile:
+			err = json.NewDecoder(tr).Decode(&imp.manifest)
+			if err != nil {
+				return fmt.Errorf("failed to decode %s: %w", header.Name, err)
+			}
+
+			if imp.manifest.FormatVersion != archiveFormatVersion {
+				return fmt.Errorf("unsupported archive format version %d", imp.manifest.FormatVersion)
+			}
+		case archiveKnowledgeFile:
+			err = json.NewDecoder(tr).Decode(&imp.knowledge)
+		case archiveDocumentsFile:
+			err = json.NewDecoder(tr).Decode(&imp.documents)
+		case archiveChunksFile:
+			err = r.createImportedKnowledge(ctx, imp, opts)
+			if err != nil {
+				return err
+			}
+
+			err = r.importChunks(ctx, imp, tr)
+		default:
+			log.Warn().
+				Str("file", header.Name).
+				Msg("ignoring unknown file in knowledge archive")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", header.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) createImportedKnowledge(ctx context.Context, imp *knowledgeImport, opts *ImportOptions) error {
+	if imp.manifest == nil || imp.knowledge == nil {
+		return fmt.Errorf("archive is missing %s or %s", archiveManifestFile, archiveKnowledgeFile)
+	}
+
+	k := imp.knowledge
+
+	k.ID = ""
+	k.Owner = opts.Owner
+	k.OwnerType = opts.OwnerType
+	k.Version = ""
+	k.Versions = nil
+	k.Message = ""
+	k.ProgressPercent = 0
+	// Not picked up by the indexer while the chunks are imported
+	k.State = types.KnowledgeStateIndexing
+
+	// The app of the exported knowledge belongs to the exporting instance,
+	// the caller authorizes the app the knowledge is imported into
+	k.AppID = opts.AppID
+
+	if opts.Name != "" {
+		k.Name = opts.Name
+	}
+
+	err := r.validateImportedKnowledge(k)
+	if err != nil {
+		return err
+	}
+
+	created, err := r.store.CreateKnowledge(ctx, k)
+	if err != nil {
+		return fmt.Errorf("failed to create knowledge: %w", err)
+	}
+
+	imp.knowledge = created
+	imp.created = true
+
+	return nil
+}
+
+// validateImportedKnowledge applies the checks of knowledge created through
+// the API, the sources are fetched again when the knowledge is refreshed
+func (r *Reconciler) validateImportedKnowledge(k *types.Knowledge) error {
+	err := Validate(&types.AssistantKnowledge{
+		Name:            k.Name,
+		Description:     k.Description,
+		RAGSettings:     k.RAGSettings,
+		Source:          k.Source,
+		RefreshEnabled:  k.RefreshEnabled,
+		RefreshSchedule: k.RefreshSchedule,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid knowledge: %w", err)
+	}
+
+	if k.Source.Local != nil {
+		_, err = ResolveLocalPath(r.config.RAG.Local.AllowedRoots, k.Source.Local.Path)
+		if err != nil {
+			return fmt.Errorf("invalid knowledge: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) importChunks(ctx context.Context, imp *knowledgeImport, archive io.Reader) error {
+	k := imp.knowledge
+	ragClient := r.getRagClient(k)
+
+	// Embeddings of another model would be searched with the wrong vectors
+	keepEmbeddings := false
+	if exporter, ok := ragClient.(rag.Exporter); ok && imp.manifest.EmbeddingsModel != "" {
+		keepEmbeddings = exporter.EmbeddingsModel() == imp.manifest.EmbeddingsModel
+		if !keepEmbeddings {
+			log.Info().
+				Str("knowledge_id", k.ID).
+				Str("exported_model", imp.manifest.EmbeddingsModel).
+				Str("model", exporter.EmbeddingsModel()).
+				Msg("embeddings models differ, embedding the imported chunks again")
+		}
+	}
+
+	scanner := bufio.NewScanner(archive)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxArchiveLineSize)
+
+	var (
+		batch    []*types.SessionRAGIndexChunk
+		imported int
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := ragClient.Index(ctx, batch...)
+		if err != nil {
+			return fmt.Errorf("failed to index chunks: %w", err)
+		}
+
+		imported += len(batch)
+		batch = nil
+
+		if imp.manifest.Chunks > 0 {
+			_ = r.updateProgress(k, types.KnowledgeStateIndexing, "importing chunks", imported*100/imp.manifest.Chunks)
+		}
+
+		return nil
+	}
+
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var chunk archiveChunk
+
+		err := json.Unmarshal(scanner.Bytes(), &chunk)
+		if err != nil {
+			return fmt.Errorf("failed to decode chunk: %w", err)
+		}
+
+		indexChunk := &types.SessionRAGIndexChunk{
+			DataEntityID:    types.GetDataEntityID(k.ID, imp.version),
+			Source:          chunk.Source,
+			Filename:        chunk.Filename,
+			DocumentID:      chunk.DocumentID,
+			DocumentGroupID: k.ID,
+			ContentOffset:   chunk.ContentOffset,
+			Content:         chunk.Content,
+			Metadata:        chunk.Metadata,
+		}
+
+		if keepEmbeddings {
+			indexChunk.Embedding = chunk.Embedding
+		}
+
+		batch = append(batch, indexChunk)
+
+		if len(batch) >= importBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read chunks: %w", err)
+	}
+
+	return flush()
+}
+
+// completeImport makes the imported chunks the current version
+func (r *Reconciler) completeImport(ctx context.Context, imp *knowledgeImport) error {
+	k := imp.knowledge
+
+	k.State = types.KnowledgeStateReady
+	k.Message = ""
+	k.ProgressPercent = 0
+	k.Version = imp.version
+
+	_, err := r.store.UpdateKnowledge(ctx, k)
+	if err != nil {
+		return fmt.Errorf("failed to update knowledge: %w", err)
+	}
+
+	_, err = r.store.CreateKnowledgeVersion(ctx, &types.KnowledgeVersion{
+		KnowledgeID: k.ID,
+		Version:     imp.version,
+		Size:        k.Size,
+		State:       types.KnowledgeStateReady,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create knowledge version: %w", err)
+	}
+
+	// The next refresh only indexes the documents that changed since the export
+	for _, doc := range imp.documents {
+		doc.KnowledgeID = k.ID
+		doc.Version = imp.version
+	}
+
+	err = r.store.ReplaceKnowledgeDocuments(ctx, k.ID, imp.documents)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("knowledge_id", k.ID).
+			Msg("failed to save imported knowledge documents, the next refresh will index all documents")
+	}
+
+	return nil
+}
+
+// deleteImportedKnowledge removes a failed import so it can be retried
+func (r *Reconciler) deleteImportedKnowledge(k *types.Knowledge, version string) {
+	ctx := context.Background()
+
+	err := r.getRagClient(k).Delete(ctx, &types.DeleteIndexRequest{
+		DataEntityID: types.GetDataEntityID(k.ID, version),
+	})
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("knowledge_id", k.ID).
+			Msg("failed to delete the chunks of the failed import")
+	}
+
+	err = r.store.DeleteKnowledge(ctx, k.ID)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("knowledge_id", k.ID).
+			Msg("failed to delete the knowledge of the failed import")
+	}
+}
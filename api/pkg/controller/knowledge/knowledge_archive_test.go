@@ -0,0 +1,283 @@
+package knowledge
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/rag"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// exporterRAG is a RAG backend that supports exporting chunks
+type exporterRAG struct {
+	*rag.MockRAG
+	*rag.MockExporter
+}
+
+func (suite *IndexerSuite) exportKnowledge(ragClient *exporterRAG, includeEmbeddings bool) *bytes.Buffer {
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		Name:    "docs",
+		Owner:   "source_user",
+		AppID:   "source_app",
+		State:   types.KnowledgeStateReady,
+		Version: "v1",
+		RAGSettings: types.RAGSettings{
+			TextSplitter: types.TextSplitterTypeText,
+			ChunkSize:    2048,
+		},
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs: []string{"https://example.com"},
+				Auth: types.KnowledgeSourceWebAuth{
+					Username: "crawler",
+					Password: "hunter2",
+				},
+			},
+		},
+	}
+
+	req := &types.ExportIndexRequest{
+		DataEntityID:      "knowledge_id-v1",
+		IncludeEmbeddings: includeEmbeddings,
+	}
+
+	ragClient.MockExporter.EXPECT().Export(gomock.Any(), req).Return([]*types.SessionRAGIndexChunk{
+		{
+			DataEntityID:  "knowledge_id-v1",
+			Source:        "https://example.com/a",
+			DocumentID:    "doc_a",
+			ContentOffset: 0,
+			Content:       "first chunk",
+			Metadata:      map[string]string{"title": "A"},
+			Embedding:     []float32{0.1, 0.2},
+		},
+		{
+			DataEntityID:  "knowledge_id-v1",
+			Source:        "https://example.com/a",
+			DocumentID:    "doc_a",
+			ContentOffset: 11,
+			Content:       "second chunk",
+			Embedding:     []float32{0.3, 0.4},
+		},
+	}, nil)
+	if includeEmbeddings {
+		ragClient.MockExporter.EXPECT().EmbeddingsModel().Return("model-a")
+	}
+
+	suite.store.EXPECT().ListKnowledgeDocuments(gomock.Any(), "knowledge_id").Return([]*types.KnowledgeDocument{
+		{KnowledgeID: "knowledge_id", Version: "v1", Source: "https://example.com/a", DocumentID: "doc_a"},
+	}, nil)
+
+	var archive bytes.Buffer
+
+	err := suite.reconciler.Export(suite.ctx, knowledge, &archive, &ExportOptions{
+		IncludeEmbeddings: includeEmbeddings,
+	})
+	suite.Require().NoError(err)
+
+	return &archive
+}
+
+func (suite *IndexerSuite) expectImport(ragClient *exporterRAG, appID string) (*[]*types.SessionRAGIndexChunk, *[]*types.KnowledgeDocument) {
+	var (
+		indexed   []*types.SessionRAGIndexChunk
+		documents []*types.KnowledgeDocument
+	)
+
+	suite.store.EXPECT().CreateKnowledge(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, k *types.Knowledge) (*types.Knowledge, error) {
+			suite.Equal("target_user", k.Owner)
+			suite.Equal(appID, k.AppID)
+			suite.Equal("docs", k.Name)
+			suite.Empty(k.Source.Web.Auth.Password, "credentials are not exported")
+			suite.Equal(types.KnowledgeStateIndexing, k.State)
+			suite.Equal(2048, k.RAGSettings.ChunkSize)
+
+			k.ID = "imported_id"
+			return k, nil
+		},
+	)
+	suite.store.EXPECT().UpdateKnowledgeState(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	ragClient.MockRAG.EXPECT().Index(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, chunks ...*types.SessionRAGIndexChunk) error {
+			indexed = append(indexed, chunks...)
+			return nil
+		},
+	)
+
+	suite.store.EXPECT().UpdateKnowledge(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, k *types.Knowledge) (*types.Knowledge, error) {
+			suite.Equal(types.KnowledgeStateReady, k.State)
+			suite.NotEmpty(k.Version)
+			return k, nil
+		},
+	)
+	suite.store.EXPECT().CreateKnowledgeVersion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, v *types.KnowledgeVersion) (*types.KnowledgeVersion, error) {
+			suite.Equal("imported_id", v.KnowledgeID)
+			return v, nil
+		},
+	)
+	suite.store.EXPECT().ReplaceKnowledgeDocuments(gomock.Any(), "imported_id", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, docs []*types.KnowledgeDocument) error {
+			documents = docs
+			return nil
+		},
+	)
+
+	return &indexed, &documents
+}
+
+func (suite *IndexerSuite) newExporterRAG() *exporterRAG {
+	ragClient := &exporterRAG{
+		MockRAG:      suite.rag,
+		MockExporter: rag.NewMockExporter(gomock.NewController(suite.T())),
+	}
+	suite.reconciler.ragClient = ragClient
+
+	return ragClient
+}
+
+func (suite *IndexerSuite) Test_ExportImport() {
+	ragClient := suite.newExporterRAG()
+
+	archive := suite.exportKnowledge(ragClient, false)
+
+	indexed, documents := suite.expectImport(ragClient, "target_app")
+
+	imported, err := suite.reconciler.Import(suite.ctx, archive, &ImportOptions{
+		Owner:     "target_user",
+		OwnerType: types.OwnerTypeUser,
+		AppID:     "target_app",
+	})
+	suite.Require().NoError(err)
+
+	suite.Equal("imported_id", imported.ID)
+	suite.Equal(types.KnowledgeStateReady, imported.State)
+
+	suite.Require().Len(*indexed, 2)
+
+	for _, chunk := range *indexed {
+		suite.Equal(types.GetDataEntityID("imported_id", imported.Version), chunk.DataEntityID)
+		suite.Equal("imported_id", chunk.DocumentGroupID)
+		suite.Nil(chunk.Embedding)
+	}
+
+	suite.Equal("first chunk", (*indexed)[0].Content)
+	suite.Equal(map[string]string{"title": "A"}, (*indexed)[0].Metadata)
+	suite.Equal(11, (*indexed)[1].ContentOffset)
+
+	suite.Require().Len(*documents, 1)
+	suite.Equal("imported_id", (*documents)[0].KnowledgeID)
+	suite.Equal(imported.Version, (*documents)[0].Version)
+}
+
+func (suite *IndexerSuite) Test_ExportImport_Embeddings() {
+	ragClient := suite.newExporterRAG()
+
+	archive := suite.exportKnowledge(ragClient, true)
+
+	indexed, _ := suite.expectImport(ragClient, "target_app")
+
+	ragClient.MockExporter.EXPECT().EmbeddingsModel().Return("model-a").AnyTimes()
+
+	_, err := suite.reconciler.Import(suite.ctx, archive, &ImportOptions{
+		Owner:     "target_user",
+		OwnerType: types.OwnerTypeUser,
+		AppID:     "target_app",
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(*indexed, 2)
+	suite.Equal([]float32{0.1, 0.2}, (*indexed)[0].Embedding)
+}
+
+func (suite *IndexerSuite) Test_ExportImport_EmbeddingsModelMismatch() {
+	ragClient := suite.newExporterRAG()
+
+	archive := suite.exportKnowledge(ragClient, true)
+
+	indexed, _ := suite.expectImport(ragClient, "target_app")
+
+	// Embedded again by the target
+	ragClient.MockExporter.EXPECT().EmbeddingsModel().Return("model-b").AnyTimes()
+
+	_, err := suite.reconciler.Import(suite.ctx, archive, &ImportOptions{
+		Owner:     "target_user",
+		OwnerType: types.OwnerTypeUser,
+		AppID:     "target_app",
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(*indexed, 2)
+	suite.Nil((*indexed)[0].Embedding)
+}
+
+func (suite *IndexerSuite) Test_Export_NotSupported() {
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		State:   types.KnowledgeStateReady,
+		Version: "v1",
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs: []string{"https://example.com"},
+			},
+		},
+	}
+
+	err := suite.reconciler.Export(suite.ctx, knowledge, &bytes.Buffer{}, nil)
+	suite.ErrorIs(err, ErrExportNotSupported)
+}
+
+func (suite *IndexerSuite) Test_ExportImport_DropsExportedApp() {
+	ragClient := suite.newExporterRAG()
+
+	archive := suite.exportKnowledge(ragClient, false)
+
+	suite.expectImport(ragClient, "")
+
+	_, err := suite.reconciler.Import(suite.ctx, archive, &ImportOptions{
+		Owner:     "target_user",
+		OwnerType: types.OwnerTypeUser,
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *IndexerSuite) Test_Import_LocalOutsideAllowedRoots() {
+	suite.cfg.RAG.Local.AllowedRoots = []string{suite.T().TempDir()}
+
+	var archive bytes.Buffer
+
+	gw := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gw)
+
+	for name, v := range map[string]interface{}{
+		archiveManifestFile: &archiveManifest{FormatVersion: archiveFormatVersion},
+		archiveKnowledgeFile: &types.Knowledge{
+			Name: "etc",
+			Source: types.KnowledgeSource{
+				Local: &types.KnowledgeSourceLocal{Path: "/etc"},
+			},
+		},
+	} {
+		bts, err := json.Marshal(v)
+		suite.Require().NoError(err)
+		suite.Require().NoError(writeArchiveFile(tw, name, bytes.NewReader(bts), int64(len(bts))))
+	}
+	suite.Require().NoError(writeArchiveFile(tw, archiveChunksFile, &bytes.Buffer{}, 0))
+	suite.Require().NoError(tw.Close())
+	suite.Require().NoError(gw.Close())
+
+	_, err := suite.reconciler.Import(suite.ctx, &archive, &ImportOptions{
+		Owner:     "target_user",
+		OwnerType: types.OwnerTypeUser,
+	})
+	suite.Error(err)
+}
@@ -0,0 +1,422 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/extract"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// errSourceUnchanged is returned when the source contents didn't change
+// since the latest version was indexed
+var errSourceUnchanged = errors.New("source unchanged")
+
+// maxBucketObjectSize is the maximum size of an indexed bucket object
+const maxBucketObjectSize = 100 * 1024 * 1024
+
+// bucketObject is an object listed from an S3 or GCS bucket
+type bucketObject struct {
+	Key      string
+	ETag     string // ETag on S3, generation on GCS
+	Size     int64
+	Modified time.Time
+}
+
+// bucketClient lists and reads the objects of a single bucket
+type bucketClient interface {
+	List(ctx context.Context, prefix string) ([]*bucketObject, error)
+	Read(ctx context.Context, key string) ([]byte, error)
+}
+
+// bucketSource is the common configuration of the S3 and GCS sources
+type bucketSource struct {
+	Prefix   string
+	Includes []string
+	Excludes []string
+}
+
+func getBucketSource(k *types.Knowledge) (*bucketSource, error) {
+	switch {
+	case k.Source.S3 != nil:
+		return &bucketSource{
+			Prefix:   k.Source.S3.Path,
+			Includes: k.Source.S3.Includes,
+			Excludes: k.Source.S3.Excludes,
+		}, nil
+	case k.Source.GCS != nil:
+		return &bucketSource{
+			Prefix:   k.Source.GCS.Path,
+			Includes: k.Source.GCS.Includes,
+			Excludes: k.Source.GCS.Excludes,
+		}, nil
+	default:
+		return nil, fmt.Errorf("no bucket source defined")
+	}
+}
+
+func newBucketClient(ctx context.Context, k *types.Knowledge, secrets map[string]string) (bucketClient, error) {
+	switch {
+	case k.Source.S3 != nil:
+		return newS3BucketClient(k.Source.S3, secrets)
+	case k.Source.GCS != nil:
+		return newGCSBucketClient(ctx, k.Source.GCS, secrets)
+	default:
+		return nil, fmt.Errorf("no bucket source defined")
+	}
+}
+
+func (r *Reconciler) extractDataFromBucket(ctx context.Context, k *types.Knowledge) ([]*indexerData, error) {
+	source, err := getBucketSource(k)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newObjectFilter(source.Includes, source.Excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := r.getAppSecrets(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := r.newBucketClient(ctx, k, secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bucket client: %w", err)
+	}
+
+	objects, err := client.List(ctx, source.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	var filtered []*bucketObject
+
+	for _, obj := range objects {
+		// Skip "directory" placeholder objects
+		if strings.HasSuffix(obj.Key, "/") {
+			continue
+		}
+
+		if !filter.match(strings.TrimPrefix(strings.TrimPrefix(obj.Key, source.Prefix), "/")) {
+			continue
+		}
+
+		if obj.Size > maxBucketObjectSize {
+			log.Warn().
+				Str("knowledge_id", k.ID).
+				Str("key", obj.Key).
+				Int64("size", obj.Size).
+				Msg("skipping bucket object larger than the maximum size")
+			continue
+		}
+
+		filtered = append(filtered, obj)
+	}
+
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("no objects found in bucket matching the prefix and filters")
+	}
+
+	fingerprint, err := getBucketFingerprint(k, filtered)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing to do if none of the objects were added, removed or
+	// modified since the latest version
+	if k.Version != "" && k.SourceFingerprint == fingerprint {
+		return nil, errSourceUnchanged
+	}
+
+	log.Info().
+		Str("knowledge_id", k.ID).
+		Int("count", len(filtered)).
+		Msg("bucket objects found")
+
+	previous, err := r.getBucketDocuments(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result    []*indexerData
+		unchanged int
+	)
+
+	for _, obj := range filtered {
+		etag, err := getObjectETag(k, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		d := &indexerData{
+			Source: obj.Key,
+			ETag:   etag,
+		}
+
+		if !obj.Modified.IsZero() {
+			d.Metadata = map[string]string{
+				types.RAGMetadataLastModified: obj.Modified.UTC().Format(time.RFC3339),
+			}
+		}
+
+		// Objects with the same ETag as in the current version aren't
+		// downloaded and extracted again, their chunks are copied
+		if doc, ok := previous[obj.Key]; ok && etag != "" && doc.ETag == etag {
+			d.Unchanged = doc
+			d.read = func(ctx context.Context) ([]byte, error) {
+				return r.readBucketObject(ctx, k, client, obj)
+			}
+			result = append(result, d)
+			unchanged++
+			continue
+		}
+
+		d.Data, err = r.readBucketObject(ctx, k, client, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, d)
+	}
+
+	if unchanged > 0 {
+		log.Info().
+			Str("knowledge_id", k.ID).
+			Int("unchanged", unchanged).
+			Msg("skipped reading unchanged bucket objects")
+	}
+
+	k.SourceFingerprint = fingerprint
+
+	return result, nil
+}
+
+// readBucketObject downloads the object and extracts its text
+func (r *Reconciler) readBucketObject(ctx context.Context, k *types.Knowledge, client bucketClient, obj *bucketObject) ([]byte, error) {
+	bts, err := client.Read(ctx, obj.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s, error: %w", obj.Key, err)
+	}
+
+	// Optional mode to disable text extractor and chunking,
+	// useful when the indexing server will know how to handle
+	// raw data directly
+	if k.RAGSettings.DisableChunking {
+		return bts, nil
+	}
+
+	extracted, err := r.extractor.Extract(ctx, &extract.ExtractRequest{
+		Content: bts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract data from %s, error: %w", obj.Key, err)
+	}
+
+	return []byte(extracted), nil
+}
+
+// getBucketDocuments returns the documents of the current version by object
+// key, nil if there is no current version
+func (r *Reconciler) getBucketDocuments(ctx context.Context, k *types.Knowledge) (map[string]*types.KnowledgeDocument, error) {
+	if k.Version == "" {
+		return nil, nil
+	}
+
+	documents, err := r.store.ListKnowledgeDocuments(ctx, k.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list knowledge documents: %w", err)
+	}
+
+	result := make(map[string]*types.KnowledgeDocument, len(documents))
+	for _, doc := range documents {
+		if doc.Version == k.Version {
+			result[doc.Source] = doc
+		}
+	}
+
+	return result, nil
+}
+
+// getObjectETag hashes the ETag of the object with the RAG settings so that
+// settings changes extract and index the object again
+func getObjectETag(k *types.Knowledge, obj *bucketObject) (string, error) {
+	if obj.ETag == "" {
+		return "", nil
+	}
+
+	settings, err := json.Marshal(k.RAGSettings)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal rag settings: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write(settings)
+	fmt.Fprintf(hash, "\n%s", obj.ETag)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readObject reads an object up to maxBucketObjectSize
+func readObject(r io.Reader) ([]byte, error) {
+	bts, err := io.ReadAll(io.LimitReader(r, maxBucketObjectSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bts) > maxBucketObjectSize {
+		return nil, fmt.Errorf("object is larger than %d bytes", maxBucketObjectSize)
+	}
+
+	return bts, nil
+}
+
+// getAppSecrets returns the secrets of the knowledge owner that are
+// available to the knowledge app, by name
+func (r *Reconciler) getAppSecrets(ctx context.Context, k *types.Knowledge) (map[string]string, error) {
+	secrets, err := r.store.ListSecrets(ctx, &store.ListSecretsQuery{
+		Owner: k.Owner,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	result := make(map[string]string)
+
+	for _, secret := range secrets {
+		if secret.AppID != "" && secret.AppID != k.AppID {
+			continue
+		}
+		result[secret.Name] = string(secret.Value)
+	}
+
+	return result, nil
+}
+
+func getSecret(secrets map[string]string, name string) (string, error) {
+	value, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret '%s' not found", name)
+	}
+	return value, nil
+}
+
+// getBucketFingerprint identifies the listed objects by their keys and ETags
+func getBucketFingerprint(k *types.Knowledge, objects []*bucketObject) (string, error) {
+	entries := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		entries = append(entries, obj.Key+"\t"+obj.ETag)
+	}
+
+	sort.Strings(entries)
+
+	return getSourceFingerprint(k, entries)
+}
+
+// getSourceFingerprint hashes the source entries together with the source
+// and RAG settings so that configuration changes are picked up too
+func getSourceFingerprint(k *types.Knowledge, entries []string) (string, error) {
+	hash := sha256.New()
+
+	for _, v := range []any{k.Source, k.RAGSettings} {
+		bts, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal knowledge settings: %w", err)
+		}
+		hash.Write(bts)
+	}
+
+	for _, entry := range entries {
+		fmt.Fprintf(hash, "\n%s", entry)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// objectFilter matches object keys against include and exclude glob
+// patterns. Patterns without a slash are matched against the file name.
+type objectFilter struct {
+	includes []glob.Glob
+	excludes []glob.Glob
+}
+
+func newObjectFilter(includes, excludes []string) (*objectFilter, error) {
+	var (
+		f   objectFilter
+		err error
+	)
+
+	f.includes, err = compileGlobs(includes)
+	if err != nil {
+		return nil, err
+	}
+
+	f.excludes, err = compileGlobs(excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func compileGlobs(patterns []string) ([]glob.Glob, error) {
+	var result []glob.Glob
+
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+		result = append(result, globMatcher{pattern: pattern, glob: g})
+	}
+
+	return result, nil
+}
+
+type globMatcher struct {
+	pattern string
+	glob    glob.Glob
+}
+
+func (g globMatcher) Match(key string) bool {
+	if !strings.Contains(g.pattern, "/") {
+		return g.glob.Match(path.Base(key))
+	}
+	return g.glob.Match(key)
+}
+
+func (f *objectFilter) match(key string) bool {
+	for _, g := range f.excludes {
+		if g.Match(key) {
+			return false
+		}
+	}
+
+	if len(f.includes) == 0 {
+		return true
+	}
+
+	for _, g := range f.includes {
+		if g.Match(key) {
+			return true
+		}
+	}
+
+	return false
+}
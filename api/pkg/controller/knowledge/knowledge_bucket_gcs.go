@@ -0,0 +1,84 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type gcsBucketClient struct {
+	bucket *storage.BucketHandle
+}
+
+func newGCSBucketClient(ctx context.Context, source *types.KnowledgeSourceGCS, secrets map[string]string) (*gcsBucketClient, error) {
+	if source.Bucket == "" {
+		return nil, fmt.Errorf("gcs bucket is required")
+	}
+
+	var opts []option.ClientOption
+
+	if source.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(source.Endpoint))
+	}
+
+	if source.CredentialsSecret != "" {
+		credentials, err := getSecret(secrets, source.CredentialsSecret)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, option.WithCredentialsJSON([]byte(credentials)))
+	} else {
+		// Public bucket or emulators such as fake-gcs-server, the application
+		// default credentials of the server are never used
+		opts = append(opts, option.WithoutAuthentication())
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcs client: %w", err)
+	}
+
+	return &gcsBucketClient{
+		bucket: client.Bucket(source.Bucket),
+	}, nil
+}
+
+func (c *gcsBucketClient) List(ctx context.Context, prefix string) ([]*bucketObject, error) {
+	var result []*bucketObject
+
+	it := c.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &bucketObject{
+			Key:      attrs.Name,
+			ETag:     strconv.FormatInt(attrs.Generation, 10),
+			Size:     attrs.Size,
+			Modified: attrs.Updated,
+		})
+	}
+
+	return result, nil
+}
+
+func (c *gcsBucketClient) Read(ctx context.Context, key string) ([]byte, error) {
+	r, err := c.bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return readObject(r)
+}
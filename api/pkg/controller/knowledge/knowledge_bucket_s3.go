@@ -0,0 +1,114 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const defaultS3Endpoint = "s3.amazonaws.com"
+
+type s3BucketClient struct {
+	client *minio.Client
+	bucket string
+}
+
+func newS3BucketClient(source *types.KnowledgeSourceS3, secrets map[string]string) (*s3BucketClient, error) {
+	if source.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+
+	endpoint := source.Endpoint
+	secure := !source.Insecure
+
+	if endpoint == "" {
+		endpoint = defaultS3Endpoint
+	}
+
+	// Accept endpoints with a scheme too, e.g. http://localhost:9000
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3 endpoint '%s': %w", endpoint, err)
+		}
+		endpoint = u.Host
+		secure = u.Scheme == "https"
+	}
+
+	creds, err := getS3Credentials(source, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: secure,
+		Region: source.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	return &s3BucketClient{
+		client: client,
+		bucket: source.Bucket,
+	}, nil
+}
+
+func getS3Credentials(source *types.KnowledgeSourceS3, secrets map[string]string) (*credentials.Credentials, error) {
+	if source.AccessKeyIDSecret == "" && source.SecretAccessKeySecret == "" {
+		// Public bucket, the server's own AWS credentials are never used as
+		// they would give every user access to the buckets of the server
+		return credentials.NewStaticV4("", "", ""), nil
+	}
+
+	accessKeyID, err := getSecret(secrets, source.AccessKeyIDSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	secretAccessKey, err := getSecret(secrets, source.SecretAccessKeySecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.NewStaticV4(accessKeyID, secretAccessKey, ""), nil
+}
+
+func (c *s3BucketClient) List(ctx context.Context, prefix string) ([]*bucketObject, error) {
+	var result []*bucketObject
+
+	for obj := range c.client.ListObjects(ctx, c.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+
+		result = append(result, &bucketObject{
+			Key:      obj.Key,
+			ETag:     obj.ETag,
+			Size:     obj.Size,
+			Modified: obj.LastModified,
+		})
+	}
+
+	return result, nil
+}
+
+func (c *s3BucketClient) Read(ctx context.Context, key string) ([]byte, error) {
+	obj, err := c.client.GetObject(ctx, c.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	return readObject(obj)
+}
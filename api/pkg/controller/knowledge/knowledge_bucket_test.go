@@ -0,0 +1,400 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/extract"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type fakeBucketClient struct {
+	objects map[string]*fakeBucketObject
+	reads   []string
+}
+
+type fakeBucketObject struct {
+	etag    string
+	content string
+}
+
+func (c *fakeBucketClient) List(_ context.Context, prefix string) ([]*bucketObject, error) {
+	var result []*bucketObject
+	for key, obj := range c.objects {
+		if strings.HasPrefix(key, prefix) {
+			result = append(result, &bucketObject{Key: key, ETag: obj.etag, Size: int64(len(obj.content))})
+		}
+	}
+	return result, nil
+}
+
+func (c *fakeBucketClient) Read(_ context.Context, key string) ([]byte, error) {
+	obj, ok := c.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found", key)
+	}
+	c.reads = append(c.reads, key)
+	return []byte(obj.content), nil
+}
+
+func (suite *ExtractorSuite) setupBucket(objects map[string]*fakeBucketObject) *fakeBucketClient {
+	client := &fakeBucketClient{objects: objects}
+
+	suite.reconciler.newBucketClient = func(_ context.Context, k *types.Knowledge, secrets map[string]string) (bucketClient, error) {
+		// Only the secrets of the knowledge app are available
+		suite.Equal(map[string]string{"AWS_SECRET_ACCESS_KEY": "secret"}, secrets)
+		return client, nil
+	}
+
+	suite.store.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{Owner: "user_id"}).Return([]*types.Secret{
+		{Name: "AWS_SECRET_ACCESS_KEY", Value: []byte("secret"), AppID: "app_id"},
+		{Name: "OTHER_APP_SECRET", Value: []byte("other"), AppID: "other_app_id"},
+	}, nil).AnyTimes()
+
+	return client
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_S3() {
+	client := suite.setupBucket(map[string]*fakeBucketObject{
+		"docs/":                 {etag: "0"},
+		"docs/guide.pdf":        {etag: "1", content: "guide"},
+		"docs/drafts/draft.pdf": {etag: "2", content: "draft"},
+		"docs/notes.txt":        {etag: "3", content: "notes"},
+		"images/logo.png":       {etag: "4", content: "logo"},
+	})
+
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: "user_id",
+		AppID: "app_id",
+		Source: types.KnowledgeSource{
+			S3: &types.KnowledgeSourceS3{
+				Bucket:   "bucket",
+				Path:     "docs/",
+				Includes: []string{"*.pdf", "*.txt"},
+				Excludes: []string{"drafts/**"},
+			},
+		},
+	}
+
+	suite.extractor.EXPECT().Extract(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *extract.ExtractRequest) (string, error) {
+		return "extracted " + string(req.Content), nil
+	}).Times(2)
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 2)
+
+	sources := map[string]string{}
+	for _, d := range data {
+		sources[d.Source] = string(d.Data)
+	}
+
+	suite.Equal(map[string]string{
+		"docs/guide.pdf": "extracted guide",
+		"docs/notes.txt": "extracted notes",
+	}, sources)
+	suite.NotEmpty(knowledge.SourceFingerprint)
+	suite.ElementsMatch([]string{"docs/guide.pdf", "docs/notes.txt"}, client.reads)
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_GCS_DisableChunking() {
+	suite.setupBucket(map[string]*fakeBucketObject{
+		"data.db": {etag: "1", content: "raw"},
+	})
+
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: "user_id",
+		AppID: "app_id",
+		RAGSettings: types.RAGSettings{
+			DisableChunking: true,
+		},
+		Source: types.KnowledgeSource{
+			GCS: &types.KnowledgeSourceGCS{
+				Bucket: "bucket",
+			},
+		},
+	}
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 1)
+	suite.Equal("raw", string(data[0].Data))
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Bucket_Unchanged() {
+	client := suite.setupBucket(map[string]*fakeBucketObject{
+		"guide.pdf": {etag: "1", content: "guide"},
+	})
+
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: "user_id",
+		AppID: "app_id",
+		Source: types.KnowledgeSource{
+			S3: &types.KnowledgeSourceS3{
+				Bucket: "bucket",
+			},
+		},
+	}
+
+	suite.extractor.EXPECT().Extract(gomock.Any(), gomock.Any()).Return("guide", nil).Times(2)
+
+	_, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+
+	knowledge.Version = "2024-01-01-00-00-00"
+
+	// Same ETags, nothing to index
+	_, err = suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.ErrorIs(err, errSourceUnchanged)
+	suite.Len(client.reads, 1)
+
+	// Modified object
+	client.objects["guide.pdf"].etag = "2"
+
+	suite.store.EXPECT().ListKnowledgeDocuments(gomock.Any(), "knowledge_id").Return(nil, nil)
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Len(data, 1)
+	suite.Len(client.reads, 2)
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Bucket_UnchangedObjects() {
+	client := suite.setupBucket(map[string]*fakeBucketObject{
+		"guide.pdf": {etag: "1", content: "guide"},
+		"notes.txt": {etag: "2", content: "notes"},
+	})
+
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		Owner:   "user_id",
+		AppID:   "app_id",
+		Version: "v1",
+		Source: types.KnowledgeSource{
+			S3: &types.KnowledgeSourceS3{
+				Bucket: "bucket",
+			},
+		},
+	}
+
+	guideETag, err := getObjectETag(knowledge, &bucketObject{Key: "guide.pdf", ETag: "1"})
+	suite.Require().NoError(err)
+
+	suite.store.EXPECT().ListKnowledgeDocuments(gomock.Any(), "knowledge_id").Return([]*types.KnowledgeDocument{
+		{KnowledgeID: "knowledge_id", Source: "guide.pdf", Version: "v1", DocumentID: "guide_id", Hash: "guide_hash", ETag: guideETag, Size: 15},
+		{KnowledgeID: "knowledge_id", Source: "notes.txt", Version: "v1", DocumentID: "notes_id", Hash: "notes_hash", ETag: "old"},
+	}, nil)
+
+	suite.extractor.EXPECT().Extract(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *extract.ExtractRequest) (string, error) {
+		return "extracted " + string(req.Content), nil
+	}).Times(2)
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 2)
+
+	// Only the modified object is read, the unchanged one reuses its document
+	suite.Equal([]string{"notes.txt"}, client.reads)
+
+	bySource := map[string]*indexerData{}
+	for _, d := range data {
+		bySource[d.Source] = d
+	}
+	suite.Nil(bySource["guide.pdf"].Data)
+	suite.Equal("extracted notes", string(bySource["notes.txt"].Data))
+
+	documents, err := getKnowledgeDocuments(knowledge, "v2", data)
+	suite.Require().NoError(err)
+	suite.Require().Len(documents, 2)
+	for _, doc := range documents {
+		if doc.Source == "guide.pdf" {
+			suite.Equal("guide_id", doc.DocumentID)
+			suite.Equal("guide_hash", doc.Hash)
+			suite.Equal(guideETag, doc.ETag)
+		}
+	}
+	suite.Equal(int64(15+len("extracted notes")), getSize(data))
+
+	// The RAG backend can't copy documents, the unchanged object is read
+	changed, err := suite.reconciler.copyUnchangedDocuments(suite.ctx, knowledge, "v2", data, documents)
+	suite.Require().NoError(err)
+	suite.Len(changed, 2)
+	suite.Equal("extracted guide", string(bySource["guide.pdf"].Data))
+	suite.ElementsMatch([]string{"notes.txt", "guide.pdf"}, client.reads)
+}
+
+func Test_readObject(t *testing.T) {
+	bts, err := readObject(strings.NewReader("content"))
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(bts))
+
+	_, err = readObject(io.LimitReader(zeroReader{}, maxBucketObjectSize+1))
+	assert.Error(t, err)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func (suite *ExtractorSuite) Test_indexKnowledge_Bucket_Unchanged() {
+	suite.setupBucket(map[string]*fakeBucketObject{
+		"guide.pdf": {etag: "1", content: "guide"},
+	})
+
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		Owner:   "user_id",
+		AppID:   "app_id",
+		Version: "2024-01-01-00-00-00",
+		State:   types.KnowledgeStateReady,
+		Source: types.KnowledgeSource{
+			S3: &types.KnowledgeSourceS3{
+				Bucket: "bucket",
+			},
+		},
+	}
+
+	fingerprint, err := getBucketFingerprint(knowledge, []*bucketObject{{Key: "guide.pdf", ETag: "1"}})
+	suite.Require().NoError(err)
+
+	knowledge.SourceFingerprint = fingerprint
+
+	suite.store.EXPECT().UpdateKnowledgeState(gomock.Any(), "knowledge_id", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	suite.store.EXPECT().UpdateKnowledge(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, k *types.Knowledge) (*types.Knowledge, error) {
+		suite.Equal(types.KnowledgeStateReady, k.State)
+		suite.Equal("2024-01-01-00-00-00", k.Version)
+		return k, nil
+	})
+
+	err = suite.reconciler.indexKnowledge(suite.ctx, knowledge, "2024-01-02-00-00-00")
+	suite.Require().NoError(err)
+}
+
+func Test_objectFilter(t *testing.T) {
+	filter, err := newObjectFilter([]string{"*.md", "guides/**"}, []string{"*.draft.md", "guides/internal/*"})
+	require.NoError(t, err)
+
+	assert.True(t, filter.match("readme.md"))
+	assert.True(t, filter.match("a/b/readme.md"))
+	assert.True(t, filter.match("guides/setup/install.pdf"))
+	assert.False(t, filter.match("notes.txt"))
+	assert.False(t, filter.match("a/plan.draft.md"))
+	assert.False(t, filter.match("guides/internal/secrets.pdf"))
+
+	filter, err = newObjectFilter(nil, nil)
+	require.NoError(t, err)
+	assert.True(t, filter.match("anything.bin"))
+}
+
+func Test_getS3Credentials_Anonymous(t *testing.T) {
+	// The server's own AWS credentials are not used
+	t.Setenv("AWS_ACCESS_KEY_ID", "server")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "server-secret")
+
+	creds, err := getS3Credentials(&types.KnowledgeSourceS3{Bucket: "docs"}, nil)
+	require.NoError(t, err)
+
+	value, err := creds.Get()
+	require.NoError(t, err)
+	assert.Empty(t, value.AccessKeyID)
+	assert.Equal(t, credentials.SignatureAnonymous, value.SignerType)
+}
+
+// Test_s3BucketClient runs against MinIO, e.g.
+// docker run -p 9000:9000 minio/minio server /data
+func Test_s3BucketClient(t *testing.T) {
+	endpoint := os.Getenv("KNOWLEDGE_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("KNOWLEDGE_TEST_S3_ENDPOINT not set, skipping S3 tests")
+	}
+
+	ctx := context.Background()
+
+	source := &types.KnowledgeSourceS3{
+		Bucket:                fmt.Sprintf("helix-test-%d", time.Now().UnixNano()),
+		Endpoint:              endpoint,
+		Insecure:              true,
+		AccessKeyIDSecret:     "S3_ACCESS_KEY_ID",
+		SecretAccessKeySecret: "S3_SECRET_ACCESS_KEY",
+	}
+
+	client, err := newS3BucketClient(source, map[string]string{
+		"S3_ACCESS_KEY_ID":     "minioadmin",
+		"S3_SECRET_ACCESS_KEY": "minioadmin",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.client.MakeBucket(ctx, source.Bucket, minio.MakeBucketOptions{}))
+
+	for key, content := range map[string]string{"docs/a.md": "hello", "docs/sub/b.md": "world", "other.md": "!"} {
+		_, err = client.client.PutObject(ctx, source.Bucket, key, strings.NewReader(content), int64(len(content)), minio.PutObjectOptions{})
+		require.NoError(t, err)
+	}
+
+	objects, err := client.List(ctx, "docs/")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.NotEmpty(t, objects[0].ETag)
+
+	bts, err := client.Read(ctx, "docs/sub/b.md")
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(bts))
+}
+
+// Test_gcsBucketClient runs against fake-gcs-server, e.g.
+// docker run -p 4443:4443 fsouza/fake-gcs-server -scheme http
+// with KNOWLEDGE_TEST_GCS_ENDPOINT=http://localhost:4443/storage/v1/
+func Test_gcsBucketClient(t *testing.T) {
+	endpoint := os.Getenv("KNOWLEDGE_TEST_GCS_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("KNOWLEDGE_TEST_GCS_ENDPOINT not set, skipping GCS tests")
+	}
+
+	ctx := context.Background()
+
+	source := &types.KnowledgeSourceGCS{
+		Bucket:   fmt.Sprintf("helix-test-%d", time.Now().UnixNano()),
+		Endpoint: endpoint,
+	}
+
+	client, err := newGCSBucketClient(ctx, source, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, client.bucket.Create(ctx, "test-project", &storage.BucketAttrs{}))
+
+	for key, content := range map[string]string{"docs/a.md": "hello", "docs/sub/b.md": "world", "other.md": "!"} {
+		w := client.bucket.Object(key).NewWriter(ctx)
+		_, err = bytes.NewBufferString(content).WriteTo(w)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+
+	objects, err := client.List(ctx, "docs/")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.NotEqual(t, "0", objects[0].ETag)
+
+	bts, err := client.Read(ctx, "docs/sub/b.md")
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(bts))
+}
@@ -0,0 +1,239 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/rag"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// copyDocumentsBatchSize is the number of documents copied per request
+const copyDocumentsBatchSize = 100
+
+// getKnowledgeDocuments returns the documents of the indexing data as they
+// are persisted for the new version
+func getKnowledgeDocuments(k *types.Knowledge, version string, data []*indexerData) ([]*types.KnowledgeDocument, error) {
+	settings, err := json.Marshal(k.RAGSettings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rag settings: %w", err)
+	}
+
+	documents := make([]*types.KnowledgeDocument, 0, len(data))
+	seen := make(map[string]bool, len(data))
+
+	for _, d := range data {
+		// Sources are unique per knowledge
+		if seen[d.Source] {
+			continue
+		}
+		seen[d.Source] = true
+
+		if d.Unchanged != nil {
+			documents = append(documents, &types.KnowledgeDocument{
+				KnowledgeID: k.ID,
+				Source:      d.Source,
+				Version:     version,
+				DocumentID:  d.Unchanged.DocumentID,
+				Hash:        d.Unchanged.Hash,
+				ETag:        d.ETag,
+				Size:        d.Unchanged.Size,
+			})
+			continue
+		}
+
+		hash := sha256.New()
+		hash.Write(settings)
+		hash.Write(d.Data)
+
+		documents = append(documents, &types.KnowledgeDocument{
+			KnowledgeID: k.ID,
+			Source:      d.Source,
+			Version:     version,
+			DocumentID:  getDocumentID(d.Data),
+			Hash:        hex.EncodeToString(hash.Sum(nil)),
+			ETag:        d.ETag,
+			Size:        int64(len(d.Data)),
+		})
+	}
+
+	return documents, nil
+}
+
+// copyUnchangedDocuments copies the chunks of the documents that didn't change
+// since the current version into the new version, without extracting and
+// embedding them again. Returns the data that still needs to be indexed, the
+// unchanged sources that weren't read are read if they couldn't be copied.
+func (r *Reconciler) copyUnchangedDocuments(ctx context.Context, k *types.Knowledge, version string, data []*indexerData, documents []*types.KnowledgeDocument) ([]*indexerData, error) {
+	changed, err := r.copyDocuments(ctx, k, version, data, documents)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range changed {
+		if d.Data != nil || d.read == nil {
+			continue
+		}
+
+		d.Data, err = d.read(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changed, nil
+}
+
+func (r *Reconciler) copyDocuments(ctx context.Context, k *types.Knowledge, version string, data []*indexerData, documents []*types.KnowledgeDocument) ([]*indexerData, error) {
+	// Nothing indexed yet
+	if k.Version == "" {
+		return data, nil
+	}
+
+	// The indexing server downloads the data, changes can't be detected
+	if k.RAGSettings.DisableDownloading {
+		return data, nil
+	}
+
+	copier, ok := r.getRagClient(k).(rag.Copier)
+	if !ok {
+		return data, nil
+	}
+
+	previous, err := r.store.ListKnowledgeDocuments(ctx, k.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list knowledge documents: %w", err)
+	}
+
+	for _, doc := range previous {
+		// Out of sync with the current version, index everything
+		if doc.Version != k.Version {
+			log.Warn().
+				Str("knowledge_id", k.ID).
+				Str("version", k.Version).
+				Str("documents_version", doc.Version).
+				Msg("knowledge documents don't match the current version, indexing all documents")
+			return data, nil
+		}
+	}
+
+	unchanged := getUnchangedDocumentIDs(previous, documents)
+	if len(unchanged) == 0 {
+		return data, nil
+	}
+
+	for start := 0; start < len(unchanged); start += copyDocumentsBatchSize {
+		end := min(start+copyDocumentsBatchSize, len(unchanged))
+
+		err = copier.Copy(ctx, &types.CopyIndexRequest{
+			FromDataEntityID: k.GetDataEntityID(),
+			ToDataEntityID:   types.GetDataEntityID(k.ID, version),
+			DocumentIDs:      unchanged[start:end],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy unchanged documents, error: %w", err)
+		}
+	}
+
+	copied := make(map[string]bool, len(unchanged))
+	for _, documentID := range unchanged {
+		copied[documentID] = true
+	}
+
+	var changed []*indexerData
+
+	for _, d := range data {
+		documentID := getDocumentID(d.Data)
+		if d.Data == nil && d.Unchanged != nil {
+			documentID = d.Unchanged.DocumentID
+		}
+
+		if !copied[documentID] {
+			changed = append(changed, d)
+		}
+	}
+
+	log.Info().
+		Str("knowledge_id", k.ID).
+		Str("new_version", version).
+		Int("unchanged_documents", len(data)-len(changed)).
+		Int("changed_documents", len(changed)).
+		Int("previous_documents", len(previous)).
+		Msg("copied unchanged documents from the current version")
+
+	return changed, nil
+}
+
+// getUnchangedDocumentIDs returns the RAG document IDs that can be copied as
+// they are. Chunks are copied by document ID, so a document ID is only
+// unchanged if all of its sources are unchanged and none were added or
+// removed.
+func getUnchangedDocumentIDs(previous, current []*types.KnowledgeDocument) []string {
+	previousBySource := make(map[string]*types.KnowledgeDocument, len(previous))
+	previousSources := make(map[string][]string)
+
+	for _, doc := range previous {
+		key := getDocumentKey(doc.Source)
+		previousBySource[key] = doc
+		previousSources[doc.DocumentID] = append(previousSources[doc.DocumentID], key)
+	}
+
+	currentSources := make(map[string][]string)
+	changed := make(map[string]bool)
+
+	for _, doc := range current {
+		key := getDocumentKey(doc.Source)
+		currentSources[doc.DocumentID] = append(currentSources[doc.DocumentID], key)
+
+		prev, ok := previousBySource[key]
+		if !ok || prev.Hash != doc.Hash {
+			changed[doc.DocumentID] = true
+		}
+	}
+
+	var unchanged []string
+
+	for documentID, sources := range currentSources {
+		if changed[documentID] {
+			continue
+		}
+
+		prevSources := previousSources[documentID]
+
+		sort.Strings(sources)
+		sort.Strings(prevSources)
+
+		if slices.Equal(sources, prevSources) {
+			unchanged = append(unchanged, documentID)
+		}
+	}
+
+	sort.Strings(unchanged)
+
+	return unchanged
+}
+
+// getDocumentKey identifies the document of a source across versions. GitHub
+// sources link to the indexed commit, so they are keyed by repository path.
+func getDocumentKey(source string) string {
+	path, ok := strings.CutPrefix(source, githubURL+"/")
+	if !ok {
+		return source
+	}
+
+	// <owner>/<repository>/blob/<commit>/<path>
+	parts := strings.SplitN(path, "/", 5)
+	if len(parts) != 5 || parts[2] != "blob" {
+		return source
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s", githubURL, parts[0], parts[1], parts[4])
+}
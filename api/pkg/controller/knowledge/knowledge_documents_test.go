@@ -0,0 +1,218 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/rag"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// copierRAG is a RAG backend that supports copying documents
+type copierRAG struct {
+	*rag.MockRAG
+	*rag.MockCopier
+}
+
+func (suite *IndexerSuite) Test_indexKnowledge_Incremental() {
+	ragClient := &copierRAG{
+		MockRAG:    suite.rag,
+		MockCopier: rag.NewMockCopier(gomock.NewController(suite.T())),
+	}
+	suite.reconciler.ragClient = ragClient
+
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		Version: "v1",
+		RAGSettings: types.RAGSettings{
+			TextSplitter: types.TextSplitterTypeText,
+			ChunkSize:    2048,
+		},
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs: []string{"https://example.com"},
+				Crawler: &types.WebsiteCrawler{
+					Enabled: true,
+				},
+			},
+		},
+	}
+
+	suite.crawler.EXPECT().Crawl(gomock.Any()).Return([]*types.CrawledDocument{
+		{SourceURL: "https://example.com/unchanged", Content: "unchanged"},
+		{SourceURL: "https://example.com/changed", Content: "changed, new content"},
+		{SourceURL: "https://example.com/added", Content: "added"},
+	}, nil)
+
+	previous, err := getKnowledgeDocuments(knowledge, "v1", []*indexerData{
+		{Source: "https://example.com/unchanged", Data: []byte("unchanged")},
+		{Source: "https://example.com/changed", Data: []byte("changed")},
+		{Source: "https://example.com/removed", Data: []byte("removed")},
+	})
+	suite.Require().NoError(err)
+
+	suite.store.EXPECT().ListKnowledgeDocuments(gomock.Any(), "knowledge_id").Return(previous, nil)
+	suite.store.EXPECT().UpdateKnowledgeState(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	// The unchanged document is copied over from the current version
+	ragClient.MockCopier.EXPECT().Copy(gomock.Any(), &types.CopyIndexRequest{
+		FromDataEntityID: "knowledge_id-v1",
+		ToDataEntityID:   "knowledge_id-v2",
+		DocumentIDs:      []string{getDocumentID([]byte("unchanged"))},
+	}).Return(nil)
+
+	// Only the changed and added documents are indexed
+	var indexed []string
+
+	suite.rag.EXPECT().Index(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, chunks ...*types.SessionRAGIndexChunk) error {
+			for _, chunk := range chunks {
+				suite.Equal("knowledge_id-v2", chunk.DataEntityID)
+				indexed = append(indexed, chunk.Source)
+			}
+			return nil
+		},
+	)
+
+	suite.store.EXPECT().UpdateKnowledge(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, k *types.Knowledge) (*types.Knowledge, error) {
+			suite.Equal("v2", k.Version)
+			return k, nil
+		},
+	)
+	suite.store.EXPECT().CreateKnowledgeVersion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, v *types.KnowledgeVersion) (*types.KnowledgeVersion, error) {
+			return v, nil
+		},
+	)
+	suite.store.EXPECT().ListKnowledgeVersions(gomock.Any(), &store.ListKnowledgeVersionQuery{
+		KnowledgeID: knowledge.ID,
+	}).Return([]*types.KnowledgeVersion{}, nil)
+
+	suite.store.EXPECT().ReplaceKnowledgeDocuments(gomock.Any(), "knowledge_id", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, documents []*types.KnowledgeDocument) error {
+			var sources []string
+			for _, doc := range documents {
+				suite.Equal("v2", doc.Version)
+				sources = append(sources, doc.Source)
+			}
+			suite.ElementsMatch([]string{
+				"https://example.com/unchanged",
+				"https://example.com/changed",
+				"https://example.com/added",
+			}, sources)
+			return nil
+		},
+	)
+
+	err = suite.reconciler.indexKnowledge(suite.ctx, knowledge, "v2")
+	suite.Require().NoError(err)
+
+	suite.ElementsMatch([]string{"https://example.com/changed", "https://example.com/added"}, indexed)
+}
+
+func (suite *IndexerSuite) Test_indexKnowledge_Incremental_VersionMismatch() {
+	ragClient := &copierRAG{
+		MockRAG:    suite.rag,
+		MockCopier: rag.NewMockCopier(gomock.NewController(suite.T())),
+	}
+	suite.reconciler.ragClient = ragClient
+
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		Version: "v1",
+		RAGSettings: types.RAGSettings{
+			TextSplitter: types.TextSplitterTypeText,
+			ChunkSize:    2048,
+		},
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs:    []string{"https://example.com"},
+				Crawler: &types.WebsiteCrawler{Enabled: true},
+			},
+		},
+	}
+
+	suite.crawler.EXPECT().Crawl(gomock.Any()).Return([]*types.CrawledDocument{
+		{SourceURL: "https://example.com", Content: "unchanged"},
+	}, nil)
+
+	// Documents saved for a version that isn't the current one
+	previous, err := getKnowledgeDocuments(knowledge, "v0", []*indexerData{
+		{Source: "https://example.com", Data: []byte("unchanged")},
+	})
+	suite.Require().NoError(err)
+
+	suite.store.EXPECT().ListKnowledgeDocuments(gomock.Any(), "knowledge_id").Return(previous, nil)
+	suite.store.EXPECT().UpdateKnowledgeState(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	// Indexed again instead of copied
+	suite.rag.EXPECT().Index(gomock.Any(), gomock.Any()).Return(nil)
+
+	suite.store.EXPECT().UpdateKnowledge(gomock.Any(), gomock.Any()).Return(knowledge, nil)
+	suite.store.EXPECT().CreateKnowledgeVersion(gomock.Any(), gomock.Any()).Return(&types.KnowledgeVersion{}, nil)
+	suite.store.EXPECT().ListKnowledgeVersions(gomock.Any(), gomock.Any()).Return([]*types.KnowledgeVersion{}, nil)
+	suite.store.EXPECT().ReplaceKnowledgeDocuments(gomock.Any(), "knowledge_id", gomock.Any()).Return(nil)
+
+	err = suite.reconciler.indexKnowledge(suite.ctx, knowledge, "v2")
+	suite.Require().NoError(err)
+}
+
+func Test_getUnchangedDocumentIDs(t *testing.T) {
+	doc := func(source, documentID, hash string) *types.KnowledgeDocument {
+		return &types.KnowledgeDocument{Source: source, DocumentID: documentID, Hash: hash}
+	}
+
+	previous := []*types.KnowledgeDocument{
+		doc("a", "id-a", "hash-a"),
+		doc("b", "id-b", "hash-b"),
+		// Same contents in two sources
+		doc("c1", "id-c", "hash-c"),
+		doc("c2", "id-c", "hash-c"),
+		doc("d1", "id-d", "hash-d"),
+		doc("d2", "id-d", "hash-d"),
+	}
+
+	current := []*types.KnowledgeDocument{
+		doc("a", "id-a", "hash-a"),
+		// Changed
+		doc("b", "id-b2", "hash-b2"),
+		doc("c1", "id-c", "hash-c"),
+		doc("c2", "id-c", "hash-c"),
+		// d2 removed, copying id-d would bring its chunks back
+		doc("d1", "id-d", "hash-d"),
+		// Added
+		doc("e", "id-e", "hash-e"),
+	}
+
+	assert.Equal(t, []string{"id-a", "id-c"}, getUnchangedDocumentIDs(previous, current))
+}
+
+func Test_getUnchangedDocumentIDs_GithubCommit(t *testing.T) {
+	doc := func(source, documentID string) *types.KnowledgeDocument {
+		return &types.KnowledgeDocument{Source: source, DocumentID: documentID, Hash: "hash-" + documentID}
+	}
+
+	previous := []*types.KnowledgeDocument{
+		doc("https://github.com/helixml/helix/blob/1111111/README.md", "id-readme"),
+		doc("https://github.com/helixml/helix/blob/1111111/docs/a.md", "id-a"),
+	}
+
+	// A new commit only changes docs/a.md
+	current := []*types.KnowledgeDocument{
+		doc("https://github.com/helixml/helix/blob/2222222/README.md", "id-readme"),
+		doc("https://github.com/helixml/helix/blob/2222222/docs/a.md", "id-a2"),
+	}
+
+	assert.Equal(t, []string{"id-readme"}, getUnchangedDocumentIDs(previous, current))
+}
+
+func Test_getDocumentKey(t *testing.T) {
+	assert.Equal(t, "https://github.com/helixml/helix/docs/a.md", getDocumentKey("https://github.com/helixml/helix/blob/2222222/docs/a.md"))
+	assert.Equal(t, "https://example.com/docs/a.md", getDocumentKey("https://example.com/docs/a.md"))
+	assert.Equal(t, "https://github.com/helixml/helix", getDocumentKey("https://github.com/helixml/helix"))
+}
@@ -6,7 +6,10 @@ import (
 	"io"
 	"net/http"
 	"path/filepath"
+	"sort"
+	"time"
 
+	"github.com/helixml/helix/api/pkg/controller/knowledge/crawler"
 	"github.com/helixml/helix/api/pkg/extract"
 	"github.com/helixml/helix/api/pkg/filestore"
 	"github.com/helixml/helix/api/pkg/types"
@@ -19,6 +22,12 @@ func (r *Reconciler) getIndexingData(ctx context.Context, k *types.Knowledge) ([
 		return r.extractDataFromWeb(ctx, k)
 	case k.Source.Filestore != nil:
 		return r.extractDataFromHelixFilestore(ctx, k)
+	case k.Source.S3 != nil, k.Source.GCS != nil:
+		return r.extractDataFromBucket(ctx, k)
+	case k.Source.Github != nil:
+		return r.extractDataFromGithub(ctx, k)
+	case k.Source.Local != nil:
+		return r.extractDataFromLocal(ctx, k)
 	default:
 		return nil, fmt.Errorf("unknown source: %+v", k.Source)
 	}
@@ -48,6 +57,17 @@ func (r *Reconciler) extractDataFromWeb(ctx context.Context, k *types.Knowledge)
 		extractorEnabled = false
 	}
 
+	var auth *crawler.Auth
+
+	if !k.RAGSettings.DisableDownloading {
+		var err error
+
+		auth, err = r.getDownloadAuth(ctx, k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get web source credentials: %w", err)
+		}
+	}
+
 	for _, u := range k.Source.Web.URLs {
 		// If we are not downloading the file, we just send the URL
 		if k.RAGSettings.DisableDownloading {
@@ -57,6 +77,29 @@ func (r *Reconciler) extractDataFromWeb(ctx context.Context, k *types.Knowledge)
 			continue
 		}
 
+		// The extractor can't authenticate, downloading the
+		// file for it
+		if extractorEnabled && auth != nil {
+			bts, err := r.downloadDirectly(ctx, u, auth)
+			if err != nil {
+				return nil, fmt.Errorf("failed to download data from %s, error: %w", u, err)
+			}
+
+			extracted, err := r.extractor.Extract(ctx, &extract.ExtractRequest{
+				Content: bts,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract data from %s, error: %w", u, err)
+			}
+
+			result = append(result, &indexerData{
+				Data:   []byte(extracted),
+				Source: u,
+			})
+
+			continue
+		}
+
 		if extractorEnabled {
 			extracted, err := r.extractor.Extract(ctx, &extract.ExtractRequest{
 				URL: u,
@@ -74,7 +117,7 @@ func (r *Reconciler) extractDataFromWeb(ctx context.Context, k *types.Knowledge)
 		}
 
 		// Download the file
-		bts, err := r.downloadDirectly(ctx, k, u)
+		bts, err := r.downloadDirectly(ctx, u, auth)
 		if err != nil {
 			return nil, fmt.Errorf("failed to download data from %s, error: %w", u, err)
 		}
@@ -113,7 +156,19 @@ func (r *Reconciler) extractDataFromWebWithCrawler(ctx context.Context, k *types
 		return nil, fmt.Errorf("no crawler defined")
 	}
 
-	crawler, err := r.newCrawler(k)
+	auth, err := r.getWebAuth(ctx, k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get web source credentials: %w", err)
+	}
+
+	fingerprint := r.getSitemapFingerprint(ctx, k, auth)
+
+	// Nothing to do if no sitemap page was modified since the latest version
+	if fingerprint != "" && k.Version != "" && k.SourceFingerprint == fingerprint {
+		return nil, errSourceUnchanged
+	}
+
+	crawler, err := r.newCrawler(k, auth)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create crawler: %w", err)
 	}
@@ -132,22 +187,61 @@ func (r *Reconciler) extractDataFromWebWithCrawler(ctx context.Context, k *types
 		})
 	}
 
+	k.SourceFingerprint = fingerprint
+
 	return data, nil
 }
 
-func (r *Reconciler) downloadDirectly(ctx context.Context, k *types.Knowledge, u string) ([]byte, error) {
+// getSitemapFingerprint identifies the sitemap pages by their URLs and last
+// modification times. Empty if the crawl isn't seeded from the sitemap or
+// some of the pages don't have lastmod, changes can't be detected then.
+func (r *Reconciler) getSitemapFingerprint(ctx context.Context, k *types.Knowledge, auth *crawler.Auth) string {
+	if !k.Source.Web.Crawler.Enabled || !k.Source.Web.Crawler.Sitemap || k.Source.Web.Crawler.Firecrawl != nil {
+		return ""
+	}
+
+	entries, err := crawler.GetSitemapEntries(ctx, r.httpClient, k, auth)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("knowledge_id", k.ID).
+			Msg("failed to get sitemap, skipping change detection")
+		return ""
+	}
+
+	if len(entries) == 0 {
+		return ""
+	}
+
+	pages := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.LastModified.IsZero() {
+			return ""
+		}
+		pages = append(pages, entry.URL+"\t"+entry.LastModified.Format(time.RFC3339))
+	}
+
+	sort.Strings(pages)
+
+	fingerprint, err := getSourceFingerprint(k, pages)
+	if err != nil {
+		log.Warn().Err(err).Str("knowledge_id", k.ID).Msg("failed to get sitemap fingerprint")
+		return ""
+	}
+
+	return fingerprint
+}
+
+func (r *Reconciler) downloadDirectly(ctx context.Context, u string, auth *crawler.Auth) ([]byte, error) {
 	// Extractor and indexer disabled, downloading directly
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get %s, error: %w", u, err)
 	}
 
-	// If username and password are specified, use them for basic auth
-	if k.Source.Web.Auth.Username != "" || k.Source.Web.Auth.Password != "" {
-		req.SetBasicAuth(k.Source.Web.Auth.Username, k.Source.Web.Auth.Password)
-	}
-
-	resp, err := r.httpClient.Do(req)
+	// Basic auth, bearer token, headers and cookies of the web source
+	resp, err := auth.Do(r.httpClient, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download, error: %w", err)
 	}
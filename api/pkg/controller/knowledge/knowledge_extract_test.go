@@ -55,13 +55,13 @@ func (suite *ExtractorSuite) SetupTest() {
 
 	var err error
 
-	suite.reconciler, err = New(suite.cfg, suite.store, suite.filestore, suite.extractor, suite.rag, b)
+	suite.reconciler, err = New(suite.cfg, suite.store, suite.filestore, suite.extractor, suite.rag, nil, b)
 	suite.Require().NoError(err)
 	suite.reconciler.newRagClient = func(settings *types.RAGSettings) rag.RAG {
 		return suite.rag
 	}
 
-	suite.reconciler.newCrawler = func(k *types.Knowledge) (crawler.Crawler, error) {
+	suite.reconciler.newCrawler = func(k *types.Knowledge, _ *crawler.Auth) (crawler.Crawler, error) {
 		return suite.crawler, nil
 	}
 }
@@ -147,3 +147,49 @@ func (suite *ExtractorSuite) Test_getIndexingData_CrawlerDisabled_ExtractEnabled
 	suite.Equal("https://example.com", data[0].Source)
 	suite.Contains(string(data[0].Data), "Hello, world!")
 }
+
+func (suite *ExtractorSuite) Test_getIndexingData_Sitemap_Unchanged() {
+	lastMod := "2024-05-01"
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<urlset><url><loc>%s/docs</loc><lastmod>%s</lastmod></url></urlset>`, server.URL, lastMod)
+	}))
+	defer server.Close()
+
+	knowledge := &types.Knowledge{
+		ID: "knowledge_id",
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs: []string{server.URL},
+				Crawler: &types.WebsiteCrawler{
+					Enabled: true,
+					Sitemap: true,
+				},
+			},
+		},
+	}
+
+	suite.crawler.EXPECT().Crawl(gomock.Any()).Return([]*types.CrawledDocument{
+		{SourceURL: server.URL + "/docs", Content: "docs"},
+	}, nil).Times(2)
+
+	_, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.NotEmpty(knowledge.SourceFingerprint)
+
+	knowledge.Version = "2024-05-01-00-00-00"
+
+	// No page modified, not crawled
+	_, err = suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.ErrorIs(err, errSourceUnchanged)
+
+	lastMod = "2024-05-02"
+
+	_, err = suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+}
@@ -0,0 +1,288 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const githubURL = "https://github.com"
+
+// binaryCheckSize is how much of a file is checked for NUL bytes
+// to detect binary files
+const binaryCheckSize = 8000
+
+func (r *Reconciler) extractDataFromGithub(ctx context.Context, k *types.Knowledge) ([]*indexerData, error) {
+	source := k.Source.Github
+	if source == nil {
+		return nil, fmt.Errorf("no github source defined")
+	}
+
+	if err := validateGithubRepository(source); err != nil {
+		return nil, err
+	}
+
+	auth, err := r.getGithubAuth(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	repoURL := fmt.Sprintf("%s/%s/%s.git", r.githubURL, source.Owner, source.Repository)
+	repoPath := filepath.Join(r.config.GitHub.RepoFolder, "knowledge", k.ID)
+
+	commit, err := cloneOrFetchBranch(ctx, repoURL, source.Branch, repoPath, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := getSourceFingerprint(k, []string{commit})
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing to do if the branch didn't move since the latest version
+	if k.Version != "" && k.SourceFingerprint == fingerprint {
+		return nil, errSourceUnchanged
+	}
+
+	var result []*indexerData
+
+	err = filepath.WalkDir(repoPath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Symlinks in the repository could point to files of the server
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(repoPath, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if !githubPathMatches(source, rel) {
+			return nil
+		}
+
+		bts, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s, error: %w", rel, err)
+		}
+
+		if len(bts) == 0 || isBinary(bts) {
+			return nil
+		}
+
+		result = append(result, &indexerData{
+			Data:   bts,
+			Source: fmt.Sprintf("%s/%s/%s/blob/%s/%s", githubURL, source.Owner, source.Repository, commit, rel),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository files: %w", err)
+	}
+
+	log.Info().
+		Str("knowledge_id", k.ID).
+		Str("repository", fmt.Sprintf("%s/%s", source.Owner, source.Repository)).
+		Str("commit", commit).
+		Int("count", len(result)).
+		Msg("github files found")
+
+	k.SourceFingerprint = fingerprint
+
+	return result, nil
+}
+
+var (
+	githubOwnerRe      = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?$`)
+	githubRepositoryRe = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// validateGithubRepository checks the owner and repository are GitHub names,
+// they are used in the clone URL
+func validateGithubRepository(source *types.KnowledgeSourceGithub) error {
+	if source.Owner == "" || source.Repository == "" {
+		return fmt.Errorf("github owner and repository are required")
+	}
+
+	if !githubOwnerRe.MatchString(source.Owner) {
+		return fmt.Errorf("invalid github owner '%s'", source.Owner)
+	}
+
+	if !githubRepositoryRe.MatchString(source.Repository) || source.Repository == "." || source.Repository == ".." {
+		return fmt.Errorf("invalid github repository '%s'", source.Repository)
+	}
+
+	return nil
+}
+
+// getGithubAuth returns the knowledge owner's GitHub OAuth token if they
+// connected GitHub, public repositories are cloned without it
+func (r *Reconciler) getGithubAuth(ctx context.Context, k *types.Knowledge) (transport.AuthMethod, error) {
+	apiKeys, err := r.store.ListAPIKeys(ctx, &store.ListApiKeysQuery{
+		Owner:     k.Owner,
+		OwnerType: k.OwnerType,
+		Type:      types.APIKeyType_Github,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get github token: %w", err)
+	}
+
+	for _, apiKey := range apiKeys {
+		if apiKey.Type == types.APIKeyType_Github {
+			return &githttp.BasicAuth{
+				Username: "x-access-token",
+				Password: apiKey.Key,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// cloneOrFetchBranch clones the branch into repoPath or, if it was cloned
+// before, fetches and checks out its latest commit. Returns the commit SHA.
+func cloneOrFetchBranch(ctx context.Context, repoURL, branch, repoPath string, auth transport.AuthMethod) (string, error) {
+	repository, err := git.PlainOpen(repoPath)
+	switch {
+	case errors.Is(err, git.ErrRepositoryNotExists):
+		opts := &git.CloneOptions{
+			URL:          repoURL,
+			Auth:         auth,
+			SingleBranch: true,
+			Depth:        1,
+		}
+		if branch != "" {
+			opts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		}
+
+		repository, err = git.PlainCloneContext(ctx, repoPath, false, opts)
+		if err != nil {
+			// Don't leave a half cloned repository behind
+			_ = os.RemoveAll(repoPath)
+			return "", fmt.Errorf("failed to clone repository: %w", err)
+		}
+	case err != nil:
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	default:
+		if branch == "" {
+			// The branch checked out by the initial clone
+			head, err := repository.Head()
+			if err != nil {
+				return "", fmt.Errorf("failed to get HEAD: %w", err)
+			}
+			branch = head.Name().Short()
+		}
+
+		err = repository.FetchContext(ctx, &git.FetchOptions{
+			RemoteName: git.DefaultRemoteName,
+			RefSpecs: []gitconfig.RefSpec{
+				gitconfig.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", branch, git.DefaultRemoteName, branch)),
+			},
+			Auth:  auth,
+			Depth: 1,
+			Force: true,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return "", fmt.Errorf("failed to fetch repository: %w", err)
+		}
+
+		remoteRef, err := repository.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch), true)
+		if err != nil {
+			return "", fmt.Errorf("failed to get remote branch %s: %w", branch, err)
+		}
+
+		// Move the local branch to the fetched commit and check it out
+		branchRef := plumbing.NewBranchReferenceName(branch)
+
+		err = repository.Storer.SetReference(plumbing.NewHashReference(branchRef, remoteRef.Hash()))
+		if err != nil {
+			return "", fmt.Errorf("failed to update branch %s: %w", branch, err)
+		}
+
+		worktree, err := repository.Worktree()
+		if err != nil {
+			return "", fmt.Errorf("failed to get worktree: %w", err)
+		}
+
+		err = worktree.Checkout(&git.CheckoutOptions{
+			Branch: branchRef,
+			Force:  true,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to checkout branch %s: %w", branch, err)
+		}
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	return head.Hash().String(), nil
+}
+
+func githubPathMatches(source *types.KnowledgeSourceGithub, rel string) bool {
+	if len(source.FilterPaths) > 0 {
+		var found bool
+		for _, p := range source.FilterPaths {
+			p = strings.Trim(p, "/")
+			if p == "" || rel == p || strings.HasPrefix(rel, p+"/") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(source.FilterExtensions) > 0 {
+		ext := strings.ToLower(path.Ext(rel))
+		for _, e := range source.FilterExtensions {
+			if ext == "."+strings.TrimPrefix(strings.ToLower(e), ".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func isBinary(bts []byte) bool {
+	if len(bts) > binaryCheckSize {
+		bts = bts[:binaryCheckSize]
+	}
+	return bytes.IndexByte(bts, 0) != -1
+}
@@ -0,0 +1,194 @@
+package knowledge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// setupGithubRepo creates a local owner/repo.git repository served
+// instead of GitHub
+func (suite *ExtractorSuite) setupGithubRepo() *git.Repository {
+	base := suite.T().TempDir()
+
+	repo, err := git.PlainInit(filepath.Join(base, "helixml", "docs.git"), false)
+	suite.Require().NoError(err)
+
+	suite.reconciler.githubURL = "file://" + base
+	suite.cfg.GitHub.RepoFolder = suite.T().TempDir()
+
+	suite.store.EXPECT().ListAPIKeys(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	return repo
+}
+
+func (suite *ExtractorSuite) commitFiles(repo *git.Repository, files map[string]string) string {
+	worktree, err := repo.Worktree()
+	suite.Require().NoError(err)
+
+	for name, content := range files {
+		filePath := filepath.Join(worktree.Filesystem.Root(), name)
+		suite.Require().NoError(os.MkdirAll(filepath.Dir(filePath), 0o755))
+		suite.Require().NoError(os.WriteFile(filePath, []byte(content), 0o644))
+
+		_, err = worktree.Add(name)
+		suite.Require().NoError(err)
+	}
+
+	hash, err := worktree.Commit("update", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	suite.Require().NoError(err)
+
+	return hash.String()
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Github() {
+	repo := suite.setupGithubRepo()
+
+	commit := suite.commitFiles(repo, map[string]string{
+		"README.md":         "# Docs",
+		"docs/guide.md":     "# Guide",
+		"docs/main.go":      "package main",
+		"docs/logo.png":     "\x89PNG\x00\x00",
+		"internal/notes.md": "# Notes",
+	})
+
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: "user_id",
+		Source: types.KnowledgeSource{
+			Github: &types.KnowledgeSourceGithub{
+				Owner:            "helixml",
+				Repository:       "docs",
+				FilterPaths:      []string{"/docs", "README.md"},
+				FilterExtensions: []string{"md", ".png"},
+			},
+		},
+	}
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+
+	sources := map[string]string{}
+	for _, d := range data {
+		sources[d.Source] = string(d.Data)
+	}
+
+	// Filtered by path and extension, binary files skipped
+	suite.Equal(map[string]string{
+		"https://github.com/helixml/docs/blob/" + commit + "/README.md":     "# Docs",
+		"https://github.com/helixml/docs/blob/" + commit + "/docs/guide.md": "# Guide",
+	}, sources)
+
+	knowledge.Version = "2024-01-01-00-00-00"
+
+	// Branch didn't move
+	_, err = suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.ErrorIs(err, errSourceUnchanged)
+
+	// Pushed a new commit, fetched into the existing clone
+	commit = suite.commitFiles(repo, map[string]string{
+		"docs/guide.md": "# Updated guide",
+	})
+
+	data, err = suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 2)
+
+	for _, d := range data {
+		suite.True(strings.Contains(d.Source, "/blob/"+commit+"/"), d.Source)
+		if strings.HasSuffix(d.Source, "/docs/guide.md") {
+			suite.Equal("# Updated guide", string(d.Data))
+		}
+	}
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Github_Branch() {
+	repo := suite.setupGithubRepo()
+
+	suite.commitFiles(repo, map[string]string{"README.md": "# Main"})
+
+	worktree, err := repo.Worktree()
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(worktree.Checkout(&git.CheckoutOptions{
+		Branch: "refs/heads/release",
+		Create: true,
+	}))
+
+	commit := suite.commitFiles(repo, map[string]string{"README.md": "# Release"})
+
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: "user_id",
+		Source: types.KnowledgeSource{
+			Github: &types.KnowledgeSourceGithub{
+				Owner:      "helixml",
+				Repository: "docs",
+				Branch:     "release",
+			},
+		},
+	}
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 1)
+	suite.Equal("https://github.com/helixml/docs/blob/"+commit+"/README.md", data[0].Source)
+	suite.Equal("# Release", string(data[0].Data))
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Github_SkipsSymlinks() {
+	repo := suite.setupGithubRepo()
+
+	secret := filepath.Join(suite.T().TempDir(), "secret.md")
+	suite.Require().NoError(os.WriteFile(secret, []byte("# Secret"), 0o644))
+
+	worktree, err := repo.Worktree()
+	suite.Require().NoError(err)
+	suite.Require().NoError(os.Symlink(secret, filepath.Join(worktree.Filesystem.Root(), "link.md")))
+	_, err = worktree.Add("link.md")
+	suite.Require().NoError(err)
+
+	commit := suite.commitFiles(repo, map[string]string{"README.md": "# Docs"})
+
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: "user_id",
+		Source: types.KnowledgeSource{
+			Github: &types.KnowledgeSourceGithub{
+				Owner:      "helixml",
+				Repository: "docs",
+			},
+		},
+	}
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 1)
+	suite.Equal("https://github.com/helixml/docs/blob/"+commit+"/README.md", data[0].Source)
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Github_InvalidRepository() {
+	for _, source := range []types.KnowledgeSourceGithub{
+		{Owner: "../helixml", Repository: "docs"},
+		{Owner: "helixml", Repository: ".."},
+		{Owner: "helixml", Repository: "docs?x=1"},
+	} {
+		knowledge := &types.Knowledge{
+			ID:     "knowledge_id",
+			Owner:  "user_id",
+			Source: types.KnowledgeSource{Github: &source},
+		}
+
+		_, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+		suite.ErrorContains(err, "invalid github")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"sort"
 	"sync/atomic"
@@ -33,6 +34,8 @@ func (r *Reconciler) index(ctx context.Context) error {
 
 		k.State = types.KnowledgeStateIndexing
 		k.Message = ""
+		// Explicit indexing requests always produce a new version
+		k.SourceFingerprint = ""
 
 		_, _ = r.store.UpdateKnowledge(ctx, k)
 
@@ -92,6 +95,19 @@ func (r *Reconciler) indexKnowledge(ctx context.Context, k *types.Knowledge, ver
 	r.updateProgress(k, types.KnowledgeStateIndexing, "retrieving data for indexing", 0)
 
 	data, err := r.getIndexingData(ctx, k)
+	if errors.Is(err, errSourceUnchanged) {
+		log.Info().
+			Str("knowledge_id", k.ID).
+			Str("version", k.Version).
+			Msg("knowledge source unchanged, keeping the current version")
+
+		k.State = types.KnowledgeStateReady
+		_, err = r.store.UpdateKnowledge(ctx, k)
+		if err != nil {
+			return fmt.Errorf("failed to update knowledge, error: %w", err)
+		}
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get indexing data, error: %w", err)
 	}
@@ -112,9 +128,23 @@ func (r *Reconciler) indexKnowledge(ctx context.Context, k *types.Knowledge, ver
 
 	start = time.Now()
 
-	err = r.indexData(ctx, k, version, data)
+	documents, err := getKnowledgeDocuments(k, version, data)
+	if err != nil {
+		return err
+	}
+
+	// Only the added and changed documents need indexing, the rest
+	// is copied over from the current version
+	changed, err := r.copyUnchangedDocuments(ctx, k, version, data, documents)
 	if err != nil {
-		return fmt.Errorf("indexing failed, error: %w", err)
+		return err
+	}
+
+	if len(changed) > 0 {
+		err = r.indexData(ctx, k, version, changed)
+		if err != nil {
+			return fmt.Errorf("indexing failed, error: %w", err)
+		}
 	}
 	elapsed = time.Since(start)
 	log.Info().
@@ -147,6 +177,16 @@ func (r *Reconciler) indexKnowledge(ctx context.Context, k *types.Knowledge, ver
 		return fmt.Errorf("failed to create knowledge version, error: %w", err)
 	}
 
+	// Documents of the new version, the next refresh compares against them
+	err = r.store.ReplaceKnowledgeDocuments(ctx, k.ID, documents)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("knowledge_id", k.ID).
+			Str("version", version).
+			Msg("failed to save knowledge documents, the next refresh will index all documents")
+	}
+
 	log.Info().
 		Str("knowledge_id", k.ID).
 		Str("new_version", version).
@@ -225,6 +265,10 @@ func (r *Reconciler) deleteKnowledgeVersion(ctx context.Context, k *types.Knowle
 func getSize(data []*indexerData) int64 {
 	size := int64(0)
 	for _, d := range data {
+		if d.Data == nil && d.Unchanged != nil {
+			size += d.Unchanged.Size
+			continue
+		}
 		size += int64(len(d.Data))
 	}
 	return size
@@ -308,6 +352,7 @@ func (r *Reconciler) indexDataDirectly(ctx context.Context, k *types.Knowledge,
 				DocumentGroupID: documentGroupID,
 				ContentOffset:   0,
 				Content:         string(d.Data),
+				Metadata:        d.Metadata,
 			})
 			if err != nil {
 				return fmt.Errorf("failed to index data from source %s, error: %w", d.Source, err)
@@ -332,7 +377,7 @@ func (r *Reconciler) indexDataDirectly(ctx context.Context, k *types.Knowledge,
 // indexDataWithChunking we expect to be operating on text data, first we split,
 // then index with the rag server
 func (r *Reconciler) indexDataWithChunking(ctx context.Context, k *types.Knowledge, version string, data []*indexerData) error {
-	chunks, err := splitData(k, data)
+	chunks, err := splitData(ctx, k, data, r.getSplitterEmbeddings())
 	if err != nil {
 		return fmt.Errorf("failed to split data, error: %w", err)
 	}
@@ -412,6 +457,14 @@ func getDocumentID(contents []byte) string {
 type indexerData struct {
 	Source string
 	Data   []byte
+	// Metadata of the document, copied to all of its chunks
+	Metadata map[string]string
+	// ETag of the bucket object, see getObjectETag
+	ETag string
+	// Unchanged is the document of the current version when the source
+	// didn't change since, its data is only read if it can't be copied
+	Unchanged *types.KnowledgeDocument
+	read      func(ctx context.Context) ([]byte, error)
 }
 
 func convertChunksIntoBatches(chunks []*text.DataPrepTextSplitterChunk, batchSize int) [][]*text.DataPrepTextSplitterChunk {
@@ -438,6 +491,7 @@ func convertTextSplitterChunks(k *types.Knowledge, version string, chunks []*tex
 			DocumentGroupID: chunk.DocumentGroupID,
 			ContentOffset:   chunk.Index,
 			Content:         chunk.Text,
+			Metadata:        chunk.Metadata,
 		})
 	}
 
@@ -450,7 +504,7 @@ func checkContents(data []*indexerData) error {
 	}
 
 	for _, d := range data {
-		if len(d.Data) > 0 {
+		if len(d.Data) > 0 || d.Unchanged != nil {
 			return nil
 		}
 	}
@@ -57,14 +57,14 @@ func (suite *IndexerSuite) SetupTest() {
 
 	b := &browser.Browser{}
 
-	suite.reconciler, err = New(suite.cfg, suite.store, suite.filestore, suite.extractor, suite.rag, b)
+	suite.reconciler, err = New(suite.cfg, suite.store, suite.filestore, suite.extractor, suite.rag, nil, b)
 	suite.Require().NoError(err)
 
 	suite.reconciler.newRagClient = func(settings *types.RAGSettings) rag.RAG {
 		return suite.rag
 	}
 
-	suite.reconciler.newCrawler = func(k *types.Knowledge) (crawler.Crawler, error) {
+	suite.reconciler.newCrawler = func(k *types.Knowledge, _ *crawler.Auth) (crawler.Crawler, error) {
 		return suite.crawler, nil
 	}
 }
@@ -153,6 +153,17 @@ func (suite *IndexerSuite) TestIndex() {
 		KnowledgeID: knowledge.ID,
 	}).Return([]*types.KnowledgeVersion{}, nil)
 
+	suite.store.EXPECT().ReplaceKnowledgeDocuments(gomock.Any(), knowledge.ID, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, knowledgeID string, documents []*types.KnowledgeDocument) error {
+			suite.Require().Len(documents, 1)
+			suite.Equal("https://example.com", documents[0].Source)
+			suite.Equal(version, documents[0].Version)
+			suite.Equal(getDocumentID([]byte("Hello world!")), documents[0].DocumentID)
+
+			return nil
+		},
+	)
+
 	// Start indexing
 	suite.reconciler.index(suite.ctx)
 
@@ -0,0 +1,215 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/extract"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// ResolveLocalPath resolves the directory of a local knowledge source,
+// following symlinks, and checks that it is under one of the allowed roots
+func ResolveLocalPath(allowedRoots []string, dir string) (string, error) {
+	if len(allowedRoots) == 0 {
+		return "", fmt.Errorf("local knowledge sources are not enabled on this server")
+	}
+
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("local path '%s' must be absolute", dir)
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(dir))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve local path '%s': %w", dir, err)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat local path '%s': %w", dir, err)
+	}
+
+	if !info.IsDir() {
+		return "", fmt.Errorf("local path '%s' is not a directory", dir)
+	}
+
+	for _, root := range allowedRoots {
+		root, err := filepath.EvalSymlinks(filepath.Clean(root))
+		if err != nil {
+			// Roots that aren't mounted can't contain the path
+			continue
+		}
+
+		if isSubPath(root, resolved) {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("local path '%s' is not under an allowed root", dir)
+}
+
+func isSubPath(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// localFile is a file of a local knowledge source directory
+type localFile struct {
+	Path    string // Absolute path
+	RelPath string // Slash separated path relative to the source directory
+	Size    int64
+	ModTime int64
+}
+
+func (r *Reconciler) extractDataFromLocal(ctx context.Context, k *types.Knowledge) ([]*indexerData, error) {
+	source := k.Source.Local
+	if source == nil {
+		return nil, fmt.Errorf("no local source defined")
+	}
+
+	dir, err := ResolveLocalPath(r.config.RAG.Local.AllowedRoots, source.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newObjectFilter(source.Includes, source.Excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := listLocalFiles(dir, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files found in '%s' matching the filters", source.Path)
+	}
+
+	entries := make([]string, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, fmt.Sprintf("%s\t%d\t%d", f.RelPath, f.Size, f.ModTime))
+	}
+
+	fingerprint, err := getSourceFingerprint(k, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing to do if none of the files were added, removed or
+	// modified since the latest version
+	if k.Version != "" && k.SourceFingerprint == fingerprint {
+		return nil, errSourceUnchanged
+	}
+
+	log.Info().
+		Str("knowledge_id", k.ID).
+		Str("path", dir).
+		Int("count", len(files)).
+		Msg("local files found")
+
+	var result []*indexerData
+
+	for _, f := range files {
+		bts, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s, error: %w", f.RelPath, err)
+		}
+
+		if len(bts) == 0 {
+			continue
+		}
+
+		// Optional mode to disable text extractor and chunking,
+		// useful when the indexing server will know how to handle
+		// raw data directly
+		if !k.RAGSettings.DisableChunking {
+			extracted, err := r.extractor.Extract(ctx, &extract.ExtractRequest{
+				Content: bts,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract data from %s, error: %w", f.RelPath, err)
+			}
+			bts = []byte(extracted)
+		}
+
+		result = append(result, &indexerData{
+			Data:   bts,
+			Source: f.Path,
+			Metadata: map[string]string{
+				types.RAGMetadataLastModified: time.Unix(0, f.ModTime).UTC().Format(time.RFC3339),
+			},
+		})
+	}
+
+	k.SourceFingerprint = fingerprint
+
+	return result, nil
+}
+
+// listLocalFiles lists the regular files under dir matching the filter, sorted
+// by path. Symlinks are skipped so that they can't point outside of the root.
+func listLocalFiles(dir string, filter *objectFilter) ([]*localFile, error) {
+	var files []*localFile
+
+	err := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if filePath != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if !filter.match(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, &localFile{
+			Path:    filePath,
+			RelPath: rel,
+			Size:    info.Size(),
+			ModTime: info.ModTime().UnixNano(),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local files: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].RelPath < files[j].RelPath
+	})
+
+	return files, nil
+}
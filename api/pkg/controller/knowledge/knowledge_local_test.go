@@ -0,0 +1,203 @@
+package knowledge
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func writeLocalFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		filePath := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o755))
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+	}
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Local() {
+	root := suite.T().TempDir()
+	suite.cfg.RAG.Local.AllowedRoots = []string{root}
+
+	outside := suite.T().TempDir()
+	writeLocalFiles(suite.T(), outside, map[string]string{"secret.md": "secret"})
+
+	dir := filepath.Join(root, "docs")
+	writeLocalFiles(suite.T(), dir, map[string]string{
+		"README.md":        "# Docs",
+		"guide/install.md": "# Install",
+		"guide/logo.png":   "png",
+		".git/config":      "[core]",
+	})
+
+	// Symlinks could point outside of the allowed roots
+	suite.Require().NoError(os.Symlink(filepath.Join(outside, "secret.md"), filepath.Join(dir, "secret.md")))
+
+	knowledge := &types.Knowledge{
+		ID: "knowledge_id",
+		Source: types.KnowledgeSource{
+			Local: &types.KnowledgeSourceLocal{
+				Path:     dir,
+				Excludes: []string{"*.png"},
+			},
+		},
+	}
+
+	suite.extractor.EXPECT().Extract(gomock.Any(), gomock.Any()).Return("extracted", nil).Times(2)
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+
+	var sources []string
+	for _, d := range data {
+		sources = append(sources, d.Source)
+		suite.Equal("extracted", string(d.Data))
+	}
+
+	suite.Equal([]string{
+		filepath.Join(dir, "README.md"),
+		filepath.Join(dir, "guide", "install.md"),
+	}, sources)
+
+	knowledge.Version = "2024-01-01-00-00-00"
+
+	// Nothing changed
+	_, err = suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.ErrorIs(err, errSourceUnchanged)
+
+	// Added a file
+	writeLocalFiles(suite.T(), dir, map[string]string{"guide/usage.md": "# Usage"})
+
+	suite.extractor.EXPECT().Extract(gomock.Any(), gomock.Any()).Return("extracted", nil).Times(3)
+
+	data, err = suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Len(data, 3)
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Local_NotAllowed() {
+	suite.cfg.RAG.Local.AllowedRoots = []string{suite.T().TempDir()}
+
+	knowledge := &types.Knowledge{
+		ID: "knowledge_id",
+		Source: types.KnowledgeSource{
+			Local: &types.KnowledgeSourceLocal{
+				Path: suite.T().TempDir(),
+			},
+		},
+	}
+
+	_, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.ErrorContains(err, "not under an allowed root")
+}
+
+func TestResolveLocalPath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	writeLocalFiles(t, root, map[string]string{"docs/README.md": "# Docs"})
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+
+	tests := []struct {
+		name        string
+		roots       []string
+		path        string
+		expected    string
+		expectError bool
+	}{
+		{name: "Root", roots: []string{root}, path: root, expected: root},
+		{name: "Subdirectory", roots: []string{root}, path: filepath.Join(root, "docs"), expected: filepath.Join(root, "docs")},
+		{name: "Parent traversal", roots: []string{filepath.Join(root, "docs")}, path: filepath.Join(root, "docs", ".."), expectError: true},
+		{name: "Symlink outside of the root", roots: []string{root}, path: filepath.Join(root, "link"), expectError: true},
+		{name: "Outside of the roots", roots: []string{root}, path: outside, expectError: true},
+		{name: "Not a directory", roots: []string{root}, path: filepath.Join(root, "docs", "README.md"), expectError: true},
+		{name: "Relative path", roots: []string{root}, path: "docs", expectError: true},
+		{name: "No roots allowed", path: root, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := ResolveLocalPath(tt.roots, tt.path)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			expected, err := filepath.EvalSymlinks(tt.expected)
+			require.NoError(t, err)
+			assert.Equal(t, expected, resolved)
+		})
+	}
+}
+
+func (suite *IndexerSuite) Test_localWatcher_RefreshesKnowledge() {
+	root := suite.T().TempDir()
+	writeLocalFiles(suite.T(), root, map[string]string{"docs/README.md": "# Docs"})
+
+	suite.cfg.RAG.Local.AllowedRoots = []string{root}
+	suite.cfg.RAG.Local.WatchDebounce = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(suite.ctx)
+	defer cancel()
+
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		State: types.KnowledgeStateReady,
+		Source: types.KnowledgeSource{
+			Local: &types.KnowledgeSourceLocal{Path: root, Watch: true},
+		},
+	}
+
+	suite.store.EXPECT().ListKnowledge(gomock.Any(), gomock.Any()).Return([]*types.Knowledge{
+		knowledge,
+		// Not watched
+		{ID: "other", Source: types.KnowledgeSource{Local: &types.KnowledgeSourceLocal{Path: root}}},
+	}, nil)
+
+	suite.Require().NoError(suite.reconciler.reconcileLocalWatchers(ctx))
+	suite.Len(suite.reconciler.watchers, 1)
+
+	refreshed := make(chan *types.Knowledge, 10)
+
+	suite.store.EXPECT().GetKnowledge(gomock.Any(), "knowledge_id").Return(knowledge, nil)
+	suite.store.EXPECT().UpdateKnowledge(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, k *types.Knowledge) (*types.Knowledge, error) {
+			refreshed <- k
+			return k, nil
+		},
+	)
+
+	// A burst of changes, including a new directory, refreshes once
+	writeLocalFiles(suite.T(), root, map[string]string{"docs/README.md": "# Updated"})
+	suite.Require().NoError(os.Mkdir(filepath.Join(root, "guide"), 0o755))
+	time.Sleep(20 * time.Millisecond)
+	writeLocalFiles(suite.T(), root, map[string]string{"guide/install.md": "# Install"})
+
+	select {
+	case k := <-refreshed:
+		suite.Equal(types.KnowledgeStatePending, k.State)
+	case <-time.After(5 * time.Second):
+		suite.FailNow("knowledge was not refreshed")
+	}
+
+	select {
+	case <-refreshed:
+		suite.Fail("knowledge refreshed more than once")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// Watching disabled
+	suite.store.EXPECT().ListKnowledge(gomock.Any(), gomock.Any()).Return([]*types.Knowledge{}, nil)
+
+	suite.Require().NoError(suite.reconciler.reconcileLocalWatchers(ctx))
+	suite.Empty(suite.reconciler.watchers)
+}
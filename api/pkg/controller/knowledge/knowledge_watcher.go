@@ -0,0 +1,265 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// localWatcher watches the directory of a local knowledge source and
+// re-indexes the knowledge once the files stop changing. Changes made on
+// other NFS clients are not reported by inotify, the refresh schedule
+// still applies to pick those up.
+type localWatcher struct {
+	knowledgeID string
+	path        string
+	cancel      context.CancelFunc
+}
+
+// runLocalWatchers keeps a watcher running for every local knowledge source
+// that has watching enabled
+func (r *Reconciler) runLocalWatchers(ctx context.Context) {
+	defer r.stopLocalWatchers()
+
+	if len(r.config.RAG.Local.AllowedRoots) == 0 {
+		return
+	}
+
+	for {
+		err := r.reconcileLocalWatchers(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to reconcile local knowledge watchers")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+func (r *Reconciler) reconcileLocalWatchers(ctx context.Context) error {
+	knowledges, err := r.store.ListKnowledge(ctx, &store.ListKnowledgeQuery{})
+	if err != nil {
+		return fmt.Errorf("failed to list knowledges: %w", err)
+	}
+
+	paths := make(map[string]string) // knowledge id to resolved path
+
+	for _, k := range knowledges {
+		if k.Source.Local == nil || !k.Source.Local.Watch {
+			continue
+		}
+
+		dir, err := ResolveLocalPath(r.config.RAG.Local.AllowedRoots, k.Source.Local.Path)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("knowledge_id", k.ID).
+				Msg("not watching local knowledge source")
+			continue
+		}
+
+		paths[k.ID] = dir
+	}
+
+	r.watchersMu.Lock()
+	defer r.watchersMu.Unlock()
+
+	for id, w := range r.watchers {
+		if paths[id] != w.path {
+			log.Info().
+				Str("knowledge_id", id).
+				Str("path", w.path).
+				Msg("stopping local knowledge watcher")
+
+			w.cancel()
+			delete(r.watchers, id)
+		}
+	}
+
+	for id, dir := range paths {
+		if _, ok := r.watchers[id]; ok {
+			continue
+		}
+
+		w, err := r.startLocalWatcher(ctx, id, dir)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("knowledge_id", id).
+				Str("path", dir).
+				Msg("failed to watch local knowledge source")
+			continue
+		}
+
+		r.watchers[id] = w
+	}
+
+	return nil
+}
+
+func (r *Reconciler) stopLocalWatchers() {
+	r.watchersMu.Lock()
+	defer r.watchersMu.Unlock()
+
+	for id, w := range r.watchers {
+		w.cancel()
+		delete(r.watchers, id)
+	}
+}
+
+func (r *Reconciler) startLocalWatcher(ctx context.Context, knowledgeID, dir string) (*localWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	// inotify isn't recursive, every directory is watched
+	err = addWatchDirs(watcher, dir)
+	if err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	log.Info().
+		Str("knowledge_id", knowledgeID).
+		Str("path", dir).
+		Msg("watching local knowledge source")
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer watcher.Close()
+		r.watchLocalChanges(ctx, knowledgeID, watcher)
+	}()
+
+	return &localWatcher{
+		knowledgeID: knowledgeID,
+		path:        dir,
+		cancel:      cancel,
+	}, nil
+}
+
+// watchLocalChanges refreshes the knowledge once no changes were seen for
+// the debounce period, so that copying many files triggers a single refresh
+func (r *Reconciler) watchLocalChanges(ctx context.Context, knowledgeID string, watcher *fsnotify.Watcher) {
+	debounce := time.NewTimer(0)
+	if !debounce.Stop() {
+		<-debounce.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			debounce.Stop()
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+
+			if strings.HasPrefix(filepath.Base(event.Name), ".") {
+				continue
+			}
+
+			// Watch new directories too, files created in them before
+			// they were watched are picked up by the refresh
+			if event.Has(fsnotify.Create) {
+				if err := addWatchDirs(watcher, event.Name); err != nil {
+					log.Debug().Err(err).Str("path", event.Name).Msg("failed to watch new path")
+				}
+			}
+
+			debounce.Reset(r.config.RAG.Local.WatchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warn().
+				Err(err).
+				Str("knowledge_id", knowledgeID).
+				Msg("local knowledge watcher error")
+		case <-debounce.C:
+			retry, err := r.refreshLocalKnowledge(ctx, knowledgeID)
+			if err != nil {
+				log.Warn().
+					Err(err).
+					Str("knowledge_id", knowledgeID).
+					Msg("failed to refresh local knowledge")
+			}
+			if retry {
+				debounce.Reset(r.config.RAG.Local.WatchDebounce)
+			}
+		}
+	}
+}
+
+// refreshLocalKnowledge sets the knowledge to pending so the indexer
+// picks it up. Returns true if the knowledge is being indexed and the
+// refresh has to be retried, as the indexing may have missed the changes.
+func (r *Reconciler) refreshLocalKnowledge(ctx context.Context, knowledgeID string) (bool, error) {
+	k, err := r.store.GetKnowledge(ctx, knowledgeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get knowledge: %w", err)
+	}
+
+	switch k.State {
+	case types.KnowledgeStatePending:
+		return false, nil
+	case types.KnowledgeStateIndexing:
+		return true, nil
+	}
+
+	log.Info().
+		Str("knowledge_id", knowledgeID).
+		Msg("local knowledge files changed, refreshing")
+
+	k.State = types.KnowledgeStatePending
+	k.Message = ""
+
+	_, err = r.store.UpdateKnowledge(ctx, k)
+	if err != nil {
+		return false, fmt.Errorf("failed to update knowledge: %w", err)
+	}
+
+	return false, nil
+}
+
+// addWatchDirs adds dir and its subdirectories to the watcher, skipping
+// hidden directories and symlinks
+func addWatchDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		if err := watcher.Add(p); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", p, err)
+		}
+
+		return nil
+	})
+}
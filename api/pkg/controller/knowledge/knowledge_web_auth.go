@@ -0,0 +1,156 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/helixml/helix/api/pkg/controller/knowledge/crawler"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// getWebAuth resolves the web source credentials, looking up the secrets in
+// the app secrets. Returns nil if the source has no credentials.
+func (r *Reconciler) getWebAuth(ctx context.Context, k *types.Knowledge) (*crawler.Auth, error) {
+	if k.Source.Web == nil || !hasWebAuth(&k.Source.Web.Auth) {
+		return nil, nil
+	}
+
+	source := &k.Source.Web.Auth
+
+	var secrets map[string]string
+
+	if usesWebAuthSecrets(source) {
+		var err error
+
+		secrets, err = r.getAppSecrets(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	auth := &crawler.Auth{
+		Username: source.Username,
+		Password: source.Password,
+		Headers:  make(map[string]string),
+	}
+
+	for _, u := range k.Source.Web.URLs {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source URL '%s': %w", u, err)
+		}
+		auth.Hosts = append(auth.Hosts, parsed.Hostname())
+	}
+
+	if source.PasswordSecret != "" {
+		password, err := getSecret(secrets, source.PasswordSecret)
+		if err != nil {
+			return nil, err
+		}
+		auth.Password = password
+	}
+
+	if source.BearerTokenSecret != "" {
+		token, err := getSecret(secrets, source.BearerTokenSecret)
+		if err != nil {
+			return nil, err
+		}
+		auth.BearerToken = token
+	}
+
+	for name, value := range source.Headers {
+		auth.Headers[name] = value
+	}
+
+	for name, secretName := range source.HeaderSecrets {
+		value, err := getSecret(secrets, secretName)
+		if err != nil {
+			return nil, err
+		}
+		auth.Headers[name] = value
+	}
+
+	for _, c := range source.Cookies {
+		value := c.Value
+
+		if c.ValueSecret != "" {
+			var err error
+
+			value, err = getSecret(secrets, c.ValueSecret)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+
+		auth.Cookies = append(auth.Cookies, &http.Cookie{
+			Name:   c.Name,
+			Value:  value,
+			Domain: c.Domain,
+			Path:   path,
+		})
+	}
+
+	if form := source.FormLogin; form != nil {
+		password, err := getSecret(secrets, form.PasswordSecret)
+		if err != nil {
+			return nil, err
+		}
+
+		auth.FormLogin = &crawler.FormLogin{
+			URL:              form.URL,
+			Username:         form.Username,
+			Password:         password,
+			UsernameSelector: form.UsernameSelector,
+			PasswordSelector: form.PasswordSelector,
+			SubmitSelector:   form.SubmitSelector,
+			WaitSelector:     form.WaitSelector,
+		}
+	}
+
+	return auth, nil
+}
+
+// getDownloadAuth returns the web source credentials for direct downloads,
+// logging in first if the source has a form login
+func (r *Reconciler) getDownloadAuth(ctx context.Context, k *types.Knowledge) (*crawler.Auth, error) {
+	auth, err := r.getWebAuth(ctx, k)
+	if err != nil || auth == nil || auth.FormLogin == nil {
+		return auth, err
+	}
+
+	cookies, err := crawler.LoginCookies(ctx, r.browser, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	auth.Cookies = cookies
+	auth.FormLogin = nil
+
+	return auth, nil
+}
+
+func hasWebAuth(a *types.KnowledgeSourceWebAuth) bool {
+	return a.Username != "" || a.Password != "" || usesWebAuthSecrets(a) ||
+		len(a.Headers) > 0 || len(a.Cookies) > 0
+}
+
+func usesWebAuthSecrets(a *types.KnowledgeSourceWebAuth) bool {
+	if a.PasswordSecret != "" || a.BearerTokenSecret != "" || len(a.HeaderSecrets) > 0 || a.FormLogin != nil {
+		return true
+	}
+
+	for _, c := range a.Cookies {
+		if c.ValueSecret != "" {
+			return true
+		}
+	}
+
+	return false
+}
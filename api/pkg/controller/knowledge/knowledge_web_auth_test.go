@@ -0,0 +1,158 @@
+package knowledge
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/controller/knowledge/crawler"
+	"github.com/helixml/helix/api/pkg/extract"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func (suite *ExtractorSuite) authenticatedServer() *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Api-Key") != "api-key" ||
+			r.Header.Get("X-Tenant") != "docs" || err != nil || cookie.Value != "session-id" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte("intranet page"))
+	}))
+	suite.T().Cleanup(server.Close)
+
+	suite.store.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{Owner: "user_id"}).Return([]*types.Secret{
+		{Name: "WIKI_TOKEN", Value: []byte("token")},
+		{Name: "WIKI_API_KEY", Value: []byte("api-key")},
+		{Name: "WIKI_SESSION", Value: []byte("session-id")},
+		{Name: "WIKI_SESSION", Value: []byte("other-app"), AppID: "other_app_id"},
+	}, nil)
+
+	return server
+}
+
+func (suite *ExtractorSuite) authenticatedKnowledge(u string) *types.Knowledge {
+	return &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: "user_id",
+		AppID: "app_id",
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs: []string{u},
+				Auth: types.KnowledgeSourceWebAuth{
+					BearerTokenSecret: "WIKI_TOKEN",
+					Headers:           map[string]string{"X-Tenant": "docs"},
+					HeaderSecrets:     map[string]string{"X-Api-Key": "WIKI_API_KEY"},
+					Cookies: []types.KnowledgeSourceWebCookie{
+						{Name: "session", ValueSecret: "WIKI_SESSION"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Web_Auth_Download() {
+	server := suite.authenticatedServer()
+
+	knowledge := suite.authenticatedKnowledge(server.URL)
+	knowledge.RAGSettings.DisableChunking = true
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 1)
+	suite.Equal("intranet page", string(data[0].Data))
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Web_Auth_Extract() {
+	server := suite.authenticatedServer()
+
+	knowledge := suite.authenticatedKnowledge(server.URL)
+
+	// Downloaded with the credentials instead of by the extractor
+	suite.extractor.EXPECT().Extract(gomock.Any(), &extract.ExtractRequest{
+		Content: []byte("intranet page"),
+	}).Return("extracted", nil)
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 1)
+	suite.Equal("extracted", string(data[0].Data))
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Web_Auth_Crawler() {
+	knowledge := &types.Knowledge{
+		ID:    "knowledge_id",
+		Owner: "user_id",
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs: []string{"https://wiki.example.com"},
+				Auth: types.KnowledgeSourceWebAuth{
+					Username: "helix",
+					FormLogin: &types.KnowledgeSourceWebFormLogin{
+						URL:              "https://wiki.example.com/login",
+						Username:         "helix",
+						PasswordSecret:   "WIKI_PASSWORD",
+						UsernameSelector: "#username",
+						PasswordSelector: "#password",
+					},
+				},
+				Crawler: &types.WebsiteCrawler{
+					Enabled: true,
+				},
+			},
+		},
+	}
+
+	suite.store.EXPECT().ListSecrets(gomock.Any(), gomock.Any()).Return([]*types.Secret{
+		{Name: "WIKI_PASSWORD", Value: []byte("secret")},
+	}, nil)
+
+	suite.reconciler.newCrawler = func(_ *types.Knowledge, auth *crawler.Auth) (crawler.Crawler, error) {
+		suite.Equal("helix", auth.Username)
+		suite.Equal([]string{"wiki.example.com"}, auth.Hosts)
+		suite.Equal(&crawler.FormLogin{
+			URL:              "https://wiki.example.com/login",
+			Username:         "helix",
+			Password:         "secret",
+			UsernameSelector: "#username",
+			PasswordSelector: "#password",
+		}, auth.FormLogin)
+		return suite.crawler, nil
+	}
+
+	suite.crawler.EXPECT().Crawl(gomock.Any()).Return([]*types.CrawledDocument{
+		{SourceURL: "https://wiki.example.com/page", Content: "page"},
+	}, nil)
+
+	_, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+}
+
+func (suite *ExtractorSuite) Test_getWebAuth_MissingSecret() {
+	knowledge := &types.Knowledge{
+		Owner: "user_id",
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				Auth: types.KnowledgeSourceWebAuth{BearerTokenSecret: "MISSING"},
+			},
+		},
+	}
+
+	suite.store.EXPECT().ListSecrets(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	_, err := suite.reconciler.getWebAuth(suite.ctx, knowledge)
+	suite.ErrorContains(err, "secret 'MISSING' not found")
+
+	// No credentials, secrets aren't listed
+	auth, err := suite.reconciler.getWebAuth(suite.ctx, &types.Knowledge{
+		Source: types.KnowledgeSource{Web: &types.KnowledgeSourceWeb{}},
+	})
+	suite.Require().NoError(err)
+	suite.Nil(auth)
+}
@@ -112,6 +112,8 @@ func (q *Query) getDocuments(ctx context.Context, prompt string, knowledges []*t
 				DistanceThreshold: knowledge.RAGSettings.Threshold,
 				DistanceFunction:  knowledge.RAGSettings.DistanceFunction,
 				MaxResults:        knowledge.RAGSettings.ResultsCount,
+				RetrievalMode:     knowledge.RAGSettings.RetrievalMode,
+				HybridAlpha:       knowledge.RAGSettings.HybridAlpha,
 			})
 			if err != nil {
 				return nil, fmt.Errorf("error querying RAG: %w", err)
@@ -1,16 +1,69 @@
 package knowledge
 
 import (
+	"context"
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
 
 	"github.com/helixml/helix/api/pkg/dataprep/text"
+	"github.com/helixml/helix/api/pkg/openai/manager"
 	"github.com/helixml/helix/api/pkg/types"
-	"github.com/rs/zerolog/log"
 
 	"github.com/tmc/langchaingo/textsplitter"
 )
 
-func splitData(k *types.Knowledge, data []*indexerData) ([]*text.DataPrepTextSplitterChunk, error) {
+// splitterEmbeddings are used by the token and semantic splitters, the
+// tokenizer matches the embeddings model unless the knowledge sets one
+type splitterEmbeddings struct {
+	model string
+	embed text.EmbedFunc
+}
+
+// getSplitterEmbeddings uses the embeddings provider and model of the server
+func (r *Reconciler) getSplitterEmbeddings() *splitterEmbeddings {
+	cfg := r.config.RAG.PGVector
+
+	embeddings := &splitterEmbeddings{model: cfg.EmbeddingsModel}
+
+	if r.providerManager == nil {
+		return embeddings
+	}
+
+	embeddings.embed = func(ctx context.Context, input []string) ([][]float32, error) {
+		client, err := r.providerManager.GetClient(ctx, &manager.GetClientRequest{Provider: cfg.Provider})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get embeddings client: %w", err)
+		}
+
+		resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+			Input: input,
+			Model: openai.EmbeddingModel(cfg.EmbeddingsModel),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embeddings: %w", err)
+		}
+
+		result := make([][]float32, len(input))
+		for _, data := range resp.Data {
+			if data.Index < 0 || data.Index >= len(input) {
+				return nil, fmt.Errorf("invalid embedding index %d", data.Index)
+			}
+			result[data.Index] = data.Embedding
+		}
+
+		return result, nil
+	}
+
+	return embeddings
+}
+
+func splitData(ctx context.Context, k *types.Knowledge, data []*indexerData, embeddings *splitterEmbeddings) ([]*text.DataPrepTextSplitterChunk, error) {
 	var chunks []*text.DataPrepTextSplitterChunk
 
 	switch k.RAGSettings.TextSplitter {
@@ -30,43 +83,330 @@ func splitData(k *types.Knowledge, data []*indexerData) ([]*text.DataPrepTextSpl
 		documentGroupID := k.ID
 
 		for _, d := range data {
-			_, err := splitter.AddDocument(d.Source, string(d.Data), documentGroupID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to split %s, error %w", d.Source, err)
+			documentID := getDocumentID(d.Data)
+			idx := 0
+
+			for _, page := range getDocumentPages(string(d.Data)) {
+				start := len(splitter.Chunks)
+
+				_, err := splitter.AddDocument(d.Source, page.Text, documentGroupID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to split %s, error %w", d.Source, err)
+				}
+
+				// Pages are split separately, the chunks belong to the whole document
+				for _, chunk := range splitter.Chunks[start:] {
+					chunk.Index = idx
+					chunk.DocumentID = documentID
+					chunk.Metadata = getChunkMetadata(d, page.Number, nil)
+					idx++
+				}
 			}
 		}
 
 		return splitter.Chunks, nil
 	default:
+		splitter, err := getTextSplitter(k, embeddings)
+		if err != nil {
+			return nil, err
+		}
+
 		log.Info().
 			Str("knowledge_id", k.ID).
+			Str("text_splitter", string(k.RAGSettings.TextSplitter)).
 			Int("chunk_size", k.RAGSettings.ChunkSize).
 			Int("chunk_overlap", k.RAGSettings.ChunkOverflow).
-			Msgf("splitting data with markdown text splitter")
+			Msgf("splitting data")
+
+		for _, d := range data {
+			fileSplitter := textsplitter.TextSplitter(splitter)
 
-		splitter := textsplitter.NewMarkdownTextSplitter(
+			// Repository files are split on the syntax of their language
+			if k.Source.Github != nil {
+				fileSplitter = getLanguageSplitter(k, d.Source, splitter)
+			}
+
+			var (
+				documentID = getDocumentID(d.Data)
+				headings   headingTracker
+				idx        int
+			)
+
+			for _, page := range getDocumentPages(string(d.Data)) {
+				parts, err := splitText(ctx, fileSplitter, page.Text)
+				if err != nil {
+					return nil, fmt.Errorf("failed to split %s, error %w", d.Source, err)
+				}
+
+				for _, part := range parts {
+					var path []string
+
+					// Lines starting with # are comments in source code
+					if fileSplitter == splitter {
+						path = headings.track(part)
+					}
+
+					chunks = append(chunks, &text.DataPrepTextSplitterChunk{
+						Filename:        d.Source,
+						Index:           idx,
+						Text:            string(part),
+						DocumentID:      documentID,
+						DocumentGroupID: k.ID,
+						Metadata:        getChunkMetadata(d, page.Number, path),
+					})
+					idx++
+				}
+			}
+		}
+	}
+
+	return chunks, nil
+}
+
+// getTextSplitter returns the splitter of the knowledge, markdown if it's
+// not set
+func getTextSplitter(k *types.Knowledge, embeddings *splitterEmbeddings) (textsplitter.TextSplitter, error) {
+	if embeddings == nil {
+		embeddings = &splitterEmbeddings{}
+	}
+
+	tokenizerModel := k.RAGSettings.TokenizerModel
+	if tokenizerModel == "" {
+		tokenizerModel = embeddings.model
+	}
+
+	switch k.RAGSettings.TextSplitter {
+	case types.TextSplitterTypeToken:
+		return text.NewTokenSplitter(text.NewTokenizer(tokenizerModel), k.RAGSettings.ChunkSize, k.RAGSettings.ChunkOverflow), nil
+	case types.TextSplitterTypeRecursive:
+		return text.NewRecursiveSplitter(k.RAGSettings.ChunkSize, k.RAGSettings.ChunkOverflow, k.RAGSettings.Separators...), nil
+	case types.TextSplitterTypeSentence:
+		return text.NewSentenceSplitter(k.RAGSettings.ChunkSize, k.RAGSettings.ChunkOverflow), nil
+	case types.TextSplitterTypeSemantic:
+		if embeddings.embed == nil {
+			return nil, fmt.Errorf("semantic splitter needs an embeddings provider")
+		}
+		return text.NewSemanticSplitter(embeddings.embed, text.NewTokenizer(tokenizerModel), k.RAGSettings.ChunkSize, k.RAGSettings.SemanticBreakpointPercentile), nil
+	case types.TextSplitterTypeMarkdown, "":
+		return textsplitter.NewMarkdownTextSplitter(
 			textsplitter.WithChunkSize(k.RAGSettings.ChunkSize),
 			textsplitter.WithChunkOverlap(k.RAGSettings.ChunkOverflow),
 			textsplitter.WithCodeBlocks(true),
-		)
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown text splitter: %s", k.RAGSettings.TextSplitter)
+	}
+}
 
-		for _, d := range data {
-			parts, err := splitter.SplitText(string(d.Data))
-			if err != nil {
-				return nil, fmt.Errorf("failed to split %s, error %w", d.Source, err)
-			}
+// splitText passes the context to the splitters calling the embeddings
+// provider
+func splitText(ctx context.Context, splitter textsplitter.TextSplitter, content string) ([]string, error) {
+	if semantic, ok := splitter.(*text.SemanticSplitter); ok {
+		return semantic.SplitTextContext(ctx, content)
+	}
+
+	return splitter.SplitText(content)
+}
+
+// documentPage is the text of a page, number is 0 if the document
+// isn't paginated
+type documentPage struct {
+	Number int
+	Text   string
+}
+
+// getDocumentPages splits the extracted text on the form feeds that PDF
+// extractors put between pages, blank pages are skipped
+func getDocumentPages(content string) []*documentPage {
+	if !strings.Contains(content, "\f") {
+		return []*documentPage{{Text: content}}
+	}
 
-			for idx, part := range parts {
-				chunks = append(chunks, &text.DataPrepTextSplitterChunk{
-					Filename:        d.Source,
-					Index:           idx,
-					Text:            string(part),
-					DocumentID:      getDocumentID(d.Data),
-					DocumentGroupID: k.ID,
-				})
+	var pages []*documentPage
+
+	for i, page := range strings.Split(content, "\f") {
+		if strings.TrimSpace(page) == "" {
+			continue
+		}
+
+		pages = append(pages, &documentPage{Number: i + 1, Text: page})
+	}
+
+	return pages
+}
+
+// getChunkMetadata combines the document metadata with the page and the
+// section headings of the chunk
+func getChunkMetadata(d *indexerData, page int, headings []string) map[string]string {
+	metadata := make(map[string]string, len(d.Metadata)+3)
+
+	for k, v := range d.Metadata {
+		metadata[k] = v
+	}
+
+	if page > 0 {
+		metadata[types.RAGMetadataPage] = strconv.Itoa(page)
+	}
+
+	if len(headings) > 0 {
+		metadata[types.RAGMetadataHeadings] = strings.Join(headings, " > ")
+		metadata[types.RAGMetadataAnchor] = getHeadingAnchor(headings[len(headings)-1])
+	}
+
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	return metadata
+}
+
+// headingTracker follows the markdown headings across the chunks of a
+// document, the splitter repeats the current heading at the start of
+// the chunks
+type headingTracker struct {
+	levels []int
+	titles []string
+}
+
+// track updates the headings with the chunk and returns the section path
+// at the start of its content
+func (h *headingTracker) track(chunk string) []string {
+	var (
+		path    []string
+		content bool
+		fenced  bool
+	)
+
+	for _, line := range strings.Split(chunk, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+		}
+
+		level, title := parseHeading(line)
+		if fenced || level == 0 {
+			if trimmed != "" && !content {
+				content = true
+				path = h.path()
 			}
+			continue
 		}
+
+		for len(h.levels) > 0 && h.levels[len(h.levels)-1] >= level {
+			h.levels = h.levels[:len(h.levels)-1]
+			h.titles = h.titles[:len(h.titles)-1]
+		}
+
+		h.levels = append(h.levels, level)
+		h.titles = append(h.titles, title)
 	}
 
-	return chunks, nil
+	// Chunk with only headings
+	if !content {
+		path = h.path()
+	}
+
+	return path
+}
+
+func (h *headingTracker) path() []string {
+	if len(h.titles) == 0 {
+		return nil
+	}
+
+	return append([]string(nil), h.titles...)
+}
+
+// parseHeading returns the level and title of an ATX heading line, level
+// is 0 if the line isn't a heading
+func parseHeading(line string) (int, string) {
+	// Up to 3 spaces of indentation, more is a code block
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return 0, ""
+	}
+
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+
+	title := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#"))
+	if title == "" {
+		return 0, ""
+	}
+
+	return level, title
+}
+
+// getHeadingAnchor returns the GitHub style anchor of the heading, most
+// documentation generators use the same format
+func getHeadingAnchor(heading string) string {
+	var sb strings.Builder
+
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteRune('-')
+		}
+	}
+
+	return sb.String()
+}
+
+// languageSeparators are the boundaries source code is split at, from the
+// top level declarations down to lines and words
+var languageSeparators = map[string][]string{
+	".go":   {"\nfunc ", "\nvar ", "\nconst ", "\ntype ", "\n\n", "\n", " ", ""},
+	".py":   {"\nclass ", "\ndef ", "\n\tdef ", "\n    def ", "\n\n", "\n", " ", ""},
+	".js":   {"\nfunction ", "\nconst ", "\nlet ", "\nvar ", "\nclass ", "\nexport ", "\n\n", "\n", " ", ""},
+	".ts":   {"\nfunction ", "\nconst ", "\nlet ", "\nvar ", "\nclass ", "\ninterface ", "\ntype ", "\nexport ", "\n\n", "\n", " ", ""},
+	".java": {"\nclass ", "\npublic ", "\nprotected ", "\nprivate ", "\nstatic ", "\n\n", "\n", " ", ""},
+	".rs":   {"\nfn ", "\npub fn ", "\nconst ", "\nstruct ", "\nenum ", "\nimpl ", "\ntrait ", "\nmod ", "\n\n", "\n", " ", ""},
+	".rb":   {"\ndef ", "\nclass ", "\nmodule ", "\n\n", "\n", " ", ""},
+	".c":    {"\nstruct ", "\nstatic ", "\nvoid ", "\nint ", "\n\n", "\n", " ", ""},
+	".cpp":  {"\nclass ", "\nnamespace ", "\nstruct ", "\nstatic ", "\nvoid ", "\nint ", "\n\n", "\n", " ", ""},
+}
+
+// languageAliases maps extensions sharing the separators of another language
+var languageAliases = map[string]string{
+	".jsx": ".js",
+	".mjs": ".js",
+	".tsx": ".ts",
+	".h":   ".c",
+	".cc":  ".cpp",
+	".hpp": ".cpp",
+}
+
+// getLanguageSplitter returns a splitter for the source code language of the
+// file, falling back to the markdown splitter for docs and other text
+func getLanguageSplitter(k *types.Knowledge, filename string, fallback textsplitter.TextSplitter) textsplitter.TextSplitter {
+	ext := strings.ToLower(path.Ext(filename))
+	if alias, ok := languageAliases[ext]; ok {
+		ext = alias
+	}
+
+	separators, ok := languageSeparators[ext]
+	if !ok {
+		return fallback
+	}
+
+	return textsplitter.NewRecursiveCharacter(
+		textsplitter.WithChunkSize(k.RAGSettings.ChunkSize),
+		textsplitter.WithChunkOverlap(k.RAGSettings.ChunkOverflow),
+		textsplitter.WithSeparators(separators),
+		textsplitter.WithKeepSeparator(true),
+	)
 }
@@ -1,7 +1,9 @@
 package knowledge
 
 import (
+	"context"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/helixml/helix/api/pkg/types"
@@ -18,10 +20,10 @@ func TestSplitData_Markdown(t *testing.T) {
 	k.RAGSettings.ChunkOverflow = 20
 	k.RAGSettings.TextSplitter = types.TextSplitterTypeMarkdown
 
-	chunks, err := splitData(k, []*indexerData{{
+	chunks, err := splitData(context.Background(), k, []*indexerData{{
 		Source: "example_code.md",
 		Data:   contents,
-	}})
+	}}, nil)
 	require.NoError(t, err)
 
 	assert.Equal(t, 1, len(chunks))
@@ -29,3 +31,175 @@ func TestSplitData_Markdown(t *testing.T) {
 	assert.Contains(t, chunks[0].Text, "For example if the payload fragment looks like this:")
 	assert.Contains(t, chunks[0].Text, "local encoded_payload, err = json.encode(json_payload)")
 }
+
+func TestSplitData_GithubCode(t *testing.T) {
+	code := `package main
+
+func first() {
+	println("first function body with some padding text")
+}
+
+func second() {
+	println("second function body with some padding text")
+}
+`
+
+	k := &types.Knowledge{
+		Source: types.KnowledgeSource{
+			Github: &types.KnowledgeSourceGithub{Owner: "helixml", Repository: "helix"},
+		},
+	}
+	k.RAGSettings.ChunkSize = 100
+	k.RAGSettings.ChunkOverflow = 0
+
+	chunks, err := splitData(context.Background(), k, []*indexerData{{
+		Source: "https://github.com/helixml/helix/blob/abc/main.go",
+		Data:   []byte(code),
+	}}, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(chunks))
+
+	// Split at the function boundary
+	assert.True(t, strings.HasPrefix(chunks[0].Text, "package main"), chunks[0].Text)
+	assert.True(t, strings.HasSuffix(chunks[0].Text, "}"), chunks[0].Text)
+	assert.True(t, strings.HasPrefix(strings.TrimSpace(chunks[1].Text), "func second()"), chunks[1].Text)
+	assert.Equal(t, "https://github.com/helixml/helix/blob/abc/main.go", chunks[1].Filename)
+}
+
+func TestSplitData_Metadata(t *testing.T) {
+	doc := `# Install
+
+Download the installer for your platform and run it with the default options.
+
+## Linux
+
+Run the install script from the terminal, it sets up the service for you.
+
+` + "```sh\n# not a heading\ncurl -sL https://get.helix.ml | bash\n```" + `
+
+# Usage
+
+Start the service and open the dashboard in your browser to get going.
+`
+
+	k := &types.Knowledge{ID: "knowledge_id"}
+	k.RAGSettings.ChunkSize = 100
+	k.RAGSettings.ChunkOverflow = 0
+
+	chunks, err := splitData(context.Background(), k, []*indexerData{{
+		Source:   "https://docs.helix.ml/install",
+		Data:     []byte(doc),
+		Metadata: map[string]string{types.RAGMetadataLastModified: "2024-05-01T00:00:00Z"},
+	}}, nil)
+	require.NoError(t, err)
+	require.Greater(t, len(chunks), 2)
+
+	first := chunks[0]
+	assert.Equal(t, "Install", first.Metadata[types.RAGMetadataHeadings])
+	assert.Equal(t, "install", first.Metadata[types.RAGMetadataAnchor])
+	assert.Equal(t, "2024-05-01T00:00:00Z", first.Metadata[types.RAGMetadataLastModified])
+
+	var headings []string
+	for i, chunk := range chunks {
+		assert.Equal(t, i, chunk.Index)
+		assert.Equal(t, getDocumentID([]byte(doc)), chunk.DocumentID)
+		headings = append(headings, chunk.Metadata[types.RAGMetadataHeadings])
+	}
+
+	assert.Contains(t, headings, "Install > Linux")
+	assert.NotContains(t, headings, "Install > Linux > not a heading")
+	assert.Equal(t, "Usage", headings[len(headings)-1])
+	assert.Equal(t, "usage", chunks[len(chunks)-1].Metadata[types.RAGMetadataAnchor])
+}
+
+func TestSplitData_Pages(t *testing.T) {
+	k := &types.Knowledge{ID: "knowledge_id"}
+	k.RAGSettings.ChunkSize = 1000
+	k.RAGSettings.ChunkOverflow = 0
+
+	// Extracted PDF, pages separated by form feeds
+	data := []byte("first page\fsecond page\f\f fourth page\f")
+
+	for _, splitter := range []types.TextSplitterType{types.TextSplitterTypeMarkdown, types.TextSplitterTypeText} {
+		k.RAGSettings.TextSplitter = splitter
+
+		chunks, err := splitData(context.Background(), k, []*indexerData{{Source: "report.pdf", Data: data}}, nil)
+		require.NoError(t, err)
+		require.Len(t, chunks, 3, splitter)
+
+		for i, page := range []string{"1", "2", "4"} {
+			assert.Equal(t, page, chunks[i].Metadata[types.RAGMetadataPage], splitter)
+			assert.Equal(t, i, chunks[i].Index, splitter)
+			assert.Equal(t, getDocumentID(data), chunks[i].DocumentID, splitter)
+		}
+	}
+
+	// Not paginated
+	chunks, err := splitData(context.Background(), k, []*indexerData{{Source: "notes.txt", Data: []byte("notes")}}, nil)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Nil(t, chunks[0].Metadata)
+}
+
+func Test_getHeadingAnchor(t *testing.T) {
+	assert.Equal(t, "getting-started", getHeadingAnchor("Getting Started"))
+	assert.Equal(t, "whats-new-in-v12", getHeadingAnchor("What's new in v1.2?"))
+	assert.Equal(t, "api_key-setup", getHeadingAnchor("`API_KEY` setup"))
+}
+
+func TestSplitData_Splitters(t *testing.T) {
+	data := []*indexerData{{
+		Source: "notes.md",
+		Data:   []byte("# Notes\n\nCats sleep a lot. Rockets need fuel.\n\nThe end."),
+	}}
+
+	embeddings := &splitterEmbeddings{
+		model: "nomic-embed-text",
+		embed: func(_ context.Context, input []string) ([][]float32, error) {
+			result := make([][]float32, len(input))
+			for i := range input {
+				result[i] = []float32{1, 1}
+			}
+			return result, nil
+		},
+	}
+
+	for _, splitterType := range []types.TextSplitterType{
+		types.TextSplitterTypeToken,
+		types.TextSplitterTypeRecursive,
+		types.TextSplitterTypeSentence,
+		types.TextSplitterTypeSemantic,
+	} {
+		t.Run(string(splitterType), func(t *testing.T) {
+			k := &types.Knowledge{ID: "knowledge_id"}
+			k.RAGSettings.TextSplitter = splitterType
+			k.RAGSettings.ChunkSize = 512
+			k.RAGSettings.ChunkOverflow = 20
+
+			chunks, err := splitData(context.Background(), k, data, embeddings)
+			require.NoError(t, err)
+			require.NotEmpty(t, chunks)
+
+			assert.Contains(t, chunks[0].Text, "Cats sleep a lot.")
+			assert.Equal(t, "Notes", chunks[0].Metadata[types.RAGMetadataHeadings])
+		})
+	}
+
+	t.Run("semantic without embeddings", func(t *testing.T) {
+		k := &types.Knowledge{}
+		k.RAGSettings.TextSplitter = types.TextSplitterTypeSemantic
+		k.RAGSettings.ChunkSize = 512
+
+		_, err := splitData(context.Background(), k, data, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		k := &types.Knowledge{}
+		k.RAGSettings.TextSplitter = "unknown"
+
+		_, err := splitData(context.Background(), k, data, nil)
+		require.Error(t, err)
+	})
+}
@@ -2,6 +2,7 @@ package knowledge
 
 import (
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/helixml/helix/api/pkg/types"
@@ -38,6 +39,83 @@ func Validate(k *types.AssistantKnowledge) error {
 				return fmt.Errorf("firecrawl api key is required")
 			}
 		}
+
+		for _, pattern := range append(k.Source.Web.Includes, k.Source.Web.Excludes...) {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid url regex '%s': %w", pattern, err)
+			}
+		}
+
+		if err := validateWebAuth(k.Source.Web); err != nil {
+			return err
+		}
+	}
+
+	if k.Source.S3 != nil {
+		if k.Source.S3.Bucket == "" {
+			return fmt.Errorf("s3 bucket is required")
+		}
+
+		if (k.Source.S3.AccessKeyIDSecret == "") != (k.Source.S3.SecretAccessKeySecret == "") {
+			return fmt.Errorf("s3 access key id and secret access key secrets must be set together")
+		}
+
+		if _, err := newObjectFilter(k.Source.S3.Includes, k.Source.S3.Excludes); err != nil {
+			return err
+		}
+	}
+
+	if k.Source.GCS != nil {
+		if k.Source.GCS.Bucket == "" {
+			return fmt.Errorf("gcs bucket is required")
+		}
+
+		if _, err := newObjectFilter(k.Source.GCS.Includes, k.Source.GCS.Excludes); err != nil {
+			return err
+		}
+	}
+
+	if k.Source.Github != nil {
+		if err := validateGithubRepository(k.Source.Github); err != nil {
+			return err
+		}
+	}
+
+	if k.Source.Local != nil {
+		if k.Source.Local.Path == "" {
+			return fmt.Errorf("local path is required")
+		}
+
+		if _, err := newObjectFilter(k.Source.Local.Includes, k.Source.Local.Excludes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateWebAuth(source *types.KnowledgeSourceWeb) error {
+	for _, c := range source.Auth.Cookies {
+		if c.Name == "" {
+			return fmt.Errorf("cookie name is required")
+		}
+	}
+
+	form := source.Auth.FormLogin
+	if form == nil {
+		return nil
+	}
+
+	if source.Crawler != nil && source.Crawler.Firecrawl != nil {
+		return fmt.Errorf("form login is not supported with firecrawl")
+	}
+
+	if form.URL == "" || form.UsernameSelector == "" || form.PasswordSelector == "" {
+		return fmt.Errorf("form login url, username selector and password selector are required")
+	}
+
+	if form.PasswordSecret == "" {
+		return fmt.Errorf("form login password secret is required")
 	}
 
 	return nil
@@ -68,6 +68,86 @@ func TestValidate(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "S3 source without bucket",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					S3: &types.KnowledgeSourceS3{Path: "docs/"},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "S3 source with only the access key secret",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					S3: &types.KnowledgeSourceS3{Bucket: "docs", AccessKeyIDSecret: "AWS_ACCESS_KEY_ID"},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "S3 source with invalid include pattern",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					S3: &types.KnowledgeSourceS3{Bucket: "docs", Includes: []string{"[a-"}},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Valid GCS source",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					GCS: &types.KnowledgeSourceGCS{Bucket: "docs", Includes: []string{"*.pdf"}},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Local source without path",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Local: &types.KnowledgeSourceLocal{Watch: true},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Web source with invalid include regex",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Web: &types.KnowledgeSourceWeb{URLs: []string{"https://example.com"}, Includes: []string{"/docs/("}},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Web source form login without password secret",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Web: &types.KnowledgeSourceWeb{
+						URLs: []string{"https://wiki.example.com"},
+						Auth: types.KnowledgeSourceWebAuth{
+							FormLogin: &types.KnowledgeSourceWebFormLogin{
+								URL:              "https://wiki.example.com/login",
+								Username:         "helix",
+								UsernameSelector: "#username",
+								PasswordSelector: "#password",
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
 		// Add more test cases for web source validation if needed
 	}
 
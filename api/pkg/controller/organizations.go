@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// HasOwnerAccess returns true if the user owns the resource directly or, for
+// resources owned by an organization, has at least the given role in it
+func (c *Controller) HasOwnerAccess(ctx context.Context, userID, owner string, ownerType types.OwnerType, role types.OrganizationRole) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+
+	if ownerType != types.OwnerTypeOrg {
+		return owner == userID, nil
+	}
+
+	membership, err := c.Options.Store.GetOrganizationMembership(ctx, owner, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get organization membership: %w", err)
+	}
+
+	return membership.Role.Includes(role), nil
+}
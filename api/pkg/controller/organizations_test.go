@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+	"go.uber.org/mock/gomock"
+)
+
+func (suite *ControllerSuite) Test_HasOwnerAccess_User() {
+	ok, err := suite.controller.HasOwnerAccess(suite.ctx, suite.user.ID, suite.user.ID, types.OwnerTypeUser, types.OrganizationRoleAdmin)
+	suite.NoError(err)
+	suite.True(ok)
+
+	ok, err = suite.controller.HasOwnerAccess(suite.ctx, suite.user.ID, "someone_else", types.OwnerTypeUser, types.OrganizationRoleMember)
+	suite.NoError(err)
+	suite.False(ok)
+}
+
+func (suite *ControllerSuite) Test_HasOwnerAccess_Organization() {
+	suite.store.EXPECT().GetOrganizationMembership(gomock.Any(), "org_id", suite.user.ID).Return(&types.OrganizationMembership{
+		OrganizationID: "org_id",
+		UserID:         suite.user.ID,
+		Role:           types.OrganizationRoleMember,
+	}, nil).Times(2)
+
+	ok, err := suite.controller.HasOwnerAccess(suite.ctx, suite.user.ID, "org_id", types.OwnerTypeOrg, types.OrganizationRoleMember)
+	suite.NoError(err)
+	suite.True(ok)
+
+	ok, err = suite.controller.HasOwnerAccess(suite.ctx, suite.user.ID, "org_id", types.OwnerTypeOrg, types.OrganizationRoleAdmin)
+	suite.NoError(err)
+	suite.False(ok)
+
+	suite.store.EXPECT().GetOrganizationMembership(gomock.Any(), "other_org_id", suite.user.ID).Return(nil, store.ErrNotFound)
+
+	ok, err = suite.controller.HasOwnerAccess(suite.ctx, suite.user.ID, "other_org_id", types.OwnerTypeOrg, types.OrganizationRoleMember)
+	suite.NoError(err)
+	suite.False(ok)
+}
+
+func (suite *ControllerSuite) Test_EvaluateSecrets_OrganizationApp() {
+	app := &types.App{
+		ID:        "app_id",
+		Owner:     "org_id",
+		OwnerType: types.OwnerTypeOrg,
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Assistants: []types.AssistantConfig{
+					{
+						ID:           "0",
+						SystemPrompt: "${ORG_KEY} [${OTHER_KEY}] ${SHARED_KEY}",
+					},
+				},
+			},
+		},
+	}
+
+	suite.store.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{
+		Owner:     "org_id",
+		OwnerType: types.OwnerTypeOrg,
+	}).Return([]*types.Secret{
+		{Name: "ORG_KEY", Value: []byte("org_value"), AppID: "app_id"},
+		{Name: "SHARED_KEY", Value: []byte("org_shared_value"), AppID: "app_id"},
+		{Name: "OTHER_KEY", Value: []byte("other_value")},
+	}, nil)
+
+	suite.store.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{
+		Owner: suite.user.ID,
+	}).Return([]*types.Secret{
+		{Name: "SHARED_KEY", Value: []byte("user_shared_value")},
+	}, nil)
+
+	app, err := suite.controller.evaluateSecrets(suite.ctx, suite.user, app)
+	suite.NoError(err)
+
+	// User secrets take precedence over organization secrets, org secrets
+	// that are not scoped to the app are not available
+	suite.Equal("org_value [] user_shared_value", app.Config.Helix.Assistants[0].SystemPrompt)
+}
+
+func (suite *ControllerSuite) Test_DeleteAPIKey_Organization() {
+	apiKey := &types.APIKey{Key: "hl-key", Owner: "org_id", OwnerType: types.OwnerTypeOrg}
+
+	suite.store.EXPECT().GetAPIKey(gomock.Any(), "hl-key").Return(apiKey, nil).Times(2)
+	suite.store.EXPECT().GetOrganizationMembership(gomock.Any(), "org_id", suite.user.ID).Return(&types.OrganizationMembership{
+		OrganizationID: "org_id",
+		UserID:         suite.user.ID,
+		Role:           types.OrganizationRoleMember,
+	}, nil)
+
+	suite.Error(suite.controller.DeleteAPIKey(suite.ctx, suite.user, "hl-key"))
+
+	suite.store.EXPECT().GetOrganizationMembership(gomock.Any(), "org_id", suite.user.ID).Return(&types.OrganizationMembership{
+		OrganizationID: "org_id",
+		UserID:         suite.user.ID,
+		Role:           types.OrganizationRoleAdmin,
+	}, nil)
+	suite.store.EXPECT().DeleteAPIKey(gomock.Any(), "hl-key").Return(nil)
+
+	suite.NoError(suite.controller.DeleteAPIKey(suite.ctx, suite.user, "hl-key"))
+}
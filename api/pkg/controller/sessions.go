@@ -564,8 +564,13 @@ func (c *Controller) checkForActions(session *types.Session) (*types.Session, er
 		}
 
 		// if the tool exists but the user cannot access it - then something funky is being attempted and we should deny it
-		if (!app.Global && !app.Shared) && app.Owner != session.Owner {
-			return nil, system.NewHTTPError403(fmt.Sprintf("you do not have access to the app with the id: %s", app.ID))
+		err = c.AuthorizeApp(ctx, &types.User{ID: session.Owner, Type: session.OwnerType}, app, types.PermissionChat)
+		if err != nil {
+			var denied *AccessDeniedError
+			if errors.As(err, &denied) {
+				return nil, system.NewHTTPError403(err.Error())
+			}
+			return nil, err
 		}
 
 		if len(app.Config.Helix.Assistants) > 0 {
@@ -592,8 +597,14 @@ func (c *Controller) checkForActions(session *types.Session) (*types.Session, er
 			}
 
 			// if the tool exists but the user cannot access it - then something funky is being attempted and we should deny it
-			if !tool.Global && tool.Owner != session.Owner {
-				return nil, system.NewHTTPError403(fmt.Sprintf("you do not have access to the tool with the id: %s", tool.ID))
+			if !tool.Global {
+				ok, err := c.HasOwnerAccess(ctx, session.Owner, tool.Owner, tool.OwnerType, types.OrganizationRoleMember)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					return nil, system.NewHTTPError403(fmt.Sprintf("you do not have access to the tool with the id: %s", tool.ID))
+				}
 			}
 
 			activeTools = append(activeTools, tool)
@@ -322,6 +322,7 @@ func chatWithModel(client openai.Client, ownerID, sessionID, model, system, user
 		SessionID:     sessionID,
 		InteractionID: "n/a",
 	})
+	ctx = openai.SetContextPriorityClass(ctx, types.SchedulerPriorityClassBatch)
 
 	resp, err := client.CreateChatCompletion(ctx, req)
 	if err != nil {
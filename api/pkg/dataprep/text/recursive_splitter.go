@@ -0,0 +1,41 @@
+package text
+
+import (
+	"github.com/tmc/langchaingo/textsplitter"
+)
+
+// DefaultSeparators split the text on paragraphs, then lines, sentences and
+// words, down to characters for text without any of them
+var DefaultSeparators = []string{"\n\n", "\n", ". ", " ", ""}
+
+// RecursiveSplitter splits the text on the first separator that makes the
+// chunks fit, recursing into the next separators for the parts that don't
+type RecursiveSplitter struct {
+	chunkSize     int
+	chunkOverflow int
+
+	splitter textsplitter.RecursiveCharacter
+}
+
+func NewRecursiveSplitter(chunkSize, chunkOverflow int, separators ...string) *RecursiveSplitter {
+	if len(separators) == 0 {
+		separators = DefaultSeparators
+	}
+
+	splitter := textsplitter.NewRecursiveCharacter(
+		textsplitter.WithChunkSize(chunkSize),
+		textsplitter.WithChunkOverlap(chunkOverflow),
+		textsplitter.WithSeparators(separators),
+		textsplitter.WithKeepSeparator(true),
+	)
+
+	return &RecursiveSplitter{
+		chunkSize:     chunkSize,
+		chunkOverflow: chunkOverflow,
+		splitter:      splitter,
+	}
+}
+
+func (r *RecursiveSplitter) SplitText(text string) ([]string, error) {
+	return r.splitter.SplitText(text)
+}
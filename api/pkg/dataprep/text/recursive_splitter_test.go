@@ -0,0 +1,34 @@
+package text
+
+import (
+	"os"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecursiveSplitter(t *testing.T) {
+	content, err := os.ReadFile("sample_web.md")
+	require.NoError(t, err)
+
+	splitter := NewRecursiveSplitter(1000, 100)
+	docs, err := splitter.SplitText(string(content))
+	require.NoError(t, err)
+
+	require.Greater(t, len(docs), 1)
+
+	for _, doc := range docs {
+		assert.LessOrEqual(t, utf8.RuneCountInString(doc), 1000)
+	}
+}
+
+func TestRecursiveSplitter_Separators(t *testing.T) {
+	splitter := NewRecursiveSplitter(10, 0, "|")
+
+	docs, err := splitter.SplitText("first|second|third")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"first", "|second", "|third"}, docs)
+}
@@ -0,0 +1,148 @@
+package text
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultSemanticBreakpointPercentile splits the text at the 5% largest
+	// distances between consecutive sentences
+	DefaultSemanticBreakpointPercentile = 95
+
+	// semanticBufferSize is the number of sentences either side embedded with
+	// each sentence, smoothing out short sentences
+	semanticBufferSize = 1
+
+	semanticEmbeddingsBatchSize = 64
+)
+
+// EmbedFunc returns the embeddings of the input texts, in order
+type EmbedFunc func(ctx context.Context, input []string) ([][]float32, error)
+
+// SemanticSplitter splits the text where the meaning of consecutive
+// sentences drifts apart, the chunks larger than the chunk size in tokens
+// are packed by sentence
+type SemanticSplitter struct {
+	embed                EmbedFunc
+	tokenizer            Tokenizer
+	chunkSize            int
+	breakpointPercentile float64
+}
+
+func NewSemanticSplitter(embed EmbedFunc, tokenizer Tokenizer, chunkSize int, breakpointPercentile float64) *SemanticSplitter {
+	if breakpointPercentile <= 0 || breakpointPercentile > 100 {
+		breakpointPercentile = DefaultSemanticBreakpointPercentile
+	}
+
+	return &SemanticSplitter{
+		embed:                embed,
+		tokenizer:            tokenizer,
+		chunkSize:            chunkSize,
+		breakpointPercentile: breakpointPercentile,
+	}
+}
+
+func (s *SemanticSplitter) SplitText(text string) ([]string, error) {
+	return s.SplitTextContext(context.Background(), text)
+}
+
+func (s *SemanticSplitter) SplitTextContext(ctx context.Context, text string) ([]string, error) {
+	sentences := splitSentences(text)
+	if len(sentences) < 2 {
+		return packSentences(sentences, s.chunkSize, 0, s.tokenizer.Count)
+	}
+
+	embeddings, err := s.embedSentences(ctx, sentences)
+	if err != nil {
+		return nil, err
+	}
+
+	distances := make([]float64, len(embeddings)-1)
+	for i := range distances {
+		distances[i] = 1 - cosineSimilarity(embeddings[i], embeddings[i+1])
+	}
+
+	threshold := percentile(distances, s.breakpointPercentile)
+
+	var (
+		chunks []string
+		start  int
+	)
+
+	for i := 0; i < len(sentences); i++ {
+		if i < len(distances) && distances[i] <= threshold {
+			continue
+		}
+
+		parts, err := packSentences(sentences[start:i+1], s.chunkSize, 0, s.tokenizer.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		chunks = append(chunks, parts...)
+		start = i + 1
+	}
+
+	return chunks, nil
+}
+
+// embedSentences embeds each sentence with its neighbours
+func (s *SemanticSplitter) embedSentences(ctx context.Context, sentences []string) ([][]float32, error) {
+	windows := make([]string, len(sentences))
+	for i := range sentences {
+		from := max(0, i-semanticBufferSize)
+		to := min(len(sentences), i+semanticBufferSize+1)
+		windows[i] = strings.TrimSpace(strings.Join(sentences[from:to], ""))
+	}
+
+	embeddings := make([][]float32, 0, len(windows))
+
+	for start := 0; start < len(windows); start += semanticEmbeddingsBatchSize {
+		batch := windows[start:min(len(windows), start+semanticEmbeddingsBatchSize)]
+
+		resp, err := s.embed(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed sentences: %w", err)
+		}
+
+		if len(resp) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(resp))
+		}
+
+		embeddings = append(embeddings, resp...)
+	}
+
+	return embeddings, nil
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// percentile returns the linearly interpolated percentile of the values
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
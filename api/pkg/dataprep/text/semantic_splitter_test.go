@@ -0,0 +1,67 @@
+package text
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topicEmbeddings embeds the texts on the topics they mention
+func topicEmbeddings(_ context.Context, input []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(input))
+
+	for i, text := range input {
+		text = strings.ToLower(text)
+		embeddings[i] = []float32{
+			float32(strings.Count(text, "cat")),
+			float32(strings.Count(text, "rocket")),
+			0.1,
+		}
+	}
+
+	return embeddings, nil
+}
+
+func TestSemanticSplitter(t *testing.T) {
+	splitter := NewSemanticSplitter(topicEmbeddings, &approximateTokenizer{}, 512, 50)
+
+	docs, err := splitter.SplitText(
+		"Cats sleep a lot. My cat likes boxes. A cat purrs when happy. " +
+			"Rockets need fuel. The rocket launched today. Rocket engines are loud.")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"Cats sleep a lot. My cat likes boxes. A cat purrs when happy.",
+		"Rockets need fuel. The rocket launched today. Rocket engines are loud.",
+	}, docs)
+}
+
+func TestSemanticSplitter_ChunkSize(t *testing.T) {
+	splitter := NewSemanticSplitter(topicEmbeddings, &approximateTokenizer{}, 6, 0)
+
+	docs, err := splitter.SplitText("My cat sleeps. My cat eats. My cat plays.")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"My cat sleeps.", "My cat eats.", "My cat plays."}, docs)
+}
+
+func TestSemanticSplitter_EmbeddingsError(t *testing.T) {
+	splitter := NewSemanticSplitter(func(context.Context, []string) ([][]float32, error) {
+		return nil, errors.New("provider down")
+	}, &approximateTokenizer{}, 512, 0)
+
+	_, err := splitter.SplitText("One. Two.")
+	require.ErrorContains(t, err, "provider down")
+}
+
+func Test_percentile(t *testing.T) {
+	values := []float64{4, 1, 3, 2}
+
+	assert.Equal(t, 1.0, percentile(values, 0))
+	assert.Equal(t, 2.5, percentile(values, 50))
+	assert.Equal(t, 4.0, percentile(values, 100))
+}
@@ -0,0 +1,208 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tmc/langchaingo/textsplitter"
+)
+
+// SentenceSplitter packs whole sentences into the chunks, the overflow is
+// made of the last sentences of the previous chunk
+type SentenceSplitter struct {
+	chunkSize     int
+	chunkOverflow int
+	lenFunc       func(string) int
+}
+
+func NewSentenceSplitter(chunkSize, chunkOverflow int) *SentenceSplitter {
+	return &SentenceSplitter{
+		chunkSize:     chunkSize,
+		chunkOverflow: chunkOverflow,
+		lenFunc:       utf8.RuneCountInString,
+	}
+}
+
+func (s *SentenceSplitter) SplitText(text string) ([]string, error) {
+	return packSentences(splitSentences(text), s.chunkSize, s.chunkOverflow, s.lenFunc)
+}
+
+// packSentences joins the sentences into chunks of up to chunkSize, longer
+// sentences are split on words
+func packSentences(sentences []string, chunkSize, chunkOverflow int, lenFunc func(string) int) ([]string, error) {
+	var (
+		chunks  []string
+		current []string
+		sizes   []int
+		size    int
+	)
+
+	flush := func() {
+		if chunk := strings.TrimSpace(strings.Join(current, "")); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	for _, sentence := range sentences {
+		length := lenFunc(sentence)
+
+		if length > chunkSize {
+			flush()
+			current, sizes, size = nil, nil, 0
+
+			parts, err := textsplitter.NewRecursiveCharacter(
+				textsplitter.WithChunkSize(chunkSize),
+				textsplitter.WithChunkOverlap(0),
+				textsplitter.WithSeparators([]string{" ", ""}),
+				textsplitter.WithLenFunc(lenFunc),
+			).SplitText(sentence)
+			if err != nil {
+				return nil, err
+			}
+
+			chunks = append(chunks, parts...)
+			continue
+		}
+
+		if size+length > chunkSize && len(current) > 0 {
+			flush()
+
+			// Keep the last sentences that fit in the overflow and leave
+			// room for the next sentence
+			keep := len(current)
+			overflow := 0
+			for keep > 0 && overflow+sizes[keep-1] <= chunkOverflow &&
+				overflow+sizes[keep-1]+length <= chunkSize {
+				overflow += sizes[keep-1]
+				keep--
+			}
+
+			current = append([]string(nil), current[keep:]...)
+			sizes = append([]int(nil), sizes[keep:]...)
+			size = overflow
+		}
+
+		current = append(current, sentence)
+		sizes = append(sizes, length)
+		size += length
+	}
+
+	flush()
+
+	return chunks, nil
+}
+
+// sentenceAbbreviations don't end a sentence when followed by a period
+var sentenceAbbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true,
+	"st": true, "vs": true, "etc": true, "e.g": true, "i.e": true, "inc": true, "ltd": true,
+	"co": true, "corp": true, "no": true, "fig": true, "approx": true, "dept": true,
+}
+
+// splitSentences splits the text after the sentence terminators and on
+// paragraphs. The sentences keep the whitespace after them, joining them
+// returns the text.
+func splitSentences(text string) []string {
+	var (
+		sentences []string
+		start     int
+	)
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		end := i + size
+
+		switch {
+		case r == '\n' && strings.HasPrefix(text[end:], "\n"):
+		case isSentenceTerminator(r):
+			// Repeated terminators and closing quotes or brackets
+			for end < len(text) {
+				next, nextSize := utf8.DecodeRuneInString(text[end:])
+				if !isSentenceTerminator(next) && !strings.ContainsRune(`"')]”’`, next) {
+					break
+				}
+				end += nextSize
+			}
+
+			if !isSentenceEnd(text, start, i, end) {
+				i = end
+				continue
+			}
+		default:
+			i = end
+			continue
+		}
+
+		end = skipSpace(text, end)
+		sentences = append(sentences, text[start:end])
+		start, i = end, end
+	}
+
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+
+	return sentences
+}
+
+func isSentenceTerminator(r rune) bool {
+	switch r {
+	case '.', '!', '?', '。', '！', '？':
+		return true
+	default:
+		return false
+	}
+}
+
+// isSentenceEnd returns true if the terminator at i, followed by the
+// closing characters up to end, ends the sentence
+func isSentenceEnd(text string, start, i, end int) bool {
+	// Full width terminators aren't followed by spaces
+	if r, _ := utf8.DecodeRuneInString(text[i:]); r > unicode.MaxASCII {
+		return true
+	}
+
+	if end == len(text) {
+		return true
+	}
+
+	if next, _ := utf8.DecodeRuneInString(text[end:]); !unicode.IsSpace(next) {
+		return false
+	}
+
+	// The next sentence starts with a capital letter, digit or symbol
+	if after := skipSpace(text, end); after < len(text) {
+		if next, _ := utf8.DecodeRuneInString(text[after:]); unicode.IsLower(next) {
+			return false
+		}
+	}
+
+	if text[i] != '.' {
+		return true
+	}
+
+	word := text[start:i]
+	if idx := strings.LastIndexFunc(word, unicode.IsSpace); idx >= 0 {
+		word = word[idx+1:]
+	}
+	word = strings.ToLower(strings.TrimLeft(word, `"'([“‘`))
+
+	// Initials, e.g. J. R. R. Tolkien
+	if utf8.RuneCountInString(word) == 1 {
+		return false
+	}
+
+	return !sentenceAbbreviations[word]
+}
+
+func skipSpace(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
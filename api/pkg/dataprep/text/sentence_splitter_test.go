@@ -0,0 +1,84 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "sentences",
+			text: "First one. Second one! Third? Last",
+			want: []string{"First one. ", "Second one! ", "Third? ", "Last"},
+		},
+		{
+			name: "abbreviations and initials",
+			text: "Dr. Smith met J. R. Tolkien, e.g. at the pub. Then left.",
+			want: []string{"Dr. Smith met J. R. Tolkien, e.g. at the pub. ", "Then left."},
+		},
+		{
+			name: "lowercase continuation",
+			text: "Version 1.2 is out. it works",
+			want: []string{"Version 1.2 is out. it works"},
+		},
+		{
+			name: "quotes",
+			text: `He said "stop." She stopped.`,
+			want: []string{`He said "stop." `, "She stopped."},
+		},
+		{
+			name: "paragraphs",
+			text: "# Title\n\nFirst paragraph\n\nSecond",
+			want: []string{"# Title\n\n", "First paragraph\n\n", "Second"},
+		},
+		{
+			name: "full width",
+			text: "你好。再见。",
+			want: []string{"你好。", "再见。"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentences := splitSentences(tt.text)
+			assert.Equal(t, tt.want, sentences)
+			assert.Equal(t, tt.text, strings.Join(sentences, ""))
+		})
+	}
+}
+
+func TestSentenceSplitter(t *testing.T) {
+	splitter := NewSentenceSplitter(45, 20)
+
+	docs, err := splitter.SplitText("One short sentence. Another short one. A third sentence here. And the end.")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"One short sentence. Another short one.",
+		"Another short one. A third sentence here.",
+		"And the end.",
+	}, docs)
+}
+
+func TestSentenceSplitter_LongSentence(t *testing.T) {
+	splitter := NewSentenceSplitter(20, 0)
+
+	docs, err := splitter.SplitText("Short. " + strings.Repeat("Word ", 10) + "end. Tail.")
+	require.NoError(t, err)
+
+	require.Greater(t, len(docs), 3)
+	assert.Equal(t, "Short.", docs[0])
+	assert.Equal(t, "Tail.", docs[len(docs)-1])
+
+	for _, doc := range docs {
+		assert.LessOrEqual(t, len(doc), 20)
+	}
+}
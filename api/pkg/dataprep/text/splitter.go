@@ -17,6 +17,8 @@ type DataPrepTextSplitterChunk struct {
 	// suite of prompts, this is where they store which prompt this chunk will
 	// be processed by
 	PromptName string
+	// Metadata is indexed along with the chunk, e.g. the page number
+	Metadata map[string]string
 }
 
 type DataPrepTextSplitterOptions struct {
@@ -0,0 +1,29 @@
+package text
+
+import (
+	"github.com/tmc/langchaingo/textsplitter"
+)
+
+// TokenSplitter splits the text like the RecursiveSplitter, with the chunk
+// size and overflow counted in tokens of the embeddings model
+type TokenSplitter struct {
+	splitter textsplitter.RecursiveCharacter
+}
+
+func NewTokenSplitter(tokenizer Tokenizer, chunkSize, chunkOverflow int) *TokenSplitter {
+	splitter := textsplitter.NewRecursiveCharacter(
+		textsplitter.WithChunkSize(chunkSize),
+		textsplitter.WithChunkOverlap(chunkOverflow),
+		textsplitter.WithSeparators(DefaultSeparators),
+		textsplitter.WithKeepSeparator(true),
+		textsplitter.WithLenFunc(tokenizer.Count),
+	)
+
+	return &TokenSplitter{
+		splitter: splitter,
+	}
+}
+
+func (t *TokenSplitter) SplitText(text string) ([]string, error) {
+	return t.splitter.SplitText(text)
+}
@@ -0,0 +1,29 @@
+package text
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenSplitter(t *testing.T) {
+	content, err := os.ReadFile("sample_web.md")
+	require.NoError(t, err)
+
+	tokenizer := &approximateTokenizer{}
+
+	splitter := NewTokenSplitter(tokenizer, 256, 32)
+	docs, err := splitter.SplitText(string(content))
+	require.NoError(t, err)
+
+	require.Greater(t, len(docs), 1)
+
+	for _, doc := range docs {
+		assert.LessOrEqual(t, tokenizer.Count(doc), 256)
+	}
+
+	assert.True(t, strings.Contains(strings.Join(docs, ""), "## [Introduction]"))
+}
@@ -0,0 +1,153 @@
+package text
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/rs/zerolog/log"
+)
+
+// Tokenizer counts the tokens of the text the way an embeddings model does
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// encodingLoadTimeout is how long NewTokenizer waits for an encoding to be
+// downloaded before it approximates the token counts
+var encodingLoadTimeout = 10 * time.Second
+
+// encodingLoad is the download of an encoding, encoding is set when done is
+// closed and stays nil if the download failed
+type encodingLoad struct {
+	done     chan struct{}
+	encoding *tiktoken.Tiktoken
+}
+
+var (
+	encodings   = make(map[string]*encodingLoad)
+	encodingsMu sync.Mutex
+)
+
+// NewTokenizer returns the tokenizer of the embeddings model. OpenAI models
+// use their BPE encoding, which is downloaded on first use, the other models
+// and air-gapped installs get an approximation.
+func NewTokenizer(model string) Tokenizer {
+	encodingName := getEncodingName(model)
+	if encodingName == "" {
+		return &approximateTokenizer{}
+	}
+
+	encodingsMu.Lock()
+	load, ok := encodings[encodingName]
+	if !ok {
+		// Not retried, air-gapped installs would keep waiting for it
+		load = &encodingLoad{done: make(chan struct{})}
+		encodings[encodingName] = load
+
+		go load.run(model, encodingName)
+	}
+	encodingsMu.Unlock()
+
+	// The download has no timeout of its own, later tokenizers get the
+	// encoding if it finishes
+	select {
+	case <-load.done:
+	case <-time.After(encodingLoadTimeout):
+		log.Warn().
+			Str("model", model).
+			Str("encoding", encodingName).
+			Msg("timed out loading the tokenizer encoding, approximating token counts")
+		return &approximateTokenizer{}
+	}
+
+	if load.encoding == nil {
+		return &approximateTokenizer{}
+	}
+
+	return &tiktokenTokenizer{encoding: load.encoding}
+}
+
+func (l *encodingLoad) run(model, encodingName string) {
+	defer close(l.done)
+
+	encoding, err := tiktoken.GetEncoding(encodingName)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("model", model).
+			Str("encoding", encodingName).
+			Msg("failed to load the tokenizer encoding, approximating token counts")
+		return
+	}
+
+	l.encoding = encoding
+}
+
+// getEncodingName returns the tiktoken encoding of OpenAI models, empty for
+// other models
+func getEncodingName(model string) string {
+	model = strings.TrimPrefix(model, "openai/")
+
+	// Newer models than the tiktoken mappings
+	if strings.HasPrefix(model, "text-embedding-") {
+		return tiktoken.MODEL_CL100K_BASE
+	}
+
+	if encodingName, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		return encodingName
+	}
+
+	for prefix, encodingName := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(model, prefix) {
+			return encodingName
+		}
+	}
+
+	return ""
+}
+
+type tiktokenTokenizer struct {
+	encoding *tiktoken.Tiktoken
+}
+
+func (t *tiktokenTokenizer) Count(text string) int {
+	return len(t.encoding.EncodeOrdinary(text))
+}
+
+// approximateTokenizer counts a token per punctuation character and per
+// four characters of words, close to the WordPiece and BPE vocabularies of
+// English text and slightly over for other languages, so chunks stay
+// within the model limits
+type approximateTokenizer struct{}
+
+func (t *approximateTokenizer) Count(text string) int {
+	var tokens, word int
+
+	flush := func() {
+		tokens += (word + 3) / 4
+		word = 0
+	}
+
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+
+	flush()
+
+	return tokens
+}
@@ -0,0 +1,79 @@
+package text
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApproximateTokenizer(t *testing.T) {
+	tokenizer := &approximateTokenizer{}
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "hello world", want: 4},
+		{text: "a, b.", want: 4},
+		{text: "internationalization", want: 5},
+		{text: "  spaces\n\tonly  ", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, tokenizer.Count(tt.text))
+		})
+	}
+}
+
+func TestNewTokenizer_Approximate(t *testing.T) {
+	// Not an OpenAI model, no encoding to download
+	tokenizer := NewTokenizer("nomic-embed-text")
+	assert.IsType(t, &approximateTokenizer{}, tokenizer)
+}
+
+// blockingBpeLoader fails the download once released
+type blockingBpeLoader struct {
+	release chan struct{}
+}
+
+func (l *blockingBpeLoader) LoadTiktokenBpe(string) (map[string]int, error) {
+	<-l.release
+	return nil, errors.New("offline")
+}
+
+func TestNewTokenizer_DownloadTimeout(t *testing.T) {
+	loader := &blockingBpeLoader{release: make(chan struct{})}
+	tiktoken.SetBpeLoader(loader)
+	defer tiktoken.SetBpeLoader(tiktoken.NewDefaultBpeLoader())
+
+	timeout := encodingLoadTimeout
+	encodingLoadTimeout = 10 * time.Millisecond
+	defer func() { encodingLoadTimeout = timeout }()
+
+	// The download of an encoding doesn't hold up the others
+	start := time.Now()
+	assert.IsType(t, &approximateTokenizer{}, NewTokenizer("davinci"))
+	assert.IsType(t, &approximateTokenizer{}, NewTokenizer("text-davinci-002"))
+	assert.Less(t, time.Since(start), time.Second)
+
+	close(loader.release)
+
+	encodingsMu.Lock()
+	load := encodings["r50k_base"]
+	encodingsMu.Unlock()
+	<-load.done
+
+	assert.IsType(t, &approximateTokenizer{}, NewTokenizer("davinci"))
+}
+
+func Test_getEncodingName(t *testing.T) {
+	assert.Equal(t, "cl100k_base", getEncodingName("text-embedding-3-small"))
+	assert.Equal(t, "cl100k_base", getEncodingName("openai/text-embedding-ada-002"))
+	assert.Equal(t, "cl100k_base", getEncodingName("gpt-4-0613"))
+	assert.Equal(t, "", getEncodingName("ts/all-MiniLM-L12-v2"))
+}
@@ -0,0 +1,97 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+)
+
+// Sealed is a value encrypted with its own data key, the data key itself is
+// stored wrapped by the master key identified by KeyID
+type Sealed struct {
+	KeyID        string
+	EncryptedKey []byte
+	Ciphertext   []byte
+}
+
+// Envelope implements envelope encryption: every value is encrypted with a
+// fresh data key and only the data keys are encrypted with the master key, so
+// rotating the master key only needs the data keys to be re-wrapped.
+type Envelope struct {
+	keys KeyManager
+}
+
+func NewEnvelope(keys KeyManager) *Envelope {
+	return &Envelope{keys: keys}
+}
+
+func (e *Envelope) CurrentKeyID() string {
+	return e.keys.CurrentKeyID()
+}
+
+// Seal encrypts the plaintext, additional data is authenticated but not stored
+// and has to be passed to Open again (e.g. the ID of the row)
+func (e *Envelope) Seal(ctx context.Context, plaintext, additionalData []byte) (*Sealed, error) {
+	dataKey, err := randomKey()
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(aead, plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, wrapped, err := e.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return &Sealed{
+		KeyID:        keyID,
+		EncryptedKey: wrapped,
+		Ciphertext:   ciphertext,
+	}, nil
+}
+
+func (e *Envelope) Open(ctx context.Context, sealed *Sealed, additionalData []byte) ([]byte, error) {
+	dataKey, err := e.keys.UnwrapKey(ctx, sealed.KeyID, sealed.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(aead, sealed.Ciphertext, additionalData)
+}
+
+// Rewrap wraps the data key with the current master key, the ciphertext is left
+// untouched. Returns false if the value already uses the current master key.
+func (e *Envelope) Rewrap(ctx context.Context, sealed *Sealed) (*Sealed, bool, error) {
+	if sealed.KeyID == e.keys.CurrentKeyID() {
+		return sealed, false, nil
+	}
+
+	dataKey, err := e.keys.UnwrapKey(ctx, sealed.KeyID, sealed.EncryptedKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	keyID, wrapped, err := e.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return &Sealed{
+		KeyID:        keyID,
+		EncryptedKey: wrapped,
+		Ciphertext:   sealed.Ciphertext,
+	}, true, nil
+}
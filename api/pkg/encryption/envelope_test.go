@@ -0,0 +1,123 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T) []byte {
+	key, err := randomKey()
+	require.NoError(t, err)
+	return key
+}
+
+func TestEnvelope_SealOpen(t *testing.T) {
+	ctx := context.Background()
+
+	keys, err := NewLocalKeyManager(newTestKey(t))
+	require.NoError(t, err)
+	envelope := NewEnvelope(keys)
+
+	sealed, err := envelope.Seal(ctx, []byte("hunter2"), []byte("secret-1"))
+	require.NoError(t, err)
+	assert.Equal(t, keys.CurrentKeyID(), sealed.KeyID)
+	assert.NotContains(t, string(sealed.Ciphertext), "hunter2")
+
+	plaintext, err := envelope.Open(ctx, sealed, []byte("secret-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", string(plaintext))
+
+	// Every value gets its own data key
+	other, err := envelope.Seal(ctx, []byte("hunter2"), []byte("secret-1"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed.EncryptedKey, other.EncryptedKey)
+	assert.NotEqual(t, sealed.Ciphertext, other.Ciphertext)
+}
+
+func TestEnvelope_Open_WrongAdditionalData(t *testing.T) {
+	ctx := context.Background()
+
+	keys, err := NewLocalKeyManager(newTestKey(t))
+	require.NoError(t, err)
+	envelope := NewEnvelope(keys)
+
+	sealed, err := envelope.Seal(ctx, []byte("hunter2"), []byte("secret-1"))
+	require.NoError(t, err)
+
+	_, err = envelope.Open(ctx, sealed, []byte("secret-2"))
+	require.Error(t, err)
+}
+
+func TestEnvelope_Rewrap(t *testing.T) {
+	ctx := context.Background()
+
+	oldKey := newTestKey(t)
+	oldKeys, err := NewLocalKeyManager(oldKey)
+	require.NoError(t, err)
+
+	sealed, err := NewEnvelope(oldKeys).Seal(ctx, []byte("hunter2"), nil)
+	require.NoError(t, err)
+
+	newKey := newTestKey(t)
+	newKeys, err := NewLocalKeyManager(newKey, oldKey)
+	require.NoError(t, err)
+	envelope := NewEnvelope(newKeys)
+
+	// Values wrapped with a previous key can still be opened
+	plaintext, err := envelope.Open(ctx, sealed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", string(plaintext))
+
+	rewrapped, changed, err := envelope.Rewrap(ctx, sealed)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, newKeys.CurrentKeyID(), rewrapped.KeyID)
+	assert.Equal(t, sealed.Ciphertext, rewrapped.Ciphertext)
+
+	_, changed, err = envelope.Rewrap(ctx, rewrapped)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// Once the old key is dropped only the re-wrapped value can be opened
+	onlyNew, err := NewLocalKeyManager(newKey)
+	require.NoError(t, err)
+	envelope = NewEnvelope(onlyNew)
+
+	_, err = envelope.Open(ctx, sealed, nil)
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	plaintext, err = envelope.Open(ctx, rewrapped, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", string(plaintext))
+}
+
+func TestNewKeyManager(t *testing.T) {
+	keys, err := NewKeyManager(config.SecretsEncryption{})
+	require.NoError(t, err)
+	assert.Nil(t, keys)
+
+	_, err = NewKeyManager(config.SecretsEncryption{MasterKey: "not base64!"})
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = NewKeyManager(config.SecretsEncryption{MasterKey: base64.StdEncoding.EncodeToString([]byte("short"))})
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	encoded := base64.StdEncoding.EncodeToString(newTestKey(t))
+
+	path := filepath.Join(t.TempDir(), "master.key")
+	require.NoError(t, os.WriteFile(path, []byte(encoded+"\n"), 0600))
+
+	fromFile, err := NewKeyManager(config.SecretsEncryption{MasterKeyFile: path})
+	require.NoError(t, err)
+
+	fromEnv, err := NewKeyManager(config.SecretsEncryption{MasterKey: encoded})
+	require.NoError(t, err)
+	assert.Equal(t, fromEnv.CurrentKeyID(), fromFile.CurrentKeyID())
+}
@@ -0,0 +1,163 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/helixml/helix/api/pkg/config"
+)
+
+// KeySize is the size of both the master keys and the data keys (AES-256)
+const KeySize = 32
+
+var (
+	ErrInvalidKey = errors.New("invalid key")
+	ErrUnknownKey = errors.New("unknown master key")
+)
+
+// KeyManager wraps and unwraps data keys with a master key. It's modelled after
+// the encrypt/decrypt APIs of cloud KMS services so that the master key never
+// has to leave the key manager.
+type KeyManager interface {
+	// CurrentKeyID returns the ID of the master key used to wrap new data keys
+	CurrentKeyID() string
+	// WrapKey encrypts the data key with the current master key
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey decrypts a data key that was wrapped with the given master key
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// NewKeyManager returns a local key manager for the configured master keys or nil
+// when no master key is configured.
+func NewKeyManager(cfg config.SecretsEncryption) (KeyManager, error) {
+	current := strings.TrimSpace(cfg.MasterKey)
+
+	if current == "" && cfg.MasterKeyFile != "" {
+		bts, err := os.ReadFile(cfg.MasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master key file: %w", err)
+		}
+		current = strings.TrimSpace(string(bts))
+	}
+
+	if current == "" {
+		if len(cfg.PreviousMasterKeys) > 0 {
+			return nil, fmt.Errorf("previous master keys are set but there is no current master key")
+		}
+		return nil, nil
+	}
+
+	keys := make([][]byte, 0, len(cfg.PreviousMasterKeys)+1)
+	for _, encoded := range append([]string{current}, cfg.PreviousMasterKeys...) {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("%w: master key is not valid base64: %s", ErrInvalidKey, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewLocalKeyManager(keys[0], keys[1:]...)
+}
+
+// LocalKeyManager keeps the master keys in memory. Previous keys are only used
+// to unwrap data keys so that they can be re-wrapped after a rotation.
+type LocalKeyManager struct {
+	currentID string
+	keys      map[string]cipher.AEAD
+}
+
+func NewLocalKeyManager(current []byte, previous ...[]byte) (*LocalKeyManager, error) {
+	m := &LocalKeyManager{
+		keys: make(map[string]cipher.AEAD),
+	}
+
+	for _, key := range append([][]byte{current}, previous...) {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		id := keyID(key)
+		if m.currentID == "" {
+			m.currentID = id
+		}
+		m.keys[id] = aead
+	}
+
+	return m, nil
+}
+
+func (m *LocalKeyManager) CurrentKeyID() string {
+	return m.currentID
+}
+
+func (m *LocalKeyManager) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	wrapped, err := seal(m.keys[m.currentID], dataKey, []byte(m.currentID))
+	if err != nil {
+		return "", nil, err
+	}
+	return m.currentID, wrapped, nil
+}
+
+func (m *LocalKeyManager) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := m.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	return open(aead, wrapped, []byte(keyID))
+}
+
+// keyID identifies a master key without revealing it
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "local:" + hex.EncodeToString(sum[:8])
+}
+
+func randomKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidKey, KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext and prepends the random nonce
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,124 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxDOCXDocumentSize limits the uncompressed size of the document XML
+const maxDOCXDocumentSize = 100 * 1024 * 1024
+
+// extractDOCX returns the paragraphs of the Word document as markdown,
+// headings styles are converted to markdown headings
+func extractDOCX(content []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read DOCX: %w", err)
+	}
+
+	for _, f := range archive.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to read DOCX document: %w", err)
+		}
+		defer rc.Close()
+
+		return parseDOCXDocument(io.LimitReader(rc, maxDOCXDocumentSize))
+	}
+
+	return "", fmt.Errorf("DOCX has no word/document.xml")
+}
+
+func parseDOCXDocument(r io.Reader) (string, error) {
+	var (
+		decoder   = xml.NewDecoder(r)
+		sb        strings.Builder
+		paragraph strings.Builder
+		heading   int
+		inText    bool
+	)
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse DOCX document: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paragraph.Reset()
+				heading = 0
+			case "pStyle":
+				heading = getDOCXHeadingLevel(getXMLAttr(t, "val"))
+			case "t":
+				inText = true
+			case "tab":
+				paragraph.WriteString("\t")
+			case "br", "cr":
+				paragraph.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text := strings.TrimSpace(paragraph.String())
+				if text == "" {
+					continue
+				}
+
+				if heading > 0 {
+					sb.WriteString(strings.Repeat("#", heading) + " ")
+				}
+				sb.WriteString(text + "\n\n")
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		}
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// getDOCXHeadingLevel returns the level of the built-in Title and HeadingN
+// paragraph styles, 0 for other styles
+func getDOCXHeadingLevel(style string) int {
+	style = strings.ToLower(style)
+
+	if style == "title" {
+		return 1
+	}
+
+	level, err := strconv.Atoi(strings.TrimPrefix(style, "heading"))
+	if err != nil || !strings.HasPrefix(style, "heading") || level < 1 {
+		return 0
+	}
+
+	return min(level, 6)
+}
+
+func getXMLAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,225 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/controller/knowledge/readability"
+)
+
+// maxDownloadSize is the maximum size of the documents downloaded by URL
+const maxDownloadSize = 100 * 1024 * 1024
+
+// ErrUnsupportedContentType is returned by the builtin extractor for
+// content types it can't extract
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// BuiltinExtractor extracts the text of common document formats in
+// process, without an external service. PDFs are limited to their text
+// layer, scanned documents need OCR from another extractor.
+type BuiltinExtractor struct {
+	httpClient *http.Client
+	parser     readability.Parser
+}
+
+var _ Extractor = &BuiltinExtractor{}
+
+func NewBuiltinExtractor() *BuiltinExtractor {
+	return &BuiltinExtractor{
+		httpClient: http.DefaultClient,
+		parser:     readability.NewParser(),
+	}
+}
+
+// Supports returns true if the extractor can extract the content type
+func (e *BuiltinExtractor) Supports(contentType string) bool {
+	switch contentType {
+	case ContentTypeText, ContentTypeMarkdown, ContentTypeHTML, ContentTypeCSV,
+		ContentTypeJSON, ContentTypePDF, ContentTypeDOCX:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *BuiltinExtractor) Extract(ctx context.Context, extractReq *ExtractRequest) (string, error) {
+	if extractReq.URL == "" && len(extractReq.Content) == 0 {
+		return "", fmt.Errorf("no URL or content provided")
+	}
+
+	content := extractReq.Content
+
+	var declared string
+
+	if len(content) == 0 {
+		var err error
+
+		content, declared, err = download(ctx, e.httpClient, extractReq.URL)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	contentType := DetectContentType(extractReq.URL, declared, content)
+
+	text, err := e.extract(ctx, contentType, extractReq.URL, content)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debug().
+		Str("url", extractReq.URL).
+		Str("content_type", contentType).
+		Int("extracted_length", len(text)).
+		Msg("extracted text")
+
+	return text, nil
+}
+
+func (e *BuiltinExtractor) extract(ctx context.Context, contentType, u string, content []byte) (string, error) {
+	switch contentType {
+	case ContentTypeText, ContentTypeMarkdown:
+		return extractText(content)
+	case ContentTypeHTML:
+		return e.extractHTML(ctx, u, content)
+	case ContentTypeCSV:
+		return extractCSV(content)
+	case ContentTypeJSON:
+		return extractJSON(content)
+	case ContentTypePDF:
+		return extractPDF(content)
+	case ContentTypeDOCX:
+		return extractDOCX(content)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+}
+
+func extractText(content []byte) (string, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	if !utf8.Valid(content) {
+		return "", fmt.Errorf("text is not valid UTF-8")
+	}
+
+	return string(content), nil
+}
+
+// extractHTML converts the main content of the page to markdown, the
+// whole page is converted if readability can't find it
+func (e *BuiltinExtractor) extractHTML(ctx context.Context, u string, content []byte) (string, error) {
+	converter := md.NewConverter("", true, nil)
+
+	article, err := e.parser.Parse(ctx, string(content), u)
+	if err != nil || strings.TrimSpace(article.Content) == "" {
+		return converter.ConvertString(string(content))
+	}
+
+	markdown, err := converter.ConvertString(article.Content)
+	if err != nil {
+		return "", err
+	}
+
+	if article.Title != "" {
+		markdown = "# " + article.Title + "\n\n" + markdown
+	}
+
+	return markdown, nil
+}
+
+// extractCSV renders the rows as a markdown table, the first row is the
+// header
+func extractCSV(content []byte) (string, error) {
+	text, err := extractText(content)
+	if err != nil {
+		return "", err
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	if len(rows) == 0 {
+		return "", nil
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	var sb strings.Builder
+
+	writeRow := func(row []string) {
+		sb.WriteString("|")
+		for i := 0; i < columns; i++ {
+			var cell string
+			if i < len(row) {
+				cell = strings.Join(strings.Fields(row[i]), " ")
+			}
+			sb.WriteString(" " + strings.ReplaceAll(cell, "|", "\\|") + " |")
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	sb.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+
+	return sb.String(), nil
+}
+
+func extractJSON(content []byte) (string, error) {
+	var buf bytes.Buffer
+
+	err := json.Indent(&buf, bytes.TrimSpace(content), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// download returns the content and the declared content type of the URL
+func download(ctx context.Context, client *http.Client, u string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("failed to download %s: %s", u, resp.Status)
+	}
+
+	bts, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return bts, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,193 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltin_Extract(t *testing.T) {
+	extractor := NewBuiltinExtractor()
+	ctx := context.Background()
+
+	t.Run("PDF", func(t *testing.T) {
+		content, err := os.ReadFile("testdata/hr_guide.pdf")
+		require.NoError(t, err)
+
+		text, err := extractor.Extract(ctx, &ExtractRequest{
+			URL:     "https://example.com/hr_guide.pdf",
+			Content: content,
+		})
+		require.NoError(t, err)
+
+		// Pages are separated by form feeds
+		assert.Greater(t, strings.Count(text, "\f"), 0)
+		assert.Contains(t, text, "Employee")
+	})
+
+	t.Run("DOCX", func(t *testing.T) {
+		text, err := extractor.Extract(ctx, &ExtractRequest{
+			Content: newTestDOCX(t, `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`+
+				`<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>Leave policy</w:t></w:r></w:p>`+
+				`<w:p><w:r><w:t xml:space="preserve">Employees get </w:t></w:r><w:r><w:t>25 days.</w:t></w:r></w:p>`+
+				`<w:p></w:p>`+
+				`</w:body></w:document>`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "## Leave policy\n\nEmployees get 25 days.", text)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		text, err := extractor.Extract(ctx, &ExtractRequest{
+			URL:     "https://example.com/people.csv",
+			Content: []byte("name,role\nAlice,\"Eng | Ops\"\nBob\n"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "| name | role |\n| --- | --- |\n| Alice | Eng \\| Ops |\n| Bob |  |\n", text)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		text, err := extractor.Extract(ctx, &ExtractRequest{
+			Content: []byte(`{"name":"Alice","tags":["a","b"]}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"name\": \"Alice\",\n  \"tags\": [\n    \"a\",\n    \"b\"\n  ]\n}", text)
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		text, err := extractor.Extract(ctx, &ExtractRequest{
+			URL:     "https://example.com/README.md",
+			Content: []byte("\xef\xbb\xbf# Title\n\nBody"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "# Title\n\nBody", text)
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		text, err := extractor.Extract(ctx, &ExtractRequest{
+			URL:     "https://example.com/page.html",
+			Content: []byte(`<html><head><title>Page</title></head><body><p>Hello <b>world</b></p></body></html>`),
+		})
+		require.NoError(t, err)
+		assert.Contains(t, text, "Hello **world**")
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := extractor.Extract(ctx, &ExtractRequest{
+			Content: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		})
+		require.ErrorIs(t, err, ErrUnsupportedContentType)
+	})
+}
+
+func TestExtractPDF_Malformed(t *testing.T) {
+	const (
+		catalog = "<< /Type /Catalog /Pages 2 0 R >>"
+		page    = "<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>"
+	)
+
+	tests := []struct {
+		name    string
+		objects []string
+		want    string
+	}{
+		{
+			name:    "invalid content stream",
+			objects: []string{catalog, "<< /Type /Pages /Kids [3 0 R] /Count 1 >>", page, "<< /Length 12 >>\nstream\nBT 1 2 3 Tj ET\nendstream"},
+			want:    "failed to read PDF page 1: bad Tj operator",
+		},
+		{
+			name:    "unknown filter",
+			objects: []string{catalog, "<< /Type /Pages /Kids [3 0 R] /Count 1 >>", page, "<< /Length 5 /Filter /Bogus >>\nstream\nxxxxx\nendstream"},
+			want:    "failed to read PDF page 1: unknown filter Bogus",
+		},
+		{
+			name:    "page tree cycle",
+			objects: []string{catalog, "<< /Type /Pages /Kids [2 0 R] /Count 2 >>"},
+			want:    "PDF page tree has more than",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := extractPDF(newTestPDF(tt.objects...))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+// newTestPDF builds a PDF of the objects, numbered from 1, the first one
+// is the catalog
+func newTestPDF(objects ...string) []byte {
+	var (
+		sb      strings.Builder
+		offsets []int
+	)
+
+	sb.WriteString("%PDF-1.4\n")
+
+	for i, obj := range objects {
+		offsets = append(offsets, sb.Len())
+		fmt.Fprintf(&sb, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := sb.Len()
+	fmt.Fprintf(&sb, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&sb, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&sb, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return []byte(sb.String())
+}
+
+func TestDetectContentType(t *testing.T) {
+	docx := newTestDOCX(t, `<w:document/>`)
+
+	tests := []struct {
+		name     string
+		url      string
+		declared string
+		content  []byte
+		want     string
+	}{
+		{name: "sniffed html", content: []byte("<!DOCTYPE html><html></html>"), want: ContentTypeHTML},
+		{name: "sniffed pdf", content: []byte("%PDF-1.4\n"), want: ContentTypePDF},
+		{name: "declared", declared: "text/csv; charset=utf-8", content: []byte("a,b"), want: ContentTypeCSV},
+		{name: "extension", url: "https://example.com/notes.md?x=1", content: []byte("# Notes"), want: ContentTypeMarkdown},
+		{name: "docx", url: "https://example.com/download", content: docx, want: ContentTypeDOCX},
+		{name: "json", content: []byte(` {"a": 1}`), want: ContentTypeJSON},
+		{name: "text", content: []byte("hello"), want: ContentTypeText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectContentType(tt.url, tt.declared, tt.content))
+		})
+	}
+}
+
+func newTestDOCX(t *testing.T, document string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+
+	f, err := w.Create("word/document.xml")
+	require.NoError(t, err)
+
+	_, err = f.Write([]byte(document))
+	require.NoError(t, err)
+
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,164 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	maxPDFPages     = 5000 // Maximum number of pages extracted from a PDF
+	maxPDFTreeNodes = 2 * maxPDFPages
+)
+
+// extractPDF returns the text layer of the pages separated by form feeds,
+// the knowledge splitter uses them for the page numbers
+func extractPDF(content []byte) (result string, err error) {
+	// Page being read, 0 for the document structure
+	var current int
+
+	// The PDF library panics on malformed content, also while resolving the
+	// page tree and the objects lazily
+	defer func() {
+		if r := recover(); r != nil {
+			result = ""
+			if current > 0 {
+				err = fmt.Errorf("failed to read PDF page %d: %v", current, r)
+			} else {
+				err = fmt.Errorf("failed to read PDF: %v", r)
+			}
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pdfPages, err := getPDFPages(reader)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		pages = make([]string, 0, len(pdfPages))
+		found bool
+	)
+
+	for i, page := range pdfPages {
+		current = i + 1
+
+		text := getPDFPageText(page)
+
+		if strings.TrimSpace(text) != "" {
+			found = true
+		}
+
+		pages = append(pages, text)
+	}
+
+	// Probably scanned, needs OCR
+	if !found {
+		return "", fmt.Errorf("PDF has no text layer")
+	}
+
+	return strings.Join(pages, "\f"), nil
+}
+
+// getPDFPages walks the page tree once, reader.Page walks it from the root
+// for every page and never ends on trees with cycles
+func getPDFPages(reader *pdf.Reader) ([]pdf.Page, error) {
+	var (
+		pages   []pdf.Page
+		visited int
+		walk    func(node pdf.Value) error
+	)
+
+	walk = func(node pdf.Value) error {
+		visited++
+		if visited > maxPDFTreeNodes {
+			return fmt.Errorf("PDF page tree has more than %d nodes", maxPDFTreeNodes)
+		}
+
+		switch node.Key("Type").Name() {
+		case "Page":
+			if len(pages) == maxPDFPages {
+				return fmt.Errorf("PDF has more than the maximum of %d pages", maxPDFPages)
+			}
+			pages = append(pages, pdf.Page{V: node})
+		case "Pages":
+			kids := node.Key("Kids")
+			for i := 0; i < kids.Len(); i++ {
+				if err := walk(kids.Index(i)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	err := walk(reader.Trailer().Key("Root").Key("Pages"))
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// getPDFPageText lays out the glyphs of the page in lines, top to bottom
+func getPDFPageText(page pdf.Page) string {
+	if page.V.IsNull() {
+		return ""
+	}
+
+	texts := page.Content().Text
+
+	// Glyphs less than a couple of points apart vertically are on the same line
+	const lineTolerance = 2
+
+	sort.SliceStable(texts, func(i, j int) bool {
+		if math.Abs(texts[i].Y-texts[j].Y) > lineTolerance {
+			return texts[i].Y > texts[j].Y
+		}
+		return texts[i].X < texts[j].X
+	})
+
+	var (
+		sb   strings.Builder
+		prev *pdf.Text
+	)
+
+	for i := range texts {
+		text := &texts[i]
+
+		if prev != nil {
+			switch {
+			case math.Abs(prev.Y-text.Y) > lineTolerance:
+				sb.WriteString("\n")
+			case isPDFWordGap(prev, text) && !strings.HasSuffix(prev.S, " ") && !strings.HasPrefix(text.S, " "):
+				sb.WriteString(" ")
+			}
+		}
+
+		sb.WriteString(text.S)
+		prev = text
+	}
+
+	return sb.String()
+}
+
+// isPDFWordGap returns true if the glyphs are far enough apart to be
+// separate words, e.g. table columns, without a space glyph between them
+func isPDFWordGap(prev, next *pdf.Text) bool {
+	if prev.W > 0 {
+		return next.X-(prev.X+prev.W) > 0.25*prev.FontSize
+	}
+
+	// Without the glyph widths, wider than any character
+	return next.X-prev.X > 1.1*prev.FontSize
+}
@@ -0,0 +1,100 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Content types the builtin extractor handles
+const (
+	ContentTypeText     = "text/plain"
+	ContentTypeMarkdown = "text/markdown"
+	ContentTypeHTML     = "text/html"
+	ContentTypeCSV      = "text/csv"
+	ContentTypeJSON     = "application/json"
+	ContentTypePDF      = "application/pdf"
+	ContentTypeDOCX     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// contentTypesByExtension are checked when sniffing only says the content
+// is text or a zip archive
+var contentTypesByExtension = map[string]string{
+	".txt":      ContentTypeText,
+	".md":       ContentTypeMarkdown,
+	".markdown": ContentTypeMarkdown,
+	".csv":      ContentTypeCSV,
+	".json":     ContentTypeJSON,
+	".docx":     ContentTypeDOCX,
+}
+
+// DetectContentType returns the MIME type of the content, without parameters.
+// The declared type, e.g. the Content-Type header, and the extension of the
+// URL are used when sniffing the content is inconclusive.
+func DetectContentType(u, declared string, content []byte) string {
+	sniffed := normalizeContentType(http.DetectContentType(content))
+
+	switch sniffed {
+	case ContentTypeText, "application/zip", "application/octet-stream":
+	default:
+		return sniffed
+	}
+
+	if declared = normalizeContentType(declared); declared != "" && declared != "application/octet-stream" &&
+		declared != ContentTypeText {
+		return declared
+	}
+
+	if contentType, ok := contentTypesByExtension[getExtension(u)]; ok {
+		return contentType
+	}
+
+	switch {
+	case sniffed == "application/zip" && isDOCX(content):
+		return ContentTypeDOCX
+	case sniffed == ContentTypeText && json.Valid(bytes.TrimSpace(content)):
+		return ContentTypeJSON
+	}
+
+	return sniffed
+}
+
+func normalizeContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+func getExtension(u string) string {
+	if u == "" {
+		return ""
+	}
+
+	if parsed, err := url.Parse(u); err == nil {
+		u = parsed.Path
+	}
+
+	return strings.ToLower(path.Ext(u))
+}
+
+func isDOCX(content []byte) bool {
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return false
+	}
+
+	for _, f := range r.File {
+		if f.Name == "word/document.xml" {
+			return true
+		}
+	}
+
+	return false
+}
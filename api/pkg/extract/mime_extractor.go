@@ -0,0 +1,178 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// MIMEExtractor detects the content type of the documents and routes them
+// to the extractors configured for it, falling back to the next extractor
+// when one fails
+type MIMEExtractor struct {
+	routes     map[string][]Extractor
+	fallback   []Extractor
+	httpClient *http.Client
+}
+
+var _ Extractor = &MIMEExtractor{}
+
+func NewMIMEExtractor(routes map[string][]Extractor, fallback ...Extractor) *MIMEExtractor {
+	return &MIMEExtractor{
+		routes:     routes,
+		fallback:   fallback,
+		httpClient: http.DefaultClient,
+	}
+}
+
+func (e *MIMEExtractor) Extract(ctx context.Context, extractReq *ExtractRequest) (string, error) {
+	if extractReq.URL == "" && len(extractReq.Content) == 0 {
+		return "", fmt.Errorf("no URL or content provided")
+	}
+
+	content := extractReq.Content
+
+	var declared string
+
+	// Downloaded once so the type can be detected and every extractor
+	// gets the same content
+	if len(content) == 0 {
+		var err error
+
+		content, declared, err = download(ctx, e.httpClient, extractReq.URL)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	contentType := DetectContentType(extractReq.URL, declared, content)
+
+	candidates := e.getExtractors(contentType)
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+
+	var errs []error
+
+	for i, extractor := range candidates {
+		text, err := extractor.Extract(ctx, &ExtractRequest{
+			URL:     extractReq.URL,
+			Content: content,
+		})
+		if err == nil {
+			return text, nil
+		}
+
+		log.Warn().
+			Err(err).
+			Str("url", extractReq.URL).
+			Str("content_type", contentType).
+			Int("attempt", i+1).
+			Int("extractors", len(candidates)).
+			Msg("text extraction failed, trying the next extractor")
+
+		errs = append(errs, err)
+	}
+
+	return "", fmt.Errorf("failed to extract %s: %w", contentType, errors.Join(errs...))
+}
+
+// getExtractors returns the extractors for the content type in the order
+// they are tried, each extractor at most once
+func (e *MIMEExtractor) getExtractors(contentType string) []Extractor {
+	var (
+		extractors []Extractor
+		seen       = make(map[Extractor]bool)
+	)
+
+	for _, extractor := range append(e.routes[contentType], e.fallback...) {
+		if seen[extractor] {
+			continue
+		}
+		seen[extractor] = true
+		extractors = append(extractors, extractor)
+	}
+
+	return extractors
+}
+
+// NewExtractor returns the text extractor for the configured provider
+func NewExtractor(cfg *config.TextExtractor) (Extractor, error) {
+	switch cfg.Provider {
+	case types.ExtractorTika:
+		return NewTikaExtractor(cfg.Tika.URL), nil
+	case types.ExtractorUnstructured:
+		return NewDefaultExtractor(cfg.Unstructured.URL), nil
+	case types.ExtractorBuiltin:
+		return NewBuiltinExtractor(), nil
+	case types.ExtractorAuto:
+		return newAutoExtractor(cfg)
+	default:
+		return nil, fmt.Errorf("unknown extractor: %s", cfg.Provider)
+	}
+}
+
+func newAutoExtractor(cfg *config.TextExtractor) (*MIMEExtractor, error) {
+	builtin := NewBuiltinExtractor()
+
+	extractors := map[types.Extractor]Extractor{
+		types.ExtractorBuiltin: builtin,
+	}
+
+	getExtractor := func(provider types.Extractor) (Extractor, error) {
+		if extractor, ok := extractors[provider]; ok {
+			return extractor, nil
+		}
+
+		var extractor Extractor
+
+		switch provider {
+		case types.ExtractorTika:
+			extractor = NewTikaExtractor(cfg.Tika.URL)
+		case types.ExtractorUnstructured:
+			extractor = NewDefaultExtractor(cfg.Unstructured.URL)
+		default:
+			return nil, fmt.Errorf("unknown extractor: %s", provider)
+		}
+
+		extractors[provider] = extractor
+		return extractor, nil
+	}
+
+	routes := make(map[string][]Extractor)
+
+	for contentType, provider := range cfg.Routes {
+		extractor, err := getExtractor(provider)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route for %s: %w", contentType, err)
+		}
+
+		contentType = normalizeContentType(contentType)
+		routes[contentType] = append(routes[contentType], extractor)
+	}
+
+	for _, contentType := range []string{
+		ContentTypeText, ContentTypeMarkdown, ContentTypeHTML, ContentTypeCSV,
+		ContentTypeJSON, ContentTypePDF, ContentTypeDOCX,
+	} {
+		routes[contentType] = append(routes[contentType], builtin)
+	}
+
+	var fallback []Extractor
+
+	if cfg.Fallback != "" {
+		extractor, err := getExtractor(cfg.Fallback)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fallback: %w", err)
+		}
+		fallback = append(fallback, extractor)
+	}
+
+	return NewMIMEExtractor(routes, fallback...), nil
+}
@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func TestMIMEExtractor_Extract(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Fallback", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		primary := NewMockExtractor(ctrl)
+		fallback := NewMockExtractor(ctrl)
+
+		req := &ExtractRequest{Content: []byte("%PDF-1.4\n")}
+
+		primary.EXPECT().Extract(ctx, req).Return("", errors.New("no text layer"))
+		fallback.EXPECT().Extract(ctx, req).Return("scanned text", nil)
+
+		extractor := NewMIMEExtractor(map[string][]Extractor{
+			ContentTypePDF: {primary},
+		}, fallback)
+
+		text, err := extractor.Extract(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, "scanned text", text)
+	})
+
+	t.Run("AllFail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		primary := NewMockExtractor(ctrl)
+		primary.EXPECT().Extract(ctx, gomock.Any()).Return("", errors.New("no text layer")).Times(1)
+
+		// The fallback is tried once even if it's also routed
+		extractor := NewMIMEExtractor(map[string][]Extractor{
+			ContentTypePDF: {primary},
+		}, primary)
+
+		_, err := extractor.Extract(ctx, &ExtractRequest{Content: []byte("%PDF-1.4\n")})
+		require.ErrorContains(t, err, "no text layer")
+	})
+
+	t.Run("Unrouted", func(t *testing.T) {
+		extractor := NewMIMEExtractor(map[string][]Extractor{})
+
+		_, err := extractor.Extract(ctx, &ExtractRequest{Content: []byte("hello")})
+		require.ErrorIs(t, err, ErrUnsupportedContentType)
+	})
+}
+
+func TestNewExtractor_Auto(t *testing.T) {
+	cfg := &config.TextExtractor{
+		Provider: types.ExtractorAuto,
+		Routes: map[string]types.Extractor{
+			ContentTypePDF: types.ExtractorTika,
+		},
+		Fallback: types.ExtractorTika,
+	}
+
+	extractor, err := NewExtractor(cfg)
+	require.NoError(t, err)
+
+	mimeExtractor, ok := extractor.(*MIMEExtractor)
+	require.True(t, ok)
+
+	// Tika first for PDFs, then the builtin extractor, the fallback is the same Tika
+	pdf := mimeExtractor.getExtractors(ContentTypePDF)
+	require.Len(t, pdf, 2)
+	assert.IsType(t, &TikaExtractor{}, pdf[0])
+	assert.IsType(t, &BuiltinExtractor{}, pdf[1])
+
+	text := mimeExtractor.getExtractors(ContentTypeText)
+	require.Len(t, text, 2)
+	assert.IsType(t, &BuiltinExtractor{}, text[0])
+	assert.IsType(t, &TikaExtractor{}, text[1])
+
+	cfg.Routes = map[string]types.Extractor{ContentTypePDF: "unknown"}
+	_, err = NewExtractor(cfg)
+	require.Error(t, err)
+}
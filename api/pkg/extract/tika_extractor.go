@@ -56,7 +56,8 @@ func (e *TikaExtractor) extract(ctx context.Context, extractReq *ExtractRequest)
 		return "", fmt.Errorf("no URL or content provided")
 	}
 
-	if extractReq.URL != "" {
+	// Content is set when the caller already downloaded the document
+	if extractReq.URL != "" && len(extractReq.Content) == 0 {
 		resp, err := e.httpClient.Get(extractReq.URL)
 		if err != nil {
 			return "", err
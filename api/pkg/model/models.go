@@ -147,6 +147,7 @@ const (
 	Model_Ollama_NousHermes2ThetaLlama3 string = "adrienbrault/nous-hermes2theta-llama3-8b:q8_0"
 	Model_Ollama_Llama3_70b             string = "llama3:70b"
 	Model_Ollama_Phi3                   string = "phi3:instruct"
+	Model_Ollama_NomicEmbedText         string = "nomic-embed-text:v1.5"
 )
 
 // See also types/models.go for model name constants
@@ -185,6 +186,15 @@ func GetDefaultOllamaModels() ([]*OllamaGenericText, error) {
 			Description:   "Small model, from Meta - 8bit quantized, 128K context",
 			Hide:          false,
 		},
+		// Embedding model for /v1/embeddings, hidden as it can't be used for chat
+		{
+			Id:            Model_Ollama_NomicEmbedText, // https://ollama.com/library/nomic-embed-text:v1.5
+			Name:          "Nomic Embed Text v1.5",
+			Memory:        GB * 1,
+			ContextLength: 8192,
+			Description:   "Text embeddings, from Nomic - 768 dimensions, 8K context",
+			Hide:          true,
+		},
 		// Old llama3:instruct, leaving in here because the id is in lots of our examples
 		{
 			Id:            "llama3:instruct", // https://ollama.com/library/llama3:instruct
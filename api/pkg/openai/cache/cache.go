@@ -0,0 +1,314 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const defaultTTL = time.Hour
+
+// Embedder creates the embeddings used for similarity caching
+type Embedder interface {
+	CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
+}
+
+// Cache stores chat completion responses in memory. Responses are looked up
+// by a hash of the whole request and, if enabled, by the embedding similarity
+// of the last message for requests that are otherwise identical.
+type Cache struct {
+	cfg      config.ResponseCache
+	embedder Embedder
+	now      func() time.Time
+
+	mu sync.Mutex
+	// entries by the exact request key
+	entries map[string]*entry
+	// entries with embeddings by namespace, used for similarity lookups
+	namespaces map[string][]*entry
+}
+
+type entry struct {
+	key       string
+	namespace string
+	response  openai.ChatCompletionResponse
+	embedding []float32
+	created   time.Time
+	expires   time.Time
+}
+
+// lookup holds the keys of a request, the embedding is only calculated when
+// similarity caching is enabled
+type lookup struct {
+	key       string
+	namespace string
+	settings  *types.AssistantCache
+	question  string
+	embedding []float32
+}
+
+func New(cfg config.ResponseCache, embedder Embedder) *Cache {
+	return &Cache{
+		cfg:        cfg,
+		embedder:   embedder,
+		now:        time.Now,
+		entries:    make(map[string]*entry),
+		namespaces: make(map[string][]*entry),
+	}
+}
+
+// newLookup returns the keys of the request, responses are only shared
+// between the requests of the same owner to the same app
+func (c *Cache) newLookup(ownerID, appID string, provider types.Provider, settings *types.AssistantCache, req *openai.ChatCompletionRequest) (*lookup, error) {
+	l := &lookup{
+		settings: settings,
+	}
+
+	var err error
+
+	l.key, err = hashRequest(ownerID, appID, provider, req, req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	// Similar requests have the same conversation history, tools and
+	// parameters and differ only in the last message
+	if len(req.Messages) > 0 {
+		l.namespace, err = hashRequest(ownerID, appID, provider, req, req.Messages[:len(req.Messages)-1])
+		if err != nil {
+			return nil, err
+		}
+		l.question = req.Messages[len(req.Messages)-1].Content
+	}
+
+	return l, nil
+}
+
+// hashRequest hashes everything in the request that affects the response
+func hashRequest(ownerID, appID string, provider types.Provider, req *openai.ChatCompletionRequest, messages []openai.ChatCompletionMessage) (string, error) {
+	bts, err := json.Marshal(struct {
+		OwnerID     string                               `json:"owner_id"`
+		AppID       string                               `json:"app_id"`
+		Provider    types.Provider                       `json:"provider"`
+		Model       string                               `json:"model"`
+		Messages    []openai.ChatCompletionMessage       `json:"messages"`
+		Tools       []openai.Tool                        `json:"tools"`
+		ToolChoice  any                                  `json:"tool_choice"`
+		Functions   []openai.FunctionDefinition          `json:"functions"`
+		Temperature float32                              `json:"temperature"`
+		TopP        float32                              `json:"top_p"`
+		MaxTokens   int                                  `json:"max_tokens"`
+		Format      *openai.ChatCompletionResponseFormat `json:"response_format"`
+	}{
+		OwnerID:     ownerID,
+		AppID:       appID,
+		Provider:    provider,
+		Model:       req.Model,
+		Messages:    messages,
+		Tools:       req.Tools,
+		ToolChoice:  req.ToolChoice,
+		Functions:   req.Functions,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		MaxTokens:   req.MaxTokens,
+		Format:      req.ResponseFormat,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	hash := sha256.Sum256(bts)
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Get returns the cached response for the request, looking for an identical
+// request first and then for a similar one
+func (c *Cache) Get(ctx context.Context, l *lookup) (*openai.ChatCompletionResponse, bool) {
+	now := c.now()
+
+	c.mu.Lock()
+	e, ok := c.entries[l.key]
+	c.mu.Unlock()
+
+	if ok && now.Before(e.expires) {
+		return &e.response, true
+	}
+
+	if !c.similarityEnabled(l) {
+		return nil, false
+	}
+
+	embedding, err := c.embed(ctx, l.question)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to embed question for the response cache")
+		return nil, false
+	}
+	l.embedding = embedding
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		best           *entry
+		bestSimilarity float64
+	)
+
+	for _, candidate := range c.namespaces[l.namespace] {
+		if !now.Before(candidate.expires) {
+			continue
+		}
+
+		similarity := cosineSimilarity(embedding, candidate.embedding)
+		if similarity >= l.settings.SimilarityThreshold && similarity > bestSimilarity {
+			best = candidate
+			bestSimilarity = similarity
+		}
+	}
+
+	if best == nil {
+		return nil, false
+	}
+
+	log.Debug().
+		Float64("similarity", bestSimilarity).
+		Msg("serving similar response from the cache")
+
+	return &best.response, true
+}
+
+// Set stores the response, evicting expired and then the oldest entries
+// when the cache is full
+func (c *Cache) Set(ctx context.Context, l *lookup, resp *openai.ChatCompletionResponse) {
+	if c.similarityEnabled(l) && l.embedding == nil {
+		embedding, err := c.embed(ctx, l.question)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to embed question for the response cache")
+		}
+		l.embedding = embedding
+	}
+
+	ttl := defaultTTL
+	if l.settings.TTLSeconds > 0 {
+		ttl = time.Duration(l.settings.TTLSeconds) * time.Second
+	}
+
+	now := c.now()
+
+	e := &entry{
+		key:       l.key,
+		namespace: l.namespace,
+		response:  *resp,
+		embedding: l.embedding,
+		created:   now,
+		expires:   now.Add(ttl),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, ok := c.entries[l.key]; ok {
+		c.remove(existing)
+	}
+
+	c.entries[e.key] = e
+	if e.embedding != nil {
+		c.namespaces[e.namespace] = append(c.namespaces[e.namespace], e)
+	}
+
+	c.evict(now)
+}
+
+func (c *Cache) similarityEnabled(l *lookup) bool {
+	return l.settings.SimilarityThreshold > 0 && l.question != "" && c.embedder != nil
+}
+
+func (c *Cache) embed(ctx context.Context, text string) ([]float32, error) {
+	resp, err := c.embedder.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: []string{text},
+		Model: openai.EmbeddingModel(c.cfg.EmbeddingsModel),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+
+	return resp.Data[0].Embedding, nil
+}
+
+func (c *Cache) evict(now time.Time) {
+	if c.cfg.MaxEntries <= 0 || len(c.entries) <= c.cfg.MaxEntries {
+		return
+	}
+
+	for _, e := range c.entries {
+		if !now.Before(e.expires) {
+			c.remove(e)
+		}
+	}
+
+	for len(c.entries) > c.cfg.MaxEntries {
+		var oldest *entry
+		for _, e := range c.entries {
+			if oldest == nil || e.created.Before(oldest.created) {
+				oldest = e
+			}
+		}
+		c.remove(oldest)
+	}
+}
+
+func (c *Cache) remove(e *entry) {
+	delete(c.entries, e.key)
+
+	if e.embedding == nil {
+		return
+	}
+
+	entries := c.namespaces[e.namespace]
+	for i, candidate := range entries {
+		if candidate == e {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+
+	if len(entries) == 0 {
+		delete(c.namespaces, e.namespace)
+	} else {
+		c.namespaces[e.namespace] = entries
+	}
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,352 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/transport"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func TestCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(CacheTestSuite))
+}
+
+type CacheTestSuite struct {
+	suite.Suite
+
+	ctx    context.Context
+	client *oai.MockClient
+	now    time.Time
+
+	embedder *fakeEmbedder
+	cache    *Cache
+	cached   *CachingMiddleware
+}
+
+// fakeEmbedder returns fixed embeddings per input
+type fakeEmbedder struct {
+	embeddings map[string][]float32
+	calls      int
+}
+
+func (e *fakeEmbedder) CreateEmbeddings(_ context.Context, request openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	e.calls++
+
+	input := request.Convert().Input.([]string)[0]
+
+	embedding, ok := e.embeddings[input]
+	if !ok {
+		return openai.EmbeddingResponse{}, errors.New("unknown input")
+	}
+
+	return openai.EmbeddingResponse{
+		Data: []openai.Embedding{{Embedding: embedding}},
+	}, nil
+}
+
+func (suite *CacheTestSuite) SetupTest() {
+	suite.ctx = oai.SetContextAppID(context.Background(), "app-1")
+	suite.ctx = oai.SetContextCache(suite.ctx, &types.AssistantCache{
+		Enabled:    true,
+		TTLSeconds: 60,
+	})
+
+	suite.client = oai.NewMockClient(gomock.NewController(suite.T()))
+	suite.now = time.Now()
+
+	suite.embedder = &fakeEmbedder{
+		embeddings: map[string][]float32{
+			"what is helix?":            {1, 0, 0},
+			"what's helix?":             {0.99, 0.1, 0},
+			"how do I install ollama?":  {0, 1, 0},
+			"how do I install ollama?!": {0, 0.98, 0.05},
+		},
+	}
+
+	suite.cache = New(config.ResponseCache{MaxEntries: 100}, suite.embedder)
+	suite.cache.now = func() time.Time { return suite.now }
+
+	suite.cached = Wrap(suite.cache, types.ProviderOpenAI, suite.client)
+}
+
+func newRequest(question string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You are a helpful assistant."},
+			{Role: openai.ChatMessageRoleUser, Content: question},
+		},
+	}
+}
+
+func newResponse(content string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		ID:    "chatcmpl-1",
+		Model: "gpt-4o",
+		Choices: []openai.ChatCompletionChoice{
+			{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+				FinishReason: openai.FinishReasonStop,
+			},
+		},
+	}
+}
+
+func (suite *CacheTestSuite) Test_ExactMatch() {
+	suite.client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newResponse("Helix is a platform"), nil).Times(1)
+
+	resp, err := suite.cached.CreateChatCompletion(suite.ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+	suite.Equal("Helix is a platform", resp.Choices[0].Message.Content)
+
+	status := &oai.CacheStatus{}
+	ctx := oai.SetCacheStatus(suite.ctx, status)
+
+	resp, err = suite.cached.CreateChatCompletion(ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+	suite.Equal("Helix is a platform", resp.Choices[0].Message.Content)
+	suite.True(status.Hit)
+}
+
+func (suite *CacheTestSuite) Test_DifferentAppsDoNotShare() {
+	suite.client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newResponse("Helix is a platform"), nil).Times(2)
+
+	_, err := suite.cached.CreateChatCompletion(suite.ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+
+	ctx := oai.SetContextAppID(suite.ctx, "app-2")
+
+	_, err = suite.cached.CreateChatCompletion(ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+}
+
+func (suite *CacheTestSuite) Test_DifferentOwnersDoNotShare() {
+	suite.client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newResponse("Helix is a platform"), nil).Times(2)
+
+	ctx := oai.SetContextValues(suite.ctx, &oai.ContextValues{OwnerID: "user-1"})
+
+	_, err := suite.cached.CreateChatCompletion(ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+
+	ctx = oai.SetContextValues(suite.ctx, &oai.ContextValues{OwnerID: "user-2"})
+
+	_, err = suite.cached.CreateChatCompletion(ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+}
+
+func (suite *CacheTestSuite) Test_NotEnabled() {
+	suite.client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newResponse("Helix is a platform"), nil).Times(2)
+
+	ctx := oai.SetContextAppID(context.Background(), "app-1")
+
+	for i := 0; i < 2; i++ {
+		_, err := suite.cached.CreateChatCompletion(ctx, newRequest("what is helix?"))
+		suite.Require().NoError(err)
+	}
+}
+
+func (suite *CacheTestSuite) Test_Expired() {
+	suite.client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newResponse("Helix is a platform"), nil).Times(2)
+
+	_, err := suite.cached.CreateChatCompletion(suite.ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+
+	suite.now = suite.now.Add(61 * time.Second)
+
+	_, err = suite.cached.CreateChatCompletion(suite.ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+}
+
+func (suite *CacheTestSuite) Test_Similarity() {
+	suite.ctx = oai.SetContextCache(suite.ctx, &types.AssistantCache{
+		Enabled:             true,
+		SimilarityThreshold: 0.95,
+	})
+
+	suite.client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newResponse("Helix is a platform"), nil)
+	suite.client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newResponse("Run the install script"), nil)
+
+	_, err := suite.cached.CreateChatCompletion(suite.ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+
+	// Similar question is served from the cache
+	resp, err := suite.cached.CreateChatCompletion(suite.ctx, newRequest("what's helix?"))
+	suite.Require().NoError(err)
+	suite.Equal("Helix is a platform", resp.Choices[0].Message.Content)
+
+	// Different question goes to the provider
+	resp, err = suite.cached.CreateChatCompletion(suite.ctx, newRequest("how do I install ollama?"))
+	suite.Require().NoError(err)
+	suite.Equal("Run the install script", resp.Choices[0].Message.Content)
+}
+
+func (suite *CacheTestSuite) Test_Similarity_DifferentHistory() {
+	suite.ctx = oai.SetContextCache(suite.ctx, &types.AssistantCache{
+		Enabled:             true,
+		SimilarityThreshold: 0.95,
+	})
+
+	suite.client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newResponse("Helix is a platform"), nil).Times(2)
+
+	_, err := suite.cached.CreateChatCompletion(suite.ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+
+	req := newRequest("what's helix?")
+	req.Messages[0].Content = "You are a pirate."
+
+	_, err = suite.cached.CreateChatCompletion(suite.ctx, req)
+	suite.Require().NoError(err)
+}
+
+func (suite *CacheTestSuite) Test_Stream_Replay() {
+	suite.client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(newResponse("Helix is a platform"), nil)
+
+	_, err := suite.cached.CreateChatCompletion(suite.ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+
+	status := &oai.CacheStatus{}
+	ctx := oai.SetCacheStatus(suite.ctx, status)
+
+	req := newRequest("what is helix?")
+	req.Stream = true
+
+	stream, err := suite.cached.CreateChatCompletionStream(ctx, req)
+	suite.Require().NoError(err)
+	suite.True(status.Hit)
+
+	content, finishReason := readStream(suite.T(), stream)
+	suite.Equal("Helix is a platform", content)
+	suite.Equal(openai.FinishReasonStop, finishReason)
+}
+
+func (suite *CacheTestSuite) Test_Stream_CachesUpstream() {
+	req := newRequest("what is helix?")
+	req.Stream = true
+
+	suite.client.EXPECT().CreateChatCompletionStream(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+			stream, writer, err := transport.NewOpenAIStreamingAdapter(req)
+			suite.Require().NoError(err)
+
+			go func() {
+				defer writer.Close()
+				for _, chunk := range transport.ResponseChunks(&openai.ChatCompletionResponse{
+					Choices: []openai.ChatCompletionChoice{
+						{Message: openai.ChatCompletionMessage{Content: "Helix is a platform"}},
+					},
+				}) {
+					_ = transport.WriteChatCompletionStream(writer, chunk)
+				}
+			}()
+
+			return stream, nil
+		})
+
+	stream, err := suite.cached.CreateChatCompletionStream(suite.ctx, req)
+	suite.Require().NoError(err)
+
+	content, _ := readStream(suite.T(), stream)
+	suite.Equal("Helix is a platform", content)
+
+	// The entry is stored once the upstream stream finishes
+	suite.Eventually(func() bool {
+		suite.cache.mu.Lock()
+		defer suite.cache.mu.Unlock()
+		return len(suite.cache.entries) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	resp, err := suite.cached.CreateChatCompletion(suite.ctx, newRequest("what is helix?"))
+	suite.Require().NoError(err)
+	suite.Equal("Helix is a platform", resp.Choices[0].Message.Content)
+}
+
+func (suite *CacheTestSuite) Test_Evict() {
+	suite.cache.cfg.MaxEntries = 2
+
+	for i, question := range []string{"a", "b", "c"} {
+		suite.now = suite.now.Add(time.Duration(i) * time.Second)
+		l, err := suite.cache.newLookup("user-1", "app-1", types.ProviderOpenAI, &types.AssistantCache{Enabled: true}, &openai.ChatCompletionRequest{
+			Messages: []openai.ChatCompletionMessage{{Content: question}},
+		})
+		suite.Require().NoError(err)
+
+		resp := newResponse(question)
+		suite.cache.Set(suite.ctx, l, &resp)
+	}
+
+	suite.Len(suite.cache.entries, 2)
+
+	for _, e := range suite.cache.entries {
+		suite.NotEqual("a", e.response.Choices[0].Message.Content)
+	}
+}
+
+func readStream(t *testing.T, stream *openai.ChatCompletionStream) (string, openai.FinishReason) {
+	var (
+		content      strings.Builder
+		finishReason openai.FinishReason
+	)
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read stream: %v", err)
+		}
+
+		if len(chunk.Choices) > 0 {
+			content.WriteString(chunk.Choices[0].Delta.Content)
+			if chunk.Choices[0].FinishReason != "" {
+				finishReason = chunk.Choices[0].FinishReason
+			}
+		}
+	}
+
+	return content.String(), finishReason
+}
+
+func Test_appendChunk_ToolCalls(t *testing.T) {
+	resp := openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}}},
+	}
+
+	first, second := 0, 1
+
+	for _, delta := range []openai.ChatCompletionStreamChoiceDelta{
+		{ToolCalls: []openai.ToolCall{{Index: &first, ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "weather"}}}},
+		{ToolCalls: []openai.ToolCall{{Index: &first, Function: openai.FunctionCall{Arguments: `{"city":`}}}},
+		{ToolCalls: []openai.ToolCall{{Index: &second, ID: "call_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "time", Arguments: "{}"}}}},
+		{ToolCalls: []openai.ToolCall{{Index: &first, Function: openai.FunctionCall{Arguments: `"London"}`}}}},
+		{FunctionCall: &openai.FunctionCall{Name: "legacy", Arguments: `{"a":`}},
+		{FunctionCall: &openai.FunctionCall{Arguments: "1}"}},
+	} {
+		appendChunk(&resp, &openai.ChatCompletionStreamResponse{
+			Choices: []openai.ChatCompletionStreamChoice{{Delta: delta}},
+		})
+	}
+
+	message := resp.Choices[0].Message
+
+	require.Len(t, message.ToolCalls, 2)
+	assert.Equal(t, "call_1", message.ToolCalls[0].ID)
+	assert.Equal(t, openai.FunctionCall{Name: "weather", Arguments: `{"city":"London"}`}, message.ToolCalls[0].Function)
+	assert.Equal(t, "call_2", message.ToolCalls[1].ID)
+	assert.Equal(t, openai.FunctionCall{Name: "time", Arguments: "{}"}, message.ToolCalls[1].Function)
+
+	assert.Equal(t, &openai.FunctionCall{Name: "legacy", Arguments: `{"a":1}`}, message.FunctionCall)
+}
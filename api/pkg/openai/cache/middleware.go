@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime/debug"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/model"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/transport"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+var _ oai.Client = &CachingMiddleware{}
+
+// CachingMiddleware serves chat completions from the cache. It only caches
+// calls made with a context that has the assistant's cache settings set, and
+// is meant to be wrapped by the logging middleware so cache hits are logged.
+type CachingMiddleware struct {
+	cache    *Cache
+	client   oai.Client
+	provider types.Provider
+}
+
+func Wrap(cache *Cache, provider types.Provider, client oai.Client) *CachingMiddleware {
+	return &CachingMiddleware{
+		cache:    cache,
+		client:   client,
+		provider: provider,
+	}
+}
+
+func (m *CachingMiddleware) ListModels(ctx context.Context) ([]model.OpenAIModel, error) {
+	return m.client.ListModels(ctx)
+}
+
+// CreateEmbeddings is not cached
+func (m *CachingMiddleware) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return m.client.CreateEmbeddings(ctx, request)
+}
+
+func (m *CachingMiddleware) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	l, ok := m.lookup(ctx, &request)
+	if !ok {
+		return m.client.CreateChatCompletion(ctx, request)
+	}
+
+	if cached, ok := m.cache.Get(ctx, l); ok {
+		setHit(ctx)
+		return *cached, nil
+	}
+
+	resp, err := m.client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	if len(resp.Choices) > 0 {
+		m.cache.Set(ctx, l, &resp)
+	}
+
+	return resp, nil
+}
+
+func (m *CachingMiddleware) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	l, ok := m.lookup(ctx, &request)
+	if !ok {
+		return m.client.CreateChatCompletionStream(ctx, request)
+	}
+
+	if cached, ok := m.cache.Get(ctx, l); ok {
+		setHit(ctx)
+		return transport.NewOpenAIStreamFromResponse(request, cached)
+	}
+
+	upstream, err := m.client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	downstream, downstreamWriter, err := transport.NewOpenAIStreamingAdapter(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create streaming adapter: %w", err)
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().Msgf("Recovered from panic: %v\n%s", r, debug.Stack())
+			}
+		}()
+		defer downstreamWriter.Close()
+
+		resp := openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}}},
+		}
+
+		for {
+			chunk, err := upstream.Recv()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				// Incomplete responses are not cached
+				log.Error().Err(err).Msg("failed to receive message from upstream stream")
+				return
+			}
+
+			appendChunk(&resp, &chunk)
+
+			err = transport.WriteChatCompletionStream(downstreamWriter, &chunk)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to write message to downstream stream")
+				return
+			}
+		}
+
+		m.cache.Set(context.Background(), l, &resp)
+	}()
+
+	return downstream, nil
+}
+
+func (m *CachingMiddleware) lookup(ctx context.Context, request *openai.ChatCompletionRequest) (*lookup, bool) {
+	settings, ok := oai.GetContextCache(ctx)
+	if !ok || !settings.Enabled {
+		return nil, false
+	}
+
+	var ownerID string
+	if vals, ok := oai.GetContextValues(ctx); ok {
+		ownerID = vals.OwnerID
+	}
+
+	appID, _ := oai.GetContextAppID(ctx)
+
+	l, err := m.cache.newLookup(ownerID, appID, m.provider, settings, request)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to create response cache key, skipping cache")
+		return nil, false
+	}
+
+	return l, true
+}
+
+func setHit(ctx context.Context) {
+	if status, ok := oai.GetCacheStatus(ctx); ok {
+		status.Hit = true
+	}
+}
+
+// appendChunk accumulates the streamed chunks into a response so it can be
+// cached and replayed
+func appendChunk(resp *openai.ChatCompletionResponse, chunk *openai.ChatCompletionStreamResponse) {
+	if chunk.ID != "" {
+		resp.ID = chunk.ID
+	}
+	if chunk.Model != "" {
+		resp.Model = chunk.Model
+	}
+	if chunk.Created != 0 {
+		resp.Created = chunk.Created
+	}
+
+	if len(chunk.Choices) == 0 {
+		return
+	}
+
+	choice := &resp.Choices[0]
+	delta := chunk.Choices[0].Delta
+
+	choice.Message.Content += delta.Content
+	if delta.FunctionCall != nil {
+		if choice.Message.FunctionCall == nil {
+			choice.Message.FunctionCall = &openai.FunctionCall{}
+		}
+		appendFunctionCall(choice.Message.FunctionCall, delta.FunctionCall)
+	}
+	for _, toolCall := range delta.ToolCalls {
+		appendToolCall(&choice.Message, toolCall)
+	}
+	if chunk.Choices[0].FinishReason != "" {
+		choice.FinishReason = chunk.Choices[0].FinishReason
+	}
+}
+
+// appendToolCall merges the tool call delta into the call with the same
+// index, the first delta of a call has its ID and name and the following
+// ones continue the arguments
+func appendToolCall(message *openai.ChatCompletionMessage, delta openai.ToolCall) {
+	for i := range message.ToolCalls {
+		toolCall := &message.ToolCalls[i]
+
+		sameCall := delta.Index != nil && toolCall.Index != nil && *toolCall.Index == *delta.Index
+		if !sameCall && delta.Index == nil && delta.ID == "" && i == len(message.ToolCalls)-1 {
+			// Providers without indexes only send the ID with the first delta
+			sameCall = true
+		}
+
+		if !sameCall {
+			continue
+		}
+
+		if delta.ID != "" {
+			toolCall.ID = delta.ID
+		}
+		if delta.Type != "" {
+			toolCall.Type = delta.Type
+		}
+		appendFunctionCall(&toolCall.Function, &delta.Function)
+
+		return
+	}
+
+	message.ToolCalls = append(message.ToolCalls, delta)
+}
+
+func appendFunctionCall(call, delta *openai.FunctionCall) {
+	if delta.Name != "" {
+		call.Name = delta.Name
+	}
+	call.Arguments += delta.Arguments
+}
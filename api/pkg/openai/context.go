@@ -9,17 +9,23 @@ import (
 const (
 	contextValuesKey = "contextValues"
 	contextAppIDKey  = "appID"
+	contextRouterKey = "router"
+	contextCacheKey  = "cache"
+	cacheStatusKey   = "cacheStatus"
 	stepKey          = "step"
+	priorityClassKey = "priorityClass"
 )
 
 type Step struct {
-	Step types.LLMCallStep
+	Step      types.LLMCallStep
+	Iteration int
 }
 
 type ContextValues struct {
 	OwnerID         string
 	SessionID       string
 	InteractionID   string
+	APIKey          string // Set when the request was authenticated with an API key
 	OriginalRequest []byte
 }
 
@@ -32,6 +38,53 @@ func GetContextAppID(ctx context.Context) (string, bool) {
 	return appID, ok
 }
 
+// SetContextPriorityClass sets the scheduling priority class of the calls
+// made with the context to the Helix runners
+func SetContextPriorityClass(ctx context.Context, class types.SchedulerPriorityClass) context.Context {
+	return context.WithValue(ctx, priorityClassKey, class)
+}
+
+func GetContextPriorityClass(ctx context.Context) (types.SchedulerPriorityClass, bool) {
+	class, ok := ctx.Value(priorityClassKey).(types.SchedulerPriorityClass)
+	return class, ok
+}
+
+// SetContextRouter records the name of the routing provider that dispatched
+// the call so the logging middleware of the serving provider can record it
+func SetContextRouter(ctx context.Context, router string) context.Context {
+	return context.WithValue(ctx, contextRouterKey, router)
+}
+
+func GetContextRouter(ctx context.Context) (string, bool) {
+	router, ok := ctx.Value(contextRouterKey).(string)
+	return router, ok
+}
+
+// SetContextCache enables the response cache for the calls made with the context
+func SetContextCache(ctx context.Context, cache *types.AssistantCache) context.Context {
+	return context.WithValue(ctx, contextCacheKey, cache)
+}
+
+func GetContextCache(ctx context.Context) (*types.AssistantCache, bool) {
+	cache, ok := ctx.Value(contextCacheKey).(*types.AssistantCache)
+	return cache, ok && cache != nil
+}
+
+// CacheStatus is set by the response cache so that the logging middleware
+// wrapping it can record whether the response was served from the cache
+type CacheStatus struct {
+	Hit bool
+}
+
+func SetCacheStatus(ctx context.Context, status *CacheStatus) context.Context {
+	return context.WithValue(ctx, cacheStatusKey, status)
+}
+
+func GetCacheStatus(ctx context.Context) (*CacheStatus, bool) {
+	status, ok := ctx.Value(cacheStatusKey).(*CacheStatus)
+	return status, ok
+}
+
 func SetContextValues(ctx context.Context, vals *ContextValues) context.Context {
 	// Check if the context already has values, if it does,
 	// preserve the OriginalRequest
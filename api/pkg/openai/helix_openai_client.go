@@ -48,6 +48,43 @@ func ListModels(ctx context.Context) ([]model.OpenAIModel, error) {
 }
 
 func (c *InternalHelixServer) CreateChatCompletion(requestCtx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	runnerResp, err := c.runInferenceRequest(requestCtx, &types.RunnerLLMInferenceRequest{
+		Request: &request,
+	})
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+
+	if runnerResp.Response == nil {
+		return openai.ChatCompletionResponse{}, nil
+	}
+
+	return *runnerResp.Response, nil
+}
+
+// CreateEmbeddings runs the embeddings on the runners through Ollama
+func (c *InternalHelixServer) CreateEmbeddings(requestCtx context.Context, request openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	embeddingReq := request.Convert()
+
+	runnerResp, err := c.runInferenceRequest(requestCtx, &types.RunnerLLMInferenceRequest{
+		Request: &openai.ChatCompletionRequest{
+			Model: string(embeddingReq.Model),
+		},
+		EmbeddingRequest: &embeddingReq,
+	})
+	if err != nil {
+		return openai.EmbeddingResponse{}, err
+	}
+
+	if runnerResp.EmbeddingResponse == nil {
+		return openai.EmbeddingResponse{}, fmt.Errorf("runner returned no embeddings")
+	}
+
+	return *runnerResp.EmbeddingResponse, nil
+}
+
+// runInferenceRequest enqueues the request for the runners and waits for the response
+func (c *InternalHelixServer) runInferenceRequest(requestCtx context.Context, inferenceReq *types.RunnerLLMInferenceRequest) (*types.RunnerLLMInferenceResponse, error) {
 	ctx, cancel := context.WithTimeout(requestCtx, chatCompletionTimeout)
 	defer cancel()
 
@@ -58,15 +95,15 @@ func (c *InternalHelixServer) CreateChatCompletion(requestCtx context.Context, r
 	// ownerID, sessionID, interactionID := GetContextValues(ctx)
 	vals, ok := GetContextValues(ctx)
 	if !ok {
-		return openai.ChatCompletionResponse{}, fmt.Errorf("ownerID not set in context, use 'openai.SetContextValues()' before calling this method")
+		return nil, fmt.Errorf("ownerID not set in context, use 'openai.SetContextValues()' before calling this method")
 	}
 
 	if vals.OwnerID == "" {
-		return openai.ChatCompletionResponse{}, fmt.Errorf("ownerID not set in context, use 'openai.SetContextValues()' before calling this method")
+		return nil, fmt.Errorf("ownerID not set in context, use 'openai.SetContextValues()' before calling this method")
 	}
 
 	var (
-		resp      openai.ChatCompletionResponse
+		resp      types.RunnerLLMInferenceResponse
 		respError error
 	)
 
@@ -80,9 +117,7 @@ func (c *InternalHelixServer) CreateChatCompletion(requestCtx context.Context, r
 
 		defer close(doneCh)
 
-		if runnerResp.Response != nil {
-			resp = *runnerResp.Response
-		}
+		resp = runnerResp
 
 		if runnerResp.Error != "" {
 			respError = fmt.Errorf("runner error: %s", runnerResp.Error)
@@ -91,22 +126,23 @@ func (c *InternalHelixServer) CreateChatCompletion(requestCtx context.Context, r
 		return nil
 	})
 	if err != nil {
-		return openai.ChatCompletionResponse{}, fmt.Errorf("failed to subscribe to runner responses: %w", err)
+		return nil, fmt.Errorf("failed to subscribe to runner responses: %w", err)
 	}
 
 	defer sub.Unsubscribe()
 
+	inferenceReq.RequestID = requestID
+	inferenceReq.CreatedAt = time.Now()
+	inferenceReq.OwnerID = vals.OwnerID
+	inferenceReq.AppID, _ = GetContextAppID(ctx)
+	inferenceReq.SessionID = vals.SessionID
+	inferenceReq.InteractionID = vals.InteractionID
+	inferenceReq.PriorityClass, _ = GetContextPriorityClass(ctx)
+
 	// Enqueue the request, it will be picked up by the runner
-	err = c.enqueueRequest(&types.RunnerLLMInferenceRequest{
-		RequestID:     requestID,
-		CreatedAt:     time.Now(),
-		OwnerID:       vals.OwnerID,
-		SessionID:     vals.SessionID,
-		InteractionID: vals.InteractionID,
-		Request:       &request,
-	})
+	err = c.enqueueRequest(inferenceReq)
 	if err != nil {
-		return openai.ChatCompletionResponse{}, fmt.Errorf("error enqueuing request: %w", err)
+		return nil, fmt.Errorf("error enqueuing request: %w", err)
 	}
 
 	// Wait for the response or until the context is done (timeout)
@@ -118,7 +154,7 @@ func (c *InternalHelixServer) CreateChatCompletion(requestCtx context.Context, r
 		if err != nil {
 			log.Error().Err(err).Msg("error releasing allocation")
 		}
-		return openai.ChatCompletionResponse{}, fmt.Errorf("request was cancelled")
+		return nil, fmt.Errorf("request was cancelled")
 	case <-ctx.Done():
 		// If this happens, we have timed out
 		log.Warn().
@@ -128,7 +164,7 @@ func (c *InternalHelixServer) CreateChatCompletion(requestCtx context.Context, r
 		if err != nil {
 			log.Error().Err(err).Msg("error releasing allocation")
 		}
-		return openai.ChatCompletionResponse{}, fmt.Errorf("timeout waiting for runner response")
+		return nil, fmt.Errorf("timeout waiting for runner response")
 	}
 
 	if respError != nil {
@@ -136,10 +172,10 @@ func (c *InternalHelixServer) CreateChatCompletion(requestCtx context.Context, r
 		if err != nil {
 			log.Error().Err(err).Msg("error releasing allocation")
 		}
-		return openai.ChatCompletionResponse{}, respError
+		return nil, respError
 	}
 
-	return resp, nil
+	return &resp, nil
 }
 
 func (c *InternalHelixServer) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
@@ -223,12 +259,17 @@ func (c *InternalHelixServer) CreateChatCompletionStream(ctx context.Context, re
 	}
 
 	// Enqueue the request, it will be picked up by the runner
+	appID, _ := GetContextAppID(ctx)
+	priorityClass, _ := GetContextPriorityClass(ctx)
+
 	err = c.enqueueRequest(&types.RunnerLLMInferenceRequest{
 		RequestID:     requestID,
 		CreatedAt:     time.Now(),
 		OwnerID:       vals.OwnerID,
+		AppID:         appID,
 		SessionID:     vals.SessionID,
 		InteractionID: vals.InteractionID,
+		PriorityClass: priorityClass,
 		Request:       &request,
 	})
 	if err != nil {
@@ -47,7 +47,7 @@ func (suite *HelixClientTestSuite) SetupTest() {
 	suite.pubsub = pubsub
 
 	cfg, _ := config.LoadServerConfig()
-	scheduler := scheduler.NewScheduler(suite.ctx, &cfg, nil)
+	scheduler := scheduler.NewScheduler(suite.ctx, &cfg, nil, nil)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          runnerID,
 		TotalMemory: 9999999999,
@@ -134,6 +134,52 @@ func (suite *HelixClientTestSuite) Test_CreateChatCompletion_ErrorResponse() {
 	suite.Contains(err.Error(), "too many tokens")
 }
 
+func (suite *HelixClientTestSuite) Test_CreateEmbeddings_Response() {
+	var (
+		ownerID       = "owner1"
+		sessionID     = "session1"
+		interactionID = "interaction1"
+	)
+
+	// Fake running will pick up our request and send a response
+	go startFakeRunner(suite.T(), suite.srv, []*types.RunnerLLMInferenceResponse{
+		{
+			OwnerID:       ownerID,
+			SessionID:     sessionID,
+			InteractionID: interactionID,
+			EmbeddingResponse: &openai.EmbeddingResponse{
+				Data: []openai.Embedding{
+					{
+						Index:     0,
+						Embedding: []float32{0.1, 0.2, 0.3},
+					},
+				},
+				Usage: openai.Usage{
+					PromptTokens: 3,
+					TotalTokens:  3,
+				},
+			},
+			Done: true,
+		},
+	})
+
+	ctx := SetContextValues(suite.ctx, &ContextValues{
+		OwnerID:       ownerID,
+		SessionID:     sessionID,
+		InteractionID: interactionID,
+	})
+
+	resp, err := suite.srv.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Model: openai.EmbeddingModel(model.Model_Ollama_NomicEmbedText),
+		Input: "Hello, world!",
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(resp.Data, 1)
+	suite.Equal([]float32{0.1, 0.2, 0.3}, resp.Data[0].Embedding)
+	suite.Equal(3, resp.Usage.TotalTokens)
+}
+
 func (suite *HelixClientTestSuite) Test_CreateChatCompletion_StreamingResponse() {
 	var (
 		ownerID       = "owner1"
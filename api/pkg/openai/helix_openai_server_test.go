@@ -38,7 +38,7 @@ func (suite *HelixOpenAiServerTestSuite) SetupTest() {
 	suite.pubsub = pubsub
 
 	cfg, _ := config.LoadServerConfig()
-	scheduler := scheduler.NewScheduler(suite.ctx, &cfg, nil)
+	scheduler := scheduler.NewScheduler(suite.ctx, &cfg, nil, nil)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "runner-1",
 		TotalMemory: model.GB * 24, // 24GB runner
@@ -51,8 +51,37 @@ func (m *LoggingMiddleware) ListModels(ctx context.Context) ([]model.OpenAIModel
 	return m.client.ListModels(ctx)
 }
 
+func (m *LoggingMiddleware) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	start := time.Now()
+
+	resp, err := m.client.CreateEmbeddings(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	req := request.Convert()
+
+	m.wg.Add(1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().Msgf("Recovered from panic: %v", r)
+			}
+		}()
+
+		defer m.wg.Done()
+
+		m.logEmbeddingsCall(ctx, &req, &resp, time.Since(start).Milliseconds())
+	}()
+
+	return resp, nil
+}
+
 func (m *LoggingMiddleware) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
 	start := time.Now()
+
+	ctx = oai.SetCacheStatus(ctx, &oai.CacheStatus{})
+
 	resp, err := m.client.CreateChatCompletion(ctx, request)
 	if err != nil {
 		return resp, err
@@ -75,6 +104,8 @@ func (m *LoggingMiddleware) CreateChatCompletion(ctx context.Context, request op
 }
 
 func (m *LoggingMiddleware) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	ctx = oai.SetCacheStatus(ctx, &oai.CacheStatus{})
+
 	upstream, err := m.client.CreateChatCompletionStream(ctx, request)
 	if err != nil {
 		return nil, err
@@ -179,6 +210,38 @@ func (m *LoggingMiddleware) logLLMCall(ctx context.Context, req *openai.ChatComp
 		return
 	}
 
+	m.createLLMCall(ctx, req.Model, types.LLMCallStepDefault, reqBts, respBts, resp.Usage, durationMs)
+}
+
+func (m *LoggingMiddleware) logEmbeddingsCall(ctx context.Context, req *openai.EmbeddingRequest, resp *openai.EmbeddingResponse, durationMs int64) {
+	reqBts, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("failed to marshal embeddings request")
+		return
+	}
+
+	// The vectors are left out, they are large and not useful in the logs
+	logged := *resp
+	logged.Data = make([]openai.Embedding, 0, len(resp.Data))
+	for _, data := range resp.Data {
+		logged.Data = append(logged.Data, openai.Embedding{
+			Object: data.Object,
+			Index:  data.Index,
+		})
+	}
+
+	respBts, err := json.MarshalIndent(logged, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("failed to marshal embeddings response")
+		return
+	}
+
+	m.createLLMCall(ctx, string(req.Model), types.LLMCallStepEmbeddings, reqBts, respBts, resp.Usage, durationMs)
+}
+
+// createLLMCall saves the call in the log stores, defaultStep is used when
+// the context doesn't have a step set
+func (m *LoggingMiddleware) createLLMCall(ctx context.Context, model string, defaultStep types.LLMCallStep, reqBts, respBts []byte, usage openai.Usage, durationMs int64) {
 	vals, ok := oai.GetContextValues(ctx)
 	if !ok {
 		// Session data will be missing for Discord, Slack, etc.
@@ -191,6 +254,9 @@ func (m *LoggingMiddleware) logLLMCall(ctx context.Context, req *openai.ChatComp
 		// It's normal to not have the step in the context (if it's not a tool)
 		log.Debug().Msg("failed to get step")
 		step = &oai.Step{}
+		if defaultStep != types.LLMCallStepDefault {
+			step.Step = defaultStep
+		}
 	}
 
 	appID, ok := oai.GetContextAppID(ctx)
@@ -198,38 +264,61 @@ func (m *LoggingMiddleware) logLLMCall(ctx context.Context, req *openai.ChatComp
 		log.Debug().Msg("failed to get app_id")
 	}
 
+	// Set when the call was dispatched through a routing provider,
+	// m.provider is the provider that actually served it
+	router, _ := oai.GetContextRouter(ctx)
+
+	cacheHit := false
+	if status, ok := oai.GetCacheStatus(ctx); ok {
+		cacheHit = status.Hit
+	}
+
 	log.Debug().
 		Str("owner_id", vals.OwnerID).
 		Str("app_id", appID).
-		Str("model", req.Model).
+		Str("model", model).
 		Str("provider", string(m.provider)).
+		Str("router", router).
+		Bool("cache_hit", cacheHit).
 		Str("step", string(step.Step)).
-		Int("prompt_tokens", resp.Usage.PromptTokens).
-		Int("completion_tokens", resp.Usage.CompletionTokens).
-		Int("total_tokens", resp.Usage.TotalTokens).
+		Int("prompt_tokens", usage.PromptTokens).
+		Int("completion_tokens", usage.CompletionTokens).
+		Int("total_tokens", usage.TotalTokens).
 		Msg("logging LLM call")
 
 	llmCall := &types.LLMCall{
 		AppID:            appID,
 		SessionID:        vals.SessionID,
 		InteractionID:    vals.InteractionID,
-		Model:            req.Model,
+		Model:            model,
 		Step:             step.Step,
+		Iteration:        step.Iteration,
 		OriginalRequest:  vals.OriginalRequest,
 		Request:          reqBts,
 		Response:         respBts,
 		Provider:         string(m.provider),
+		Router:           router,
+		CacheHit:         cacheHit,
 		DurationMs:       durationMs,
-		PromptTokens:     int64(resp.Usage.PromptTokens),
-		CompletionTokens: int64(resp.Usage.CompletionTokens),
-		TotalTokens:      int64(resp.Usage.TotalTokens),
+		PromptTokens:     int64(usage.PromptTokens),
+		CompletionTokens: int64(usage.CompletionTokens),
+		TotalTokens:      int64(usage.TotalTokens),
 		UserID:           vals.OwnerID,
+		APIKey:           types.HashAPIKey(vals.APIKey),
+	}
+
+	// Cached responses didn't consume any tokens from the provider, don't
+	// count them towards usage and quotas
+	if cacheHit {
+		llmCall.PromptTokens = 0
+		llmCall.CompletionTokens = 0
+		llmCall.TotalTokens = 0
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), logCallTimeout)
 	defer cancel()
 
 	for _, logStore := range m.logStores {
-		_, err = logStore.CreateLLMCall(ctx, llmCall)
+		_, err := logStore.CreateLLMCall(ctx, llmCall)
 		if err != nil {
 			log.Error().Err(err).Msg("failed to log LLM call")
 		}
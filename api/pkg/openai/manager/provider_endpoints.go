@@ -0,0 +1,123 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	openai "github.com/sashabaranov/go-openai"
+	"gopkg.in/yaml.v3"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/model"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/cache"
+	"github.com/helixml/helix/api/pkg/openai/logger"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type endpointsFile struct {
+	Endpoints []*types.ProviderEndpoint `yaml:"endpoints"`
+}
+
+// LoadEndpointsFile reads provider endpoints from a YAML file, for example:
+//
+//	endpoints:
+//	  - name: vllm
+//	    base_url: http://vllm:8000/v1
+//	    api_key: ${VLLM_API_KEY}
+//	    models:
+//	      - meta-llama/Meta-Llama-3.1-8B-Instruct
+func LoadEndpointsFile(path string) ([]*types.ProviderEndpoint, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider endpoints file: %w", err)
+	}
+
+	var file endpointsFile
+	err = yaml.Unmarshal([]byte(os.ExpandEnv(string(bts))), &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse provider endpoints file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+
+	for _, endpoint := range file.Endpoints {
+		if endpoint.Name == "" {
+			return nil, fmt.Errorf("provider endpoint name is required")
+		}
+		if endpoint.BaseURL == "" {
+			return nil, fmt.Errorf("base_url is required for provider endpoint '%s'", endpoint.Name)
+		}
+		if types.Provider(endpoint.Name).IsBuiltin() {
+			return nil, fmt.Errorf("provider endpoint name '%s' is reserved", endpoint.Name)
+		}
+		if seen[endpoint.Name] {
+			return nil, fmt.Errorf("duplicate provider endpoint '%s'", endpoint.Name)
+		}
+		seen[endpoint.Name] = true
+
+		endpoint.OwnerType = types.OwnerTypeSystem
+	}
+
+	return file.Endpoints, nil
+}
+
+func newEndpointClient(cfg *config.ServerConfig, endpoint *types.ProviderEndpoint, apiKey string, responseCache *cache.Cache, logStores ...logger.LogStore) oai.Client {
+	var client oai.Client = oai.New(apiKey, endpoint.BaseURL)
+
+	if len(endpoint.Models) > 0 {
+		client = &modelFilterClient{
+			endpoint: endpoint,
+			client:   client,
+		}
+	}
+
+	return wrapClient(cfg, types.Provider(endpoint.Name), client, responseCache, logStores...)
+}
+
+var _ oai.Client = &modelFilterClient{}
+
+// modelFilterClient enforces the provider endpoint's model allow-list
+type modelFilterClient struct {
+	endpoint *types.ProviderEndpoint
+	client   oai.Client
+}
+
+func (c *modelFilterClient) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	if !c.endpoint.AllowsModel(request.Model) {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("model '%s' is not allowed for provider '%s'", request.Model, c.endpoint.Name)
+	}
+	return c.client.CreateChatCompletion(ctx, request)
+}
+
+func (c *modelFilterClient) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	if !c.endpoint.AllowsModel(request.Model) {
+		return nil, fmt.Errorf("model '%s' is not allowed for provider '%s'", request.Model, c.endpoint.Name)
+	}
+	return c.client.CreateChatCompletionStream(ctx, request)
+}
+
+func (c *modelFilterClient) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	req := request.Convert()
+	if !c.endpoint.AllowsModel(string(req.Model)) {
+		return openai.EmbeddingResponse{}, fmt.Errorf("model '%s' is not allowed for provider '%s'", req.Model, c.endpoint.Name)
+	}
+	return c.client.CreateEmbeddings(ctx, req)
+}
+
+func (c *modelFilterClient) ListModels(ctx context.Context) ([]model.OpenAIModel, error) {
+	models, err := c.client.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]model.OpenAIModel, 0, len(models))
+	for _, m := range models {
+		if c.endpoint.AllowsModel(m.ID) {
+			filtered = append(filtered, m)
+		}
+	}
+
+	return filtered, nil
+}
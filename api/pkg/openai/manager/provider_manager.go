@@ -2,14 +2,18 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/helixml/helix/api/pkg/config"
 	"github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/cache"
 	"github.com/helixml/helix/api/pkg/openai/logger"
+	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/types"
 )
 
@@ -29,16 +33,35 @@ type ProviderManager interface {
 
 type providerClient struct {
 	client openai.Client
+	// updated is set for clients created from provider endpoints stored
+	// in the database, used to detect when the client needs to be recreated
+	updated time.Time
 }
 
 type MultiClientManager struct {
+	cfg       *config.ServerConfig
+	store     store.Store
+	logStores []logger.LogStore
+	// responseCache is nil when the response cache is disabled
+	responseCache *cache.Cache
+
 	clients   map[types.Provider]*providerClient
 	clientsMu *sync.RWMutex
+
+	// endpointClients are created lazily from provider endpoints stored
+	// in the database so they can be added or changed without a restart
+	endpointClients   map[types.Provider]*providerClient
+	endpointClientsMu *sync.Mutex
 }
 
-func NewProviderManager(cfg *config.ServerConfig, helixInference openai.Client, logStores ...logger.LogStore) *MultiClientManager {
+func NewProviderManager(cfg *config.ServerConfig, store store.Store, helixInference openai.Client, logStores ...logger.LogStore) (*MultiClientManager, error) {
 	clients := make(map[types.Provider]*providerClient)
 
+	responseCache, err := newResponseCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	if cfg.Providers.OpenAI.APIKey != "" {
 		log.Info().
 			Str("base_url", cfg.Providers.OpenAI.BaseURL).
@@ -48,7 +71,7 @@ func NewProviderManager(cfg *config.ServerConfig, helixInference openai.Client,
 			cfg.Providers.OpenAI.APIKey,
 			cfg.Providers.OpenAI.BaseURL)
 
-		loggedClient := logger.Wrap(cfg, types.ProviderOpenAI, openaiClient, logStores...)
+		loggedClient := wrapClient(cfg, types.ProviderOpenAI, openaiClient, responseCache, logStores...)
 
 		clients[types.ProviderOpenAI] = &providerClient{client: loggedClient}
 	}
@@ -62,43 +85,205 @@ func NewProviderManager(cfg *config.ServerConfig, helixInference openai.Client,
 			cfg.Providers.TogetherAI.APIKey,
 			cfg.Providers.TogetherAI.BaseURL)
 
-		loggedClient := logger.Wrap(cfg, types.ProviderTogetherAI, togetherAiClient, logStores...)
+		loggedClient := wrapClient(cfg, types.ProviderTogetherAI, togetherAiClient, responseCache, logStores...)
 
 		clients[types.ProviderTogetherAI] = &providerClient{client: loggedClient}
 	}
 
 	// Always configure Helix provider too
 
-	loggedClient := logger.Wrap(cfg, types.ProviderHelix, helixInference, logStores...)
+	loggedClient := wrapClient(cfg, types.ProviderHelix, helixInference, responseCache, logStores...)
 
 	clients[types.ProviderHelix] = &providerClient{client: loggedClient}
 
-	return &MultiClientManager{
-		clients:   clients,
-		clientsMu: &sync.RWMutex{},
+	if cfg.Providers.EndpointsFile != "" {
+		endpoints, err := LoadEndpointsFile(cfg.Providers.EndpointsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, endpoint := range endpoints {
+			provider := types.Provider(endpoint.Name)
+			if _, ok := clients[provider]; ok {
+				return nil, fmt.Errorf("provider endpoint '%s' conflicts with an already configured provider", endpoint.Name)
+			}
+
+			log.Info().
+				Str("name", endpoint.Name).
+				Str("base_url", endpoint.BaseURL).
+				Msg("initializing provider endpoint client")
+
+			clients[provider] = &providerClient{
+				client: newEndpointClient(cfg, endpoint, endpoint.APIKey, responseCache, logStores...),
+			}
+		}
 	}
+
+	m := &MultiClientManager{
+		cfg:               cfg,
+		store:             store,
+		logStores:         logStores,
+		responseCache:     responseCache,
+		clients:           clients,
+		clientsMu:         &sync.RWMutex{},
+		endpointClients:   make(map[types.Provider]*providerClient),
+		endpointClientsMu: &sync.Mutex{},
+	}
+
+	if cfg.Providers.RoutersFile != "" {
+		routers, err := LoadRoutersFile(cfg.Providers.RoutersFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, router := range routers {
+			provider := types.Provider(router.Name)
+			if _, ok := clients[provider]; ok {
+				return nil, fmt.Errorf("router '%s' conflicts with an already configured provider", router.Name)
+			}
+
+			log.Info().
+				Str("name", router.Name).
+				Int("models", len(router.Models)).
+				Msg("initializing provider router")
+
+			// Not wrapped with the logging middleware, calls are logged by
+			// the clients of the providers the router dispatches to
+			clients[provider] = &providerClient{client: newRouterClient(router, m)}
+		}
+	}
+
+	return m, nil
 }
 
 func (m *MultiClientManager) ListProviders(ctx context.Context) ([]types.Provider, error) {
 	m.clientsMu.RLock()
-	defer m.clientsMu.RUnlock()
-
 	providers := make([]types.Provider, 0, len(m.clients))
 	for provider := range m.clients {
 		providers = append(providers, provider)
 	}
+	m.clientsMu.RUnlock()
+
+	if m.store == nil {
+		return providers, nil
+	}
+
+	endpoints, err := m.store.ListProviderEndpoints(ctx, &store.ListProviderEndpointsQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provider endpoints: %w", err)
+	}
+
+	for _, endpoint := range endpoints {
+		providers = append(providers, types.Provider(endpoint.Name))
+	}
 
 	return providers, nil
 }
 
 func (m *MultiClientManager) GetClient(ctx context.Context, req *GetClientRequest) (openai.Client, error) {
 	m.clientsMu.RLock()
-	defer m.clientsMu.RUnlock()
-
 	client, ok := m.clients[req.Provider]
-	if !ok {
-		return nil, fmt.Errorf("no client found for provider: %s", req.Provider)
+	m.clientsMu.RUnlock()
+
+	if ok {
+		return client.client, nil
+	}
+
+	return m.getEndpointClient(ctx, req.Provider)
+}
+
+// getEndpointClient looks up the provider endpoint in the database and returns
+// a client for it. Clients are cached and only recreated when the endpoint
+// has been updated.
+func (m *MultiClientManager) getEndpointClient(ctx context.Context, provider types.Provider) (openai.Client, error) {
+	if m.store == nil || provider.IsBuiltin() {
+		return nil, fmt.Errorf("no client found for provider: %s", provider)
+	}
+
+	endpoint, err := m.store.GetProviderEndpointByName(ctx, string(provider))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("no client found for provider: %s", provider)
+		}
+		return nil, fmt.Errorf("failed to get provider endpoint '%s': %w", provider, err)
+	}
+
+	m.endpointClientsMu.Lock()
+	defer m.endpointClientsMu.Unlock()
+
+	if client, ok := m.endpointClients[provider]; ok && client.updated.Equal(endpoint.Updated) {
+		return client.client, nil
 	}
 
+	apiKey, err := m.getEndpointAPIKey(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("name", endpoint.Name).
+		Str("base_url", endpoint.BaseURL).
+		Msg("initializing provider endpoint client")
+
+	client := &providerClient{
+		client:  newEndpointClient(m.cfg, endpoint, apiKey, m.responseCache, m.logStores...),
+		updated: endpoint.Updated,
+	}
+
+	m.endpointClients[provider] = client
+
 	return client.client, nil
 }
+
+// getEndpointAPIKey resolves the API key from the endpoint owner's secrets
+func (m *MultiClientManager) getEndpointAPIKey(ctx context.Context, endpoint *types.ProviderEndpoint) (string, error) {
+	if endpoint.APIKeySecret == "" {
+		return endpoint.APIKey, nil
+	}
+
+	secrets, err := m.store.ListSecrets(ctx, &store.ListSecretsQuery{
+		Owner: endpoint.Owner,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list secrets for provider endpoint '%s': %w", endpoint.Name, err)
+	}
+
+	for _, secret := range secrets {
+		if secret.Name == endpoint.APIKeySecret {
+			return string(secret.Value), nil
+		}
+	}
+
+	return "", fmt.Errorf("secret '%s' for provider endpoint '%s' not found", endpoint.APIKeySecret, endpoint.Name)
+}
+
+// wrapClient wraps the provider client with the response cache, if enabled,
+// and the logging middleware which also logs the cache hits
+func wrapClient(cfg *config.ServerConfig, provider types.Provider, client openai.Client, responseCache *cache.Cache, logStores ...logger.LogStore) openai.Client {
+	if responseCache != nil {
+		client = cache.Wrap(responseCache, provider, client)
+	}
+
+	return logger.Wrap(cfg, provider, client, logStores...)
+}
+
+func newResponseCache(cfg *config.ServerConfig) (*cache.Cache, error) {
+	if !cfg.ResponseCache.Enabled {
+		return nil, nil
+	}
+
+	var embedder cache.Embedder
+
+	switch cfg.ResponseCache.EmbeddingsProvider {
+	case "":
+		// Only exact matches will be cached
+	case types.ProviderOpenAI:
+		embedder = openai.New(cfg.Providers.OpenAI.APIKey, cfg.Providers.OpenAI.BaseURL)
+	case types.ProviderTogetherAI:
+		embedder = openai.New(cfg.Providers.TogetherAI.APIKey, cfg.Providers.TogetherAI.BaseURL)
+	default:
+		return nil, fmt.Errorf("unsupported response cache embeddings provider '%s', must be openai or togetherai", cfg.ResponseCache.EmbeddingsProvider)
+	}
+
+	return cache.New(cfg.ResponseCache, embedder), nil
+}
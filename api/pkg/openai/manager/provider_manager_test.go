@@ -0,0 +1,184 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func newTestEndpointServer(t *testing.T, expectedAPIKey string) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+expectedAPIKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(&openai.ChatCompletionResponse{
+			Model: "allowed-model",
+		})
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestLoadEndpointsFile(t *testing.T) {
+	t.Setenv("TEST_VLLM_API_KEY", "secret-key")
+
+	path := filepath.Join(t.TempDir(), "endpoints.yaml")
+	err := os.WriteFile(path, []byte(`
+endpoints:
+  - name: vllm
+    base_url: http://vllm:8000/v1
+    api_key: ${TEST_VLLM_API_KEY}
+    models:
+      - model-a
+`), 0644)
+	require.NoError(t, err)
+
+	endpoints, err := LoadEndpointsFile(path)
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+
+	require.Equal(t, "vllm", endpoints[0].Name)
+	require.Equal(t, "secret-key", endpoints[0].APIKey)
+	require.Equal(t, []string{"model-a"}, endpoints[0].Models)
+	require.Equal(t, types.OwnerTypeSystem, endpoints[0].OwnerType)
+}
+
+func TestLoadEndpointsFile_ReservedName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "endpoints.yaml")
+	err := os.WriteFile(path, []byte(`
+endpoints:
+  - name: openai
+    base_url: http://localhost/v1
+`), 0644)
+	require.NoError(t, err)
+
+	_, err = LoadEndpointsFile(path)
+	require.Error(t, err)
+}
+
+func TestGetClient_ProviderEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := store.NewMockStore(ctrl)
+
+	ts := newTestEndpointServer(t, "endpoint-key")
+
+	endpoint := &types.ProviderEndpoint{
+		ID:           "pe_1",
+		Name:         "gateway",
+		Owner:        "user-1",
+		BaseURL:      ts.URL,
+		APIKeySecret: "gateway-key",
+		Models:       []string{"allowed-model"},
+		Updated:      time.Now(),
+	}
+
+	st.EXPECT().GetProviderEndpointByName(gomock.Any(), "gateway").Return(endpoint, nil).Times(2)
+	// Secret should only be resolved once as the client is cached
+	st.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{Owner: "user-1"}).Return([]*types.Secret{
+		{Name: "other", Value: []byte("wrong")},
+		{Name: "gateway-key", Value: []byte("endpoint-key")},
+	}, nil).Times(1)
+
+	m, err := NewProviderManager(&config.ServerConfig{}, st, oai.NewMockClient(ctrl))
+	require.NoError(t, err)
+
+	client, err := m.GetClient(context.Background(), &GetClientRequest{Provider: "gateway"})
+	require.NoError(t, err)
+
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model: "allowed-model",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "allowed-model", resp.Model)
+
+	_, err = client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model: "other-model",
+	})
+	require.Error(t, err)
+
+	cached, err := m.GetClient(context.Background(), &GetClientRequest{Provider: "gateway"})
+	require.NoError(t, err)
+	require.Equal(t, client, cached)
+}
+
+func TestGetClient_ProviderEndpointUpdated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := store.NewMockStore(ctrl)
+
+	first := &types.ProviderEndpoint{
+		Name:    "gateway",
+		BaseURL: "http://localhost:1/v1",
+		Updated: time.Now(),
+	}
+	second := &types.ProviderEndpoint{
+		Name:    "gateway",
+		BaseURL: "http://localhost:2/v1",
+		Updated: first.Updated.Add(time.Minute),
+	}
+
+	gomock.InOrder(
+		st.EXPECT().GetProviderEndpointByName(gomock.Any(), "gateway").Return(first, nil),
+		st.EXPECT().GetProviderEndpointByName(gomock.Any(), "gateway").Return(second, nil),
+	)
+
+	m, err := NewProviderManager(&config.ServerConfig{}, st, oai.NewMockClient(ctrl))
+	require.NoError(t, err)
+
+	client1, err := m.GetClient(context.Background(), &GetClientRequest{Provider: "gateway"})
+	require.NoError(t, err)
+
+	client2, err := m.GetClient(context.Background(), &GetClientRequest{Provider: "gateway"})
+	require.NoError(t, err)
+
+	require.NotSame(t, client1, client2)
+}
+
+func TestGetClient_UnknownProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := store.NewMockStore(ctrl)
+
+	st.EXPECT().GetProviderEndpointByName(gomock.Any(), "missing").Return(nil, store.ErrNotFound)
+
+	m, err := NewProviderManager(&config.ServerConfig{}, st, oai.NewMockClient(ctrl))
+	require.NoError(t, err)
+
+	_, err = m.GetClient(context.Background(), &GetClientRequest{Provider: "missing"})
+	require.Error(t, err)
+
+	// Builtin providers that are not configured never hit the database
+	_, err = m.GetClient(context.Background(), &GetClientRequest{Provider: types.ProviderOpenAI})
+	require.Error(t, err)
+}
+
+func TestListProviders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := store.NewMockStore(ctrl)
+
+	st.EXPECT().ListProviderEndpoints(gomock.Any(), gomock.Any()).Return([]*types.ProviderEndpoint{
+		{Name: "gateway"},
+	}, nil)
+
+	m, err := NewProviderManager(&config.ServerConfig{}, st, oai.NewMockClient(ctrl))
+	require.NoError(t, err)
+
+	providers, err := m.ListProviders(context.Background())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []types.Provider{types.ProviderHelix, "gateway"}, providers)
+}
@@ -0,0 +1,570 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+	"gopkg.in/yaml.v3"
+
+	"github.com/helixml/helix/api/pkg/model"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const (
+	defaultFailureThreshold = 3
+	defaultCooldown         = 30 * time.Second
+)
+
+// RouterConfig declares a routing provider. Requests sent to it are
+// dispatched to other providers serving the same logical model.
+type RouterConfig struct {
+	// Name is used as the provider name, for example in the app config
+	Name string `yaml:"name"`
+	// FailureThreshold is the number of consecutive 5xx/429 responses
+	// after which the provider is taken out of rotation
+	FailureThreshold int `yaml:"failure_threshold"`
+	// Cooldown is how long the provider is kept out of rotation before
+	// a single trial request is let through again
+	Cooldown time.Duration `yaml:"cooldown"`
+	Models   []RouterModel `yaml:"models"`
+}
+
+// RouterModel maps a logical model name to the providers serving it.
+// Targets with the lowest priority are tried first and are load balanced
+// using weighted round-robin. Higher priorities form the fallback chain.
+type RouterModel struct {
+	Name    string        `yaml:"name"`
+	Targets []RouteTarget `yaml:"targets"`
+}
+
+type RouteTarget struct {
+	Provider types.Provider `yaml:"provider"`
+	// Model is the model name on the target provider, defaults
+	// to the logical model name
+	Model    string `yaml:"model"`
+	Weight   int    `yaml:"weight"`
+	Priority int    `yaml:"priority"`
+}
+
+type routersFile struct {
+	Routers []*RouterConfig `yaml:"routers"`
+}
+
+// LoadRoutersFile reads routing providers from a YAML file, for example:
+//
+//	routers:
+//	  - name: llama-pool
+//	    failure_threshold: 3
+//	    cooldown: 30s
+//	    models:
+//	      - name: llama3-70b
+//	        targets:
+//	          - provider: togetherai
+//	            model: meta-llama/Llama-3-70b-chat-hf
+//	            weight: 3
+//	          - provider: vllm
+//	            weight: 1
+//	          - provider: openai
+//	            model: gpt-4o
+//	            priority: 1
+func LoadRoutersFile(path string) ([]*RouterConfig, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider routers file: %w", err)
+	}
+
+	var file routersFile
+	err = yaml.Unmarshal(bts, &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse provider routers file: %w", err)
+	}
+
+	for _, router := range file.Routers {
+		if router.Name == "" {
+			return nil, fmt.Errorf("router name is required")
+		}
+		if types.Provider(router.Name).IsBuiltin() {
+			return nil, fmt.Errorf("router name '%s' is reserved", router.Name)
+		}
+		if router.FailureThreshold <= 0 {
+			router.FailureThreshold = defaultFailureThreshold
+		}
+		if router.Cooldown <= 0 {
+			router.Cooldown = defaultCooldown
+		}
+
+		for idx := range router.Models {
+			m := &router.Models[idx]
+			if m.Name == "" {
+				return nil, fmt.Errorf("model name is required for router '%s'", router.Name)
+			}
+			if len(m.Targets) == 0 {
+				return nil, fmt.Errorf("no targets configured for model '%s' in router '%s'", m.Name, router.Name)
+			}
+			for tIdx := range m.Targets {
+				target := &m.Targets[tIdx]
+				if target.Provider == "" {
+					return nil, fmt.Errorf("target provider is required for model '%s' in router '%s'", m.Name, router.Name)
+				}
+				if target.Provider == types.Provider(router.Name) {
+					return nil, fmt.Errorf("router '%s' cannot route to itself", router.Name)
+				}
+				if target.Model == "" {
+					target.Model = m.Name
+				}
+				if target.Weight <= 0 {
+					target.Weight = 1
+				}
+			}
+		}
+	}
+
+	err = checkRouterCycles(file.Routers)
+	if err != nil {
+		return nil, err
+	}
+
+	return file.Routers, nil
+}
+
+// checkRouterCycles returns an error if the routers route to each other in a
+// loop, e.g. a -> b -> a, the requests would be routed forever
+func checkRouterCycles(routers []*RouterConfig) error {
+	targets := make(map[string][]string, len(routers))
+
+	for _, router := range routers {
+		if _, ok := targets[router.Name]; ok {
+			return fmt.Errorf("duplicate router name '%s'", router.Name)
+		}
+
+		targets[router.Name] = []string{}
+
+		for _, m := range router.Models {
+			for _, target := range m.Targets {
+				targets[router.Name] = append(targets[router.Name], string(target.Provider))
+			}
+		}
+	}
+
+	const (
+		visiting = iota + 1
+		done
+	)
+
+	state := make(map[string]int, len(routers))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		path = append(path, name)
+
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("routers cannot route to each other in a cycle: %s", strings.Join(path, " -> "))
+		case done:
+			return nil
+		}
+
+		state[name] = visiting
+
+		for _, target := range targets[name] {
+			// Only routers can lead back to a router
+			if _, ok := targets[target]; !ok {
+				continue
+			}
+
+			if err := visit(target, path); err != nil {
+				return err
+			}
+		}
+
+		state[name] = done
+
+		return nil
+	}
+
+	for _, router := range routers {
+		if err := visit(router.Name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var _ oai.Client = &RouterClient{}
+
+// RouterClient load balances and fails over between providers. Every
+// underlying provider client is already wrapped with the logging middleware
+// so the LLM call is recorded against the provider that served it.
+type RouterClient struct {
+	name     string
+	manager  ProviderManager
+	health   *healthTracker
+	models   map[string]*routedModel
+	modelIDs []string
+}
+
+func newRouterClient(cfg *RouterConfig, manager ProviderManager) *RouterClient {
+	models := make(map[string]*routedModel, len(cfg.Models))
+	modelIDs := make([]string, 0, len(cfg.Models))
+
+	for _, m := range cfg.Models {
+		models[m.Name] = newRoutedModel(m.Targets)
+		modelIDs = append(modelIDs, m.Name)
+	}
+
+	return &RouterClient{
+		name:     cfg.Name,
+		manager:  manager,
+		health:   newHealthTracker(cfg.FailureThreshold, cfg.Cooldown),
+		models:   models,
+		modelIDs: modelIDs,
+	}
+}
+
+func (c *RouterClient) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (resp openai.ChatCompletionResponse, err error) {
+	err = c.route(ctx, request.Model, func(client oai.Client, target *RouteTarget) error {
+		req := request
+		req.Model = target.Model
+
+		resp, err = client.CreateChatCompletion(oai.SetContextRouter(ctx, c.name), req)
+		return err
+	})
+	return resp, err
+}
+
+func (c *RouterClient) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (stream *openai.ChatCompletionStream, err error) {
+	// Failover is only possible before the stream has started, once the
+	// upstream accepted the request the stream is handed to the caller
+	err = c.route(ctx, request.Model, func(client oai.Client, target *RouteTarget) error {
+		req := request
+		req.Model = target.Model
+
+		stream, err = client.CreateChatCompletionStream(oai.SetContextRouter(ctx, c.name), req)
+		return err
+	})
+	return stream, err
+}
+
+func (c *RouterClient) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequestConverter) (resp openai.EmbeddingResponse, err error) {
+	embeddingReq := request.Convert()
+
+	err = c.route(ctx, string(embeddingReq.Model), func(client oai.Client, target *RouteTarget) error {
+		req := embeddingReq
+		req.Model = openai.EmbeddingModel(target.Model)
+
+		resp, err = client.CreateEmbeddings(oai.SetContextRouter(ctx, c.name), req)
+		return err
+	})
+	return resp, err
+}
+
+func (c *RouterClient) ListModels(_ context.Context) ([]model.OpenAIModel, error) {
+	models := make([]model.OpenAIModel, 0, len(c.modelIDs))
+	for _, id := range c.modelIDs {
+		models = append(models, model.OpenAIModel{
+			ID:      id,
+			Object:  "model",
+			OwnedBy: c.name,
+		})
+	}
+	return models, nil
+}
+
+func (c *RouterClient) route(ctx context.Context, modelName string, call func(client oai.Client, target *RouteTarget) error) error {
+	m, ok := c.models[modelName]
+	if !ok {
+		return fmt.Errorf("model '%s' is not routed by provider '%s'", modelName, c.name)
+	}
+
+	var lastErr error
+
+	for _, target := range m.candidates(c.health) {
+		client, err := c.manager.GetClient(ctx, &GetClientRequest{Provider: target.Provider})
+		if err != nil {
+			log.Warn().Err(err).
+				Str("router", c.name).
+				Str("provider", string(target.Provider)).
+				Msg("failed to get client for route target, trying next")
+			lastErr = err
+			continue
+		}
+
+		// Only one trial request goes to a provider recovering from failures
+		if !c.health.acquire(target.Provider) {
+			continue
+		}
+
+		err = call(client, target)
+		if err == nil {
+			c.health.recordSuccess(target.Provider)
+			return nil
+		}
+
+		if ctx.Err() != nil || !isRetriableError(err) {
+			c.health.release(target.Provider)
+			return err
+		}
+
+		c.health.recordFailure(target.Provider)
+
+		log.Warn().Err(err).
+			Str("router", c.name).
+			Str("provider", string(target.Provider)).
+			Str("model", target.Model).
+			Msg("route target failed, trying next")
+
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return fmt.Errorf("no healthy providers available for model '%s' in provider '%s'", modelName, c.name)
+	}
+
+	return fmt.Errorf("all providers failed for model '%s' in provider '%s': %w", modelName, c.name, lastErr)
+}
+
+// isRetriableError returns true for rate limits, server errors and errors
+// that never reached the provider (network, missing client). Other client
+// errors, such as bad requests, would fail the same way on any provider.
+// Canceled requests say nothing about the provider.
+func isRetriableError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode > 0 {
+		return isRetriableStatus(apiErr.HTTPStatusCode)
+	}
+
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode > 0 {
+		return isRetriableStatus(reqErr.HTTPStatusCode)
+	}
+
+	return true
+}
+
+func isRetriableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// routedModel holds the targets of a logical model grouped by priority
+type routedModel struct {
+	groups []*targetGroup
+}
+
+func newRoutedModel(targets []RouteTarget) *routedModel {
+	byPriority := make(map[int]*targetGroup)
+	for idx := range targets {
+		target := targets[idx]
+		group, ok := byPriority[target.Priority]
+		if !ok {
+			group = &targetGroup{priority: target.Priority}
+			byPriority[target.Priority] = group
+		}
+		group.targets = append(group.targets, &target)
+		group.current = append(group.current, 0)
+	}
+
+	groups := make([]*targetGroup, 0, len(byPriority))
+	for _, group := range byPriority {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].priority < groups[j].priority
+	})
+
+	return &routedModel{groups: groups}
+}
+
+// candidates returns the available targets in the order they should be tried
+func (m *routedModel) candidates(health *healthTracker) []*RouteTarget {
+	var result []*RouteTarget
+	for _, group := range m.groups {
+		result = append(result, group.order(health)...)
+	}
+	return result
+}
+
+// targetGroup load balances between targets of the same priority using
+// smooth weighted round-robin
+type targetGroup struct {
+	priority int
+	targets  []*RouteTarget
+
+	mu      sync.Mutex
+	current []int
+}
+
+// order picks the next target using weighted round-robin, the remaining
+// available targets follow ordered by weight so they can be used for failover
+func (g *targetGroup) order(health *healthTracker) []*RouteTarget {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var (
+		available []int
+		total     int
+		selected  = -1
+	)
+
+	for idx, target := range g.targets {
+		if !health.available(target.Provider) {
+			continue
+		}
+		available = append(available, idx)
+
+		total += target.Weight
+		g.current[idx] += target.Weight
+		if selected == -1 || g.current[idx] > g.current[selected] {
+			selected = idx
+		}
+	}
+
+	if selected == -1 {
+		return nil
+	}
+
+	g.current[selected] -= total
+
+	result := make([]*RouteTarget, 0, len(available))
+	result = append(result, g.targets[selected])
+
+	sort.SliceStable(available, func(i, j int) bool {
+		return g.targets[available[i]].Weight > g.targets[available[j]].Weight
+	})
+	for _, idx := range available {
+		if idx != selected {
+			result = append(result, g.targets[idx])
+		}
+	}
+
+	return result
+}
+
+// healthTracker is a simple circuit breaker per provider. After the failure
+// threshold is reached the provider is skipped until the cooldown passes,
+// then a single trial request is let through and either closes the circuit
+// again or restarts the cooldown. A trial that never reports back expires
+// after another cooldown.
+type healthTracker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	state map[types.Provider]*providerHealth
+}
+
+type providerHealth struct {
+	consecutiveFailures int
+	openedAt            time.Time
+	trialStarted        time.Time // Zero unless a trial request is in flight
+}
+
+func newHealthTracker(threshold int, cooldown time.Duration) *healthTracker {
+	return &healthTracker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     make(map[types.Provider]*providerHealth),
+	}
+}
+
+// available returns true if requests can be sent to the provider, without
+// taking the trial request of an open circuit
+func (h *healthTracker) available(provider types.Provider) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, ok := h.check(provider)
+	return ok
+}
+
+// acquire returns true if a request can be sent to the provider. When the
+// circuit is open the request becomes the trial request, other requests skip
+// the provider until the trial reports back.
+func (h *healthTracker) acquire(provider types.Provider) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state, ok := h.check(provider)
+	if ok && state != nil {
+		state.trialStarted = h.now()
+	}
+	return ok
+}
+
+// release gives the trial request back without a verdict on the provider
+func (h *healthTracker) release(provider types.Provider) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if state, ok := h.state[provider]; ok {
+		state.trialStarted = time.Time{}
+	}
+}
+
+// check returns whether the provider is available and, if its circuit is
+// open, its state. Must be called with the lock held.
+func (h *healthTracker) check(provider types.Provider) (*providerHealth, bool) {
+	state, ok := h.state[provider]
+	if !ok || state.consecutiveFailures < h.threshold {
+		return nil, true
+	}
+
+	now := h.now()
+	if now.Sub(state.openedAt) < h.cooldown {
+		return nil, false
+	}
+
+	if !state.trialStarted.IsZero() && now.Sub(state.trialStarted) < h.cooldown {
+		return nil, false
+	}
+
+	return state, true
+}
+
+func (h *healthTracker) recordSuccess(provider types.Provider) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.state, provider)
+}
+
+func (h *healthTracker) recordFailure(provider types.Provider) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state, ok := h.state[provider]
+	if !ok {
+		state = &providerHealth{}
+		h.state[provider] = state
+	}
+
+	state.consecutiveFailures++
+	if state.consecutiveFailures >= h.threshold {
+		if state.consecutiveFailures == h.threshold {
+			log.Warn().
+				Str("provider", string(provider)).
+				Int("failures", state.consecutiveFailures).
+				Msg("provider marked unhealthy")
+		}
+		// (Re)open the circuit, this also restarts the cooldown
+		// after a failed trial request
+		state.openedAt = h.now()
+		state.trialStarted = time.Time{}
+	}
+}
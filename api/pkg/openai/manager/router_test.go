@@ -0,0 +1,337 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	gomock "go.uber.org/mock/gomock"
+
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func TestRouterTestSuite(t *testing.T) {
+	suite.Run(t, new(RouterTestSuite))
+}
+
+type RouterTestSuite struct {
+	suite.Suite
+
+	ctx     context.Context
+	ctrl    *gomock.Controller
+	manager *MockProviderManager
+
+	primary   *oai.MockClient
+	secondary *oai.MockClient
+	fallback  *oai.MockClient
+}
+
+func (suite *RouterTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.manager = NewMockProviderManager(suite.ctrl)
+
+	suite.primary = oai.NewMockClient(suite.ctrl)
+	suite.secondary = oai.NewMockClient(suite.ctrl)
+	suite.fallback = oai.NewMockClient(suite.ctrl)
+
+	clients := map[types.Provider]oai.Client{
+		"primary":   suite.primary,
+		"secondary": suite.secondary,
+		"fallback":  suite.fallback,
+	}
+
+	suite.manager.EXPECT().GetClient(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *GetClientRequest) (oai.Client, error) {
+			return clients[req.Provider], nil
+		}).AnyTimes()
+}
+
+func (suite *RouterTestSuite) newRouter() *RouterClient {
+	return newRouterClient(&RouterConfig{
+		Name:             "pool",
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+		Models: []RouterModel{
+			{
+				Name: "llama3",
+				Targets: []RouteTarget{
+					{Provider: "primary", Model: "llama3-primary", Weight: 2},
+					{Provider: "secondary", Model: "llama3-secondary", Weight: 1},
+					{Provider: "fallback", Model: "gpt-4o", Weight: 1, Priority: 1},
+				},
+			},
+		},
+	}, suite.manager)
+}
+
+func (suite *RouterTestSuite) expectCall(client *oai.MockClient, model string, err error) *gomock.Call {
+	return client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+			suite.Equal(model, req.Model)
+
+			router, ok := oai.GetContextRouter(ctx)
+			suite.True(ok)
+			suite.Equal("pool", router)
+
+			if err != nil {
+				return openai.ChatCompletionResponse{}, err
+			}
+			return openai.ChatCompletionResponse{Model: req.Model}, nil
+		})
+}
+
+func (suite *RouterTestSuite) Test_WeightedRoundRobin() {
+	router := suite.newRouter()
+
+	suite.expectCall(suite.primary, "llama3-primary", nil).Times(4)
+	suite.expectCall(suite.secondary, "llama3-secondary", nil).Times(2)
+
+	for i := 0; i < 6; i++ {
+		_, err := router.CreateChatCompletion(suite.ctx, openai.ChatCompletionRequest{Model: "llama3"})
+		suite.Require().NoError(err)
+	}
+}
+
+func (suite *RouterTestSuite) Test_FailoverOnRateLimit() {
+	router := suite.newRouter()
+
+	rateLimited := &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}
+
+	gomock.InOrder(
+		suite.expectCall(suite.primary, "llama3-primary", rateLimited),
+		suite.expectCall(suite.secondary, "llama3-secondary", nil),
+	)
+
+	resp, err := router.CreateChatCompletion(suite.ctx, openai.ChatCompletionRequest{Model: "llama3"})
+	suite.Require().NoError(err)
+	suite.Equal("llama3-secondary", resp.Model)
+}
+
+func (suite *RouterTestSuite) Test_FallbackChain() {
+	router := suite.newRouter()
+
+	serverErr := &openai.RequestError{HTTPStatusCode: http.StatusBadGateway}
+
+	gomock.InOrder(
+		suite.expectCall(suite.primary, "llama3-primary", serverErr),
+		suite.expectCall(suite.secondary, "llama3-secondary", serverErr),
+		suite.expectCall(suite.fallback, "gpt-4o", nil),
+	)
+
+	resp, err := router.CreateChatCompletion(suite.ctx, openai.ChatCompletionRequest{Model: "llama3"})
+	suite.Require().NoError(err)
+	suite.Equal("gpt-4o", resp.Model)
+}
+
+func (suite *RouterTestSuite) Test_NoFailoverOnBadRequest() {
+	router := suite.newRouter()
+
+	badRequest := &openai.APIError{HTTPStatusCode: http.StatusBadRequest}
+
+	suite.expectCall(suite.primary, "llama3-primary", badRequest)
+
+	_, err := router.CreateChatCompletion(suite.ctx, openai.ChatCompletionRequest{Model: "llama3"})
+	suite.Require().ErrorIs(err, badRequest)
+}
+
+func (suite *RouterTestSuite) Test_CircuitBreaker() {
+	router := suite.newRouter()
+
+	now := time.Now()
+	router.health.now = func() time.Time { return now }
+
+	serverErr := &openai.APIError{HTTPStatusCode: http.StatusInternalServerError}
+
+	// Two consecutive failures open the circuit for the primary provider
+	suite.expectCall(suite.primary, "llama3-primary", serverErr).Times(2)
+	suite.expectCall(suite.secondary, "llama3-secondary", nil).Times(5)
+
+	for i := 0; i < 5; i++ {
+		_, err := router.CreateChatCompletion(suite.ctx, openai.ChatCompletionRequest{Model: "llama3"})
+		suite.Require().NoError(err)
+	}
+
+	suite.False(router.health.available("primary"))
+
+	// After the cooldown a trial request is let through again
+	now = now.Add(2 * time.Minute)
+	suite.True(router.health.available("primary"))
+
+	suite.expectCall(suite.primary, "llama3-primary", nil)
+
+	resp, err := router.CreateChatCompletion(suite.ctx, openai.ChatCompletionRequest{Model: "llama3"})
+	suite.Require().NoError(err)
+	suite.Equal("llama3-primary", resp.Model)
+	suite.True(router.health.available("primary"))
+}
+
+func (suite *RouterTestSuite) Test_CircuitBreaker_SingleTrial() {
+	router := suite.newRouter()
+
+	now := time.Now()
+	router.health.now = func() time.Time { return now }
+
+	router.health.recordFailure("primary")
+	router.health.recordFailure("primary")
+	suite.False(router.health.acquire("primary"))
+
+	// Only one trial request is let through after the cooldown
+	now = now.Add(2 * time.Minute)
+	suite.True(router.health.acquire("primary"))
+	suite.False(router.health.acquire("primary"))
+	suite.False(router.health.available("primary"))
+
+	// A failed trial restarts the cooldown
+	router.health.recordFailure("primary")
+	suite.False(router.health.acquire("primary"))
+
+	now = now.Add(2 * time.Minute)
+	suite.True(router.health.acquire("primary"))
+
+	// A trial that never reports back expires
+	now = now.Add(2 * time.Minute)
+	suite.True(router.health.acquire("primary"))
+
+	router.health.recordSuccess("primary")
+	suite.True(router.health.acquire("primary"))
+	suite.True(router.health.acquire("primary"))
+}
+
+func (suite *RouterTestSuite) Test_NoFailoverOnCanceled() {
+	router := suite.newRouter()
+
+	suite.expectCall(suite.primary, "llama3-primary", context.Canceled)
+
+	_, err := router.CreateChatCompletion(suite.ctx, openai.ChatCompletionRequest{Model: "llama3"})
+	suite.Require().ErrorIs(err, context.Canceled)
+	suite.True(router.health.available("primary"))
+}
+
+func (suite *RouterTestSuite) Test_Stream_Failover() {
+	router := suite.newRouter()
+
+	rateLimited := &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}
+
+	gomock.InOrder(
+		suite.primary.EXPECT().CreateChatCompletionStream(gomock.Any(), gomock.Any()).Return(nil, rateLimited),
+		suite.secondary.EXPECT().CreateChatCompletionStream(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+				suite.Equal("llama3-secondary", req.Model)
+				return &openai.ChatCompletionStream{}, nil
+			}),
+	)
+
+	stream, err := router.CreateChatCompletionStream(suite.ctx, openai.ChatCompletionRequest{Model: "llama3"})
+	suite.Require().NoError(err)
+	suite.NotNil(stream)
+}
+
+func (suite *RouterTestSuite) Test_UnknownModel() {
+	router := suite.newRouter()
+
+	_, err := router.CreateChatCompletion(suite.ctx, openai.ChatCompletionRequest{Model: "unknown"})
+	suite.Require().Error(err)
+}
+
+func (suite *RouterTestSuite) Test_ListModels() {
+	router := suite.newRouter()
+
+	models, err := router.ListModels(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Require().Len(models, 1)
+	suite.Equal("llama3", models[0].ID)
+}
+
+func TestLoadRoutersFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routers.yaml")
+	err := os.WriteFile(path, []byte(`
+routers:
+  - name: pool
+    cooldown: 10s
+    models:
+      - name: llama3
+        targets:
+          - provider: togetherai
+            model: meta-llama/Llama-3-70b-chat-hf
+            weight: 3
+          - provider: vllm
+`), 0644)
+	require.NoError(t, err)
+
+	routers, err := LoadRoutersFile(path)
+	require.NoError(t, err)
+	require.Len(t, routers, 1)
+
+	router := routers[0]
+	require.Equal(t, defaultFailureThreshold, router.FailureThreshold)
+	require.Equal(t, 10*time.Second, router.Cooldown)
+
+	targets := router.Models[0].Targets
+	require.Equal(t, 3, targets[0].Weight)
+	require.Equal(t, "llama3", targets[1].Model)
+	require.Equal(t, 1, targets[1].Weight)
+}
+
+func TestLoadRoutersFile_Cycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routers.yaml")
+	err := os.WriteFile(path, []byte(`
+routers:
+  - name: entry
+    models:
+      - name: llama3
+        targets:
+          - provider: pool-a
+  - name: pool-a
+    models:
+      - name: llama3
+        targets:
+          - provider: togetherai
+          - provider: pool-b
+            priority: 1
+  - name: pool-b
+    models:
+      - name: llama3
+        targets:
+          - provider: pool-a
+`), 0644)
+	require.NoError(t, err)
+
+	_, err = LoadRoutersFile(path)
+	require.ErrorContains(t, err, "entry -> pool-a -> pool-b -> pool-a")
+}
+
+func TestLoadRoutersFile_Chain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routers.yaml")
+	err := os.WriteFile(path, []byte(`
+routers:
+  - name: pool-a
+    models:
+      - name: llama3
+        targets:
+          - provider: pool-b
+          - provider: pool-c
+  - name: pool-b
+    models:
+      - name: llama3
+        targets:
+          - provider: pool-c
+  - name: pool-c
+    models:
+      - name: llama3
+        targets:
+          - provider: togetherai
+`), 0644)
+	require.NoError(t, err)
+
+	routers, err := LoadRoutersFile(path)
+	require.NoError(t, err)
+	require.Len(t, routers, 3)
+}
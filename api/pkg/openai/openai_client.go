@@ -26,6 +26,7 @@ const (
 type Client interface {
 	CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
 	CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error)
+	CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
 
 	ListModels(ctx context.Context) ([]model.OpenAIModel, error)
 }
@@ -78,6 +79,10 @@ func (c *RetryableClient) CreateChatCompletionStream(ctx context.Context, reques
 	return c.apiClient.CreateChatCompletionStream(ctx, request)
 }
 
+func (c *RetryableClient) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return c.apiClient.CreateEmbeddings(ctx, request)
+}
+
 // TODO: just use OpenAI client's ListModels function and separate this from TogetherAI
 func (c *RetryableClient) ListModels(ctx context.Context) ([]model.OpenAIModel, error) {
 	url := c.baseURL + "/models"
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// NewOpenAIStreamFromResponse streams a complete response as SSE chunks in the
+// same shape as the providers send them, used when a response was not produced
+// by a streaming call (e.g. served from the cache or after running tools)
+func NewOpenAIStreamFromResponse(req openai.ChatCompletionRequest, resp *openai.ChatCompletionResponse) (*openai.ChatCompletionStream, error) {
+	stream, writer, err := NewOpenAIStreamingAdapter(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create streaming adapter: %w", err)
+	}
+
+	go func() {
+		defer writer.Close()
+
+		for _, chunk := range ResponseChunks(resp) {
+			err := WriteChatCompletionStream(writer, chunk)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to stream response")
+				return
+			}
+		}
+	}()
+
+	return stream, nil
+}
+
+// ResponseChunks splits the first choice of the response into stream chunks
+func ResponseChunks(resp *openai.ChatCompletionResponse) []*openai.ChatCompletionStreamResponse {
+	newChunk := func(delta openai.ChatCompletionStreamChoiceDelta, finishReason openai.FinishReason) *openai.ChatCompletionStreamResponse {
+		return &openai.ChatCompletionStreamResponse{
+			ID:      resp.ID,
+			Object:  "chat.completion.chunk",
+			Created: resp.Created,
+			Model:   resp.Model,
+			Choices: []openai.ChatCompletionStreamChoice{
+				{
+					Index:        0,
+					Delta:        delta,
+					FinishReason: finishReason,
+				},
+			},
+		}
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil
+	}
+
+	message := resp.Choices[0].Message
+
+	chunks := []*openai.ChatCompletionStreamResponse{
+		newChunk(openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}, ""),
+	}
+
+	// Split the content on word boundaries like a model would stream it
+	for _, word := range strings.SplitAfter(message.Content, " ") {
+		if word == "" {
+			continue
+		}
+		chunks = append(chunks, newChunk(openai.ChatCompletionStreamChoiceDelta{Content: word}, ""))
+	}
+
+	if message.FunctionCall != nil || len(message.ToolCalls) > 0 {
+		toolCalls := make([]openai.ToolCall, len(message.ToolCalls))
+		for i := range message.ToolCalls {
+			toolCalls[i] = message.ToolCalls[i]
+			// Stream deltas carry the index of the call they belong to
+			index := i
+			toolCalls[i].Index = &index
+		}
+
+		chunks = append(chunks, newChunk(openai.ChatCompletionStreamChoiceDelta{
+			FunctionCall: message.FunctionCall,
+			ToolCalls:    toolCalls,
+		}, ""))
+	}
+
+	finishReason := resp.Choices[0].FinishReason
+	if finishReason == "" {
+		finishReason = openai.FinishReasonStop
+	}
+
+	chunks = append(chunks, newChunk(openai.ChatCompletionStreamChoiceDelta{}, finishReason))
+
+	return chunks
+}
@@ -0,0 +1,399 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// Request identifies the caller of the OpenAI compatible API, every
+// non-empty field is a scope with its own limits
+type Request struct {
+	UserID string
+	APIKey string
+	AppID  string
+}
+
+// ExceededError is returned when a request is over one of its quotas
+type ExceededError struct {
+	Scope types.QuotaScope
+	Limit types.QuotaLimit
+	Max   int64
+	// RetryAfter is how long until the request would be allowed again
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	switch e.Limit {
+	case types.QuotaLimitRequestsPerMinute:
+		return fmt.Sprintf("rate limit reached for %s on requests per minute: limit %d, please try again in %s",
+			e.Scope, e.Max, e.RetryAfter.Round(time.Second))
+	default:
+		return fmt.Sprintf("%s quota exceeded for %s: limit %d tokens", e.Limit, e.Scope, e.Max)
+	}
+}
+
+// RateLimited returns true if the request was rejected because of the
+// request rate rather than the token budget
+func (e *ExceededError) RateLimited() bool {
+	return e.Limit == types.QuotaLimitRequestsPerMinute
+}
+
+// Manager enforces the token budgets and request rate limits. Token
+// consumption is calculated from the logged LLM calls, request rates are
+// tracked in memory per API server.
+type Manager struct {
+	cfg   *config.ServerConfig
+	store store.Store
+	now   func() time.Time
+
+	limitersMu    sync.Mutex
+	limiters      map[string]*limiterEntry
+	limitersSwept time.Time
+}
+
+// limiterEntry is the rate limiter of a scope and when it was last used
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// Limiters are refilled at the latest a minute after their last use, so
+// idle limiters can be dropped and recreated full when they are used again
+const (
+	limiterIdleTimeout   = time.Minute
+	limiterSweepInterval = time.Minute
+)
+
+func NewManager(cfg *config.ServerConfig, store store.Store) *Manager {
+	return &Manager{
+		cfg:      cfg,
+		store:    store,
+		now:      time.Now,
+		limiters: make(map[string]*limiterEntry),
+	}
+}
+
+type subject struct {
+	scope   types.QuotaScope
+	id      string
+	limits  types.UsageLimits
+	usageQ  store.SumLLMCallTokensQuery
+	limiter string
+}
+
+type budget struct {
+	limit    types.QuotaLimit
+	max      int64
+	since    time.Time
+	resetsAt time.Time
+}
+
+// Check returns an *ExceededError if the request is over any of its quotas,
+// otherwise the request is counted against the rate limits
+func (m *Manager) Check(ctx context.Context, req *Request) error {
+	if !m.cfg.UsageQuotas.Enabled {
+		return nil
+	}
+
+	subjects, err := m.subjects(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	now := m.now()
+
+	for _, s := range subjects {
+		for _, b := range budgets(s.limits, now) {
+			used, err := m.used(ctx, s, b)
+			if err != nil {
+				return err
+			}
+
+			if used >= b.max {
+				return &ExceededError{
+					Scope:      s.scope,
+					Limit:      b.limit,
+					Max:        b.max,
+					RetryAfter: b.resetsAt.Sub(now),
+				}
+			}
+		}
+	}
+
+	var reservations []*rate.Reservation
+
+	for _, s := range subjects {
+		if s.limits.RequestsPerMinute <= 0 {
+			continue
+		}
+
+		r := m.getLimiter(s.limiter, s.limits.RequestsPerMinute).ReserveN(now, 1)
+		if delay := r.DelayFrom(now); delay > 0 {
+			// Give back the requests counted against the other scopes
+			r.CancelAt(now)
+			for _, reservation := range reservations {
+				reservation.CancelAt(now)
+			}
+
+			return &ExceededError{
+				Scope:      s.scope,
+				Limit:      types.QuotaLimitRequestsPerMinute,
+				Max:        s.limits.RequestsPerMinute,
+				RetryAfter: delay,
+			}
+		}
+
+		reservations = append(reservations, r)
+	}
+
+	return nil
+}
+
+// Status returns the current consumption against every configured limit
+func (m *Manager) Status(ctx context.Context, req *Request) ([]*types.QuotaStatus, error) {
+	statuses := []*types.QuotaStatus{}
+
+	if !m.cfg.UsageQuotas.Enabled {
+		return statuses, nil
+	}
+
+	subjects, err := m.subjects(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+
+	for _, s := range subjects {
+		for _, b := range budgets(s.limits, now) {
+			used, err := m.used(ctx, s, b)
+			if err != nil {
+				return nil, err
+			}
+
+			statuses = append(statuses, &types.QuotaStatus{
+				Scope:     s.scope,
+				ScopeID:   s.id,
+				Limit:     b.limit,
+				Max:       b.max,
+				Used:      used,
+				Remaining: max(b.max-used, 0),
+				ResetsAt:  b.resetsAt,
+			})
+		}
+
+		if s.limits.RequestsPerMinute > 0 {
+			limiter := m.getLimiter(s.limiter, s.limits.RequestsPerMinute)
+
+			tokens := math.Max(limiter.TokensAt(now), 0)
+			used := s.limits.RequestsPerMinute - int64(math.Floor(tokens))
+			refill := time.Duration((float64(s.limits.RequestsPerMinute) - tokens) / float64(limiter.Limit()) * float64(time.Second))
+
+			statuses = append(statuses, &types.QuotaStatus{
+				Scope:     s.scope,
+				ScopeID:   s.id,
+				Limit:     types.QuotaLimitRequestsPerMinute,
+				Max:       s.limits.RequestsPerMinute,
+				Used:      used,
+				Remaining: s.limits.RequestsPerMinute - used,
+				ResetsAt:  now.Add(refill),
+			})
+		}
+	}
+
+	return statuses, nil
+}
+
+func (m *Manager) subjects(ctx context.Context, req *Request) ([]*subject, error) {
+	var subjects []*subject
+
+	if req.UserID != "" {
+		limits := m.cfg.UsageQuotas.User
+		subjects = append(subjects, &subject{
+			scope: types.QuotaScopeUser,
+			id:    req.UserID,
+			limits: types.UsageLimits{
+				DailyTokens:       limits.DailyTokens,
+				MonthlyTokens:     limits.MonthlyTokens,
+				RequestsPerMinute: limits.RequestsPerMinute,
+			},
+			usageQ:  store.SumLLMCallTokensQuery{UserID: req.UserID},
+			limiter: "user:" + req.UserID,
+		})
+	}
+
+	if req.APIKey != "" {
+		limits := m.cfg.UsageQuotas.APIKey
+		subjects = append(subjects, &subject{
+			scope: types.QuotaScopeAPIKey,
+			id:    maskAPIKey(req.APIKey),
+			limits: types.UsageLimits{
+				DailyTokens:       limits.DailyTokens,
+				MonthlyTokens:     limits.MonthlyTokens,
+				RequestsPerMinute: limits.RequestsPerMinute,
+			},
+			usageQ:  store.SumLLMCallTokensQuery{APIKey: types.HashAPIKey(req.APIKey)},
+			limiter: "api_key:" + req.APIKey,
+		})
+	}
+
+	if req.AppID != "" {
+		limits, err := m.appLimits(ctx, req.AppID)
+		if err != nil {
+			return nil, err
+		}
+
+		subjects = append(subjects, &subject{
+			scope:   types.QuotaScopeApp,
+			id:      req.AppID,
+			limits:  limits,
+			usageQ:  store.SumLLMCallTokensQuery{AppID: req.AppID},
+			limiter: "app:" + req.AppID,
+		})
+	}
+
+	return subjects, nil
+}
+
+// appLimits returns the limits from the app config if set, otherwise the
+// default app limits. Apps can only lower the default limits.
+func (m *Manager) appLimits(ctx context.Context, appID string) (types.UsageLimits, error) {
+	limits := types.UsageLimits{
+		DailyTokens:       m.cfg.UsageQuotas.App.DailyTokens,
+		MonthlyTokens:     m.cfg.UsageQuotas.App.MonthlyTokens,
+		RequestsPerMinute: m.cfg.UsageQuotas.App.RequestsPerMinute,
+	}
+
+	app, err := m.store.GetApp(ctx, appID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return limits, nil
+		}
+		return limits, fmt.Errorf("failed to get app '%s': %w", appID, err)
+	}
+
+	if app.Config.Helix.Limits != nil {
+		override := app.Config.Helix.Limits
+
+		limits.DailyTokens = lowerLimit(limits.DailyTokens, override.DailyTokens)
+		limits.MonthlyTokens = lowerLimit(limits.MonthlyTokens, override.MonthlyTokens)
+		limits.RequestsPerMinute = lowerLimit(limits.RequestsPerMinute, override.RequestsPerMinute)
+	}
+
+	return limits, nil
+}
+
+// lowerLimit returns the override if it's within the limit, 0 is unlimited
+func lowerLimit(limit, override int64) int64 {
+	if override <= 0 {
+		return limit
+	}
+	if limit <= 0 || override < limit {
+		return override
+	}
+	return limit
+}
+
+func (m *Manager) used(ctx context.Context, s *subject, b *budget) (int64, error) {
+	q := s.usageQ
+	q.Since = b.since
+
+	used, err := m.store.SumLLMCallTokens(ctx, &q)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s token usage: %w", s.scope, err)
+	}
+
+	return used, nil
+}
+
+func (m *Manager) getLimiter(key string, requestsPerMinute int64) *rate.Limiter {
+	m.limitersMu.Lock()
+	defer m.limitersMu.Unlock()
+
+	now := m.now()
+	limit := rate.Limit(float64(requestsPerMinute) / 60)
+
+	m.sweepLimiters(now)
+
+	entry, ok := m.limiters[key]
+	if !ok {
+		entry = &limiterEntry{
+			limiter: rate.NewLimiter(limit, int(requestsPerMinute)),
+		}
+		m.limiters[key] = entry
+	}
+
+	entry.lastUsed = now
+
+	// Limits can change when the app config is updated
+	if entry.limiter.Limit() != limit {
+		entry.limiter.SetLimitAt(now, limit)
+		entry.limiter.SetBurstAt(now, int(requestsPerMinute))
+	}
+
+	return entry.limiter
+}
+
+// sweepLimiters drops the limiters that weren't used for a while, at most
+// once per sweep interval. The caller holds limitersMu.
+func (m *Manager) sweepLimiters(now time.Time) {
+	if now.Sub(m.limitersSwept) < limiterSweepInterval {
+		return
+	}
+
+	m.limitersSwept = now
+
+	for key, entry := range m.limiters {
+		if now.Sub(entry.lastUsed) >= limiterIdleTimeout {
+			delete(m.limiters, key)
+		}
+	}
+}
+
+// budgets returns the token budgets for the current day and month (UTC)
+func budgets(limits types.UsageLimits, now time.Time) []*budget {
+	var result []*budget
+
+	now = now.UTC()
+
+	if limits.DailyTokens > 0 {
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		result = append(result, &budget{
+			limit:    types.QuotaLimitDailyTokens,
+			max:      limits.DailyTokens,
+			since:    start,
+			resetsAt: start.AddDate(0, 0, 1),
+		})
+	}
+
+	if limits.MonthlyTokens > 0 {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		result = append(result, &budget{
+			limit:    types.QuotaLimitMonthlyTokens,
+			max:      limits.MonthlyTokens,
+			since:    start,
+			resetsAt: start.AddDate(0, 1, 0),
+		})
+	}
+
+	return result
+}
+
+// maskAPIKey hides all but the last characters of the key so it can be
+// returned in the quota status
+func maskAPIKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
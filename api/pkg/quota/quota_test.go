@@ -0,0 +1,215 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func TestQuotaTestSuite(t *testing.T) {
+	suite.Run(t, new(QuotaTestSuite))
+}
+
+type QuotaTestSuite struct {
+	suite.Suite
+
+	ctx   context.Context
+	store *store.MockStore
+	cfg   *config.ServerConfig
+	now   time.Time
+
+	manager *Manager
+}
+
+func (suite *QuotaTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.store = store.NewMockStore(gomock.NewController(suite.T()))
+
+	suite.cfg = &config.ServerConfig{}
+	suite.cfg.UsageQuotas.Enabled = true
+
+	suite.now = time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	suite.manager = NewManager(suite.cfg, suite.store)
+	suite.manager.now = func() time.Time { return suite.now }
+}
+
+func (suite *QuotaTestSuite) Test_Disabled() {
+	suite.cfg.UsageQuotas.Enabled = false
+	suite.cfg.UsageQuotas.User.DailyTokens = 100
+
+	err := suite.manager.Check(suite.ctx, &Request{UserID: "user-1"})
+	suite.NoError(err)
+}
+
+func (suite *QuotaTestSuite) Test_DailyTokensExceeded() {
+	suite.cfg.UsageQuotas.User.DailyTokens = 1000
+
+	suite.store.EXPECT().SumLLMCallTokens(gomock.Any(), &store.SumLLMCallTokensQuery{
+		UserID: "user-1",
+		Since:  time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC),
+	}).Return(int64(1000), nil)
+
+	err := suite.manager.Check(suite.ctx, &Request{UserID: "user-1"})
+
+	var exceededErr *ExceededError
+	suite.Require().ErrorAs(err, &exceededErr)
+	suite.Equal(types.QuotaScopeUser, exceededErr.Scope)
+	suite.Equal(types.QuotaLimitDailyTokens, exceededErr.Limit)
+	suite.False(exceededErr.RateLimited())
+	// Resets at midnight UTC
+	suite.Equal(13*time.Hour+30*time.Minute, exceededErr.RetryAfter)
+}
+
+func (suite *QuotaTestSuite) Test_MonthlyTokensAPIKey() {
+	suite.cfg.UsageQuotas.APIKey.MonthlyTokens = 5000
+
+	suite.store.EXPECT().SumLLMCallTokens(gomock.Any(), &store.SumLLMCallTokensQuery{
+		APIKey: types.HashAPIKey("hl-key"),
+		Since:  time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+	}).Return(int64(4999), nil)
+
+	err := suite.manager.Check(suite.ctx, &Request{UserID: "user-1", APIKey: "hl-key"})
+	suite.NoError(err)
+}
+
+func (suite *QuotaTestSuite) Test_RequestsPerMinute() {
+	suite.cfg.UsageQuotas.User.RequestsPerMinute = 2
+
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-1"}))
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-1"}))
+
+	err := suite.manager.Check(suite.ctx, &Request{UserID: "user-1"})
+
+	var exceededErr *ExceededError
+	suite.Require().ErrorAs(err, &exceededErr)
+	suite.True(exceededErr.RateLimited())
+	suite.Equal(30*time.Second, exceededErr.RetryAfter)
+
+	// Other users are not affected
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-2"}))
+
+	// A request is allowed again once the limiter has refilled
+	suite.now = suite.now.Add(30 * time.Second)
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-1"}))
+}
+
+func (suite *QuotaTestSuite) Test_RequestsPerMinute_IdleLimitersEvicted() {
+	suite.cfg.UsageQuotas.User.RequestsPerMinute = 2
+
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-1"}))
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-2"}))
+	suite.Len(suite.manager.limiters, 2)
+
+	suite.now = suite.now.Add(limiterIdleTimeout / 2)
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-2"}))
+
+	// user-1 is idle and dropped on the next sweep, user-2 was used since
+	suite.now = suite.now.Add(limiterIdleTimeout / 2)
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-3"}))
+	suite.Len(suite.manager.limiters, 2)
+	suite.Contains(suite.manager.limiters, "user:user-2")
+	suite.Contains(suite.manager.limiters, "user:user-3")
+	suite.NotContains(suite.manager.limiters, "user:user-1")
+
+	// Recreated full
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-1"}))
+	suite.NoError(suite.manager.Check(suite.ctx, &Request{UserID: "user-1"}))
+}
+
+func (suite *QuotaTestSuite) Test_RequestsPerMinute_RejectedRequestNotCounted() {
+	suite.cfg.UsageQuotas.User.RequestsPerMinute = 10
+	suite.cfg.UsageQuotas.APIKey.RequestsPerMinute = 1
+
+	req := &Request{UserID: "user-1", APIKey: "hl-key"}
+
+	suite.NoError(suite.manager.Check(suite.ctx, req))
+	suite.Error(suite.manager.Check(suite.ctx, req))
+
+	statuses, err := suite.manager.Status(suite.ctx, req)
+	suite.Require().NoError(err)
+	suite.Require().Len(statuses, 2)
+
+	suite.Equal(types.QuotaScopeUser, statuses[0].Scope)
+	suite.Equal(int64(1), statuses[0].Used)
+	suite.Equal(int64(9), statuses[0].Remaining)
+
+	suite.Equal(types.QuotaScopeAPIKey, statuses[1].Scope)
+	suite.Equal("****-key", statuses[1].ScopeID)
+	suite.Equal(int64(1), statuses[1].Used)
+}
+
+func (suite *QuotaTestSuite) Test_AppLimitsOverride() {
+	suite.cfg.UsageQuotas.App.DailyTokens = 1000
+
+	suite.store.EXPECT().GetApp(gomock.Any(), "app-1").Return(&types.App{
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Limits: &types.UsageLimits{DailyTokens: 100, MonthlyTokens: 5000},
+			},
+		},
+	}, nil)
+
+	suite.store.EXPECT().SumLLMCallTokens(gomock.Any(), &store.SumLLMCallTokensQuery{
+		AppID: "app-1",
+		Since: time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC),
+	}).Return(int64(500), nil)
+
+	err := suite.manager.Check(suite.ctx, &Request{AppID: "app-1"})
+
+	var exceededErr *ExceededError
+	suite.Require().ErrorAs(err, &exceededErr)
+	suite.Equal(types.QuotaScopeApp, exceededErr.Scope)
+	suite.Equal(int64(100), exceededErr.Max)
+}
+
+func (suite *QuotaTestSuite) Test_AppLimitsOverride_CantRaiseLimits() {
+	suite.cfg.UsageQuotas.App.DailyTokens = 100
+
+	// Unlimited for the app, still limited by the default app limits
+	suite.store.EXPECT().GetApp(gomock.Any(), "app-1").Return(&types.App{
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Limits: &types.UsageLimits{},
+			},
+		},
+	}, nil).Times(2)
+
+	suite.store.EXPECT().SumLLMCallTokens(gomock.Any(), gomock.Any()).Return(int64(500), nil)
+
+	err := suite.manager.Check(suite.ctx, &Request{AppID: "app-1"})
+
+	var exceededErr *ExceededError
+	suite.Require().ErrorAs(err, &exceededErr)
+	suite.Equal(int64(100), exceededErr.Max)
+
+	limits, err := suite.manager.appLimits(suite.ctx, "app-1")
+	suite.Require().NoError(err)
+	suite.Equal(types.UsageLimits{DailyTokens: 100}, limits)
+}
+
+func (suite *QuotaTestSuite) Test_Status() {
+	suite.cfg.UsageQuotas.User.DailyTokens = 1000
+	suite.cfg.UsageQuotas.User.MonthlyTokens = 20000
+
+	suite.store.EXPECT().SumLLMCallTokens(gomock.Any(), gomock.Any()).Return(int64(1200), nil).Times(2)
+
+	statuses, err := suite.manager.Status(suite.ctx, &Request{UserID: "user-1"})
+	suite.Require().NoError(err)
+	suite.Require().Len(statuses, 2)
+
+	suite.Equal(types.QuotaLimitDailyTokens, statuses[0].Limit)
+	suite.Equal(int64(0), statuses[0].Remaining)
+	suite.Equal(time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC), statuses[0].ResetsAt)
+
+	suite.Equal(types.QuotaLimitMonthlyTokens, statuses[1].Limit)
+	suite.Equal(int64(18800), statuses[1].Remaining)
+	suite.Equal(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), statuses[1].ResetsAt)
+}
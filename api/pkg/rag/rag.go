@@ -13,3 +13,29 @@ type RAG interface {
 	Query(ctx context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error)
 	Delete(ctx context.Context, req *types.DeleteIndexRequest) error
 }
+
+// Copier is implemented by RAG backends that can copy indexed documents to
+// another data entity without embedding them again. Knowledge refreshes use
+// it to carry unchanged documents over to the new version.
+type Copier interface {
+	Copy(ctx context.Context, req *types.CopyIndexRequest) error
+}
+
+// Exporter is implemented by RAG backends that can list the indexed chunks
+// of a data entity. Knowledge exports use it to move the knowledge to
+// another Helix instance without fetching the sources again.
+type Exporter interface {
+	Export(ctx context.Context, req *types.ExportIndexRequest) ([]*types.SessionRAGIndexChunk, error)
+	// EmbeddingsModel is the model of the exported embeddings, imported
+	// chunks keep their embeddings when the models match
+	EmbeddingsModel() string
+}
+
+// getHybridAlpha returns the weight of the vector score in hybrid queries,
+// 0 only searches by keywords
+func getHybridAlpha(q *types.SessionRAGQuery) float64 {
+	if q.HybridAlpha == nil {
+		return DefaultHybridAlpha
+	}
+	return *q.HybridAlpha
+}
@@ -18,6 +18,7 @@ const (
 	DefaultDistanceFunction = "cosine"
 	DefaultThreshold        = 0.4
 	DefaultMaxResults       = 3
+	DefaultHybridAlpha      = 0.5
 
 	DefaultChunkSize     = 2048
 	DefaultChunkOverflow = 20
@@ -107,6 +108,7 @@ func (l *Llamaindex) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*ty
 		Int("max_results", q.MaxResults).
 		Int("distance_threshold", int(q.DistanceThreshold)).
 		Str("data_entity_id", q.DataEntityID).
+		Str("retrieval_mode", string(q.RetrievalMode)).
 		Logger()
 
 	if q.Prompt == "" {
@@ -130,6 +132,11 @@ func (l *Llamaindex) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*ty
 		q.MaxResults = DefaultMaxResults
 	}
 
+	if q.RetrievalMode == types.RAGRetrievalModeHybrid && q.HybridAlpha == nil {
+		alpha := DefaultHybridAlpha
+		q.HybridAlpha = &alpha
+	}
+
 	bts, err := json.Marshal(q)
 	if err != nil {
 		return nil, err
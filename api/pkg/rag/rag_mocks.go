@@ -88,3 +88,94 @@ func (mr *MockRAGMockRecorder) Query(ctx, q any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockRAG)(nil).Query), ctx, q)
 }
+
+// MockCopier is a mock of Copier interface.
+type MockCopier struct {
+	ctrl     *gomock.Controller
+	recorder *MockCopierMockRecorder
+	isgomock struct{}
+}
+
+// MockCopierMockRecorder is the mock recorder for MockCopier.
+type MockCopierMockRecorder struct {
+	mock *MockCopier
+}
+
+// NewMockCopier creates a new mock instance.
+func NewMockCopier(ctrl *gomock.Controller) *MockCopier {
+	mock := &MockCopier{ctrl: ctrl}
+	mock.recorder = &MockCopierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCopier) EXPECT() *MockCopierMockRecorder {
+	return m.recorder
+}
+
+// Copy mocks base method.
+func (m *MockCopier) Copy(ctx context.Context, req *types.CopyIndexRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Copy", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Copy indicates an expected call of Copy.
+func (mr *MockCopierMockRecorder) Copy(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Copy", reflect.TypeOf((*MockCopier)(nil).Copy), ctx, req)
+}
+
+// MockExporter is a mock of Exporter interface.
+type MockExporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockExporterMockRecorder
+	isgomock struct{}
+}
+
+// MockExporterMockRecorder is the mock recorder for MockExporter.
+type MockExporterMockRecorder struct {
+	mock *MockExporter
+}
+
+// NewMockExporter creates a new mock instance.
+func NewMockExporter(ctrl *gomock.Controller) *MockExporter {
+	mock := &MockExporter{ctrl: ctrl}
+	mock.recorder = &MockExporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExporter) EXPECT() *MockExporterMockRecorder {
+	return m.recorder
+}
+
+// EmbeddingsModel mocks base method.
+func (m *MockExporter) EmbeddingsModel() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmbeddingsModel")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// EmbeddingsModel indicates an expected call of EmbeddingsModel.
+func (mr *MockExporterMockRecorder) EmbeddingsModel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmbeddingsModel", reflect.TypeOf((*MockExporter)(nil).EmbeddingsModel))
+}
+
+// Export mocks base method.
+func (m *MockExporter) Export(ctx context.Context, req *types.ExportIndexRequest) ([]*types.SessionRAGIndexChunk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, req)
+	ret0, _ := ret[0].([]*types.SessionRAGIndexChunk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockExporterMockRecorder) Export(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockExporter)(nil).Export), ctx, req)
+}
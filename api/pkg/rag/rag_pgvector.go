@@ -0,0 +1,662 @@
+package rag
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/openai/manager"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const (
+	pgvectorTable = "helix_rag_chunks"
+
+	// pgvectorEmbeddingsBatchSize is the number of chunks embedded per request
+	pgvectorEmbeddingsBatchSize = 100
+)
+
+// pgvectorDistanceOperators maps the distance functions to the pgvector operators
+var pgvectorDistanceOperators = map[string]string{
+	"l2":            "<->",
+	"inner_product": "<#>",
+	"cosine":        "<=>",
+}
+
+// Static check
+var (
+	_ RAG      = &PGVector{}
+	_ Copier   = &PGVector{}
+	_ Exporter = &PGVector{}
+)
+
+// PGVector stores the chunks in the Postgres database with the pgvector
+// extension, the embeddings are generated through the provider manager
+type PGVector struct {
+	cfg             config.Store
+	providerManager manager.ProviderManager
+	provider        types.Provider
+	model           string
+	dimensions      int
+
+	db    *gorm.DB
+	ready chan struct{}
+}
+
+func NewPGVector(cfg *config.ServerConfig, providerManager manager.ProviderManager) (*PGVector, error) {
+	if cfg.RAG.PGVector.Dimensions <= 0 {
+		return nil, fmt.Errorf("pgvector embeddings dimensions must be set")
+	}
+
+	p := &PGVector{
+		cfg:             cfg.Store,
+		providerManager: providerManager,
+		provider:        cfg.RAG.PGVector.Provider,
+		model:           cfg.RAG.PGVector.EmbeddingsModel,
+		dimensions:      cfg.RAG.PGVector.Dimensions,
+		ready:           make(chan struct{}),
+	}
+
+	go p.waitForPostgres()
+
+	return p, nil
+}
+
+func (p *PGVector) waitForPostgres() {
+	err := retry.Do(func() error {
+		return p.connect(context.Background())
+	},
+		retry.Attempts(0),
+		retry.Delay(2*time.Second),
+		retry.MaxDelay(10*time.Second),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			log.Warn().
+				Err(err).
+				Uint("retries", n).
+				Msg("waiting for pgvector to come up")
+		}),
+	)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to connect to pgvector")
+		return
+	}
+
+	log.Info().Msg("pgvector is up and table is ready")
+	close(p.ready)
+}
+
+func (p *PGVector) connect(ctx context.Context) error {
+	sslSettings := "sslmode=disable"
+	if os.Getenv(store.ENV_POSTGRES_SSL) == "true" {
+		sslSettings = "sslmode=require"
+	}
+
+	dsn := fmt.Sprintf("user=%s password=%s host=%s port=%d dbname=%s %s",
+		p.cfg.Username, p.cfg.Password, p.cfg.Host, p.cfg.Port, p.cfg.Database, sslSettings)
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS vector",
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id text PRIMARY KEY,
+			created timestamptz NOT NULL DEFAULT now(),
+			data_entity_id text NOT NULL,
+			document_id text NOT NULL DEFAULT '',
+			document_group_id text NOT NULL DEFAULT '',
+			filename text NOT NULL DEFAULT '',
+			source text NOT NULL DEFAULT '',
+			content_offset integer NOT NULL DEFAULT 0,
+			content text NOT NULL,
+			metadata jsonb NOT NULL DEFAULT '{}',
+			embedding vector(%d) NOT NULL
+		)`, pgvectorTable, p.dimensions),
+		// Tables created before chunks had metadata
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS metadata jsonb NOT NULL DEFAULT '{}'", pgvectorTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_data_entity_id_idx ON %s (data_entity_id)", pgvectorTable, pgvectorTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_embedding_idx ON %s USING hnsw (embedding vector_cosine_ops)", pgvectorTable, pgvectorTable),
+	}
+
+	for _, statement := range statements {
+		err = db.WithContext(ctx).Exec(statement).Error
+		if err != nil {
+			return fmt.Errorf("failed to prepare pgvector table: %w", err)
+		}
+	}
+
+	p.db = db
+
+	return nil
+}
+
+func (p *PGVector) ensureReady(ctx context.Context) error {
+	select {
+	case <-p.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pgvectorChunk is a row of the chunks table
+type pgvectorChunk struct {
+	ID              string
+	DataEntityID    string
+	DocumentID      string
+	DocumentGroupID string
+	Filename        string
+	Source          string
+	ContentOffset   int
+	Content         string
+	Metadata        pgvectorMetadata
+	Embedding       pgvectorEmbedding
+}
+
+func (pgvectorChunk) TableName() string {
+	return pgvectorTable
+}
+
+// pgvectorEmbedding is written in the pgvector text format, e.g. [1,2,3]
+type pgvectorEmbedding []float32
+
+func (e pgvectorEmbedding) Value() (driver.Value, error) {
+	values := make([]string, 0, len(e))
+	for _, v := range e {
+		values = append(values, strconv.FormatFloat(float64(v), 'f', -1, 32))
+	}
+
+	return "[" + strings.Join(values, ",") + "]", nil
+}
+
+func (e *pgvectorEmbedding) Scan(src interface{}) error {
+	var str string
+
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return fmt.Errorf("unexpected embedding type %T", src)
+	}
+
+	str = strings.TrimSuffix(strings.TrimPrefix(str, "["), "]")
+	if str == "" {
+		*e = nil
+		return nil
+	}
+
+	values := strings.Split(str, ",")
+	embedding := make(pgvectorEmbedding, 0, len(values))
+
+	for _, v := range values {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 32)
+		if err != nil {
+			return fmt.Errorf("invalid embedding value %q: %w", v, err)
+		}
+		embedding = append(embedding, float32(f))
+	}
+
+	*e = embedding
+	return nil
+}
+
+// pgvectorMetadata is stored in the jsonb metadata column
+type pgvectorMetadata map[string]string
+
+func (m pgvectorMetadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+
+	bts, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(bts), nil
+}
+
+func (m *pgvectorMetadata) Scan(src interface{}) error {
+	var bts []byte
+
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		bts = v
+	case string:
+		bts = []byte(v)
+	default:
+		return fmt.Errorf("unexpected metadata type %T", src)
+	}
+
+	return json.Unmarshal(bts, m)
+}
+
+func (p *PGVector) Index(ctx context.Context, indexReqs ...*types.SessionRAGIndexChunk) error {
+	if err := p.ensureReady(ctx); err != nil {
+		return err
+	}
+
+	if len(indexReqs) == 0 {
+		return fmt.Errorf("no index requests provided")
+	}
+
+	for start := 0; start < len(indexReqs); start += pgvectorEmbeddingsBatchSize {
+		end := min(start+pgvectorEmbeddingsBatchSize, len(indexReqs))
+		batch := indexReqs[start:end]
+
+		embeddings, err := p.getEmbeddings(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		chunks := make([]*pgvectorChunk, 0, len(batch))
+		for i, indexReq := range batch {
+			chunks = append(chunks, &pgvectorChunk{
+				ID:              system.GenerateID(),
+				DataEntityID:    indexReq.DataEntityID,
+				DocumentID:      indexReq.DocumentID,
+				DocumentGroupID: indexReq.DocumentGroupID,
+				Filename:        indexReq.Filename,
+				Source:          indexReq.Source,
+				ContentOffset:   indexReq.ContentOffset,
+				Content:         indexReq.Content,
+				Metadata:        indexReq.Metadata,
+				Embedding:       embeddings[i],
+			})
+		}
+
+		err = p.db.WithContext(ctx).Create(chunks).Error
+		if err != nil {
+			return fmt.Errorf("error inserting chunks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// getEmbeddings embeds the chunks, reusing the embeddings of imported chunks
+func (p *PGVector) getEmbeddings(ctx context.Context, chunks []*types.SessionRAGIndexChunk) ([]pgvectorEmbedding, error) {
+	embeddings := make([]pgvectorEmbedding, len(chunks))
+
+	var (
+		contents []string
+		missing  []int
+	)
+
+	for i, chunk := range chunks {
+		if len(chunk.Embedding) == p.dimensions {
+			embeddings[i] = chunk.Embedding
+			continue
+		}
+
+		contents = append(contents, chunk.Content)
+		missing = append(missing, i)
+	}
+
+	if len(contents) == 0 {
+		return embeddings, nil
+	}
+
+	embedded, err := p.embed(ctx, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, idx := range missing {
+		embeddings[idx] = embedded[i]
+	}
+
+	return embeddings, nil
+}
+
+func (p *PGVector) embed(ctx context.Context, input []string) ([]pgvectorEmbedding, error) {
+	client, err := p.providerManager.GetClient(ctx, &manager.GetClientRequest{Provider: p.provider})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embeddings client: %w", err)
+	}
+
+	resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: input,
+		Model: openai.EmbeddingModel(p.model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+
+	if len(resp.Data) != len(input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(input), len(resp.Data))
+	}
+
+	embeddings := make([]pgvectorEmbedding, len(input))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(input) {
+			return nil, fmt.Errorf("invalid embedding index %d", data.Index)
+		}
+
+		if len(data.Embedding) != p.dimensions {
+			return nil, fmt.Errorf("expected embeddings with %d dimensions, got %d, check RAG_PGVECTOR_DIMENSIONS", p.dimensions, len(data.Embedding))
+		}
+
+		embeddings[data.Index] = data.Embedding
+	}
+
+	return embeddings, nil
+}
+
+// pgvectorResult is a row of the query results
+type pgvectorResult struct {
+	ID              string
+	DocumentID      string
+	DocumentGroupID string
+	Filename        string
+	Source          string
+	ContentOffset   int
+	Content         string
+	Metadata        pgvectorMetadata
+	Distance        float64
+}
+
+func (p *PGVector) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
+	if err := p.ensureReady(ctx); err != nil {
+		return nil, err
+	}
+
+	if q.Prompt == "" {
+		return nil, fmt.Errorf("prompt cannot be empty")
+	}
+
+	if q.DataEntityID == "" {
+		return nil, fmt.Errorf("data entity ID cannot be empty")
+	}
+
+	maxResults := q.MaxResults
+	if maxResults == 0 {
+		maxResults = DefaultMaxResults
+	}
+
+	var (
+		results []*pgvectorResult
+		err     error
+	)
+
+	switch q.RetrievalMode {
+	case types.RAGRetrievalModeKeyword:
+		results, err = p.queryKeyword(ctx, q, maxResults)
+	case types.RAGRetrievalModeHybrid:
+		results, err = p.queryHybrid(ctx, q, maxResults)
+	default:
+		results, err = p.queryVector(ctx, q, maxResults)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("retrieval_mode", string(q.RetrievalMode)).
+		Int("num_results", len(results)).
+		Msg("pgvector results")
+
+	ragResults := make([]*types.SessionRAGResult, 0, len(results))
+	for _, result := range results {
+		ragResults = append(ragResults, &types.SessionRAGResult{
+			ID:              result.ID,
+			DocumentID:      result.DocumentID,
+			DocumentGroupID: result.DocumentGroupID,
+			Filename:        result.Filename,
+			Source:          result.Source,
+			ContentOffset:   result.ContentOffset,
+			Content:         result.Content,
+			Distance:        result.Distance,
+			Metadata:        result.Metadata,
+		})
+	}
+
+	return ragResults, nil
+}
+
+// filtered restricts the query to the data entity and the filters of the query
+func (p *PGVector) filtered(ctx context.Context, q *types.SessionRAGQuery) *gorm.DB {
+	tx := p.db.WithContext(ctx).Table(pgvectorTable).Where("data_entity_id = ?", q.DataEntityID)
+
+	if len(q.Filters.Filenames) > 0 {
+		tx = tx.Where("filename IN ?", q.Filters.Filenames)
+	}
+
+	if len(q.Filters.Sources) > 0 {
+		tx = tx.Where("source IN ?", q.Filters.Sources)
+	}
+
+	return tx
+}
+
+func (p *PGVector) queryVector(ctx context.Context, q *types.SessionRAGQuery, maxResults int) ([]*pgvectorResult, error) {
+	distanceFunction := q.DistanceFunction
+	if distanceFunction == "" {
+		distanceFunction = DefaultDistanceFunction
+	}
+
+	operator, ok := pgvectorDistanceOperators[distanceFunction]
+	if !ok {
+		return nil, fmt.Errorf("unknown distance function: %s", distanceFunction)
+	}
+
+	threshold := q.DistanceThreshold
+	if threshold == 0 {
+		threshold = DefaultThreshold
+	}
+
+	embeddings, err := p.embed(ctx, []string{q.Prompt})
+	if err != nil {
+		return nil, err
+	}
+
+	distance := "embedding " + operator + " ?"
+
+	var results []*pgvectorResult
+
+	err = p.filtered(ctx, q).
+		Select("id, document_id, document_group_id, filename, source, content_offset, content, metadata, "+distance+" AS distance", embeddings[0]).
+		Where(distance+" < ?", embeddings[0], threshold).
+		Order("distance").
+		Limit(maxResults).
+		Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("error querying chunks: %w", err)
+	}
+
+	return results, nil
+}
+
+// queryKeyword runs a full text search on the content, the distance is derived
+// from the rank so that better matches have a lower distance like in the vector search
+func (p *PGVector) queryKeyword(ctx context.Context, q *types.SessionRAGQuery, maxResults int) ([]*pgvectorResult, error) {
+	var results []*pgvectorResult
+
+	err := p.filtered(ctx, q).
+		Select("id, document_id, document_group_id, filename, source, content_offset, content, metadata, "+
+			"1 / (1 + ts_rank_cd(to_tsvector('english', content), plainto_tsquery('english', ?))) AS distance", q.Prompt).
+		Where("to_tsvector('english', content) @@ plainto_tsquery('english', ?)", q.Prompt).
+		Order("distance").
+		Limit(maxResults).
+		Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("error querying chunks: %w", err)
+	}
+
+	return results, nil
+}
+
+func (p *PGVector) queryHybrid(ctx context.Context, q *types.SessionRAGQuery, maxResults int) ([]*pgvectorResult, error) {
+	alpha := getHybridAlpha(q)
+
+	candidates := maxResults * DefaultRerankCandidatesFactor
+
+	vectorResults, err := p.queryVector(ctx, q, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	keywordResults, err := p.queryKeyword(ctx, q, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	return fuseResults(alpha, maxResults, vectorResults, keywordResults), nil
+}
+
+// fuseResults combines the vector and keyword results with reciprocal rank
+// fusion, alpha is the weight of the vector results (same as typesense's hybrid search)
+func fuseResults(alpha float64, maxResults int, vectorResults, keywordResults []*pgvectorResult) []*pgvectorResult {
+	scores := make(map[string]float64)
+	results := make(map[string]*pgvectorResult)
+
+	for _, ranked := range []struct {
+		weight  float64
+		results []*pgvectorResult
+	}{
+		{alpha, vectorResults},
+		{1 - alpha, keywordResults},
+	} {
+		for rank, result := range ranked.results {
+			if _, ok := results[result.ID]; !ok {
+				results[result.ID] = result
+			}
+			scores[result.ID] += ranked.weight / float64(rank+1)
+		}
+	}
+
+	fused := make([]*pgvectorResult, 0, len(results))
+	for id, result := range results {
+		result.Distance = 1 - scores[id]
+		fused = append(fused, result)
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool {
+		if fused[i].Distance == fused[j].Distance {
+			return fused[i].ID < fused[j].ID
+		}
+		return fused[i].Distance < fused[j].Distance
+	})
+
+	if len(fused) > maxResults {
+		fused = fused[:maxResults]
+	}
+
+	return fused
+}
+
+func (p *PGVector) Delete(ctx context.Context, r *types.DeleteIndexRequest) error {
+	if err := p.ensureReady(ctx); err != nil {
+		return err
+	}
+
+	if r.DataEntityID == "" {
+		return fmt.Errorf("data entity ID cannot be empty")
+	}
+
+	err := p.db.WithContext(ctx).Where("data_entity_id = ?", r.DataEntityID).Delete(&pgvectorChunk{}).Error
+	if err != nil {
+		return fmt.Errorf("error deleting chunks: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PGVector) Copy(ctx context.Context, r *types.CopyIndexRequest) error {
+	if err := p.ensureReady(ctx); err != nil {
+		return err
+	}
+
+	if r.FromDataEntityID == "" || r.ToDataEntityID == "" {
+		return fmt.Errorf("data entity IDs cannot be empty")
+	}
+
+	if len(r.DocumentIDs) == 0 {
+		return nil
+	}
+
+	// The embeddings are copied along with the content
+	err := p.db.WithContext(ctx).Exec(fmt.Sprintf(`INSERT INTO %s
+		(id, data_entity_id, document_id, document_group_id, filename, source, content_offset, content, metadata, embedding)
+		SELECT gen_random_uuid()::text, ?, document_id, document_group_id, filename, source, content_offset, content, metadata, embedding
+		FROM %s WHERE data_entity_id = ? AND document_id IN ?`, pgvectorTable, pgvectorTable),
+		r.ToDataEntityID, r.FromDataEntityID, r.DocumentIDs).Error
+	if err != nil {
+		return fmt.Errorf("error copying chunks: %w", err)
+	}
+
+	return nil
+}
+
+// Export returns the chunks of the data entity in document order
+func (p *PGVector) Export(ctx context.Context, r *types.ExportIndexRequest) ([]*types.SessionRAGIndexChunk, error) {
+	if err := p.ensureReady(ctx); err != nil {
+		return nil, err
+	}
+
+	if r.DataEntityID == "" {
+		return nil, fmt.Errorf("data entity ID cannot be empty")
+	}
+
+	columns := "id, data_entity_id, document_id, document_group_id, filename, source, content_offset, content, metadata"
+	if r.IncludeEmbeddings {
+		columns += ", embedding"
+	}
+
+	var rows []*pgvectorChunk
+
+	err := p.db.WithContext(ctx).
+		Select(columns).
+		Where("data_entity_id = ?", r.DataEntityID).
+		Order("source, document_id, content_offset").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("error exporting chunks: %w", err)
+	}
+
+	chunks := make([]*types.SessionRAGIndexChunk, 0, len(rows))
+	for _, row := range rows {
+		chunks = append(chunks, &types.SessionRAGIndexChunk{
+			DataEntityID:    row.DataEntityID,
+			Source:          row.Source,
+			Filename:        row.Filename,
+			DocumentID:      row.DocumentID,
+			DocumentGroupID: row.DocumentGroupID,
+			ContentOffset:   row.ContentOffset,
+			Content:         row.Content,
+			Metadata:        row.Metadata,
+			Embedding:       row.Embedding,
+		})
+	}
+
+	return chunks, nil
+}
+
+func (p *PGVector) EmbeddingsModel() string {
+	return p.model
+}
@@ -0,0 +1,210 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/manager"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func Test_pgvectorEmbedding_Value(t *testing.T) {
+	value, err := pgvectorEmbedding{1, 0.5, -0.25}.Value()
+	require.NoError(t, err)
+	require.Equal(t, "[1,0.5,-0.25]", value)
+}
+
+func Test_pgvectorEmbedding_Scan(t *testing.T) {
+	var e pgvectorEmbedding
+
+	require.NoError(t, e.Scan([]byte("[1,-0.5,2.25]")))
+	require.Equal(t, pgvectorEmbedding{1, -0.5, 2.25}, e)
+
+	require.NoError(t, e.Scan("[]"))
+	require.Nil(t, e)
+
+	require.Error(t, e.Scan("[1,x]"))
+}
+
+func Test_pgvectorMetadata(t *testing.T) {
+	value, err := pgvectorMetadata{"page": "2"}.Value()
+	require.NoError(t, err)
+	require.Equal(t, `{"page":"2"}`, value)
+
+	value, err = pgvectorMetadata(nil).Value()
+	require.NoError(t, err)
+	require.Equal(t, "{}", value)
+
+	var metadata pgvectorMetadata
+	require.NoError(t, metadata.Scan([]byte(`{"headings":"Install > Linux"}`)))
+	require.Equal(t, pgvectorMetadata{"headings": "Install > Linux"}, metadata)
+}
+
+func Test_fuseResults(t *testing.T) {
+	vectorResults := []*pgvectorResult{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	keywordResults := []*pgvectorResult{{ID: "c"}, {ID: "d"}}
+
+	fused := fuseResults(0.5, 3, vectorResults, keywordResults)
+	require.Len(t, fused, 3)
+
+	// c is in both lists
+	require.Equal(t, "c", fused[0].ID)
+	require.Equal(t, "a", fused[1].ID)
+	// b and d are tied, ordered by ID
+	require.Equal(t, "b", fused[2].ID)
+	require.InDelta(t, 1-(0.5/3+0.5), fused[0].Distance, 0.0001)
+
+	// Only the keyword results count
+	fused = fuseResults(0, 1, vectorResults, keywordResults)
+	require.Equal(t, "c", fused[0].ID)
+}
+
+// PGVectorTestSuite runs against a Postgres database with the pgvector
+// extension, e.g. docker run -p 5433:5432 -e POSTGRES_PASSWORD=postgres ankane/pgvector
+type PGVectorTestSuite struct {
+	suite.Suite
+	ctx context.Context
+
+	pg *PGVector
+}
+
+func TestPGVectorTestSuite(t *testing.T) {
+	if os.Getenv("PGVECTOR_TEST_HOST") == "" {
+		t.Skip("PGVECTOR_TEST_HOST not set, skipping pgvector tests")
+	}
+
+	suite.Run(t, new(PGVectorTestSuite))
+}
+
+// testEmbeddings embeds the text by counting a few keywords so the
+// results are predictable
+func testEmbeddings(input []string) openai.EmbeddingResponse {
+	var resp openai.EmbeddingResponse
+	for i, text := range input {
+		text = strings.ToLower(text)
+		resp.Data = append(resp.Data, openai.Embedding{
+			Index: i,
+			Embedding: []float32{
+				float32(strings.Count(text, "helix")) + 0.01,
+				float32(strings.Count(text, "rag")) + 0.01,
+				float32(strings.Count(text, "cat")) + 0.01,
+			},
+		})
+	}
+	return resp
+}
+
+func (suite *PGVectorTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+
+	ctrl := gomock.NewController(suite.T())
+	client := oai.NewMockClient(ctrl)
+	client.EXPECT().CreateEmbeddings(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, request openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+		return testEmbeddings(request.Convert().Input.([]string)), nil
+	}).AnyTimes()
+
+	providerManager := manager.NewMockProviderManager(ctrl)
+	providerManager.EXPECT().GetClient(gomock.Any(), gomock.Any()).Return(client, nil).AnyTimes()
+
+	cfg := &config.ServerConfig{}
+	cfg.Store = config.Store{
+		Host:     os.Getenv("PGVECTOR_TEST_HOST"),
+		Port:     5433,
+		Username: "postgres",
+		Password: "postgres",
+		Database: "postgres",
+	}
+	cfg.RAG.PGVector.Provider = types.ProviderOpenAI
+	cfg.RAG.PGVector.EmbeddingsModel = "test"
+	cfg.RAG.PGVector.Dimensions = 3
+
+	pg, err := NewPGVector(cfg, providerManager)
+	suite.Require().NoError(err)
+
+	suite.pg = pg
+
+	ctx, cancel := context.WithTimeout(suite.ctx, 30*time.Second)
+	defer cancel()
+
+	suite.Require().NoError(pg.ensureReady(ctx))
+}
+
+func (suite *PGVectorTestSuite) TestIndexQueryDelete() {
+	dataEntityID := system.GenerateDataEntityID()
+
+	err := suite.pg.Index(suite.ctx,
+		&types.SessionRAGIndexChunk{
+			DataEntityID: dataEntityID,
+			Filename:     "helix.md",
+			DocumentID:   "doc-1",
+			Content:      "Helix is a platform for private GenAI",
+			Metadata:     map[string]string{types.RAGMetadataPage: "3"},
+		},
+		&types.SessionRAGIndexChunk{
+			DataEntityID: dataEntityID,
+			Filename:     "rag.md",
+			DocumentID:   "doc-2",
+			Content:      "RAG retrieves the relevant RAG chunks",
+		},
+		&types.SessionRAGIndexChunk{
+			DataEntityID: dataEntityID,
+			Filename:     "cat.md",
+			DocumentID:   "doc-3",
+			Content:      "The cat sat on the mat",
+		},
+	)
+	suite.Require().NoError(err)
+
+	results, err := suite.pg.Query(suite.ctx, &types.SessionRAGQuery{
+		Prompt:       "what is helix?",
+		DataEntityID: dataEntityID,
+		MaxResults:   1,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Equal("helix.md", results[0].Filename)
+	suite.Equal(map[string]string{types.RAGMetadataPage: "3"}, results[0].Metadata)
+
+	results, err = suite.pg.Query(suite.ctx, &types.SessionRAGQuery{
+		Prompt:        "cat",
+		DataEntityID:  dataEntityID,
+		MaxResults:    3,
+		RetrievalMode: types.RAGRetrievalModeKeyword,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Equal("doc-3", results[0].DocumentID)
+
+	results, err = suite.pg.Query(suite.ctx, &types.SessionRAGQuery{
+		Prompt:       "helix rag",
+		DataEntityID: dataEntityID,
+		MaxResults:   3,
+		Filters: types.RAGQueryFilters{
+			Filenames: []string{"rag.md"},
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Equal("rag.md", results[0].Filename)
+
+	err = suite.pg.Delete(suite.ctx, &types.DeleteIndexRequest{DataEntityID: dataEntityID})
+	suite.Require().NoError(err)
+
+	results, err = suite.pg.Query(suite.ctx, &types.SessionRAGQuery{
+		Prompt:       "what is helix?",
+		DataEntityID: dataEntityID,
+	})
+	suite.Require().NoError(err)
+	suite.Empty(results)
+}
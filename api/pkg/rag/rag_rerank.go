@@ -0,0 +1,86 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// DefaultRerankCandidatesFactor is how many more results than requested are
+// retrieved for reranking when the number of candidates isn't set
+const DefaultRerankCandidatesFactor = 4
+
+// Reranker orders the retrieved results by their relevance to the prompt,
+// the most relevant first
+type Reranker interface {
+	Rerank(ctx context.Context, prompt string, results []*types.SessionRAGResult) ([]*types.SessionRAGResult, error)
+}
+
+// Static check
+var _ RAG = &Reranking{}
+
+// Reranking retrieves more candidates than requested from the wrapped RAG and
+// returns the best ones according to the reranker
+type Reranking struct {
+	rag        RAG
+	reranker   Reranker
+	candidates int
+}
+
+func NewReranking(rag RAG, reranker Reranker, candidates int) *Reranking {
+	return &Reranking{
+		rag:        rag,
+		reranker:   reranker,
+		candidates: candidates,
+	}
+}
+
+func (r *Reranking) Index(ctx context.Context, req ...*types.SessionRAGIndexChunk) error {
+	return r.rag.Index(ctx, req...)
+}
+
+func (r *Reranking) Delete(ctx context.Context, req *types.DeleteIndexRequest) error {
+	return r.rag.Delete(ctx, req)
+}
+
+func (r *Reranking) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
+	maxResults := q.MaxResults
+	if maxResults == 0 {
+		maxResults = DefaultMaxResults
+	}
+
+	candidates := r.candidates
+	if candidates < maxResults {
+		candidates = maxResults * DefaultRerankCandidatesFactor
+	}
+
+	candidatesQuery := *q
+	candidatesQuery.MaxResults = candidates
+
+	results, err := r.rag.Query(ctx, &candidatesQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) > 1 {
+		results, err = r.reranker.Rerank(ctx, q.Prompt, results)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rerank results: %w", err)
+		}
+	}
+
+	log.Debug().
+		Int("candidates", candidates).
+		Int("max_results", maxResults).
+		Int("num_results", len(results)).
+		Msg("reranked RAG results")
+
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+
+	return results, nil
+}
@@ -0,0 +1,114 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// Static check
+var _ Reranker = &CrossEncoderReranker{}
+
+// CrossEncoderReranker scores the results with a cross-encoder served behind
+// a /rerank endpoint. The request and response follow the Cohere rerank API
+// which is also implemented by Jina, Text Embeddings Inference, Infinity and vLLM.
+type CrossEncoderReranker struct {
+	url        string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewCrossEncoderReranker calls the endpoint of the settings, the API key is
+// resolved from the secret of the settings by the caller
+func NewCrossEncoderReranker(settings *types.RAGRerankSettings, apiKey string) *CrossEncoderReranker {
+	return &CrossEncoderReranker{
+		url:        settings.URL,
+		apiKey:     apiKey,
+		model:      settings.Model,
+		httpClient: http.DefaultClient,
+	}
+}
+
+type rerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+}
+
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+func (r *CrossEncoderReranker) Rerank(ctx context.Context, prompt string, results []*types.SessionRAGResult) ([]*types.SessionRAGResult, error) {
+	rerankReq := &rerankRequest{
+		Model: r.model,
+		Query: prompt,
+	}
+
+	for _, result := range results {
+		rerankReq.Documents = append(rerankReq.Documents, result.Content)
+	}
+
+	bts, err := json.Marshal(rerankReq)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(bts))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request to the reranker: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("error response from the reranker: %s (%s)", resp.Status, string(body))
+	}
+
+	var rerankResp rerankResponse
+	err = json.Unmarshal(body, &rerankResp)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON (%s), error: %w", string(body), err)
+	}
+
+	var reranked []*types.SessionRAGResult
+
+	for _, scored := range rerankResp.Results {
+		if scored.Index < 0 || scored.Index >= len(results) {
+			return nil, fmt.Errorf("reranker returned an invalid index %d", scored.Index)
+		}
+
+		result := results[scored.Index]
+		result.Score = scored.RelevanceScore
+		reranked = append(reranked, result)
+	}
+
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+
+	return reranked, nil
+}
@@ -0,0 +1,102 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/tools"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// Static check
+var _ Reranker = &LLMReranker{}
+
+const llmRerankSystemPrompt = `You are a search relevance judge. You will be given a question and a numbered list of passages.
+Score how useful each passage is for answering the question from 0 (irrelevant) to 10 (answers the question).
+Reply only with a JSON array of objects with the passage "index" and its "score", for example:
+[{"index": 0, "score": 7}, {"index": 1, "score": 2}]`
+
+// LLMReranker asks a model to score the results, used when there is no
+// cross-encoder available
+type LLMReranker struct {
+	client oai.Client
+	model  string
+}
+
+func NewLLMReranker(client oai.Client, model string) *LLMReranker {
+	return &LLMReranker{
+		client: client,
+		model:  model,
+	}
+}
+
+type llmRerankScore struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+func (r *LLMReranker) Rerank(ctx context.Context, prompt string, results []*types.SessionRAGResult) ([]*types.SessionRAGResult, error) {
+	var passages strings.Builder
+
+	fmt.Fprintf(&passages, "Question: %s\n\n", prompt)
+	for i, result := range results {
+		fmt.Fprintf(&passages, "Passage %d:\n%s\n\n", i, result.Content)
+	}
+
+	ctx = oai.SetStep(ctx, &oai.Step{Step: types.LLMCallStepRerank})
+
+	resp, err := r.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: r.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: llmRerankSystemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: passages.String(),
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rerank scores: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no rerank scores returned")
+	}
+
+	var scores []llmRerankScore
+
+	err = json.Unmarshal([]byte(tools.AttemptFixJSON(resp.Choices[0].Message.Content)), &scores)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rerank scores (%s): %w", resp.Choices[0].Message.Content, err)
+	}
+
+	// Passages the model didn't score keep a zero score and go last
+	for _, result := range results {
+		result.Score = 0
+	}
+
+	for _, score := range scores {
+		if score.Index < 0 || score.Index >= len(results) {
+			continue
+		}
+		results[score.Index].Score = score.Score
+	}
+
+	reranked := make([]*types.SessionRAGResult, len(results))
+	copy(reranked, results)
+
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+
+	return reranked, nil
+}
@@ -0,0 +1,163 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type reverseReranker struct {
+	prompt string
+}
+
+func (r *reverseReranker) Rerank(_ context.Context, prompt string, results []*types.SessionRAGResult) ([]*types.SessionRAGResult, error) {
+	r.prompt = prompt
+
+	reranked := make([]*types.SessionRAGResult, 0, len(results))
+	for i := len(results) - 1; i >= 0; i-- {
+		reranked = append(reranked, results[i])
+	}
+	return reranked, nil
+}
+
+func testResults(contents ...string) []*types.SessionRAGResult {
+	var results []*types.SessionRAGResult
+	for _, content := range contents {
+		results = append(results, &types.SessionRAGResult{Content: content})
+	}
+	return results
+}
+
+func TestReranking_Query(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ragClient := NewMockRAG(ctrl)
+
+	ragClient.EXPECT().Query(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
+		require.Equal(t, 8, q.MaxResults)
+		require.Equal(t, types.RAGRetrievalModeHybrid, q.RetrievalMode)
+		return testResults("a", "b", "c", "d"), nil
+	})
+
+	reranker := &reverseReranker{}
+
+	results, err := NewReranking(ragClient, reranker, 0).Query(context.Background(), &types.SessionRAGQuery{
+		Prompt:        "what is helix?",
+		MaxResults:    2,
+		RetrievalMode: types.RAGRetrievalModeHybrid,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "what is helix?", reranker.prompt)
+	require.Len(t, results, 2)
+	require.Equal(t, "d", results[0].Content)
+	require.Equal(t, "c", results[1].Content)
+}
+
+func TestReranking_Query_Candidates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ragClient := NewMockRAG(ctrl)
+
+	ragClient.EXPECT().Query(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
+		require.Equal(t, 20, q.MaxResults)
+		return testResults("a"), nil
+	})
+
+	results, err := NewReranking(ragClient, &reverseReranker{}, 20).Query(context.Background(), &types.SessionRAGQuery{
+		MaxResults: 3,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+}
+
+func TestCrossEncoderReranker_Rerank(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var req rerankRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "rerank-model", req.Model)
+		require.Equal(t, "what is helix?", req.Query)
+		require.Equal(t, []string{"a", "b", "c"}, req.Documents)
+
+		_, _ = w.Write([]byte(`{"results": [{"index": 1, "relevance_score": 0.2}, {"index": 2, "relevance_score": 0.9}]}`))
+	}))
+	defer srv.Close()
+
+	reranker := NewCrossEncoderReranker(&types.RAGRerankSettings{
+		URL:   srv.URL,
+		Model: "rerank-model",
+	}, "secret")
+
+	results, err := reranker.Rerank(context.Background(), "what is helix?", testResults("a", "b", "c"))
+	require.NoError(t, err)
+
+	require.Len(t, results, 2)
+	require.Equal(t, "c", results[0].Content)
+	require.Equal(t, 0.9, results[0].Score)
+	require.Equal(t, "b", results[1].Content)
+}
+
+func TestCrossEncoderReranker_Rerank_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	reranker := NewCrossEncoderReranker(&types.RAGRerankSettings{URL: srv.URL}, "")
+
+	_, err := reranker.Rerank(context.Background(), "what is helix?", testResults("a", "b"))
+	require.Error(t, err)
+}
+
+func TestLLMReranker_Rerank(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := oai.NewMockClient(ctrl)
+
+	client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		step, ok := oai.GetStep(ctx)
+		require.True(t, ok)
+		require.Equal(t, types.LLMCallStepRerank, step.Step)
+		require.Equal(t, "rerank-model", req.Model)
+
+		return openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{
+				{
+					Message: openai.ChatCompletionMessage{
+						Content: "```json\n[{\"index\": 0, \"score\": 1}, {\"index\": 2, \"score\": 9}, {\"index\": 7, \"score\": 10}]\n```",
+					},
+				},
+			},
+		}, nil
+	})
+
+	results, err := NewLLMReranker(client, "rerank-model").Rerank(context.Background(), "what is helix?", testResults("a", "b", "c"))
+	require.NoError(t, err)
+
+	require.Len(t, results, 3)
+	require.Equal(t, "c", results[0].Content)
+	require.Equal(t, "a", results[1].Content)
+	require.Equal(t, "b", results[2].Content)
+}
+
+func Test_typesenseFilter(t *testing.T) {
+	require.Equal(t, "data_entity_id:123", typesenseFilter(&types.SessionRAGQuery{
+		DataEntityID: "123",
+	}))
+
+	require.Equal(t, "data_entity_id:123 && filename:=[`a b.pdf`,`c.md`] && source:=[`https://example.com`]", typesenseFilter(&types.SessionRAGQuery{
+		DataEntityID: "123",
+		Filters: types.RAGQueryFilters{
+			Filenames: []string{"a b.pdf", "c.md"},
+			Sources:   []string{"https://example.com"},
+		},
+	}))
+}
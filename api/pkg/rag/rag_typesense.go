@@ -3,6 +3,7 @@ package rag
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/helixml/helix/api/pkg/types"
@@ -25,7 +26,11 @@ type Typesense struct {
 	ready      chan struct{}
 }
 
-var _ RAG = &Typesense{}
+var (
+	_ RAG      = &Typesense{}
+	_ Copier   = &Typesense{}
+	_ Exporter = &Typesense{}
+)
 
 func NewTypesense(settings *types.RAGSettings) (*Typesense, error) {
 	client := typesense.NewClient(
@@ -107,7 +112,7 @@ func (t *Typesense) index(ctx context.Context, indexReqs ...*types.SessionRAGInd
 	}
 
 	if len(indexReqs) == 1 {
-		_, err := t.client.Collection(t.collection).Documents().Create(ctx, indexReqs[0])
+		_, err := t.client.Collection(t.collection).Documents().Create(ctx, newTypesenseDocument(indexReqs[0]))
 		return err
 	}
 
@@ -120,7 +125,7 @@ func (t *Typesense) index(ctx context.Context, indexReqs ...*types.SessionRAGInd
 
 	var docs []interface{}
 	for _, indexReq := range indexReqs {
-		docs = append(docs, indexReq)
+		docs = append(docs, newTypesenseDocument(indexReq))
 	}
 
 	_, err := t.client.Collection(t.collection).Documents().Import(ctx, docs, params)
@@ -131,20 +136,49 @@ func (t *Typesense) index(ctx context.Context, indexReqs ...*types.SessionRAGInd
 	return nil
 }
 
+// typesenseDocument is a chunk as it's indexed, Typesense only embeds the
+// content if the embedding isn't set
+type typesenseDocument struct {
+	*types.SessionRAGIndexChunk
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+func newTypesenseDocument(chunk *types.SessionRAGIndexChunk) *typesenseDocument {
+	return &typesenseDocument{
+		SessionRAGIndexChunk: chunk,
+		Embedding:            chunk.Embedding,
+	}
+}
+
 func (t *Typesense) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
 	if err := t.ensureReady(ctx); err != nil {
 		return nil, err
 	}
 
-	// TODO: implement hybrid search https://typesense.org/docs/26.0/api/vector-search.html#hybrid-search
 	searchParameters := &api.SearchCollectionParams{
 		Q:             pointer.String(q.Prompt),
-		QueryBy:       pointer.String("embedding,content"),
-		FilterBy:      pointer.String("data_entity_id:" + q.DataEntityID),
-		SortBy:        pointer.String("_text_match:desc,_vector_distance:asc"),
+		FilterBy:      pointer.String(typesenseFilter(q)),
 		ExcludeFields: pointer.String("embedding"), // Don't return the raw floating point numbers in the vector field in the search API response, to save on network bandwidth.
 	}
 
+	switch q.RetrievalMode {
+	case types.RAGRetrievalModeVector:
+		searchParameters.QueryBy = pointer.String("embedding")
+	case types.RAGRetrievalModeKeyword:
+		searchParameters.QueryBy = pointer.String("content")
+		searchParameters.SortBy = pointer.String("_text_match:desc")
+	case types.RAGRetrievalModeHybrid:
+		// https://typesense.org/docs/26.0/api/vector-search.html#hybrid-search
+		// alpha is the weight of the vector search in the rank fusion
+		alpha := getHybridAlpha(q)
+
+		searchParameters.QueryBy = pointer.String("content,embedding")
+		searchParameters.VectorQuery = pointer.String(fmt.Sprintf("embedding:([], alpha: %g)", alpha))
+	default:
+		searchParameters.QueryBy = pointer.String("embedding,content")
+		searchParameters.SortBy = pointer.String("_text_match:desc,_vector_distance:asc")
+	}
+
 	if q.MaxResults > 0 {
 		searchParameters.Limit = pointer.Int(q.MaxResults)
 	}
@@ -165,7 +199,12 @@ func (t *Typesense) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*typ
 			DocumentID:      getStrVariable(&hit, "document_id"),
 			Source:          getStrVariable(&hit, "source"),
 			Content:         getStrVariable(&hit, "content"),
+			Filename:        getStrVariable(&hit, "filename"),
 			ContentOffset:   getIntVariable(&hit, "content_offset"),
+			Metadata:        getMetadataVariable(&hit),
+		}
+		if hit.VectorDistance != nil {
+			ragResult.Distance = float64(*hit.VectorDistance)
 		}
 		ragResults = append(ragResults, ragResult)
 	}
@@ -173,6 +212,33 @@ func (t *Typesense) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*typ
 	return ragResults, nil
 }
 
+// typesenseFilter restricts the search to the data entity and the filters
+// of the query
+func typesenseFilter(q *types.SessionRAGQuery) string {
+	filters := []string{"data_entity_id:" + q.DataEntityID}
+
+	if len(q.Filters.Filenames) > 0 {
+		filters = append(filters, "filename:="+typesenseValues(q.Filters.Filenames))
+	}
+
+	if len(q.Filters.Sources) > 0 {
+		filters = append(filters, "source:="+typesenseValues(q.Filters.Sources))
+	}
+
+	return strings.Join(filters, " && ")
+}
+
+// typesenseValues formats a list of exact match values, backticks allow
+// the values to contain spaces, commas and other special characters
+func typesenseValues(values []string) string {
+	escaped := make([]string, 0, len(values))
+	for _, v := range values {
+		escaped = append(escaped, "`"+strings.ReplaceAll(v, "`", "")+"`")
+	}
+
+	return "[" + strings.Join(escaped, ",") + "]"
+}
+
 func (t *Typesense) Delete(ctx context.Context, r *types.DeleteIndexRequest) error {
 	if err := t.ensureReady(ctx); err != nil {
 		return err
@@ -185,6 +251,73 @@ func (t *Typesense) Delete(ctx context.Context, r *types.DeleteIndexRequest) err
 	return err
 }
 
+// typesenseCopyPageSize is the number of chunks read per search page when
+// copying documents, the maximum Typesense allows
+const typesenseCopyPageSize = 250
+
+// Copy reads the chunks of the documents, embeddings included, and imports
+// them into the new data entity. Typesense doesn't embed documents again
+// when the embedding is provided.
+func (t *Typesense) Copy(ctx context.Context, r *types.CopyIndexRequest) error {
+	if err := t.ensureReady(ctx); err != nil {
+		return err
+	}
+
+	if r.FromDataEntityID == "" || r.ToDataEntityID == "" {
+		return fmt.Errorf("data entity IDs cannot be empty")
+	}
+
+	if len(r.DocumentIDs) == 0 {
+		return nil
+	}
+
+	filter := fmt.Sprintf("data_entity_id:%s && document_id:=%s", r.FromDataEntityID, typesenseValues(r.DocumentIDs))
+
+	var docs []interface{}
+
+	for page := 1; ; page++ {
+		results, err := t.client.Collection(t.collection).Documents().Search(ctx, &api.SearchCollectionParams{
+			Q:        pointer.String("*"),
+			QueryBy:  pointer.String("content"),
+			FilterBy: pointer.String(filter),
+			Page:     pointer.Int(page),
+			PerPage:  pointer.Int(typesenseCopyPageSize),
+		})
+		if err != nil {
+			return fmt.Errorf("error reading documents: %w", err)
+		}
+
+		if results.Hits == nil || len(*results.Hits) == 0 {
+			break
+		}
+
+		for _, hit := range *results.Hits {
+			doc := *hit.Document
+			delete(doc, "id")
+			doc["data_entity_id"] = r.ToDataEntityID
+			docs = append(docs, doc)
+		}
+
+		if len(*results.Hits) < typesenseCopyPageSize {
+			break
+		}
+	}
+
+	if len(docs) == 0 {
+		return nil
+	}
+
+	_, err := t.client.Collection(t.collection).Documents().Import(ctx, docs, &api.ImportDocumentsParams{
+		Action:    pointer.String("create"),
+		BatchSize: pointer.Int(typesenseCopyPageSize),
+	})
+	if err != nil {
+		return fmt.Errorf("error importing documents: %w", err)
+	}
+
+	return nil
+}
+
 func getStrVariable(hit *api.SearchResultHit, key string) string {
 	val, ok := (*hit.Document)[key]
 	if !ok {
@@ -208,6 +341,24 @@ func getIntVariable(hit *api.SearchResultHit, key string) int {
 	return int(val.(float64))
 }
 
+// getMetadataVariable reads the chunk metadata, it's not part of the schema
+// so Typesense stores it without indexing it
+func getMetadataVariable(hit *api.SearchResultHit) map[string]string {
+	val, ok := (*hit.Document)["metadata"].(map[string]interface{})
+	if !ok || len(val) == 0 {
+		return nil
+	}
+
+	metadata := make(map[string]string, len(val))
+	for k, v := range val {
+		if str, ok := v.(string); ok {
+			metadata[k] = str
+		}
+	}
+
+	return metadata
+}
+
 func (t *Typesense) ensureCollection(ctx context.Context) error {
 	log.Info().Str("collection", t.collection).Msg("ensuring collection")
 
@@ -220,7 +371,7 @@ func (t *Typesense) ensureCollection(ctx context.Context) error {
 	for _, collection := range collections {
 		if collection.Name == t.collection {
 			log.Info().Str("collection", t.collection).Msg("collection already exists")
-			return nil
+			return t.ensureFilenameField(ctx, collection)
 		}
 	}
 
@@ -244,6 +395,7 @@ func (t *Typesense) ensureCollection(ctx context.Context) error {
 				Name: "source",
 				Type: "string",
 			},
+			filenameField,
 			{
 				Name: "content",
 				Type: "string",
@@ -290,3 +442,112 @@ func (t *Typesense) ensureCollection(ctx context.Context) error {
 
 	return nil
 }
+
+// filenameField is used by the filename filters, it's optional as documents
+// indexed from URLs don't have a filename
+var filenameField = api.Field{
+	Name:     "filename",
+	Type:     "string",
+	Optional: pointer.True(),
+}
+
+// ensureFilenameField adds the filename field to collections created before
+// it was part of the schema, Typesense indexes the existing documents
+func (t *Typesense) ensureFilenameField(ctx context.Context, collection *api.CollectionResponse) error {
+	for _, field := range collection.Fields {
+		if field.Name == filenameField.Name {
+			return nil
+		}
+	}
+
+	log.Info().Str("collection", t.collection).Msg("adding filename field to the collection")
+
+	_, err := t.client.Collection(t.collection).Update(ctx, &api.CollectionUpdateSchema{
+		Fields: []api.Field{filenameField},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add filename field: %w", err)
+	}
+
+	return nil
+}
+
+// Export reads the chunks of the data entity page by page
+func (t *Typesense) Export(ctx context.Context, r *types.ExportIndexRequest) ([]*types.SessionRAGIndexChunk, error) {
+	if err := t.ensureReady(ctx); err != nil {
+		return nil, err
+	}
+
+	if r.DataEntityID == "" {
+		return nil, fmt.Errorf("data entity ID cannot be empty")
+	}
+
+	params := &api.SearchCollectionParams{
+		Q:        pointer.String("*"),
+		QueryBy:  pointer.String("content"),
+		FilterBy: pointer.String("data_entity_id:" + r.DataEntityID),
+		SortBy:   pointer.String("content_offset:asc"),
+		PerPage:  pointer.Int(typesenseCopyPageSize),
+	}
+
+	if !r.IncludeEmbeddings {
+		params.ExcludeFields = pointer.String("embedding")
+	}
+
+	var chunks []*types.SessionRAGIndexChunk
+
+	for page := 1; ; page++ {
+		params.Page = pointer.Int(page)
+
+		results, err := t.client.Collection(t.collection).Documents().Search(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("error reading documents: %w", err)
+		}
+
+		if results.Hits == nil || len(*results.Hits) == 0 {
+			break
+		}
+
+		for _, hit := range *results.Hits {
+			chunks = append(chunks, &types.SessionRAGIndexChunk{
+				DataEntityID:    getStrVariable(&hit, "data_entity_id"),
+				Source:          getStrVariable(&hit, "source"),
+				Filename:        getStrVariable(&hit, "filename"),
+				DocumentID:      getStrVariable(&hit, "document_id"),
+				DocumentGroupID: getStrVariable(&hit, "document_group_id"),
+				ContentOffset:   getIntVariable(&hit, "content_offset"),
+				Content:         getStrVariable(&hit, "content"),
+				Metadata:        getMetadataVariable(&hit),
+				Embedding:       getEmbeddingVariable(&hit),
+			})
+		}
+
+		if len(*results.Hits) < typesenseCopyPageSize {
+			break
+		}
+	}
+
+	return chunks, nil
+}
+
+func (t *Typesense) EmbeddingsModel() string {
+	return defaultModelName
+}
+
+func getEmbeddingVariable(hit *api.SearchResultHit) []float32 {
+	values, ok := (*hit.Document)["embedding"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	embedding := make([]float32, 0, len(values))
+	for _, v := range values {
+		f, ok := v.(float64)
+		if !ok {
+			return nil
+		}
+		embedding = append(embedding, float32(f))
+	}
+
+	return embedding
+}
@@ -83,6 +83,7 @@ func (suite *TypesenseTestSuite) TestIndexAndQuery() {
 			Source:          "test",
 			Content:         "Natural language processing is an important field in AI.",
 			ContentOffset:   0,
+			Metadata:        map[string]string{types.RAGMetadataHeadings: "Fields > NLP"},
 		},
 	}
 
@@ -129,6 +130,10 @@ func (suite *TypesenseTestSuite) TestIndexAndQuery() {
 				resultIDs[i] = result.DocumentID
 			}
 			suite.ElementsMatch(tc.expectedIDs, resultIDs)
+
+			if tc.query.DataEntityID == "doc2" {
+				suite.Equal("Fields > NLP", results[0].Metadata[types.RAGMetadataHeadings])
+			}
 		})
 	}
 }
@@ -608,6 +608,8 @@ func (i *OllamaInferenceModelInstance) processInteraction(inferenceReq *types.Ru
 	defer cancel()
 
 	switch {
+	case inferenceReq.EmbeddingRequest != nil:
+		return i.processEmbeddings(timeoutCtx, inferenceReq)
 	case inferenceReq.Request.Stream:
 		start := time.Now()
 		err := i.client.Chat(timeoutCtx, &req, func(resp api.ChatResponse) error {
@@ -680,6 +682,87 @@ func (i *OllamaInferenceModelInstance) processInteraction(inferenceReq *types.Ru
 	}
 }
 
+// processEmbeddings embeds the inputs one by one, Ollama's embeddings API
+// takes a single prompt
+func (i *OllamaInferenceModelInstance) processEmbeddings(ctx context.Context, inferenceReq *types.RunnerLLMInferenceRequest) error {
+	inputs, err := embeddingInputs(inferenceReq.EmbeddingRequest.Input)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+
+	resp := &openai.EmbeddingResponse{
+		Object: "list",
+		Model:  inferenceReq.EmbeddingRequest.Model,
+	}
+
+	for idx, input := range inputs {
+		embeddingResp, err := i.client.Embeddings(ctx, &api.EmbeddingRequest{
+			Model:  string(inferenceReq.EmbeddingRequest.Model),
+			Prompt: input,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get embeddings from inference API: %w", err)
+		}
+
+		embedding := make([]float32, len(embeddingResp.Embedding))
+		for j, v := range embeddingResp.Embedding {
+			embedding[j] = float32(v)
+		}
+
+		resp.Data = append(resp.Data, openai.Embedding{
+			Object:    "embedding",
+			Index:     idx,
+			Embedding: embedding,
+		})
+
+		// The embeddings API doesn't report the token count, estimate
+		// it with the usual ~4 characters per token
+		resp.Usage.PromptTokens += (len(input) + 3) / 4
+	}
+
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+
+	err = i.responseHandler(&types.RunnerLLMInferenceResponse{
+		RequestID:         inferenceReq.RequestID,
+		OwnerID:           inferenceReq.OwnerID,
+		SessionID:         inferenceReq.SessionID,
+		InteractionID:     inferenceReq.InteractionID,
+		EmbeddingResponse: resp,
+		DurationMs:        time.Since(start).Milliseconds(),
+		Done:              true,
+	})
+	if err != nil {
+		log.Error().Msgf("error writing event: %s", err.Error())
+	}
+
+	return nil
+}
+
+// embeddingInputs returns the input of the embedding request as a list of
+// strings, the request is decoded from JSON so lists are []any
+func embeddingInputs(input any) ([]string, error) {
+	switch v := input.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []any:
+		inputs := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported embeddings input, only strings are supported")
+			}
+			inputs = append(inputs, str)
+		}
+		return inputs, nil
+	default:
+		return nil, fmt.Errorf("unsupported embeddings input type %T, only strings are supported", input)
+	}
+}
+
 func (i *OllamaInferenceModelInstance) responseStreamProcessor(req *types.RunnerLLMInferenceRequest, resp *openai.ChatCompletionStreamResponse, done bool, durationMs int64) {
 	if req == nil {
 		log.Error().Msgf("no current request")
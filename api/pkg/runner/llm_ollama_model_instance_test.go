@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_embeddingInputs(t *testing.T) {
+	var decoded struct {
+		Input any `json:"input"`
+	}
+
+	err := json.Unmarshal([]byte(`{"input": ["hello", "world"]}`), &decoded)
+	assert.NoError(t, err)
+
+	inputs, err := embeddingInputs(decoded.Input)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello", "world"}, inputs)
+
+	inputs, err = embeddingInputs("hello")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello"}, inputs)
+
+	err = json.Unmarshal([]byte(`{"input": [[1, 2, 3]]}`), &decoded)
+	assert.NoError(t, err)
+
+	_, err = embeddingInputs(decoded.Input)
+	assert.Error(t, err)
+}
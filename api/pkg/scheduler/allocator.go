@@ -34,6 +34,8 @@ type allocator struct {
 	slots           *xsync.MapOf[uuid.UUID, *Slot] // Maps slot ID to Slot details.
 	modelStaleFunc  TimeoutFunc                    // Function to check if models are stale
 	slotTimeoutFunc TimeoutFunc                    // Function to check if slots have timed out due to error
+	onRelease       func(*Slot)                    // Called when the work of a slot is released, optional
+	onRemove        func(*Slot)                    // Called when a slot is removed with its work, optional
 }
 
 // NewWorkloadAllocator creates a new allocator instance with timeout functions for models and runners.
@@ -84,6 +86,7 @@ func (a *allocator) AllocateSlot(slotID uuid.UUID, req *Workload) error {
 func (a *allocator) AllocateNewSlot(runnerID string, req *Workload) (*Slot, error) {
 	// Create a new slot and schedule the workload.
 	slot := NewSlot(runnerID, req, a.modelStaleFunc, a.slotTimeoutFunc)
+	slot.onRelease = a.onRelease
 	log.Trace().
 		Str("runner_id", slot.RunnerID).
 		Str("slot_id", slot.ID.String()).
@@ -201,7 +204,7 @@ func (a *allocator) ReconcileSlots(props *types.RunnerState) error {
 			Bool("is_stale", s.IsStale()).
 			Bool("is_scheduling", s.IsScheduled()).
 			Msg("deleting slot")
-		a.slots.Delete(id)
+		a.removeSlot(s)
 	}
 
 	// Warn if the runner's state doesn't match the allocator's records.
@@ -286,7 +289,7 @@ func (a *allocator) DeadSlots(deadRunnerIDs []string) []*Slot {
 		slots := make([]*Slot, 0, a.slots.Size())
 		runnerSlots := a.RunnerSlots(runnerID)
 		for _, slot := range runnerSlots {
-			a.slots.Delete(slot.ID)
+			a.removeSlot(slot)
 			slots = append(slots, slot)
 		}
 
@@ -322,5 +325,18 @@ func (a *allocator) StartSlot(slotID uuid.UUID) error {
 }
 
 func (a *allocator) DeleteSlot(slotID uuid.UUID) {
-	a.slots.Delete(slotID)
+	slot, ok := a.slots.Load(slotID)
+	if !ok {
+		return
+	}
+	a.removeSlot(slot)
+}
+
+// removeSlot deletes the slot and notifies the removal of its work
+func (a *allocator) removeSlot(slot *Slot) {
+	a.slots.Delete(slot.ID)
+
+	if a.onRemove != nil && (slot.IsActive() || slot.IsScheduled()) {
+		a.onRemove(slot)
+	}
 }
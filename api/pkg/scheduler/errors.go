@@ -11,6 +11,8 @@ var (
 	ErrRunnersAreFull     = errors.New("runners are full")
 	ErrNoRunnersAvailable = errors.New("no runners available")
 	ErrModelWontFit       = errors.New("model won't fit in any runner")
+
+	ErrWorkloadInterrupted = errors.New("workload was interrupted by an API restart")
 )
 
 // ErrorHandlingStrategy is a function that handles errors returned by the scheduler.
@@ -0,0 +1,242 @@
+package scheduler
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// waitAverageWeight is the weight of the latest wait in the moving average
+// of the wait times of a tenant
+const waitAverageWeight = 0.2
+
+// flow is the queued work of a tenant in a priority class
+type flow struct {
+	tenant string
+	class  types.SchedulerPriorityClass
+}
+
+// fairShare orders the queue with start-time fair queueing: each workload is
+// tagged with the virtual time at which its flow would finish it if the
+// runners were shared by the weights of the flows. A tenant queueing a lot of
+// work only pushes back its own work, the work of the other tenants is tagged
+// from the current virtual time.
+type fairShare struct {
+	classWeights           map[types.SchedulerPriorityClass]float64
+	tenantWeights          map[string]float64
+	maxConcurrency         int
+	maxConcurrencyByTenant map[string]int
+	maxQueued              int
+
+	mu          sync.Mutex
+	virtualTime float64            // Start tag of the last scheduled workload
+	finishTags  map[flow]float64   // Finish tag of the last queued workload by flow
+	active      map[string]string  // Tenants of the scheduled and running workloads by ID
+	waits       map[string]float64 // Moving average of the wait times by tenant, in seconds
+	queued      map[string]int     // Queued workloads by tenant
+}
+
+func newFairShare(cfg *config.Helix) *fairShare {
+	return &fairShare{
+		classWeights:           cfg.PriorityClassWeights,
+		tenantWeights:          cfg.TenantWeights,
+		maxConcurrency:         cfg.TenantMaxConcurrency,
+		maxConcurrencyByTenant: cfg.TenantMaxConcurrencyOverrides,
+		maxQueued:              cfg.TenantQueueSize,
+		finishTags:             make(map[flow]float64),
+		active:                 make(map[string]string),
+		waits:                  make(map[string]float64),
+		queued:                 make(map[string]int),
+	}
+}
+
+func (f *fairShare) tenantWeight(tenant string) float64 {
+	if w, ok := f.tenantWeights[tenant]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+func (f *fairShare) classWeight(class types.SchedulerPriorityClass) float64 {
+	if w, ok := f.classWeights[class]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+func (f *fairShare) tenantMaxConcurrency(tenant string) int {
+	if limit, ok := f.maxConcurrencyByTenant[tenant]; ok {
+		return limit
+	}
+	return f.maxConcurrency
+}
+
+// canQueue checks the tenant didn't reach its queue size
+func (f *fairShare) canQueue(work *Workload) bool {
+	if f.maxQueued <= 0 {
+		return true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.queued[work.Tenant()] < f.maxQueued
+}
+
+// queue tags the work to order it in the queue
+func (f *fairShare) queue(work *Workload) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := flow{tenant: work.Tenant(), class: work.PriorityClass()}
+
+	work.startTag = math.Max(f.virtualTime, f.finishTags[key])
+	work.finishTag = work.startTag + 1/(f.tenantWeight(key.tenant)*f.classWeight(key.class))
+	if work.enqueued.IsZero() {
+		work.enqueued = time.Now()
+	}
+
+	f.finishTags[key] = work.finishTag
+	f.queued[key.tenant]++
+}
+
+// unqueue removes work that failed to schedule
+func (f *fairShare) unqueue(work *Workload) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.decrementQueued(work.Tenant())
+}
+
+// canSchedule checks the tenant didn't reach its concurrency limit
+func (f *fairShare) canSchedule(work *Workload) bool {
+	limit := f.tenantMaxConcurrency(work.Tenant())
+	if limit <= 0 {
+		return true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.activeCount(work.Tenant()) < limit
+}
+
+// scheduled moves the work from the queue to the active work of its tenant
+func (f *fairShare) scheduled(work *Workload) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tenant := work.Tenant()
+
+	f.decrementQueued(tenant)
+	f.active[work.ID()] = tenant
+
+	wait := time.Since(work.enqueued).Seconds()
+	if average, ok := f.waits[tenant]; ok {
+		f.waits[tenant] = average + waitAverageWeight*(wait-average)
+	} else {
+		f.waits[tenant] = wait
+	}
+
+	if work.startTag > f.virtualTime {
+		f.virtualTime = work.startTag
+
+		// The flows without backlog start over from the virtual time
+		for key, finishTag := range f.finishTags {
+			if finishTag <= f.virtualTime {
+				delete(f.finishTags, key)
+			}
+		}
+	}
+}
+
+// rescheduled keeps the work of a dead runner active for its tenant
+func (f *fairShare) rescheduled(work *Workload) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.active[work.ID()] = work.Tenant()
+}
+
+// released removes the work from the active work of its tenant
+func (f *fairShare) released(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tenant, ok := f.active[id]
+	if !ok {
+		return
+	}
+
+	delete(f.active, id)
+
+	if f.queued[tenant] == 0 && f.activeCount(tenant) == 0 {
+		delete(f.waits, tenant)
+	}
+}
+
+func (f *fairShare) decrementQueued(tenant string) {
+	f.queued[tenant]--
+	if f.queued[tenant] <= 0 {
+		delete(f.queued, tenant)
+	}
+}
+
+func (f *fairShare) activeCount(tenant string) int {
+	var count int
+	for _, t := range f.active {
+		if t == tenant {
+			count++
+		}
+	}
+	return count
+}
+
+// tenantQueues returns the queues of the tenants with queued or active work
+func (f *fairShare) tenantQueues(queue []*Workload) []*types.SchedulerTenantQueue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tenants := make(map[string]*types.SchedulerTenantQueue)
+
+	getTenant := func(tenant string) *types.SchedulerTenantQueue {
+		t, ok := tenants[tenant]
+		if !ok {
+			t = &types.SchedulerTenantQueue{
+				Tenant:             tenant,
+				Weight:             f.tenantWeight(tenant),
+				QueuedByClass:      make(map[types.SchedulerPriorityClass]int),
+				MaxConcurrency:     f.tenantMaxConcurrency(tenant),
+				AverageWaitSeconds: f.waits[tenant],
+			}
+			tenants[tenant] = t
+		}
+		return t
+	}
+
+	for _, work := range queue {
+		t := getTenant(work.Tenant())
+		t.Queued++
+		t.QueuedByClass[work.PriorityClass()]++
+		t.OldestWaitSeconds = math.Max(t.OldestWaitSeconds, time.Since(work.enqueued).Seconds())
+	}
+
+	for _, tenant := range f.active {
+		getTenant(tenant).Active++
+	}
+
+	result := make([]*types.SchedulerTenantQueue, 0, len(tenants))
+	for _, t := range tenants {
+		result = append(result, t)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Tenant < result[j].Tenant
+	})
+
+	return result
+}
@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/model"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_FairShare_Order(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+
+	// A heavy tenant queues first
+	for _, id := range []string{"a-1", "a-2", "a-3"} {
+		require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, id, "tenant-a", ""))
+	}
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "b-1", "tenant-b", ""))
+	require.NoError(t, enqueueTestSession(scheduler, "priority", model.Model_Ollama_Llama3_8b, "", true))
+
+	assert.Equal(t, []string{"priority", "a-1", "b-1", "a-2", "a-3"}, queueIDs(scheduler))
+}
+
+func TestScheduler_FairShare_Weights(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	config.Providers.Helix.TenantWeights = map[string]float64{"tenant-a": 2}
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+
+	for _, id := range []string{"a-1", "a-2", "a-3", "a-4"} {
+		require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, id, "tenant-a", ""))
+	}
+	for _, id := range []string{"b-1", "b-2"} {
+		require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, id, "tenant-b", ""))
+	}
+
+	// Tenant A gets twice the share of tenant B
+	assert.Equal(t, []string{"a-1", "a-2", "b-1", "a-3", "a-4", "b-2"}, queueIDs(scheduler))
+}
+
+func TestScheduler_FairShare_PriorityClasses(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	config.Providers.Helix.PriorityClassWeights = map[types.SchedulerPriorityClass]float64{
+		types.SchedulerPriorityClassInteractive: 4,
+		types.SchedulerPriorityClassBatch:       1,
+	}
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+
+	for _, id := range []string{"batch-1", "batch-2"} {
+		require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, id, "tenant-a", types.SchedulerPriorityClassBatch))
+	}
+	for _, id := range []string{"chat-1", "chat-2"} {
+		require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, id, "tenant-a", types.SchedulerPriorityClassInteractive))
+	}
+
+	assert.Equal(t, []string{"chat-1", "chat-2", "batch-1", "batch-2"}, queueIDs(scheduler))
+}
+
+func TestScheduler_FairShare_MaxConcurrency(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	config.Providers.Helix.TenantMaxConcurrency = 1
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+
+	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
+	scheduler.UpdateRunner(&types.RunnerState{
+		ID:          "test-runner",
+		TotalMemory: m.GetMemoryRequirements(types.SessionModeInference) * 3,
+	})
+
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "a-1", "tenant-a", ""))
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "a-2", "tenant-a", ""))
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "b-1", "tenant-b", ""))
+
+	processTestQueue(scheduler)
+	assert.Equal(t, []string{"a-2"}, queueIDs(scheduler))
+
+	require.NoError(t, scheduler.Release("a-1"))
+
+	processTestQueue(scheduler)
+	assert.Empty(t, queueIDs(scheduler))
+}
+
+func TestScheduler_FairShare_MaxConcurrency_StaleSlot(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	config.Providers.Helix.TenantMaxConcurrency = 1
+	config.Providers.Helix.SlotTTL = time.Nanosecond
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+
+	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
+	scheduler.UpdateRunner(&types.RunnerState{
+		ID:          "test-runner",
+		TotalMemory: m.GetMemoryRequirements(types.SessionModeInference) * 3,
+	})
+
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "a-1", "tenant-a", ""))
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "a-2", "tenant-a", ""))
+
+	processTestQueue(scheduler)
+	assert.Equal(t, []string{"a-2"}, queueIDs(scheduler))
+
+	// The runner never picks a-1 up, the timed out slot frees the tenant
+	slots := scheduler.allocator.RunnerSlots("test-runner")
+	require.Len(t, slots, 1)
+	assert.True(t, slots[0].IsStale())
+
+	processTestQueue(scheduler)
+	assert.Empty(t, queueIDs(scheduler))
+}
+
+func TestScheduler_FairShare_TenantQueueSize(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	config.Providers.Helix.TenantQueueSize = 1
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "a-1", "tenant-a", ""))
+	assert.ErrorContains(t, enqueueTestTenantLLMWorkload(scheduler, "a-2", "tenant-a", ""), "queue is full for tenant-a")
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "b-1", "tenant-b", ""))
+}
+
+func TestScheduler_DashboardTenantsData(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	config.Providers.Helix.TenantMaxConcurrencyOverrides = map[string]int{"tenant-b": 5}
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+
+	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
+	scheduler.UpdateRunner(&types.RunnerState{
+		ID:          "test-runner",
+		TotalMemory: m.GetMemoryRequirements(types.SessionModeInference),
+	})
+
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "a-1", "tenant-a", ""))
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "a-2", "tenant-a", types.SchedulerPriorityClassBatch))
+	require.NoError(t, enqueueTestTenantLLMWorkload(scheduler, "b-1", "tenant-b", ""))
+
+	// The runner only fits a-1
+	processTestQueue(scheduler)
+
+	tenants := scheduler.DashboardTenantsData()
+	require.Len(t, tenants, 2)
+
+	assert.Equal(t, "tenant-a", tenants[0].Tenant)
+	assert.Equal(t, 1, tenants[0].Queued)
+	assert.Equal(t, map[types.SchedulerPriorityClass]int{types.SchedulerPriorityClassBatch: 1}, tenants[0].QueuedByClass)
+	assert.Equal(t, 1, tenants[0].Active)
+	assert.Greater(t, tenants[0].OldestWaitSeconds, 0.0)
+
+	assert.Equal(t, "tenant-b", tenants[1].Tenant)
+	assert.Equal(t, 1, tenants[1].Queued)
+	assert.Equal(t, 0, tenants[1].Active)
+	assert.Equal(t, 5, tenants[1].MaxConcurrency)
+
+	// The tenants without work are no longer listed, b-1 is scheduled
+	// before a-2 as tenant A already had its share
+	require.NoError(t, scheduler.Release("a-1"))
+	processTestQueue(scheduler)
+	require.NoError(t, scheduler.Release("b-1"))
+	processTestQueue(scheduler)
+	require.NoError(t, scheduler.Release("a-2"))
+
+	assert.Empty(t, scheduler.DashboardTenantsData())
+}
+
+func enqueueTestTenantLLMWorkload(scheduler Scheduler, name, owner string, class types.SchedulerPriorityClass) error {
+	work, err := NewLLMWorkload(&types.RunnerLLMInferenceRequest{
+		RequestID:     name,
+		OwnerID:       owner,
+		PriorityClass: class,
+		Request: &openai.ChatCompletionRequest{
+			Model: model.Model_Ollama_Llama3_8b,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return scheduler.Enqueue(work)
+}
+
+func processTestQueue(scheduler *scheduler) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	scheduler.processQueue(ctx)
+}
+
+func queueIDs(scheduler *scheduler) []string {
+	scheduler.queueMtx.Lock()
+	defer scheduler.queueMtx.Unlock()
+
+	ids := make([]string, 0, len(scheduler.queue))
+	for _, w := range scheduler.queue {
+		ids = append(ids, w.ID())
+	}
+	return ids
+}
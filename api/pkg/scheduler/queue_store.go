@@ -0,0 +1,221 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// QueueStore persists the workloads until they are released so that they
+// can be rescheduled after an API restart. It is implemented by store.Store.
+type QueueStore interface {
+	SaveSchedulerWorkload(ctx context.Context, workload *types.SchedulerWorkload) error
+	UpdateSchedulerWorkloadState(ctx context.Context, id string, state types.SchedulerWorkloadState) error
+	ListSchedulerWorkloads(ctx context.Context, instance string) ([]*types.SchedulerWorkload, error)
+	DeleteSchedulerWorkload(ctx context.Context, id string) error
+	HeartbeatSchedulerWorkloads(ctx context.Context, instance string) error
+	ClaimSchedulerWorkloads(ctx context.Context, instance string, expiredBefore time.Time) ([]*types.SchedulerWorkload, error)
+	DeleteExpiredSchedulerWorkloads(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+const (
+	// workloadHeartbeatInterval is how often an instance renews the lease on
+	// its persisted workloads
+	workloadHeartbeatInterval = 30 * time.Second
+	// workloadLeaseTimeout is how long the workloads of an instance that
+	// stopped heartbeating are kept before other instances take them over
+	workloadLeaseTimeout = 2 * time.Minute
+	// workloadRetention is how long the workloads nobody took over are kept
+	workloadRetention = 24 * time.Hour
+)
+
+// saveWorkload persists a newly queued session. LLM inference requests are
+// not persisted, their callers wait for the response and are gone after a
+// restart.
+func (s *scheduler) saveWorkload(work *Workload) error {
+	if s.queueStore == nil || work.WorkloadType != WorkloadTypeSession {
+		return nil
+	}
+
+	err := s.queueStore.SaveSchedulerWorkload(context.Background(), &types.SchedulerWorkload{
+		ID:       work.ID(),
+		Instance: s.instance,
+		State:    types.SchedulerWorkloadStateQueued,
+		Workload: work.ToRunnerWorkload(),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.workloadStates.Store(work.ID(), types.SchedulerWorkloadStateQueued)
+
+	return nil
+}
+
+// setWorkloadState persists the state of a workload when it changes, the
+// work carries on if it can't be saved
+func (s *scheduler) setWorkloadState(id string, state types.SchedulerWorkloadState) {
+	if s.queueStore == nil {
+		return
+	}
+
+	current, ok := s.workloadStates.Load(id)
+	if !ok || current == state {
+		return
+	}
+
+	err := s.queueStore.UpdateSchedulerWorkloadState(context.Background(), id, state)
+	if err != nil {
+		log.Warn().Err(err).Str("id", id).Str("state", string(state)).Msg("error saving workload state")
+		return
+	}
+
+	s.workloadStates.Store(id, state)
+}
+
+// slotReleased deletes the workload of the slot when the runner finished it
+// or the slot timed out
+func (s *scheduler) slotReleased(slot *Slot) {
+	work, ok := s.workStore.LoadAndDelete(slot.ID)
+	if !ok {
+		return
+	}
+
+	s.fairShare.released(work.ID())
+	s.deleteWorkload(work.ID())
+}
+
+// slotRemoved frees the tenant concurrency of the work of a removed slot.
+// The work stays persisted, the work of dead runners is rescheduled.
+func (s *scheduler) slotRemoved(slot *Slot) {
+	work, ok := s.workStore.Load(slot.ID)
+	if !ok {
+		return
+	}
+
+	s.fairShare.released(work.ID())
+}
+
+// deleteWorkload removes a released workload
+func (s *scheduler) deleteWorkload(id string) {
+	if s.queueStore == nil {
+		return
+	}
+
+	if _, ok := s.workloadStates.LoadAndDelete(id); !ok {
+		return
+	}
+
+	err := s.queueStore.DeleteSchedulerWorkload(context.Background(), id)
+	if err != nil {
+		log.Warn().Err(err).Str("id", id).Msg("error deleting workload")
+	}
+}
+
+// restoreQueue queues the sessions this instance didn't release before it
+// restarted, along with the sessions of instances that stopped heartbeating,
+// e.g. the pods replaced by a deployment. The slots were lost with the
+// restart and the runners stop the work of the slots they are no longer
+// given, so scheduled and running work is scheduled again.
+func (s *scheduler) restoreQueue(ctx context.Context) error {
+	if s.queueStore == nil {
+		return nil
+	}
+
+	workloads, err := s.queueStore.ListSchedulerWorkloads(ctx, s.instance)
+	if err != nil {
+		return fmt.Errorf("error listing persisted workloads: %w", err)
+	}
+
+	claimed, err := s.queueStore.ClaimSchedulerWorkloads(ctx, s.instance, time.Now().Add(-workloadLeaseTimeout))
+	if err != nil {
+		return fmt.Errorf("error claiming expired workloads: %w", err)
+	}
+
+	s.restoreWorkloads(append(workloads, claimed...))
+
+	return nil
+}
+
+// maintainQueueStore keeps the lease on the persisted workloads of this
+// instance, takes over the workloads of instances that stopped and deletes
+// the workloads nobody took over.
+func (s *scheduler) maintainQueueStore(ctx context.Context) {
+	ticker := time.NewTicker(workloadHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.heartbeatQueueStore(ctx)
+		}
+	}
+}
+
+func (s *scheduler) heartbeatQueueStore(ctx context.Context) {
+	err := s.queueStore.HeartbeatSchedulerWorkloads(ctx, s.instance)
+	if err != nil {
+		log.Warn().Err(err).Msg("error heartbeating persisted workloads")
+	}
+
+	deleted, err := s.queueStore.DeleteExpiredSchedulerWorkloads(ctx, time.Now().Add(-workloadRetention))
+	if err != nil {
+		log.Warn().Err(err).Msg("error deleting abandoned workloads")
+	} else if deleted > 0 {
+		log.Info().Int64("workloads", deleted).Msg("deleted abandoned workloads")
+	}
+
+	claimed, err := s.queueStore.ClaimSchedulerWorkloads(ctx, s.instance, time.Now().Add(-workloadLeaseTimeout))
+	if err != nil {
+		log.Warn().Err(err).Msg("error claiming expired workloads")
+		return
+	}
+
+	s.restoreWorkloads(claimed)
+}
+
+// restoreWorkloads queues persisted sessions owned by this instance
+func (s *scheduler) restoreWorkloads(workloads []*types.SchedulerWorkload) {
+	if len(workloads) == 0 {
+		return
+	}
+
+	s.queueMtx.Lock()
+	defer s.queueMtx.Unlock()
+
+	var restored int
+
+	for _, persisted := range workloads {
+		s.workloadStates.Store(persisted.ID, persisted.State)
+
+		work, err := newWorkload(persisted.Workload)
+		if err != nil {
+			log.Warn().Err(err).Str("id", persisted.ID).Msg("dropping persisted workload")
+			s.deleteWorkload(persisted.ID)
+			continue
+		}
+
+		// Persisted by older versions, nobody is waiting for the response
+		if work.WorkloadType != WorkloadTypeSession {
+			s.deleteWorkload(persisted.ID)
+			continue
+		}
+
+		s.setWorkloadState(persisted.ID, types.SchedulerWorkloadStateQueued)
+
+		// Restored even if the queue size was lowered since
+		s.push(work)
+		restored++
+	}
+
+	// The runners of a restarted or stopped instance are given until they
+	// would be considered dead to reconnect
+	s.runnersReconnectDeadline = time.Now().Add(s.runnerTTL)
+
+	log.Info().Int("workloads", restored).Msg("restored scheduler queue")
+}
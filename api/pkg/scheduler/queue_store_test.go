@@ -0,0 +1,371 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/model"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryQueueStore keeps the persisted workloads across scheduler instances
+type memoryQueueStore struct {
+	mu        sync.Mutex
+	workloads []*types.SchedulerWorkload
+}
+
+func (m *memoryQueueStore) SaveSchedulerWorkload(_ context.Context, workload *types.SchedulerWorkload) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workload.Heartbeat = time.Now()
+	for i, w := range m.workloads {
+		if w.ID == workload.ID {
+			m.workloads[i] = workload
+			return nil
+		}
+	}
+	m.workloads = append(m.workloads, workload)
+	return nil
+}
+
+func (m *memoryQueueStore) UpdateSchedulerWorkloadState(_ context.Context, id string, state types.SchedulerWorkloadState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, w := range m.workloads {
+		if w.ID == id {
+			w.State = state
+		}
+	}
+	return nil
+}
+
+func (m *memoryQueueStore) ListSchedulerWorkloads(_ context.Context, instance string) ([]*types.SchedulerWorkload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workloads := make([]*types.SchedulerWorkload, 0, len(m.workloads))
+	for _, w := range m.workloads {
+		if w.Instance != instance {
+			continue
+		}
+		copied := *w
+		workloads = append(workloads, &copied)
+	}
+	return workloads, nil
+}
+
+func (m *memoryQueueStore) DeleteSchedulerWorkload(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.workloads = Filter(m.workloads, func(w *types.SchedulerWorkload) bool {
+		return w.ID != id
+	})
+	return nil
+}
+
+func (m *memoryQueueStore) HeartbeatSchedulerWorkloads(_ context.Context, instance string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, w := range m.workloads {
+		if w.Instance == instance {
+			w.Heartbeat = time.Now()
+		}
+	}
+	return nil
+}
+
+func (m *memoryQueueStore) ClaimSchedulerWorkloads(_ context.Context, instance string, expiredBefore time.Time) ([]*types.SchedulerWorkload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var claimed []*types.SchedulerWorkload
+	for _, w := range m.workloads {
+		if w.Instance == instance || !w.Heartbeat.Before(expiredBefore) {
+			continue
+		}
+		w.Instance = instance
+		w.Heartbeat = time.Now()
+		copied := *w
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (m *memoryQueueStore) DeleteExpiredSchedulerWorkloads(_ context.Context, expiredBefore time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before := len(m.workloads)
+	m.workloads = Filter(m.workloads, func(w *types.SchedulerWorkload) bool {
+		return !w.Heartbeat.Before(expiredBefore)
+	})
+	return int64(before - len(m.workloads)), nil
+}
+
+func (m *memoryQueueStore) instances() map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	instances := make(map[string]string, len(m.workloads))
+	for _, w := range m.workloads {
+		instances[w.ID] = w.Instance
+	}
+	return instances
+}
+
+func (m *memoryQueueStore) states() map[string]types.SchedulerWorkloadState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states := make(map[string]types.SchedulerWorkloadState, len(m.workloads))
+	for _, w := range m.workloads {
+		states[w.ID] = w.State
+	}
+	return states
+}
+
+func testLLMRunnerWorkload(id string) *types.RunnerWorkload {
+	return &types.RunnerWorkload{
+		LLMInferenceRequest: &types.RunnerLLMInferenceRequest{
+			RequestID: id,
+			Request: &openai.ChatCompletionRequest{
+				Model: model.Model_Ollama_Llama3_8b,
+			},
+		},
+	}
+}
+
+func testSessionRunnerWorkload(id string) *types.RunnerWorkload {
+	return &types.RunnerWorkload{
+		Session: &types.Session{
+			ID:        id,
+			ModelName: model.Model_Ollama_Llama3_8b,
+			Mode:      types.SessionModeInference,
+		},
+	}
+}
+
+func TestScheduler_QueueStore_Lifecycle(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	queueStore := &memoryQueueStore{}
+
+	// Without a queue processor so the states can be checked in between
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+	scheduler.queueStore = queueStore
+
+	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
+	scheduler.UpdateRunner(&types.RunnerState{
+		ID:          "test-runner",
+		TotalMemory: m.GetMemoryRequirements(types.SessionModeInference) * 2,
+	})
+
+	// LLM inference requests aren't persisted, their callers wait for them
+	err := enqueueTestLLMWorkload(scheduler, "request-1", model.Model_Ollama_Llama3_8b)
+	require.NoError(t, err)
+	assert.Empty(t, queueStore.states())
+
+	err = enqueueTestSession(scheduler, "session-1", model.Model_Ollama_Llama3_8b, "", false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.SchedulerWorkloadState{"session-1": types.SchedulerWorkloadStateQueued}, queueStore.states())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	scheduler.processQueue(ctx)
+	assert.Equal(t, map[string]types.SchedulerWorkloadState{"session-1": types.SchedulerWorkloadStateScheduled}, queueStore.states())
+
+	err = scheduler.Begin("session-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.SchedulerWorkloadState{"session-1": types.SchedulerWorkloadStateRunning}, queueStore.states())
+
+	err = scheduler.Release("session-1")
+	require.NoError(t, err)
+	assert.Empty(t, queueStore.states())
+}
+
+func TestScheduler_QueueStore_Restore(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	queueStore := &memoryQueueStore{
+		workloads: []*types.SchedulerWorkload{
+			{ID: "queued", Instance: "test-instance", State: types.SchedulerWorkloadStateQueued, Workload: testSessionRunnerWorkload("queued")},
+			{ID: "scheduled", Instance: "test-instance", State: types.SchedulerWorkloadStateScheduled, Workload: testSessionRunnerWorkload("scheduled")},
+			{ID: "running", Instance: "test-instance", State: types.SchedulerWorkloadStateRunning, Workload: testSessionRunnerWorkload("running")},
+			{ID: "request", Instance: "test-instance", State: types.SchedulerWorkloadStateQueued, Workload: testLLMRunnerWorkload("request")},
+			{ID: "other-instance", Instance: "other-instance", Heartbeat: time.Now(), State: types.SchedulerWorkloadStateQueued, Workload: testSessionRunnerWorkload("other-instance")},
+			{ID: "unknown-model", Instance: "test-instance", State: types.SchedulerWorkloadStateQueued, Workload: &types.RunnerWorkload{
+				Session: &types.Session{
+					ID:        "unknown-model",
+					ModelName: "unknown",
+					Mode:      types.SessionModeInference,
+				},
+			}},
+		},
+	}
+
+	var failed []string
+	errorFunc := func(work *Workload, _ error) {
+		failed = append(failed, work.ID())
+	}
+
+	config.Providers.Helix.SchedulerInstance = "test-instance"
+	scheduler := newSchedulerWithoutQueue(&config, errorFunc)
+	scheduler.queueStore = queueStore
+
+	err := scheduler.restoreQueue(context.Background())
+	require.NoError(t, err)
+
+	// Nobody waits for the LLM inference request, it is dropped without an error
+	assert.Empty(t, failed)
+
+	queued := make([]string, 0, len(scheduler.queue))
+	for _, w := range scheduler.queue {
+		queued = append(queued, w.ID())
+	}
+	assert.Equal(t, []string{"queued", "scheduled", "running"}, queued)
+
+	// The other instance is alive and restores its own workloads
+	assert.Equal(t, map[string]types.SchedulerWorkloadState{
+		"queued":         types.SchedulerWorkloadStateQueued,
+		"scheduled":      types.SchedulerWorkloadStateQueued,
+		"running":        types.SchedulerWorkloadStateQueued,
+		"other-instance": types.SchedulerWorkloadStateQueued,
+	}, queueStore.states())
+}
+
+func TestScheduler_QueueStore_RestoreWaitsForRunners(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	config.Providers.Helix.SchedulerInstance = "test-instance"
+	queueStore := &memoryQueueStore{
+		workloads: []*types.SchedulerWorkload{
+			{ID: "queued", Instance: "test-instance", State: types.SchedulerWorkloadStateQueued, Workload: testSessionRunnerWorkload("queued")},
+		},
+	}
+
+	hasErr := false
+	errorFunc := func(*Workload, error) {
+		hasErr = true
+	}
+
+	scheduler := newSchedulerWithoutQueue(&config, errorFunc)
+	scheduler.queueStore = queueStore
+
+	err := scheduler.restoreQueue(context.Background())
+	require.NoError(t, err)
+
+	// No runners yet, the work stays queued instead of failing
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	scheduler.processQueue(ctx)
+	assert.Len(t, scheduler.queue, 1)
+	assert.False(t, hasErr)
+
+	// The runner reconnects
+	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
+	scheduler.UpdateRunner(&types.RunnerState{
+		ID:          "test-runner",
+		TotalMemory: m.GetMemoryRequirements(types.SessionModeInference) * 2,
+	})
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	scheduler.processQueue(ctx)
+	assert.Len(t, scheduler.queue, 0)
+	assert.False(t, hasErr)
+	assert.Equal(t, map[string]types.SchedulerWorkloadState{"queued": types.SchedulerWorkloadStateScheduled}, queueStore.states())
+}
+
+func TestScheduler_QueueStore_StaleSlot(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	config.Providers.Helix.SlotTTL = time.Nanosecond
+	queueStore := &memoryQueueStore{}
+
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+	scheduler.queueStore = queueStore
+
+	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
+	scheduler.UpdateRunner(&types.RunnerState{
+		ID:          "test-runner",
+		TotalMemory: m.GetMemoryRequirements(types.SessionModeInference) * 2,
+	})
+
+	err := enqueueTestSession(scheduler, "session-1", model.Model_Ollama_Llama3_8b, "", false)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	scheduler.processQueue(ctx)
+	assert.Equal(t, map[string]types.SchedulerWorkloadState{"session-1": types.SchedulerWorkloadStateScheduled}, queueStore.states())
+
+	// The runner never picks the work up, the slot times out and releases it
+	slots := scheduler.allocator.RunnerSlots("test-runner")
+	require.Len(t, slots, 1)
+	assert.True(t, slots[0].IsStale())
+	assert.Empty(t, queueStore.states())
+}
+
+func TestScheduler_QueueStore_RestoreOtherInstance(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	queueStore := &memoryQueueStore{
+		workloads: []*types.SchedulerWorkload{
+			{ID: "stopped", Instance: "old-instance", Heartbeat: time.Now().Add(-time.Hour), State: types.SchedulerWorkloadStateRunning, Workload: testSessionRunnerWorkload("stopped")},
+			{ID: "alive", Instance: "other-instance", Heartbeat: time.Now(), State: types.SchedulerWorkloadStateQueued, Workload: testSessionRunnerWorkload("alive")},
+		},
+	}
+
+	// Restarted under a new name, e.g. a new pod of a deployment
+	config.Providers.Helix.SchedulerInstance = "new-instance"
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+	scheduler.queueStore = queueStore
+
+	err := scheduler.restoreQueue(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, scheduler.queue, 1)
+	assert.Equal(t, "stopped", scheduler.queue[0].ID())
+	assert.Equal(t, map[string]string{
+		"stopped": "new-instance",
+		"alive":   "other-instance",
+	}, queueStore.instances())
+	assert.Equal(t, types.SchedulerWorkloadStateQueued, queueStore.states()["stopped"])
+
+	// The other instance stops later on, its workload is taken over by the
+	// next heartbeat
+	queueStore.workloads[1].Heartbeat = time.Now().Add(-time.Hour)
+	scheduler.heartbeatQueueStore(context.Background())
+
+	assert.Len(t, scheduler.queue, 2)
+	assert.Equal(t, map[string]string{
+		"stopped": "new-instance",
+		"alive":   "new-instance",
+	}, queueStore.instances())
+}
+
+func TestScheduler_QueueStore_DeleteAbandoned(t *testing.T) {
+	config, _ := config.LoadServerConfig()
+	config.Providers.Helix.SchedulerInstance = "test-instance"
+	queueStore := &memoryQueueStore{
+		workloads: []*types.SchedulerWorkload{
+			{ID: "abandoned", Instance: "old-instance", Heartbeat: time.Now().Add(-workloadRetention - time.Hour), State: types.SchedulerWorkloadStateQueued, Workload: testSessionRunnerWorkload("abandoned")},
+			{ID: "own", Instance: "test-instance", Heartbeat: time.Now().Add(-workloadRetention - time.Hour), State: types.SchedulerWorkloadStateQueued, Workload: testSessionRunnerWorkload("own")},
+		},
+	}
+
+	scheduler := newSchedulerWithoutQueue(&config, nil)
+	scheduler.queueStore = queueStore
+
+	// The lease of the own workloads is renewed before the abandoned ones
+	// are deleted
+	scheduler.heartbeatQueueStore(context.Background())
+
+	assert.Equal(t, map[string]string{"own": "test-instance"}, queueStore.instances())
+	assert.Empty(t, scheduler.queue)
+}
@@ -2,8 +2,11 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -31,6 +34,7 @@ type Scheduler interface {
 	Enqueue(work *Workload) error
 	DashboardData() ([]*types.SessionSummary, error)
 	DashboardSlotsData() []types.DesiredSlots
+	DashboardTenantsData() []*types.SchedulerTenantQueue
 }
 
 // scheduler is a struct implementing the Scheduler interface.
@@ -44,20 +48,41 @@ type scheduler struct {
 	queueMtx          *sync.Mutex
 	queueSize         int
 	onSchedulingErr   func(work *Workload, err error)
+	fairShare         *fairShare                                         // Orders the queue and limits the work by tenant.
+	queueStore        QueueStore                                         // Persists the queue across restarts, optional.
+	instance          string                                             // Owner of the persisted workloads.
+	workloadStates    *xsync.MapOf[string, types.SchedulerWorkloadState] // Persisted state of the workloads by ID.
+	runnerTTL         time.Duration
+	// Runners can't be available before they reconnect after a restart,
+	// restored work waits for them until this deadline
+	runnersReconnectDeadline time.Time
 }
 
 var _ Scheduler = &scheduler{}
 
 // NewScheduler creates a new scheduler with a workload allocator.
+// The work persisted in the queue store before a restart, or by instances that
+// stopped, is queued again, the queue store can be nil to keep the queue in
+// memory only.
 // This also starts a goroutine to process the queue in the background.
-func NewScheduler(ctx context.Context, cfg *config.ServerConfig, onSchedulingErr func(work *Workload, err error)) *scheduler {
+func NewScheduler(ctx context.Context, cfg *config.ServerConfig, queueStore QueueStore, onSchedulingErr func(work *Workload, err error)) *scheduler {
 	scheduler := newSchedulerWithoutQueue(cfg, onSchedulingErr)
+	scheduler.queueStore = queueStore
+
+	err := scheduler.restoreQueue(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("error restoring the scheduler queue")
+	}
 
 	// Start a goroutine to process the buffered queue
 	go func() {
 		scheduler.processQueue(ctx)
 	}()
 
+	if queueStore != nil {
+		go scheduler.maintainQueueStore(ctx)
+	}
+
 	return scheduler
 }
 
@@ -79,6 +104,11 @@ func newSchedulerWithoutQueue(cfg *config.ServerConfig, onSchedulingErr func(wor
 		NewTimeoutFunc(cfg.Providers.Helix.RunnerTTL),
 	)
 
+	instance := cfg.Providers.Helix.SchedulerInstance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
 	queueSize := 100
 	if cfg.Providers.Helix.QueueSize > 0 {
 		queueSize = cfg.Providers.Helix.QueueSize
@@ -109,8 +139,15 @@ func newSchedulerWithoutQueue(cfg *config.ServerConfig, onSchedulingErr func(wor
 		queueMtx:          &sync.Mutex{},
 		queueSize:         queueSize,
 		onSchedulingErr:   onSchedulingErr,
+		fairShare:         newFairShare(&cfg.Providers.Helix),
+		workloadStates:    xsync.NewMapOf[string, types.SchedulerWorkloadState](),
+		instance:          instance,
+		runnerTTL:         cfg.Providers.Helix.RunnerTTL,
 	}
 
+	allocator.onRelease = scheduler.slotReleased
+	allocator.onRemove = scheduler.slotRemoved
+
 	return scheduler
 }
 
@@ -191,6 +228,9 @@ func (s *scheduler) Schedule(work *Workload) (err error) {
 // Release frees the resources associated with a specific scheduled request.
 // It finds the request by its ID, releases the allocated slot, and removes the associated work from the store.
 func (s *scheduler) Release(id string) error {
+	// The work is done, it's no longer rescheduled after a restart.
+	s.deleteWorkload(id)
+
 	// Find the slot ID associated with the request.
 	slotID, ok := s.find(id)
 	if !ok {
@@ -219,7 +259,7 @@ func (s *scheduler) WorkForRunner(id string, workType WorkloadType, newWorkOnly
 	deadSlots := s.allocator.DeadSlots(s.cluster.DeadRunnerIDs())
 	for _, dead := range deadSlots {
 		// Get work associated with the dead slot.
-		work, ok := s.workStore.Load(dead.ID)
+		work, ok := s.workStore.LoadAndDelete(dead.ID)
 		if !ok {
 			continue // Work not owned by this scheduler, ignore it.
 		}
@@ -236,8 +276,12 @@ func (s *scheduler) WorkForRunner(id string, workType WorkloadType, newWorkOnly
 				Str("runner_id", id).
 				Str("slot_id", dead.ID.String()).
 				Msg("failed to reschedule work for dead slot")
+			s.onSchedulingErr(work, err)
+			s.deleteWorkload(work.ID())
 			continue
 		}
+		s.fairShare.rescheduled(work)
+		s.setWorkloadState(work.ID(), types.SchedulerWorkloadStateScheduled)
 	}
 
 	// Iterate through the slots assigned to the runner.
@@ -259,6 +303,7 @@ func (s *scheduler) WorkForRunner(id string, workType WorkloadType, newWorkOnly
 				continue // Work is not new, ignore it.
 			}
 			slot.Start() // Mark the work in the slot as started.
+			s.setWorkloadState(work.ID(), types.SchedulerWorkloadStateRunning)
 			return work, nil
 		}
 	}
@@ -289,8 +334,7 @@ func (s *scheduler) find(id string) (uuid.UUID, bool) {
 }
 
 // Enqueue adds a workload to the scheduler's queue.
-// TODO(Phil): Previous implementations saved the session queue in the database to requeue on
-// restarts. I don't think this is a particularly useful feature ATM. But may be in the future.
+// The workload is persisted in the queue store until it is released.
 func (s *scheduler) Enqueue(work *Workload) error {
 	s.queueMtx.Lock()
 	defer s.queueMtx.Unlock()
@@ -306,22 +350,40 @@ func (s *scheduler) Enqueue(work *Workload) error {
 		return fmt.Errorf("queue is full")
 	}
 
-	// Check if the work is a session and has priority
-	if work.WorkloadType == WorkloadTypeSession {
-		if work.Session().Metadata.Priority {
-			// Add the work to the front of the queue.
-			// Ignoring the order of other priority sessions here to avoid complexity
-			s.queue = append([]*Workload{work}, s.queue...)
-			return nil
-		}
+	if !s.fairShare.canQueue(work) {
+		return fmt.Errorf("queue is full for %s", work.Tenant())
+	}
+
+	err := s.saveWorkload(work)
+	if err != nil {
+		return fmt.Errorf("error persisting work: %w", err)
 	}
 
-	// Queue the work
-	s.queue = append(s.queue, work)
+	s.push(work)
 
 	return nil
 }
 
+// push adds the work to the queue, the caller must hold the queue lock.
+func (s *scheduler) push(work *Workload) {
+	s.fairShare.queue(work)
+
+	// Check if the work is a session and has priority
+	if work.isPriority() {
+		// Add the work to the front of the queue.
+		// Ignoring the order of other priority sessions here to avoid complexity
+		s.queue = append([]*Workload{work}, s.queue...)
+		return
+	}
+
+	// Queue the work in fair-share order, behind the priority sessions
+	i := len(s.queue)
+	for i > 0 && !s.queue[i-1].isPriority() && s.queue[i-1].finishTag > work.finishTag {
+		i--
+	}
+	s.queue = slices.Insert(s.queue, i, work)
+}
+
 // TODO(PHIL): Deprecate in preference of a new dashboard API
 // DashboardData returns the queue of work for the scheduler in old SessionSummary format
 func (s *scheduler) DashboardData() ([]*types.SessionSummary, error) {
@@ -353,6 +415,7 @@ func (s *scheduler) DashboardData() ([]*types.SessionSummary, error) {
 				Completed:     w.LLMInferenceRequest().CreatedAt,
 				Summary:       "LLM Inference Request",
 				Priority:      w.LLMInferenceRequest().Priority,
+				AppID:         w.LLMInferenceRequest().AppID,
 			})
 		}
 	}
@@ -379,6 +442,14 @@ func (s *scheduler) DashboardSlotsData() []types.DesiredSlots {
 	return desiredSlots
 }
 
+// DashboardTenantsData returns the queue depth and wait times by tenant
+func (s *scheduler) DashboardTenantsData() []*types.SchedulerTenantQueue {
+	s.queueMtx.Lock()
+	defer s.queueMtx.Unlock()
+
+	return s.fairShare.tenantQueues(s.queue)
+}
+
 func (s *scheduler) Begin(requestID string) error {
 	// Find the slot ID associated with the request.
 	slotID, ok := s.find(requestID)
@@ -393,6 +464,8 @@ func (s *scheduler) Begin(requestID string) error {
 		return fmt.Errorf("problem starting slot: %w", err)
 	}
 
+	s.setWorkloadState(requestID, types.SchedulerWorkloadStateRunning)
+
 	return nil
 }
 
@@ -433,8 +506,20 @@ func (s *scheduler) processQueue(ctx context.Context) {
 
 			// Schedule any requests that are currently in the queue.
 			for _, work := range s.queue {
+				// Keep the work of the tenants at their concurrency limit queued
+				if !s.fairShare.canSchedule(work) {
+					unscheduledQueue = append(unscheduledQueue, work)
+					continue
+				}
+
 				err := s.Schedule(work)
 				if err != nil {
+					// Restored work waits for the runners to reconnect
+					if errors.Is(err, ErrNoRunnersAvailable) && time.Now().Before(s.runnersReconnectDeadline) {
+						unscheduledQueue = append(unscheduledQueue, work)
+						continue
+					}
+
 					retry, err := ErrorHandlingStrategy(err, work)
 
 					// If we can retry, break out of the loop and try again later
@@ -446,7 +531,12 @@ func (s *scheduler) processQueue(ctx context.Context) {
 					// If we can't retry, write an error to the request and continue so it takes it off
 					// the queue
 					s.onSchedulingErr(work, err)
+					s.deleteWorkload(work.ID())
+					s.fairShare.unqueue(work)
+					continue
 				}
+				s.fairShare.scheduled(work)
+				s.setWorkloadState(work.ID(), types.SchedulerWorkloadStateScheduled)
 			}
 			// Clear processed queue
 			s.queue = unscheduledQueue
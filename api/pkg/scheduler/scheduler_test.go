@@ -14,14 +14,14 @@ import (
 
 func TestScheduler_NoRunnersAvailable(t *testing.T) {
 	config, _ := config.LoadServerConfig()
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	err := scheduleTestLLMWorkload(scheduler, "test-request-1", model.Model_Ollama_Llama3_8b)
 	assert.ErrorContains(t, err, "no runners available")
 }
 
 func TestScheduler_TimeoutRunner(t *testing.T) {
 	config, _ := config.LoadServerConfig()
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 
 	// Monkeypatch the scheduler's cluster
 	timeoutRunner1Func := func(id string, t time.Time) bool {
@@ -55,7 +55,7 @@ func TestScheduler_TimeoutRunner(t *testing.T) {
 
 func TestScheduler_ThreeJobsOnSingleRunnerThatCanFitTwo(t *testing.T) {
 	config, _ := config.LoadServerConfig()
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	m, _ := model.GetModel(string(model.Model_Ollama_Llama3_8b))
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "test-runner",
@@ -75,7 +75,7 @@ func TestScheduler_ThreeJobsOnSingleRunnerThatCanFitTwo(t *testing.T) {
 
 func TestScheduler_TestWarmSlot(t *testing.T) {
 	config, _ := config.LoadServerConfig()
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "test-runner",
@@ -103,7 +103,7 @@ func TestScheduler_TestWarmSlot(t *testing.T) {
 func TestScheduler_TestRemoveStaleSlots(t *testing.T) {
 	config, _ := config.LoadServerConfig()
 	config.Providers.Helix.ModelTTL = 1 * time.Microsecond
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "test-runner",
@@ -154,7 +154,7 @@ func TestScheduler_TestRemoveStaleSlots(t *testing.T) {
 
 func TestScheduler_FullWhenJobsWarm(t *testing.T) {
 	config, _ := config.LoadServerConfig()
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "test-runner",
@@ -179,7 +179,7 @@ func TestScheduler_FullWhenJobsWarm(t *testing.T) {
 func TestScheduler_MaximiseUtilization(t *testing.T) {
 	config, _ := config.LoadServerConfig()
 	config.Providers.Helix.SchedulingStrategy = string(SchedulingStrategy_MaxUtilization)
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "test-runner-1",
@@ -213,7 +213,7 @@ func TestScheduler_MaximiseUtilization(t *testing.T) {
 func TestScheduler_TestSessionScheduler(t *testing.T) {
 	config, _ := config.LoadServerConfig()
 	config.Providers.Helix.ModelTTL = 1 * time.Microsecond
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "test-runner",
@@ -244,7 +244,7 @@ func TestScheduler_TestSessionScheduler(t *testing.T) {
 
 func TestScheduler_LoraDirSession(t *testing.T) {
 	config, _ := config.LoadServerConfig()
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	m, _ := model.GetModel(model.Model_Axolotl_Mistral7b)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "test-runner-1",
@@ -290,7 +290,7 @@ func TestScheduler_LoraDirSession(t *testing.T) {
 func TestScheduler_RunnerWithWrongModel(t *testing.T) {
 	config, _ := config.LoadServerConfig()
 	config.Providers.Helix.ModelTTL = 1 * time.Microsecond
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "test-runner",
@@ -335,7 +335,7 @@ func TestScheduler_SlotTimeoutTest(t *testing.T) {
 	config, _ := config.LoadServerConfig()
 	config.Providers.Helix.SlotTTL = 1 * time.Microsecond
 	config.Providers.Helix.ModelTTL = 1 * time.Microsecond
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	m, _ := model.GetModel(model.Model_Ollama_Llama3_8b)
 	scheduler.UpdateRunner(&types.RunnerState{
 		ID:          "test-runner",
@@ -358,7 +358,7 @@ func TestScheduler_EnqueueLLMRequest(t *testing.T) {
 	// Create the server and helper function to test if the queue is empty
 	config, _ := config.LoadServerConfig()
 	config.Providers.Helix.QueueSize = 1
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	emptyQueueFunc := func() bool {
 		return len(scheduler.queue) == 0
 	}
@@ -404,7 +404,7 @@ func TestScheduler_EnqueueSessionRequest(t *testing.T) {
 	// Create the server and helper function to test if the queue is empty
 	config, _ := config.LoadServerConfig()
 	config.Providers.Helix.QueueSize = 2
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	emptyQueueFunc := func() bool {
 		return len(scheduler.queue) == 0
 	}
@@ -438,7 +438,7 @@ func TestScheduler_EnqueueSessionRequest(t *testing.T) {
 
 func TestScheduler_RunnerLifecycle(t *testing.T) {
 	config, _ := config.LoadServerConfig()
-	scheduler := NewScheduler(context.Background(), &config, nil)
+	scheduler := NewScheduler(context.Background(), &config, nil, nil)
 	emptyQueueFunc := func() bool {
 		return len(scheduler.queue) == 0
 	}
@@ -21,6 +21,7 @@ type Slot struct {
 	isStaleFunc      TimeoutFunc
 	isErrorFunc      TimeoutFunc
 	isNew            bool
+	onRelease        func(*Slot) // Called when scheduled or active work is released
 }
 
 // NewSlot creates a new slot with the given runnerID and work
@@ -101,11 +102,18 @@ func (s *Slot) IsScheduled() bool {
 // Sets a slot as no longer active
 func (s *Slot) Release() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
+	hadWork := s.isActive || s.isScheduled
 	s.isActive = false
 	s.isScheduled = false
 	s.lastActivityTime = time.Now()
+	onRelease := s.onRelease
+
+	s.mu.Unlock()
+
+	if hadWork && onRelease != nil {
+		onRelease(s)
+	}
 }
 
 // Marks the work as started
@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/helixml/helix/api/pkg/model"
 	"github.com/helixml/helix/api/pkg/types"
@@ -18,6 +19,11 @@ type Workload struct {
 	WorkloadType       WorkloadType
 	llmInfereceRequest *types.RunnerLLMInferenceRequest
 	session            *types.Session
+
+	// Set when the work is queued
+	enqueued  time.Time
+	startTag  float64 // Fair-share virtual time the work can start at
+	finishTag float64 // Fair-share virtual time the work is done at, the queue order
 }
 
 func NewLLMWorkload(work *types.RunnerLLMInferenceRequest) (*Workload, error) {
@@ -36,6 +42,19 @@ func NewSessionWorkload(work *types.Session) (*Workload, error) {
 	return validate(workload)
 }
 
+// newWorkload converts a workload sent to runners back
+func newWorkload(work *types.RunnerWorkload) (*Workload, error) {
+	switch {
+	case work == nil:
+		return nil, fmt.Errorf("workload is nil")
+	case work.LLMInferenceRequest != nil:
+		return NewLLMWorkload(work.LLMInferenceRequest)
+	case work.Session != nil:
+		return NewSessionWorkload(work.Session)
+	}
+	return nil, fmt.Errorf("unable to parse workload")
+}
+
 // Check model conversion so we don't have to do it later
 func validate(work *Workload) (*Workload, error) {
 	_, err := model.GetModel(work.ModelName().String())
@@ -83,6 +102,48 @@ func (w *Workload) Mode() types.SessionMode {
 	panic(fmt.Sprintf("unknown workload type: %s", w.WorkloadType))
 }
 
+// Tenant returns the app of the work, or else its owner, the work of each
+// tenant gets a fair share of the runners
+func (w *Workload) Tenant() string {
+	switch w.WorkloadType {
+	case WorkloadTypeLLMInferenceRequest:
+		if w.llmInfereceRequest.AppID != "" {
+			return w.llmInfereceRequest.AppID
+		}
+		return w.llmInfereceRequest.OwnerID
+	case WorkloadTypeSession:
+		if w.session.ParentApp != "" {
+			return w.session.ParentApp
+		}
+		return w.session.Owner
+	}
+	panic(fmt.Sprintf("unknown workload type: %s", w.WorkloadType))
+}
+
+func (w *Workload) PriorityClass() types.SchedulerPriorityClass {
+	switch w.WorkloadType {
+	case WorkloadTypeLLMInferenceRequest:
+		if w.llmInfereceRequest.PriorityClass != "" {
+			return w.llmInfereceRequest.PriorityClass
+		}
+		if w.llmInfereceRequest.EmbeddingRequest != nil {
+			return types.SchedulerPriorityClassBatch
+		}
+		return types.SchedulerPriorityClassInteractive
+	case WorkloadTypeSession:
+		if w.session.Mode == types.SessionModeFinetune {
+			return types.SchedulerPriorityClassFineTune
+		}
+		return types.SchedulerPriorityClassInteractive
+	}
+	panic(fmt.Sprintf("unknown workload type: %s", w.WorkloadType))
+}
+
+// isPriority is true for the sessions that skip the fair-share order
+func (w *Workload) isPriority() bool {
+	return w.WorkloadType == WorkloadTypeSession && w.session.Metadata.Priority
+}
+
 func (w *Workload) LLMInferenceRequest() *types.RunnerLLMInferenceRequest {
 	if w.WorkloadType != WorkloadTypeLLMInferenceRequest {
 		panic(fmt.Sprintf("workload is not  an LLM inference request: %#v", w))
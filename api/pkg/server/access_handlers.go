@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/helixml/helix/api/pkg/auth"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// listAppAccessGrants godoc
+// @Summary List app access grants
+// @Description List the users that have been granted a role on the app, requires the manage_access permission.
+// @Tags    apps
+// @Success 200 {array} types.AccessGrant
+// @Param id path string true "App ID"
+// @Router /api/v1/apps/{id}/access [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listAppAccessGrants(_ http.ResponseWriter, r *http.Request) ([]*types.AccessGrant, *system.HTTPError) {
+	if _, httpErr := s.getAppForAccess(r); httpErr != nil {
+		return nil, httpErr
+	}
+	return s.listAccessGrants(r.Context(), types.AccessResourceTypeApp, getID(r))
+}
+
+// createAppAccessGrant godoc
+// @Summary Grant access to an app
+// @Description Give a user a role on the app or change the role they were granted, requires the manage_access permission.
+// @Tags    apps
+// @Success 200 {object} types.AccessGrant
+// @Param request body types.CreateAccessGrantRequest true "Request body with the user and role."
+// @Param id path string true "App ID"
+// @Router /api/v1/apps/{id}/access [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) createAppAccessGrant(_ http.ResponseWriter, r *http.Request) (*types.AccessGrant, *system.HTTPError) {
+	if _, httpErr := s.getAppForAccess(r); httpErr != nil {
+		return nil, httpErr
+	}
+	return s.createAccessGrant(r, types.AccessResourceTypeApp, getID(r))
+}
+
+// deleteAppAccessGrant godoc
+// @Summary Revoke access to an app
+// @Description Remove the role granted to a user on the app, requires the manage_access permission.
+// @Tags    apps
+// @Success 200 {object} types.AccessGrant
+// @Param id path string true "App ID"
+// @Param user_id path string true "User ID"
+// @Router /api/v1/apps/{id}/access/{user_id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) deleteAppAccessGrant(_ http.ResponseWriter, r *http.Request) (*types.AccessGrant, *system.HTTPError) {
+	if _, httpErr := s.getAppForAccess(r); httpErr != nil {
+		return nil, httpErr
+	}
+	return s.deleteAccessGrant(r, types.AccessResourceTypeApp, getID(r))
+}
+
+// listKnowledgeAccessGrants godoc
+// @Summary List knowledge access grants
+// @Description List the users that have been granted a role on the knowledge, requires the manage_access permission.
+// @Tags    knowledge
+// @Success 200 {array} types.AccessGrant
+// @Param id path string true "Knowledge ID"
+// @Router /api/v1/knowledge/{id}/access [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listKnowledgeAccessGrants(_ http.ResponseWriter, r *http.Request) ([]*types.AccessGrant, *system.HTTPError) {
+	if httpErr := s.authorizeKnowledgeAccess(r); httpErr != nil {
+		return nil, httpErr
+	}
+	return s.listAccessGrants(r.Context(), types.AccessResourceTypeKnowledge, getID(r))
+}
+
+// createKnowledgeAccessGrant godoc
+// @Summary Grant access to knowledge
+// @Description Give a user a role on the knowledge or change the role they were granted, requires the manage_access permission.
+// @Tags    knowledge
+// @Success 200 {object} types.AccessGrant
+// @Param request body types.CreateAccessGrantRequest true "Request body with the user and role."
+// @Param id path string true "Knowledge ID"
+// @Router /api/v1/knowledge/{id}/access [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) createKnowledgeAccessGrant(_ http.ResponseWriter, r *http.Request) (*types.AccessGrant, *system.HTTPError) {
+	if httpErr := s.authorizeKnowledgeAccess(r); httpErr != nil {
+		return nil, httpErr
+	}
+	return s.createAccessGrant(r, types.AccessResourceTypeKnowledge, getID(r))
+}
+
+// deleteKnowledgeAccessGrant godoc
+// @Summary Revoke access to knowledge
+// @Description Remove the role granted to a user on the knowledge, requires the manage_access permission.
+// @Tags    knowledge
+// @Success 200 {object} types.AccessGrant
+// @Param id path string true "Knowledge ID"
+// @Param user_id path string true "User ID"
+// @Router /api/v1/knowledge/{id}/access/{user_id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) deleteKnowledgeAccessGrant(_ http.ResponseWriter, r *http.Request) (*types.AccessGrant, *system.HTTPError) {
+	if httpErr := s.authorizeKnowledgeAccess(r); httpErr != nil {
+		return nil, httpErr
+	}
+	return s.deleteAccessGrant(r, types.AccessResourceTypeKnowledge, getID(r))
+}
+
+// getAdminAppAccess godoc
+// @Summary List who can access an app
+// @Description List every user that can access the app with their role and where the access comes from. Server admins are not listed.
+// @Tags    admin
+// @Success 200 {array} types.ResourceAccess
+// @Param id path string true "App ID"
+// @Router /api/v1/admin/apps/{id}/access [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) getAdminAppAccess(_ http.ResponseWriter, r *http.Request) ([]*types.ResourceAccess, *system.HTTPError) {
+	app, err := s.Store.GetApp(r.Context(), getID(r))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	access, err := s.Controller.ListAppAccess(r.Context(), app)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return access, nil
+}
+
+// getAppForAccess returns the app from the request if the user can manage who has access to it
+func (s *HelixAPIServer) getAppForAccess(r *http.Request) (*types.App, *system.HTTPError) {
+	app, err := s.Store.GetApp(r.Context(), getID(r))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	if httpErr := s.authorizeApp(r.Context(), getRequestUser(r), app, types.PermissionManageAccess); httpErr != nil {
+		return nil, httpErr
+	}
+
+	return app, nil
+}
+
+func (s *HelixAPIServer) authorizeKnowledgeAccess(r *http.Request) *system.HTTPError {
+	existing, err := s.Store.GetKnowledge(r.Context(), getID(r))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return system.NewHTTPError404(store.ErrNotFound.Error())
+		}
+		return system.NewHTTPError500(err.Error())
+	}
+
+	return s.authorizeKnowledge(r.Context(), getRequestUser(r), existing, types.PermissionManageAccess)
+}
+
+func (s *HelixAPIServer) listAccessGrants(ctx context.Context, resourceType types.AccessResourceType, resourceID string) ([]*types.AccessGrant, *system.HTTPError) {
+	grants, err := s.Store.ListAccessGrants(ctx, &store.ListAccessGrantsQuery{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return grants, nil
+}
+
+// createAccessGrant grants the role to the user, replacing the role they
+// were previously granted on the resource
+func (s *HelixAPIServer) createAccessGrant(r *http.Request, resourceType types.AccessResourceType, resourceID string) (*types.AccessGrant, *system.HTTPError) {
+	ctx := r.Context()
+	user := getRequestUser(r)
+
+	var req types.CreateAccessGrantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if req.UserID == "" {
+		return nil, system.NewHTTPError400("user_id is required")
+	}
+
+	if !req.Role.Valid() {
+		return nil, system.NewHTTPError400("invalid role '%s'", req.Role)
+	}
+
+	if _, err := s.authMiddleware.authenticator.GetUserByID(ctx, req.UserID); err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, system.NewHTTPError400("user '%s' does not exist", req.UserID)
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	existing, err := s.Store.ListAccessGrants(ctx, &store.ListAccessGrantsQuery{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		UserID:       req.UserID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	if len(existing) > 0 {
+		grant := existing[0]
+		grant.Role = req.Role
+		grant.GrantedBy = user.ID
+
+		updated, err := s.Store.UpdateAccessGrant(ctx, grant)
+		if err != nil {
+			return nil, system.NewHTTPError500(err.Error())
+		}
+		return updated, nil
+	}
+
+	created, err := s.Store.CreateAccessGrant(ctx, &types.AccessGrant{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		UserID:       req.UserID,
+		Role:         req.Role,
+		GrantedBy:    user.ID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return created, nil
+}
+
+func (s *HelixAPIServer) deleteAccessGrant(r *http.Request, resourceType types.AccessResourceType, resourceID string) (*types.AccessGrant, *system.HTTPError) {
+	ctx := r.Context()
+	userID := mux.Vars(r)["user_id"]
+
+	existing, err := s.Store.ListAccessGrants(ctx, &store.ListAccessGrantsQuery{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		UserID:       userID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	if len(existing) == 0 {
+		return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+	}
+
+	err = s.Store.DeleteAccessGrant(ctx, existing[0].ID)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing[0], nil
+}
+
+// deleteAccessGrants removes the grants of a deleted resource
+func (s *HelixAPIServer) deleteAccessGrants(ctx context.Context, resourceType types.AccessResourceType, resourceID string) error {
+	grants, err := s.Store.ListAccessGrants(ctx, &store.ListAccessGrantsQuery{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, grant := range grants {
+		if err := s.Store.DeleteAccessGrant(ctx, grant.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
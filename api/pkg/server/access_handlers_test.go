@@ -0,0 +1,69 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/auth"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// usersAuthenticator knows a fixed set of users
+type usersAuthenticator struct {
+	users map[string]*types.User
+}
+
+func (a *usersAuthenticator) GetUserByID(_ context.Context, userID string) (*types.User, error) {
+	user, ok := a.users[userID]
+	if !ok {
+		return nil, auth.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (a *usersAuthenticator) ValidateUserToken(_ context.Context, _ string) (*jwt.Token, error) {
+	return nil, nil
+}
+
+func (suite *OpenAIChatSuite) setupAccessGrantUsers() {
+	suite.server.authMiddleware = newAuthMiddleware(&usersAuthenticator{
+		users: map[string]*types.User{
+			"other_user": {ID: "other_user"},
+		},
+	}, suite.store, authMiddlewareConfig{})
+}
+
+func (suite *OpenAIChatSuite) newAccessGrantRequest(body string) *http.Request {
+	req, err := http.NewRequest("POST", "/api/v1/apps/app_id/access-grants", bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+
+	return req.WithContext(suite.authCtx)
+}
+
+func (suite *OpenAIChatSuite) TestCreateAccessGrant() {
+	suite.setupAccessGrantUsers()
+
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.store.EXPECT().CreateAccessGrant(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, grant *types.AccessGrant) (*types.AccessGrant, error) {
+			suite.Equal("other_user", grant.UserID)
+			suite.Equal(suite.userID, grant.GrantedBy)
+			return grant, nil
+		})
+
+	grant, httpErr := suite.server.createAccessGrant(suite.newAccessGrantRequest(`{"user_id": "other_user", "role": "viewer"}`), types.AccessResourceTypeApp, "app_id")
+	suite.Require().Nil(httpErr)
+	suite.Equal("other_user", grant.UserID)
+}
+
+func (suite *OpenAIChatSuite) TestCreateAccessGrant_UnknownUser() {
+	suite.setupAccessGrantUsers()
+
+	_, httpErr := suite.server.createAccessGrant(suite.newAccessGrantRequest(`{"user_id": "typo_user", "role": "viewer"}`), types.AccessResourceTypeApp, "app_id")
+	suite.Require().NotNil(httpErr)
+	suite.Equal(http.StatusBadRequest, httpErr.StatusCode)
+}
@@ -35,6 +35,7 @@ func (s *HelixAPIServer) listApps(_ http.ResponseWriter, r *http.Request) ([]*ty
 	userApps, err := s.Store.ListApps(ctx, &store.ListAppsQuery{
 		Owner:     user.ID,
 		OwnerType: user.Type,
+		Member:    user.ID,
 	})
 	if err != nil {
 		return nil, system.NewHTTPError500(err.Error())
@@ -55,8 +56,23 @@ func (s *HelixAPIServer) listApps(_ http.ResponseWriter, r *http.Request) ([]*ty
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
+	grantedApps, err := s.listGrantedApps(ctx, user)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
 	allApps := append(nonGlobalUserApps, globalApps...)
 
+	seen := map[string]bool{}
+	for _, app := range allApps {
+		seen[app.ID] = true
+	}
+	for _, app := range grantedApps {
+		if !seen[app.ID] {
+			allApps = append(allApps, app)
+		}
+	}
+
 	// Extract the "type" query parameter
 	queryType := r.URL.Query().Get("type")
 
@@ -79,6 +95,31 @@ func (s *HelixAPIServer) listApps(_ http.ResponseWriter, r *http.Request) ([]*ty
 	return filteredApps, nil
 }
 
+// listGrantedApps returns the apps the user has been granted access to
+func (s *HelixAPIServer) listGrantedApps(ctx context.Context, user *types.User) ([]*types.App, error) {
+	grants, err := s.Store.ListAccessGrants(ctx, &store.ListAccessGrantsQuery{
+		ResourceType: types.AccessResourceTypeApp,
+		UserID:       user.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var granted []*types.App
+	for _, grant := range grants {
+		app, err := s.Store.GetApp(ctx, grant.ResourceID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		granted = append(granted, app)
+	}
+
+	return granted, nil
+}
+
 // createTool godoc
 // @Summary Create new app
 // @Description Create new app. Apps are pre-configured to spawn sessions with specific tools and config.
@@ -86,6 +127,7 @@ func (s *HelixAPIServer) listApps(_ http.ResponseWriter, r *http.Request) ([]*ty
 
 // @Success 200 {object} types.App
 // @Param request    body types.App true "Request body with app configuration.")
+// @Param org_id query string false "Organization that owns the app"
 // @Router /api/v1/apps [post]
 // @Security BearerAuth
 func (s *HelixAPIServer) createApp(_ http.ResponseWriter, r *http.Request) (*types.App, *system.HTTPError) {
@@ -103,18 +145,23 @@ func (s *HelixAPIServer) createApp(_ http.ResponseWriter, r *http.Request) (*typ
 	user := getRequestUser(r)
 	ctx := r.Context()
 
-	// Getting existing tools for the user
+	owner, ownerType, httpErr := s.requestOwner(r, user, types.OrganizationRoleMember)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	// Getting existing tools for the owner
 	existingApps, err := s.Store.ListApps(ctx, &store.ListAppsQuery{
-		Owner:     user.ID,
-		OwnerType: user.Type,
+		Owner:     owner,
+		OwnerType: ownerType,
 	})
 	if err != nil {
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
 	app.ID = system.GenerateAppID()
-	app.Owner = user.ID
-	app.OwnerType = user.Type
+	app.Owner = owner
+	app.OwnerType = ownerType
 	app.Updated = time.Now()
 
 	for _, a := range existingApps {
@@ -208,6 +255,21 @@ func (s *HelixAPIServer) createApp(_ http.ResponseWriter, r *http.Request) (*typ
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
+	// Organization members only get the viewer role on org apps, the member
+	// creating the app should still be able to manage it
+	if created.OwnerType == types.OwnerTypeOrg {
+		_, err = s.Store.CreateAccessGrant(ctx, &types.AccessGrant{
+			ResourceType: types.AccessResourceTypeApp,
+			ResourceID:   created.ID,
+			UserID:       user.ID,
+			Role:         types.AccessRoleAdmin,
+			GrantedBy:    user.ID,
+		})
+		if err != nil {
+			return nil, system.NewHTTPError500(err.Error())
+		}
+	}
+
 	_, err = s.Controller.CreateAPIKey(ctx, user, &types.APIKey{
 		Name:  "api key 1",
 		Type:  types.APIKeyType_App,
@@ -221,7 +283,20 @@ func (s *HelixAPIServer) createApp(_ http.ResponseWriter, r *http.Request) (*typ
 }
 
 func (s *HelixAPIServer) validateKnowledge(k *types.AssistantKnowledge) error {
-	return knowledge.Validate(k)
+	err := knowledge.Validate(k)
+	if err != nil {
+		return err
+	}
+
+	// Local directories can only be indexed under the roots allowed by the admin
+	if k.Source.Local != nil {
+		_, err = knowledge.ResolveLocalPath(s.Cfg.RAG.Local.AllowedRoots, k.Source.Local.Path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (s *HelixAPIServer) validateTriggers(triggers []types.Trigger) error {
@@ -359,8 +434,9 @@ func (s *HelixAPIServer) getApp(_ http.ResponseWriter, r *http.Request) (*types.
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if (!app.Global && !app.Shared) && app.Owner != user.ID {
-		return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+	httpErr := s.authorizeApp(r.Context(), user, app, types.PermissionView)
+	if httpErr != nil {
+		return nil, httpErr
 	}
 	return app, nil
 }
@@ -402,16 +478,24 @@ func (s *HelixAPIServer) updateApp(_ http.ResponseWriter, r *http.Request) (*typ
 			return nil, system.NewHTTPError403("only admin users can update global apps")
 		}
 	} else {
-		if existing.Owner != user.ID {
-			return nil, system.NewHTTPError403("you do not have permission to update this app")
+		httpErr := s.authorizeApp(r.Context(), user, existing, types.PermissionEdit)
+		if httpErr != nil {
+			return nil, httpErr
 		}
 	}
 
+	httpErr := accessError(s.Controller.AuthorizeAppUpdate(r.Context(), user, existing, &update))
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
 	err = s.validateTriggers(update.Config.Helix.Triggers)
 	if err != nil {
 		return nil, system.NewHTTPError400(err.Error())
 	}
 
+	update.Owner = existing.Owner
+	update.OwnerType = existing.OwnerType
 	update.Updated = time.Now()
 
 	// Validate and default tools
@@ -498,11 +582,24 @@ func (s *HelixAPIServer) updateGithubApp(_ http.ResponseWriter, r *http.Request)
 			return nil, system.NewHTTPError403("only admin users can update global apps")
 		}
 	} else {
-		if existing.Owner != user.ID {
-			return nil, system.NewHTTPError403("you do not have permission to update this app")
+		httpErr := s.authorizeApp(r.Context(), user, existing, types.PermissionEdit)
+		if httpErr != nil {
+			return nil, httpErr
 		}
 	}
 
+	original := *existing
+
+	candidate := *existing
+	candidate.Config.Secrets = appUpdate.Secrets
+	candidate.Shared = appUpdate.Shared
+	candidate.Global = appUpdate.Global
+
+	httpErr := accessError(s.Controller.AuthorizeAppUpdate(r.Context(), user, &original, &candidate))
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
 	if existing.AppSource == types.AppSourceGithub {
 		client, err := s.getGithubClientFromRequest(r)
 		if err != nil {
@@ -514,6 +611,10 @@ func (s *HelixAPIServer) updateGithubApp(_ http.ResponseWriter, r *http.Request)
 			App:          existing,
 			ToolsPlanner: s.Controller.ToolsPlanner,
 			UpdateApp: func(app *types.App) (*types.App, error) {
+				// The config pulled from the repo can't refer to new secrets either
+				if err := s.Controller.AuthorizeAppUpdate(r.Context(), user, &original, app); err != nil {
+					return nil, err
+				}
 				return s.Store.UpdateApp(r.Context(), app)
 			},
 		})
@@ -523,7 +624,7 @@ func (s *HelixAPIServer) updateGithubApp(_ http.ResponseWriter, r *http.Request)
 
 		existing, err = githubApp.Update()
 		if err != nil {
-			return nil, system.NewHTTPError500(err.Error())
+			return nil, accessError(err)
 		}
 	}
 
@@ -570,8 +671,9 @@ func (s *HelixAPIServer) deleteApp(_ http.ResponseWriter, r *http.Request) (*typ
 			return nil, system.NewHTTPError403("only admin users can delete global apps")
 		}
 	} else {
-		if existing.Owner != user.ID {
-			return nil, system.NewHTTPError403("you do not have permission to delete this app")
+		httpErr := s.authorizeApp(r.Context(), user, existing, types.PermissionDelete)
+		if httpErr != nil {
+			return nil, httpErr
 		}
 	}
 
@@ -596,6 +698,11 @@ func (s *HelixAPIServer) deleteApp(_ http.ResponseWriter, r *http.Request) (*typ
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
+	err = s.deleteAccessGrants(r.Context(), types.AccessResourceTypeApp, id)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
 	return existing, nil
 }
 
@@ -14,10 +14,13 @@ import (
 
 var (
 	// Allowed paths for app API keys. Currently we support
-	// only OpenAI compatible chat completions API
+	// only OpenAI compatible chat completions and embeddings
+	// APIs and the quota status of the key
 	AppAPIKeyPaths = map[string]bool{
 		"/v1/chat/completions":  true,
+		"/v1/embeddings":        true,
 		"/api/v1/sessions/chat": true,
+		"/api/v1/quotas":        true,
 	}
 )
 
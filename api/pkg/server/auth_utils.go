@@ -2,9 +2,12 @@ package server
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/helixml/helix/api/pkg/controller"
+	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
 )
 
@@ -144,6 +147,14 @@ func hasUser(user *types.User) bool {
 	return user.ID != ""
 }
 
+// getRequestAPIKey returns the API key the user authenticated with, if any
+func getRequestAPIKey(user *types.User) string {
+	if user.TokenType != types.TokenTypeAPIKey {
+		return ""
+	}
+	return user.Token
+}
+
 func isAdmin(user *types.User) bool {
 	return hasUser(user) && user.Admin
 }
@@ -176,3 +187,55 @@ func canEditSession(user *types.User, session *types.Session) bool {
 	}
 	return false
 }
+
+// authorizeOwner returns a 403 error with the message unless the user owns the
+// resource or has at least the given role in the organization that owns it
+func (s *HelixAPIServer) authorizeOwner(ctx context.Context, user *types.User, owner string, ownerType types.OwnerType, role types.OrganizationRole, message string) *system.HTTPError {
+	ok, err := s.Controller.HasOwnerAccess(ctx, user.ID, owner, ownerType, role)
+	if err != nil {
+		return system.NewHTTPError500(err.Error())
+	}
+	if !ok {
+		return system.NewHTTPError403(message)
+	}
+	return nil
+}
+
+// requestOwner returns the owner of resources created by the request: the
+// organization passed in the org_id query parameter, or the user
+func (s *HelixAPIServer) requestOwner(r *http.Request, user *types.User, role types.OrganizationRole) (string, types.OwnerType, *system.HTTPError) {
+	orgID := r.URL.Query().Get("org_id")
+	if orgID == "" {
+		return user.ID, user.Type, nil
+	}
+
+	httpErr := s.authorizeOwner(r.Context(), user, orgID, types.OwnerTypeOrg, role, "you do not have permission to create resources in this organization")
+	if httpErr != nil {
+		return "", "", httpErr
+	}
+
+	return orgID, types.OwnerTypeOrg, nil
+}
+
+// authorizeApp returns a 403 error naming the missing permission unless the
+// user has the permission on the app
+func (s *HelixAPIServer) authorizeApp(ctx context.Context, user *types.User, app *types.App, permission types.Permission) *system.HTTPError {
+	return accessError(s.Controller.AuthorizeApp(ctx, user, app, permission))
+}
+
+// authorizeKnowledge returns a 403 error naming the missing permission unless
+// the user has the permission on the knowledge
+func (s *HelixAPIServer) authorizeKnowledge(ctx context.Context, user *types.User, knowledge *types.Knowledge, permission types.Permission) *system.HTTPError {
+	return accessError(s.Controller.AuthorizeKnowledge(ctx, user, knowledge, permission))
+}
+
+func accessError(err error) *system.HTTPError {
+	if err == nil {
+		return nil
+	}
+	var denied *controller.AccessDeniedError
+	if errors.As(err, &denied) {
+		return system.NewHTTPError403(err.Error())
+	}
+	return system.NewHTTPError500(err.Error())
+}
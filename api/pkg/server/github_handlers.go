@@ -3,9 +3,11 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -69,7 +71,19 @@ func (apiServer *HelixAPIServer) githubWebhook(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	hook, err := githubhook.Parse([]byte(app.Config.Github.WebhookSecret), r)
+	knowledge, err := apiServer.Store.ListKnowledge(r.Context(), &store.ListKnowledgeQuery{
+		AppID: app.ID,
+	})
+	if err != nil {
+		log.Error().Msgf("error loading app knowledge: %s %s", appID, err.Error())
+		http.Error(w, fmt.Sprintf("error loading app knowledge: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	hook, err := githubhook.New(r)
+	if err == nil && !githubWebhookSigned(hook, app, knowledge) {
+		err = errors.New("Invalid signature")
+	}
 	if err != nil {
 		log.Error().Msgf("error parsing webhook: %s", err.Error())
 		http.Error(w, fmt.Sprintf("error parsing webhook: %s", err.Error()), http.StatusBadRequest)
@@ -101,6 +115,18 @@ func (apiServer *HelixAPIServer) githubWebhook(w http.ResponseWriter, r *http.Re
 			return
 		}
 
+		err = apiServer.refreshGithubKnowledge(r.Context(), knowledge, &evt)
+		if err != nil {
+			log.Error().Msgf("error refreshing github knowledge: %s", err.Error())
+			http.Error(w, fmt.Sprintf("error refreshing github knowledge: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		// the rest is for apps deployed from a github repo
+		if app.Config.Github == nil {
+			return
+		}
+
 		// only accept pushes to master or main
 		if *evt.Ref != "refs/heads/master" && *evt.Ref != "refs/heads/main" {
 			log.Info().Msgf("ignoring push to branch: %s %s", *evt.Ref, *evt.Repo.HTMLURL)
@@ -146,6 +172,75 @@ func (apiServer *HelixAPIServer) githubWebhook(w http.ResponseWriter, r *http.Re
 	}
 }
 
+// githubWebhookSigned checks the webhook signature against the app's github
+// webhook secret and the webhook secrets of its github knowledge sources
+func githubWebhookSigned(hook *githubhook.Hook, app *types.App, knowledge []*types.Knowledge) bool {
+	var secrets []string
+
+	if app.Config.Github != nil {
+		secrets = append(secrets, app.Config.Github.WebhookSecret)
+	}
+
+	for _, k := range knowledge {
+		if k.Source.Github != nil && k.Source.Github.WebhookSecret != "" {
+			secrets = append(secrets, k.Source.Github.WebhookSecret)
+		}
+	}
+
+	for _, secret := range secrets {
+		if hook.SignedBy([]byte(secret)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refreshGithubKnowledge queues the knowledge indexed from the pushed
+// repository branch for indexing
+func (apiServer *HelixAPIServer) refreshGithubKnowledge(ctx context.Context, knowledge []*types.Knowledge, evt *github_api.PushEvent) error {
+	for _, k := range knowledge {
+		source := k.Source.Github
+		if source == nil {
+			continue
+		}
+
+		if !strings.EqualFold(fmt.Sprintf("%s/%s", source.Owner, source.Repository), evt.GetRepo().GetFullName()) {
+			continue
+		}
+
+		branch := source.Branch
+		if branch == "" {
+			branch = evt.GetRepo().GetDefaultBranch()
+		}
+
+		if evt.GetRef() != "refs/heads/"+branch {
+			continue
+		}
+
+		// Already queued or indexing
+		if k.State == types.KnowledgeStatePending || k.State == types.KnowledgeStateIndexing {
+			continue
+		}
+
+		log.Info().
+			Str("knowledge_id", k.ID).
+			Str("repository", evt.GetRepo().GetFullName()).
+			Str("ref", evt.GetRef()).
+			Msg("github push, refreshing knowledge")
+
+		k.State = types.KnowledgeStatePending
+		k.Message = ""
+
+		_, err := apiServer.Store.UpdateKnowledge(ctx, k)
+		if err != nil {
+			return fmt.Errorf("failed to update knowledge '%s': %w", k.Name, err)
+		}
+	}
+
+	return nil
+}
+
 // do we already have the github token as an api key in the database?
 func (apiServer *HelixAPIServer) getGithubDatabaseToken(ctx context.Context, user *types.User) (string, error) {
 	apiKeys, err := apiServer.Store.ListAPIKeys(ctx, &store.ListApiKeysQuery{
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type GithubWebhookSuite struct {
+	suite.Suite
+
+	store  *store.MockStore
+	server *HelixAPIServer
+}
+
+func TestGithubWebhookSuite(t *testing.T) {
+	suite.Run(t, new(GithubWebhookSuite))
+}
+
+func (suite *GithubWebhookSuite) SetupTest() {
+	ctrl := gomock.NewController(suite.T())
+
+	suite.store = store.NewMockStore(ctrl)
+	suite.server = &HelixAPIServer{
+		Store: suite.store,
+	}
+
+	suite.store.EXPECT().GetApp(gomock.Any(), "app_id").Return(&types.App{ID: "app_id"}, nil)
+}
+
+func (suite *GithubWebhookSuite) pushRequest(secret, ref string) *http.Request {
+	payload := `{"ref": "` + ref + `", "repository": {"full_name": "helixml/docs", "default_branch": "main"}}`
+	body := url.Values{"payload": []string{payload}}.Encode()
+
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(body))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/github/webhook?app_id=app_id", strings.NewReader(body))
+	req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-GitHub-Delivery", "delivery_id")
+
+	return req
+}
+
+func (suite *GithubWebhookSuite) knowledge() []*types.Knowledge {
+	return []*types.Knowledge{
+		{
+			ID:    "docs_main",
+			State: types.KnowledgeStateReady,
+			Source: types.KnowledgeSource{
+				Github: &types.KnowledgeSourceGithub{Owner: "helixml", Repository: "docs", WebhookSecret: "secret"},
+			},
+		},
+		{
+			ID:    "docs_release",
+			State: types.KnowledgeStateReady,
+			Source: types.KnowledgeSource{
+				Github: &types.KnowledgeSourceGithub{Owner: "helixml", Repository: "docs", Branch: "release"},
+			},
+		},
+		{
+			ID:    "other_repo",
+			State: types.KnowledgeStateReady,
+			Source: types.KnowledgeSource{
+				Github: &types.KnowledgeSourceGithub{Owner: "helixml", Repository: "helix"},
+			},
+		},
+		{
+			ID:    "docs_indexing",
+			State: types.KnowledgeStateIndexing,
+			Source: types.KnowledgeSource{
+				Github: &types.KnowledgeSourceGithub{Owner: "helixml", Repository: "docs"},
+			},
+		},
+	}
+}
+
+func (suite *GithubWebhookSuite) TestPush_RefreshesKnowledge() {
+	suite.store.EXPECT().ListKnowledge(gomock.Any(), &store.ListKnowledgeQuery{AppID: "app_id"}).Return(suite.knowledge(), nil)
+
+	suite.store.EXPECT().UpdateKnowledge(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, k *types.Knowledge) (*types.Knowledge, error) {
+		suite.Equal("docs_main", k.ID)
+		suite.Equal(types.KnowledgeStatePending, k.State)
+		return k, nil
+	})
+
+	rec := httptest.NewRecorder()
+	suite.server.githubWebhook(rec, suite.pushRequest("secret", "refs/heads/main"))
+
+	suite.Equal(http.StatusOK, rec.Code)
+}
+
+func (suite *GithubWebhookSuite) TestPush_Branch() {
+	suite.store.EXPECT().ListKnowledge(gomock.Any(), gomock.Any()).Return(suite.knowledge(), nil)
+
+	suite.store.EXPECT().UpdateKnowledge(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, k *types.Knowledge) (*types.Knowledge, error) {
+		suite.Equal("docs_release", k.ID)
+		return k, nil
+	})
+
+	rec := httptest.NewRecorder()
+	suite.server.githubWebhook(rec, suite.pushRequest("secret", "refs/heads/release"))
+
+	suite.Equal(http.StatusOK, rec.Code)
+}
+
+func (suite *GithubWebhookSuite) TestPush_InvalidSignature() {
+	suite.store.EXPECT().ListKnowledge(gomock.Any(), gomock.Any()).Return(suite.knowledge(), nil)
+
+	rec := httptest.NewRecorder()
+	suite.server.githubWebhook(rec, suite.pushRequest("wrong", "refs/heads/main"))
+
+	suite.Equal(http.StatusBadRequest, rec.Code)
+}
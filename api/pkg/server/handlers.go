@@ -893,6 +893,17 @@ func (apiServer *HelixAPIServer) createAPIKey(res http.ResponseWriter, req *http
 		newAPIKey.AppID = &sql.NullString{String: apiKeyStr, Valid: true}
 	}
 
+	if newAPIKey.AppID != nil && newAPIKey.AppID.String != "" {
+		app, err := apiServer.Store.GetApp(ctx, newAPIKey.AppID.String)
+		if err != nil {
+			return "", err
+		}
+		err = apiServer.Controller.AuthorizeApp(ctx, user, app, types.PermissionManageKeys)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	createdKey, err := apiServer.Controller.CreateAPIKey(ctx, user, newAPIKey)
 	if err != nil {
 		return "", err
@@ -918,14 +929,36 @@ func (apiServer *HelixAPIServer) getAPIKeys(res http.ResponseWriter, req *http.R
 	user := getRequestUser(req)
 	ctx := req.Context()
 
-	apiKeys, err := apiServer.Controller.GetAPIKeys(ctx, user)
-	if err != nil {
-		return nil, err
-	}
-
 	typesParam := req.URL.Query().Get("types")
 	appIDParam := req.URL.Query().Get("app_id")
 
+	var (
+		apiKeys []*types.APIKey
+		err     error
+	)
+	if appIDParam != "" {
+		// The keys of an app are listed to everyone that manages them
+		app, err := apiServer.Store.GetApp(ctx, appIDParam)
+		if err != nil {
+			return nil, err
+		}
+		err = apiServer.Controller.AuthorizeApp(ctx, user, app, types.PermissionManageKeys)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys, err = apiServer.Store.ListAPIKeys(ctx, &store.ListApiKeysQuery{
+			AppID: appIDParam,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		apiKeys, err = apiServer.Controller.GetAPIKeys(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	includeAllTypes := false
 	if typesParam == "all" {
 		includeAllTypes = true
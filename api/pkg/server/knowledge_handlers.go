@@ -3,8 +3,11 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
+	"github.com/helixml/helix/api/pkg/controller/knowledge"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
@@ -15,17 +18,46 @@ func (s *HelixAPIServer) listKnowledge(_ http.ResponseWriter, r *http.Request) (
 	ctx := r.Context()
 	user := getRequestUser(r)
 
-	appID := r.URL.Query().Get("app_id")
-
-	knowledges, err := s.Store.ListKnowledge(ctx, &store.ListKnowledgeQuery{
+	query := &store.ListKnowledgeQuery{
 		Owner:     user.ID,
 		OwnerType: user.Type,
-		AppID:     appID,
-	})
+		Member:    user.ID,
+	}
+
+	// The knowledge of an app is filtered below, users that were granted
+	// access to the app can see it too
+	if appID := r.URL.Query().Get("app_id"); appID != "" {
+		_, err := s.Store.GetApp(ctx, appID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+			}
+			return nil, system.NewHTTPError500(err.Error())
+		}
+
+		query = &store.ListKnowledgeQuery{AppID: appID}
+	}
+
+	listed, err := s.Store.ListKnowledge(ctx, query)
 	if err != nil {
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
+	knowledges := []*types.Knowledge{}
+	for _, knowledge := range listed {
+		role, err := s.Controller.KnowledgeRole(ctx, user, knowledge)
+		if err != nil {
+			return nil, system.NewHTTPError500(err.Error())
+		}
+		if !role.Has(types.PermissionView) {
+			continue
+		}
+		if !role.Has(types.PermissionEdit) {
+			knowledge.RedactCredentials()
+		}
+		knowledges = append(knowledges, knowledge)
+	}
+
 	for idx, knowledge := range knowledges {
 		if knowledge.RefreshEnabled && knowledge.RefreshSchedule != "" {
 			nextRun, err := s.knowledgeManager.NextRun(ctx, knowledge.ID)
@@ -51,8 +83,19 @@ func (s *HelixAPIServer) getKnowledge(_ http.ResponseWriter, r *http.Request) (*
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("you do not have permission to delete this knowledge")
+	httpErr := s.authorizeKnowledge(r.Context(), user, existing, types.PermissionView)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	role, err := s.Controller.KnowledgeRole(r.Context(), user, existing)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	// Credentials are only shown to the users that can change them
+	if !role.Has(types.PermissionEdit) {
+		existing.RedactCredentials()
 	}
 
 	return existing, nil
@@ -70,8 +113,9 @@ func (s *HelixAPIServer) listKnowledgeVersions(_ http.ResponseWriter, r *http.Re
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("you do not have permission to delete this knowledge")
+	httpErr := s.authorizeKnowledge(r.Context(), user, existing, types.PermissionView)
+	if httpErr != nil {
+		return nil, httpErr
 	}
 
 	versions, err := s.Store.ListKnowledgeVersions(r.Context(), &store.ListKnowledgeVersionQuery{
@@ -96,8 +140,9 @@ func (s *HelixAPIServer) deleteKnowledge(_ http.ResponseWriter, r *http.Request)
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("you do not have permission to delete this knowledge")
+	httpErr := s.authorizeKnowledge(r.Context(), user, existing, types.PermissionDelete)
+	if httpErr != nil {
+		return nil, httpErr
 	}
 
 	err = s.deleteKnowledgeAndVersions(existing)
@@ -105,6 +150,11 @@ func (s *HelixAPIServer) deleteKnowledge(_ http.ResponseWriter, r *http.Request)
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
+	err = s.deleteAccessGrants(r.Context(), types.AccessResourceTypeKnowledge, id)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
 	return existing, nil
 }
 
@@ -169,8 +219,9 @@ func (s *HelixAPIServer) refreshKnowledge(_ http.ResponseWriter, r *http.Request
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("you do not have permission to refresh this knowledge")
+	httpErr := s.authorizeKnowledge(r.Context(), user, existing, types.PermissionEdit)
+	if httpErr != nil {
+		return nil, httpErr
 	}
 
 	switch existing.State {
@@ -191,3 +242,140 @@ func (s *HelixAPIServer) refreshKnowledge(_ http.ResponseWriter, r *http.Request
 
 	return updated, nil
 }
+
+// exportKnowledge godoc
+// @Summary Export knowledge
+// @Description Export the knowledge, its RAG settings and the extracted text as a gzipped tarball that can be imported into another Helix instance
+// @Tags    knowledge
+// @Produce application/gzip
+// @Param   id                 path   string  true   "Knowledge ID"
+// @Param   include_embeddings query  bool    false  "Export the chunk embeddings"
+// @Success 200 {file} file
+// @Router /api/v1/knowledge/{id}/export [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) exportKnowledge(rw http.ResponseWriter, r *http.Request) {
+	user := getRequestUser(r)
+	id := getID(r)
+
+	existing, err := s.Store.GetKnowledge(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(rw, store.ErrNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Exports contain the indexed content of every document
+	httpErr := s.authorizeKnowledge(r.Context(), user, existing, types.PermissionEdit)
+	if httpErr != nil {
+		http.Error(rw, httpErr.Message, httpErr.StatusCode)
+		return
+	}
+
+	if existing.State != types.KnowledgeStateReady {
+		http.Error(rw, fmt.Sprintf("knowledge is %s, only ready knowledge can be exported", existing.State), http.StatusBadRequest)
+		return
+	}
+
+	includeEmbeddings, _ := strconv.ParseBool(r.URL.Query().Get("include_embeddings"))
+
+	archive := &exportResponseWriter{
+		rw:       rw,
+		filename: existing.ID + ".tar.gz",
+	}
+
+	err = s.knowledgeManager.Export(r.Context(), existing, archive, &knowledge.ExportOptions{
+		IncludeEmbeddings: includeEmbeddings,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("knowledge_id", existing.ID).Msg("error exporting knowledge")
+
+		// The status is already sent, the connection is aborted so the client
+		// doesn't mistake the truncated archive for a complete one
+		if archive.started {
+			panic(http.ErrAbortHandler)
+		}
+
+		if errors.Is(err, knowledge.ErrExportNotSupported) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// exportResponseWriter streams the export to the response, the archive
+// headers are sent with the first write so failures before it can still be
+// reported with an error status
+type exportResponseWriter struct {
+	rw       http.ResponseWriter
+	filename string
+	started  bool
+}
+
+func (w *exportResponseWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.rw.Header().Set("Content-Type", "application/gzip")
+		w.rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", w.filename))
+		w.started = true
+	}
+
+	return w.rw.Write(p)
+}
+
+// importKnowledge godoc
+// @Summary Import knowledge
+// @Description Recreate knowledge from an export of another Helix instance, the chunks are indexed without fetching the sources again
+// @Tags    knowledge
+// @Accept  application/gzip
+// @Produce json
+// @Param   app_id  query  string  false  "App of the imported knowledge"
+// @Param   org_id  query  string  false  "Organization that owns the imported knowledge when no app is set"
+// @Param   name    query  string  false  "Name of the imported knowledge, defaults to the exported name"
+// @Success 200 {object} types.Knowledge
+// @Router /api/v1/knowledge/import [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) importKnowledge(_ http.ResponseWriter, r *http.Request) (*types.Knowledge, *system.HTTPError) {
+	ctx := r.Context()
+	user := getRequestUser(r)
+
+	owner, ownerType, httpErr := s.requestOwner(r, user, types.OrganizationRoleMember)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	appID := r.URL.Query().Get("app_id")
+	if appID != "" {
+		app, err := s.Store.GetApp(ctx, appID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+			}
+			return nil, system.NewHTTPError500(err.Error())
+		}
+
+		httpErr := s.authorizeApp(ctx, user, app, types.PermissionEdit)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+
+		// Knowledge belongs to the owner of its app
+		owner, ownerType = app.Owner, app.OwnerType
+	}
+
+	imported, err := s.knowledgeManager.Import(ctx, r.Body, &knowledge.ImportOptions{
+		Owner:     owner,
+		OwnerType: ownerType,
+		AppID:     appID,
+		Name:      r.URL.Query().Get("name"),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("error importing knowledge")
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	return imported, nil
+}
@@ -23,15 +23,43 @@ func (s *HelixAPIServer) knowledgeSearch(_ http.ResponseWriter, r *http.Request)
 	knowledgeID := r.URL.Query().Get("knowledge_id") // Optional knowledge ID to search within
 	prompt := r.URL.Query().Get("prompt")            // Search query
 
-	knowledges, err := s.Controller.Options.Store.ListKnowledge(ctx, &store.ListKnowledgeQuery{
-		AppID: appID,
-		Owner: user.ID,
-		ID:    knowledgeID,
-	})
+	// Optional metadata filters, can be repeated
+	filters := types.RAGQueryFilters{
+		Filenames: r.URL.Query()["filename"],
+		Sources:   r.URL.Query()["source"],
+	}
+
+	query := &store.ListKnowledgeQuery{
+		AppID:  appID,
+		Owner:  user.ID,
+		Member: user.ID,
+		ID:     knowledgeID,
+	}
+	// Knowledge of an app can also be searched by the users it was shared with,
+	// access is checked below
+	if appID != "" || knowledgeID != "" {
+		query = &store.ListKnowledgeQuery{
+			AppID: appID,
+			ID:    knowledgeID,
+		}
+	}
+
+	listed, err := s.Controller.Options.Store.ListKnowledge(ctx, query)
 	if err != nil {
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
+	var knowledges []*types.Knowledge
+	for _, knowledge := range listed {
+		role, err := s.Controller.KnowledgeRole(ctx, user, knowledge)
+		if err != nil {
+			return nil, system.NewHTTPError500(err.Error())
+		}
+		if role.Has(types.PermissionView) {
+			knowledges = append(knowledges, knowledge)
+		}
+	}
+
 	var (
 		results   []*types.KnowledgeSearchResult
 		resultsMu sync.Mutex
@@ -62,6 +90,9 @@ func (s *HelixAPIServer) knowledgeSearch(_ http.ResponseWriter, r *http.Request)
 				DistanceThreshold: knowledge.RAGSettings.Threshold,
 				DistanceFunction:  knowledge.RAGSettings.DistanceFunction,
 				MaxResults:        knowledge.RAGSettings.ResultsCount,
+				RetrievalMode:     knowledge.RAGSettings.RetrievalMode,
+				HybridAlpha:       knowledge.RAGSettings.HybridAlpha,
+				Filters:           filters,
 			})
 			if err != nil {
 				return fmt.Errorf("error querying RAG for knowledge %s: %w", knowledge.ID, err)
@@ -84,8 +84,9 @@ func (s *HelixAPIServer) listAppLLMCalls(w http.ResponseWriter, r *http.Request)
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if app.Owner != user.ID && !isAdmin(user) {
-		return nil, system.NewHTTPError403("you do not have permission to view this app's LLM calls")
+	httpErr := s.authorizeApp(r.Context(), user, app, types.PermissionEdit)
+	if httpErr != nil {
+		return nil, httpErr
 	}
 
 	// Parse query parameters
@@ -29,7 +29,7 @@ const (
 // @Summary Stream responses for chat
 // @Description Creates a model response for the given chat conversation.
 // @Tags    chat
-// @Success 200 {object} openai.ChatCompletionResponse
+// @Success 200 {object} types.ChatCompletionResponse
 // @Param request    body openai.ChatCompletionRequest true "Request body with options for conversational AI.")
 // @Router /v1/chat/completions [post]
 // @Security BearerAuth
@@ -76,6 +76,7 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 		OwnerID:         user.ID,
 		SessionID:       "n/a",
 		InteractionID:   "n/a",
+		APIKey:          getRequestAPIKey(user),
 		OriginalRequest: body,
 	})
 
@@ -94,6 +95,10 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 		}(),
 	}
 
+	if !s.checkQuota(ctx, rw, user, options.AppID) {
+		return
+	}
+
 	if user.AppID != "" {
 		options.AppID = user.AppID
 
@@ -162,9 +167,14 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 
 		rw.Header().Set("Content-Type", "application/json")
 
+		response := &types.ChatCompletionResponse{
+			ChatCompletionResponse: *resp,
+			Citations:              options.Citations,
+		}
+
 		if r.URL.Query().Get("pretty") == "true" {
 			// Pretty print the response with indentation
-			bts, err := json.MarshalIndent(resp, "", "  ")
+			bts, err := json.MarshalIndent(response, "", "  ")
 			if err != nil {
 				log.Error().Err(err).Msg("error marshalling response")
 				http.Error(rw, err.Error(), http.StatusInternalServerError)
@@ -175,7 +185,7 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 			return
 		}
 
-		err = json.NewEncoder(rw).Encode(resp)
+		err = json.NewEncoder(rw).Encode(response)
 		if err != nil {
 			log.Error().Err(err).Msg("error writing response")
 		}
@@ -194,6 +204,8 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 	rw.Header().Set("Cache-Control", "no-cache")
 	rw.Header().Set("Connection", "keep-alive")
 
+	var last openai.ChatCompletionStreamResponse
+
 	// Write the stream into the response
 	for {
 		response, err := stream.Recv()
@@ -204,6 +216,7 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 			http.Error(rw, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		last = response
 
 		// Write the response to the client
 		bts, err := json.Marshal(response)
@@ -221,6 +234,31 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 		}
 	}
 
+	writeCitationsChunk(rw, last.ID, last.Model, options.Citations)
+}
+
+// writeCitationsChunk sends the citations after the streamed response, the
+// chunk has no choices so OpenAI clients skip it
+func writeCitationsChunk(rw http.ResponseWriter, id, model string, citations []*types.Citation) {
+	if len(citations) == 0 {
+		return
+	}
+
+	bts, err := json.Marshal(&types.ChatCompletionStreamResponse{
+		ChatCompletionStreamResponse: openai.ChatCompletionStreamResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Model:   model,
+			Choices: []openai.ChatCompletionStreamChoice{},
+		},
+		Citations: citations,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("error marshalling citations")
+		return
+	}
+
+	writeChunk(rw, bts)
 }
 
 func (s *HelixAPIServer) getAppLoraAssistant(ctx context.Context, appID string) (*types.AssistantConfig, error) {
@@ -24,6 +24,7 @@ import (
 	"github.com/helixml/helix/api/pkg/openai"
 	"github.com/helixml/helix/api/pkg/openai/manager"
 	"github.com/helixml/helix/api/pkg/pubsub"
+	"github.com/helixml/helix/api/pkg/quota"
 	"github.com/helixml/helix/api/pkg/rag"
 	"github.com/helixml/helix/api/pkg/scheduler"
 	"github.com/helixml/helix/api/pkg/store"
@@ -85,7 +86,7 @@ func (suite *OpenAIChatSuite) SetupTest() {
 		Filestore:       filestoreMock,
 		Extractor:       extractorMock,
 		RAG:             suite.rag,
-		Scheduler:       scheduler.NewScheduler(context.Background(), cfg, nil),
+		Scheduler:       scheduler.NewScheduler(context.Background(), cfg, nil, nil),
 		PubSub:          suite.pubsub,
 	})
 	suite.NoError(err)
@@ -165,6 +166,43 @@ func (suite *OpenAIChatSuite) TestChatCompletions_Basic_Blocking() {
 	suite.Equal("**model-result**", resp.Choices[0].Message.Content)
 }
 
+func (suite *OpenAIChatSuite) TestChatCompletions_QuotaExceeded() {
+	cfg := &config.ServerConfig{}
+	cfg.UsageQuotas.Enabled = true
+	cfg.UsageQuotas.User.DailyTokens = 1000
+
+	suite.server.quotaManager = quota.NewManager(cfg, suite.store)
+
+	suite.store.EXPECT().SumLLMCallTokens(gomock.Any(), gomock.Any()).Return(int64(1500), nil)
+
+	req, err := http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(`{
+		"model": "meta-llama/Meta-Llama-3.1-8B-Instruct-Turbo",
+		"messages": [
+			{
+				"role": "user",
+				"content": "tell me about oceans!"
+			}
+		]
+	}`))
+	suite.NoError(err)
+
+	req = req.WithContext(suite.authCtx)
+
+	rec := httptest.NewRecorder()
+
+	suite.server.createChatCompletion(rec, req)
+
+	suite.Equal(http.StatusTooManyRequests, rec.Code)
+	suite.NotEmpty(rec.Header().Get("Retry-After"))
+
+	var resp oai.ErrorResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &resp)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(resp.Error)
+	suite.Equal("insufficient_quota", resp.Error.Type)
+	suite.Equal("insufficient_quota", resp.Error.Code)
+}
+
 func (suite *OpenAIChatSuite) TestChatCompletions_Streaming() {
 
 	req, err := http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(`{
@@ -526,10 +564,13 @@ func (suite *OpenAIChatSuite) TestChatCompletions_AppRag_Blocking() {
 		MaxResults:        2,
 	}).Return([]*types.SessionRAGResult{
 		{
-			Content: "This is a test RAG source 1",
+			DocumentID: "doc_1",
+			Content:    "This is a test RAG source 1",
 		},
 		{
-			Content: "This is a test RAG source 2",
+			DocumentID: "doc_2",
+			Content:    "This is a test RAG source 2",
+			Metadata:   map[string]string{types.RAGMetadataPage: "2"},
 		},
 	}, nil)
 
@@ -606,6 +647,16 @@ func (suite *OpenAIChatSuite) TestChatCompletions_AppRag_Blocking() {
 	suite.Equal(oai.FinishReasonStop, resp.Choices[0].FinishReason)
 	suite.Equal("assistant", resp.Choices[0].Message.Role)
 	suite.Equal("**model-result**", resp.Choices[0].Message.Content)
+
+	// Knowledge the answer is based on
+	var citationsResp types.ChatCompletionResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &citationsResp)
+	suite.NoError(err)
+
+	suite.Equal([]*types.Citation{
+		{DocumentID: "doc_1", Snippet: "This is a test RAG source 1"},
+		{DocumentID: "doc_2", Snippet: "This is a test RAG source 2", Metadata: map[string]string{types.RAGMetadataPage: "2"}},
+	}, citationsResp.Citations)
 }
 
 // TestChatCompletions_AppFromAuth_Blocking test that simulates app id coming
@@ -1009,3 +1060,22 @@ func (suite *OpenAIChatSuite) TestChatCompletions_App_CustomQueryParams() {
 	err = json.Unmarshal(rec.Body.Bytes(), &resp)
 	suite.NoError(err)
 }
+
+func Test_writeCitationsChunk(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	// Nothing is written without citations
+	writeCitationsChunk(rec, "chat_id", "model", nil)
+	require.Empty(t, rec.Body.String())
+
+	writeCitationsChunk(rec, "chat_id", "model", []*types.Citation{{DocumentID: "doc_1", Source: "https://docs.helix.ml"}})
+
+	data, ok := strings.CutPrefix(strings.TrimSpace(rec.Body.String()), "data: ")
+	require.True(t, ok)
+
+	var chunk types.ChatCompletionStreamResponse
+	require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+	require.Equal(t, "chat_id", chunk.ID)
+	require.Empty(t, chunk.Choices)
+	require.Equal(t, []*types.Citation{{DocumentID: "doc_1", Source: "https://docs.helix.ml"}}, chunk.Citations)
+}
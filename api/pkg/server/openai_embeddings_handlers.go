@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// createEmbeddings godoc
+// @Summary Creates embeddings
+// @Description Creates an embedding vector representing the input text. The provider query parameter selects the provider, defaults to the inference provider.
+// @Tags    embeddings
+// @Success 200 {object} openai.EmbeddingResponse
+// @Param request    body openai.EmbeddingRequest true "Request body with the input and the model."
+// @Param provider   query string false "Provider to create the embeddings with"
+// @Router /v1/embeddings [post]
+// @Security BearerAuth
+// @externalDocs.url https://platform.openai.com/docs/api-reference/embeddings/create
+func (s *HelixAPIServer) createEmbeddings(rw http.ResponseWriter, r *http.Request) {
+	addCorsHeaders(rw)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	user := getRequestUser(r)
+
+	if !hasUser(user) {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		log.Error().Msg("unauthorized")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 10*MEGABYTE))
+	if err != nil {
+		log.Error().Err(err).Msg("error reading body")
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var embeddingRequest openai.EmbeddingRequest
+	err = json.Unmarshal(body, &embeddingRequest)
+	if err != nil {
+		log.Error().Err(err).Msg("error unmarshalling body")
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if embeddingRequest.Input == nil {
+		http.Error(rw, "input is required", http.StatusBadRequest)
+		return
+	}
+
+	appID := r.URL.Query().Get("app_id")
+	if user.AppID != "" {
+		appID = user.AppID
+	} else if appID != "" {
+		// The usage is attributed to the app, only for users who can chat with it
+		app, err := s.Store.GetApp(r.Context(), appID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(rw, "app not found", http.StatusNotFound)
+				return
+			}
+			log.Error().Err(err).Str("app_id", appID).Msg("error getting app")
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if httpErr := s.authorizeApp(r.Context(), user, app, types.PermissionChat); httpErr != nil {
+			http.Error(rw, httpErr.Message, httpErr.StatusCode)
+			return
+		}
+	}
+
+	ctx := oai.SetContextValues(r.Context(), &oai.ContextValues{
+		OwnerID:         user.ID,
+		SessionID:       "n/a",
+		InteractionID:   "n/a",
+		APIKey:          getRequestAPIKey(user),
+		OriginalRequest: body,
+	})
+
+	if !s.checkQuota(ctx, rw, user, appID) {
+		return
+	}
+
+	ctx = oai.SetContextAppID(ctx, appID)
+
+	resp, err := s.Controller.CreateEmbeddings(ctx, types.Provider(r.URL.Query().Get("provider")), embeddingRequest)
+	if err != nil {
+		log.Error().Err(err).Msg("error creating embeddings")
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(rw).Encode(resp)
+	if err != nil {
+		log.Error().Err(err).Msg("error writing response")
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	oai "github.com/sashabaranov/go-openai"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/auth"
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/quota"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func (suite *OpenAIChatSuite) TestEmbeddings() {
+	req, err := http.NewRequest("POST", "/v1/embeddings", bytes.NewBufferString(`{
+		"model": "BAAI/bge-large-en-v1.5",
+		"input": ["tell me about oceans!", "and rivers"]
+	}`))
+	suite.NoError(err)
+
+	req = req.WithContext(suite.authCtx)
+
+	rec := httptest.NewRecorder()
+
+	suite.openAiClient.EXPECT().CreateEmbeddings(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, request oai.EmbeddingRequestConverter) (oai.EmbeddingResponse, error) {
+			embeddingReq := request.Convert()
+			suite.Equal(oai.EmbeddingModel("BAAI/bge-large-en-v1.5"), embeddingReq.Model)
+			suite.Equal([]any{"tell me about oceans!", "and rivers"}, embeddingReq.Input)
+
+			vals, ok := openai.GetContextValues(ctx)
+			suite.True(ok)
+			suite.Equal("user_id", vals.OwnerID)
+
+			return oai.EmbeddingResponse{
+				Model: "BAAI/bge-large-en-v1.5",
+				Data: []oai.Embedding{
+					{Index: 0, Embedding: []float32{0.1, 0.2}},
+					{Index: 1, Embedding: []float32{0.3, 0.4}},
+				},
+				Usage: oai.Usage{PromptTokens: 8, TotalTokens: 8},
+			}, nil
+		})
+
+	suite.server.createEmbeddings(rec, req)
+
+	suite.Equal(http.StatusOK, rec.Code)
+
+	var resp oai.EmbeddingResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &resp)
+	suite.Require().NoError(err)
+
+	suite.Require().Len(resp.Data, 2)
+	suite.Equal([]float32{0.3, 0.4}, resp.Data[1].Embedding)
+	suite.Equal(8, resp.Usage.TotalTokens)
+}
+
+func (suite *OpenAIChatSuite) TestEmbeddings_MissingInput() {
+	req, err := http.NewRequest("POST", "/v1/embeddings", bytes.NewBufferString(`{
+		"model": "BAAI/bge-large-en-v1.5"
+	}`))
+	suite.NoError(err)
+
+	req = req.WithContext(suite.authCtx)
+
+	rec := httptest.NewRecorder()
+
+	suite.server.createEmbeddings(rec, req)
+
+	suite.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func (suite *OpenAIChatSuite) TestEmbeddings_QuotaExceeded() {
+	cfg := &config.ServerConfig{}
+	cfg.UsageQuotas.Enabled = true
+	cfg.UsageQuotas.User.DailyTokens = 1000
+
+	suite.server.quotaManager = quota.NewManager(cfg, suite.store)
+
+	suite.store.EXPECT().SumLLMCallTokens(gomock.Any(), gomock.Any()).Return(int64(1500), nil)
+
+	req, err := http.NewRequest("POST", "/v1/embeddings", bytes.NewBufferString(`{
+		"model": "BAAI/bge-large-en-v1.5",
+		"input": "tell me about oceans!"
+	}`))
+	suite.NoError(err)
+
+	req = req.WithContext(suite.authCtx)
+
+	rec := httptest.NewRecorder()
+
+	suite.server.createEmbeddings(rec, req)
+
+	suite.Equal(http.StatusTooManyRequests, rec.Code)
+}
+
+func (suite *OpenAIChatSuite) TestEmbeddings_OtherUsersApp() {
+	suite.store.EXPECT().GetApp(gomock.Any(), "app_id").Return(&types.App{
+		ID:        "app_id",
+		Owner:     "other_user",
+		OwnerType: types.OwnerTypeUser,
+	}, nil)
+	suite.store.EXPECT().ListAccessGrants(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	req, err := http.NewRequest("POST", "/v1/embeddings?app_id=app_id", bytes.NewBufferString(`{
+		"model": "BAAI/bge-large-en-v1.5",
+		"input": "tell me about oceans!"
+	}`))
+	suite.NoError(err)
+
+	req = req.WithContext(suite.authCtx)
+
+	rec := httptest.NewRecorder()
+
+	suite.server.createEmbeddings(rec, req)
+
+	suite.Equal(http.StatusForbidden, rec.Code)
+}
+
+func (suite *OpenAIChatSuite) TestEmbeddings_AppAPIKey() {
+	middleware := newAuthMiddleware(auth.NewMockAuthenticator(&types.User{}), suite.store, authMiddlewareConfig{})
+
+	suite.store.EXPECT().GetAPIKey(gomock.Any(), "hl-app-key").Return(&types.APIKey{
+		Key:       "hl-app-key",
+		Owner:     suite.userID,
+		OwnerType: types.OwnerTypeUser,
+		AppID:     &sql.NullString{String: "app_id", Valid: true},
+	}, nil)
+
+	suite.openAiClient.EXPECT().CreateEmbeddings(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ oai.EmbeddingRequestConverter) (oai.EmbeddingResponse, error) {
+			appID, ok := openai.GetContextAppID(ctx)
+			suite.True(ok)
+			suite.Equal("app_id", appID)
+
+			return oai.EmbeddingResponse{
+				Data: []oai.Embedding{{Index: 0, Embedding: []float32{0.1, 0.2}}},
+			}, nil
+		})
+
+	req, err := http.NewRequest("POST", "/v1/embeddings", bytes.NewBufferString(`{
+		"model": "BAAI/bge-large-en-v1.5",
+		"input": "tell me about oceans!"
+	}`))
+	suite.NoError(err)
+	req.Header.Set("Authorization", "Bearer hl-app-key")
+
+	rec := httptest.NewRecorder()
+
+	middleware.auth(suite.server.createEmbeddings)(rec, req)
+
+	suite.Equal(http.StatusOK, rec.Code, rec.Body.String())
+}
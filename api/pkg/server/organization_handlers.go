@@ -0,0 +1,393 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/helixml/helix/api/pkg/auth"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// listOrganizations godoc
+// @Summary List organizations
+// @Description List the organizations the user is a member of.
+// @Tags    organizations
+// @Success 200 {array} types.Organization
+// @Router /api/v1/orgs [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listOrganizations(_ http.ResponseWriter, r *http.Request) ([]*types.Organization, *system.HTTPError) {
+	user := getRequestUser(r)
+
+	orgs, err := s.Store.ListOrganizations(r.Context(), &store.ListOrganizationsQuery{
+		UserID: user.ID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return orgs, nil
+}
+
+// createOrganization godoc
+// @Summary Create a new organization
+// @Description Create a new organization, the user becomes its owner.
+// @Tags    organizations
+// @Success 200 {object} types.Organization
+// @Param request body types.Organization true "Request body with the organization name."
+// @Router /api/v1/orgs [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) createOrganization(_ http.ResponseWriter, r *http.Request) (*types.Organization, *system.HTTPError) {
+	user := getRequestUser(r)
+
+	var org types.Organization
+	if err := json.NewDecoder(r.Body).Decode(&org); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if org.Name == "" {
+		return nil, system.NewHTTPError400("name is required")
+	}
+
+	org.ID = ""
+	org.Owner = user.ID
+
+	created, err := s.Store.CreateOrganization(r.Context(), &org)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return created, nil
+}
+
+// getOrganization godoc
+// @Summary Get an organization
+// @Tags    organizations
+// @Success 200 {object} types.Organization
+// @Param id path string true "Organization ID"
+// @Router /api/v1/orgs/{id} [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) getOrganization(_ http.ResponseWriter, r *http.Request) (*types.Organization, *system.HTTPError) {
+	user := getRequestUser(r)
+	id := getID(r)
+
+	org, httpErr := s.getOrganizationForUser(r, user, id, types.OrganizationRoleMember)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	return org, nil
+}
+
+// updateOrganization godoc
+// @Summary Update an organization
+// @Description Update the name of an organization, requires the admin role.
+// @Tags    organizations
+// @Success 200 {object} types.Organization
+// @Param request body types.Organization true "Request body with the organization name."
+// @Param id path string true "Organization ID"
+// @Router /api/v1/orgs/{id} [put]
+// @Security BearerAuth
+func (s *HelixAPIServer) updateOrganization(_ http.ResponseWriter, r *http.Request) (*types.Organization, *system.HTTPError) {
+	user := getRequestUser(r)
+	id := getID(r)
+
+	var update types.Organization
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	existing, httpErr := s.getOrganizationForUser(r, user, id, types.OrganizationRoleAdmin)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if update.Name != "" {
+		existing.Name = update.Name
+	}
+	existing.DisplayName = update.DisplayName
+
+	updated, err := s.Store.UpdateOrganization(r.Context(), existing)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return updated, nil
+}
+
+// deleteOrganization godoc
+// @Summary Delete an organization
+// @Description Delete an organization, requires the owner role. Organizations that still own apps, knowledge or secrets can't be deleted.
+// @Tags    organizations
+// @Success 200 {object} types.Organization
+// @Param id path string true "Organization ID"
+// @Router /api/v1/orgs/{id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) deleteOrganization(_ http.ResponseWriter, r *http.Request) (*types.Organization, *system.HTTPError) {
+	ctx := r.Context()
+	user := getRequestUser(r)
+	id := getID(r)
+
+	existing, httpErr := s.getOrganizationForUser(r, user, id, types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	apps, err := s.Store.ListApps(ctx, &store.ListAppsQuery{Owner: id, OwnerType: types.OwnerTypeOrg})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+	knowledge, err := s.Store.ListKnowledge(ctx, &store.ListKnowledgeQuery{Owner: id, OwnerType: types.OwnerTypeOrg})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+	secrets, err := s.Store.ListSecrets(ctx, &store.ListSecretsQuery{Owner: id, OwnerType: types.OwnerTypeOrg})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	if len(apps) > 0 || len(knowledge) > 0 || len(secrets) > 0 {
+		return nil, system.NewHTTPError400("organization still owns %d apps, %d knowledge and %d secrets, delete them first", len(apps), len(knowledge), len(secrets))
+	}
+
+	err = s.Store.DeleteOrganization(ctx, id)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing, nil
+}
+
+// listOrganizationMembers godoc
+// @Summary List organization members
+// @Tags    organizations
+// @Success 200 {array} types.OrganizationMembership
+// @Param id path string true "Organization ID"
+// @Router /api/v1/orgs/{id}/members [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listOrganizationMembers(_ http.ResponseWriter, r *http.Request) ([]*types.OrganizationMembership, *system.HTTPError) {
+	user := getRequestUser(r)
+	id := getID(r)
+
+	if _, httpErr := s.getOrganizationForUser(r, user, id, types.OrganizationRoleMember); httpErr != nil {
+		return nil, httpErr
+	}
+
+	memberships, err := s.Store.ListOrganizationMemberships(r.Context(), &store.ListOrganizationMembershipsQuery{
+		OrganizationID: id,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return memberships, nil
+}
+
+// addOrganizationMember godoc
+// @Summary Add an organization member
+// @Description Add a user to the organization, requires the admin role. Only owners can add other owners.
+// @Tags    organizations
+// @Success 200 {object} types.OrganizationMembership
+// @Param request body types.AddOrganizationMemberRequest true "Request body with the user ID and role."
+// @Param id path string true "Organization ID"
+// @Router /api/v1/orgs/{id}/members [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) addOrganizationMember(_ http.ResponseWriter, r *http.Request) (*types.OrganizationMembership, *system.HTTPError) {
+	user := getRequestUser(r)
+	id := getID(r)
+
+	var req types.AddOrganizationMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if req.UserID == "" {
+		return nil, system.NewHTTPError400("user_id is required")
+	}
+
+	if req.Role == "" {
+		req.Role = types.OrganizationRoleMember
+	}
+
+	if !req.Role.Valid() {
+		return nil, system.NewHTTPError400("invalid role '%s'", req.Role)
+	}
+
+	if _, httpErr := s.getOrganizationForUser(r, user, id, requiredRoleToManage(req.Role)); httpErr != nil {
+		return nil, httpErr
+	}
+
+	if _, err := s.authMiddleware.authenticator.GetUserByID(r.Context(), req.UserID); err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, system.NewHTTPError400("user '%s' does not exist", req.UserID)
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	created, err := s.Store.CreateOrganizationMembership(r.Context(), &types.OrganizationMembership{
+		OrganizationID: id,
+		UserID:         req.UserID,
+		Role:           req.Role,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return created, nil
+}
+
+// updateOrganizationMember godoc
+// @Summary Update the role of an organization member
+// @Description Change the role of a member, requires the admin role. Only owners can grant or revoke the owner role.
+// @Tags    organizations
+// @Success 200 {object} types.OrganizationMembership
+// @Param request body types.UpdateOrganizationMemberRequest true "Request body with the new role."
+// @Param id path string true "Organization ID"
+// @Param user_id path string true "User ID"
+// @Router /api/v1/orgs/{id}/members/{user_id} [put]
+// @Security BearerAuth
+func (s *HelixAPIServer) updateOrganizationMember(_ http.ResponseWriter, r *http.Request) (*types.OrganizationMembership, *system.HTTPError) {
+	ctx := r.Context()
+	user := getRequestUser(r)
+	id := getID(r)
+	userID := mux.Vars(r)["user_id"]
+
+	var req types.UpdateOrganizationMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if !req.Role.Valid() {
+		return nil, system.NewHTTPError400("invalid role '%s'", req.Role)
+	}
+
+	existing, httpErr := s.getOrganizationMember(r, id, userID)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	// Changing from or to owner needs the owner role
+	requiredRole := requiredRoleToManage(req.Role)
+	if existing.Role == types.OrganizationRoleOwner {
+		requiredRole = types.OrganizationRoleOwner
+	}
+
+	if _, httpErr := s.getOrganizationForUser(r, user, id, requiredRole); httpErr != nil {
+		return nil, httpErr
+	}
+
+	if existing.Role == types.OrganizationRoleOwner && req.Role != types.OrganizationRoleOwner {
+		if httpErr := s.ensureAnotherOwner(r, id, userID); httpErr != nil {
+			return nil, httpErr
+		}
+	}
+
+	existing.Role = req.Role
+
+	updated, err := s.Store.UpdateOrganizationMembership(ctx, existing)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return updated, nil
+}
+
+// removeOrganizationMember godoc
+// @Summary Remove an organization member
+// @Description Remove a user from the organization, requires the admin role unless members remove themselves. Only owners can remove other owners.
+// @Tags    organizations
+// @Success 200 {object} types.OrganizationMembership
+// @Param id path string true "Organization ID"
+// @Param user_id path string true "User ID"
+// @Router /api/v1/orgs/{id}/members/{user_id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) removeOrganizationMember(_ http.ResponseWriter, r *http.Request) (*types.OrganizationMembership, *system.HTTPError) {
+	user := getRequestUser(r)
+	id := getID(r)
+	userID := mux.Vars(r)["user_id"]
+
+	existing, httpErr := s.getOrganizationMember(r, id, userID)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	// Anyone can leave, removing others needs the rights to manage their role
+	if userID != user.ID {
+		if _, httpErr := s.getOrganizationForUser(r, user, id, requiredRoleToManage(existing.Role)); httpErr != nil {
+			return nil, httpErr
+		}
+	}
+
+	if existing.Role == types.OrganizationRoleOwner {
+		if httpErr := s.ensureAnotherOwner(r, id, userID); httpErr != nil {
+			return nil, httpErr
+		}
+	}
+
+	err := s.Store.DeleteOrganizationMembership(r.Context(), id, userID)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing, nil
+}
+
+// getOrganizationForUser returns the organization if the user has at least the role in it
+func (s *HelixAPIServer) getOrganizationForUser(r *http.Request, user *types.User, id string, role types.OrganizationRole) (*types.Organization, *system.HTTPError) {
+	org, err := s.Store.GetOrganization(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	httpErr := s.authorizeOwner(r.Context(), user, org.ID, types.OwnerTypeOrg, role, fmt.Sprintf("you need the %s role in this organization", role))
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	return org, nil
+}
+
+func (s *HelixAPIServer) getOrganizationMember(r *http.Request, orgID, userID string) (*types.OrganizationMembership, *system.HTTPError) {
+	membership, err := s.Store.GetOrganizationMembership(r.Context(), orgID, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("member not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+	return membership, nil
+}
+
+// ensureAnotherOwner makes sure that an organization is not left without owners
+func (s *HelixAPIServer) ensureAnotherOwner(r *http.Request, orgID, userID string) *system.HTTPError {
+	memberships, err := s.Store.ListOrganizationMemberships(r.Context(), &store.ListOrganizationMembershipsQuery{
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return system.NewHTTPError500(err.Error())
+	}
+
+	for _, m := range memberships {
+		if m.UserID != userID && m.Role == types.OrganizationRoleOwner {
+			return nil
+		}
+	}
+
+	return system.NewHTTPError400("an organization needs at least one owner")
+}
+
+// requiredRoleToManage returns the role needed to add or remove a member with the given role
+func requiredRoleToManage(role types.OrganizationRole) types.OrganizationRole {
+	if role == types.OrganizationRoleOwner {
+		return types.OrganizationRoleOwner
+	}
+	return types.OrganizationRoleAdmin
+}
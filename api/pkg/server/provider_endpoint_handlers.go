@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// listProviderEndpoints godoc
+// @Summary List provider endpoints
+// @Description List OpenAI compatible provider endpoints stored in the database.
+// @Tags    providers
+// @Success 200 {array} types.ProviderEndpoint
+// @Router /api/v1/provider-endpoints [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listProviderEndpoints(_ http.ResponseWriter, r *http.Request) ([]*types.ProviderEndpoint, *system.HTTPError) {
+	endpoints, err := s.Store.ListProviderEndpoints(r.Context(), &store.ListProviderEndpointsQuery{})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return endpoints, nil
+}
+
+// createProviderEndpoint godoc
+// @Summary Create a new provider endpoint
+// @Description Create a new OpenAI compatible provider endpoint. It becomes available
+// @Description as a provider without restarting the server.
+// @Tags    providers
+// @Success 200 {object} types.ProviderEndpoint
+// @Param request body types.ProviderEndpoint true "Request body with provider endpoint configuration."
+// @Router /api/v1/provider-endpoints [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) createProviderEndpoint(_ http.ResponseWriter, r *http.Request) (*types.ProviderEndpoint, *system.HTTPError) {
+	user := getRequestUser(r)
+
+	var endpoint types.ProviderEndpoint
+	if err := json.NewDecoder(r.Body).Decode(&endpoint); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	endpoint.ID = ""
+
+	if httpErr := s.validateProviderEndpoint(r, &endpoint); httpErr != nil {
+		return nil, httpErr
+	}
+
+	endpoint.Owner = user.ID
+	endpoint.OwnerType = types.OwnerTypeUser
+
+	created, err := s.Store.CreateProviderEndpoint(r.Context(), &endpoint)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return created, nil
+}
+
+// updateProviderEndpoint godoc
+// @Summary Update an existing provider endpoint
+// @Description Update an existing provider endpoint. Clients are recreated on next use.
+// @Tags    providers
+// @Success 200 {object} types.ProviderEndpoint
+// @Param request body types.ProviderEndpoint true "Request body with updated provider endpoint configuration."
+// @Param id path string true "Provider endpoint ID"
+// @Router /api/v1/provider-endpoints/{id} [put]
+// @Security BearerAuth
+func (s *HelixAPIServer) updateProviderEndpoint(_ http.ResponseWriter, r *http.Request) (*types.ProviderEndpoint, *system.HTTPError) {
+	id := getID(r)
+
+	existing, err := s.Store.GetProviderEndpoint(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("Provider endpoint not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	var endpoint types.ProviderEndpoint
+	if err := json.NewDecoder(r.Body).Decode(&endpoint); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	// Name is used to reference the provider from apps, renaming
+	// would silently break them
+	if endpoint.Name != existing.Name {
+		return nil, system.NewHTTPError400("provider endpoint name cannot be changed")
+	}
+
+	endpoint.ID = existing.ID
+
+	if httpErr := s.validateProviderEndpoint(r, &endpoint); httpErr != nil {
+		return nil, httpErr
+	}
+
+	endpoint.Created = existing.Created
+	endpoint.Owner = existing.Owner
+	endpoint.OwnerType = existing.OwnerType
+
+	updated, err := s.Store.UpdateProviderEndpoint(r.Context(), &endpoint)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return updated, nil
+}
+
+// deleteProviderEndpoint godoc
+// @Summary Delete a provider endpoint
+// @Description Delete a provider endpoint.
+// @Tags    providers
+// @Success 200 {object} types.ProviderEndpoint
+// @Param id path string true "Provider endpoint ID"
+// @Router /api/v1/provider-endpoints/{id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) deleteProviderEndpoint(_ http.ResponseWriter, r *http.Request) (*types.ProviderEndpoint, *system.HTTPError) {
+	id := getID(r)
+
+	existing, err := s.Store.GetProviderEndpoint(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("Provider endpoint not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	err = s.Store.DeleteProviderEndpoint(r.Context(), id)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing, nil
+}
+
+func (s *HelixAPIServer) validateProviderEndpoint(r *http.Request, endpoint *types.ProviderEndpoint) *system.HTTPError {
+	if endpoint.Name == "" {
+		return system.NewHTTPError400("name is required")
+	}
+
+	if endpoint.BaseURL == "" {
+		return system.NewHTTPError400("base_url is required")
+	}
+
+	if types.Provider(endpoint.Name).IsBuiltin() {
+		return system.NewHTTPError400("provider name '%s' is reserved", endpoint.Name)
+	}
+
+	// API keys must come from the secrets store, we don't want
+	// to keep them in plain text in the provider endpoints table
+	if endpoint.APIKey != "" {
+		return system.NewHTTPError400("api_key cannot be set directly, create a secret and set api_key_secret instead")
+	}
+
+	providers, err := s.providerManager.ListProviders(r.Context())
+	if err != nil {
+		return system.NewHTTPError500(err.Error())
+	}
+
+	for _, provider := range providers {
+		if string(provider) != endpoint.Name {
+			continue
+		}
+		// Either a config declared provider or another stored
+		// endpoint, updates of the same endpoint are fine
+		existing, err := s.Store.GetProviderEndpointByName(r.Context(), endpoint.Name)
+		if err != nil || existing.ID != endpoint.ID {
+			return system.NewHTTPError400("provider '%s' already exists", endpoint.Name)
+		}
+	}
+
+	return nil
+}
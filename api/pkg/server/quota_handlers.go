@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/quota"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// getQuotas godoc
+// @Summary Get usage quotas
+// @Description Get the current token and request consumption against each configured quota. App API keys see the quotas of their app.
+// @Tags    quotas
+// @Produce json
+// @Param   app_id query    string  false  "App ID"
+// @Success 200 {array} types.QuotaStatus
+// @Router /api/v1/quotas [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) getQuotas(_ http.ResponseWriter, r *http.Request) ([]*types.QuotaStatus, *system.HTTPError) {
+	user := getRequestUser(r)
+
+	appID := r.URL.Query().Get("app_id")
+	if user.AppID != "" {
+		appID = user.AppID
+	}
+
+	if appID != "" && appID != user.AppID {
+		app, err := s.Store.GetApp(r.Context(), appID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+			}
+			return nil, system.NewHTTPError500(err.Error())
+		}
+
+		httpErr := s.authorizeApp(r.Context(), user, app, types.PermissionView)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+	}
+
+	statuses, err := s.quotaManager.Status(r.Context(), &quota.Request{
+		UserID: user.ID,
+		APIKey: getRequestAPIKey(user),
+		AppID:  appID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return statuses, nil
+}
+
+// checkQuota checks the user's quotas, writing an OpenAI style error
+// response and returning false if the request is not allowed
+func (s *HelixAPIServer) checkQuota(ctx context.Context, rw http.ResponseWriter, user *types.User, appID string) bool {
+	if s.quotaManager == nil {
+		return true
+	}
+
+	if user.AppID != "" {
+		appID = user.AppID
+	}
+
+	err := s.quotaManager.Check(ctx, &quota.Request{
+		UserID: user.ID,
+		APIKey: getRequestAPIKey(user),
+		AppID:  appID,
+	})
+	if err == nil {
+		return true
+	}
+
+	var exceededErr *quota.ExceededError
+	if !errors.As(err, &exceededErr) {
+		log.Error().Err(err).Msg("error checking quotas")
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+
+	log.Info().
+		Str("user_id", user.ID).
+		Str("app_id", appID).
+		Str("scope", string(exceededErr.Scope)).
+		Str("limit", string(exceededErr.Limit)).
+		Msg("quota exceeded")
+
+	writeQuotaError(rw, exceededErr)
+
+	return false
+}
+
+// writeQuotaError writes the error in the same format as the OpenAI API so
+// that clients with retry logic for OpenAI handle it
+func writeQuotaError(rw http.ResponseWriter, exceededErr *quota.ExceededError) {
+	apiErr := &openai.APIError{
+		Message: exceededErr.Error(),
+		Type:    "insufficient_quota",
+		Code:    "insufficient_quota",
+	}
+	if exceededErr.RateLimited() {
+		apiErr.Type = "requests"
+		apiErr.Code = "rate_limit_exceeded"
+	}
+
+	retryAfter := int64(math.Ceil(exceededErr.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	rw.WriteHeader(http.StatusTooManyRequests)
+
+	err := json.NewEncoder(rw).Encode(&openai.ErrorResponse{Error: apiErr})
+	if err != nil {
+		log.Error().Err(err).Msg("error writing quota error response")
+	}
+}
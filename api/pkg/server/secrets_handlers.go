@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -12,7 +13,7 @@ import (
 
 // listSecrets godoc
 // @Summary List secrets
-// @Description List secrets for the user.
+// @Description List secrets of the user and of the organizations the user is a member of.
 // @Tags    secrets
 // @Success 200 {array} types.Secret
 // @Router /api/v1/secrets [get]
@@ -27,6 +28,7 @@ func (s *HelixAPIServer) listSecrets(w http.ResponseWriter, r *http.Request) ([]
 	query := &store.ListSecretsQuery{
 		Owner:     user.ID,
 		OwnerType: types.OwnerTypeUser,
+		Member:    user.ID,
 	}
 
 	secrets, err := s.Store.ListSecrets(ctx, query)
@@ -49,6 +51,7 @@ func (s *HelixAPIServer) listSecrets(w http.ResponseWriter, r *http.Request) ([]
 // @Tags    secrets
 // @Success 200 {object} types.Secret
 // @Param request body types.Secret true "Request body with secret configuration."
+// @Param org_id query string false "Organization that owns the secret"
 // @Router /api/v1/secrets [post]
 // @Security BearerAuth
 func (s *HelixAPIServer) createSecret(w http.ResponseWriter, r *http.Request) (*types.Secret, *system.HTTPError) {
@@ -63,12 +66,36 @@ func (s *HelixAPIServer) createSecret(w http.ResponseWriter, r *http.Request) (*
 		return nil, system.NewHTTPError400(err.Error())
 	}
 
+	owner, ownerType, httpErr := s.requestOwner(r, user, types.OrganizationRoleAdmin)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if secretReq.AppID != "" {
+		app, err := s.Store.GetApp(ctx, secretReq.AppID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+			}
+			return nil, system.NewHTTPError500(err.Error())
+		}
+
+		httpErr := s.authorizeApp(ctx, user, app, types.PermissionManageSecrets)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+
+		// App secrets belong to the owner of the app so they are loaded when it runs
+		owner, ownerType = app.Owner, app.OwnerType
+	}
+
 	secret := &types.Secret{
 		Name:  secretReq.Name,
 		Value: []byte(secretReq.Value),
+		AppID: secretReq.AppID,
 	}
-	secret.Owner = user.ID
-	secret.OwnerType = types.OwnerTypeUser
+	secret.Owner = owner
+	secret.OwnerType = ownerType
 
 	createdSecret, err := s.Store.CreateSecret(ctx, secret)
 	if err != nil {
@@ -104,9 +131,24 @@ func (s *HelixAPIServer) updateSecret(w http.ResponseWriter, r *http.Request) (*
 		return nil, system.NewHTTPError400(err.Error())
 	}
 
+	existing, err := s.Store.GetSecret(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("Secret not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	httpErr := s.authorizeSecret(ctx, user, existing, "you do not have permission to update this secret")
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
 	secret.ID = id
-	secret.Owner = user.ID
-	secret.OwnerType = types.OwnerTypeUser
+	secret.Owner = existing.Owner
+	secret.OwnerType = existing.OwnerType
+	secret.AppID = existing.AppID
+	secret.Created = existing.Created
 
 	updatedSecret, err := s.Store.UpdateSecret(ctx, &secret)
 	if err != nil {
@@ -147,8 +189,9 @@ func (s *HelixAPIServer) deleteSecret(w http.ResponseWriter, r *http.Request) (*
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("Secret not found")
+	httpErr := s.authorizeSecret(ctx, user, existing, "you do not have permission to delete this secret")
+	if httpErr != nil {
+		return nil, httpErr
 	}
 
 	err = s.Store.DeleteSecret(ctx, id)
@@ -164,3 +207,21 @@ func (s *HelixAPIServer) deleteSecret(w http.ResponseWriter, r *http.Request) (*
 
 	return existing, nil
 }
+
+// authorizeSecret allows the owners of a secret and, for app secrets, the users
+// with the manage_secrets permission on the app
+func (s *HelixAPIServer) authorizeSecret(ctx context.Context, user *types.User, secret *types.Secret, message string) *system.HTTPError {
+	if secret.AppID == "" {
+		return s.authorizeOwner(ctx, user, secret.Owner, secret.OwnerType, types.OrganizationRoleAdmin, message)
+	}
+
+	app, err := s.Store.GetApp(ctx, secret.AppID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return s.authorizeOwner(ctx, user, secret.Owner, secret.OwnerType, types.OrganizationRoleAdmin, message)
+		}
+		return system.NewHTTPError500(err.Error())
+	}
+
+	return s.authorizeApp(ctx, user, app, types.PermissionManageSecrets)
+}
@@ -21,11 +21,13 @@ import (
 	"github.com/helixml/helix/api/pkg/openai"
 	"github.com/helixml/helix/api/pkg/openai/manager"
 	"github.com/helixml/helix/api/pkg/pubsub"
+	"github.com/helixml/helix/api/pkg/quota"
 	"github.com/helixml/helix/api/pkg/scheduler"
 	"github.com/helixml/helix/api/pkg/server/spa"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/stripe"
 	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/usage"
 
 	_ "net/http/pprof"
 )
@@ -68,6 +70,8 @@ type HelixAPIServer struct {
 	knowledgeManager  knowledge.KnowledgeManager
 	router            *mux.Router
 	scheduler         scheduler.Scheduler
+	quotaManager      *quota.Manager
+	usageReporter     *usage.Reporter
 }
 
 func NewServer(
@@ -100,6 +104,11 @@ func NewServer(
 		return nil, fmt.Errorf("runner token is required")
 	}
 
+	prices, err := usage.LoadPriceTable(cfg.Providers.PricesFile)
+	if err != nil {
+		return nil, err
+	}
+
 	return &HelixAPIServer{
 		Cfg:               cfg,
 		Store:             store,
@@ -120,6 +129,8 @@ func NewServer(
 		pubsub:           ps,
 		knowledgeManager: knowledgeManager,
 		scheduler:        scheduler,
+		quotaManager:     quota.NewManager(cfg, store),
+		usageReporter:    usage.NewReporter(store, prices),
 	}, nil
 }
 
@@ -275,11 +286,21 @@ func (apiServer *HelixAPIServer) registerRoutes(_ context.Context) (*mux.Router,
 	// OpenAI API compatible routes
 	router.HandleFunc("/v1/chat/completions", apiServer.authMiddleware.auth(apiServer.createChatCompletion)).Methods("POST", "OPTIONS")
 	router.HandleFunc("/v1/models", apiServer.authMiddleware.auth(apiServer.listModels)).Methods("GET")
+	router.HandleFunc("/v1/embeddings", apiServer.authMiddleware.auth(apiServer.createEmbeddings)).Methods("POST", "OPTIONS")
 	// Azure OpenAI API compatible routes
 	router.HandleFunc("/openai/deployments/{model}/chat/completions", apiServer.authMiddleware.auth(apiServer.createChatCompletion)).Methods("POST", "OPTIONS")
 
 	authRouter.HandleFunc("/providers", apiServer.listProviders).Methods("GET")
 
+	authRouter.HandleFunc("/quotas", system.Wrapper(apiServer.getQuotas)).Methods("GET")
+	authRouter.HandleFunc("/usage", apiServer.getUsage).Methods("GET")
+	adminRouter.HandleFunc("/admin/usage", apiServer.getAdminUsage).Methods("GET")
+
+	adminRouter.HandleFunc("/provider-endpoints", system.Wrapper(apiServer.listProviderEndpoints)).Methods("GET")
+	adminRouter.HandleFunc("/provider-endpoints", system.Wrapper(apiServer.createProviderEndpoint)).Methods("POST")
+	adminRouter.HandleFunc("/provider-endpoints/{id}", system.Wrapper(apiServer.updateProviderEndpoint)).Methods("PUT")
+	adminRouter.HandleFunc("/provider-endpoints/{id}", system.Wrapper(apiServer.deleteProviderEndpoint)).Methods("DELETE")
+
 	// Helix inference route
 	authRouter.HandleFunc("/sessions/chat", apiServer.startChatSessionHandler).Methods("POST")
 
@@ -309,6 +330,16 @@ func (apiServer *HelixAPIServer) registerRoutes(_ context.Context) (*mux.Router,
 	authRouter.HandleFunc("/secrets/{id}", system.Wrapper(apiServer.updateSecret)).Methods("PUT")
 	authRouter.HandleFunc("/secrets/{id}", system.Wrapper(apiServer.deleteSecret)).Methods("DELETE")
 
+	authRouter.HandleFunc("/orgs", system.Wrapper(apiServer.listOrganizations)).Methods("GET")
+	authRouter.HandleFunc("/orgs", system.Wrapper(apiServer.createOrganization)).Methods("POST")
+	authRouter.HandleFunc("/orgs/{id}", system.Wrapper(apiServer.getOrganization)).Methods("GET")
+	authRouter.HandleFunc("/orgs/{id}", system.Wrapper(apiServer.updateOrganization)).Methods("PUT")
+	authRouter.HandleFunc("/orgs/{id}", system.Wrapper(apiServer.deleteOrganization)).Methods("DELETE")
+	authRouter.HandleFunc("/orgs/{id}/members", system.Wrapper(apiServer.listOrganizationMembers)).Methods("GET")
+	authRouter.HandleFunc("/orgs/{id}/members", system.Wrapper(apiServer.addOrganizationMember)).Methods("POST")
+	authRouter.HandleFunc("/orgs/{id}/members/{user_id}", system.Wrapper(apiServer.updateOrganizationMember)).Methods("PUT")
+	authRouter.HandleFunc("/orgs/{id}/members/{user_id}", system.Wrapper(apiServer.removeOrganizationMember)).Methods("DELETE")
+
 	authRouter.HandleFunc("/apps", system.Wrapper(apiServer.listApps)).Methods("GET")
 	authRouter.HandleFunc("/apps", system.Wrapper(apiServer.createApp)).Methods("POST")
 	authRouter.HandleFunc("/apps/{id}", system.Wrapper(apiServer.getApp)).Methods("GET")
@@ -316,14 +347,22 @@ func (apiServer *HelixAPIServer) registerRoutes(_ context.Context) (*mux.Router,
 	authRouter.HandleFunc("/apps/github/{id}", system.Wrapper(apiServer.updateGithubApp)).Methods("PUT")
 	authRouter.HandleFunc("/apps/{id}", system.Wrapper(apiServer.deleteApp)).Methods("DELETE")
 	authRouter.HandleFunc("/apps/{id}/llm-calls", system.Wrapper(apiServer.listAppLLMCalls)).Methods("GET")
+	authRouter.HandleFunc("/apps/{id}/access", system.Wrapper(apiServer.listAppAccessGrants)).Methods("GET")
+	authRouter.HandleFunc("/apps/{id}/access", system.Wrapper(apiServer.createAppAccessGrant)).Methods("POST")
+	authRouter.HandleFunc("/apps/{id}/access/{user_id}", system.Wrapper(apiServer.deleteAppAccessGrant)).Methods("DELETE")
 
 	authRouter.HandleFunc("/search", system.Wrapper(apiServer.knowledgeSearch)).Methods("GET")
 
 	authRouter.HandleFunc("/knowledge", system.Wrapper(apiServer.listKnowledge)).Methods("GET")
+	authRouter.HandleFunc("/knowledge/import", system.Wrapper(apiServer.importKnowledge)).Methods("POST")
 	authRouter.HandleFunc("/knowledge/{id}", system.Wrapper(apiServer.getKnowledge)).Methods("GET")
 	authRouter.HandleFunc("/knowledge/{id}", system.Wrapper(apiServer.deleteKnowledge)).Methods("DELETE")
 	authRouter.HandleFunc("/knowledge/{id}/refresh", system.Wrapper(apiServer.refreshKnowledge)).Methods("POST")
 	authRouter.HandleFunc("/knowledge/{id}/versions", system.Wrapper(apiServer.listKnowledgeVersions)).Methods("GET")
+	authRouter.HandleFunc("/knowledge/{id}/export", apiServer.exportKnowledge).Methods("GET")
+	authRouter.HandleFunc("/knowledge/{id}/access", system.Wrapper(apiServer.listKnowledgeAccessGrants)).Methods("GET")
+	authRouter.HandleFunc("/knowledge/{id}/access", system.Wrapper(apiServer.createKnowledgeAccessGrant)).Methods("POST")
+	authRouter.HandleFunc("/knowledge/{id}/access/{user_id}", system.Wrapper(apiServer.deleteKnowledgeAccessGrant)).Methods("DELETE")
 
 	// we know which app this is by the token that is used (which is linked to the app)
 	// this is so frontend devs don't need anything other than their access token
@@ -332,6 +371,7 @@ func (apiServer *HelixAPIServer) registerRoutes(_ context.Context) (*mux.Router,
 	authRouter.HandleFunc("/apps/script", system.Wrapper(apiServer.appRunScript)).Methods("POST", "OPTIONS")
 	adminRouter.HandleFunc("/dashboard", system.DefaultWrapper(apiServer.dashboard)).Methods("GET")
 	adminRouter.HandleFunc("/llm_calls", system.Wrapper(apiServer.listLLMCalls)).Methods("GET")
+	adminRouter.HandleFunc("/admin/apps/{id}/access", system.Wrapper(apiServer.getAdminAppAccess)).Methods("GET")
 
 	// all these routes are secured via runner tokens
 	runnerRouter.HandleFunc("/runner/{runnerid}/nextsession", system.DefaultWrapper(apiServer.getNextRunnerSession)).Methods("GET")
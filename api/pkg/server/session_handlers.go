@@ -83,6 +83,11 @@ func (s *HelixAPIServer) startChatSessionHandler(rw http.ResponseWriter, req *ht
 			return
 		}
 
+		if httpErr := s.authorizeApp(ctx, user, app, types.PermissionChat); httpErr != nil {
+			http.Error(rw, httpErr.Message, httpErr.StatusCode)
+			return
+		}
+
 		// If an AssistantID is specified, get the correct assistant from the app
 		if startReq.AssistantID != "" {
 			var assistant *types.AssistantConfig
@@ -502,7 +507,10 @@ func (s *HelixAPIServer) handleBlockingSession(ctx context.Context, user *types.
 
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(rw).Encode(chatCompletionResponse)
+	err = json.NewEncoder(rw).Encode(&types.ChatCompletionResponse{
+		ChatCompletionResponse: *chatCompletionResponse,
+		Citations:              options.Citations,
+	})
 	if err != nil {
 		log.Err(err).Msg("error writing response")
 	}
@@ -588,6 +596,8 @@ func (s *HelixAPIServer) handleStreamingSession(ctx context.Context, user *types
 		}
 	}
 
+	writeCitationsChunk(rw, session.ID, chatCompletionRequest.Model, options.Citations)
+
 	// Update last interaction
 	session.Interactions[len(session.Interactions)-1].Message = fullResponse
 	session.Interactions[len(session.Interactions)-1].Completed = time.Now()
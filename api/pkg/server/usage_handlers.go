@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/helixml/helix/api/pkg/usage"
+)
+
+// getUsage godoc
+// @Summary Get usage
+// @Description Get the user's token usage and estimated cost, aggregated from the LLM calls
+// @Tags    usage
+// @Produce json
+// @Produce text/csv
+// @Param   from     query    string  false  "Start of the period (inclusive), YYYY-MM-DD or RFC3339, defaults to the start of the month"
+// @Param   to       query    string  false  "End of the period (exclusive), YYYY-MM-DD or RFC3339, defaults to now"
+// @Param   group_by query    string  false  "Comma separated list of user, app, model, provider, day (default day)"
+// @Param   app_id   query    string  false  "Filter by app ID"
+// @Param   format   query    string  false  "json (default) or csv"
+// @Success 200 {object} types.UsageReport
+// @Router /api/v1/usage [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) getUsage(rw http.ResponseWriter, r *http.Request) {
+	user := getRequestUser(r)
+
+	q, httpErr := parseUsageQuery(r)
+	if httpErr != nil {
+		http.Error(rw, httpErr.Error(), httpErr.StatusCode)
+		return
+	}
+
+	q.UserID = user.ID
+
+	s.writeUsageReport(rw, r, q)
+}
+
+// getAdminUsage godoc
+// @Summary Get usage of all users
+// @Description Get the token usage and estimated cost of all users, aggregated from the LLM calls
+// @Tags    usage
+// @Produce json
+// @Produce text/csv
+// @Param   from     query    string  false  "Start of the period (inclusive), YYYY-MM-DD or RFC3339, defaults to the start of the month"
+// @Param   to       query    string  false  "End of the period (exclusive), YYYY-MM-DD or RFC3339, defaults to now"
+// @Param   group_by query    string  false  "Comma separated list of user, app, model, provider, day (default day)"
+// @Param   user_id  query    string  false  "Filter by user ID"
+// @Param   app_id   query    string  false  "Filter by app ID"
+// @Param   format   query    string  false  "json (default) or csv"
+// @Success 200 {object} types.UsageReport
+// @Router /api/v1/admin/usage [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) getAdminUsage(rw http.ResponseWriter, r *http.Request) {
+	q, httpErr := parseUsageQuery(r)
+	if httpErr != nil {
+		http.Error(rw, httpErr.Error(), httpErr.StatusCode)
+		return
+	}
+
+	q.UserID = r.URL.Query().Get("user_id")
+
+	s.writeUsageReport(rw, r, q)
+}
+
+func (s *HelixAPIServer) writeUsageReport(rw http.ResponseWriter, r *http.Request, q *usage.Query) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(rw, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	report, err := s.usageReporter.Report(r.Context(), q)
+	if err != nil {
+		log.Error().Err(err).Msg("error getting usage report")
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "csv" {
+		rw.Header().Set("Content-Type", "text/csv")
+		rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"usage-%s-%s.csv\"",
+			q.From.Format(time.DateOnly), q.To.Format(time.DateOnly)))
+
+		err = usage.WriteCSV(rw, report)
+		if err != nil {
+			log.Error().Err(err).Msg("error writing usage CSV")
+		}
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(rw).Encode(report)
+	if err != nil {
+		log.Error().Err(err).Msg("error writing usage report")
+	}
+}
+
+func parseUsageQuery(r *http.Request) (*usage.Query, *system.HTTPError) {
+	now := time.Now().UTC()
+
+	q := &usage.Query{
+		AppID: r.URL.Query().Get("app_id"),
+		From:  time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		To:    now,
+	}
+
+	var err error
+
+	if from := r.URL.Query().Get("from"); from != "" {
+		q.From, err = parseUsageTime(from)
+		if err != nil {
+			return nil, system.NewHTTPError400(fmt.Sprintf("invalid from: %s", err))
+		}
+	}
+
+	if to := r.URL.Query().Get("to"); to != "" {
+		q.To, err = parseUsageTime(to)
+		if err != nil {
+			return nil, system.NewHTTPError400(fmt.Sprintf("invalid to: %s", err))
+		}
+	}
+
+	if !q.To.After(q.From) {
+		return nil, system.NewHTTPError400("to must be after from")
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = string(types.UsageGroupByDay)
+	}
+
+	q.GroupBy, err = usage.ParseGroupBy(groupBy)
+	if err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	return q, nil
+}
+
+func parseUsageTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.DateOnly, s)
+	if err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
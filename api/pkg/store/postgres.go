@@ -25,6 +25,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/encryption"
 	"github.com/helixml/helix/api/pkg/types"
 )
 
@@ -35,11 +36,18 @@ type PostgresStore struct {
 	db               *goqu.Database
 
 	gdb *gorm.DB
+
+	// secrets encrypts secret values at rest, nil if no master key is configured
+	secrets *encryption.Envelope
 }
 
 func NewPostgresStore(
 	cfg config.Store,
 ) (*PostgresStore, error) {
+	keys, err := encryption.NewKeyManager(cfg.SecretsEncryption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secrets master key: %w", err)
+	}
 
 	// Waiting for connection
 	gormDB, err := connect(context.Background(), cfg)
@@ -77,6 +85,10 @@ func NewPostgresStore(
 		gdb:              gormDB,
 	}
 
+	if keys != nil {
+		store.secrets = encryption.NewEnvelope(keys)
+	}
+
 	if cfg.AutoMigrate {
 		err = store.MigrateUp()
 		if err != nil {
@@ -104,12 +116,18 @@ func (s *PostgresStore) autoMigrate() error {
 		&types.Tool{},
 		&types.Knowledge{},
 		&types.KnowledgeVersion{},
+		&types.KnowledgeDocument{},
 		&types.SessionToolBinding{},
 		&types.DataEntity{},
 		&types.ScriptRun{},
 		&types.LLMCall{},
 		&MigrationScript{},
 		&types.Secret{},
+		&types.ProviderEndpoint{},
+		&types.SchedulerWorkload{},
+		&types.Organization{},
+		&types.OrganizationMembership{},
+		&types.AccessGrant{},
 	)
 	if err != nil {
 		return err
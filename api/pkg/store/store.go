@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/helixml/helix/api/pkg/types"
 )
@@ -25,9 +26,14 @@ type GetSessionsQuery struct {
 	Limit         int             `json:"limit"`
 }
 
+// Owner and OwnerType in the list queries below match the resources of a single
+// owner, Member additionally matches everything owned by the organizations the
+// given user is a member of.
+
 type ListApiKeysQuery struct {
 	Owner     string           `json:"owner"`
 	OwnerType types.OwnerType  `json:"owner_type"`
+	Member    string           `json:"member"`
 	Type      types.APIKeyType `json:"type"`
 	AppID     string           `json:"app_id"`
 }
@@ -35,23 +41,27 @@ type ListApiKeysQuery struct {
 type ListToolsQuery struct {
 	Owner     string          `json:"owner"`
 	OwnerType types.OwnerType `json:"owner_type"`
+	Member    string          `json:"member"`
 	Global    bool            `json:"global"`
 }
 
 type ListSecretsQuery struct {
 	Owner     string          `json:"owner"`
 	OwnerType types.OwnerType `json:"owner_type"`
+	Member    string          `json:"member"`
 }
 
 type ListAppsQuery struct {
 	Owner     string          `json:"owner"`
 	OwnerType types.OwnerType `json:"owner_type"`
+	Member    string          `json:"member"`
 	Global    bool            `json:"global"`
 }
 
 type ListDataEntitiesQuery struct {
 	Owner     string          `json:"owner"`
 	OwnerType types.OwnerType `json:"owner_type"`
+	Member    string          `json:"member"`
 }
 
 //go:generate mockgen -source $GOFILE -destination store_mocks.go -package $GOPACKAGE
@@ -125,6 +135,9 @@ type Store interface {
 	ListKnowledgeVersions(ctx context.Context, q *ListKnowledgeVersionQuery) ([]*types.KnowledgeVersion, error)
 	DeleteKnowledgeVersion(ctx context.Context, id string) error
 
+	ListKnowledgeDocuments(ctx context.Context, knowledgeID string) ([]*types.KnowledgeDocument, error)
+	ReplaceKnowledgeDocuments(ctx context.Context, knowledgeID string, documents []*types.KnowledgeDocument) error
+
 	// GPTScript runs history table
 	CreateScriptRun(ctx context.Context, task *types.ScriptRun) (*types.ScriptRun, error)
 	ListScriptRuns(ctx context.Context, q *types.GptScriptRunsQuery) ([]*types.ScriptRun, error)
@@ -132,6 +145,45 @@ type Store interface {
 
 	CreateLLMCall(ctx context.Context, call *types.LLMCall) (*types.LLMCall, error)
 	ListLLMCalls(ctx context.Context, q *ListLLMCallsQuery) ([]*types.LLMCall, int64, error)
+	SumLLMCallTokens(ctx context.Context, q *SumLLMCallTokensQuery) (int64, error)
+	GetLLMCallUsage(ctx context.Context, q *GetLLMCallUsageQuery) ([]*types.UsageRow, error)
+
+	// provider endpoints
+	CreateProviderEndpoint(ctx context.Context, endpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error)
+	UpdateProviderEndpoint(ctx context.Context, endpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error)
+	GetProviderEndpoint(ctx context.Context, id string) (*types.ProviderEndpoint, error)
+	GetProviderEndpointByName(ctx context.Context, name string) (*types.ProviderEndpoint, error)
+	ListProviderEndpoints(ctx context.Context, q *ListProviderEndpointsQuery) ([]*types.ProviderEndpoint, error)
+	DeleteProviderEndpoint(ctx context.Context, id string) error
+
+	// scheduler queue
+	SaveSchedulerWorkload(ctx context.Context, workload *types.SchedulerWorkload) error
+	UpdateSchedulerWorkloadState(ctx context.Context, id string, state types.SchedulerWorkloadState) error
+	ListSchedulerWorkloads(ctx context.Context, instance string) ([]*types.SchedulerWorkload, error)
+	HeartbeatSchedulerWorkloads(ctx context.Context, instance string) error
+	ClaimSchedulerWorkloads(ctx context.Context, instance string, expiredBefore time.Time) ([]*types.SchedulerWorkload, error)
+	DeleteExpiredSchedulerWorkloads(ctx context.Context, expiredBefore time.Time) (int64, error)
+	DeleteSchedulerWorkload(ctx context.Context, id string) error
+
+	// organizations
+	CreateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error)
+	UpdateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error)
+	GetOrganization(ctx context.Context, id string) (*types.Organization, error)
+	ListOrganizations(ctx context.Context, q *ListOrganizationsQuery) ([]*types.Organization, error)
+	DeleteOrganization(ctx context.Context, id string) error
+
+	CreateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error)
+	UpdateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error)
+	GetOrganizationMembership(ctx context.Context, orgID, userID string) (*types.OrganizationMembership, error)
+	ListOrganizationMemberships(ctx context.Context, q *ListOrganizationMembershipsQuery) ([]*types.OrganizationMembership, error)
+	DeleteOrganizationMembership(ctx context.Context, orgID, userID string) error
+
+	// access grants
+	CreateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error)
+	UpdateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error)
+	GetAccessGrant(ctx context.Context, id string) (*types.AccessGrant, error)
+	ListAccessGrants(ctx context.Context, q *ListAccessGrantsQuery) ([]*types.AccessGrant, error)
+	DeleteAccessGrant(ctx context.Context, id string) error
 }
 
 var ErrNotFound = errors.New("not found")
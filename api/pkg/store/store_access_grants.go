@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"gorm.io/gorm"
+)
+
+type ListAccessGrantsQuery struct {
+	ResourceType types.AccessResourceType `json:"resource_type"`
+	ResourceID   string                   `json:"resource_id"`
+	UserID       string                   `json:"user_id"`
+}
+
+func (s *PostgresStore) CreateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error) {
+	if grant.ID == "" {
+		grant.ID = system.GenerateAccessGrantID()
+	}
+
+	if grant.ResourceType == "" || grant.ResourceID == "" {
+		return nil, fmt.Errorf("resource not specified")
+	}
+
+	if grant.UserID == "" {
+		return nil, fmt.Errorf("user id not specified")
+	}
+
+	if !grant.Role.Valid() {
+		return nil, fmt.Errorf("invalid role '%s'", grant.Role)
+	}
+
+	grant.Created = time.Now()
+	grant.Updated = grant.Created
+
+	err := s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing types.AccessGrant
+		err := tx.Where("resource_type = ? AND resource_id = ? AND user_id = ?", grant.ResourceType, grant.ResourceID, grant.UserID).First(&existing).Error
+		if err == nil {
+			return fmt.Errorf("user '%s' already has access to %s %s", grant.UserID, grant.ResourceType, grant.ResourceID)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return tx.Create(grant).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAccessGrant(ctx, grant.ID)
+}
+
+func (s *PostgresStore) UpdateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error) {
+	if grant.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	if !grant.Role.Valid() {
+		return nil, fmt.Errorf("invalid role '%s'", grant.Role)
+	}
+
+	grant.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(grant).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAccessGrant(ctx, grant.ID)
+}
+
+func (s *PostgresStore) GetAccessGrant(ctx context.Context, id string) (*types.AccessGrant, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	var grant types.AccessGrant
+	err := s.gdb.WithContext(ctx).Where("id = ?", id).First(&grant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (s *PostgresStore) ListAccessGrants(ctx context.Context, q *ListAccessGrantsQuery) ([]*types.AccessGrant, error) {
+	query := s.gdb.WithContext(ctx)
+
+	if q != nil {
+		if q.ResourceType != "" {
+			query = query.Where("resource_type = ?", q.ResourceType)
+		}
+		if q.ResourceID != "" {
+			query = query.Where("resource_id = ?", q.ResourceID)
+		}
+		if q.UserID != "" {
+			query = query.Where("user_id = ?", q.UserID)
+		}
+	}
+
+	var grants []*types.AccessGrant
+	err := query.Order("created ASC").Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+func (s *PostgresStore) DeleteAccessGrant(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Delete(&types.AccessGrant{ID: id}).Error
+}
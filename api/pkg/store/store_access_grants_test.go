@@ -0,0 +1,69 @@
+package store
+
+import (
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) TestAccessGrants() {
+	appID := system.GenerateAppID()
+	userID := "test-user-" + system.GenerateUUID()
+
+	grant, err := suite.db.CreateAccessGrant(suite.ctx, &types.AccessGrant{
+		ResourceType: types.AccessResourceTypeApp,
+		ResourceID:   appID,
+		UserID:       userID,
+		Role:         types.AccessRoleViewer,
+		GrantedBy:    "test-owner",
+	})
+	require.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), grant.ID)
+
+	suite.T().Cleanup(func() {
+		_ = suite.db.DeleteAccessGrant(suite.ctx, grant.ID)
+	})
+
+	_, err = suite.db.CreateAccessGrant(suite.ctx, &types.AccessGrant{
+		ResourceType: types.AccessResourceTypeApp,
+		ResourceID:   appID,
+		UserID:       userID,
+		Role:         types.AccessRoleAdmin,
+	})
+	assert.Error(suite.T(), err, "user already has access")
+
+	_, err = suite.db.CreateAccessGrant(suite.ctx, &types.AccessGrant{
+		ResourceType: types.AccessResourceTypeApp,
+		ResourceID:   appID,
+		UserID:       userID + "-other",
+		Role:         "superuser",
+	})
+	assert.Error(suite.T(), err, "invalid role")
+
+	grant.Role = types.AccessRoleEditor
+	_, err = suite.db.UpdateAccessGrant(suite.ctx, grant)
+	require.NoError(suite.T(), err)
+
+	grants, err := suite.db.ListAccessGrants(suite.ctx, &ListAccessGrantsQuery{
+		ResourceType: types.AccessResourceTypeApp,
+		UserID:       userID,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), grants, 1)
+	assert.Equal(suite.T(), appID, grants[0].ResourceID)
+	assert.Equal(suite.T(), types.AccessRoleEditor, grants[0].Role)
+
+	grants, err = suite.db.ListAccessGrants(suite.ctx, &ListAccessGrantsQuery{
+		ResourceType: types.AccessResourceTypeKnowledge,
+		ResourceID:   appID,
+	})
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), grants)
+
+	err = suite.db.DeleteAccessGrant(suite.ctx, grant.ID)
+	require.NoError(suite.T(), err)
+
+	_, err = suite.db.GetAccessGrant(suite.ctx, grant.ID)
+	assert.ErrorIs(suite.T(), err, ErrNotFound)
+}
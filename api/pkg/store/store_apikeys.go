@@ -43,10 +43,7 @@ func (s *PostgresStore) GetAPIKey(ctx context.Context, key string) (*types.APIKe
 
 func (s *PostgresStore) ListAPIKeys(ctx context.Context, q *ListApiKeysQuery) ([]*types.APIKey, error) {
 	var apiKeys []*types.APIKey
-	queryAPIKey := &types.APIKey{
-		Owner:     q.Owner,
-		OwnerType: q.OwnerType,
-	}
+	queryAPIKey := &types.APIKey{}
 
 	if q.Type != "" {
 		queryAPIKey.Type = q.Type
@@ -56,7 +53,8 @@ func (s *PostgresStore) ListAPIKeys(ctx context.Context, q *ListApiKeysQuery) ([
 		queryAPIKey.AppID = &sql.NullString{String: q.AppID, Valid: true}
 	}
 
-	err := s.gdb.WithContext(ctx).Where(queryAPIKey).Find(&apiKeys).Error
+	query := s.gdb.WithContext(ctx).Where(queryAPIKey)
+	err := ownedBy(query, q.Owner, q.OwnerType, q.Member).Find(&apiKeys).Error
 	if err != nil {
 		return nil, err
 	}
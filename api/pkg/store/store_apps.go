@@ -298,11 +298,10 @@ func (s *PostgresStore) GetAppWithTools(ctx context.Context, id string) (*types.
 
 func (s *PostgresStore) ListApps(ctx context.Context, q *ListAppsQuery) ([]*types.App, error) {
 	var apps []*types.App
-	err := s.gdb.WithContext(ctx).Where(&types.App{
-		Owner:     q.Owner,
-		OwnerType: q.OwnerType,
-		Global:    q.Global,
-	}).Order("id DESC").Find(&apps).Error
+	query := s.gdb.WithContext(ctx).Where(&types.App{
+		Global: q.Global,
+	})
+	err := ownedBy(query, q.Owner, q.OwnerType, q.Member).Order("id DESC").Find(&apps).Error
 	if err != nil {
 		return nil, err
 	}
@@ -66,10 +66,7 @@ func (s *PostgresStore) GetDataEntity(ctx context.Context, id string) (*types.Da
 
 func (s *PostgresStore) ListDataEntities(ctx context.Context, q *ListDataEntitiesQuery) ([]*types.DataEntity, error) {
 	var entities []*types.DataEntity
-	err := s.gdb.WithContext(ctx).Where(&types.DataEntity{
-		Owner:     q.Owner,
-		OwnerType: q.OwnerType,
-	}).Find(&entities).Error
+	err := ownedBy(s.gdb.WithContext(ctx), q.Owner, q.OwnerType, q.Member).Find(&entities).Error
 	if err != nil {
 		return nil, err
 	}
@@ -17,6 +17,7 @@ const (
 	DefaultKnowledgeResultsCount     = 3
 	DefaultKnowledgeThreshold        = 0.4
 	DefaultKnowledgeChunkSize        = 2000
+	DefaultKnowledgeTokenChunkSize   = 512 // Token and semantic splitters
 )
 
 func (s *PostgresStore) CreateKnowledge(ctx context.Context, knowledge *types.Knowledge) (*types.Knowledge, error) {
@@ -41,6 +42,12 @@ func (s *PostgresStore) CreateKnowledge(ctx context.Context, knowledge *types.Kn
 }
 
 func setDefaultKnowledgeRAGSettings(knowledge *types.Knowledge) {
+	switch knowledge.RAGSettings.TextSplitter {
+	case types.TextSplitterTypeToken, types.TextSplitterTypeSemantic:
+		if knowledge.RAGSettings.ChunkSize == 0 {
+			knowledge.RAGSettings.ChunkSize = DefaultKnowledgeTokenChunkSize
+		}
+	}
 	if knowledge.RAGSettings.ChunkSize == 0 {
 		knowledge.RAGSettings.ChunkSize = DefaultKnowledgeChunkSize
 	}
@@ -133,20 +140,15 @@ func (s *PostgresStore) UpdateKnowledgeState(ctx context.Context, id string, sta
 type ListKnowledgeQuery struct {
 	Owner     string
 	OwnerType types.OwnerType
+	Member    string
 	State     types.KnowledgeState
 	ID        string // Knowledge ID to search for
 	AppID     string
 }
 
 func (s *PostgresStore) ListKnowledge(ctx context.Context, q *ListKnowledgeQuery) ([]*types.Knowledge, error) {
-	query := s.gdb.WithContext(ctx)
+	query := ownedBy(s.gdb.WithContext(ctx), q.Owner, q.OwnerType, q.Member)
 
-	if q.Owner != "" {
-		query = query.Where("owner = ?", q.Owner)
-	}
-	if q.OwnerType != "" {
-		query = query.Where("owner_type = ?", q.OwnerType)
-	}
 	if q.State != "" {
 		query = query.Where("state = ?", q.State)
 	}
@@ -180,6 +182,11 @@ func (s *PostgresStore) DeleteKnowledge(ctx context.Context, id string) error {
 			return err
 		}
 
+		// Delete the indexed documents
+		if err := tx.Where("knowledge_id = ?", id).Delete(&types.KnowledgeDocument{}).Error; err != nil {
+			return err
+		}
+
 		// Delete the knowledge
 		if err := tx.Delete(&types.Knowledge{ID: id}).Error; err != nil {
 			return err
@@ -261,3 +268,44 @@ func (s *PostgresStore) DeleteKnowledgeVersion(ctx context.Context, id string) e
 	}
 	return nil
 }
+
+func (s *PostgresStore) ListKnowledgeDocuments(ctx context.Context, knowledgeID string) ([]*types.KnowledgeDocument, error) {
+	if knowledgeID == "" {
+		return nil, fmt.Errorf("knowledge_id not specified")
+	}
+
+	var documents []*types.KnowledgeDocument
+
+	err := s.gdb.WithContext(ctx).Where("knowledge_id = ?", knowledgeID).Order("source").Find(&documents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// ReplaceKnowledgeDocuments sets the documents of the current knowledge version
+func (s *PostgresStore) ReplaceKnowledgeDocuments(ctx context.Context, knowledgeID string, documents []*types.KnowledgeDocument) error {
+	if knowledgeID == "" {
+		return fmt.Errorf("knowledge_id not specified")
+	}
+
+	now := time.Now()
+
+	for _, document := range documents {
+		document.KnowledgeID = knowledgeID
+		document.Created = now
+	}
+
+	return s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("knowledge_id = ?", knowledgeID).Delete(&types.KnowledgeDocument{}).Error; err != nil {
+			return err
+		}
+
+		if len(documents) == 0 {
+			return nil
+		}
+
+		return tx.CreateInBatches(documents, 500).Error
+	})
+}
@@ -294,3 +294,36 @@ func (suite *PostgresStoreTestSuite) TestPostgresStore_DeleteKnowledgeVersion()
 	// Cleanup
 	suite.db.DeleteKnowledge(context.Background(), knowledge.ID)
 }
+
+func (suite *PostgresStoreTestSuite) TestPostgresStore_ReplaceKnowledgeDocuments() {
+	knowledgeID := system.GenerateKnowledgeID()
+
+	err := suite.db.ReplaceKnowledgeDocuments(context.Background(), knowledgeID, []*types.KnowledgeDocument{
+		{Source: "a.md", Version: "v1", DocumentID: "hash-a"},
+		{Source: "b.md", Version: "v1", DocumentID: "hash-b"},
+	})
+	suite.NoError(err)
+
+	err = suite.db.ReplaceKnowledgeDocuments(context.Background(), knowledgeID, []*types.KnowledgeDocument{
+		{Source: "b.md", Version: "v2", DocumentID: "hash-b2"},
+		{Source: "c.md", Version: "v2", DocumentID: "hash-c"},
+	})
+	suite.NoError(err)
+
+	documents, err := suite.db.ListKnowledgeDocuments(context.Background(), knowledgeID)
+	suite.NoError(err)
+	suite.Require().Len(documents, 2)
+
+	suite.Equal("b.md", documents[0].Source)
+	suite.Equal("hash-b2", documents[0].DocumentID)
+	suite.Equal("v2", documents[0].Version)
+	suite.Equal("c.md", documents[1].Source)
+
+	// Cleanup
+	err = suite.db.ReplaceKnowledgeDocuments(context.Background(), knowledgeID, nil)
+	suite.NoError(err)
+
+	documents, err = suite.db.ListKnowledgeDocuments(context.Background(), knowledgeID)
+	suite.NoError(err)
+	suite.Empty(documents)
+}
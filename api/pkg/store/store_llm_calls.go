@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/helixml/helix/api/pkg/system"
@@ -68,3 +69,110 @@ func (s *PostgresStore) ListLLMCalls(ctx context.Context, q *ListLLMCallsQuery)
 
 	return calls, totalCount, nil
 }
+
+type SumLLMCallTokensQuery struct {
+	AppID  string
+	UserID string
+	APIKey string
+	// Since only includes calls created at or after this time
+	Since time.Time
+}
+
+// SumLLMCallTokens returns the total number of tokens consumed by the LLM calls matching the query
+func (s *PostgresStore) SumLLMCallTokens(ctx context.Context, q *SumLLMCallTokensQuery) (int64, error) {
+	query := s.gdb.WithContext(ctx).Model(&types.LLMCall{})
+
+	if q.AppID != "" {
+		query = query.Where("app_id = ?", q.AppID)
+	}
+
+	if q.UserID != "" {
+		query = query.Where("user_id = ?", q.UserID)
+	}
+
+	if q.APIKey != "" {
+		query = query.Where("api_key = ?", q.APIKey)
+	}
+
+	if !q.Since.IsZero() {
+		query = query.Where("created >= ?", q.Since)
+	}
+
+	var total int64
+
+	err := query.Select("COALESCE(SUM(total_tokens), 0)").Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+type GetLLMCallUsageQuery struct {
+	AppID  string
+	UserID string
+	From   time.Time
+	To     time.Time
+
+	// Rows are always grouped by provider and model so that the cost
+	// can be calculated, these add the user, app and day
+	GroupByUser bool
+	GroupByApp  bool
+	GroupByDay  bool
+}
+
+// GetLLMCallUsage returns the number of calls and tokens consumed, aggregated by provider, model
+// and the optional groupings in the query
+func (s *PostgresStore) GetLLMCallUsage(ctx context.Context, q *GetLLMCallUsageQuery) ([]*types.UsageRow, error) {
+	query := s.gdb.WithContext(ctx).Model(&types.LLMCall{})
+
+	if q.AppID != "" {
+		query = query.Where("app_id = ?", q.AppID)
+	}
+
+	if q.UserID != "" {
+		query = query.Where("user_id = ?", q.UserID)
+	}
+
+	if !q.From.IsZero() {
+		query = query.Where("created >= ?", q.From)
+	}
+
+	if !q.To.IsZero() {
+		query = query.Where("created < ?", q.To)
+	}
+
+	groups := []string{"provider", "model"}
+	if q.GroupByUser {
+		groups = append(groups, "user_id")
+	}
+	if q.GroupByApp {
+		groups = append(groups, "app_id")
+	}
+
+	selects := append([]string{}, groups...)
+	if q.GroupByDay {
+		selects = append(selects, "to_char(date_trunc('day', created), 'YYYY-MM-DD') AS date")
+		groups = append(groups, "date")
+	}
+
+	selects = append(selects,
+		"COUNT(*) AS calls",
+		"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens",
+		"COALESCE(SUM(completion_tokens), 0) AS completion_tokens",
+		"COALESCE(SUM(total_tokens), 0) AS total_tokens",
+	)
+
+	var rows []*types.UsageRow
+
+	err := query.
+		Select(strings.Join(selects, ", ")).
+		Group(strings.Join(groups, ", ")).
+		Order(strings.Join(groups, ", ")).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) TestSumLLMCallTokens() {
+	userID := "test-user-" + system.GenerateUUID()
+	apiKey := "test-key-" + system.GenerateUUID()
+
+	for _, call := range []*types.LLMCall{
+		{UserID: userID, APIKey: apiKey, TotalTokens: 100},
+		{UserID: userID, APIKey: apiKey, TotalTokens: 50},
+		{UserID: userID, TotalTokens: 25},
+	} {
+		_, err := suite.db.CreateLLMCall(suite.ctx, call)
+		require.NoError(suite.T(), err)
+	}
+
+	total, err := suite.db.SumLLMCallTokens(suite.ctx, &SumLLMCallTokensQuery{UserID: userID})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(175), total)
+
+	total, err = suite.db.SumLLMCallTokens(suite.ctx, &SumLLMCallTokensQuery{APIKey: apiKey})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(150), total)
+
+	total, err = suite.db.SumLLMCallTokens(suite.ctx, &SumLLMCallTokensQuery{
+		UserID: userID,
+		Since:  time.Now().Add(time.Hour),
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), total)
+}
+
+func (suite *PostgresStoreTestSuite) TestGetLLMCallUsage() {
+	userID := "test-user-" + system.GenerateUUID()
+
+	for _, call := range []*types.LLMCall{
+		{UserID: userID, AppID: "app-1", Provider: "openai", Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		{UserID: userID, AppID: "app-2", Provider: "openai", Model: "gpt-4o", PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30},
+		{UserID: userID, AppID: "app-1", Provider: "helix", Model: "llama3", PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2},
+	} {
+		_, err := suite.db.CreateLLMCall(suite.ctx, call)
+		require.NoError(suite.T(), err)
+	}
+
+	rows, err := suite.db.GetLLMCallUsage(suite.ctx, &GetLLMCallUsageQuery{UserID: userID})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), rows, 2)
+
+	assert.Equal(suite.T(), "helix", rows[0].Provider)
+	assert.Equal(suite.T(), int64(1), rows[0].Calls)
+	assert.Equal(suite.T(), "openai", rows[1].Provider)
+	assert.Equal(suite.T(), int64(2), rows[1].Calls)
+	assert.Equal(suite.T(), int64(45), rows[1].TotalTokens)
+
+	rows, err = suite.db.GetLLMCallUsage(suite.ctx, &GetLLMCallUsageQuery{
+		UserID:     userID,
+		GroupByApp: true,
+		GroupByDay: true,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), rows, 3)
+	assert.Equal(suite.T(), time.Now().UTC().Format(time.DateOnly), rows[0].Date)
+}
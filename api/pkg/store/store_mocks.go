@@ -12,6 +12,7 @@ package store
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	types "github.com/helixml/helix/api/pkg/types"
 	gomock "go.uber.org/mock/gomock"
@@ -41,6 +42,21 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// ClaimSchedulerWorkloads mocks base method.
+func (m *MockStore) ClaimSchedulerWorkloads(ctx context.Context, instance string, expiredBefore time.Time) ([]*types.SchedulerWorkload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimSchedulerWorkloads", ctx, instance, expiredBefore)
+	ret0, _ := ret[0].([]*types.SchedulerWorkload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimSchedulerWorkloads indicates an expected call of ClaimSchedulerWorkloads.
+func (mr *MockStoreMockRecorder) ClaimSchedulerWorkloads(ctx, instance, expiredBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimSchedulerWorkloads", reflect.TypeOf((*MockStore)(nil).ClaimSchedulerWorkloads), ctx, instance, expiredBefore)
+}
+
 // CreateAPIKey mocks base method.
 func (m *MockStore) CreateAPIKey(ctx context.Context, apiKey *types.APIKey) (*types.APIKey, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +72,21 @@ func (mr *MockStoreMockRecorder) CreateAPIKey(ctx, apiKey any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockStore)(nil).CreateAPIKey), ctx, apiKey)
 }
 
+// CreateAccessGrant mocks base method.
+func (m *MockStore) CreateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccessGrant", ctx, grant)
+	ret0, _ := ret[0].(*types.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccessGrant indicates an expected call of CreateAccessGrant.
+func (mr *MockStoreMockRecorder) CreateAccessGrant(ctx, grant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessGrant", reflect.TypeOf((*MockStore)(nil).CreateAccessGrant), ctx, grant)
+}
+
 // CreateApp mocks base method.
 func (m *MockStore) CreateApp(ctx context.Context, tool *types.App) (*types.App, error) {
 	m.ctrl.T.Helper()
@@ -131,6 +162,51 @@ func (mr *MockStoreMockRecorder) CreateLLMCall(ctx, call any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLLMCall", reflect.TypeOf((*MockStore)(nil).CreateLLMCall), ctx, call)
 }
 
+// CreateOrganization mocks base method.
+func (m *MockStore) CreateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, org)
+	ret0, _ := ret[0].(*types.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockStoreMockRecorder) CreateOrganization(ctx, org any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockStore)(nil).CreateOrganization), ctx, org)
+}
+
+// CreateOrganizationMembership mocks base method.
+func (m *MockStore) CreateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganizationMembership", ctx, membership)
+	ret0, _ := ret[0].(*types.OrganizationMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganizationMembership indicates an expected call of CreateOrganizationMembership.
+func (mr *MockStoreMockRecorder) CreateOrganizationMembership(ctx, membership any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganizationMembership", reflect.TypeOf((*MockStore)(nil).CreateOrganizationMembership), ctx, membership)
+}
+
+// CreateProviderEndpoint mocks base method.
+func (m *MockStore) CreateProviderEndpoint(ctx context.Context, endpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProviderEndpoint", ctx, endpoint)
+	ret0, _ := ret[0].(*types.ProviderEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateProviderEndpoint indicates an expected call of CreateProviderEndpoint.
+func (mr *MockStoreMockRecorder) CreateProviderEndpoint(ctx, endpoint any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProviderEndpoint", reflect.TypeOf((*MockStore)(nil).CreateProviderEndpoint), ctx, endpoint)
+}
+
 // CreateScriptRun mocks base method.
 func (m *MockStore) CreateScriptRun(ctx context.Context, task *types.ScriptRun) (*types.ScriptRun, error) {
 	m.ctrl.T.Helper()
@@ -234,6 +310,20 @@ func (mr *MockStoreMockRecorder) DeleteAPIKey(ctx, apiKey any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKey", reflect.TypeOf((*MockStore)(nil).DeleteAPIKey), ctx, apiKey)
 }
 
+// DeleteAccessGrant mocks base method.
+func (m *MockStore) DeleteAccessGrant(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccessGrant", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccessGrant indicates an expected call of DeleteAccessGrant.
+func (mr *MockStoreMockRecorder) DeleteAccessGrant(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccessGrant", reflect.TypeOf((*MockStore)(nil).DeleteAccessGrant), ctx, id)
+}
+
 // DeleteApp mocks base method.
 func (m *MockStore) DeleteApp(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -262,6 +352,21 @@ func (mr *MockStoreMockRecorder) DeleteDataEntity(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDataEntity", reflect.TypeOf((*MockStore)(nil).DeleteDataEntity), ctx, id)
 }
 
+// DeleteExpiredSchedulerWorkloads mocks base method.
+func (m *MockStore) DeleteExpiredSchedulerWorkloads(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSchedulerWorkloads", ctx, expiredBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSchedulerWorkloads indicates an expected call of DeleteExpiredSchedulerWorkloads.
+func (mr *MockStoreMockRecorder) DeleteExpiredSchedulerWorkloads(ctx, expiredBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSchedulerWorkloads", reflect.TypeOf((*MockStore)(nil).DeleteExpiredSchedulerWorkloads), ctx, expiredBefore)
+}
+
 // DeleteKnowledge mocks base method.
 func (m *MockStore) DeleteKnowledge(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -290,6 +395,62 @@ func (mr *MockStoreMockRecorder) DeleteKnowledgeVersion(ctx, id any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKnowledgeVersion", reflect.TypeOf((*MockStore)(nil).DeleteKnowledgeVersion), ctx, id)
 }
 
+// DeleteOrganization mocks base method.
+func (m *MockStore) DeleteOrganization(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrganization", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrganization indicates an expected call of DeleteOrganization.
+func (mr *MockStoreMockRecorder) DeleteOrganization(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrganization", reflect.TypeOf((*MockStore)(nil).DeleteOrganization), ctx, id)
+}
+
+// DeleteOrganizationMembership mocks base method.
+func (m *MockStore) DeleteOrganizationMembership(ctx context.Context, orgID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrganizationMembership", ctx, orgID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrganizationMembership indicates an expected call of DeleteOrganizationMembership.
+func (mr *MockStoreMockRecorder) DeleteOrganizationMembership(ctx, orgID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrganizationMembership", reflect.TypeOf((*MockStore)(nil).DeleteOrganizationMembership), ctx, orgID, userID)
+}
+
+// DeleteProviderEndpoint mocks base method.
+func (m *MockStore) DeleteProviderEndpoint(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProviderEndpoint", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProviderEndpoint indicates an expected call of DeleteProviderEndpoint.
+func (mr *MockStoreMockRecorder) DeleteProviderEndpoint(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProviderEndpoint", reflect.TypeOf((*MockStore)(nil).DeleteProviderEndpoint), ctx, id)
+}
+
+// DeleteSchedulerWorkload mocks base method.
+func (m *MockStore) DeleteSchedulerWorkload(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSchedulerWorkload", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSchedulerWorkload indicates an expected call of DeleteSchedulerWorkload.
+func (mr *MockStoreMockRecorder) DeleteSchedulerWorkload(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSchedulerWorkload", reflect.TypeOf((*MockStore)(nil).DeleteSchedulerWorkload), ctx, id)
+}
+
 // DeleteScriptRun mocks base method.
 func (m *MockStore) DeleteScriptRun(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -391,6 +552,21 @@ func (mr *MockStoreMockRecorder) GetAPIKey(ctx, apiKey any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockStore)(nil).GetAPIKey), ctx, apiKey)
 }
 
+// GetAccessGrant mocks base method.
+func (m *MockStore) GetAccessGrant(ctx context.Context, id string) (*types.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessGrant", ctx, id)
+	ret0, _ := ret[0].(*types.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessGrant indicates an expected call of GetAccessGrant.
+func (mr *MockStoreMockRecorder) GetAccessGrant(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessGrant", reflect.TypeOf((*MockStore)(nil).GetAccessGrant), ctx, id)
+}
+
 // GetApp mocks base method.
 func (m *MockStore) GetApp(ctx context.Context, id string) (*types.App, error) {
 	m.ctrl.T.Helper()
@@ -466,6 +642,81 @@ func (mr *MockStoreMockRecorder) GetKnowledgeVersion(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKnowledgeVersion", reflect.TypeOf((*MockStore)(nil).GetKnowledgeVersion), ctx, id)
 }
 
+// GetLLMCallUsage mocks base method.
+func (m *MockStore) GetLLMCallUsage(ctx context.Context, q *GetLLMCallUsageQuery) ([]*types.UsageRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLLMCallUsage", ctx, q)
+	ret0, _ := ret[0].([]*types.UsageRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLLMCallUsage indicates an expected call of GetLLMCallUsage.
+func (mr *MockStoreMockRecorder) GetLLMCallUsage(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLLMCallUsage", reflect.TypeOf((*MockStore)(nil).GetLLMCallUsage), ctx, q)
+}
+
+// GetOrganization mocks base method.
+func (m *MockStore) GetOrganization(ctx context.Context, id string) (*types.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganization", ctx, id)
+	ret0, _ := ret[0].(*types.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganization indicates an expected call of GetOrganization.
+func (mr *MockStoreMockRecorder) GetOrganization(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganization", reflect.TypeOf((*MockStore)(nil).GetOrganization), ctx, id)
+}
+
+// GetOrganizationMembership mocks base method.
+func (m *MockStore) GetOrganizationMembership(ctx context.Context, orgID, userID string) (*types.OrganizationMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizationMembership", ctx, orgID, userID)
+	ret0, _ := ret[0].(*types.OrganizationMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizationMembership indicates an expected call of GetOrganizationMembership.
+func (mr *MockStoreMockRecorder) GetOrganizationMembership(ctx, orgID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizationMembership", reflect.TypeOf((*MockStore)(nil).GetOrganizationMembership), ctx, orgID, userID)
+}
+
+// GetProviderEndpoint mocks base method.
+func (m *MockStore) GetProviderEndpoint(ctx context.Context, id string) (*types.ProviderEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProviderEndpoint", ctx, id)
+	ret0, _ := ret[0].(*types.ProviderEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProviderEndpoint indicates an expected call of GetProviderEndpoint.
+func (mr *MockStoreMockRecorder) GetProviderEndpoint(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviderEndpoint", reflect.TypeOf((*MockStore)(nil).GetProviderEndpoint), ctx, id)
+}
+
+// GetProviderEndpointByName mocks base method.
+func (m *MockStore) GetProviderEndpointByName(ctx context.Context, name string) (*types.ProviderEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProviderEndpointByName", ctx, name)
+	ret0, _ := ret[0].(*types.ProviderEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProviderEndpointByName indicates an expected call of GetProviderEndpointByName.
+func (mr *MockStoreMockRecorder) GetProviderEndpointByName(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviderEndpointByName", reflect.TypeOf((*MockStore)(nil).GetProviderEndpointByName), ctx, name)
+}
+
 // GetSecret mocks base method.
 func (m *MockStore) GetSecret(ctx context.Context, id string) (*types.Secret, error) {
 	m.ctrl.T.Helper()
@@ -556,6 +807,20 @@ func (mr *MockStoreMockRecorder) GetUserMeta(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMeta", reflect.TypeOf((*MockStore)(nil).GetUserMeta), ctx, id)
 }
 
+// HeartbeatSchedulerWorkloads mocks base method.
+func (m *MockStore) HeartbeatSchedulerWorkloads(ctx context.Context, instance string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HeartbeatSchedulerWorkloads", ctx, instance)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HeartbeatSchedulerWorkloads indicates an expected call of HeartbeatSchedulerWorkloads.
+func (mr *MockStoreMockRecorder) HeartbeatSchedulerWorkloads(ctx, instance any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HeartbeatSchedulerWorkloads", reflect.TypeOf((*MockStore)(nil).HeartbeatSchedulerWorkloads), ctx, instance)
+}
+
 // ListAPIKeys mocks base method.
 func (m *MockStore) ListAPIKeys(ctx context.Context, query *ListApiKeysQuery) ([]*types.APIKey, error) {
 	m.ctrl.T.Helper()
@@ -571,6 +836,21 @@ func (mr *MockStoreMockRecorder) ListAPIKeys(ctx, query any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockStore)(nil).ListAPIKeys), ctx, query)
 }
 
+// ListAccessGrants mocks base method.
+func (m *MockStore) ListAccessGrants(ctx context.Context, q *ListAccessGrantsQuery) ([]*types.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccessGrants", ctx, q)
+	ret0, _ := ret[0].([]*types.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccessGrants indicates an expected call of ListAccessGrants.
+func (mr *MockStoreMockRecorder) ListAccessGrants(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccessGrants", reflect.TypeOf((*MockStore)(nil).ListAccessGrants), ctx, q)
+}
+
 // ListApps mocks base method.
 func (m *MockStore) ListApps(ctx context.Context, q *ListAppsQuery) ([]*types.App, error) {
 	m.ctrl.T.Helper()
@@ -616,6 +896,21 @@ func (mr *MockStoreMockRecorder) ListKnowledge(ctx, q any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKnowledge", reflect.TypeOf((*MockStore)(nil).ListKnowledge), ctx, q)
 }
 
+// ListKnowledgeDocuments mocks base method.
+func (m *MockStore) ListKnowledgeDocuments(ctx context.Context, knowledgeID string) ([]*types.KnowledgeDocument, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKnowledgeDocuments", ctx, knowledgeID)
+	ret0, _ := ret[0].([]*types.KnowledgeDocument)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKnowledgeDocuments indicates an expected call of ListKnowledgeDocuments.
+func (mr *MockStoreMockRecorder) ListKnowledgeDocuments(ctx, knowledgeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKnowledgeDocuments", reflect.TypeOf((*MockStore)(nil).ListKnowledgeDocuments), ctx, knowledgeID)
+}
+
 // ListKnowledgeVersions mocks base method.
 func (m *MockStore) ListKnowledgeVersions(ctx context.Context, q *ListKnowledgeVersionQuery) ([]*types.KnowledgeVersion, error) {
 	m.ctrl.T.Helper()
@@ -647,6 +942,66 @@ func (mr *MockStoreMockRecorder) ListLLMCalls(ctx, q any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLLMCalls", reflect.TypeOf((*MockStore)(nil).ListLLMCalls), ctx, q)
 }
 
+// ListOrganizationMemberships mocks base method.
+func (m *MockStore) ListOrganizationMemberships(ctx context.Context, q *ListOrganizationMembershipsQuery) ([]*types.OrganizationMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrganizationMemberships", ctx, q)
+	ret0, _ := ret[0].([]*types.OrganizationMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrganizationMemberships indicates an expected call of ListOrganizationMemberships.
+func (mr *MockStoreMockRecorder) ListOrganizationMemberships(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrganizationMemberships", reflect.TypeOf((*MockStore)(nil).ListOrganizationMemberships), ctx, q)
+}
+
+// ListOrganizations mocks base method.
+func (m *MockStore) ListOrganizations(ctx context.Context, q *ListOrganizationsQuery) ([]*types.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrganizations", ctx, q)
+	ret0, _ := ret[0].([]*types.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrganizations indicates an expected call of ListOrganizations.
+func (mr *MockStoreMockRecorder) ListOrganizations(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrganizations", reflect.TypeOf((*MockStore)(nil).ListOrganizations), ctx, q)
+}
+
+// ListProviderEndpoints mocks base method.
+func (m *MockStore) ListProviderEndpoints(ctx context.Context, q *ListProviderEndpointsQuery) ([]*types.ProviderEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProviderEndpoints", ctx, q)
+	ret0, _ := ret[0].([]*types.ProviderEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProviderEndpoints indicates an expected call of ListProviderEndpoints.
+func (mr *MockStoreMockRecorder) ListProviderEndpoints(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProviderEndpoints", reflect.TypeOf((*MockStore)(nil).ListProviderEndpoints), ctx, q)
+}
+
+// ListSchedulerWorkloads mocks base method.
+func (m *MockStore) ListSchedulerWorkloads(ctx context.Context, instance string) ([]*types.SchedulerWorkload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSchedulerWorkloads", ctx, instance)
+	ret0, _ := ret[0].([]*types.SchedulerWorkload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSchedulerWorkloads indicates an expected call of ListSchedulerWorkloads.
+func (mr *MockStoreMockRecorder) ListSchedulerWorkloads(ctx, instance any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSchedulerWorkloads", reflect.TypeOf((*MockStore)(nil).ListSchedulerWorkloads), ctx, instance)
+}
+
 // ListScriptRuns mocks base method.
 func (m *MockStore) ListScriptRuns(ctx context.Context, q *types.GptScriptRunsQuery) ([]*types.ScriptRun, error) {
 	m.ctrl.T.Helper()
@@ -722,6 +1077,64 @@ func (mr *MockStoreMockRecorder) LookupKnowledge(ctx, q any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupKnowledge", reflect.TypeOf((*MockStore)(nil).LookupKnowledge), ctx, q)
 }
 
+// ReplaceKnowledgeDocuments mocks base method.
+func (m *MockStore) ReplaceKnowledgeDocuments(ctx context.Context, knowledgeID string, documents []*types.KnowledgeDocument) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceKnowledgeDocuments", ctx, knowledgeID, documents)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceKnowledgeDocuments indicates an expected call of ReplaceKnowledgeDocuments.
+func (mr *MockStoreMockRecorder) ReplaceKnowledgeDocuments(ctx, knowledgeID, documents any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceKnowledgeDocuments", reflect.TypeOf((*MockStore)(nil).ReplaceKnowledgeDocuments), ctx, knowledgeID, documents)
+}
+
+// SaveSchedulerWorkload mocks base method.
+func (m *MockStore) SaveSchedulerWorkload(ctx context.Context, workload *types.SchedulerWorkload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSchedulerWorkload", ctx, workload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSchedulerWorkload indicates an expected call of SaveSchedulerWorkload.
+func (mr *MockStoreMockRecorder) SaveSchedulerWorkload(ctx, workload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSchedulerWorkload", reflect.TypeOf((*MockStore)(nil).SaveSchedulerWorkload), ctx, workload)
+}
+
+// SumLLMCallTokens mocks base method.
+func (m *MockStore) SumLLMCallTokens(ctx context.Context, q *SumLLMCallTokensQuery) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumLLMCallTokens", ctx, q)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumLLMCallTokens indicates an expected call of SumLLMCallTokens.
+func (mr *MockStoreMockRecorder) SumLLMCallTokens(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumLLMCallTokens", reflect.TypeOf((*MockStore)(nil).SumLLMCallTokens), ctx, q)
+}
+
+// UpdateAccessGrant mocks base method.
+func (m *MockStore) UpdateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccessGrant", ctx, grant)
+	ret0, _ := ret[0].(*types.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccessGrant indicates an expected call of UpdateAccessGrant.
+func (mr *MockStoreMockRecorder) UpdateAccessGrant(ctx, grant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccessGrant", reflect.TypeOf((*MockStore)(nil).UpdateAccessGrant), ctx, grant)
+}
+
 // UpdateApp mocks base method.
 func (m *MockStore) UpdateApp(ctx context.Context, tool *types.App) (*types.App, error) {
 	m.ctrl.T.Helper()
@@ -781,6 +1194,65 @@ func (mr *MockStoreMockRecorder) UpdateKnowledgeState(ctx, id, state, message, p
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateKnowledgeState", reflect.TypeOf((*MockStore)(nil).UpdateKnowledgeState), ctx, id, state, message, percent)
 }
 
+// UpdateOrganization mocks base method.
+func (m *MockStore) UpdateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrganization", ctx, org)
+	ret0, _ := ret[0].(*types.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrganization indicates an expected call of UpdateOrganization.
+func (mr *MockStoreMockRecorder) UpdateOrganization(ctx, org any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrganization", reflect.TypeOf((*MockStore)(nil).UpdateOrganization), ctx, org)
+}
+
+// UpdateOrganizationMembership mocks base method.
+func (m *MockStore) UpdateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrganizationMembership", ctx, membership)
+	ret0, _ := ret[0].(*types.OrganizationMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrganizationMembership indicates an expected call of UpdateOrganizationMembership.
+func (mr *MockStoreMockRecorder) UpdateOrganizationMembership(ctx, membership any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrganizationMembership", reflect.TypeOf((*MockStore)(nil).UpdateOrganizationMembership), ctx, membership)
+}
+
+// UpdateProviderEndpoint mocks base method.
+func (m *MockStore) UpdateProviderEndpoint(ctx context.Context, endpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProviderEndpoint", ctx, endpoint)
+	ret0, _ := ret[0].(*types.ProviderEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProviderEndpoint indicates an expected call of UpdateProviderEndpoint.
+func (mr *MockStoreMockRecorder) UpdateProviderEndpoint(ctx, endpoint any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProviderEndpoint", reflect.TypeOf((*MockStore)(nil).UpdateProviderEndpoint), ctx, endpoint)
+}
+
+// UpdateSchedulerWorkloadState mocks base method.
+func (m *MockStore) UpdateSchedulerWorkloadState(ctx context.Context, id string, state types.SchedulerWorkloadState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSchedulerWorkloadState", ctx, id, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSchedulerWorkloadState indicates an expected call of UpdateSchedulerWorkloadState.
+func (mr *MockStoreMockRecorder) UpdateSchedulerWorkloadState(ctx, id, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSchedulerWorkloadState", reflect.TypeOf((*MockStore)(nil).UpdateSchedulerWorkloadState), ctx, id, state)
+}
+
 // UpdateSecret mocks base method.
 func (m *MockStore) UpdateSecret(ctx context.Context, secret *types.Secret) (*types.Secret, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"gorm.io/gorm"
+)
+
+type ListOrganizationsQuery struct {
+	// UserID limits the list to the organizations the user is a member of
+	UserID string `json:"user_id"`
+}
+
+type ListOrganizationMembershipsQuery struct {
+	OrganizationID string `json:"organization_id"`
+	UserID         string `json:"user_id"`
+}
+
+// CreateOrganization creates the organization and makes its owner the first
+// member with the owner role
+func (s *PostgresStore) CreateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	if org.ID == "" {
+		org.ID = system.GenerateOrganizationID()
+	}
+
+	if org.Name == "" {
+		return nil, fmt.Errorf("name not specified")
+	}
+
+	if org.Owner == "" {
+		return nil, fmt.Errorf("owner not specified")
+	}
+
+	org.Created = time.Now()
+	org.Updated = org.Created
+
+	err := s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing types.Organization
+		if err := tx.Where("name = ?", org.Name).First(&existing).Error; err == nil {
+			return fmt.Errorf("an organization with the name '%s' already exists", org.Name)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&types.OrganizationMembership{
+			OrganizationID: org.ID,
+			UserID:         org.Owner,
+			Created:        org.Created,
+			Updated:        org.Created,
+			Role:           types.OrganizationRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrganization(ctx, org.ID)
+}
+
+func (s *PostgresStore) UpdateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	if org.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	if org.Name == "" {
+		return nil, fmt.Errorf("name not specified")
+	}
+
+	org.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(org).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrganization(ctx, org.ID)
+}
+
+func (s *PostgresStore) GetOrganization(ctx context.Context, id string) (*types.Organization, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	var org types.Organization
+	err := s.gdb.WithContext(ctx).Where("id = ?", id).First(&org).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (s *PostgresStore) ListOrganizations(ctx context.Context, q *ListOrganizationsQuery) ([]*types.Organization, error) {
+	query := s.gdb.WithContext(ctx)
+
+	if q != nil && q.UserID != "" {
+		query = query.Where("id IN (?)", s.gdb.Model(&types.OrganizationMembership{}).
+			Select("organization_id").
+			Where("user_id = ?", q.UserID))
+	}
+
+	var orgs []*types.Organization
+	err := query.Order("name ASC").Find(&orgs).Error
+	if err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// DeleteOrganization deletes the organization and its memberships, resources
+// owned by the organization are not deleted
+func (s *PostgresStore) DeleteOrganization(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", id).Delete(&types.OrganizationMembership{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&types.Organization{ID: id}).Error
+	})
+}
+
+func (s *PostgresStore) CreateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error) {
+	if membership.OrganizationID == "" {
+		return nil, fmt.Errorf("organization id not specified")
+	}
+
+	if membership.UserID == "" {
+		return nil, fmt.Errorf("user id not specified")
+	}
+
+	if !membership.Role.Valid() {
+		return nil, fmt.Errorf("invalid role '%s'", membership.Role)
+	}
+
+	membership.Created = time.Now()
+	membership.Updated = membership.Created
+
+	err := s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing types.OrganizationMembership
+		err := tx.Where("organization_id = ? AND user_id = ?", membership.OrganizationID, membership.UserID).First(&existing).Error
+		if err == nil {
+			return fmt.Errorf("user '%s' is already a member of the organization", membership.UserID)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return tx.Create(membership).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrganizationMembership(ctx, membership.OrganizationID, membership.UserID)
+}
+
+func (s *PostgresStore) UpdateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error) {
+	if membership.OrganizationID == "" {
+		return nil, fmt.Errorf("organization id not specified")
+	}
+
+	if membership.UserID == "" {
+		return nil, fmt.Errorf("user id not specified")
+	}
+
+	if !membership.Role.Valid() {
+		return nil, fmt.Errorf("invalid role '%s'", membership.Role)
+	}
+
+	membership.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(membership).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrganizationMembership(ctx, membership.OrganizationID, membership.UserID)
+}
+
+func (s *PostgresStore) GetOrganizationMembership(ctx context.Context, orgID, userID string) (*types.OrganizationMembership, error) {
+	if orgID == "" {
+		return nil, fmt.Errorf("organization id not specified")
+	}
+
+	if userID == "" {
+		return nil, fmt.Errorf("user id not specified")
+	}
+
+	var membership types.OrganizationMembership
+	err := s.gdb.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (s *PostgresStore) ListOrganizationMemberships(ctx context.Context, q *ListOrganizationMembershipsQuery) ([]*types.OrganizationMembership, error) {
+	query := s.gdb.WithContext(ctx)
+
+	if q != nil {
+		if q.OrganizationID != "" {
+			query = query.Where("organization_id = ?", q.OrganizationID)
+		}
+		if q.UserID != "" {
+			query = query.Where("user_id = ?", q.UserID)
+		}
+	}
+
+	var memberships []*types.OrganizationMembership
+	err := query.Order("created ASC").Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (s *PostgresStore) DeleteOrganizationMembership(ctx context.Context, orgID, userID string) error {
+	if orgID == "" {
+		return fmt.Errorf("organization id not specified")
+	}
+
+	if userID == "" {
+		return fmt.Errorf("user id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Delete(&types.OrganizationMembership{}).Error
+}
+
+// ownedBy restricts the query to resources of the owner. When member is set,
+// resources owned by the organizations the member belongs to match as well.
+func ownedBy(query *gorm.DB, owner string, ownerType types.OwnerType, member string) *gorm.DB {
+	if member == "" {
+		if owner != "" {
+			query = query.Where("owner = ?", owner)
+		}
+		if ownerType != "" {
+			query = query.Where("owner_type = ?", ownerType)
+		}
+		return query
+	}
+
+	orgs := "(owner_type = ? AND owner IN (SELECT organization_id FROM organization_memberships WHERE user_id = ?))"
+
+	switch {
+	case owner == "":
+		return query.Where(orgs, types.OwnerTypeOrg, member)
+	case ownerType == "":
+		return query.Where("(owner = ? OR "+orgs+")", owner, types.OwnerTypeOrg, member)
+	default:
+		return query.Where("((owner = ? AND owner_type = ?) OR "+orgs+")", owner, ownerType, types.OwnerTypeOrg, member)
+	}
+}
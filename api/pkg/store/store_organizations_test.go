@@ -0,0 +1,142 @@
+package store
+
+import (
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) createTestOrganization(owner string) *types.Organization {
+	org, err := suite.db.CreateOrganization(suite.ctx, &types.Organization{
+		Name:  "test-org-" + system.GenerateUUID(),
+		Owner: owner,
+	})
+	require.NoError(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		assert.NoError(suite.T(), suite.db.DeleteOrganization(suite.ctx, org.ID))
+	})
+
+	return org
+}
+
+func (suite *PostgresStoreTestSuite) TestOrganizationCreate() {
+	owner := "test-owner-" + system.GenerateUUID()
+	org := suite.createTestOrganization(owner)
+
+	assert.NotEmpty(suite.T(), org.ID)
+
+	membership, err := suite.db.GetOrganizationMembership(suite.ctx, org.ID, owner)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), types.OrganizationRoleOwner, membership.Role)
+
+	_, err = suite.db.CreateOrganization(suite.ctx, &types.Organization{
+		Name:  org.Name,
+		Owner: owner,
+	})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PostgresStoreTestSuite) TestOrganizationMemberships() {
+	owner := "test-owner-" + system.GenerateUUID()
+	member := "test-member-" + system.GenerateUUID()
+	org := suite.createTestOrganization(owner)
+
+	_, err := suite.db.CreateOrganizationMembership(suite.ctx, &types.OrganizationMembership{
+		OrganizationID: org.ID,
+		UserID:         member,
+		Role:           types.OrganizationRoleMember,
+	})
+	require.NoError(suite.T(), err)
+
+	_, err = suite.db.CreateOrganizationMembership(suite.ctx, &types.OrganizationMembership{
+		OrganizationID: org.ID,
+		UserID:         member,
+		Role:           types.OrganizationRoleAdmin,
+	})
+	assert.Error(suite.T(), err, "user is already a member")
+
+	orgs, err := suite.db.ListOrganizations(suite.ctx, &ListOrganizationsQuery{UserID: member})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), orgs, 1)
+	assert.Equal(suite.T(), org.ID, orgs[0].ID)
+
+	updated, err := suite.db.UpdateOrganizationMembership(suite.ctx, &types.OrganizationMembership{
+		OrganizationID: org.ID,
+		UserID:         member,
+		Role:           types.OrganizationRoleAdmin,
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), types.OrganizationRoleAdmin, updated.Role)
+
+	memberships, err := suite.db.ListOrganizationMemberships(suite.ctx, &ListOrganizationMembershipsQuery{OrganizationID: org.ID})
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), memberships, 2)
+
+	err = suite.db.DeleteOrganizationMembership(suite.ctx, org.ID, member)
+	require.NoError(suite.T(), err)
+
+	orgs, err = suite.db.ListOrganizations(suite.ctx, &ListOrganizationsQuery{UserID: member})
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), orgs)
+}
+
+func (suite *PostgresStoreTestSuite) TestOrganizationOwnedSecrets() {
+	owner := "test-owner-" + system.GenerateUUID()
+	member := "test-member-" + system.GenerateUUID()
+	org := suite.createTestOrganization(owner)
+
+	_, err := suite.db.CreateOrganizationMembership(suite.ctx, &types.OrganizationMembership{
+		OrganizationID: org.ID,
+		UserID:         member,
+		Role:           types.OrganizationRoleMember,
+	})
+	require.NoError(suite.T(), err)
+
+	orgSecret, err := suite.db.CreateSecret(suite.ctx, &types.Secret{
+		Name:      "org-secret",
+		Owner:     org.ID,
+		OwnerType: types.OwnerTypeOrg,
+		Value:     []byte("org-value"),
+	})
+	require.NoError(suite.T(), err)
+
+	memberSecret, err := suite.db.CreateSecret(suite.ctx, &types.Secret{
+		Name:      "member-secret",
+		Owner:     member,
+		OwnerType: types.OwnerTypeUser,
+		Value:     []byte("member-value"),
+	})
+	require.NoError(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		assert.NoError(suite.T(), suite.db.DeleteSecret(suite.ctx, orgSecret.ID))
+		assert.NoError(suite.T(), suite.db.DeleteSecret(suite.ctx, memberSecret.ID))
+	})
+
+	secrets, err := suite.db.ListSecrets(suite.ctx, &ListSecretsQuery{
+		Owner:     member,
+		OwnerType: types.OwnerTypeUser,
+	})
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), secrets, 1)
+
+	secrets, err = suite.db.ListSecrets(suite.ctx, &ListSecretsQuery{
+		Owner:     member,
+		OwnerType: types.OwnerTypeUser,
+		Member:    member,
+	})
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), secrets, 2)
+
+	// The owner only sees the organization secret
+	secrets, err = suite.db.ListSecrets(suite.ctx, &ListSecretsQuery{
+		Owner:     owner,
+		OwnerType: types.OwnerTypeUser,
+		Member:    owner,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), secrets, 1)
+	assert.Equal(suite.T(), orgSecret.ID, secrets[0].ID)
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"gorm.io/gorm"
+)
+
+type ListProviderEndpointsQuery struct {
+	Owner     string          `json:"owner"`
+	OwnerType types.OwnerType `json:"owner_type"`
+}
+
+func (s *PostgresStore) CreateProviderEndpoint(ctx context.Context, endpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error) {
+	if endpoint.ID == "" {
+		endpoint.ID = system.GenerateProviderEndpointID()
+	}
+
+	if endpoint.Name == "" {
+		return nil, fmt.Errorf("name not specified")
+	}
+
+	if endpoint.Owner == "" {
+		return nil, fmt.Errorf("owner not specified")
+	}
+
+	endpoint.Created = time.Now()
+	endpoint.Updated = endpoint.Created
+
+	err := s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing types.ProviderEndpoint
+		if err := tx.Where("name = ?", endpoint.Name).First(&existing).Error; err == nil {
+			return fmt.Errorf("a provider endpoint with the name '%s' already exists", endpoint.Name)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return tx.Create(endpoint).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetProviderEndpoint(ctx, endpoint.ID)
+}
+
+func (s *PostgresStore) UpdateProviderEndpoint(ctx context.Context, endpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error) {
+	if endpoint.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	if endpoint.Owner == "" {
+		return nil, fmt.Errorf("owner not specified")
+	}
+
+	endpoint.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(endpoint).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetProviderEndpoint(ctx, endpoint.ID)
+}
+
+func (s *PostgresStore) GetProviderEndpoint(ctx context.Context, id string) (*types.ProviderEndpoint, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	var endpoint types.ProviderEndpoint
+	err := s.gdb.WithContext(ctx).Where("id = ?", id).First(&endpoint).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// GetProviderEndpointByName looks up the endpoint by its name which is
+// used as the provider name when selecting a client
+func (s *PostgresStore) GetProviderEndpointByName(ctx context.Context, name string) (*types.ProviderEndpoint, error) {
+	if name == "" {
+		return nil, fmt.Errorf("name not specified")
+	}
+
+	var endpoint types.ProviderEndpoint
+	err := s.gdb.WithContext(ctx).Where("name = ?", name).First(&endpoint).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (s *PostgresStore) ListProviderEndpoints(ctx context.Context, q *ListProviderEndpointsQuery) ([]*types.ProviderEndpoint, error) {
+	query := s.gdb.WithContext(ctx)
+
+	if q != nil {
+		if q.Owner != "" {
+			query = query.Where("owner = ?", q.Owner)
+		}
+		if q.OwnerType != "" {
+			query = query.Where("owner_type = ?", q.OwnerType)
+		}
+	}
+
+	var endpoints []*types.ProviderEndpoint
+	err := query.Order("name ASC").Find(&endpoints).Error
+	if err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (s *PostgresStore) DeleteProviderEndpoint(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	err := s.gdb.WithContext(ctx).Delete(&types.ProviderEndpoint{
+		ID: id,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
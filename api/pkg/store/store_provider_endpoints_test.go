@@ -0,0 +1,83 @@
+package store
+
+import (
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) TestProviderEndpointCreate() {
+	endpoint := &types.ProviderEndpoint{
+		Name:         "test-endpoint-" + system.GenerateUUID(),
+		Owner:        "test-owner-" + system.GenerateUUID(),
+		OwnerType:    types.OwnerTypeUser,
+		BaseURL:      "http://localhost:8000/v1",
+		APIKeySecret: "test-secret",
+		Models:       []string{"model-a", "model-b"},
+	}
+
+	created, err := suite.db.CreateProviderEndpoint(suite.ctx, endpoint)
+	require.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), created.ID)
+	assert.Equal(suite.T(), endpoint.Name, created.Name)
+	assert.Equal(suite.T(), endpoint.Models, created.Models)
+
+	fetched, err := suite.db.GetProviderEndpointByName(suite.ctx, endpoint.Name)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), created.ID, fetched.ID)
+
+	// Names are unique
+	_, err = suite.db.CreateProviderEndpoint(suite.ctx, &types.ProviderEndpoint{
+		Name:    endpoint.Name,
+		Owner:   endpoint.Owner,
+		BaseURL: endpoint.BaseURL,
+	})
+	assert.Error(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		err := suite.db.DeleteProviderEndpoint(suite.ctx, created.ID)
+		assert.NoError(suite.T(), err)
+	})
+}
+
+func (suite *PostgresStoreTestSuite) TestProviderEndpointUpdate() {
+	endpoint := &types.ProviderEndpoint{
+		Name:    "test-endpoint-" + system.GenerateUUID(),
+		Owner:   "test-owner-" + system.GenerateUUID(),
+		BaseURL: "http://localhost:8000/v1",
+	}
+
+	created, err := suite.db.CreateProviderEndpoint(suite.ctx, endpoint)
+	require.NoError(suite.T(), err)
+
+	created.BaseURL = "http://localhost:9000/v1"
+	created.Models = []string{"model-c"}
+
+	updated, err := suite.db.UpdateProviderEndpoint(suite.ctx, created)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "http://localhost:9000/v1", updated.BaseURL)
+	assert.Equal(suite.T(), []string{"model-c"}, updated.Models)
+
+	suite.T().Cleanup(func() {
+		err := suite.db.DeleteProviderEndpoint(suite.ctx, created.ID)
+		assert.NoError(suite.T(), err)
+	})
+}
+
+func (suite *PostgresStoreTestSuite) TestProviderEndpointDelete() {
+	endpoint := &types.ProviderEndpoint{
+		Name:    "test-endpoint-" + system.GenerateUUID(),
+		Owner:   "test-owner-" + system.GenerateUUID(),
+		BaseURL: "http://localhost:8000/v1",
+	}
+
+	created, err := suite.db.CreateProviderEndpoint(suite.ctx, endpoint)
+	require.NoError(suite.T(), err)
+
+	err = suite.db.DeleteProviderEndpoint(suite.ctx, created.ID)
+	require.NoError(suite.T(), err)
+
+	_, err = suite.db.GetProviderEndpoint(suite.ctx, created.ID)
+	assert.ErrorIs(suite.T(), err, ErrNotFound)
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveSchedulerWorkload creates or replaces the workload
+func (s *PostgresStore) SaveSchedulerWorkload(ctx context.Context, workload *types.SchedulerWorkload) error {
+	if workload.ID == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	if workload.Workload == nil {
+		return fmt.Errorf("workload not specified")
+	}
+
+	now := time.Now()
+	if workload.Created.IsZero() {
+		workload.Created = now
+	}
+	workload.Updated = now
+	workload.Heartbeat = now
+
+	return s.gdb.WithContext(ctx).Save(workload).Error
+}
+
+func (s *PostgresStore) UpdateSchedulerWorkloadState(ctx context.Context, id string, state types.SchedulerWorkloadState) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Model(&types.SchedulerWorkload{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"state":   state,
+		"updated": time.Now(),
+	}).Error
+}
+
+// ListSchedulerWorkloads returns the workloads of the scheduler instance in
+// the order they were queued
+func (s *PostgresStore) ListSchedulerWorkloads(ctx context.Context, instance string) ([]*types.SchedulerWorkload, error) {
+	var workloads []*types.SchedulerWorkload
+
+	err := s.gdb.WithContext(ctx).Where("instance = ?", instance).Order("created ASC").Find(&workloads).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return workloads, nil
+}
+
+// HeartbeatSchedulerWorkloads keeps the workloads of a running scheduler
+// instance from being taken over
+func (s *PostgresStore) HeartbeatSchedulerWorkloads(ctx context.Context, instance string) error {
+	return s.gdb.WithContext(ctx).Model(&types.SchedulerWorkload{}).Where("instance = ?", instance).Update("heartbeat", time.Now()).Error
+}
+
+// ClaimSchedulerWorkloads takes over the workloads of the other instances
+// that weren't seen since expiredBefore, in the order they were queued.
+// Concurrent claims take over each workload once.
+func (s *PostgresStore) ClaimSchedulerWorkloads(ctx context.Context, instance string, expiredBefore time.Time) ([]*types.SchedulerWorkload, error) {
+	var workloads []*types.SchedulerWorkload
+
+	err := s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("instance <> ? AND heartbeat < ?", instance, expiredBefore).
+			Order("created ASC").
+			Find(&workloads).Error
+		if err != nil {
+			return err
+		}
+
+		if len(workloads) == 0 {
+			return nil
+		}
+
+		ids := make([]string, 0, len(workloads))
+		for _, w := range workloads {
+			ids = append(ids, w.ID)
+		}
+
+		now := time.Now()
+
+		err = tx.Model(&types.SchedulerWorkload{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"instance":  instance,
+			"heartbeat": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, w := range workloads {
+			w.Instance = instance
+			w.Heartbeat = now
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workloads, nil
+}
+
+// DeleteExpiredSchedulerWorkloads deletes the workloads abandoned since
+// expiredBefore and returns how many were deleted
+func (s *PostgresStore) DeleteExpiredSchedulerWorkloads(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	res := s.gdb.WithContext(ctx).Where("heartbeat < ?", expiredBefore).Delete(&types.SchedulerWorkload{})
+	return res.RowsAffected, res.Error
+}
+
+func (s *PostgresStore) DeleteSchedulerWorkload(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Delete(&types.SchedulerWorkload{ID: id}).Error
+}
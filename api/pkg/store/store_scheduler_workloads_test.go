@@ -0,0 +1,121 @@
+package store
+
+import (
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) TestSchedulerWorkloads() {
+	id := "test-request-" + system.GenerateUUID()
+
+	err := suite.db.SaveSchedulerWorkload(suite.ctx, &types.SchedulerWorkload{
+		ID:       id,
+		Instance: "test-instance",
+		State:    types.SchedulerWorkloadStateQueued,
+		Workload: &types.RunnerWorkload{
+			LLMInferenceRequest: &types.RunnerLLMInferenceRequest{
+				RequestID: id,
+				OwnerID:   "test-owner",
+			},
+		},
+	})
+	require.NoError(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		err := suite.db.DeleteSchedulerWorkload(suite.ctx, id)
+		assert.NoError(suite.T(), err)
+	})
+
+	err = suite.db.UpdateSchedulerWorkloadState(suite.ctx, id, types.SchedulerWorkloadStateRunning)
+	require.NoError(suite.T(), err)
+
+	// Other instances restore their own workloads
+	workloads, err := suite.db.ListSchedulerWorkloads(suite.ctx, "other-instance")
+	require.NoError(suite.T(), err)
+
+	for _, w := range workloads {
+		assert.NotEqual(suite.T(), id, w.ID)
+	}
+
+	workloads, err = suite.db.ListSchedulerWorkloads(suite.ctx, "test-instance")
+	require.NoError(suite.T(), err)
+
+	var found *types.SchedulerWorkload
+	for _, w := range workloads {
+		if w.ID == id {
+			found = w
+		}
+	}
+
+	require.NotNil(suite.T(), found)
+	assert.Equal(suite.T(), types.SchedulerWorkloadStateRunning, found.State)
+	require.NotNil(suite.T(), found.Workload.LLMInferenceRequest)
+	assert.Equal(suite.T(), "test-owner", found.Workload.LLMInferenceRequest.OwnerID)
+
+	err = suite.db.DeleteSchedulerWorkload(suite.ctx, id)
+	require.NoError(suite.T(), err)
+
+	workloads, err = suite.db.ListSchedulerWorkloads(suite.ctx, "test-instance")
+	require.NoError(suite.T(), err)
+
+	for _, w := range workloads {
+		assert.NotEqual(suite.T(), id, w.ID)
+	}
+}
+
+func (suite *PostgresStoreTestSuite) TestSchedulerWorkloads_Claim() {
+	id := "test-session-" + system.GenerateUUID()
+	oldInstance := "old-instance-" + system.GenerateUUID()
+	newInstance := "new-instance-" + system.GenerateUUID()
+
+	err := suite.db.SaveSchedulerWorkload(suite.ctx, &types.SchedulerWorkload{
+		ID:       id,
+		Instance: oldInstance,
+		State:    types.SchedulerWorkloadStateQueued,
+		Workload: &types.RunnerWorkload{
+			Session: &types.Session{ID: id},
+		},
+	})
+	require.NoError(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		err := suite.db.DeleteSchedulerWorkload(suite.ctx, id)
+		assert.NoError(suite.T(), err)
+	})
+
+	// The old instance is still alive
+	claimed, err := suite.db.ClaimSchedulerWorkloads(suite.ctx, newInstance, time.Now().Add(-time.Minute))
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), claimed)
+
+	err = suite.db.HeartbeatSchedulerWorkloads(suite.ctx, oldInstance)
+	require.NoError(suite.T(), err)
+
+	// The old instance stopped
+	claimed, err = suite.db.ClaimSchedulerWorkloads(suite.ctx, newInstance, time.Now().Add(time.Minute))
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), claimed, 1)
+	assert.Equal(suite.T(), id, claimed[0].ID)
+	assert.Equal(suite.T(), newInstance, claimed[0].Instance)
+
+	workloads, err := suite.db.ListSchedulerWorkloads(suite.ctx, newInstance)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), workloads, 1)
+
+	// Claimed once
+	claimed, err = suite.db.ClaimSchedulerWorkloads(suite.ctx, newInstance, time.Now().Add(time.Minute))
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), claimed)
+
+	deleted, err := suite.db.DeleteExpiredSchedulerWorkloads(suite.ctx, time.Now().Add(time.Minute))
+	require.NoError(suite.T(), err)
+	assert.GreaterOrEqual(suite.T(), deleted, int64(1))
+
+	workloads, err = suite.db.ListSchedulerWorkloads(suite.ctx, newInstance)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), workloads)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/helixml/helix/api/pkg/encryption"
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
 	"gorm.io/gorm"
@@ -23,7 +24,12 @@ func (s *PostgresStore) CreateSecret(ctx context.Context, secret *types.Secret)
 	secret.Created = time.Now()
 	secret.Updated = secret.Created
 
-	err := s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	row, err := s.sealSecret(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Check if a secret with the same name already exists for this owner
 		var existingSecret types.Secret
 		if err := tx.Where("owner = ? AND name = ?", secret.Owner, secret.Name).First(&existingSecret).Error; err == nil {
@@ -33,7 +39,7 @@ func (s *PostgresStore) CreateSecret(ctx context.Context, secret *types.Secret)
 		}
 
 		// If no existing secret found, create the new one
-		return tx.Create(secret).Error
+		return tx.Create(row).Error
 	})
 	if err != nil {
 		return nil, err
@@ -52,7 +58,12 @@ func (s *PostgresStore) UpdateSecret(ctx context.Context, secret *types.Secret)
 
 	secret.Updated = time.Now()
 
-	err := s.gdb.WithContext(ctx).Save(secret).Error
+	row, err := s.sealSecret(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.gdb.WithContext(ctx).Save(row).Error
 	if err != nil {
 		return nil, err
 	}
@@ -72,22 +83,29 @@ func (s *PostgresStore) GetSecret(ctx context.Context, id string) (*types.Secret
 		}
 		return nil, err
 	}
+
+	if err := s.openSecret(ctx, &secret); err != nil {
+		return nil, err
+	}
 	return &secret, nil
 }
 
 func (s *PostgresStore) ListSecrets(ctx context.Context, q *ListSecretsQuery) ([]*types.Secret, error) {
-	if q.Owner == "" {
+	if q.Owner == "" && q.Member == "" {
 		return nil, fmt.Errorf("owner not specified")
 	}
 
 	var secrets []*types.Secret
-	err := s.gdb.WithContext(ctx).Where(&types.Secret{
-		Owner:     q.Owner,
-		OwnerType: q.OwnerType,
-	}).Find(&secrets).Error
+	err := ownedBy(s.gdb.WithContext(ctx), q.Owner, q.OwnerType, q.Member).Find(&secrets).Error
 	if err != nil {
 		return nil, err
 	}
+
+	for _, secret := range secrets {
+		if err := s.openSecret(ctx, secret); err != nil {
+			return nil, err
+		}
+	}
 	return secrets, nil
 }
 
@@ -104,3 +122,110 @@ func (s *PostgresStore) DeleteSecret(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// RotateSecretsMasterKey re-wraps the data keys of all secrets with the current
+// master key and encrypts secrets that were stored before encryption was enabled.
+// Returns the number of updated secrets.
+func (s *PostgresStore) RotateSecretsMasterKey(ctx context.Context) (int, error) {
+	if s.secrets == nil {
+		return 0, fmt.Errorf("no secrets master key configured")
+	}
+
+	var (
+		rows    []*types.Secret
+		updated int
+	)
+
+	result := s.gdb.WithContext(ctx).FindInBatches(&rows, 100, func(_ *gorm.DB, _ int) error {
+		for _, row := range rows {
+			var (
+				sealed  *encryption.Sealed
+				changed = true
+				err     error
+			)
+
+			if row.KeyID == "" {
+				sealed, err = s.secrets.Seal(ctx, row.Value, []byte(row.ID))
+			} else {
+				sealed, changed, err = s.secrets.Rewrap(ctx, secretSealed(row))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to encrypt secret %s: %w", row.ID, err)
+			}
+			if !changed {
+				continue
+			}
+
+			err = s.gdb.WithContext(ctx).Model(&types.Secret{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+				"key_id":        sealed.KeyID,
+				"encrypted_key": sealed.EncryptedKey,
+				"value":         sealed.Ciphertext,
+			}).Error
+			if err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return updated, result.Error
+	}
+
+	return updated, nil
+}
+
+// sealSecret returns a copy of the secret with the value encrypted, the secret
+// is stored as is when encryption is not configured
+func (s *PostgresStore) sealSecret(ctx context.Context, secret *types.Secret) (*types.Secret, error) {
+	row := *secret
+	row.KeyID = ""
+	row.EncryptedKey = nil
+
+	if s.secrets == nil {
+		return &row, nil
+	}
+
+	// Binding the ciphertext to the ID prevents values from being swapped between rows
+	sealed, err := s.secrets.Seal(ctx, secret.Value, []byte(secret.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	row.KeyID = sealed.KeyID
+	row.EncryptedKey = sealed.EncryptedKey
+	row.Value = sealed.Ciphertext
+
+	return &row, nil
+}
+
+// openSecret decrypts the secret value in place, secrets stored before
+// encryption was enabled are returned unchanged
+func (s *PostgresStore) openSecret(ctx context.Context, secret *types.Secret) error {
+	if secret.KeyID == "" {
+		return nil
+	}
+
+	if s.secrets == nil {
+		return fmt.Errorf("secret %s is encrypted but no secrets master key is configured", secret.ID)
+	}
+
+	value, err := s.secrets.Open(ctx, secretSealed(secret), []byte(secret.ID))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret %s: %w", secret.ID, err)
+	}
+
+	secret.Value = value
+	secret.KeyID = ""
+	secret.EncryptedKey = nil
+
+	return nil
+}
+
+func secretSealed(secret *types.Secret) *encryption.Sealed {
+	return &encryption.Sealed{
+		KeyID:        secret.KeyID,
+		EncryptedKey: secret.EncryptedKey,
+		Ciphertext:   secret.Value,
+	}
+}
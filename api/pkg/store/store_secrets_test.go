@@ -1,6 +1,10 @@
 package store
 
 import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/helixml/helix/api/pkg/config"
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
 	"github.com/stretchr/testify/assert"
@@ -105,3 +109,105 @@ func (suite *PostgresStoreTestSuite) TestSecretDelete() {
 	_, err = suite.db.GetSecret(suite.ctx, createdSecret.ID)
 	assert.Error(suite.T(), err)
 }
+
+// Fixed keys so that rows left behind by interrupted runs can still be rotated
+var (
+	testSecretsKey    = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	testSecretsNewKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", 32)))
+)
+
+func (suite *PostgresStoreTestSuite) newEncryptedStore(encryption config.SecretsEncryption) *PostgresStore {
+	cfg := suite.db.cfg
+	cfg.SecretsEncryption = encryption
+
+	store, err := NewPostgresStore(cfg)
+	require.NoError(suite.T(), err)
+	return store
+}
+
+func (suite *PostgresStoreTestSuite) TestSecretEncrypted() {
+	db := suite.newEncryptedStore(config.SecretsEncryption{MasterKey: testSecretsKey})
+
+	createdSecret, err := db.CreateSecret(suite.ctx, &types.Secret{
+		Name:  "encrypted-secret",
+		Owner: "test-owner-" + system.GenerateUUID(),
+		Value: []byte("encrypted-value"),
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "encrypted-value", string(createdSecret.Value))
+
+	suite.T().Cleanup(func() {
+		err := db.DeleteSecret(suite.ctx, createdSecret.ID)
+		assert.NoError(suite.T(), err)
+	})
+
+	var row types.Secret
+	err = db.gdb.Where("id = ?", createdSecret.ID).First(&row).Error
+	require.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), row.KeyID)
+	assert.NotEmpty(suite.T(), row.EncryptedKey)
+	assert.NotContains(suite.T(), string(row.Value), "encrypted-value")
+
+	listedSecrets, err := db.ListSecrets(suite.ctx, &ListSecretsQuery{Owner: createdSecret.Owner})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), listedSecrets, 1)
+	assert.Equal(suite.T(), "encrypted-value", string(listedSecrets[0].Value))
+
+	// Without the master key the value can't be read
+	_, err = suite.db.GetSecret(suite.ctx, createdSecret.ID)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PostgresStoreTestSuite) TestSecretRotateMasterKey() {
+	owner := "test-owner-" + system.GenerateUUID()
+
+	// Stored before encryption was enabled
+	plainSecret, err := suite.db.CreateSecret(suite.ctx, &types.Secret{
+		Name:  "plain-secret",
+		Owner: owner,
+		Value: []byte("plain-value"),
+	})
+	require.NoError(suite.T(), err)
+
+	oldDB := suite.newEncryptedStore(config.SecretsEncryption{MasterKey: testSecretsKey})
+	encryptedSecret, err := oldDB.CreateSecret(suite.ctx, &types.Secret{
+		Name:  "encrypted-secret",
+		Owner: owner,
+		Value: []byte("encrypted-value"),
+	})
+	require.NoError(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		assert.NoError(suite.T(), suite.db.DeleteSecret(suite.ctx, plainSecret.ID))
+		assert.NoError(suite.T(), suite.db.DeleteSecret(suite.ctx, encryptedSecret.ID))
+	})
+
+	db := suite.newEncryptedStore(config.SecretsEncryption{
+		MasterKey:          testSecretsNewKey,
+		PreviousMasterKeys: []string{testSecretsKey},
+	})
+
+	updated, err := db.RotateSecretsMasterKey(suite.ctx)
+	require.NoError(suite.T(), err)
+	assert.GreaterOrEqual(suite.T(), updated, 2)
+
+	// Only the new key is needed after the rotation
+	newDB := suite.newEncryptedStore(config.SecretsEncryption{MasterKey: testSecretsNewKey})
+
+	listedSecrets, err := newDB.ListSecrets(suite.ctx, &ListSecretsQuery{Owner: owner})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), listedSecrets, 2)
+
+	values := map[string]string{}
+	for _, secret := range listedSecrets {
+		values[secret.Name] = string(secret.Value)
+	}
+	assert.Equal(suite.T(), map[string]string{
+		"plain-secret":     "plain-value",
+		"encrypted-secret": "encrypted-value",
+	}, values)
+
+	updated, err = db.RotateSecretsMasterKey(suite.ctx)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, updated)
+}
@@ -66,11 +66,10 @@ func (s *PostgresStore) GetTool(ctx context.Context, id string) (*types.Tool, er
 
 func (s *PostgresStore) ListTools(ctx context.Context, q *ListToolsQuery) ([]*types.Tool, error) {
 	var tools []*types.Tool
-	err := s.gdb.WithContext(ctx).Where(&types.Tool{
-		Owner:     q.Owner,
-		OwnerType: q.OwnerType,
-		Global:    q.Global,
-	}).Find(&tools).Error
+	query := s.gdb.WithContext(ctx).Where(&types.Tool{
+		Global: q.Global,
+	})
+	err := ownedBy(query, q.Owner, q.OwnerType, q.Member).Find(&tools).Error
 	if err != nil {
 		return nil, err
 	}
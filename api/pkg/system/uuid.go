@@ -20,7 +20,10 @@ const (
 	KnowledgePrefix           = "kno_"
 	KnowledgeVersionPrefix    = "knov_"
 	SecretPrefix              = "sec_"
+	ProviderEndpointPrefix    = "pe_"
 	TestRunPrefix             = "testrun_"
+	OrganizationPrefix        = "org_"
+	AccessGrantPrefix         = "grant_"
 )
 
 func GenerateUUID() string {
@@ -75,6 +78,10 @@ func GenerateSecretID() string {
 	return fmt.Sprintf("%s%s", SecretPrefix, newID())
 }
 
+func GenerateProviderEndpointID() string {
+	return fmt.Sprintf("%s%s", ProviderEndpointPrefix, newID())
+}
+
 // GenerateVersion generates a version string for the knowledge
 // This is used to identify the version of the knowledge
 // and to determine if the knowledge has been updated
@@ -85,3 +92,11 @@ func GenerateVersion() string {
 func GenerateTestRunID() string {
 	return fmt.Sprintf("%s%s", TestRunPrefix, newID())
 }
+
+func GenerateOrganizationID() string {
+	return fmt.Sprintf("%s%s", OrganizationPrefix, newID())
+}
+
+func GenerateAccessGrantID() string {
+	return fmt.Sprintf("%s%s", AccessGrantPrefix, newID())
+}
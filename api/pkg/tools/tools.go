@@ -24,6 +24,8 @@ type Planner interface {
 	// TODO: RAG lookup
 	RunAction(ctx context.Context, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*RunActionResponse, error)
 	RunActionStream(ctx context.Context, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*oai.ChatCompletionStream, error)
+	// RunToolCall runs a native tool call with the arguments generated by the model
+	RunToolCall(ctx context.Context, sessionID, interactionID string, tool *types.Tool, action, arguments string) (*RunActionResponse, error)
 	// Validation and defaulting
 	ValidateAndDefault(ctx context.Context, tool *types.Tool) (*types.Tool, error)
 }
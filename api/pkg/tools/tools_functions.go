@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const (
+	// OpenAI function names must match ^[a-zA-Z0-9_-]{1,64}$
+	maxFunctionNameLength = 64
+	// maxToolCallResponseSize limits how much of the API response is passed
+	// back to the model
+	maxToolCallResponseSize = 1 << 20
+)
+
+var invalidFunctionNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// ToolFunction is a tool action exposed to the model as a native function
+type ToolFunction struct {
+	Tool   *types.Tool
+	Action string
+	// Definition is sent to the model in the request tools
+	Definition openai.Tool
+}
+
+// Name is the function name the model uses in the tool calls
+func (f *ToolFunction) Name() string {
+	return f.Definition.Function.Name
+}
+
+// GetToolFunctions converts the tools into native function definitions. API
+// tools get a function per action with the parameters from the OpenAPI schema,
+// GPTScript and Zapier tools get a single function that takes the input text.
+func GetToolFunctions(tools []*types.Tool) ([]*ToolFunction, error) {
+	var functions []*ToolFunction
+
+	names := make(map[string]bool)
+
+	add := func(tool *types.Tool, action, description string, parameters any) {
+		name := uniqueFunctionName(action, names)
+
+		functions = append(functions, &ToolFunction{
+			Tool:   tool,
+			Action: action,
+			Definition: openai.Tool{
+				Type: openai.ToolTypeFunction,
+				Function: &openai.FunctionDefinition{
+					Name:        name,
+					Description: description,
+					Parameters:  parameters,
+				},
+			},
+		})
+	}
+
+	for _, tool := range tools {
+		switch tool.ToolType {
+		case types.ToolTypeAPI:
+			if tool.Config.API == nil {
+				continue
+			}
+
+			parameters, err := getActionParameters(tool.Config.API.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get parameters for tool %s: %w", tool.Name, err)
+			}
+
+			for _, action := range tool.Config.API.Actions {
+				description := action.Description
+				if description == "" {
+					description = tool.Description
+				}
+
+				params, ok := parameters[action.Name]
+				if !ok {
+					params = objectSchema(map[string]any{}, nil)
+				}
+
+				add(tool, action.Name, description, params)
+			}
+		case types.ToolTypeGPTScript:
+			add(tool, tool.Name, tool.Description, objectSchema(map[string]any{
+				"input": map[string]any{
+					"type":        "string",
+					"description": "Input for the script",
+				},
+			}, []string{"input"}))
+		case types.ToolTypeZapier:
+			add(tool, tool.Name, tool.Description, objectSchema(map[string]any{
+				"instructions": map[string]any{
+					"type":        "string",
+					"description": "Instructions describing what to do in Zapier",
+				},
+			}, []string{"instructions"}))
+		}
+	}
+
+	return functions, nil
+}
+
+// getActionParameters builds a JSON schema of the path and query parameters
+// for every operation in the OpenAPI spec, keyed by the operation ID
+func getActionParameters(spec string) (map[string]any, error) {
+	loader := openapi3.NewLoader()
+
+	schema, err := loader.LoadFromData([]byte(spec))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi spec: %w", err)
+	}
+
+	parameters := make(map[string]any)
+
+	for _, pathItem := range schema.Paths.Map() {
+		for _, operation := range pathItem.Operations() {
+			properties := make(map[string]any)
+
+			var required []string
+
+			for _, param := range operation.Parameters {
+				if param.Value == nil || (param.Value.In != "query" && param.Value.In != "path") {
+					continue
+				}
+
+				property := map[string]any{
+					"type": "string",
+				}
+
+				if param.Value.Schema != nil && param.Value.Schema.Value != nil {
+					bts, err := json.Marshal(param.Value.Schema.Value)
+					if err != nil {
+						return nil, fmt.Errorf("failed to marshal schema of parameter %s: %w", param.Value.Name, err)
+					}
+
+					err = json.Unmarshal(bts, &property)
+					if err != nil {
+						return nil, fmt.Errorf("failed to unmarshal schema of parameter %s: %w", param.Value.Name, err)
+					}
+				}
+
+				if _, ok := property["description"]; !ok && param.Value.Description != "" {
+					property["description"] = param.Value.Description
+				}
+
+				properties[param.Value.Name] = property
+
+				if param.Value.Required {
+					required = append(required, param.Value.Name)
+				}
+			}
+
+			parameters[operation.OperationID] = objectSchema(properties, required)
+		}
+	}
+
+	return parameters, nil
+}
+
+func objectSchema(properties map[string]any, required []string) map[string]any {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func uniqueFunctionName(name string, names map[string]bool) string {
+	name = invalidFunctionNameChars.ReplaceAllString(name, "_")
+	if name == "" {
+		name = "tool"
+	}
+	if len(name) > maxFunctionNameLength {
+		name = name[:maxFunctionNameLength]
+	}
+
+	unique := name
+	for i := 2; names[unique]; i++ {
+		suffix := "_" + strconv.Itoa(i)
+		if len(name)+len(suffix) > maxFunctionNameLength {
+			unique = name[:maxFunctionNameLength-len(suffix)] + suffix
+		} else {
+			unique = name + suffix
+		}
+	}
+
+	names[unique] = true
+
+	return unique
+}
+
+// RunToolCall runs a tool call requested by the model. Unlike RunAction the
+// parameters come from the model's function arguments and API responses are
+// returned raw so the model can interpret them.
+func (c *ChainStrategy) RunToolCall(ctx context.Context, sessionID, interactionID string, tool *types.Tool, action, arguments string) (*RunActionResponse, error) {
+	args := make(map[string]any)
+
+	if arguments != "" {
+		err := json.Unmarshal([]byte(arguments), &args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tool call arguments: %w", err)
+		}
+	}
+
+	switch tool.ToolType {
+	case types.ToolTypeAPI:
+		return c.runAPIToolCall(ctx, sessionID, interactionID, tool, action, args)
+	case types.ToolTypeGPTScript:
+		return c.RunGPTScriptAction(ctx, tool, toolCallHistory(args, "input"), action)
+	case types.ToolTypeZapier:
+		return c.RunZapierAction(ctx, tool, toolCallHistory(args, "instructions"), action)
+	default:
+		return nil, fmt.Errorf("unknown tool type: %s", tool.ToolType)
+	}
+}
+
+func (c *ChainStrategy) runAPIToolCall(ctx context.Context, sessionID, interactionID string, tool *types.Tool, action string, args map[string]any) (*RunActionResponse, error) {
+	params := make(map[string]string, len(args))
+	for k, v := range args {
+		params[k] = argumentString(v)
+	}
+
+	req, err := c.prepareRequest(ctx, tool, action, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare request: %w", err)
+	}
+
+	started := time.Now()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make api call: %w", err)
+	}
+	defer resp.Body.Close()
+
+	log.Info().
+		Str("session_id", sessionID).
+		Str("interaction_id", interactionID).
+		Str("tool", tool.Name).
+		Str("action", action).
+		Str("url", req.URL.String()).
+		Int("status_code", resp.StatusCode).
+		Dur("time_taken", time.Since(started)).
+		Msg("API tool call done")
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxToolCallResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api response: %w", err)
+	}
+
+	result := &RunActionResponse{
+		Message:    string(body),
+		RawMessage: string(body),
+	}
+
+	// Errors are passed to the model as the result so it can tell the user
+	if resp.StatusCode >= 400 {
+		result.Error = fmt.Sprintf("API returned status code %d", resp.StatusCode)
+	}
+
+	return result, nil
+}
+
+func toolCallHistory(args map[string]any, key string) []*types.ToolHistoryMessage {
+	return []*types.ToolHistoryMessage{
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: argumentString(args[key]),
+		},
+	}
+}
+
+func argumentString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		bts, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(bts)
+	}
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func Test_GetToolFunctions(t *testing.T) {
+	actions, err := GetActionsFromSchema(petStoreApiSpec)
+	require.NoError(t, err)
+
+	functions, err := GetToolFunctions([]*types.Tool{
+		{
+			Name:        "petStore",
+			Description: "Pet store API",
+			ToolType:    types.ToolTypeAPI,
+			Config: types.ToolConfig{
+				API: &types.ToolApiConfig{
+					Schema:  petStoreApiSpec,
+					Actions: actions,
+				},
+			},
+		},
+		{
+			Name:        "hello world",
+			Description: "Says hello",
+			ToolType:    types.ToolTypeGPTScript,
+			Config: types.ToolConfig{
+				GPTScript: &types.ToolGPTScriptConfig{Script: "echo hello"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, functions, 4)
+
+	byName := make(map[string]*ToolFunction)
+	for _, fn := range functions {
+		assert.Equal(t, openai.ToolTypeFunction, fn.Definition.Type)
+		byName[fn.Name()] = fn
+	}
+
+	showPet, ok := byName["showPetById"]
+	require.True(t, ok)
+	assert.Equal(t, "showPetById", showPet.Action)
+	assert.Equal(t, "Info for a specific pet", showPet.Definition.Function.Description)
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"petId": map[string]any{
+				"type":        "string",
+				"description": "The id of the pet to retrieve",
+			},
+		},
+		"required": []string{"petId"},
+	}, showPet.Definition.Function.Parameters)
+
+	listPets, ok := byName["listPets"]
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"limit": map[string]any{
+				"type":        "integer",
+				"format":      "int32",
+				"maximum":     float64(100),
+				"description": "How many items to return at one time (max 100)",
+			},
+		},
+	}, listPets.Definition.Function.Parameters)
+
+	script, ok := byName["hello_world"]
+	require.True(t, ok)
+	assert.Equal(t, "hello world", script.Action)
+}
+
+func Test_uniqueFunctionName(t *testing.T) {
+	names := make(map[string]bool)
+
+	assert.Equal(t, "get_weather", uniqueFunctionName("get weather", names))
+	assert.Equal(t, "get_weather_2", uniqueFunctionName("get.weather", names))
+
+	long := strings.Repeat("a", 70)
+	assert.Equal(t, strings.Repeat("a", 64), uniqueFunctionName(long, names))
+	assert.Equal(t, strings.Repeat("a", 62)+"_2", uniqueFunctionName(long, names))
+}
+
+func (suite *ActionTestSuite) TestAction_RunToolCall_showPetById() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("/pets/99944", r.URL.Path)
+		suite.Equal("GET", r.Method)
+
+		fmt.Fprint(w, `{"id": 99944, "name": "doggie"}`)
+	}))
+	defer ts.Close()
+
+	actions, err := GetActionsFromSchema(petStoreApiSpec)
+	suite.Require().NoError(err)
+
+	tool := &types.Tool{
+		Name:     "petStore",
+		ToolType: types.ToolTypeAPI,
+		Config: types.ToolConfig{
+			API: &types.ToolApiConfig{
+				URL:     ts.URL,
+				Schema:  petStoreApiSpec,
+				Actions: actions,
+			},
+		},
+	}
+
+	resp, err := suite.strategy.RunToolCall(suite.ctx, "session-123", "i-123", tool, "showPetById", `{"petId": 99944}`)
+	suite.Require().NoError(err)
+	suite.Equal(`{"id": 99944, "name": "doggie"}`, resp.Message)
+	suite.Empty(resp.Error)
+}
+
+func (suite *ActionTestSuite) TestAction_RunToolCall_APIError() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "pet not found", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	actions, err := GetActionsFromSchema(petStoreApiSpec)
+	suite.Require().NoError(err)
+
+	tool := &types.Tool{
+		Name:     "petStore",
+		ToolType: types.ToolTypeAPI,
+		Config: types.ToolConfig{
+			API: &types.ToolApiConfig{
+				URL:     ts.URL,
+				Schema:  petStoreApiSpec,
+				Actions: actions,
+			},
+		},
+	}
+
+	resp, err := suite.strategy.RunToolCall(suite.ctx, "session-123", "i-123", tool, "showPetById", `{"petId": "1"}`)
+	suite.Require().NoError(err)
+	suite.Equal("API returned status code 404", resp.Error)
+	suite.Contains(resp.Message, "pet not found")
+}
@@ -0,0 +1,120 @@
+package types
+
+import "time"
+
+// Permission is an action on an app or knowledge that is checked by the
+// controller before it's carried out
+type Permission string
+
+const (
+	PermissionView          Permission = "view"
+	PermissionChat          Permission = "chat"
+	PermissionEdit          Permission = "edit"
+	PermissionDelete        Permission = "delete"
+	PermissionManageSecrets Permission = "manage_secrets"
+	PermissionManageKeys    Permission = "manage_keys"
+	PermissionManageAccess  Permission = "manage_access"
+)
+
+// AccessRole is a named set of permissions that can be granted on a resource
+type AccessRole string
+
+const (
+	// AccessRoleViewer can see the resource and chat with the app
+	AccessRoleViewer AccessRole = "viewer"
+	// AccessRoleEditor can additionally change the configuration
+	AccessRoleEditor AccessRole = "editor"
+	// AccessRoleAdmin has every permission, including managing secrets, API
+	// keys and who else has access. Owners are always admins.
+	AccessRoleAdmin AccessRole = "admin"
+)
+
+var accessRolePermissions = map[AccessRole][]Permission{
+	AccessRoleViewer: {PermissionView, PermissionChat},
+	AccessRoleEditor: {PermissionView, PermissionChat, PermissionEdit},
+	AccessRoleAdmin: {
+		PermissionView,
+		PermissionChat,
+		PermissionEdit,
+		PermissionDelete,
+		PermissionManageSecrets,
+		PermissionManageKeys,
+		PermissionManageAccess,
+	},
+}
+
+// Valid returns true for the known roles
+func (r AccessRole) Valid() bool {
+	_, ok := accessRolePermissions[r]
+	return ok
+}
+
+// Permissions returns the permissions included in the role
+func (r AccessRole) Permissions() []Permission {
+	return accessRolePermissions[r]
+}
+
+// Has returns true if the role includes the permission
+func (r AccessRole) Has(permission Permission) bool {
+	for _, p := range accessRolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Max returns the role with more permissions
+func (r AccessRole) Max(other AccessRole) AccessRole {
+	if len(other.Permissions()) > len(r.Permissions()) {
+		return other
+	}
+	return r
+}
+
+type AccessResourceType string
+
+const (
+	AccessResourceTypeApp       AccessResourceType = "app"
+	AccessResourceTypeKnowledge AccessResourceType = "knowledge"
+)
+
+// AccessGrant gives a user a role on a single app or knowledge in addition
+// to the access they get through ownership or organization membership
+type AccessGrant struct {
+	ID      string    `json:"id" gorm:"primaryKey"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+
+	// A user has at most one grant per resource
+	ResourceType AccessResourceType `json:"resource_type" gorm:"uniqueIndex:idx_access_grant_resource_user"`
+	ResourceID   string             `json:"resource_id" gorm:"uniqueIndex:idx_access_grant_resource_user"`
+	UserID       string             `json:"user_id" gorm:"index;uniqueIndex:idx_access_grant_resource_user"`
+	Role         AccessRole         `json:"role"`
+	// GrantedBy is the user that created the grant
+	GrantedBy string `json:"granted_by"`
+}
+
+type CreateAccessGrantRequest struct {
+	UserID string     `json:"user_id"`
+	Role   AccessRole `json:"role"`
+}
+
+type AccessSource string
+
+const (
+	AccessSourceOwner        AccessSource = "owner"
+	AccessSourceOrganization AccessSource = "organization"
+	AccessSourceGrant        AccessSource = "grant"
+	// AccessSourcePublic is used for global and shared apps that every user can use
+	AccessSourcePublic AccessSource = "public"
+)
+
+// ResourceAccess describes why a user can access a resource. UserID is "*"
+// for global and shared apps.
+type ResourceAccess struct {
+	UserID      string       `json:"user_id"`
+	Role        AccessRole   `json:"role"`
+	Source      AccessSource `json:"source"`
+	Permissions []Permission `json:"permissions"`
+}
@@ -106,6 +106,7 @@ type OwnerType string
 const (
 	OwnerTypeUser   OwnerType = "user"
 	OwnerTypeSystem OwnerType = "system"
+	OwnerTypeOrg    OwnerType = "org"
 )
 
 type PaymentType string
@@ -333,4 +334,6 @@ type Extractor string
 const (
 	ExtractorTika         Extractor = "tika"
 	ExtractorUnstructured Extractor = "unstructured"
+	ExtractorBuiltin      Extractor = "builtin" // Pure Go, no external service
+	ExtractorAuto         Extractor = "auto"    // Builtin where supported, routed by MIME type
 )
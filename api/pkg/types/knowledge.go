@@ -76,6 +76,11 @@ type Knowledge struct {
 	// Size of the knowledge in bytes
 	Size int64 `json:"size"`
 
+	// SourceFingerprint identifies the source contents the latest version
+	// was indexed from, for example the object keys and ETags of a bucket.
+	// Refreshes are skipped while it doesn't change.
+	SourceFingerprint string `json:"source_fingerprint"`
+
 	Versions []*KnowledgeVersion `json:"versions" `
 
 	NextRun time.Time `json:"next_run" gorm:"-"` // Populated by the cron job controller
@@ -85,6 +90,41 @@ func (k *Knowledge) GetDataEntityID() string {
 	return GetDataEntityID(k.ID, k.Version)
 }
 
+// RedactCredentials removes the credentials and the names of the secrets
+// holding them, for users that can see the knowledge but not change it
+func (k *Knowledge) RedactCredentials() {
+	k.RAGSettings.Reranking.APIKeySecret = ""
+	k.RAGSettings.Typesense.APIKey = ""
+
+	source := &k.Source
+	if source.S3 != nil {
+		s3 := *source.S3
+		s3.AccessKeyIDSecret = ""
+		s3.SecretAccessKeySecret = ""
+		source.S3 = &s3
+	}
+	if source.GCS != nil {
+		gcs := *source.GCS
+		gcs.CredentialsSecret = ""
+		source.GCS = &gcs
+	}
+	if source.Github != nil {
+		github := *source.Github
+		github.WebhookSecret = ""
+		source.Github = &github
+	}
+	if source.Web != nil {
+		web := *source.Web
+		web.Auth = KnowledgeSourceWebAuth{}
+		if web.Crawler != nil && web.Crawler.Firecrawl != nil {
+			crawler := *web.Crawler
+			crawler.Firecrawl = &Firecrawl{APIURL: crawler.Firecrawl.APIURL}
+			web.Crawler = &crawler
+		}
+		source.Web = &web
+	}
+}
+
 type KnowledgeVersion struct {
 	ID          string         `json:"id" gorm:"primaryKey"`
 	Created     time.Time      `json:"created"`
@@ -96,6 +136,20 @@ type KnowledgeVersion struct {
 	Message     string         `json:"message"` // Set if something wrong happens
 }
 
+// KnowledgeDocument is a document indexed in the current version of a
+// knowledge. Refreshes compare the content hashes to only index added or
+// changed documents.
+type KnowledgeDocument struct {
+	KnowledgeID string    `json:"knowledge_id" gorm:"primaryKey"`
+	Source      string    `json:"source" gorm:"primaryKey"`
+	Version     string    `json:"version"`     // Knowledge version the document is indexed in
+	DocumentID  string    `json:"document_id"` // Hash of the contents, the RAG document ID
+	Hash        string    `json:"hash"`        // Hash of the contents and the RAG settings
+	ETag        string    `json:"etag"`        // Hash of the ETag of the bucket object and the RAG settings, unchanged objects aren't read again
+	Size        int64     `json:"size"`        // Size of the extracted contents
+	Created     time.Time `json:"created"`
+}
+
 func (k *KnowledgeVersion) GetDataEntityID() string {
 	return GetDataEntityID(k.KnowledgeID, k.Version)
 }
@@ -118,8 +172,10 @@ const (
 
 type KnowledgeSource struct {
 	Filestore *KnowledgeSourceHelixFilestore `json:"filestore" yaml:"filestore"`
-	S3        *KnowledgeSourceS3             `json:"s3"`
-	GCS       *KnowledgeSourceGCS            `json:"gcs"`
+	S3        *KnowledgeSourceS3             `json:"s3" yaml:"s3"`
+	GCS       *KnowledgeSourceGCS            `json:"gcs" yaml:"gcs"`
+	Github    *KnowledgeSourceGithub         `json:"github" yaml:"github"`
+	Local     *KnowledgeSourceLocal          `json:"local" yaml:"local"`
 	Web       *KnowledgeSourceWeb            `json:"web"`
 	Content   *string                        `json:"text"`
 }
@@ -147,7 +203,10 @@ func (KnowledgeSource) GormDataType() string {
 }
 
 type KnowledgeSourceWeb struct {
-	Excludes []string               `json:"excludes" yaml:"excludes"`
+	Excludes []string `json:"excludes" yaml:"excludes"`
+	// Includes are URL regexes of the pages to index, pages that don't match
+	// are still crawled for links. Excludes take precedence.
+	Includes []string               `json:"includes" yaml:"includes"`
 	URLs     []string               `json:"urls" yaml:"urls"`
 	Auth     KnowledgeSourceWebAuth `json:"auth" yaml:"auth"`
 	// Additional options for the crawler
@@ -162,6 +221,12 @@ type WebsiteCrawler struct {
 	MaxPages    int    `json:"max_pages" yaml:"max_pages"` // Limit number of pages to crawl to avoid infinite crawling (max 500 by default)
 	UserAgent   string `json:"user_agent" yaml:"user_agent"`
 	Readability bool   `json:"readability" yaml:"readability"` // Apply readability middleware to the HTML content
+	// Sitemap seeds the crawl with the pages listed in the sitemaps, the
+	// crawl is skipped if no page was modified since the latest version
+	Sitemap     bool     `json:"sitemap" yaml:"sitemap"`
+	SitemapURLs []string `json:"sitemap_urls" yaml:"sitemap_urls"` // Defaults to the robots.txt sitemaps or /sitemap.xml
+	// RespectRobotsTxt skips the pages disallowed by robots.txt and waits for its crawl delay
+	RespectRobotsTxt bool `json:"respect_robots_txt" yaml:"respect_robots_txt"`
 }
 
 type Firecrawl struct {
@@ -169,33 +234,107 @@ type Firecrawl struct {
 	APIURL string `json:"api_url" yaml:"api_url"`
 }
 
+// KnowledgeSourceWebAuth authenticates the crawler and downloads, secrets are
+// looked up by name in the app secrets of the knowledge owner
 type KnowledgeSourceWebAuth struct {
-	Username string
-	Password string
+	// HTTP basic auth
+	Username       string `json:"username" yaml:"username"`
+	Password       string `json:"password" yaml:"password"`
+	PasswordSecret string `json:"password_secret" yaml:"password_secret"` // Used instead of Password if set
+	// BearerTokenSecret is sent as the Authorization bearer token
+	BearerTokenSecret string                     `json:"bearer_token_secret" yaml:"bearer_token_secret"`
+	Headers           map[string]string          `json:"headers" yaml:"headers"`
+	HeaderSecrets     map[string]string          `json:"header_secrets" yaml:"header_secrets"` // Header name to secret name
+	Cookies           []KnowledgeSourceWebCookie `json:"cookies" yaml:"cookies"`
+	// FormLogin logs in with the browser before crawling, the session
+	// cookies are then used for all requests
+	FormLogin *KnowledgeSourceWebFormLogin `json:"form_login" yaml:"form_login"`
+}
+
+type KnowledgeSourceWebCookie struct {
+	Name        string `json:"name" yaml:"name"`
+	Value       string `json:"value" yaml:"value"`
+	ValueSecret string `json:"value_secret" yaml:"value_secret"` // Used instead of Value if set
+	Domain      string `json:"domain" yaml:"domain"`             // Defaults to the source URL hosts
+	Path        string `json:"path" yaml:"path"`                 // Defaults to /
+}
+
+// KnowledgeSourceWebFormLogin fills and submits a login form, selectors
+// are CSS selectors
+type KnowledgeSourceWebFormLogin struct {
+	URL              string `json:"url" yaml:"url"`
+	Username         string `json:"username" yaml:"username"`
+	PasswordSecret   string `json:"password_secret" yaml:"password_secret"`
+	UsernameSelector string `json:"username_selector" yaml:"username_selector"`
+	PasswordSelector string `json:"password_selector" yaml:"password_selector"`
+	SubmitSelector   string `json:"submit_selector" yaml:"submit_selector"` // Presses enter in the password field if empty
+	// WaitSelector is an element shown once logged in, otherwise waits for
+	// the page to load after submitting
+	WaitSelector string `json:"wait_selector" yaml:"wait_selector"`
 }
 
 type KnowledgeSourceHelixFilestore struct {
 	Path string `json:"path" yaml:"path"`
 }
 
-// KnowledgeSourceS3 authentication through AWS IAM role
+// KnowledgeSourceS3 indexes objects from an S3 compatible bucket. If no
+// credential secrets are set, the bucket is accessed anonymously.
 type KnowledgeSourceS3 struct {
-	Bucket string `json:"bucket"`
-	Path   string `json:"path"`
+	Bucket string `json:"bucket" yaml:"bucket"`
+	// Path is the prefix of the objects to index
+	Path string `json:"path" yaml:"path"`
+	// Endpoint for S3 compatible storage such as MinIO, defaults to AWS S3
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	Region   string `json:"region" yaml:"region"`
+	Insecure bool   `json:"insecure" yaml:"insecure"` // Use plain HTTP to talk to the endpoint
+	// Includes and Excludes are glob patterns matched against the object key
+	// relative to the path, patterns without a slash match the file name
+	Includes []string `json:"includes" yaml:"includes"`
+	Excludes []string `json:"excludes" yaml:"excludes"`
+	// Names of the app secrets holding the credentials
+	AccessKeyIDSecret     string `json:"access_key_id_secret" yaml:"access_key_id_secret"`
+	SecretAccessKeySecret string `json:"secret_access_key_secret" yaml:"secret_access_key_secret"`
 }
 
-// KnowledgeSourceGCS authentication through GCP service account
+// KnowledgeSourceGCS indexes objects from a GCS bucket. If no credentials
+// secret is set, the bucket is accessed anonymously.
 type KnowledgeSourceGCS struct {
-	Bucket string `json:"bucket"`
-	Path   string `json:"path"`
+	Bucket string `json:"bucket" yaml:"bucket"`
+	// Path is the prefix of the objects to index
+	Path string `json:"path" yaml:"path"`
+	// Endpoint overrides the GCS API endpoint, for example for fake-gcs-server
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	// Includes and Excludes are glob patterns matched against the object key
+	// relative to the path, patterns without a slash match the file name
+	Includes []string `json:"includes" yaml:"includes"`
+	Excludes []string `json:"excludes" yaml:"excludes"`
+	// Name of the app secret holding the service account JSON key
+	CredentialsSecret string `json:"credentials_secret" yaml:"credentials_secret"`
 }
 
+// KnowledgeSourceGithub indexes the files of a GitHub repository branch.
+// Private repositories are cloned with the owner's GitHub OAuth token.
 type KnowledgeSourceGithub struct {
-	Owner            string   `json:"owner"`
-	Repository       string   `json:"repository"`
-	Branch           string   `json:"branch"`
-	FilterPaths      []string `json:"filter_paths"`
-	FilterExtensions []string `json:"filter_extensions"`
+	Owner      string `json:"owner" yaml:"owner"`
+	Repository string `json:"repository" yaml:"repository"`
+	Branch     string `json:"branch" yaml:"branch"` // Defaults to the default branch
+	// FilterPaths limits indexing to files under these repository paths
+	FilterPaths []string `json:"filter_paths" yaml:"filter_paths"`
+	// FilterExtensions limits indexing to files with these extensions, e.g. .go, .md
+	FilterExtensions []string `json:"filter_extensions" yaml:"filter_extensions"`
+	// WebhookSecret verifies push events sent to the GitHub webhook endpoint
+	WebhookSecret string `json:"webhook_secret" yaml:"webhook_secret"`
+}
+
+// KnowledgeSourceLocal indexes the files of a directory on the API server,
+// for example a mounted NFS share. The path must be under one of the roots
+// allowed by the server admin (RAG_LOCAL_ALLOWED_ROOTS).
+type KnowledgeSourceLocal struct {
+	Path     string   `json:"path" yaml:"path"`
+	Includes []string `json:"includes" yaml:"includes"` // Glob patterns of the files to index, e.g. *.md
+	Excludes []string `json:"excludes" yaml:"excludes"` // Glob patterns of the files to skip
+	// Watch re-indexes the knowledge when files in the directory change
+	Watch bool `json:"watch" yaml:"watch"`
 }
 
 // CrawledDocument used internally to work with the crawled data
@@ -198,3 +198,29 @@ type OpenAIMessage struct {
 	// For Role=tool prompts this should be set to the ID given in the assistant's prior request to call a tool.
 	ToolCallID string `json:"tool_call_id,omitempty"`
 }
+
+// Citation is a knowledge chunk that was added to the prompt, returned with
+// the chat completion so that clients can render footnotes instead of
+// parsing the document IDs from the response
+type Citation struct {
+	DocumentID  string            `json:"document_id"`
+	KnowledgeID string            `json:"knowledge_id,omitempty"`
+	Source      string            `json:"source"`        // URL or filename of the document
+	URL         string            `json:"url,omitempty"` // Link to the section, web sources only
+	Snippet     string            `json:"snippet"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// ChatCompletionResponse is the OpenAI chat completion response with
+// the citations of the knowledge used to answer
+type ChatCompletionResponse struct {
+	openai.ChatCompletionResponse
+	Citations []*Citation `json:"citations,omitempty"`
+}
+
+// ChatCompletionStreamResponse is sent after the streamed response chunks
+// when knowledge was used, it has no choices
+type ChatCompletionStreamResponse struct {
+	openai.ChatCompletionStreamResponse
+	Citations []*Citation `json:"citations,omitempty"`
+}
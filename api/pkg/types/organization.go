@@ -0,0 +1,72 @@
+package types
+
+import "time"
+
+// Organization groups users so that apps, knowledge, secrets and other
+// resources can be owned by the organization (OwnerTypeOrg) and shared by
+// all of its members
+type Organization struct {
+	ID      string    `json:"id" gorm:"primaryKey"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+
+	// Name is a unique, URL friendly identifier (e.g. "acme")
+	Name        string `json:"name" yaml:"name" gorm:"uniqueIndex"`
+	DisplayName string `json:"display_name" yaml:"display_name"`
+	// Owner is the user that created the organization
+	Owner string `json:"owner" yaml:"owner"`
+}
+
+type OrganizationRole string
+
+const (
+	// OrganizationRoleOwner can manage the organization itself, including
+	// deleting it and changing the roles of other owners
+	OrganizationRoleOwner OrganizationRole = "owner"
+	// OrganizationRoleAdmin can manage members and change or delete
+	// resources owned by the organization
+	OrganizationRoleAdmin OrganizationRole = "admin"
+	// OrganizationRoleMember can see and use resources owned by the
+	// organization and create new ones
+	OrganizationRoleMember OrganizationRole = "member"
+)
+
+func (r OrganizationRole) level() int {
+	switch r {
+	case OrganizationRoleOwner:
+		return 3
+	case OrganizationRoleAdmin:
+		return 2
+	case OrganizationRoleMember:
+		return 1
+	}
+	return 0
+}
+
+// Valid returns true for the known roles
+func (r OrganizationRole) Valid() bool {
+	return r.level() > 0
+}
+
+// Includes returns true if the role grants at least the permissions of the other role
+func (r OrganizationRole) Includes(other OrganizationRole) bool {
+	return r.Valid() && r.level() >= other.level()
+}
+
+type OrganizationMembership struct {
+	OrganizationID string    `json:"organization_id" gorm:"primaryKey"`
+	UserID         string    `json:"user_id" gorm:"primaryKey;index"`
+	Created        time.Time `json:"created"`
+	Updated        time.Time `json:"updated"`
+
+	Role OrganizationRole `json:"role"`
+}
+
+type AddOrganizationMemberRequest struct {
+	UserID string           `json:"user_id"`
+	Role   OrganizationRole `json:"role"`
+}
+
+type UpdateOrganizationMemberRequest struct {
+	Role OrganizationRole `json:"role"`
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type Provider string
 
 const (
@@ -7,3 +9,58 @@ const (
 	ProviderTogetherAI Provider = "togetherai"
 	ProviderHelix      Provider = "helix"
 )
+
+// IsBuiltin returns true for providers that are configured through
+// their dedicated environment variables rather than provider endpoints
+func (p Provider) IsBuiltin() bool {
+	switch p {
+	case ProviderOpenAI, ProviderTogetherAI, ProviderHelix:
+		return true
+	}
+	return false
+}
+
+// ProviderEndpoint is a named OpenAI compatible endpoint (vLLM, LiteLLM,
+// Azure, internal gateways, etc.). Endpoints can be declared in the
+// server config or created through the API, in which case they are
+// picked up by the provider manager without a restart.
+type ProviderEndpoint struct {
+	ID      string    `json:"id" gorm:"primaryKey"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+
+	// Name is used as the provider name when selecting a client,
+	// for example in the app config or the ?provider= query parameter
+	Name        string `json:"name" yaml:"name" gorm:"uniqueIndex"`
+	Description string `json:"description" yaml:"description"`
+
+	Owner     string    `json:"owner" yaml:"owner" gorm:"index"`
+	OwnerType OwnerType `json:"owner_type" yaml:"owner_type"`
+
+	BaseURL string `json:"base_url" yaml:"base_url"`
+	// APIKey can be set directly when the endpoint is declared in the
+	// server config. Endpoints created through the API should use
+	// APIKeySecret instead.
+	APIKey string `json:"api_key,omitempty" yaml:"api_key" gorm:"-"`
+	// APIKeySecret is the name of a secret, owned by the endpoint owner,
+	// that holds the API key
+	APIKeySecret string `json:"api_key_secret" yaml:"api_key_secret"`
+
+	// Models is an optional allow-list of models that can be used
+	// through this endpoint. Empty list allows all models.
+	Models []string `json:"models" yaml:"models" gorm:"serializer:json"`
+}
+
+// AllowsModel returns true if the model is in the endpoint's allow-list
+// or if the allow-list is empty
+func (p *ProviderEndpoint) AllowsModel(model string) bool {
+	if len(p.Models) == 0 {
+		return true
+	}
+	for _, m := range p.Models {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// QuotaScope is the subject a usage quota applies to
+type QuotaScope string
+
+const (
+	QuotaScopeUser   QuotaScope = "user"
+	QuotaScopeAPIKey QuotaScope = "api_key"
+	QuotaScopeApp    QuotaScope = "app"
+)
+
+// QuotaLimit is the kind of limit enforced by a quota
+type QuotaLimit string
+
+const (
+	QuotaLimitDailyTokens       QuotaLimit = "daily_tokens"
+	QuotaLimitMonthlyTokens     QuotaLimit = "monthly_tokens"
+	QuotaLimitRequestsPerMinute QuotaLimit = "requests_per_minute"
+)
+
+// HashAPIKey returns the SHA-256 of the API key, LLM calls store the hash
+// instead of the key
+func HashAPIKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// UsageLimits are the token budgets and request rate allowed for a scope,
+// 0 means unlimited
+type UsageLimits struct {
+	DailyTokens       int64 `json:"daily_tokens,omitempty" yaml:"daily_tokens,omitempty"`
+	MonthlyTokens     int64 `json:"monthly_tokens,omitempty" yaml:"monthly_tokens,omitempty"`
+	RequestsPerMinute int64 `json:"requests_per_minute,omitempty" yaml:"requests_per_minute,omitempty"`
+}
+
+// QuotaStatus is the current consumption against a single limit
+type QuotaStatus struct {
+	Scope     QuotaScope `json:"scope"`
+	ScopeID   string     `json:"scope_id"`
+	Limit     QuotaLimit `json:"limit"`
+	Max       int64      `json:"max"`
+	Used      int64      `json:"used"`
+	Remaining int64      `json:"remaining"`
+	ResetsAt  time.Time  `json:"resets_at"`
+}
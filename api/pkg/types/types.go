@@ -90,8 +90,12 @@ type SessionOrigin struct {
 type TextSplitterType string
 
 const (
-	TextSplitterTypeMarkdown TextSplitterType = "markdown"
-	TextSplitterTypeText     TextSplitterType = "text"
+	TextSplitterTypeMarkdown  TextSplitterType = "markdown"
+	TextSplitterTypeText      TextSplitterType = "text"
+	TextSplitterTypeToken     TextSplitterType = "token"     // Chunk size and overflow in tokens of the embeddings model
+	TextSplitterTypeRecursive TextSplitterType = "recursive" // Paragraphs, then lines, sentences and words
+	TextSplitterTypeSentence  TextSplitterType = "sentence"  // Whole sentences, the overflow is the last sentences
+	TextSplitterTypeSemantic  TextSplitterType = "semantic"  // Breaks where the embeddings of the sentences drift apart, chunk size in tokens
 )
 
 type RAGSettings struct {
@@ -99,13 +103,21 @@ type RAGSettings struct {
 	Threshold        float64 `json:"threshold" yaml:"threshold"`                 // this is the threshold for a "good" answer - will default to 0.2
 	ResultsCount     int     `json:"results_count" yaml:"results_count"`         // this is the max number of results to return - will default to 3
 
-	TextSplitter       TextSplitterType `json:"text_splitter" yaml:"text_splitter"`             // Markdown if empty or 'text'
-	ChunkSize          int              `json:"chunk_size" yaml:"chunk_size"`                   // the size of each text chunk - will default to 2000 bytes
+	TextSplitter       TextSplitterType `json:"text_splitter" yaml:"text_splitter"`             // Markdown if empty, text, token, recursive, sentence or semantic
+	ChunkSize          int              `json:"chunk_size" yaml:"chunk_size"`                   // the size of each text chunk - will default to 2000 bytes, or 512 tokens for the token and semantic splitters
 	ChunkOverflow      int              `json:"chunk_overflow" yaml:"chunk_overflow"`           // the amount of overlap between chunks - will default to 32 bytes
 	DisableChunking    bool             `json:"disable_chunking" yaml:"disable_chunking"`       // if true, we will not chunk the text and send the entire file to the RAG indexing endpoint
 	DisableDownloading bool             `json:"disable_downloading" yaml:"disable_downloading"` // if true, we will not download the file and send the URL to the RAG indexing endpoint
 	PromptTemplate     string           `json:"prompt_template" yaml:"prompt_template"`         // the prompt template to use for the RAG query
 
+	Separators                   []string `json:"separators,omitempty" yaml:"separators,omitempty"`                                         // the separators of the recursive splitter in order - will default to paragraphs, lines, sentences and words
+	TokenizerModel               string   `json:"tokenizer_model,omitempty" yaml:"tokenizer_model,omitempty"`                               // the model the token and semantic splitters count tokens for - will default to the embeddings model
+	SemanticBreakpointPercentile float64  `json:"semantic_breakpoint_percentile,omitempty" yaml:"semantic_breakpoint_percentile,omitempty"` // the percentile of the distances between sentences the semantic splitter breaks at - will default to 95
+
+	RetrievalMode RAGRetrievalMode  `json:"retrieval_mode,omitempty" yaml:"retrieval_mode,omitempty"` // vector, keyword or hybrid - will default to the backend's default search
+	HybridAlpha   *float64          `json:"hybrid_alpha,omitempty" yaml:"hybrid_alpha,omitempty"`     // the weight of the vector score in hybrid mode between 0 and 1 - will default to 0.5
+	Reranking     RAGRerankSettings `json:"reranking,omitempty" yaml:"reranking,omitempty"`           // optional reranking of the retrieved results
+
 	// RAG endpoint configuration if used with a custom RAG service
 	IndexURL  string `json:"index_url" yaml:"index_url"`   // the URL of the index endpoint (defaults to Helix RAG_INDEX_URL env var)
 	QueryURL  string `json:"query_url" yaml:"query_url"`   // the URL of the query endpoint (defaults to Helix RAG_QUERY_URL env var)
@@ -118,6 +130,34 @@ type RAGSettings struct {
 	} `json:"typesense" yaml:"typesense"`
 }
 
+type RAGRetrievalMode string
+
+const (
+	RAGRetrievalModeVector  RAGRetrievalMode = "vector"
+	RAGRetrievalModeKeyword RAGRetrievalMode = "keyword"
+	RAGRetrievalModeHybrid  RAGRetrievalMode = "hybrid"
+)
+
+type RAGRerankerType string
+
+const (
+	// RAGRerankerCrossEncoder calls a /rerank endpoint (Cohere, Jina, TEI and
+	// others share the same API) that scores the results with a cross-encoder
+	RAGRerankerCrossEncoder RAGRerankerType = "cross_encoder"
+	// RAGRerankerLLM asks a model from the provider manager to score the results
+	RAGRerankerLLM RAGRerankerType = "llm"
+)
+
+type RAGRerankSettings struct {
+	Enabled      bool            `json:"enabled" yaml:"enabled"`
+	Type         RAGRerankerType `json:"type" yaml:"type"`                                         // cross_encoder or llm
+	Provider     Provider        `json:"provider,omitempty" yaml:"provider,omitempty"`             // provider of the LLM reranker - will default to the inference provider
+	Model        string          `json:"model,omitempty" yaml:"model,omitempty"`                   // the reranking model
+	URL          string          `json:"url,omitempty" yaml:"url,omitempty"`                       // the URL of the cross-encoder rerank endpoint
+	APIKeySecret string          `json:"api_key_secret,omitempty" yaml:"api_key_secret,omitempty"` // the name of the knowledge owner's secret holding the API key of the cross-encoder rerank endpoint
+	Candidates   int             `json:"candidates,omitempty" yaml:"candidates,omitempty"`         // the number of results to retrieve for reranking - will default to 4x results count
+}
+
 func (m RAGSettings) Value() (driver.Value, error) {
 	j, err := json.Marshal(m)
 	return j, err
@@ -149,22 +189,60 @@ type SessionRAGIndexChunk struct {
 	DocumentGroupID string `json:"document_group_id"`
 	ContentOffset   int    `json:"content_offset"`
 	Content         string `json:"content"`
+	// Metadata is returned with the query results, see the RAGMetadata keys
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Embedding of the content by the embeddings model of the backend, set
+	// on exports and imports so the chunks aren't embedded again
+	Embedding []float32 `json:"-"`
 }
 
+// Chunk metadata set by the knowledge indexer, other keys can be set by
+// the sources
+const (
+	RAGMetadataPage         = "page"          // Page number, starting at 1
+	RAGMetadataHeadings     = "headings"      // Markdown section headings path, e.g. "Install > Linux"
+	RAGMetadataAnchor       = "anchor"        // URL fragment of the section, without the #
+	RAGMetadataLastModified = "last_modified" // RFC3339 last modified time of the document
+)
+
 // the query we post to llamaindex to get results back from a user
 // prompt against a rag enabled session
 type SessionRAGQuery struct {
-	Prompt            string  `json:"prompt"`
-	DataEntityID      string  `json:"data_entity_id"`
-	DistanceThreshold float64 `json:"distance_threshold"`
-	DistanceFunction  string  `json:"distance_function"`
-	MaxResults        int     `json:"max_results"`
+	Prompt            string           `json:"prompt"`
+	DataEntityID      string           `json:"data_entity_id"`
+	DistanceThreshold float64          `json:"distance_threshold"`
+	DistanceFunction  string           `json:"distance_function"`
+	MaxResults        int              `json:"max_results"`
+	RetrievalMode     RAGRetrievalMode `json:"retrieval_mode,omitempty"`
+	HybridAlpha       *float64         `json:"hybrid_alpha,omitempty"`
+	Filters           RAGQueryFilters  `json:"filters,omitempty"`
+}
+
+// RAGQueryFilters restricts the results to chunks of the given files or sources,
+// empty lists don't filter
+type RAGQueryFilters struct {
+	Filenames []string `json:"filenames,omitempty"`
+	Sources   []string `json:"sources,omitempty"`
 }
 
 type DeleteIndexRequest struct {
 	DataEntityID string `json:"data_entity_id"`
 }
 
+// CopyIndexRequest copies the chunks of the documents from one data entity
+// to another
+type CopyIndexRequest struct {
+	FromDataEntityID string   `json:"from_data_entity_id"`
+	ToDataEntityID   string   `json:"to_data_entity_id"`
+	DocumentIDs      []string `json:"document_ids"`
+}
+
+// ExportIndexRequest lists the chunks of a data entity
+type ExportIndexRequest struct {
+	DataEntityID      string `json:"data_entity_id"`
+	IncludeEmbeddings bool   `json:"include_embeddings"`
+}
+
 // the thing we load from llamaindex when we send the user prompt
 // there and it does a lookup
 type SessionRAGResult struct {
@@ -178,6 +256,9 @@ type SessionRAGResult struct {
 	ContentOffset   int     `json:"content_offset"`
 	Content         string  `json:"content"`
 	Distance        float64 `json:"distance"`
+	Score           float64 `json:"score,omitempty"` // relevance score, set when the results are reranked
+	// Metadata of the chunk set at index time
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // gives us a quick way to add settings
@@ -596,6 +677,8 @@ type StepInfo struct {
 	Name    string       `json:"name"`
 	Type    StepInfoType `json:"type"`
 	Message string       `json:"message"`
+	// Iteration of the agent loop the step belongs to, 0 outside of the loop
+	Iteration int `json:"iteration,omitempty"`
 }
 
 // the context of a long running python process
@@ -749,6 +832,7 @@ type RunnerState struct {
 type DashboardData struct {
 	DesiredSlots              []DesiredSlots              `json:"desired_slots"`
 	SessionQueue              []*SessionSummary           `json:"session_queue"`
+	TenantQueues              []*SchedulerTenantQueue     `json:"tenant_queues"`
 	Runners                   []*RunnerState              `json:"runners"`
 	GlobalSchedulingDecisions []*GlobalSchedulingDecision `json:"global_scheduling_decisions"`
 }
@@ -1012,17 +1096,63 @@ type AssistantConfig struct {
 
 	IsActionableTemplate string `json:"is_actionable_template,omitempty" yaml:"is_actionable_template,omitempty"`
 
+	// ToolCalling selects how the model is told about the tools, defaults to
+	// the prompt based classifier
+	ToolCalling ToolCallingMode `json:"tool_calling,omitempty" yaml:"tool_calling,omitempty"`
+	// ToolExecution selects who runs the tool calls requested by the model
+	// when native tool calling is used, defaults to the server
+	ToolExecution ToolExecution `json:"tool_execution,omitempty" yaml:"tool_execution,omitempty"`
+	// MaxIterations limits how many rounds of tool calls the model can make
+	// before it has to answer, or how many actions the classifier runs in a
+	// row, defaults to 5
+	MaxIterations int `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+
 	APIs       []AssistantAPI       `json:"apis,omitempty" yaml:"apis,omitempty"`
 	GPTScripts []AssistantGPTScript `json:"gptscripts,omitempty" yaml:"gptscripts,omitempty"`
 	Zapier     []AssistantZapier    `json:"zapier,omitempty" yaml:"zapier,omitempty"`
 	Tools      []*Tool              `json:"tools,omitempty" yaml:"tools,omitempty"`
 
+	Cache *AssistantCache `json:"cache,omitempty" yaml:"cache,omitempty"`
+
 	Tests []struct {
 		Name  string     `json:"name,omitempty" yaml:"name,omitempty"`
 		Steps []TestStep `json:"steps,omitempty" yaml:"steps,omitempty"`
 	} `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
+type ToolCallingMode string
+
+const (
+	// ToolCallingModeClassifier asks the tools model whether the message is
+	// actionable and which action to run
+	ToolCallingModeClassifier ToolCallingMode = "classifier"
+	// ToolCallingModeNative exposes the tools to the model as OpenAI function
+	// definitions, falling back to the classifier if the model doesn't support
+	// function calling
+	ToolCallingModeNative ToolCallingMode = "native"
+)
+
+type ToolExecution string
+
+const (
+	// ToolExecutionServer runs the tool calls and sends the results back to
+	// the model for the final answer
+	ToolExecutionServer ToolExecution = "server"
+	// ToolExecutionClient returns the tool calls to the caller
+	ToolExecutionClient ToolExecution = "client"
+)
+
+// AssistantCache configures caching of the assistant's chat completion responses
+type AssistantCache struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// TTLSeconds is how long a response is cached for, defaults to an hour
+	TTLSeconds int `json:"ttl_seconds,omitempty" yaml:"ttl_seconds,omitempty"`
+	// SimilarityThreshold enables serving responses for similar questions when
+	// the cosine similarity of their embeddings is at least this value (e.g. 0.95).
+	// When 0 only identical requests are served from the cache.
+	SimilarityThreshold float64 `json:"similarity_threshold,omitempty" yaml:"similarity_threshold,omitempty"`
+}
+
 // Add this new type
 type TestStep struct {
 	Prompt         string `json:"prompt" yaml:"prompt"`
@@ -1037,6 +1167,8 @@ type AppHelixConfig struct {
 	ExternalURL string            `json:"external_url,omitempty" yaml:"external_url,omitempty"`
 	Assistants  []AssistantConfig `json:"assistants,omitempty" yaml:"assistants,omitempty"`
 	Triggers    []Trigger         `json:"triggers,omitempty" yaml:"triggers,omitempty"`
+	// Limits lower the default usage quotas of the app, they can't raise them
+	Limits *UsageLimits `json:"limits,omitempty" yaml:"limits,omitempty"`
 }
 
 type AppHelixConfigMetadata struct {
@@ -1379,10 +1511,19 @@ type RunnerLLMInferenceRequest struct {
 
 	Priority      bool
 	OwnerID       string
+	AppID         string
 	SessionID     string
 	InteractionID string
 
+	// PriorityClass sets the share of the runners of the request, defaults
+	// to batch for embeddings and interactive otherwise
+	PriorityClass SchedulerPriorityClass
+
 	Request *openai.ChatCompletionRequest
+
+	// EmbeddingRequest is set for embeddings, Request then only
+	// carries the model for scheduling
+	EmbeddingRequest *openai.EmbeddingRequest
 }
 
 type RunnerLLMInferenceResponse struct {
@@ -1394,8 +1535,9 @@ type RunnerLLMInferenceResponse struct {
 	SessionID     string
 	InteractionID string
 
-	Response       *openai.ChatCompletionResponse
-	StreamResponse *openai.ChatCompletionStreamResponse
+	Response          *openai.ChatCompletionResponse
+	StreamResponse    *openai.ChatCompletionStreamResponse
+	EmbeddingResponse *openai.EmbeddingResponse
 
 	// Error is set if there was an error
 	Error string
@@ -1415,6 +1557,9 @@ const (
 	LLMCallStepPrepareAPIRequest LLMCallStep = "prepare_api_request"
 	LLMCallStepInterpretResponse LLMCallStep = "interpret_response"
 	LLMCallStepGenerateTitle     LLMCallStep = "generate_title"
+	LLMCallStepAgentIteration    LLMCallStep = "agent_iteration"
+	LLMCallStepRerank            LLMCallStep = "rerank"
+	LLMCallStepEmbeddings        LLMCallStep = "embeddings"
 )
 
 // LLMCall used to store the request and response of LLM calls
@@ -1423,13 +1568,17 @@ type LLMCall struct {
 	ID               string         `json:"id" gorm:"primaryKey"`
 	AppID            string         `json:"app_id" gorm:"index"`
 	UserID           string         `json:"user_id" gorm:"index"`
+	APIKey           string         `json:"-" gorm:"index"` // Hash of the API key used for the call, used for API key quotas
 	Created          time.Time      `json:"created"`
 	Updated          time.Time      `json:"updated"`
 	SessionID        string         `json:"session_id" gorm:"index"`
 	InteractionID    string         `json:"interaction_id" gorm:"index"`
 	Model            string         `json:"model"`
 	Provider         string         `json:"provider"`
+	Router           string         `json:"router"`    // Set if the call was dispatched through a routing provider
+	CacheHit         bool           `json:"cache_hit"` // Set if the response was served from the response cache
 	Step             LLMCallStep    `json:"step" gorm:"index"`
+	Iteration        int            `json:"iteration"` // Agent loop iteration, set for agent_iteration steps
 	OriginalRequest  datatypes.JSON `json:"original_request" gorm:"type:jsonb"`
 	Request          datatypes.JSON `json:"request" gorm:"type:jsonb"`
 	Response         datatypes.JSON `json:"response" gorm:"type:jsonb"`
@@ -1454,6 +1603,9 @@ type Secret struct {
 	Name      string `json:"name" yaml:"name"`
 	Value     []byte `json:"value" yaml:"value" gorm:"type:bytea"`
 	AppID     string `json:"app_id" yaml:"app_id"` // optional, if set, the secret will be available to the specified app
+	// KeyID is the master key that wrapped EncryptedKey, empty if Value is stored unencrypted
+	KeyID        string `json:"-" yaml:"-"`
+	EncryptedKey []byte `json:"-" yaml:"-" gorm:"type:bytea"`
 }
 
 type GetDesiredRunnerSlotsResponse struct {
@@ -1488,6 +1640,71 @@ type RunnerWorkload struct {
 	Session             *Session
 }
 
+func (m RunnerWorkload) Value() (driver.Value, error) {
+	j, err := json.Marshal(m)
+	return j, err
+}
+
+func (t *RunnerWorkload) Scan(src interface{}) error {
+	source, ok := src.([]byte)
+	if !ok {
+		return errors.New("type assertion .([]byte) failed.")
+	}
+	var result RunnerWorkload
+	if err := json.Unmarshal(source, &result); err != nil {
+		return err
+	}
+	*t = result
+	return nil
+}
+
+func (RunnerWorkload) GormDataType() string {
+	return "json"
+}
+
+// SchedulerPriorityClass groups workloads by urgency, each class gets a
+// configurable share of the runners
+type SchedulerPriorityClass string
+
+const (
+	SchedulerPriorityClassInteractive SchedulerPriorityClass = "interactive" // Chat completions and inference sessions
+	SchedulerPriorityClassBatch       SchedulerPriorityClass = "batch"       // Embeddings and data preparation
+	SchedulerPriorityClassFineTune    SchedulerPriorityClass = "finetune"    // Fine-tuning sessions
+)
+
+// SchedulerTenantQueue is the queue of a tenant of the scheduler, the app of
+// the workloads or else their owner
+type SchedulerTenantQueue struct {
+	Tenant             string                         `json:"tenant"`
+	Weight             float64                        `json:"weight"`
+	Queued             int                            `json:"queued"`
+	QueuedByClass      map[SchedulerPriorityClass]int `json:"queued_by_class"`
+	Active             int                            `json:"active"`          // Scheduled and running workloads
+	MaxConcurrency     int                            `json:"max_concurrency"` // 0 is unlimited
+	OldestWaitSeconds  float64                        `json:"oldest_wait_seconds"`
+	AverageWaitSeconds float64                        `json:"average_wait_seconds"` // Of the recently scheduled workloads
+}
+
+type SchedulerWorkloadState string
+
+const (
+	SchedulerWorkloadStateQueued    SchedulerWorkloadState = "queued"    // Waiting in the scheduler queue
+	SchedulerWorkloadStateScheduled SchedulerWorkloadState = "scheduled" // Allocated to a slot, not started by the runner yet
+	SchedulerWorkloadStateRunning   SchedulerWorkloadState = "running"   // Started by the runner
+)
+
+// SchedulerWorkload is a workload of the scheduler queue, persisted until it
+// is released so that it can be rescheduled after an API restart
+type SchedulerWorkload struct {
+	ID        string                 `json:"id" gorm:"primaryKey"` // Request or session ID
+	Created   time.Time              `json:"created"`
+	Updated   time.Time              `json:"updated"`
+	Instance  string                 `json:"instance" gorm:"index"`  // Scheduler instance that owns the workload
+	Heartbeat time.Time              `json:"heartbeat" gorm:"index"` // Last time the owner was seen, other instances take over the workload once it expires
+	State     SchedulerWorkloadState `json:"state"`
+	Workload  *RunnerWorkload        `json:"workload" gorm:"jsonb"`
+}
+
 type RunnerActualSlot struct {
 	ID         uuid.UUID                  `json:"id"`
 	Attributes RunnerActualSlotAttributes `json:"attributes"`
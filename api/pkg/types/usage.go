@@ -0,0 +1,38 @@
+package types
+
+import "time"
+
+// UsageGroupBy is a dimension the usage report can be grouped by
+type UsageGroupBy string
+
+const (
+	UsageGroupByUser     UsageGroupBy = "user"
+	UsageGroupByApp      UsageGroupBy = "app"
+	UsageGroupByModel    UsageGroupBy = "model"
+	UsageGroupByProvider UsageGroupBy = "provider"
+	UsageGroupByDay      UsageGroupBy = "day"
+)
+
+// UsageRow is the aggregated usage of LLM calls. Fields that are not part
+// of the grouping are left empty.
+type UsageRow struct {
+	Date             string  `json:"date,omitempty"` // YYYY-MM-DD (UTC)
+	UserID           string  `json:"user_id,omitempty"`
+	AppID            string  `json:"app_id,omitempty"`
+	Provider         string  `json:"provider,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost" gorm:"-"` // Estimated cost based on the price table
+}
+
+type UsageReport struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	GroupBy  []UsageGroupBy `json:"group_by"`
+	Currency string         `json:"currency"`
+	Rows     []*UsageRow    `json:"rows"`
+	Total    *UsageRow      `json:"total"`
+}
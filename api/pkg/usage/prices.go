@@ -0,0 +1,128 @@
+package usage
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultCurrency = "USD"
+	// wildcard matches any provider or model in the price table
+	wildcard = "*"
+)
+
+// Price is the price per million tokens of a model served by a provider
+type Price struct {
+	Provider   string  `yaml:"provider" json:"provider"`
+	Model      string  `yaml:"model" json:"model"`
+	Prompt     float64 `yaml:"prompt" json:"prompt"`
+	Completion float64 `yaml:"completion" json:"completion"`
+}
+
+type PriceTable struct {
+	Currency string   `yaml:"currency" json:"currency"`
+	Prices   []*Price `yaml:"prices" json:"prices"`
+
+	index map[priceKey]*Price
+}
+
+// LoadPriceTable reads the price table from a YAML file, for example:
+//
+//	currency: USD
+//	prices:
+//	  - provider: openai
+//	    model: gpt-4o
+//	    prompt: 2.5
+//	    completion: 10
+//	  - provider: helix
+//	    model: "*"
+//	    prompt: 0.1
+//	    completion: 0.1
+//
+// Prices are per million tokens. An empty path returns an empty table where
+// everything is free.
+func LoadPriceTable(path string) (*PriceTable, error) {
+	if path == "" {
+		return NewPriceTable(defaultCurrency, nil)
+	}
+
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prices file: %w", err)
+	}
+
+	var table PriceTable
+	err = yaml.Unmarshal(bts, &table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prices file: %w", err)
+	}
+
+	return NewPriceTable(table.Currency, table.Prices)
+}
+
+func NewPriceTable(currency string, prices []*Price) (*PriceTable, error) {
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	table := &PriceTable{
+		Currency: currency,
+		Prices:   prices,
+		index:    make(map[priceKey]*Price),
+	}
+
+	for _, price := range prices {
+		if price.Provider == "" {
+			price.Provider = wildcard
+		}
+		if price.Model == "" {
+			price.Model = wildcard
+		}
+		if price.Prompt < 0 || price.Completion < 0 {
+			return nil, fmt.Errorf("negative price for provider '%s' model '%s'", price.Provider, price.Model)
+		}
+
+		key := priceKey{provider: price.Provider, model: price.Model}
+		if _, ok := table.index[key]; ok {
+			return nil, fmt.Errorf("duplicate price for provider '%s' model '%s'", price.Provider, price.Model)
+		}
+		table.index[key] = price
+	}
+
+	return table, nil
+}
+
+// Lookup returns the price for the provider and model. The most specific
+// entry wins: provider and model, then any model of the provider, then the
+// model on any provider.
+func (t *PriceTable) Lookup(provider, model string) (*Price, bool) {
+	for _, key := range []priceKey{
+		{provider: provider, model: model},
+		{provider: provider, model: wildcard},
+		{provider: wildcard, model: model},
+		{provider: wildcard, model: wildcard},
+	} {
+		if price, ok := t.index[key]; ok {
+			return price, true
+		}
+	}
+
+	return nil, false
+}
+
+// Cost returns the estimated cost of the tokens, 0 if there is no price
+func (t *PriceTable) Cost(provider, model string, promptTokens, completionTokens int64) float64 {
+	price, ok := t.Lookup(provider, model)
+	if !ok {
+		return 0
+	}
+
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1_000_000
+}
+
+type priceKey struct {
+	provider string
+	model    string
+}
@@ -0,0 +1,56 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPriceTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.yaml")
+	err := os.WriteFile(path, []byte(`
+currency: EUR
+prices:
+  - provider: openai
+    model: gpt-4o
+    prompt: 2.5
+    completion: 10
+  - provider: togetherai
+    prompt: 0.2
+    completion: 0.2
+  - model: llama3:instruct
+    prompt: 0.1
+    completion: 0.3
+`), 0644)
+	require.NoError(t, err)
+
+	table, err := LoadPriceTable(path)
+	require.NoError(t, err)
+	require.Equal(t, "EUR", table.Currency)
+
+	// Exact match
+	require.InDelta(t, 12.5, table.Cost("openai", "gpt-4o", 1_000_000, 1_000_000), 0.0001)
+	// Any model of the provider
+	require.InDelta(t, 0.4, table.Cost("togetherai", "meta-llama/Llama-3-70b-chat-hf", 1_000_000, 1_000_000), 0.0001)
+	// Model on any provider
+	require.InDelta(t, 0.4, table.Cost("helix", "llama3:instruct", 1_000_000, 1_000_000), 0.0001)
+	// Unknown
+	require.Equal(t, 0.0, table.Cost("openai", "gpt-4o-mini", 1_000_000, 1_000_000))
+}
+
+func TestLoadPriceTable_Empty(t *testing.T) {
+	table, err := LoadPriceTable("")
+	require.NoError(t, err)
+	require.Equal(t, "USD", table.Currency)
+	require.Equal(t, 0.0, table.Cost("openai", "gpt-4o", 1000, 1000))
+}
+
+func TestNewPriceTable_Duplicate(t *testing.T) {
+	_, err := NewPriceTable("", []*Price{
+		{Provider: "openai", Model: "gpt-4o", Prompt: 1},
+		{Provider: "openai", Model: "gpt-4o", Prompt: 2},
+	})
+	require.Error(t, err)
+}
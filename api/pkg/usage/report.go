@@ -0,0 +1,209 @@
+package usage
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// Query selects the LLM calls to include in the report and how to group them
+type Query struct {
+	UserID  string
+	AppID   string
+	From    time.Time
+	To      time.Time
+	GroupBy []types.UsageGroupBy
+}
+
+// Reporter aggregates token usage from the logged LLM calls and estimates
+// the cost from the price table
+type Reporter struct {
+	store  store.Store
+	prices *PriceTable
+}
+
+func NewReporter(store store.Store, prices *PriceTable) *Reporter {
+	return &Reporter{
+		store:  store,
+		prices: prices,
+	}
+}
+
+// ParseGroupBy parses a comma separated list of groupings, e.g. "model,day"
+func ParseGroupBy(s string) ([]types.UsageGroupBy, error) {
+	var groupBy []types.UsageGroupBy
+
+	seen := make(map[types.UsageGroupBy]bool)
+
+	for _, part := range strings.Split(s, ",") {
+		group := types.UsageGroupBy(strings.TrimSpace(part))
+		if group == "" {
+			continue
+		}
+
+		switch group {
+		case types.UsageGroupByUser, types.UsageGroupByApp, types.UsageGroupByModel,
+			types.UsageGroupByProvider, types.UsageGroupByDay:
+		default:
+			return nil, fmt.Errorf("invalid group_by '%s', must be one of user, app, model, provider, day", group)
+		}
+
+		if !seen[group] {
+			seen[group] = true
+			groupBy = append(groupBy, group)
+		}
+	}
+
+	return groupBy, nil
+}
+
+func (r *Reporter) Report(ctx context.Context, q *Query) (*types.UsageReport, error) {
+	groups := make(map[types.UsageGroupBy]bool)
+	for _, group := range q.GroupBy {
+		groups[group] = true
+	}
+
+	rows, err := r.store.GetLLMCallUsage(ctx, &store.GetLLMCallUsageQuery{
+		UserID:      q.UserID,
+		AppID:       q.AppID,
+		From:        q.From,
+		To:          q.To,
+		GroupByUser: groups[types.UsageGroupByUser],
+		GroupByApp:  groups[types.UsageGroupByApp],
+		GroupByDay:  groups[types.UsageGroupByDay],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LLM call usage: %w", err)
+	}
+
+	report := &types.UsageReport{
+		From:     q.From,
+		To:       q.To,
+		GroupBy:  q.GroupBy,
+		Currency: r.prices.Currency,
+		Rows:     []*types.UsageRow{},
+		Total:    &types.UsageRow{},
+	}
+
+	// The store always groups by provider and model so the cost can be
+	// calculated per model, merge those rows into the requested groups
+	merged := make(map[types.UsageRow]*types.UsageRow)
+
+	for _, row := range rows {
+		row.Cost = r.prices.Cost(row.Provider, row.Model, row.PromptTokens, row.CompletionTokens)
+
+		key := types.UsageRow{}
+		if groups[types.UsageGroupByDay] {
+			key.Date = row.Date
+		}
+		if groups[types.UsageGroupByUser] {
+			key.UserID = row.UserID
+		}
+		if groups[types.UsageGroupByApp] {
+			key.AppID = row.AppID
+		}
+		if groups[types.UsageGroupByProvider] {
+			key.Provider = row.Provider
+		}
+		if groups[types.UsageGroupByModel] {
+			key.Model = row.Model
+		}
+
+		group, ok := merged[key]
+		if !ok {
+			group = &types.UsageRow{}
+			*group = key
+			merged[key] = group
+			report.Rows = append(report.Rows, group)
+		}
+
+		addUsage(group, row)
+		addUsage(report.Total, row)
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		for _, cmp := range [][2]string{
+			{a.Date, b.Date},
+			{a.UserID, b.UserID},
+			{a.AppID, b.AppID},
+			{a.Provider, b.Provider},
+			{a.Model, b.Model},
+		} {
+			if cmp[0] != cmp[1] {
+				return cmp[0] < cmp[1]
+			}
+		}
+		return false
+	})
+
+	return report, nil
+}
+
+func addUsage(dst, src *types.UsageRow) {
+	dst.Calls += src.Calls
+	dst.PromptTokens += src.PromptTokens
+	dst.CompletionTokens += src.CompletionTokens
+	dst.TotalTokens += src.TotalTokens
+	dst.Cost += src.Cost
+}
+
+// WriteCSV writes the report rows as CSV, with a column for each grouping
+// followed by the usage and cost
+func WriteCSV(w io.Writer, report *types.UsageReport) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{}
+	for _, group := range report.GroupBy {
+		header = append(header, string(group))
+	}
+	header = append(header, "calls", "prompt_tokens", "completion_tokens", "total_tokens", "cost_"+strings.ToLower(report.Currency))
+
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		record := []string{}
+		for _, group := range report.GroupBy {
+			switch group {
+			case types.UsageGroupByDay:
+				record = append(record, row.Date)
+			case types.UsageGroupByUser:
+				record = append(record, row.UserID)
+			case types.UsageGroupByApp:
+				record = append(record, row.AppID)
+			case types.UsageGroupByProvider:
+				record = append(record, row.Provider)
+			case types.UsageGroupByModel:
+				record = append(record, row.Model)
+			}
+		}
+
+		record = append(record,
+			strconv.FormatInt(row.Calls, 10),
+			strconv.FormatInt(row.PromptTokens, 10),
+			strconv.FormatInt(row.CompletionTokens, 10),
+			strconv.FormatInt(row.TotalTokens, 10),
+			strconv.FormatFloat(row.Cost, 'f', 6, 64),
+		)
+
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,79 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	groupBy, err := ParseGroupBy("model, day,model")
+	require.NoError(t, err)
+	require.Equal(t, []types.UsageGroupBy{types.UsageGroupByModel, types.UsageGroupByDay}, groupBy)
+
+	_, err = ParseGroupBy("model,session")
+	require.Error(t, err)
+}
+
+func TestReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := store.NewMockStore(ctrl)
+
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	st.EXPECT().GetLLMCallUsage(gomock.Any(), &store.GetLLMCallUsageQuery{
+		UserID:     "user-1",
+		From:       from,
+		To:         to,
+		GroupByDay: true,
+	}).Return([]*types.UsageRow{
+		{Date: "2024-03-02", Provider: "openai", Model: "gpt-4o", Calls: 1, PromptTokens: 1_000_000, TotalTokens: 1_000_000},
+		{Date: "2024-03-01", Provider: "openai", Model: "gpt-4o", Calls: 2, PromptTokens: 1_000_000, CompletionTokens: 1_000_000, TotalTokens: 2_000_000},
+		{Date: "2024-03-01", Provider: "togetherai", Model: "llama3", Calls: 3, PromptTokens: 500, TotalTokens: 500},
+	}, nil)
+
+	prices, err := NewPriceTable("USD", []*Price{
+		{Provider: "openai", Model: "gpt-4o", Prompt: 2.5, Completion: 10},
+	})
+	require.NoError(t, err)
+
+	reporter := NewReporter(st, prices)
+
+	report, err := reporter.Report(context.Background(), &Query{
+		UserID:  "user-1",
+		From:    from,
+		To:      to,
+		GroupBy: []types.UsageGroupBy{types.UsageGroupByDay},
+	})
+	require.NoError(t, err)
+
+	// Rows of different models on the same day are merged
+	require.Len(t, report.Rows, 2)
+
+	require.Equal(t, "2024-03-01", report.Rows[0].Date)
+	require.Empty(t, report.Rows[0].Model)
+	require.Equal(t, int64(5), report.Rows[0].Calls)
+	require.Equal(t, int64(2_000_500), report.Rows[0].TotalTokens)
+	require.InDelta(t, 12.5, report.Rows[0].Cost, 0.0001)
+
+	require.Equal(t, "2024-03-02", report.Rows[1].Date)
+	require.InDelta(t, 2.5, report.Rows[1].Cost, 0.0001)
+
+	require.Equal(t, int64(6), report.Total.Calls)
+	require.InDelta(t, 15.0, report.Total.Cost, 0.0001)
+
+	var buf bytes.Buffer
+	err = WriteCSV(&buf, report)
+	require.NoError(t, err)
+	require.Equal(t, "day,calls,prompt_tokens,completion_tokens,total_tokens,cost_usd\n"+
+		"2024-03-01,5,1000500,1000000,2000500,12.500000\n"+
+		"2024-03-02,1,1000000,0,1000000,2.500000\n", buf.String())
+}
@@ -339,8 +339,22 @@ export interface  IGlobalSchedulingDecision {
   model_name: string,
 }
 
+export type ISchedulerPriorityClass = 'interactive' | 'batch' | 'finetune'
+
+export interface ISchedulerTenantQueue {
+  tenant: string,
+  weight: number,
+  queued: number,
+  queued_by_class: Partial<Record<ISchedulerPriorityClass, number>>,
+  active: number,
+  max_concurrency: number,
+  oldest_wait_seconds: number,
+  average_wait_seconds: number,
+}
+
 export interface IDashboardData {
   session_queue: ISessionSummary[],
+  tenant_queues: ISchedulerTenantQueue[],
   runners: IRunnerState[],
   global_scheduling_decisions: IGlobalSchedulingDecision[],
   desired_slots: ISlot[],
@@ -605,10 +619,35 @@ export interface IKnowledgeSource {
     s3?: {
       bucket: string;
       path: string;
+      endpoint?: string;
+      region?: string;
+      insecure?: boolean;
+      includes?: string[];
+      excludes?: string[];
+      access_key_id_secret?: string;
+      secret_access_key_secret?: string;
     };
     gcs?: {
       bucket: string;
       path: string;
+      endpoint?: string;
+      includes?: string[];
+      excludes?: string[];
+      credentials_secret?: string;
+    };
+    github?: {
+      owner: string;
+      repository: string;
+      branch?: string;
+      filter_paths?: string[];
+      filter_extensions?: string[];
+      webhook_secret?: string;
+    };
+    local?: {
+      path: string;
+      includes?: string[];
+      excludes?: string[];
+      watch?: boolean;
     };
     filestore?: {
       path: string;
@@ -616,9 +655,30 @@ export interface IKnowledgeSource {
     web?: {
       urls?: string[];
       excludes?: string[];
+      includes?: string[];
       auth?: {
-        username: string;
-        password: string;
+        username?: string;
+        password?: string;
+        password_secret?: string;
+        bearer_token_secret?: string;
+        headers?: { [key: string]: string };
+        header_secrets?: { [key: string]: string };
+        cookies?: {
+          name: string;
+          value?: string;
+          value_secret?: string;
+          domain?: string;
+          path?: string;
+        }[];
+        form_login?: {
+          url: string;
+          username: string;
+          password_secret: string;
+          username_selector: string;
+          password_selector: string;
+          submit_selector?: string;
+          wait_selector?: string;
+        };
       };
       crawler?: {
         firecrawl?: {
@@ -630,6 +690,9 @@ export interface IKnowledgeSource {
         max_pages?: number;
         user_agent?: string;
         readability?: boolean;
+        sitemap?: boolean;
+        sitemap_urls?: string[];
+        respect_robots_txt?: boolean;
       };
     };
     text?: string;
@@ -649,9 +712,20 @@ export interface ISessionRAGResult {
   source: string;
   document_id: string;
   document_group_id: string;
+  metadata?: Record<string, string>;
   // Add any other properties that your API returns
 }
 
+// Knowledge the chat completion is based on, returned as `citations`
+export interface ICitation {
+  document_id: string;
+  knowledge_id?: string;
+  source: string;
+  url?: string;
+  snippet: string;
+  metadata?: Record<string, string>;
+}
+
 export interface IAppHelixConfig {
   name: string;
   description: string;
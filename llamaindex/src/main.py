@@ -18,7 +18,8 @@ def healthcheck():
 #   "document_id": "abc",
 #   "document_group_id": "def",
 #   "content_offset": 0,
-#   "content": "hello world"
+#   "content": "hello world",
+#   "metadata": {"page": "1"}
 # }' http://localhost:5000/api/v1/rag/chunk
 # this route will convert the text chunk into an embedding and then store it in the database
 @app.route('/api/v1/rag/chunk', methods=['POST'])
@@ -47,7 +48,10 @@ def delete_rag_data(id):
 #   "prompt": "hello world",
 #   "distance_function": "cosine",
 #   "distance_threshold": 0.1,
-#   "max_results": 5
+#   "max_results": 5,
+#   "retrieval_mode": "hybrid",
+#   "hybrid_alpha": 0.5,
+#   "filters": {"filenames": ["test.txt"]}
 # }' http://localhost:5000/api/v1/rag/prompt
 # this will
 #  * convert the prompt
@@ -62,6 +66,9 @@ def rag_query():
   distance_threshold = data["distance_threshold"]
   distance_function = data["distance_function"]
   max_results = data["max_results"]
+  retrieval_mode = data.get("retrieval_mode") or "vector"
+  hybrid_alpha = data.get("hybrid_alpha") or 0.5
+  filters = data.get("filters") or {}
 
   if prompt is None or len(prompt) == 0:
     return jsonify({"error": "missing prompt"}), 400
@@ -79,8 +86,16 @@ def rag_query():
     return jsonify({"error": "missing max_results"}), 400
   if isinstance(max_results, (int, float)) == False:
     return jsonify({"error": "max_results must be a number"}), 400
-  promptEmbedding = getEmbedding(prompt)
-  results = sql.queryPrompt(data_entity_id, promptEmbedding, distance_function, distance_threshold, max_results)
+  if retrieval_mode not in ["vector", "keyword", "hybrid"]:
+    return jsonify({"error": "retrieval_mode must be one of 'vector', 'keyword', or 'hybrid'"}), 400
+  if isinstance(hybrid_alpha, (int, float)) == False or hybrid_alpha < 0 or hybrid_alpha > 1:
+    return jsonify({"error": "hybrid_alpha must be a number between 0 and 1"}), 400
+  promptEmbedding = None
+  if retrieval_mode != "keyword":
+    promptEmbedding = getEmbedding(prompt)
+  results = sql.queryPrompt(data_entity_id, prompt, promptEmbedding, distance_function, distance_threshold, max_results,
+    retrieval_mode=retrieval_mode, alpha=hybrid_alpha,
+    filenames=filters.get("filenames"), sources=filters.get("sources"))
   pprint.pprint(results)
   return jsonify(results), 200
 
@@ -0,0 +1,19 @@
+from alembic import op
+
+revision: str = '06'
+down_revision = '05'
+branch_labels = None
+depends_on = None
+
+def upgrade() -> None:
+  query = """
+alter table helix_document_chunk add column metadata jsonb not null default '{}';
+  """
+  op.execute(query)
+
+
+def downgrade() -> None:
+  query = """
+alter table helix_document_chunk drop column metadata;
+  """
+  op.execute(query)
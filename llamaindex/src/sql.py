@@ -3,6 +3,7 @@ from alembic.command import upgrade
 from alembic.config import Config
 from pgvector.sqlalchemy import Vector
 from sqlalchemy import insert, String, Integer, create_engine, text, select
+from sqlalchemy.dialects.postgresql import JSONB
 from sqlalchemy.orm import declarative_base, mapped_column, sessionmaker
 import uuid
 import pprint
@@ -69,6 +70,9 @@ class HelixDocumentChunk(Base):
   # when it's matched to an embedding record
   content_offset = mapped_column(Integer)
   content = mapped_column(String)
+  # arbitrary chunk metadata set at index time, e.g. page number or headings,
+  # metadata is reserved on declarative classes
+  metadata_ = mapped_column("metadata", JSONB, default=dict)
   embedding = mapped_column(Vector(VECTOR_DIMENSION))
 
 def checkDocumentChunkData(data_dict):
@@ -93,12 +97,15 @@ def checkDocumentChunkData(data_dict):
 #     "filename": "test.txt",
 #     "content_offset": 0,
 #     "content": "hello world",
+#     "metadata": {"page": "1"},
 #     "embedding": [1, 2, 3, 4]
 # }
 # we expect the embedding to already have been calculated before we put it into the DB
 def insertData(data_dict):
   data_dict["id"] = uuid.uuid4()
-  stmt = insert(HelixDocumentChunk).values(**data_dict).returning(HelixDocumentChunk.id)
+  data_dict["metadata"] = data_dict.get("metadata") or {}
+  # inserted into the table as the metadata attribute is renamed on the class
+  stmt = insert(HelixDocumentChunk.__table__).values(**data_dict).returning(HelixDocumentChunk.__table__.c.id)
   with engine.connect() as connection:
     cursor = connection.execute(stmt)
     connection.commit()
@@ -116,6 +123,7 @@ def convertRow(row):
       "source": row.source,
       "content_offset": row.content_offset,
       "content": row.content,
+      "metadata": row.metadata or {},
       "embedding": row.embedding.tolist()  # Convert ndarray to list
     }
 
@@ -132,6 +140,7 @@ def convertSimpleRow(row):
       "source": row.source,
       "content_offset": row.content_offset,
       "content": row.content,
+      "metadata": row.metadata or {},
       "distance": row.distance,
     }
 
@@ -146,42 +155,119 @@ def getRow(row_id):
     row = result.fetchone()
     return convertRow(row)
 
+# builds the where clause shared by the vector and keyword searches, values
+# are passed as bound parameters
+def buildFilters(data_entity_id, filenames, sources):
+  clauses = ["data_entity_id = :data_entity_id"]
+  params = {"data_entity_id": data_entity_id}
+  if filenames:
+    clauses.append("filename = any(:filenames)")
+    params["filenames"] = list(filenames)
+  if sources:
+    clauses.append("source = any(:sources)")
+    params["sources"] = list(sources)
+  return " and ".join(clauses), params
+
 # given a already calculated prompt embedding and a session ID - find matching rows
-def queryPrompt(data_entity_id, query_embedding, distance_function, distance_threshold, max_results):
+def queryVector(data_entity_id, query_embedding, distance_function, distance_threshold, max_results, filenames=None, sources=None):
   distance_functions = {
     "l2": "<->",
     "inner_product": "<#>",
     "cosine": "<=>"
   }
-  distance_function_string = distance_functions[distance_function]
+  distance_function_string = distance_functions.get(distance_function)
   if distance_function_string is None:
     raise Exception(f"Unknown distance function: {distance_function}")
-  
+
   embedding_number_str = "[" + ",".join(str(num) for num in query_embedding) + "]"
   embedding_str = f"embedding {distance_function_string} '{embedding_number_str}'"
 
+  where, params = buildFilters(data_entity_id, filenames, sources)
+  params["distance_threshold"] = distance_threshold
+  params["max_results"] = int(max_results)
+
   raw_sql = text(f"""
 select
-  id, data_entity_id, document_id, document_group_id, filename, source, content_offset, content,
+  id, data_entity_id, document_id, document_group_id, filename, source, content_offset, content, metadata,
   {embedding_str} as distance
 from 
   {TABLE_NAME}
 where
-  data_entity_id = '{data_entity_id}'
+  {where}
   and
-  {embedding_str} < {distance_threshold}
+  {embedding_str} < :distance_threshold
 order by
   {embedding_str}
-limit {max_results}
+limit :max_results
   """)
 
   session = Session()
-  result = session.execute(raw_sql)
+  result = session.execute(raw_sql, params)
   rows = result.fetchall()
   session.close()
 
   return convertSimpleRows(rows)
 
+# full text search on the content, the distance is derived from the rank so
+# that better matches have a lower distance like in the vector search
+def queryKeyword(data_entity_id, prompt, max_results, filenames=None, sources=None):
+  where, params = buildFilters(data_entity_id, filenames, sources)
+  params["prompt"] = prompt
+  params["max_results"] = int(max_results)
+
+  raw_sql = text(f"""
+select
+  id, data_entity_id, document_id, document_group_id, filename, source, content_offset, content, metadata,
+  1 / (1 + ts_rank_cd(to_tsvector('english', content), plainto_tsquery('english', :prompt))) as distance
+from
+  {TABLE_NAME}
+where
+  {where}
+  and
+  to_tsvector('english', content) @@ plainto_tsquery('english', :prompt)
+order by
+  distance
+limit :max_results
+  """)
+
+  session = Session()
+  result = session.execute(raw_sql, params)
+  rows = result.fetchall()
+  session.close()
+
+  return convertSimpleRows(rows)
+
+# combines the vector and keyword results with rank fusion, alpha is the
+# weight of the vector search (same as typesense's hybrid search)
+def queryHybrid(data_entity_id, prompt, query_embedding, distance_function, distance_threshold, max_results, alpha, filenames=None, sources=None):
+  candidates = int(max_results) * 4
+  vector_rows = queryVector(data_entity_id, query_embedding, distance_function, distance_threshold, candidates, filenames, sources)
+  keyword_rows = queryKeyword(data_entity_id, prompt, candidates, filenames, sources)
+
+  scores = {}
+  rows = {}
+  for weight, results in [(alpha, vector_rows), (1 - alpha, keyword_rows)]:
+    for rank, row in enumerate(results):
+      key = (row["document_id"], row["content_offset"])
+      rows.setdefault(key, row)
+      scores[key] = scores.get(key, 0) + weight / (rank + 1)
+
+  ranked = sorted(scores.items(), key=lambda item: item[1], reverse=True)[:int(max_results)]
+
+  results = []
+  for key, score in ranked:
+    row = dict(rows[key])
+    row["distance"] = 1 - score
+    results.append(row)
+  return results
+
+def queryPrompt(data_entity_id, prompt, query_embedding, distance_function, distance_threshold, max_results, retrieval_mode="vector", alpha=0.5, filenames=None, sources=None):
+  if retrieval_mode == "keyword":
+    return queryKeyword(data_entity_id, prompt, max_results, filenames, sources)
+  if retrieval_mode == "hybrid":
+    return queryHybrid(data_entity_id, prompt, query_embedding, distance_function, distance_threshold, max_results, alpha, filenames, sources)
+  return queryVector(data_entity_id, query_embedding, distance_function, distance_threshold, max_results, filenames, sources)
+
 def deleteDataByEntityId(data_entity_id):
     if not data_entity_id:
         raise Exception("Missing data entity id")